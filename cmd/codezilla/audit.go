@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"codezilla/internal/tools"
+	"codezilla/internal/workflow"
+)
+
+// runAudit scans the project's dependencies for known vulnerabilities and prints a report.
+// It shares exit codes with the review command so both can gate hooks and CI.
+func runAudit(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	scanner := fs.String("scanner", "auto", "Scanner to run: auto, govulncheck or npm-audit")
+	failOn := fs.String("fail-on", "none", "Exit with status 1 when vulnerabilities reach this severity: low, medium, high or none")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	sarifOut := fs.Bool("sarif", false, "Print the vulnerabilities as SARIF 2.1.0, for GitHub code scanning and IDEs")
+	updateBaseline := fs.Bool("update-baseline", false, "Accept the current vulnerabilities as known in "+tools.BaselineFile+" so later audits only report new ones")
+	noBaseline := fs.Bool("no-baseline", false, "Report every vulnerability, including those the baseline accepts")
+	if err := fs.Parse(args); err != nil {
+		return reviewExitError
+	}
+
+	var threshold workflow.Severity
+	if *failOn != "none" {
+		var err error
+		threshold, err = workflow.ParseSeverity(*failOn)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return reviewExitError
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return reviewExitError
+	}
+
+	report, err := workflow.RunVulnCheck(ctx, cwd, *scanner)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return reviewExitError
+	}
+
+	if *updateBaseline || !*noBaseline {
+		baseline, err := tools.LoadBaseline(cwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return reviewExitError
+		}
+		if *updateBaseline {
+			baseline.Update(cwd, "audit", report.CodeIssues())
+			if err := baseline.Save(cwd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return reviewExitError
+			}
+			fmt.Printf("Accepted %d vulnerabilities in %s\n", len(report.Vulnerabilities), tools.BaselineFile)
+			return reviewExitOK
+		}
+		report.ApplyBaseline(baseline, cwd)
+	}
+
+	switch {
+	case *sarifOut:
+		if err := tools.WriteSARIF(os.Stdout, cwd, report.CodeIssues()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return reviewExitError
+		}
+	case *jsonOut:
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	default:
+		fmt.Println("codezilla audit")
+		fmt.Println(workflow.FormatVulnReport(report))
+	}
+
+	if len(report.Scanners) == 0 {
+		return reviewExitError
+	}
+	if threshold != "" && report.HasVulnerabilitiesAtLeast(threshold) {
+		return reviewExitFindings
+	}
+	return reviewExitOK
+}
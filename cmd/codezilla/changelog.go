@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"codezilla/internal/core"
+	"codezilla/internal/workflow"
+	"codezilla/pkg/logger"
+)
+
+// runChangelog generates a changelog section from git history
+func runChangelog(args []string) int {
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	model := fs.String("model", "", "Override default model")
+	from := fs.String("from", "", "Start ref, exclusive (default: latest tag)")
+	to := fs.String("to", "HEAD", "End ref, inclusive")
+	version := fs.String("version", "", "Version heading for the section (default: Unreleased)")
+	write := fs.String("write", "", "Prepend the section to this file (e.g. CHANGELOG.md) instead of printing it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	config := loadCommandConfig(*configPath)
+	if *model != "" {
+		config.DefaultModel = *model
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *from == "" {
+		*from = workflow.LatestTag(ctx, cwd)
+	}
+
+	commits, err := workflow.CommitsBetween(ctx, cwd, *from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(commits) == 0 {
+		fmt.Fprintln(os.Stderr, "No commits found in range")
+		return 0
+	}
+
+	log, err := logger.New(logger.Config{LogFile: config.LogFile, LogLevel: config.LogLevel, Silent: true})
+	if err != nil {
+		log, _ = logger.New(logger.Config{Silent: true})
+	}
+	defer log.Close()
+
+	client, err := core.NewLLMClient(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	llm := core.NewLLMClientAdapter(client, config.DefaultModel).WithCache(core.NewLLMCache(config))
+	changelog, err := workflow.NewChangelogWorkflow(llm, log).Generate(ctx, commits, *version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	markdown := changelog.Markdown()
+	if *write == "" {
+		fmt.Print(markdown)
+		return 0
+	}
+
+	if err := workflow.PrependChangelog(*write, markdown); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Added %d commits to %s\n", len(commits), *write)
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"codezilla/internal/cli"
+)
+
+// subcommand is a one-shot command run instead of the interactive session
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// subcommands returns all available subcommands
+func subcommands() []subcommand {
+	cmds := []subcommand{
+		{name: "review", summary: "Review staged changes or a revision range and report findings", run: runReview},
+		{name: "audit", summary: "Scan dependencies for known vulnerabilities with govulncheck and npm audit", run: runAudit},
+		{name: "changelog", summary: "Generate a CHANGELOG.md section from the commits between two refs", run: runChangelog},
+		{name: "secrets", summary: "Store, read or delete credentials in the OS keychain or encrypted file", run: runSecrets},
+		{name: "triage", summary: "Find the failing steps of a CI log or the latest failed run and propose fixes", run: runTriage},
+		{name: "new", summary: "Create files from a built-in or user template", run: runNew},
+		{name: "work", summary: "Plan an issue, then work through the approved plan interactively", run: runWork},
+		{name: "doctor", summary: "Check the Ollama server and the programs the tools rely on", run: runDoctor},
+		{name: "completion", summary: "Print the shell completion script for bash, zsh or fish", run: runCompletion},
+		{name: "self-update", summary: "Replace this binary with the latest release, verified against its checksums", run: runSelfUpdate},
+		{name: "install-hooks", summary: "Install git hooks that run the review before commit/push", run: runInstallHooks},
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].name < cmds[j].name })
+	return cmds
+}
+
+// findSubcommand looks up a subcommand by name
+func findSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range subcommands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// loadCommandConfig loads configuration for a subcommand, exiting if the file is invalid
+func loadCommandConfig(configPath string) *cli.Config {
+	if configPath == "" {
+		configPath = getDefaultConfigPath()
+	}
+	config, err := cli.LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return config
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"codezilla/internal/core"
+)
+
+// completeCommand is the hidden command the completion scripts call with the words
+// typed so far, the last one being completed
+const completeCommand = "__complete"
+
+// modelCacheTTL is how long the model names listed for completion are reused
+const modelCacheTTL = 10 * time.Minute
+
+// completionArgs are the choices for the first argument of subcommands that take one
+var completionArgs = map[string][]string{
+	"completion": {"bash", "zsh", "fish"},
+	"secrets":    {"set", "get", "delete", "list"},
+}
+
+// flagLine matches a flag in the usage printed by the flag package, with the type
+// of its value unless it is a boolean
+var flagLine = regexp.MustCompile(`^  -([\w-]+)( \w+)?`)
+
+const bashCompletion = `# bash completion for codezilla
+_codezilla() {
+    local IFS=$'\n'
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    COMPREPLY=($(compgen -W "$(codezilla __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F _codezilla codezilla
+`
+
+const zshCompletion = `#compdef codezilla
+# zsh completion for codezilla
+_codezilla() {
+    local -a candidates
+    candidates=("${(@f)$(codezilla __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${candidates[1]}" ]]; then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+if [[ "$funcstack[1]" = "_codezilla" ]]; then
+    _codezilla "$@"
+else
+    compdef _codezilla codezilla
+fi
+`
+
+const fishCompletion = `# fish completion for codezilla
+function __codezilla_complete
+    set -l words (commandline -opc) (commandline -ct)
+    codezilla __complete $words[2..-1] 2>/dev/null
+end
+complete -c codezilla -a '(__codezilla_complete)'
+`
+
+// runCompletion prints the completion script for a shell
+func runCompletion(args []string) int {
+	scripts := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}
+	if len(args) != 1 || scripts[args[0]] == "" {
+		fmt.Fprintln(os.Stderr, "Usage: codezilla completion bash|zsh|fish")
+		fmt.Fprintln(os.Stderr, "  bash: source <(codezilla completion bash)")
+		fmt.Fprintln(os.Stderr, "  zsh:  source <(codezilla completion zsh)")
+		fmt.Fprintln(os.Stderr, "  fish: codezilla completion fish > ~/.config/fish/completions/codezilla.fish")
+		return 2
+	}
+	fmt.Print(scripts[args[0]])
+	return 0
+}
+
+// runComplete prints the completions of the last of words, one per line
+func runComplete(words []string) int {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	for _, c := range complete(words, commandFlags) {
+		fmt.Println(c)
+	}
+	return 0
+}
+
+// complete returns the candidates for the last of words: subcommands, flags of the
+// subcommand, model names after -model, and the choices of a subcommand's argument.
+// Nothing is returned where the shell should complete file names. flagsOf returns the
+// flags of a subcommand, as commandFlags does.
+func complete(words []string, flagsOf func(command string) map[string]bool) []string {
+	current := words[len(words)-1]
+	before := words[:len(words)-1]
+	if len(before) == 0 && !strings.HasPrefix(current, "-") {
+		var names []string
+		for _, cmd := range subcommands() {
+			names = append(names, cmd.name)
+		}
+		return withPrefix(names, current)
+	}
+
+	command := ""
+	if len(before) > 0 {
+		if _, ok := findSubcommand(before[0]); ok {
+			command = before[0]
+		}
+	}
+	flags := flagsOf(command)
+
+	// The value of the previous flag, unless it is a boolean
+	if len(before) > 0 {
+		if prev := before[len(before)-1]; strings.HasPrefix(prev, "-") && !strings.Contains(prev, "=") {
+			name := strings.TrimLeft(prev, "-")
+			if name == "model" {
+				return withPrefix(modelNames(configFlag(before)), current)
+			}
+			if flags[name] {
+				return nil
+			}
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(current, "-"):
+		var names []string
+		for name := range flags {
+			names = append(names, "-"+name)
+		}
+		return withPrefix(names, current)
+	case command != "" && positional(before[1:], flags) == 0:
+		return withPrefix(completionArgs[command], current)
+	}
+	return nil
+}
+
+// withPrefix returns the candidates starting with prefix, sorted
+func withPrefix(candidates []string, prefix string) []string {
+	var matched []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matched = append(matched, c)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// positional counts the arguments among words that aren't flags or flag values
+func positional(words []string, flags map[string]bool) int {
+	n := 0
+	for i := 0; i < len(words); i++ {
+		if !strings.HasPrefix(words[i], "-") {
+			n++
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(words[i], "-"), "=")
+		if flags[name] && !hasValue {
+			i++ // Skip the value
+		}
+	}
+	return n
+}
+
+// commandFlags returns the flags of a subcommand, or of the interactive session when
+// command is "", and whether each takes a value. They are read from the usage the
+// command prints for -h, so they never drift from its flag definitions.
+func commandFlags(command string) map[string]bool {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	args := []string{"-h"}
+	if command != "" {
+		args = []string{command, "-h"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, exe, args...).CombinedOutput()
+	return parseFlags(string(out))
+}
+
+// parseFlags returns the flags listed in usage printed by the flag package, and
+// whether each takes a value
+func parseFlags(usage string) map[string]bool {
+	flags := make(map[string]bool)
+	for _, line := range strings.Split(usage, "\n") {
+		if m := flagLine.FindStringSubmatch(line); m != nil {
+			flags[m[1]] = m[2] != ""
+		}
+	}
+	return flags
+}
+
+// configFlag returns the value of -config among words, or ""
+func configFlag(words []string) string {
+	for i, w := range words {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if name != "config" || !strings.HasPrefix(w, "-") {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(words) {
+			return words[i+1]
+		}
+	}
+	return ""
+}
+
+// modelCache is the list of models kept between completions, since asking the
+// Ollama server on every keystroke is slow
+type modelCache struct {
+	Time   time.Time `json:"time"`
+	URL    string    `json:"url"`
+	Models []string  `json:"models"`
+}
+
+// modelNames returns the names of the models of the configured server, from a cache
+// refreshed every modelCacheTTL. When the server can't be reached, the last list is used.
+func modelNames(configPath string) []string {
+	config := loadCommandConfig(configPath)
+
+	var cached modelCache
+	cachePath := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "codezilla", "completion-models.json")
+		if data, err := os.ReadFile(cachePath); err == nil {
+			json.Unmarshal(data, &cached)
+		}
+	}
+	if cached.URL == config.OllamaURL && time.Since(cached.Time) < modelCacheTTL {
+		return cached.Models
+	}
+
+	client, err := core.NewLLMClient(config)
+	if err != nil {
+		return cached.Models
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return cached.Models
+	}
+
+	fresh := modelCache{Time: time.Now(), URL: config.OllamaURL}
+	for _, m := range models.Models {
+		fresh.Models = append(fresh.Models, m.Name)
+	}
+	if cachePath != "" {
+		if data, err := json.Marshal(fresh); err == nil && os.MkdirAll(filepath.Dir(cachePath), 0o755) == nil {
+			os.WriteFile(cachePath, data, 0o644)
+		}
+	}
+	return fresh.Models
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testFlags stands in for the flags the binary reports for -h
+func testFlags(command string) map[string]bool {
+	switch command {
+	case "":
+		return map[string]bool{"config": true, "model": true, "verbose": false}
+	case "review":
+		return map[string]bool{"config": true, "range": true, "staged": false}
+	default:
+		return map[string]bool{"config": true}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"re"}, []string{"review"}},
+		{[]string{"se"}, []string{"secrets", "self-update"}},
+		{[]string{"-v"}, []string{"-verbose"}},
+		{[]string{"nope", "-"}, []string{"-config", "-model", "-verbose"}},
+		{[]string{"review", "-"}, []string{"-config", "-range", "-staged"}},
+		{[]string{"review", "-range", ""}, nil},
+		{[]string{"review", "-staged", ""}, nil},
+		{[]string{"secrets", ""}, []string{"delete", "get", "list", "set"}},
+		{[]string{"secrets", "-config", "c.yaml", "g"}, []string{"get"}},
+		{[]string{"secrets", "-config=c.yaml", "l"}, []string{"list"}},
+		{[]string{"secrets", "set", ""}, nil},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.words, " "), func(t *testing.T) {
+			if got := complete(tt.words, testFlags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositional(t *testing.T) {
+	flags := map[string]bool{"config": true, "staged": false}
+	tests := []struct {
+		words []string
+		want  int
+	}{
+		{nil, 0},
+		{[]string{"set", "token"}, 2},
+		{[]string{"-config", "c.yaml", "set"}, 1},
+		{[]string{"-config=c.yaml", "set"}, 1},
+		{[]string{"--config", "c.yaml"}, 0},
+		{[]string{"-staged", "HEAD~1"}, 1},
+	}
+	for _, tt := range tests {
+		if got := positional(tt.words, flags); got != tt.want {
+			t.Errorf("positional(%q) = %d, want %d", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"-config", "a.yaml"}, "a.yaml"},
+		{[]string{"review", "--config=b.yaml", "-staged"}, "b.yaml"},
+		{[]string{"-config"}, ""},
+		{[]string{"config", "c.yaml"}, ""},
+		{[]string{"-model", "config"}, ""},
+	}
+	for _, tt := range tests {
+		if got := configFlag(tt.words); got != tt.want {
+			t.Errorf("configFlag(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	usage := "Usage of review:\n  -config string\n    \tPath to the config file\n  -staged\n    \tReview staged changes\n"
+	want := map[string]bool{"config": true, "staged": false}
+	if got := parseFlags(usage); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFlags() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"codezilla/internal/core"
+	"codezilla/internal/doctor"
+	"codezilla/pkg/style"
+)
+
+// runDoctor checks the configuration, the Ollama server and the programs the tools
+// rely on, and explains how to fix what is missing. It fails only when the assistant
+// can't work at all; missing programs degrade single features.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	jsonOut := fs.Bool("json", false, "Print the results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *configPath == "" {
+		*configPath = getDefaultConfigPath()
+	}
+	config := loadCommandConfig(*configPath)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	// The server and the default model
+	serverErr := ""
+	modelFound := false
+	client, err := core.NewLLMClient(config)
+	if err == nil {
+		listCtx, cancelList := context.WithTimeout(ctx, 10*time.Second)
+		models, listErr := client.ListModels(listCtx)
+		cancelList()
+		err = listErr
+		if err == nil {
+			for _, m := range models.Models {
+				if m.Name == config.DefaultModel || m.Name == config.DefaultModel+":latest" {
+					modelFound = true
+				}
+			}
+		}
+	}
+	if err != nil {
+		serverErr = err.Error()
+	}
+
+	checker := &doctor.Checker{OS: runtime.GOOS}
+	results := checker.Check(ctx, doctor.Requirements)
+
+	if *jsonOut {
+		data, _ := json.MarshalIndent(map[string]interface{}{
+			"config":       *configPath,
+			"ollama_url":   config.OllamaURL,
+			"ollama_error": serverErr,
+			"model":        config.DefaultModel,
+			"model_found":  modelFound,
+			"tools":        results,
+		}, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printDoctorReport(*configPath, config.OllamaURL, serverErr, config.DefaultModel, modelFound, results)
+	}
+	if serverErr != "" || !modelFound {
+		return 1
+	}
+	return 0
+}
+
+// printDoctorReport prints the results of runDoctor for people
+func printDoctorReport(configPath, ollamaURL, serverErr, model string, modelFound bool, results []doctor.Result) {
+	ok, bad, warn := style.ColorGreen(style.Text("✓")), style.ColorRed(style.Text("✗")), style.ColorYellow("!")
+
+	fmt.Printf("%s Config: %s\n", ok, configPath)
+	switch {
+	case serverErr != "":
+		fmt.Printf("%s Ollama at %s is unreachable: %s\n", bad, ollamaURL, serverErr)
+		fmt.Println("    Fix: start it with `ollama serve`, or set ollama_url")
+	case !modelFound:
+		fmt.Printf("%s Ollama at %s is up, but the model %s isn't pulled\n", bad, ollamaURL, model)
+		fmt.Printf("    Fix: ollama pull %s\n", model)
+	default:
+		fmt.Printf("%s Ollama at %s, model %s\n", ok, ollamaURL, model)
+	}
+
+	fmt.Println("\nPrograms the tools use:")
+	degraded := 0
+	for _, r := range results {
+		switch {
+		case r.Status == doctor.StatusOK && r.Found != r.Binary:
+			fmt.Printf("%s %s (using %s)\n", ok, r.Binary, r.Found)
+		case r.Status == doctor.StatusOK && r.Version != "":
+			fmt.Printf("%s %s %s\n", ok, r.Binary, r.Version)
+		case r.Status == doctor.StatusOK:
+			fmt.Printf("%s %s\n", ok, r.Binary)
+		case r.Status == doctor.StatusOld:
+			degraded++
+			fmt.Printf("%s %s %s is older than %s: %s\n", warn, r.Binary, r.Version, r.MinVersion, r.Degraded)
+			fmt.Printf("    Fix: %s\n", r.Fix)
+		default:
+			degraded++
+			names := strings.Join(append([]string{r.Binary}, r.Alternatives...), " or ")
+			fmt.Printf("%s %s not found: %s\n", warn, names, r.Degraded)
+			fmt.Printf("    Fix: %s\n", r.Fix)
+		}
+	}
+	switch degraded {
+	case 0:
+		fmt.Println("\nEverything the tools use is installed.")
+	case 1:
+		fmt.Println("\n1 program is missing or outdated; the feature above is degraded.")
+	default:
+		fmt.Printf("\n%d programs are missing or outdated; the features above are degraded.\n", degraded)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"codezilla/internal/workflow"
+)
+
+// runInstallHooks writes git hooks that run the review workflow
+func runInstallHooks(args []string) int {
+	fs := flag.NewFlagSet("install-hooks", flag.ContinueOnError)
+	mode := fs.String("mode", "warn", "Hook behavior on findings: warn or block")
+	failOn := fs.String("fail-on", "high", "Minimum severity that blocks in block mode: low, medium or high")
+	hooks := fs.String("hooks", "pre-commit", "Comma-separated hooks to install: "+strings.Join(workflow.SupportedHooks, ", "))
+	force := fs.Bool("force", false, "Replace existing hooks not written by codezilla (a .bak copy is kept)")
+	dir := fs.String("dir", ".", "Repository directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	severity, err := workflow.ParseSeverity(*failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	// Prefer the absolute path of this binary so hooks work without PATH setup
+	binary := "codezilla"
+	if exe, err := os.Executable(); err == nil {
+		binary = exe
+	}
+
+	var hookNames []string
+	for _, h := range strings.Split(*hooks, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hookNames = append(hookNames, h)
+		}
+	}
+
+	written, err := workflow.InstallHooks(context.Background(), *dir, workflow.HookOptions{
+		Hooks:  hookNames,
+		Mode:   workflow.HookMode(*mode),
+		FailOn: severity,
+		Binary: binary,
+		Force:  *force,
+	})
+	for _, path := range written {
+		fmt.Printf("Installed %s\n", path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
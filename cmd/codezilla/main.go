@@ -11,15 +11,32 @@ import (
 
 	"codezilla/internal/cli"
 	"codezilla/internal/core"
+	"codezilla/internal/i18n"
 	"codezilla/internal/ui"
+	"codezilla/pkg/style"
 )
 
+// versionInfo is the release this binary was built from, set with
+// -ldflags "-X main.versionInfo=v2.1.0"
+var versionInfo = "dev"
+
 func main() {
+	// Dispatch one-shot subcommands before parsing interactive flags
+	if len(os.Args) > 1 {
+		if os.Args[1] == completeCommand {
+			os.Exit(runComplete(os.Args[2:]))
+		}
+		if cmd, ok := findSubcommand(os.Args[1]); ok {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+
 	// Parse command line flags
 	var (
 		configPath  = flag.String("config", "", "Path to config file")
 		uiType      = flag.String("ui", "fancy", "UI type: minimal or fancy")
 		noColors    = flag.Bool("no-colors", false, "Disable colored output")
+		verbose     = flag.Bool("verbose", false, "Trace the agent's work: prompts, tool calls and timings")
 		model       = flag.String("model", "", "Override default model")
 		ollamaURL   = flag.String("ollama-url", "", "Override Ollama API URL")
 		temperature = flag.Float64("temperature", -1, "Override temperature (0.0-1.0)")
@@ -31,7 +48,7 @@ func main() {
 
 	// Handle version
 	if *version {
-		fmt.Println("Codezilla v2.0.0 - Modular Architecture")
+		fmt.Printf("Codezilla %s\n", versionInfo)
 		os.Exit(0)
 	}
 
@@ -49,8 +66,8 @@ func main() {
 	// Load configuration
 	config, err := cli.LoadConfig(*configPath)
 	if err != nil {
-		config = cli.DefaultConfig()
-		fmt.Printf("Note: Using default configuration\n")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Apply CLI overrides
@@ -71,18 +88,50 @@ func main() {
 	if *noColors {
 		config.NoColor = true
 	}
+	if *verbose {
+		config.Verbose = true
+	}
+
+	app, ctx, cancel := startApp(config, *uiType, *noColors)
+	defer cancel()
+	defer app.Close()
+
+	// Run the application
+	if err := app.Run(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// startApp creates the UI and the application, exiting on failure. The returned context
+// is cancelled on interrupt.
+func startApp(config *cli.Config, uiType string, noColors bool) (*core.App, context.Context, context.CancelFunc) {
+	// Get history file path, separate per project unless disabled
+	historyPath := config.HistoryFile
+	if historyPath == "" {
+		historyPath, _ = cli.GetDefaultHistoryFilePath()
+	}
+	if historyPath != "" && config.HistoryPerProject {
+		historyPath = cli.ProjectHistoryFile(historyPath, config.WorkingDirectory)
+	}
 
-	// Get history file path
-	historyPath, _ := cli.GetDefaultHistoryFilePath()
+	// Menus and messages follow the configured language
+	i18n.SetLanguage(config.Language)
+	style.SetUnicode(config.Unicode)
+	cli.SetEditMode(config.EditMode)
+	if err := cli.SetKeyBindings(config.KeyBindings); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring key_bindings: %v\n", err)
+	}
 
 	// Create UI based on selection
 	var appUI ui.UI
-	switch *uiType {
+	var err error
+	switch uiType {
 	case "minimal":
-		appUI, err = ui.NewMinimalUI(historyPath)
+		appUI, err = ui.NewMinimalUI(historyPath, config.HistoryMaxEntries)
 	default:
 		// Default to fancy UI
-		appUI, err = ui.NewFancyUI(historyPath)
+		appUI, err = ui.NewFancyUI(historyPath, config.HistoryMaxEntries)
 	}
 
 	if err != nil {
@@ -91,7 +140,7 @@ func main() {
 	}
 
 	// Disable colors if requested
-	if *noColors {
+	if noColors {
 		appUI.DisableColors()
 	}
 
@@ -101,11 +150,9 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
 		os.Exit(1)
 	}
-	defer app.Close()
 
 	// Setup signal handling
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -115,18 +162,23 @@ func main() {
 		cancel()
 	}()
 
-	// Run the application
-	if err := app.Run(ctx); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	return app, ctx, cancel
 }
 
+// getDefaultConfigPath returns the first existing config file (JSON, YAML or TOML)
+// in the config directory, or config.json if there is none yet
 func getDefaultConfigPath() string {
+	dir := "."
 	if home, err := os.UserHomeDir(); err == nil {
-		return filepath.Join(home, ".config", "codezilla", "config.json")
+		dir = filepath.Join(home, ".config", "codezilla")
 	}
-	return "config.json"
+	for _, name := range cli.ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, cli.ConfigFileNames[0])
 }
 
 func printHelp() {
@@ -134,6 +186,7 @@ func printHelp() {
 
 Usage:
   codezilla [options]
+  codezilla <command> [command options]
 
 Options:
   -config string       Path to configuration file
@@ -143,9 +196,32 @@ Options:
   -max-tokens int      Override max tokens
   -ui string           UI type: fancy (default) or minimal
   -no-colors           Disable colored output
+  -verbose             Trace the agent's work: prompts, tool calls and timings
   -version             Show version information
   -help                Show this help message
 
+Commands:
+  completion <shell>   Print the completion script for bash, zsh or fish
+                       (e.g. source <(codezilla completion bash))
+  changelog            Generate release notes from git history
+                       (-from v1.0.0 -to HEAD -version 1.1.0 -write CHANGELOG.md)
+  new <template>       Create files from a template (-list to show templates and variables;
+                       e.g. new go-package userstore, new go-test name=parse package=cli)
+  review               Review staged changes (or -range A..B) and print findings
+                       (-json, or -sarif for code scanning)
+  doctor               Check the Ollama server and the programs the tools use
+                       (git, gopls, gh, govulncheck, ...), with fixes for what's missing
+  install-hooks        Install git hooks that run the review before commit/push
+                       (-hooks pre-commit,pre-push -mode warn|block -fail-on high)
+  secrets              Manage credentials outside config.json
+                       (set|get|delete <name>, list; reference as "secret:<name>")
+  self-update          Replace this binary with the latest release
+                       (-check to only report a newer version)
+  triage               Triage a CI failure and propose fixes
+                       (-log ci.log, or the latest failed GitHub/GitLab run; -json)
+  work <issue>         Plan a GitHub/GitLab issue (URL or #number), then work through
+                       the approved plan before continuing the session
+
 UI Types:
   fancy     - Enhanced UI with animations and emoji (default)
   minimal   - Minimal UI with no colors or special formatting
@@ -172,6 +248,12 @@ Examples:
   # Override temperature
   codezilla -temperature 0.8
 
+  # Plan and work on issue 42 of the repository's origin
+  codezilla work 42
+
+  # Block commits when the staged diff has high-severity findings
+  codezilla install-hooks -mode block -fail-on high
+
 The modular architecture allows easy switching between different UI implementations
 while keeping the core functionality unchanged.
 `)
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"codezilla/internal/scaffold"
+)
+
+// runNew creates files from a template
+func runNew(args []string) int {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	dir := fs.String("dir", ".", "Directory to create the files in")
+	force := fs.Bool("force", false, "Replace files that already exist")
+	dryRun := fs.Bool("dry-run", false, "Show the files that would be created without writing them")
+	list := fs.Bool("list", false, "List the available templates")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: codezilla new [flags] <template> [value...] [name=value...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	config := loadCommandConfig(*configPath)
+	registry, err := scaffold.Load(config.TemplatesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *list || fs.NArg() == 0 {
+		printTemplates(registry, config.TemplatesDir)
+		if fs.NArg() == 0 && !*list {
+			return 2
+		}
+		return 0
+	}
+
+	tmpl, err := registry.Get(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	vars, err := scaffold.ParseAssignments(tmpl, fs.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	tx, err := scaffold.Stage(tmpl, vars, *dir, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *dryRun {
+		fmt.Println(tx.Diff())
+		return 0
+	}
+	files := tx.Files()
+	if err := tx.Commit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, f := range files {
+		fmt.Printf("Created %s\n", f)
+	}
+	return 0
+}
+
+// printTemplates lists the templates with their variables
+func printTemplates(registry *scaffold.Registry, userDir string) {
+	fmt.Printf("Templates (add your own under %s):\n", userDir)
+	for _, t := range registry.List() {
+		fmt.Printf("\n  %-16s %s\n", t.Name, t.Description)
+		if t.Source != "built-in" {
+			fmt.Printf("  %-16s from %s\n", "", t.Source)
+		}
+		for _, v := range t.Variables {
+			detail := v.Description
+			switch {
+			case v.Required:
+				detail += " (required)"
+			case v.Default != "":
+				detail += fmt.Sprintf(" (default: %s)", v.Default)
+			}
+			fmt.Printf("  %-16s   %s: %s\n", "", v.Name, strings.TrimSpace(detail))
+		}
+	}
+}
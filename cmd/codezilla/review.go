@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"codezilla/internal/core"
+	"codezilla/internal/tools"
+	"codezilla/internal/workflow"
+	"codezilla/pkg/logger"
+)
+
+// Exit codes used by the review command (and relied upon by installed hooks)
+const (
+	reviewExitOK       = 0
+	reviewExitFindings = 1
+	reviewExitError    = 2
+)
+
+// runReview reviews a diff in one-shot mode and prints the findings
+func runReview(args []string) int {
+	fs := flag.NewFlagSet("review", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	model := fs.String("model", "", "Override default model")
+	staged := fs.Bool("staged", false, "Review changes staged for commit (default when no -range is given)")
+	revRange := fs.String("range", "", "Review a revision range, e.g. main..HEAD")
+	failOn := fs.String("fail-on", "none", "Exit with status 1 when findings reach this severity: low, medium, high or none")
+	jsonOut := fs.Bool("json", false, "Print the result as JSON")
+	sarifOut := fs.Bool("sarif", false, "Print the findings as SARIF 2.1.0, for GitHub code scanning and IDEs")
+	if err := fs.Parse(args); err != nil {
+		return reviewExitError
+	}
+
+	var threshold workflow.Severity
+	if *failOn != "none" {
+		var err error
+		threshold, err = workflow.ParseSeverity(*failOn)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return reviewExitError
+		}
+	}
+
+	config := loadCommandConfig(*configPath)
+	if *model != "" {
+		config.DefaultModel = *model
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return reviewExitError
+	}
+
+	var diff string
+	if *revRange != "" && !*staged {
+		diff, err = workflow.RangeDiff(ctx, cwd, *revRange)
+	} else {
+		diff, err = workflow.StagedDiff(ctx, cwd)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return reviewExitError
+	}
+
+	log, err := logger.New(logger.Config{LogFile: config.LogFile, LogLevel: config.LogLevel, Silent: true})
+	if err != nil {
+		log, _ = logger.New(logger.Config{Silent: true})
+	}
+	defer log.Close()
+
+	client, err := core.NewLLMClient(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	llm := core.NewLLMClientAdapter(client, config.DefaultModel).WithCache(core.NewLLMCache(config))
+	result, err := workflow.NewReviewWorkflow(llm, log).ReviewDiff(ctx, diff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return reviewExitError
+	}
+
+	switch {
+	case *sarifOut:
+		if err := tools.WriteSARIF(os.Stdout, cwd, result.CodeIssues()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return reviewExitError
+		}
+	case *jsonOut:
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+	default:
+		printReviewResult(result)
+	}
+
+	if threshold != "" && result.HasFindingsAtLeast(threshold) {
+		return reviewExitFindings
+	}
+	return reviewExitOK
+}
+
+// printReviewResult prints a human-readable review report
+func printReviewResult(result *workflow.ReviewResult) {
+	if result.Summary != "" {
+		fmt.Printf("codezilla review: %s\n", result.Summary)
+	}
+	if result.Truncated {
+		fmt.Println("Note: the diff was truncated before review")
+	}
+	if len(result.Findings) == 0 {
+		fmt.Println("No findings.")
+		return
+	}
+	for _, f := range result.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		fmt.Printf("  [%s] %s: %s\n", strings.ToUpper(string(f.Severity)), location, f.Message)
+	}
+}
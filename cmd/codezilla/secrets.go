@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"codezilla/internal/cli"
+	"codezilla/internal/secrets"
+)
+
+// runSecrets manages credentials kept in the secrets backend
+func runSecrets(args []string) int {
+	fs := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	reveal := fs.Bool("reveal", false, "Print the full value for get instead of a masked one")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: codezilla secrets [flags] set|get|delete <name>")
+		fmt.Fprintln(os.Stderr, "       codezilla secrets [flags] list")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	config := loadCommandConfig(*configPath)
+	store, err := cli.OpenSecrets(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if store == nil {
+		fmt.Fprintln(os.Stderr, "Error: secrets_backend is \"none\"")
+		return 1
+	}
+
+	action := rest[0]
+	if action == "list" {
+		lister, ok := store.(interface{ Keys() ([]string, error) })
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: the %s backend cannot list secrets\n", store.Name())
+			return 1
+		}
+		keys, err := lister.Keys()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, k := range keys {
+			fmt.Println(k)
+		}
+		return 0
+	}
+
+	if len(rest) != 2 {
+		fs.Usage()
+		return 2
+	}
+	name := rest[1]
+	if err := secrets.ValidateKey(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	switch action {
+	case "set":
+		value, err := readSecretValue(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if err := store.Set(name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Stored %s in %s\n", name, store.Name())
+		fmt.Printf("Reference it in your config file as \"%s%s\"\n", cli.SecretRefPrefix, name)
+	case "get":
+		value, err := store.Get(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if *reveal {
+			fmt.Println(value)
+		} else {
+			fmt.Println(maskSecret(value))
+		}
+	case "delete":
+		if err := store.Delete(name); err != nil {
+			if errors.Is(err, secrets.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Error: secret %s not found\n", name)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			return 1
+		}
+		fmt.Printf("Deleted %s from %s\n", name, store.Name())
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown secrets action %q\n", action)
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
+
+// readSecretValue reads a secret without echo from a terminal, or from stdin when piped
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		value, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if len(value) == 0 {
+			return "", fmt.Errorf("value must not be empty")
+		}
+		return string(value), nil
+	}
+
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("value must not be empty")
+	}
+	return value, nil
+}
+
+// maskSecret hides all but the last few characters of a secret
+func maskSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
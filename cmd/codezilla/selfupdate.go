@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"codezilla/internal/update"
+)
+
+// runSelfUpdate replaces this binary with the latest release
+func runSelfUpdate(args []string) int {
+	fs := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	check := fs.Bool("check", false, "Only report whether a newer version is available")
+	force := fs.Bool("force", false, "Install the latest release even if it isn't newer, as for development builds")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	config := loadCommandConfig(*configPath)
+	if !config.SelfUpdate {
+		fmt.Fprintln(os.Stderr, "Error: self-update is disabled (self_update is false); update codezilla the way it was installed")
+		return 1
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot find this binary: %v\n", err)
+		return 1
+	}
+	if manager := update.Manager(exe); manager != "" && !*check {
+		fmt.Fprintf(os.Stderr, "codezilla was installed by a package manager; update it with %s\n", manager)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	updater := &update.Updater{Feed: config.UpdateFeed}
+	release, err := updater.Latest(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	newer := update.Newer(release.Version, versionInfo)
+	switch {
+	case *check && newer:
+		fmt.Printf("codezilla %s is available (this is %s)\n", release.Version, versionInfo)
+		return 0
+	case !newer && !*force:
+		fmt.Printf("codezilla %s is up to date (latest release: %s)\n", versionInfo, release.Version)
+		return 0
+	case *check:
+		return 0
+	}
+
+	fmt.Printf("Updating codezilla %s to %s...\n", versionInfo, release.Version)
+	if err := updater.Install(ctx, release, exe); err != nil {
+		if errors.Is(err, update.ErrManaged) {
+			fmt.Fprintf(os.Stderr, "codezilla was %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return 1
+	}
+	fmt.Printf("Installed codezilla %s at %s\n", release.Version, exe)
+	return 0
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"codezilla/internal/core"
+	"codezilla/internal/workflow"
+	"codezilla/pkg/logger"
+)
+
+// runTriage triages a CI failure from a log file or the latest failed run
+func runTriage(args []string) int {
+	fs := flag.NewFlagSet("triage", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	model := fs.String("model", "", "Override default model")
+	logFile := fs.String("log", "", "CI log file to triage (default: fetch the latest failed run from GitHub or GitLab)")
+	jsonOut := fs.Bool("json", false, "Print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	config := loadCommandConfig(*configPath)
+	if *model != "" {
+		config.DefaultModel = *model
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var run *workflow.CIRun
+	if *logFile != "" {
+		data, err := os.ReadFile(*logFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		run = &workflow.CIRun{Name: *logFile, Jobs: []workflow.CIJob{{Log: string(data)}}}
+	} else {
+		repo, err := workflow.DetectRepo(ctx, cwd, config.Forge.Provider)
+		if err == nil {
+			var forge workflow.Forge
+			if forge, err = workflow.NewForge(repo, config.Forge.APIURL, config.Forge.Token, cwd); err == nil {
+				run, err = forge.LatestFailedRun(ctx)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Triaging %s on %s (%s)\n", run.Name, run.Branch, run.URL)
+	}
+
+	log, err := logger.New(logger.Config{LogFile: config.LogFile, LogLevel: config.LogLevel, Silent: true})
+	if err != nil {
+		log, _ = logger.New(logger.Config{Silent: true})
+	}
+	defer log.Close()
+
+	client, err := core.NewLLMClient(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	llm := core.NewLLMClientAdapter(client, config.DefaultModel).WithCache(core.NewLLMCache(config))
+	result, err := workflow.NewCITriageWorkflow(llm, log).Triage(ctx, run.Jobs, cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *jsonOut {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+	printTriageResult(result)
+	return 0
+}
+
+// printTriageResult prints the failures and proposed fixes
+func printTriageResult(result *workflow.CITriageResult) {
+	if result.Summary != "" {
+		fmt.Printf("codezilla triage: %s\n", result.Summary)
+	}
+	for _, f := range result.Failures {
+		step := f.Step
+		if f.Job != "" {
+			step = f.Job + " / " + step
+		}
+		fmt.Printf("\nFailed step: %s\n", step)
+		for _, e := range f.Errors {
+			fmt.Printf("  %s\n", e)
+		}
+		for _, loc := range f.Locations {
+			if loc.File != "" {
+				fmt.Printf("  -> %s:%d\n", loc.File, loc.Line)
+			}
+		}
+	}
+	if len(result.Fixes) > 0 {
+		fmt.Println("\nProposed fixes:")
+		for _, fix := range result.Fixes {
+			location := fix.File
+			if fix.Line > 0 {
+				location = fmt.Sprintf("%s:%d", fix.File, fix.Line)
+			}
+			if location == "" {
+				location = "(outside the code)"
+			}
+			fmt.Printf("  %s: %s\n    %s\n", location, fix.Problem, fix.Fix)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runWork starts an interactive session that plans and works on an issue
+func runWork(args []string) int {
+	fs := flag.NewFlagSet("work", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to config file")
+	model := fs.String("model", "", "Override default model")
+	uiType := fs.String("ui", "fancy", "UI type: minimal or fancy")
+	noColors := fs.Bool("no-colors", false, "Disable colored output")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: codezilla work [flags] <issue-url|#number>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	config := loadCommandConfig(*configPath)
+	if *model != "" {
+		config.DefaultModel = *model
+	}
+	if *noColors {
+		config.NoColor = true
+	}
+
+	app, ctx, cancel := startApp(config, *uiType, *noColors)
+	defer cancel()
+	defer app.Close()
+
+	if err := app.RunWork(ctx, fs.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -5,11 +5,12 @@ go 1.23.0
 toolchain go1.24.2
 
 require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/creack/pty v1.1.24
 	github.com/mattn/go-runewidth v0.0.16
+	golang.org/x/sys v0.32.0
 	golang.org/x/term v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/rivo/uniseg v0.2.0 // indirect
-	golang.org/x/sys v0.32.0 // indirect
-)
+require github.com/rivo/uniseg v0.2.0 // indirect
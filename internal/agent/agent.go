@@ -2,20 +2,21 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"codezilla/internal/analytics"
+	"codezilla/internal/hooks"
 	"codezilla/internal/tools"
 	"codezilla/llm/ollama"
 	"codezilla/pkg/logger"
+	"codezilla/pkg/style"
 )
 
 var (
@@ -29,6 +30,10 @@ type Agent interface {
 	// ProcessMessage processes a user message and returns the agent's response
 	ProcessMessage(ctx context.Context, message string) (string, error)
 
+	// Continue resumes a request stopped by its budget (see BudgetError) with a fresh
+	// budget, or by the write quota (see QuotaError) with room for more changes
+	Continue(ctx context.Context) (string, error)
+
 	// ExecuteTool executes a tool with the given parameters
 	ExecuteTool(ctx context.Context, toolName string, params map[string]interface{}) (interface{}, error)
 
@@ -52,6 +57,38 @@ type Agent interface {
 
 	// SetMaxTokens changes the max tokens setting
 	SetMaxTokens(maxTokens int)
+
+	// SetSystemPrompt replaces the system prompt, filling in tool descriptions
+	SetSystemPrompt(prompt string)
+
+	// SystemPrompt returns the system prompt currently sent to the model
+	SystemPrompt() string
+
+	// Messages returns a copy of the conversation context
+	Messages() []Message
+
+	// SetDryRun turns dry-run mode on or off. In dry-run mode, tool calls that change
+	// state are shown with their predicted effect instead of being executed.
+	SetDryRun(enabled bool)
+
+	// DryRun reports whether dry-run mode is on
+	DryRun() bool
+
+	// Citations returns the sources cited by the last response, numbered as its footnotes
+	Citations() []Citation
+
+	// ChangedFiles returns the files changed while answering the last message
+	ChangedFiles() []ChangedFile
+
+	// SetOutputLevel changes how much of its work the agent prints
+	SetOutputLevel(level OutputLevel)
+
+	// OutputLevel returns how much of its work the agent prints
+	OutputLevel() OutputLevel
+
+	// Output returns a writer for the live output of tool calls, which follows the
+	// output level
+	Output() io.Writer
 }
 
 // Config contains configuration for the agent
@@ -65,6 +102,53 @@ type Config struct {
 	PromptTemplate *PromptTemplate
 	Logger         *logger.Logger
 	PermissionMgr  tools.ToolPermissionManager
+	// InjectionDefense guards tool results against prompt injection (default: off)
+	InjectionDefense InjectionDefense
+	// InjectionClassifier, if set, screens tool results before they enter the context
+	InjectionClassifier InjectionClassifier
+	// OutputContract enforces the format of model responses (default: off)
+	OutputContract OutputContract
+	// ToolStats, if set, records tool outcomes and adds usage hints to the prompt
+	ToolStats *ToolStats
+	// Analytics, if set, counts tool calls for the user's usage dashboard
+	Analytics *analytics.Store
+	// Budget limits the model calls, tokens and time spent on one request
+	Budget Budget
+	// WriteQuota limits the files changed over the agent's lifetime
+	WriteQuota WriteQuota
+	// Output is how much of its work the agent prints to stderr; the zero value
+	// prints nothing but warnings
+	Output OutputLevel
+	// Prefetch reads files named in the user's message and runs git status while the
+	// model generates, so those calls are answered instantly
+	Prefetch bool
+	// Hooks, if set, run user commands before and after tool calls and file writes
+	Hooks *hooks.Runner
+	// WorkingDirectory, if set, returns the directory commands run in, where commits
+	// are looked up for the todo items in progress
+	WorkingDirectory func() string
+	// EditChecker, if set, formats and lints the files a tool call wrote and returns
+	// the problems found, which are added to the call's result for the model to fix
+	EditChecker func(ctx context.Context, files []string) string
+	// BuildChecker, if set, runs the project's build once the model answers after
+	// writing files; a failed build is handed back to the model to fix
+	BuildChecker BuildChecker
+	// BuildAttempts is how many failed builds are handed back; 0 only reports them
+	BuildAttempts int
+	// ShowReasoning prints the reasoning sections of responses, dimmed, in verbose
+	// mode. They are always kept out of the answer, the tool parser and the context.
+	ShowReasoning bool
+	// Clarify turns uncertain tool calls into questions for the user (default: off)
+	Clarify ClarifyPolicy
+	// StopSequences end generation when the model writes one, and are cut from
+	// responses of models that ignore them; empty disables them
+	StopSequences []string
+	// Citations labels tool results with source numbers and asks the model to cite
+	// them; cited sources are listed as footnotes under the response
+	Citations bool
+	// Client, if set, makes the model calls instead of a client for OllamaURL, such as
+	// one with authentication or spread over several servers
+	Client ollama.Client
 }
 
 // DefaultConfig returns a default configuration
@@ -76,6 +160,7 @@ func DefaultConfig() *Config {
 		OllamaURL:      "http://localhost:11434/api",
 		PromptTemplate: DefaultPromptTemplate(),
 		Logger:         logger.DefaultLogger(),
+		StopSequences:  DefaultStopSequences,
 	}
 }
 
@@ -87,6 +172,18 @@ type agent struct {
 	toolRegistry  tools.ToolRegistry
 	logger        *logger.Logger
 	permissionMgr tools.ToolPermissionManager
+	usage         *budgetUsage    // Work done for the current request
+	writes        *writeUsage     // Files changed so far, for the write quota
+	overQuota     bool            // The last request stopped at the write quota
+	sources       []Source        // Tool results in the conversation, for citations
+	citations     []Citation      // Sources cited by the last response
+	changes       turnChanges     // Files changed while answering the current message
+	failures      *failureTracker // Repeated failures in the current request
+	build         buildCheck      // Build check of the current request
+	dryRun        bool
+	simulated     int // State-changing calls shown but not executed in the current request
+	prefetch      *prefetcher
+	out           io.Writer // Where work is printed; stderr when nil
 }
 
 // NewAgent creates a new agent with the given configuration
@@ -99,13 +196,15 @@ func NewAgent(config *Config) Agent {
 		config.Logger = logger.DefaultLogger()
 	}
 
-	var ollamaOpts []func(*ollama.ClientOptions)
-	if config.OllamaURL != "" {
-		ollamaOpts = append(ollamaOpts, ollama.WithBaseURL(config.OllamaURL))
+	ollamaClient := config.Client
+	if ollamaClient == nil {
+		var ollamaOpts []func(*ollama.ClientOptions)
+		if config.OllamaURL != "" {
+			ollamaOpts = append(ollamaOpts, ollama.WithBaseURL(config.OllamaURL))
+		}
+		ollamaClient = ollama.NewClient(ollamaOpts...)
 	}
 
-	ollamaClient := ollama.NewClient(ollamaOpts...)
-
 	// If no permission manager is provided, create one with a default callback that always allows execution
 	// This will be replaced by the CLI with a proper interactive callback
 	if config.PermissionMgr == nil {
@@ -122,18 +221,14 @@ func NewAgent(config *Config) Agent {
 		toolRegistry:  config.ToolRegistry,
 		logger:        config.Logger,
 		permissionMgr: config.PermissionMgr,
+		writes:        &writeUsage{},
+		prefetch:      newPrefetcher(),
 	}
+	agent.context.SetSanitizer(&Sanitizer{Mode: config.InjectionDefense, Classifier: config.InjectionClassifier})
 
 	// Add initial system message if provided
 	if config.SystemPrompt != "" {
-		// Format system prompt with tool information
-		var toolSpecs []tools.ToolSpec
-		if config.ToolRegistry != nil {
-			toolSpecs = config.ToolRegistry.GetToolSpecs()
-		}
-
-		formattedPrompt := FormatSystemPrompt(config.SystemPrompt, toolSpecs)
-		agent.AddSystemMessage(formattedPrompt)
+		agent.SetSystemPrompt(config.SystemPrompt)
 	}
 
 	return agent
@@ -145,6 +240,19 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 
 	// Add user message to context
 	a.AddUserMessage(message)
+	a.build = buildCheck{}
+	a.changes = turnChanges{}
+
+	// Stage likely tool results while the model works out what it needs
+	if a.config.Prefetch && a.toolRegistry != nil {
+		a.prefetch.start(ctx, a.toolRegistry, prefetchCalls(message))
+		defer a.prefetch.reset()
+	}
+
+	// Resolve a pasted stack trace up front so the model starts from the faulting code
+	if tools.ParseStackTrace(message) != nil {
+		a.attachStackTrace(ctx, message)
+	}
 
 	// Check if we should create a todo plan for this message
 	if a.shouldCreateTodoPlan(message) {
@@ -159,6 +267,46 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 		}
 	}
 
+	return a.cite(a.run(ctx))
+}
+
+// Continue resumes a request that was stopped by its budget, with a fresh budget, or
+// by the write quota, allowing another quota's worth of changes
+func (a *agent) Continue(ctx context.Context) (string, error) {
+	a.logger.Debug("Continuing request after budget stop")
+	if a.overQuota {
+		a.writes.grant()
+		a.overQuota = false
+	}
+	return a.cite(a.run(ctx))
+}
+
+// cite replaces the source markers in a response with footnotes when citations are on
+func (a *agent) cite(response string, err error) (string, error) {
+	a.citations = nil
+	if a.config.Citations {
+		response, a.citations = attachCitations(response, a.sources)
+	}
+	return response, err
+}
+
+// Citations returns the sources cited by the last response
+func (a *agent) Citations() []Citation {
+	return a.citations
+}
+
+// ChangedFiles returns the files changed while answering the last message
+func (a *agent) ChangedFiles() []ChangedFile {
+	return a.changes.list()
+}
+
+// run generates responses and executes their tool calls until the model answers
+// without tools or the request budget is exhausted
+func (a *agent) run(ctx context.Context) (string, error) {
+	a.usage = &budgetUsage{started: time.Now()}
+	a.failures = newFailureTracker()
+	a.simulated = 0
+
 	// Generate response
 	a.logger.Debug("Generating initial response")
 	response, err := a.generateResponse(ctx)
@@ -166,6 +314,7 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 		a.logger.Error("Failed to generate response", "error", err)
 		return "", fmt.Errorf("failed to generate response: %w", err)
 	}
+	response, toolsAllowed := a.enforceOutputContract(ctx, response)
 
 	a.logger.Debug("Checking for tool calls in response")
 
@@ -176,12 +325,28 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 	maxIterations := 10 // Safety limit to prevent infinite loops
 	iterations := 0
 
-	for iterations < maxIterations {
+	for iterations < maxIterations && toolsAllowed {
 		iterations++
 
 		// Check for tool usage in response - extract ALL tool calls
 		toolCalls := a.extractAllToolCalls(finalResponse)
 		if len(toolCalls) == 0 {
+			// Don't let the model declare success on code that doesn't build
+			prompt, note := a.checkBuild(ctx, true)
+			if prompt != "" {
+				a.AddAssistantMessage(finalResponse)
+				a.AddUserMessage(prompt)
+				followUpResponse, followUpErr := a.generateResponse(ctx)
+				if followUpErr != nil {
+					a.logger.Error("Failed to generate response after build failure", "error", followUpErr)
+					break
+				}
+				finalResponse, toolsAllowed = a.enforceOutputContract(ctx, followUpResponse)
+				continue
+			}
+			if note != "" {
+				finalResponse += "\n\n" + note
+			}
 			a.logger.Debug("No more tool calls detected, reached final response",
 				"iterations", iterations)
 			break
@@ -206,12 +371,56 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 				"tool", toolCall.ToolName,
 				"params", fmt.Sprintf("%v", toolCall.Params))
 
-			// Add tool call to context
-			a.context.AddToolCallMessage(toolCall.ToolName, toolCall.Params)
+			// Pause before a change the write quota doesn't allow
+			if !a.dryRun && tools.ChangesState(toolCall.ToolName, toolCall.Params) {
+				if reason := a.config.WriteQuota.exceeded(a.writes, toolCall.ToolName, toolCall.Params); reason != "" {
+					a.logger.Warn("Write quota exceeded", "reason", reason, "tool", toolCall.ToolName)
+					a.overQuota = true
+					finalResponse = a.writes.summary(reason)
+					if text := strings.TrimSpace(remainingText); text != "" {
+						finalResponse = text + "\n\n" + finalResponse
+					}
+					a.AddAssistantMessage(finalResponse)
+					return finalResponse, &QuotaError{Reason: reason}
+				}
+			}
+
+			// Ask the user rather than run a guess at what the model meant
+			if question := a.checkToolCall(toolCall); question != "" {
+				a.logger.Info("Asking the user about an uncertain tool call", "tool", toolCall.ToolName)
+				finalResponse = question
+				if text := strings.TrimSpace(remainingText); text != "" {
+					finalResponse = text + "\n\n" + question
+				}
+				finalResponse = markHypothetical(finalResponse, a.simulated)
+				a.AddAssistantMessage(finalResponse)
+				return finalResponse, nil
+			}
 
 			// Execute tool
 			a.logger.Debug("Executing tool", "tool", toolCall.ToolName)
-			result, err := a.ExecuteTool(ctx, toolCall.ToolName, toolCall.Params)
+			var result interface{}
+			var err error
+			if a.dryRun && tools.ChangesState(toolCall.ToolName, toolCall.Params) {
+				result, err = a.simulateTool(ctx, toolCall.ToolName, toolCall.Params)
+			} else {
+				started := time.Now()
+				// The call is recorded as it ran, with any edits the user made to it
+				result, toolCall.Params, err = a.executeTool(ctx, toolCall.ToolName, toolCall.Params)
+				a.recordToolStats(toolCall.ToolName, time.Since(started), err)
+				if err == nil {
+					tools.RecordTodoToolCall(ctx, a.workingDirectory(), toolCall.ToolName, toolCall.Params)
+					a.writes.record(toolCall.ToolName, toolCall.Params, result)
+					a.changes.after(toolCall.ToolName, toolCall.Params, result)
+					a.refreshEditedFiles(toolCall.ToolName, toolCall.Params, result)
+					if len(tools.WrittenFiles(toolCall.ToolName, toolCall.Params)) > 0 {
+						a.build.pending = true
+					}
+				}
+			}
+			a.context.AddToolCallMessage(toolCall.ToolName, toolCall.Params)
+			a.usage.steps = append(a.usage.steps, toolStep(toolCall, err))
+			a.failures.recordTool(toolCall.ToolName, err)
 
 			if err != nil {
 				a.logger.Error("Tool execution failed", "tool", toolCall.ToolName, "error", err)
@@ -225,8 +434,29 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 				}
 			}
 
-			// Add tool result to context
-			a.context.AddToolResultMessage(result, err)
+			// Add tool result to context, screened for prompt injection
+			tr := a.toolResult(ctx, toolCall.ToolName, result, err)
+			if a.config.Citations && err == nil {
+				tr.Source = a.addSource(toolCall)
+			}
+			a.context.AddToolResult(tr)
+		}
+
+		// Stop between steps when the budget is used up, so no tool result is lost
+		if reason := a.config.Budget.exhausted(a.usage); reason != "" {
+			a.logger.Info("Request budget exhausted", "reason", reason, "iteration", iterations)
+			finalResponse = a.usage.summary(reason)
+			if remainingText != "" {
+				finalResponse = remainingText + "\n\n" + finalResponse
+			}
+			finalResponse = markHypothetical(finalResponse, a.simulated)
+			a.AddAssistantMessage(finalResponse)
+			return finalResponse, &BudgetError{Reason: reason}
+		}
+
+		// Step back before trying again when the same thing keeps failing
+		if reason := a.failures.due(); reason != "" {
+			a.reflect(ctx, reason)
 		}
 
 		// Generate follow-up response
@@ -239,6 +469,7 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 			// If we can't get a follow-up, use what we have so far
 			break
 		}
+		followUpResponse, toolsAllowed = a.enforceOutputContract(ctx, followUpResponse)
 
 		a.logger.Debug("Received follow-up response",
 			"iteration", iterations,
@@ -258,14 +489,86 @@ func (a *agent) ProcessMessage(ctx context.Context, message string) (string, err
 			"maxIterations", maxIterations)
 	}
 
+	// Stopped while still fixing the build: say where it stands
+	if _, note := a.checkBuild(ctx, false); note != "" {
+		finalResponse += "\n\n" + note
+	}
+
+	finalResponse = markHypothetical(finalResponse, a.simulated)
+
 	// Add assistant response to context
 	a.AddAssistantMessage(finalResponse)
 
 	return finalResponse, nil
 }
 
+// recordToolStats records a tool call's outcome. Calls that never ran (unknown tools,
+// denied permission) say nothing about the tool and are skipped.
+func (a *agent) recordToolStats(toolName string, duration time.Duration, err error) {
+	if errors.Is(err, ErrToolNotFound) || errors.Is(err, tools.ErrPermissionDenied) {
+		return
+	}
+	if recordErr := a.config.Analytics.RecordTool(time.Now(), toolName, err == nil); recordErr != nil {
+		a.logger.Warn("Failed to save usage analytics", "error", recordErr)
+	}
+	if a.config.ToolStats == nil {
+		return
+	}
+	if err := a.config.ToolStats.Record(toolName, duration, err == nil); err != nil {
+		a.logger.Warn("Failed to save tool stats", "error", err)
+	}
+}
+
+// workingDirectory is the directory commands run in, the process's unless configured
+func (a *agent) workingDirectory() string {
+	if a.config.WorkingDirectory != nil {
+		return a.config.WorkingDirectory()
+	}
+	return "."
+}
+
+// enforceOutputContract checks a model response against the output contract and returns
+// the response to use, and whether tool calls in it may run
+func (a *agent) enforceOutputContract(ctx context.Context, response string) (string, bool) {
+	mode := a.config.OutputContract
+	if mode == ContractOff || mode == "" {
+		return response, true
+	}
+
+	repaired, violations := CheckOutputContract(response)
+	if a.failures != nil {
+		a.failures.recordContract(len(violations) > 0)
+	}
+	if len(violations) == 0 {
+		return response, true
+	}
+	for _, v := range violations {
+		a.logger.Warn("Model response broke the output contract", "kind", v.Kind, "excerpt", v.Excerpt)
+	}
+	if mode != ContractStrict {
+		return repaired, true
+	}
+
+	retry, err := a.generate(ctx, contractCorrection)
+	if err != nil {
+		a.logger.Error("Failed to regenerate response after contract violation", "error", err)
+		return repaired, false
+	}
+	if retryRepaired, retryViolations := CheckOutputContract(retry); len(retryViolations) > 0 {
+		a.logger.Warn("Regenerated response still broke the output contract; not running its tool calls",
+			"violations", len(retryViolations))
+		return retryRepaired, false
+	}
+	return retry, true
+}
+
 // generateResponse generates a response from the LLM using the Generate endpoint
 func (a *agent) generateResponse(ctx context.Context) (string, error) {
+	return a.generate(ctx, "")
+}
+
+// generate generates a response, adding note to the system prompt for this request only
+func (a *agent) generate(ctx context.Context, note string) (string, error) {
 	// Get formatted messages for the LLM
 	messages := a.context.GetFormattedMessages()
 
@@ -290,6 +593,9 @@ func (a *agent) generateResponse(ctx context.Context) (string, error) {
 		toolsInfo += "\nWhen you need to use a tool, you can format your response in one of these ways:\n\n"
 		toolsInfo += "1. XML format:\n"
 		toolsInfo += "<tool>\n  <name>toolName</name>\n  <params>\n    <param1>value1</param1>\n    <param2>value2</param2>\n  </params>\n</tool>\n\n"
+		toolsInfo += "   Array parameters list their values in <item> elements, and object values use one child element per field:\n"
+		toolsInfo += "   <excludePatterns>\n     <item>*.log</item>\n     <item>tmp/**</item>\n   </excludePatterns>\n"
+		toolsInfo += "   <edits>\n     <item>\n       <old_string>foo</old_string>\n       <new_string>bar</new_string>\n     </item>\n   </edits>\n\n"
 		toolsInfo += "2. JSON format:\n"
 		toolsInfo += "```json\n{\n  \"tool\": \"toolName\",\n  \"params\": {\n    \"param1\": \"value1\",\n    \"param2\": \"value2\"\n  }\n}\n```\n\n"
 		toolsInfo += "3. For bash/shell commands, use code blocks:\n"
@@ -314,6 +620,21 @@ func (a *agent) generateResponse(ctx context.Context) (string, error) {
 	if toolsInfo != "" && !strings.Contains(systemPrompt, "You have access to the following tools") {
 		systemPrompt = systemPrompt + "\n\n" + toolsInfo
 	}
+	if a.config.ToolStats != nil && a.toolRegistry != nil {
+		var names []string
+		for _, tool := range a.toolRegistry.ListTools() {
+			names = append(names, tool.Name())
+		}
+		if hints := FormatToolHints(a.config.ToolStats.Hints(names)); hints != "" {
+			systemPrompt += "\n\n" + hints
+		}
+	}
+	if a.config.OutputContract != ContractOff && a.config.OutputContract != "" {
+		systemPrompt += "\n\n" + contractHardening
+	}
+	if note != "" {
+		systemPrompt += "\n\n" + note
+	}
 
 	// Track if we have any user/assistant messages
 	hasConversation := false
@@ -366,6 +687,9 @@ func (a *agent) generateResponse(ctx context.Context) (string, error) {
 			"temperature": a.config.Temperature,
 		},
 	}
+	if len(a.config.StopSequences) > 0 {
+		request.Options["stop"] = a.config.StopSequences
+	}
 
 	a.logger.Debug("Sending Generate request to Ollama",
 		"model", a.config.Model,
@@ -373,6 +697,9 @@ func (a *agent) generateResponse(ctx context.Context) (string, error) {
 		"systemPromptLength", len(systemPrompt),
 		"userPromptLength", userPrompt.Len())
 
+	a.logger.Debug("Prompt sent to the model", "system", systemPrompt, "prompt", request.Prompt)
+	a.traceBlock(fmt.Sprintf("PROMPT (%s, system %d chars, prompt %d chars)", a.config.Model, len(systemPrompt), userPrompt.Len()), lastTurn(request.Prompt))
+
 	// Send request to Ollama
 	startTime := time.Now()
 	response, err := a.ollamaClient.Generate(ctx, request)
@@ -385,13 +712,25 @@ func (a *agent) generateResponse(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("failed to get response from Ollama Generate API: %w", err)
 	}
 
+	if a.usage != nil {
+		a.usage.llmCalls++
+		a.usage.tokens += response.PromptEvalCount + response.EvalCount
+	}
+
 	a.logger.Debug("Received response from Ollama Generate API",
 		"responseLength", len(response.Response),
 		"duration", duration.String(),
 		"model", response.Model)
+	a.traceBlock(fmt.Sprintf("RESPONSE (%s, %d prompt + %d generated tokens)", formatDuration(duration), response.PromptEvalCount, response.EvalCount), response.Response)
 
 	// Process the response if needed
-	cleanResponse := response.Response
+	cleanResponse, reasoning := SplitReasoning(response.Response)
+	if len(reasoning) > 0 {
+		a.logger.Debug("Removed reasoning from response", "sections", len(reasoning), "removedLength", len(response.Response)-len(cleanResponse))
+		if a.tracing() && a.config.ShowReasoning {
+			printReasoning(a.stderr(), reasoning)
+		}
+	}
 
 	// Some models might prepend "Assistant:" to their responses when using the Generate API
 	// Let's remove it if present
@@ -400,6 +739,12 @@ func (a *agent) generateResponse(ctx context.Context) (string, error) {
 		cleanResponse = strings.TrimSpace(cleanResponse)
 	}
 
+	// Drop turns the model went on to write itself
+	if trimmed := TrimExtraTurns(cleanResponse, a.config.StopSequences); trimmed != cleanResponse {
+		a.logger.Debug("Trimmed invented turns from response", "removedLength", len(cleanResponse)-len(trimmed))
+		cleanResponse = trimmed
+	}
+
 	// If the response is empty, provide a fallback
 	if cleanResponse == "" {
 		a.logger.Warn("Empty response from model, using fallback")
@@ -447,306 +792,61 @@ func (a *agent) extractAllToolCalls(response string) []toolCallWithRemaining {
 	return toolCalls
 }
 
-// extractToolCall extracts a single tool call from the response
+// extractToolCall extracts the first tool call from the response and returns the
+// response with the call removed
 func (a *agent) extractToolCall(response string) (*ToolCall, string, bool) {
-	// Log for debugging purposes
 	a.logger.Debug("Checking for tool calls in response", "responseLength", len(response))
 
-	// Add more debug logging when fixing tool detection
-	a.logger.Debug("Full response for tool detection", "response", response)
-
-	// Define all patterns
-	jsonPattern := regexp.MustCompile("(?s)```json\\s*\\n(.*?)\\n?```")
-	bashPattern := regexp.MustCompile("(?s)```(bash|sh|shell|terminal|console)\\s*\\n(.*?)\\n?```")
-	xmlPattern := regexp.MustCompile(`(?s)<tool>[\s\n]*(.*?)[\s\n]*</tool>`)
-
-	// Find the earliest match among all patterns
-	type match struct {
-		start      int
-		end        int
-		matchType  string
-		submatches []string
-	}
-
-	var earliestMatch *match
-
-	// Check JSON pattern
-	if loc := jsonPattern.FindStringSubmatchIndex(response); loc != nil && len(loc) >= 4 {
-		earliestMatch = &match{
-			start:      loc[0],
-			end:        loc[1],
-			matchType:  "json",
-			submatches: jsonPattern.FindStringSubmatch(response),
-		}
-	}
-
-	// Check bash pattern
-	if loc := bashPattern.FindStringSubmatchIndex(response); loc != nil && len(loc) >= 6 {
-		if earliestMatch == nil || loc[0] < earliestMatch.start {
-			earliestMatch = &match{
-				start:      loc[0],
-				end:        loc[1],
-				matchType:  "bash",
-				submatches: bashPattern.FindStringSubmatch(response),
-			}
-		}
-	}
-
-	// Check XML pattern
-	if loc := xmlPattern.FindStringSubmatchIndex(response); loc != nil && len(loc) >= 2 {
-		if earliestMatch == nil || loc[0] < earliestMatch.start {
-			earliestMatch = &match{
-				start:      loc[0],
-				end:        loc[1],
-				matchType:  "xml",
-				submatches: xmlPattern.FindStringSubmatch(response),
-			}
-		}
-	}
-
-	// If no match found, return
-	if earliestMatch == nil {
+	span, found := parseNextToolCall(response, a.logger)
+	if !found {
 		a.logger.Debug("No tool call patterns found in response")
 		return nil, response, false
 	}
 
-	// Process the earliest match based on its type
-	var result *ToolCall
-	remainingText := response[:earliestMatch.start] + response[earliestMatch.end:]
-	remainingText = strings.TrimSpace(remainingText)
-
-	switch earliestMatch.matchType {
-	case "json":
-		if len(earliestMatch.submatches) >= 2 {
-			jsonContent := strings.TrimSpace(earliestMatch.submatches[1])
-			a.logger.Debug("Found JSON code block", "content", jsonContent)
-
-			// Try to parse as JSON tool call
-			var jsonToolCall struct {
-				Tool   string                 `json:"tool"`
-				Name   string                 `json:"name"` // Alternative field name
-				Params map[string]interface{} `json:"params"`
-			}
-
-			if err := json.Unmarshal([]byte(jsonContent), &jsonToolCall); err == nil {
-				toolName := jsonToolCall.Tool
-				if toolName == "" {
-					toolName = jsonToolCall.Name
-				}
+	a.logger.Debug("Successfully extracted tool call",
+		"toolName", span.call.ToolName,
+		"paramsCount", len(span.call.Params))
 
-				if toolName != "" && jsonToolCall.Params != nil {
-					a.logger.Debug("Successfully parsed JSON tool call", "toolName", toolName)
-
-					result = &ToolCall{
-						ToolName: toolName,
-						Params:   jsonToolCall.Params,
-					}
-
-					return result, remainingText, true
-				}
-			}
-		}
-
-	case "bash":
-		if len(earliestMatch.submatches) >= 3 {
-			command := strings.TrimSpace(earliestMatch.submatches[2])
-			a.logger.Debug("Found bash code block", "language", earliestMatch.submatches[1], "command", command)
-
-			// Create tool call for bash execution
-			result = &ToolCall{
-				ToolName: "execute",
-				Params: map[string]interface{}{
-					"command": command,
-				},
-			}
-
-			return result, remainingText, true
-		}
-
-	case "xml":
-		// Extract and process the XML tool call
-		toolXML := earliestMatch.submatches[0]
-		a.logger.Debug("Found potential tool call", "xml", toolXML)
-
-		// Continue with XML processing below
-	}
-
-	// If we get here and it's not XML, something went wrong
-	if earliestMatch.matchType != "xml" {
-		return nil, response, false
-	}
-
-	// For XML processing, we already have the match from earliestMatch
-	var matches []string
-	var pattern *regexp.Regexp
-
-	if earliestMatch.matchType == "xml" {
-		matches = earliestMatch.submatches
-		pattern = xmlPattern
-	} else {
-		// Should not reach here
-		return nil, response, false
-	}
-
-	if len(matches) < 2 {
-		// Try alternative pattern with backticks that might be used by LLMs
-		altPattern := regexp.MustCompile("(?s)```xml[\\s\\n]*(.*?)[\\s\\n]*```")
-		matches = altPattern.FindStringSubmatch(response)
-
-		if len(matches) < 2 {
-			// If still no matches, look for any <n> tag for backward compatibility
-			directToolPattern := regexp.MustCompile(`(?s)<n>\s*(.*?)\s*</n>`)
-			matches = directToolPattern.FindStringSubmatch(response)
-
-			if len(matches) >= 2 {
-				// Found a direct tool call, wrap it in a tool tag structure
-				toolName := matches[1]
-				a.logger.Debug("Found direct <n> tag", "toolName", toolName)
-
-				// Find the parameters section
-				paramsMatch := regexp.MustCompile(`(?s)<params>(.*?)</params>`).FindStringSubmatch(response)
-				if len(paramsMatch) >= 2 {
-					// Reconstruct into proper tool XML format
-					toolXML := fmt.Sprintf("<n>%s</n>\n<params>%s</params>",
-						toolName, paramsMatch[1])
-					matches[1] = toolXML
-					a.logger.Debug("Reconstructed tool call from direct tag", "toolXML", toolXML)
-				} else {
-					a.logger.Debug("No params section found for direct tool tag")
-					return nil, response, false
-				}
-			} else {
-				a.logger.Debug("No tool call patterns found in response")
-				return nil, response, false
-			}
-		}
-	}
-
-	// Extract tool XML content
-	toolXML := matches[0]
-	a.logger.Debug("Found potential tool call", "xml", toolXML)
-
-	// Clean up the XML - remove any leading/trailing backticks or formatting
-	toolXML = strings.TrimPrefix(toolXML, "```xml")
-	toolXML = strings.TrimSuffix(toolXML, "```")
-	toolXML = strings.TrimSpace(toolXML)
-
-	// Ensure XML has root element
-	if !strings.HasPrefix(toolXML, "<") {
-		toolXML = "<tool>" + toolXML + "</tool>"
-	}
-
-	// Try standard XML parsing first
-	var xmlToolCall XMLToolCall
-	err := xml.Unmarshal([]byte(toolXML), &xmlToolCall)
-
-	// Add more debug logging for XML parsing
-	a.logger.Debug("Attempting to parse XML", "toolXML", toolXML, "error", err,
-		"extractedName", xmlToolCall.Name)
-
-	if err == nil && xmlToolCall.Name != "" {
-		// Successfully parsed XML
-		a.logger.Debug("Successfully parsed tool call with standard XML parser",
-			"toolName", xmlToolCall.Name)
-
-		// Parse the parameters from inner XML
-		params, err := parseXMLParams(xmlToolCall.Params.XMLData, a.logger)
-		if err != nil {
-			a.logger.Error("Failed to parse parameters", "error", err)
-			return nil, response, false
-		}
-
-		// Create the ToolCall object
-		result := &ToolCall{
-			ToolName: xmlToolCall.Name,
-			Params:   params,
-		}
-
-		a.logger.Debug("Successfully extracted tool call",
-			"toolName", result.ToolName,
-			"paramsCount", len(result.Params))
-
-		// The tool section has already been removed from remainingText above
-		return result, remainingText, true
-	}
-
-	// If standard parsing failed, try fallback methods
-	a.logger.Debug("Standard XML parsing failed, trying fallback methods", "error", err)
-
-	// Try legacy approach
-	toolName := extractXMLElement(toolXML, "name")
-	if toolName == "" {
-		// We should not see missing name field errors anymore with our improved extraction
-		// but we'll still log it for debugging purposes
-		a.logger.Debug("Tool name extracted as empty, this should not happen with improved extraction", "xml", toolXML)
-
-		// Try to handle legacy JSON format for backward compatibility
-		if strings.Contains(toolXML, "\"name\"") {
-			a.logger.Debug("Detected legacy JSON format, trying to parse as JSON")
-			return extractLegacyJSONToolCall(a, toolXML, response, pattern)
-		}
-
-		return nil, response, false
-	}
-
-	// Extract params section
-	paramsSection := extractXMLElement(toolXML, "params")
-	if paramsSection == "" {
-		a.logger.Error("Tool call missing params section", "xml", toolXML)
-		return nil, response, false
-	}
-
-	// Parse parameters from params section
-	params := extractXMLParams(paramsSection, a.logger)
-	if params == nil {
-		a.logger.Error("Failed to extract parameters from tool call", "xml", toolXML)
-		return nil, response, false
-	}
-
-	// Create the ToolCall object
-	result = &ToolCall{
-		ToolName: toolName,
-		Params:   params,
-	}
-
-	a.logger.Debug("Successfully extracted tool call using fallback method",
-		"toolName", result.ToolName,
-		"paramsCount", len(result.Params))
-
-	// The tool section has already been removed from remainingText above
-	return result, remainingText, true
+	remainingText := strings.TrimSpace(response[:span.start] + response[span.end:])
+	return span.call, remainingText, true
 }
 
 // ExecuteTool executes a tool with the given parameters
 func (a *agent) ExecuteTool(ctx context.Context, toolName string, params map[string]interface{}) (interface{}, error) {
+	result, _, err := a.executeTool(ctx, toolName, params)
+	return result, err
+}
+
+// executeTool executes a tool and returns the parameters it ran with, which the user
+// may have edited when granting permission
+func (a *agent) executeTool(ctx context.Context, toolName string, params map[string]interface{}) (interface{}, map[string]interface{}, error) {
 	if a.toolRegistry == nil {
-		return nil, ErrToolNotFound
+		return nil, params, ErrToolNotFound
 	}
 
 	// Get the tool
 	tool, found := a.toolRegistry.GetTool(toolName)
 	if !found {
 		a.logger.Error("Tool not found", "tool", toolName)
-		fmt.Fprintf(os.Stderr, "\n==== TOOL NOT FOUND ====\n")
-		fmt.Fprintf(os.Stderr, "Tool name: %s\n", toolName)
-		fmt.Fprintf(os.Stderr, "=======================\n\n")
-		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, toolName)
+		a.notef("%s", callLine(toolName, params, 0, ErrToolNotFound))
+		return nil, params, fmt.Errorf("%w: %s", ErrToolNotFound, toolName)
 	}
 
 	// Nil check for tool
 	if tool == nil {
 		a.logger.Error("Tool is nil", "tool", toolName)
-		return nil, fmt.Errorf("tool %s is nil", toolName)
+		return nil, params, fmt.Errorf("tool %s is nil", toolName)
 	}
+	params = tools.CoerceParams(params, tool.ParameterSchema())
 
-	// Log tool execution start in XML format
-	fmt.Fprintf(os.Stderr, "\n==== EXECUTING TOOL ====\n")
-	fmt.Fprintf(os.Stderr, "<tool_execution>\n")
-	fmt.Fprintf(os.Stderr, "  <tool_name>%s</tool_name>\n", agentEscapeXML(toolName))
-	fmt.Fprintf(os.Stderr, "  <description>%s</description>\n", agentEscapeXML(tool.Description()))
+	// Trace the call's parameters in XML format
+	a.tracef("\n==== EXECUTING TOOL ====\n")
+	a.tracef("<tool_execution>\n")
+	a.tracef("  <tool_name>%s</tool_name>\n", agentEscapeXML(toolName))
+	a.tracef("  <description>%s</description>\n", agentEscapeXML(tool.Description()))
 
 	// Format parameters as XML
-	fmt.Fprintf(os.Stderr, "  <parameters>\n")
+	a.tracef("  <parameters>\n")
 
 	// Sort parameters for consistent output
 	keys := make([]string, 0, len(params))
@@ -758,13 +858,13 @@ func (a *agent) ExecuteTool(ctx context.Context, toolName string, params map[str
 	// Add each parameter as XML
 	for _, k := range keys {
 		v := params[k]
-		fmt.Fprintf(os.Stderr, "    <%s>%v</%s>\n", k, agentFormatXMLValue(v), k)
+		a.tracef("    <%s>%v</%s>\n", k, agentFormatXMLValue(v), k)
 	}
 
-	fmt.Fprintf(os.Stderr, "  </parameters>\n")
-	fmt.Fprintf(os.Stderr, "  <start_time>%s</start_time>\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(os.Stderr, "</tool_execution>\n")
-	fmt.Fprintf(os.Stderr, "=======================\n\n")
+	a.tracef("  </parameters>\n")
+	a.tracef("  <start_time>%s</start_time>\n", time.Now().Format(time.RFC3339))
+	a.tracef("</tool_execution>\n")
+	a.tracef("=======================\n\n")
 
 	a.logger.Info("Executing tool", "tool", toolName, "params", params)
 
@@ -772,84 +872,156 @@ func (a *agent) ExecuteTool(ctx context.Context, toolName string, params map[str
 	err := tools.ValidateToolParams(tool, params)
 	if err != nil {
 		a.logger.Error("Invalid tool parameters", "tool", toolName, "error", err)
-		fmt.Fprintf(os.Stderr, "\n==== TOOL VALIDATION ERROR ====\n")
-		fmt.Fprintf(os.Stderr, "Tool: %s\n", toolName)
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		fmt.Fprintf(os.Stderr, "==============================\n\n")
-		return nil, err
+		a.tracef("\n==== TOOL VALIDATION ERROR ====\n")
+		a.tracef("Tool: %s\n", toolName)
+		a.tracef("Error: %v\n", err)
+		a.tracef("==============================\n\n")
+		a.notef("%s", callLine(toolName, params, 0, err))
+		return nil, params, err
 	}
 
 	// Request execution permission
 	if a.permissionMgr != nil {
 		a.logger.Debug("Requesting tool execution permission", "tool", toolName)
 
-		fmt.Fprintf(os.Stderr, "\n==== PERMISSION REQUEST ====\n")
-		fmt.Fprintf(os.Stderr, "Tool: %s\n", toolName)
+		a.tracef("\n==== PERMISSION REQUEST ====\n")
+		a.tracef("Tool: %s\n", toolName)
 
 		// Request permission
 		granted, err := a.permissionMgr.RequestPermission(ctx, toolName, params, tool)
 		if err != nil {
 			a.logger.Error("Permission request failed", "tool", toolName, "error", err)
-			fmt.Fprintf(os.Stderr, "Permission request error: %v\n", err)
-			fmt.Fprintf(os.Stderr, "============================\n\n")
-			return nil, fmt.Errorf("failed to request permission: %w", err)
+			a.tracef("Permission request error: %v\n", err)
+			a.tracef("============================\n\n")
+			return nil, params, fmt.Errorf("failed to request permission: %w", err)
 		}
 
 		if !granted {
 			a.logger.Info("Permission denied for tool execution", "tool", toolName)
-			fmt.Fprintf(os.Stderr, "Permission denied by user\n")
-			fmt.Fprintf(os.Stderr, "============================\n\n")
-			return nil, tools.ErrPermissionDenied
+			a.tracef("Permission denied by user\n")
+			a.tracef("============================\n\n")
+			a.notef("%s", callLine(toolName, params, 0, tools.ErrPermissionDenied))
+			return nil, params, tools.ErrPermissionDenied
 		}
 
-		fmt.Fprintf(os.Stderr, "Permission granted\n")
-		fmt.Fprintf(os.Stderr, "============================\n\n")
+		a.tracef("Permission granted\n")
+		a.tracef("============================\n\n")
 		a.logger.Debug("Permission granted for tool execution", "tool", toolName)
 	}
 
-	// Execute the tool
+	// Hooks may veto the call, such as a policy script checking commands
+	if err := a.config.Hooks.Run(ctx, hooks.Payload{Event: hooks.BeforeTool, Tool: toolName, Params: hookParams(params)}); err != nil {
+		a.logger.Info("Tool call blocked by hook", "tool", toolName, "error", err)
+		a.notef("%s", callLine(toolName, params, 0, err))
+		return nil, params, fmt.Errorf("%w: %s: blocked by hook: %v", ErrToolExecutionFailed, toolName, err)
+	}
+
+	// Execute the tool, or use the result staged for it. Calls that may change state
+	// first discard staged results, which could be outdated afterwards.
+	if tools.ChangesState(toolName, params) {
+		a.prefetch.reset()
+	}
 	startTime := time.Now()
-	result, err := tool.Execute(ctx, params)
+	result, prefetched := a.prefetch.take(toolName, params)
+	if prefetched {
+		a.logger.Debug("Using prefetched tool result", "tool", toolName)
+	} else {
+		// Read the files about to be written once the parameters are final, so the
+		// changed files footer compares against the files actually written
+		a.changes.before(tools.WrittenFiles(toolName, params))
+		result, err = tool.Execute(ctx, params)
+	}
 	duration := time.Since(startTime)
 
 	if err != nil {
 		// Log tool execution failure
 		a.logger.Error("Tool execution failed", "tool", toolName, "error", err, "duration", duration.String())
-		fmt.Fprintf(os.Stderr, "\n==== TOOL EXECUTION FAILED ====\n")
-		fmt.Fprintf(os.Stderr, "Tool: %s\n", toolName)
-		fmt.Fprintf(os.Stderr, "Duration: %s\n", duration.String())
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		fmt.Fprintf(os.Stderr, "==============================\n\n")
-		return nil, fmt.Errorf("%w: %s: %v", ErrToolExecutionFailed, toolName, err)
+		a.tracef("\n==== TOOL EXECUTION FAILED ====\n")
+		a.tracef("Tool: %s\n", toolName)
+		a.tracef("Duration: %s\n", duration.String())
+		a.tracef("Error: %v\n", err)
+		a.tracef("==============================\n\n")
+		if !a.tracing() {
+			a.notef("%s", callLine(toolName, params, duration, err))
+		}
+		return nil, params, fmt.Errorf("%w: %s: %v", ErrToolExecutionFailed, toolName, err)
 	}
 
 	// Log tool execution success
-	fmt.Fprintf(os.Stderr, "\n==== TOOL EXECUTION COMPLETED ====\n")
-	fmt.Fprintf(os.Stderr, "<tool_result>\n")
-	fmt.Fprintf(os.Stderr, "  <tool_name>%s</tool_name>\n", agentEscapeXML(toolName))
-	fmt.Fprintf(os.Stderr, "  <duration>%s</duration>\n", duration.String())
+	a.tracef("\n==== TOOL EXECUTION COMPLETED ====\n")
+	a.tracef("<tool_result>\n")
+	a.tracef("  <tool_name>%s</tool_name>\n", agentEscapeXML(toolName))
+	a.tracef("  <duration>%s</duration>\n", duration.String())
 
 	// Format result as XML inline
 	xmlOutput := formatToolResultAsXML(result, toolName)
 
-	// Truncate very large results for the log
-	if len(xmlOutput) > 500 {
-		fmt.Fprintf(os.Stderr, "  <result_truncated length=\"%d\">\n%s...\n  </result_truncated>\n",
+	// Show results with a schema in their rendered form, otherwise truncate large results
+	var rendered string
+	if describer, ok := tool.(tools.ResultDescriber); ok {
+		rendered, _ = tools.RenderResult(result, describer.ResultSchema(), tools.RenderOptions{MaxRows: 20, MaxWidth: 80})
+	}
+	if rendered != "" {
+		a.tracef("  <result>\n%s  </result>\n", style.Text(rendered))
+	} else if len(xmlOutput) > 500 {
+		a.tracef("  <result_truncated length=\"%d\">\n%s...\n  </result_truncated>\n",
 			len(xmlOutput), xmlOutput[:500])
 	} else {
-		fmt.Fprintf(os.Stderr, "  <result>\n%s\n  </result>\n", xmlOutput)
+		a.tracef("  <result>\n%s\n  </result>\n", xmlOutput)
 	}
 
-	fmt.Fprintf(os.Stderr, "  <finish_time>%s</finish_time>\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(os.Stderr, "</tool_result>\n")
-	fmt.Fprintf(os.Stderr, "================================\n\n")
+	a.tracef("  <finish_time>%s</finish_time>\n", time.Now().Format(time.RFC3339))
+	a.tracef("</tool_result>\n")
+	a.tracef("================================\n\n")
 
 	a.logger.Info("Tool executed successfully",
 		"tool", toolName,
 		"duration", duration.String(),
 		"resultSize", len(xmlOutput))
+	if !a.tracing() {
+		a.notef("%s", callLine(toolName, params, duration, nil))
+	}
+
+	if files := tools.WrittenFiles(toolName, params); len(files) > 0 && a.config.EditChecker != nil {
+		if report := a.config.EditChecker(ctx, files); report != "" {
+			result = fmt.Sprintf("%v\n\n%s", result, report)
+		}
+	}
+	a.runAfterToolHooks(ctx, toolName, params, result)
+	return result, params, nil
+}
+
+// runAfterToolHooks runs the hooks for a successful call and for each file it wrote.
+// The call already happened, so failures are only reported.
+func (a *agent) runAfterToolHooks(ctx context.Context, toolName string, params map[string]interface{}, result interface{}) {
+	runner := a.config.Hooks
+	if runner == nil {
+		return
+	}
+	payloads := []hooks.Payload{}
+	if runner.Has(hooks.AfterTool, toolName) {
+		payloads = append(payloads, hooks.Payload{Event: hooks.AfterTool, Tool: toolName, Params: hookParams(params), Result: fmt.Sprint(result)})
+	}
+	for _, file := range tools.WrittenFiles(toolName, params) {
+		payloads = append(payloads, hooks.Payload{Event: hooks.AfterFileWrite, Tool: toolName, File: file})
+	}
+	for _, payload := range payloads {
+		if err := runner.Run(ctx, payload); err != nil {
+			a.logger.Warn("Hook failed", "event", payload.Event, "tool", toolName, "error", err)
+			a.warnf("%v", err)
+		}
+	}
+}
 
-	return result, nil
+// hookParams leaves out the values tools add to their parameters for internal use
+func hookParams(params map[string]interface{}) map[string]interface{} {
+	clean := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if !strings.HasPrefix(k, "_") {
+			clean[k] = v
+		}
+	}
+	return clean
 }
 
 // AddSystemMessage adds a system message to the context
@@ -871,26 +1043,25 @@ func (a *agent) AddAssistantMessage(message string) {
 func (a *agent) ClearContext() {
 	a.logger.Info("Clearing conversation context (keeping system messages)")
 
-	// Display to stderr for visibility
-	fmt.Fprintf(os.Stderr, "\n==== CLEARING CONVERSATION CONTEXT ====\n")
-	fmt.Fprintf(os.Stderr, "Time: %s\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(os.Stderr, "Keeping system messages\n")
-	fmt.Fprintf(os.Stderr, "======================================\n\n")
+	a.tracef("\n==== CLEARING CONVERSATION CONTEXT ====\n")
+	a.tracef("Time: %s\n", time.Now().Format(time.RFC3339))
+	a.tracef("Keeping system messages\n")
+	a.tracef("======================================\n\n")
 
 	// Call the context's ClearContext method
 	a.context.ClearContext()
+	a.sources = nil
 }
 
 // SetModel changes the active model used by the agent
 func (a *agent) SetModel(model string) {
 	a.logger.Info("Changing model", "from", a.config.Model, "to", model)
 
-	// Display to stderr for visibility
-	fmt.Fprintf(os.Stderr, "\n==== CHANGING MODEL ====\n")
-	fmt.Fprintf(os.Stderr, "From: %s\n", a.config.Model)
-	fmt.Fprintf(os.Stderr, "To: %s\n", model)
-	fmt.Fprintf(os.Stderr, "Time: %s\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(os.Stderr, "=======================\n\n")
+	a.tracef("\n==== CHANGING MODEL ====\n")
+	a.tracef("From: %s\n", a.config.Model)
+	a.tracef("To: %s\n", model)
+	a.tracef("Time: %s\n", time.Now().Format(time.RFC3339))
+	a.tracef("=======================\n\n")
 
 	// Update the model in the config
 	a.config.Model = model
@@ -908,6 +1079,79 @@ func (a *agent) SetMaxTokens(maxTokens int) {
 	a.config.MaxTokens = maxTokens
 }
 
+// SetSystemPrompt replaces the system prompt, filling in tool descriptions
+func (a *agent) SetSystemPrompt(prompt string) {
+	var toolSpecs []tools.ToolSpec
+	if a.toolRegistry != nil {
+		toolSpecs = a.toolRegistry.GetToolSpecs()
+	}
+
+	a.config.SystemPrompt = prompt
+	formatted := FormatSystemPrompt(prompt, toolSpecs)
+	if a.config.Citations {
+		formatted += "\n\n" + citationInstructions
+	}
+	a.context.SetSystemPrompt(formatted)
+}
+
+// SystemPrompt returns the system prompt currently sent to the model
+func (a *agent) SystemPrompt() string {
+	return a.context.SystemPrompt()
+}
+
+// SetDryRun turns dry-run mode on or off
+func (a *agent) SetDryRun(enabled bool) {
+	a.dryRun = enabled
+	a.logger.Info("Dry-run mode changed", "enabled", enabled)
+}
+
+// DryRun reports whether dry-run mode is on
+func (a *agent) DryRun() bool {
+	return a.dryRun
+}
+
+// SetOutputLevel changes how much of its work the agent prints
+func (a *agent) SetOutputLevel(level OutputLevel) {
+	a.logger.Info("Output level changed", "from", a.config.Output, "to", level)
+	a.config.Output = level
+}
+
+// OutputLevel returns how much of its work the agent prints
+func (a *agent) OutputLevel() OutputLevel {
+	return a.config.Output
+}
+
+// Messages returns a copy of the conversation context
+func (a *agent) Messages() []Message {
+	return a.context.GetMessages()
+}
+
+// toolResult records a tool's result with what the sanitizer needs to guard it
+func (a *agent) toolResult(ctx context.Context, toolName string, result interface{}, err error) ToolResult {
+	tr := ToolResult{ToolName: toolName, Result: result}
+	if err != nil {
+		tr.Error = err.Error()
+		return tr
+	}
+
+	if tool, ok := a.toolRegistry.GetTool(toolName); ok {
+		if ext, ok := tool.(ExternalContent); ok {
+			tr.Untrusted = ext.ExternalContent()
+		}
+		if describer, ok := tool.(tools.ResultDescriber); ok {
+			schema := describer.ResultSchema()
+			tr.Schema = &schema
+		}
+	}
+	if sanitizer := a.context.Sanitizer(); sanitizer != nil {
+		tr.Warning = sanitizer.Screen(ctx, formatToolResultBody(result))
+		if tr.Warning != "" {
+			a.logger.Warn("Tool result flagged as possible prompt injection", "tool", toolName)
+		}
+	}
+	return tr
+}
+
 // formatToolResultAsXML formats a tool result as XML for display
 func formatToolResultAsXML(result interface{}, toolName string) string {
 	var builder strings.Builder
@@ -937,53 +1181,6 @@ func formatToolResultAsXML(result interface{}, toolName string) string {
 	return builder.String()
 }
 
-// extractXMLElement extracts a specific element from an XML string
-func extractXMLElement(xmlStr string, elementName string) string {
-	// First try the requested element name
-	result := tryExtractXMLElement(xmlStr, elementName)
-
-	// If we're looking for "name" and didn't find it, try "n" as an alternative
-	if result == "" && elementName == "name" {
-		result = tryExtractXMLElement(xmlStr, "n")
-	}
-
-	return result
-}
-
-// tryExtractXMLElement attempts to extract a specific XML element by name
-func tryExtractXMLElement(xmlStr string, elementName string) string {
-	// Create patterns for opening and closing tags
-	openTag := regexp.MustCompile(fmt.Sprintf(`<%s[^>]*>`, regexp.QuoteMeta(elementName)))
-	closeTag := regexp.MustCompile(fmt.Sprintf(`</%s>`, regexp.QuoteMeta(elementName)))
-
-	// First check if it's a self-closing tag
-	selfClosingPattern := regexp.MustCompile(fmt.Sprintf(`<%s[^>]*/?>`, regexp.QuoteMeta(elementName)))
-	if selfClosingPattern.MatchString(xmlStr) {
-		return "" // Self-closing tag has no content
-	}
-
-	// Find positions of opening and closing tags
-	openMatches := openTag.FindStringIndex(xmlStr)
-	closeMatches := closeTag.FindStringIndex(xmlStr)
-
-	// Check if we found both tags
-	if openMatches == nil || closeMatches == nil {
-		return "" // Element not found
-	}
-
-	// Extract content between tags
-	openEnd := openMatches[1]     // End position of opening tag
-	closeStart := closeMatches[0] // Start position of closing tag
-
-	// Validate positions
-	if openEnd >= closeStart || openEnd >= len(xmlStr) || closeStart > len(xmlStr) {
-		return "" // Invalid positions
-	}
-
-	// Return the content between opening and closing tags, trimmed
-	return strings.TrimSpace(xmlStr[openEnd:closeStart])
-}
-
 // extractXMLParams is the fallback method for parsing parameters when standard XML parsing fails
 func extractXMLParams(paramsXML string, logger *logger.Logger) map[string]interface{} {
 	params := make(map[string]interface{})
@@ -1113,60 +1310,6 @@ func agentFormatXMLValue(value interface{}) string {
 	}
 }
 
-// extractLegacyJSONToolCall handles legacy JSON format for backward compatibility
-func extractLegacyJSONToolCall(a *agent, toolJSON string, response string, pattern *regexp.Regexp) (*ToolCall, string, bool) {
-	a.logger.Debug("Attempting to parse legacy JSON tool call", "json", toolJSON)
-
-	// Parse the tool call
-	var toolCall struct {
-		Name   string                 `json:"name"`
-		Params map[string]interface{} `json:"params"`
-	}
-
-	err := json.Unmarshal([]byte(toolJSON), &toolCall)
-	if err != nil {
-		a.logger.Error("Failed to parse legacy JSON tool call", "error", err, "json", toolJSON)
-
-		// Try with preprocessing
-		toolJSON = strings.ReplaceAll(toolJSON, "\n", "")
-		toolJSON = strings.ReplaceAll(toolJSON, "\r", "")
-
-		// Try again with cleaned JSON
-		err = json.Unmarshal([]byte(toolJSON), &toolCall)
-		if err != nil {
-			a.logger.Error("Failed to parse legacy JSON after cleaning", "error", err)
-			return nil, response, false
-		}
-	}
-
-	// Validate the tool call has required fields
-	if toolCall.Name == "" {
-		a.logger.Error("Legacy JSON tool call missing name field", "json", toolJSON)
-		return nil, response, false
-	}
-
-	if toolCall.Params == nil {
-		a.logger.Error("Legacy JSON tool call missing params field", "json", toolJSON)
-		return nil, response, false
-	}
-
-	// Create the ToolCall object
-	result := &ToolCall{
-		ToolName: toolCall.Name,
-		Params:   toolCall.Params,
-	}
-
-	a.logger.Debug("Successfully extracted legacy JSON tool call",
-		"toolName", result.ToolName,
-		"paramsCount", len(result.Params))
-
-	// Remove the tool section from the response
-	remainingText := pattern.ReplaceAllString(response, "")
-	remainingText = strings.TrimSpace(remainingText)
-
-	return result, remainingText, true
-}
-
 // escapeXML escapes XML special characters
 func agentEscapeXML(s string) string {
 	s = strings.ReplaceAll(s, "&", "&amp;")
@@ -1225,7 +1368,7 @@ Use the todo_create tool with these exact parameters:
 - items: An array of task objects, each with:
   - content: The task description
   - priority: "high", "medium", or "low"
-  - dependencies: Array of task IDs that must complete first (optional)
+  - dependencies: Tasks that must complete first, by position (task_1 is the first) (optional)
 
 Example format:
 <tool>
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Budget limits the work the agent does for one request. Zero fields are unlimited.
+type Budget struct {
+	MaxWallTime time.Duration
+	MaxLLMCalls int
+	MaxTokens   int // Prompt and completion tokens as reported by the model server
+}
+
+// budgetUsage is the work done so far for the current request
+type budgetUsage struct {
+	started  time.Time
+	llmCalls int
+	tokens   int
+	steps    []string // One line per tool call, for the progress summary
+}
+
+// BudgetError is returned with a partial response when a request runs out of budget.
+// Call Continue to resume it with a fresh budget.
+type BudgetError struct {
+	Reason string
+}
+
+func (e *BudgetError) Error() string {
+	return "request budget exhausted: " + e.Reason
+}
+
+// exhausted returns why the budget is used up, or "" while work may continue
+func (b Budget) exhausted(u *budgetUsage) string {
+	if u == nil {
+		return ""
+	}
+	if b.MaxLLMCalls > 0 && u.llmCalls >= b.MaxLLMCalls {
+		return fmt.Sprintf("%d of %d model calls used", u.llmCalls, b.MaxLLMCalls)
+	}
+	if b.MaxTokens > 0 && u.tokens >= b.MaxTokens {
+		return fmt.Sprintf("%d of %d tokens used", u.tokens, b.MaxTokens)
+	}
+	if elapsed := time.Since(u.started); b.MaxWallTime > 0 && elapsed >= b.MaxWallTime {
+		return fmt.Sprintf("%s of %s used", elapsed.Round(time.Second), b.MaxWallTime)
+	}
+	return ""
+}
+
+// summary describes what was done before the budget ran out
+func (u *budgetUsage) summary(reason string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Stopped before finishing: the request budget is exhausted (%s).\n", reason)
+	if len(u.steps) > 0 {
+		b.WriteString("\nProgress so far:\n")
+		for _, step := range u.steps {
+			b.WriteString("- " + step + "\n")
+		}
+	}
+	fmt.Fprintf(&b, "\n%d model calls, %d tokens, %s elapsed.", u.llmCalls, u.tokens, time.Since(u.started).Round(time.Second))
+	return b.String()
+}
+
+// toolStep describes a tool call for the progress summary
+func toolStep(call *ToolCall, err error) string {
+	var target string
+	for _, key := range []string{"file_path", "path", "command", "dir", "pattern", "handle"} {
+		if v, ok := call.Params[key].(string); ok && v != "" {
+			target = v
+			break
+		}
+	}
+	if len(target) > 60 {
+		target = target[:57] + "..."
+	}
+	step := call.ToolName
+	if target != "" {
+		step += " " + target
+	}
+	if err != nil {
+		step += " (failed)"
+	}
+	return step
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// echoTool returns its input
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echoes its input" }
+func (echoTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{Type: "object", Properties: map[string]tools.JSONSchema{"path": {Type: "string"}}}
+}
+func (echoTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return params["path"], nil
+}
+
+// loopingModel serves a model that always calls a tool and reports 100 tokens per call
+func loopingModel() *httptest.Server {
+	calls := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"response":          "Checking.\n<tool><name>echo</name><params><path>step" + strconv.Itoa(calls) + "</path></params></tool>",
+			"done":              true,
+			"prompt_eval_count": 80,
+			"eval_count":        20,
+		})
+	}))
+}
+
+func TestBudgetStopsAgentLoop(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget Budget
+		reason string
+		steps  int
+	}{
+		{"model calls", Budget{MaxLLMCalls: 2}, "2 of 2 model calls used", 2},
+		{"tokens", Budget{MaxTokens: 250}, "300 of 250 tokens used", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := loopingModel()
+			defer server.Close()
+
+			log, _ := logger.New(logger.Config{Silent: true})
+			registry := tools.NewToolRegistry()
+			registry.RegisterTool(echoTool{})
+			a := NewAgent(&Config{
+				Model:        "test",
+				MaxTokens:    4000,
+				OllamaURL:    server.URL,
+				ToolRegistry: registry,
+				Logger:       log,
+				Budget:       tt.budget,
+			})
+
+			response, err := a.ProcessMessage(context.Background(), "hi")
+			var budgetErr *BudgetError
+			if !errors.As(err, &budgetErr) {
+				t.Fatalf("expected a BudgetError, got %v", err)
+			}
+			if budgetErr.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", budgetErr.Reason, tt.reason)
+			}
+			if got := strings.Count(response, "- echo step"); got != tt.steps {
+				t.Errorf("summary lists %d steps, want %d:\n%s", got, tt.steps, response)
+			}
+
+			// Continuing starts a fresh budget
+			if _, err := a.Continue(context.Background()); !errors.As(err, &budgetErr) {
+				t.Errorf("expected Continue to stop at the budget again, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBudgetExhausted(t *testing.T) {
+	usage := &budgetUsage{started: time.Now().Add(-time.Minute), llmCalls: 3, tokens: 900}
+
+	tests := []struct {
+		budget Budget
+		want   string
+	}{
+		{Budget{}, ""},
+		{Budget{MaxLLMCalls: 4, MaxTokens: 1000, MaxWallTime: time.Hour}, ""},
+		{Budget{MaxLLMCalls: 3}, "3 of 3 model calls used"},
+		{Budget{MaxTokens: 500}, "900 of 500 tokens used"},
+		{Budget{MaxWallTime: 30 * time.Second}, "1m0s of 30s used"},
+	}
+	for _, tt := range tests {
+		if got := tt.budget.exhausted(usage); got != tt.want {
+			t.Errorf("%+v: exhausted = %q, want %q", tt.budget, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+)
+
+// buildFailedPrompt feeds a failed build back to the model: command, output, attempt, attempts
+const buildFailedPrompt = "The project does not build after your changes. `%s` failed:\n\n```\n%s\n```\n\nFix these errors before answering; do not say the work is done while the build fails. (Fix attempt %d of %d.)"
+
+// BuildChecker runs the project's build command. It returns the command, and the
+// failed build's output with a non-nil error.
+type BuildChecker func(ctx context.Context) (command, output string, err error)
+
+// buildCheck tracks whether the current request has to prove the project still builds
+type buildCheck struct {
+	pending  bool // Files were written since the last passing build
+	attempts int  // Failed builds handed back to the model
+}
+
+// checkBuild runs the build after a request that wrote files, once the model answers
+// without calling tools. When the build fails and retry allows another attempt it
+// returns the prompt asking the model to fix it; otherwise it returns a note for the
+// final answer, saying the build passed or summarizing how it still fails.
+func (a *agent) checkBuild(ctx context.Context, retry bool) (prompt, note string) {
+	if a.config.BuildChecker == nil || !a.build.pending || a.dryRun {
+		return "", ""
+	}
+	command, output, err := a.config.BuildChecker(ctx)
+	if err == nil {
+		a.build.pending = false
+		a.logger.Info("Build check passed", "command", command)
+		return "", fmt.Sprintf("✓ Build check passed: `%s`", command)
+	}
+
+	attempts := a.config.BuildAttempts
+	a.logger.Info("Build check failed", "command", command, "attempt", a.build.attempts+1, "error", err)
+	a.traceBlock("BUILD CHECK FAILED: "+command, output)
+	if !retry || a.build.attempts >= attempts {
+		a.build.pending = false
+		status := "The project does not build"
+		if a.build.attempts > 0 {
+			status += fmt.Sprintf(" after %d fix attempts", a.build.attempts)
+		}
+		return "", fmt.Sprintf("⚠️ %s. `%s` fails with:\n\n```\n%s\n```", status, command, output)
+	}
+	a.build.attempts++
+	return fmt.Sprintf(buildFailedPrompt, command, output, a.build.attempts, attempts), ""
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+func TestBuildCheckHandsFailuresBack(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Prompt)
+
+		response := "Done."
+		if len(prompts)%2 == 1 {
+			response = `<tool><name>fileWrite</name><params><file_path>main.go</file_path></params></tool>`
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		failures  int // Builds that fail before one passes
+		attempts  int
+		wantCalls int
+		wantNote  string
+	}{
+		{"passes", 0, 2, 2, "✓ Build check passed: `make build`"},
+		{"fixed", 1, 2, 4, "✓ Build check passed: `make build`"},
+		{"gives up", 5, 2, 6, "⚠️ The project does not build after 2 fix attempts. `make build` fails with:\n\n```\nmain.go:3: undefined: foo\n```"},
+		{"report only", 5, 0, 2, "⚠️ The project does not build. `make build` fails with:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompts = nil
+			var writes, builds int
+			log, _ := logger.New(logger.Config{Silent: true})
+			registry := tools.NewToolRegistry()
+			registry.RegisterTool(countingTool{name: "fileWrite", calls: &writes})
+			a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log,
+				BuildAttempts: tt.attempts,
+				BuildChecker: func(ctx context.Context) (string, string, error) {
+					if builds++; builds <= tt.failures {
+						return "make build", "main.go:3: undefined: foo", errors.New("exit status 2")
+					}
+					return "make build", "", nil
+				},
+			})
+
+			response, err := a.ProcessMessage(context.Background(), "add foo")
+			if err != nil {
+				t.Fatalf("ProcessMessage: %v", err)
+			}
+			if len(prompts) != tt.wantCalls {
+				t.Errorf("model calls = %d, want %d", len(prompts), tt.wantCalls)
+			}
+			if tt.failures > 0 && tt.attempts > 0 && !strings.Contains(prompts[2], "main.go:3: undefined: foo") {
+				t.Errorf("build failure was not handed back:\n%s", prompts[2])
+			}
+			if !strings.HasPrefix(response, "Done.") || !strings.Contains(response, tt.wantNote) {
+				t.Errorf("response = %q, want the note %q", response, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestBuildCheckSkipsReadOnlyRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": "It parses the config.", "done": true})
+	}))
+	defer server.Close()
+
+	var builds int
+	log, _ := logger.New(logger.Config{Silent: true})
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: tools.NewToolRegistry(), Logger: log,
+		BuildChecker: func(ctx context.Context) (string, string, error) {
+			builds++
+			return "make build", "", nil
+		},
+	})
+	response, err := a.ProcessMessage(context.Background(), "what does this do?")
+	if err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if builds != 0 || response != "It parses the config." {
+		t.Errorf("builds = %d, response = %q; want no build for an answer without edits", builds, response)
+	}
+}
@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"os"
+	"sort"
+
+	"codezilla/internal/tools"
+)
+
+// ChangedFile is a file changed while answering a message, with the lines added and
+// removed since before the first change
+type ChangedFile struct {
+	Path    string `json:"path"` // Absolute path
+	Kind    string `json:"kind"` // tools.FileCreated, FileModified or FileDeleted
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	// Counted is false when the lines couldn't be counted, as for files a command
+	// changed, whose earlier contents weren't read
+	Counted bool `json:"counted"`
+}
+
+// turnChanges tracks the files changed while answering a message
+type turnChanges struct {
+	files map[string]*turnFile // Absolute path -> state before the first change
+}
+
+// turnFile is a changed file as it was before the first change
+type turnFile struct {
+	before *string // Contents; nil when the file didn't exist
+	known  bool    // Whether before was read; false for files found changed afterwards
+	kind   string  // Kind of change reported, when before isn't known
+}
+
+// before reads the files a call is about to write, unless an earlier call of the same
+// turn changed them already
+func (t *turnChanges) before(paths []string) {
+	for _, path := range paths {
+		path = absPath(path)
+		if _, ok := t.files[path]; ok {
+			continue
+		}
+		file := &turnFile{known: true}
+		if data, err := os.ReadFile(path); err == nil {
+			content := string(data)
+			file.before = &content
+		}
+		t.add(path, file)
+	}
+}
+
+// after records the files a successful call changed that weren't read beforehand:
+// those renameSymbol rewrote and those found changed after a command
+func (t *turnChanges) after(toolName string, params map[string]interface{}, result interface{}) {
+	for _, path := range editedFiles(toolName, params, result) {
+		if path = absPath(path); t.files[path] == nil {
+			t.add(path, &turnFile{kind: tools.FileModified})
+		}
+	}
+	if toolName == "execute" {
+		fields, _ := result.(map[string]interface{})
+		changes, _ := fields["changed_files"].([]tools.FileChange)
+		for _, c := range changes {
+			if path := absPath(c.Path); t.files[path] == nil {
+				t.add(path, &turnFile{kind: c.Kind})
+			}
+		}
+	}
+}
+
+func (t *turnChanges) add(path string, file *turnFile) {
+	if t.files == nil {
+		t.files = make(map[string]*turnFile)
+	}
+	t.files[path] = file
+}
+
+// list compares the changed files with their contents before the turn, leaving out
+// those that ended up as they were
+func (t *turnChanges) list() []ChangedFile {
+	var changed []ChangedFile
+	for path, file := range t.files {
+		var after *string
+		if data, err := os.ReadFile(path); err == nil {
+			content := string(data)
+			after = &content
+		}
+
+		c := ChangedFile{Path: path, Counted: true}
+		switch {
+		case !file.known:
+			c.Kind, c.Counted = file.kind, false
+			if after == nil {
+				c.Kind = tools.FileDeleted
+			} else if c.Kind == tools.FileCreated {
+				c.Added, c.Counted = tools.CountLines(*after), true
+			}
+		case file.before == nil && after == nil:
+			continue
+		case file.before == nil:
+			c.Kind, c.Added = tools.FileCreated, tools.CountLines(*after)
+		case after == nil:
+			c.Kind, c.Removed = tools.FileDeleted, tools.CountLines(*file.before)
+		case *file.before == *after:
+			continue
+		default:
+			c.Kind = tools.FileModified
+			c.Added, c.Removed = tools.LineDelta(*file.before, *after)
+		}
+		changed = append(changed, c)
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+	return changed
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codezilla/internal/tools"
+)
+
+func TestTurnChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	write := func(name, content string) {
+		if err := os.WriteFile(path(name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("edited.go", "a\nb\nc\n")
+	write("restored.go", "same\n")
+	write("removed.go", "x\ny\n")
+	write("built.log", "old\n")
+
+	var changes turnChanges
+	changes.before([]string{path("edited.go"), path("restored.go"), path("removed.go"), path("new.go")})
+	write("edited.go", "a\nB\nc\nd\n")
+	write("restored.go", "changed\n")
+	write("restored.go", "same\n")
+	os.Remove(path("removed.go"))
+	write("new.go", "package x\n\nfunc X() {}\n")
+
+	// A command changed files that weren't read beforehand
+	write("built.log", "new\n")
+	write("gen.go", "one\ntwo\n")
+	changes.after("execute", map[string]interface{}{"command": "make"}, map[string]interface{}{
+		"changed_files": []tools.FileChange{
+			{Path: path("built.log"), Kind: tools.FileModified},
+			{Path: path("gen.go"), Kind: tools.FileCreated},
+			{Path: path("edited.go"), Kind: tools.FileModified},
+		},
+	})
+
+	want := []ChangedFile{
+		{Path: path("built.log"), Kind: tools.FileModified},
+		{Path: path("edited.go"), Kind: tools.FileModified, Added: 2, Removed: 1, Counted: true},
+		{Path: path("gen.go"), Kind: tools.FileCreated, Added: 2, Counted: true},
+		{Path: path("new.go"), Kind: tools.FileCreated, Added: 3, Counted: true},
+		{Path: path("removed.go"), Kind: tools.FileDeleted, Removed: 2, Counted: true},
+	}
+	if got := changes.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("list() =\n%+v\nwant\n%+v", got, want)
+	}
+}
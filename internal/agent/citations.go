@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// citationInstructions asks the model to cite the tool results its claims rest on
+const citationInstructions = `## Citing sources
+Each tool result is labeled with a source number, such as [source 3]. When a statement in your answer relies on a tool result, cite it right after the statement as [source 3], or with the lines it comes from as [source 3:10-24]. Only cite sources you were given.`
+
+// Source is a tool result that responses can cite
+type Source struct {
+	ID      int    `json:"id"` // Number the model cites it by
+	Tool    string `json:"tool"`
+	Path    string `json:"path,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// Citation is a source cited by a response, numbered as the footnote shown for it
+type Citation struct {
+	Number    int `json:"number"`
+	Source    `json:"source"`
+	StartLine int `json:"start_line,omitempty"`
+	EndLine   int `json:"end_line,omitempty"`
+}
+
+// String describes the cited source, such as "main.go:10-24 (fileRead)"
+func (c Citation) String() string {
+	var target string
+	switch {
+	case c.Path != "":
+		target = c.Path
+		if c.StartLine > 0 {
+			target += ":" + strconv.Itoa(c.StartLine)
+			if c.EndLine > c.StartLine {
+				target += "-" + strconv.Itoa(c.EndLine)
+			}
+		}
+	case c.Command != "":
+		target = "output of `" + c.Command + "`"
+	default:
+		return "result of " + c.Tool
+	}
+	return target + " (" + c.Tool + ")"
+}
+
+// citationMarker matches [source 3] and [source 3:10-24], with a space before it
+var citationMarker = regexp.MustCompile(`( ?)\[source (\d+)(?::(\d+)(?:-(\d+))?)?\]`)
+
+// addSource records a successful tool call as a source and returns its number
+func (a *agent) addSource(call *ToolCall) int {
+	source := Source{ID: len(a.sources) + 1, Tool: call.ToolName}
+	for _, key := range []string{"file_path", "path", "dir"} {
+		if v, ok := call.Params[key].(string); ok && v != "" {
+			source.Path = v
+			break
+		}
+	}
+	if v, ok := call.Params["command"].(string); ok {
+		source.Command = v
+	}
+	a.sources = append(a.sources, source)
+	return source.ID
+}
+
+// attachCitations turns the source markers in a response into numbered footnotes,
+// listed at the end, and returns the citations. Markers naming sources that don't
+// exist are dropped rather than shown as evidence.
+func attachCitations(response string, sources []Source) (string, []Citation) {
+	var citations []Citation
+	numbers := map[string]int{}
+	cited := citationMarker.ReplaceAllStringFunc(response, func(marker string) string {
+		m := citationMarker.FindStringSubmatch(marker)
+		id, _ := strconv.Atoi(m[2])
+		if id < 1 || id > len(sources) {
+			return ""
+		}
+		key := m[2] + ":" + m[3] + "-" + m[4]
+		n, ok := numbers[key]
+		if !ok {
+			c := Citation{Number: len(citations) + 1, Source: sources[id-1]}
+			c.StartLine, _ = strconv.Atoi(m[3])
+			c.EndLine, _ = strconv.Atoi(m[4])
+			citations = append(citations, c)
+			n = c.Number
+			numbers[key] = n
+		}
+		return fmt.Sprintf("%s[%d]", m[1], n)
+	})
+	if len(citations) == 0 {
+		return cited, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(cited, "\n"))
+	b.WriteString("\n\nSources:\n")
+	for _, c := range citations {
+		fmt.Fprintf(&b, "[%d] %s\n", c.Number, c)
+	}
+	return strings.TrimRight(b.String(), "\n"), citations
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttachCitations(t *testing.T) {
+	sources := []Source{
+		{ID: 1, Tool: "fileRead", Path: "internal/auth/login.go"},
+		{ID: 2, Tool: "execute", Command: "go test ./..."},
+	}
+
+	tests := []struct {
+		name      string
+		response  string
+		want      string
+		citations []Citation
+	}{
+		{
+			name:     "no markers",
+			response: "Nothing to cite.",
+			want:     "Nothing to cite.",
+		},
+		{
+			name:     "numbered in order of first use",
+			response: "The tests fail [source 2]. The redirect is never set [source 1:40-52], see also [source 2].",
+			want: "The tests fail [1]. The redirect is never set [2], see also [1].\n\n" +
+				"Sources:\n" +
+				"[1] output of `go test ./...` (execute)\n" +
+				"[2] internal/auth/login.go:40-52 (fileRead)",
+			citations: []Citation{
+				{Number: 1, Source: sources[1]},
+				{Number: 2, Source: sources[0], StartLine: 40, EndLine: 52},
+			},
+		},
+		{
+			name:     "unknown sources are dropped",
+			response: "It is fine [source 7]. Line 3 says so [source 1:3].",
+			want:     "It is fine. Line 3 says so [1].\n\nSources:\n[1] internal/auth/login.go:3 (fileRead)",
+			citations: []Citation{
+				{Number: 1, Source: sources[0], StartLine: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, citations := attachCitations(tt.response, sources)
+			if got != tt.want {
+				t.Errorf("response =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(citations, tt.citations) {
+				t.Errorf("citations = %+v, want %+v", citations, tt.citations)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// ClarifyPolicy decides which uncertain tool calls are turned into a question for the
+// user instead of running the agent's best guess. A call is uncertain when it names a
+// tool that doesn't exist but closely resembles one, or leaves out required parameters.
+type ClarifyPolicy struct {
+	Enabled bool
+	// Level is the least strict permission level whose tools are clarified: AlwaysAsk
+	// covers only tools that ask every time, NeverAsk covers every tool
+	Level tools.PermissionLevel
+}
+
+// maxToolNameDistance is how many edits away from a tool's name a misspelled name may
+// be to still count as meaning that tool
+const maxToolNameDistance = 2
+
+// checkToolCall looks for doubts about a tool call before it runs. A misspelled tool
+// name is corrected to the tool it most likely means. When the call is uncertain and
+// its tool is covered by the clarify policy, the question to ask the user is returned
+// instead, and the call should not run.
+func (a *agent) checkToolCall(call *ToolCall) string {
+	if a.toolRegistry == nil {
+		return ""
+	}
+	tool, found := a.toolRegistry.GetTool(call.ToolName)
+	if !found {
+		guess, exact := a.guessToolName(call.ToolName)
+		if guess == "" {
+			// Nothing close: the model is told the tool doesn't exist
+			return ""
+		}
+		if !exact && a.clarifies(guess) {
+			return fmt.Sprintf("I was about to use a tool called `%s`, which doesn't exist. Did you mean for me to use `%s`? Let me know and I'll go ahead.", call.ToolName, guess)
+		}
+		a.logger.Info("Correcting misspelled tool name", "requested", call.ToolName, "tool", guess)
+		call.ToolName = guess
+		tool, _ = a.toolRegistry.GetTool(guess)
+	}
+	if tool == nil || !a.clarifies(call.ToolName) {
+		return ""
+	}
+
+	schema := tool.ParameterSchema()
+	missing := missingParams(schema, tools.CoerceParams(call.Params, schema))
+	if len(missing) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Before I run `%s` I need more to go on; I don't know what to use for:\n", call.ToolName)
+	for _, name := range missing {
+		if description := schema.Properties[name].Description; description != "" {
+			fmt.Fprintf(&b, "- `%s`: %s\n", name, description)
+		} else {
+			fmt.Fprintf(&b, "- `%s`\n", name)
+		}
+	}
+	b.WriteString("\nCould you tell me?")
+	return b.String()
+}
+
+// clarifies reports whether uncertain calls to toolName are asked about
+func (a *agent) clarifies(toolName string) bool {
+	if !a.config.Clarify.Enabled {
+		return false
+	}
+	level := tools.NeverAsk
+	if a.permissionMgr != nil {
+		level = a.permissionMgr.GetDefaultPermissionLevel(toolName)
+	}
+	return level <= a.config.Clarify.Level
+}
+
+// guessToolName returns the registered tool a misspelled name most likely means, and
+// whether the names only differ in case. It returns "" when no tool is close, or when
+// two are equally close.
+func (a *agent) guessToolName(name string) (string, bool) {
+	best, bestDistance, tied := "", maxToolNameDistance+1, false
+	for _, tool := range a.toolRegistry.ListTools() {
+		candidate := tool.Name()
+		if strings.EqualFold(candidate, name) {
+			return candidate, true
+		}
+		d := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if d < bestDistance {
+			best, bestDistance, tied = candidate, d, false
+		} else if d == bestDistance {
+			tied = true
+		}
+	}
+	// Short names are too easily close to each other to guess between
+	if best == "" || tied || bestDistance*3 >= len(name) {
+		return "", false
+	}
+	return best, false
+}
+
+// missingParams returns the required parameters a call leaves out or leaves empty, sorted
+func missingParams(schema tools.JSONSchema, params map[string]interface{}) []string {
+	var missing []string
+	for _, name := range schema.Required {
+		value, ok := params[name]
+		if s, isString := value.(string); !ok || value == nil || (isString && strings.TrimSpace(s) == "") {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
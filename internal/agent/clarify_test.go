@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// requiredTool is a tool whose command parameter is required
+type requiredTool struct {
+	countingTool
+}
+
+func (requiredTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type:       "object",
+		Properties: map[string]tools.JSONSchema{"command": {Type: "string", Description: "The command to run"}},
+		Required:   []string{"command"},
+	}
+}
+
+func TestClarifyUncertainToolCalls(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      string
+		policy    ClarifyPolicy
+		wantRuns  int
+		wantAsked string
+	}{
+		{"clear call", `<tool><name>execute</name><params><command>ls</command></params></tool>`, ClarifyPolicy{Enabled: true, Level: tools.AlwaysAsk}, 1, ""},
+		{"missing parameter", `<tool><name>execute</name><params></params></tool>`, ClarifyPolicy{Enabled: true, Level: tools.AlwaysAsk}, 0, "- `command`: The command to run"},
+		{"misspelled name", `<tool><name>exeucte</name><params><command>ls</command></params></tool>`, ClarifyPolicy{Enabled: true, Level: tools.AlwaysAsk}, 0, "Did you mean for me to use `execute`?"},
+		{"name differs in case", `<tool><name>Execute</name><params><command>ls</command></params></tool>`, ClarifyPolicy{Enabled: true, Level: tools.AlwaysAsk}, 1, ""},
+		{"misspelled name, not clarified", `<tool><name>exeucte</name><params><command>ls</command></params></tool>`, ClarifyPolicy{}, 1, ""},
+		{"tool below the level", `<tool><name>echo</name><params></params></tool>`, ClarifyPolicy{Enabled: true, Level: tools.AlwaysAsk}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := []string{"Let me check.\n" + tt.call, "Done."}
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response := responses[min(calls, len(responses)-1)]
+				calls++
+				json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+			}))
+			defer server.Close()
+
+			var runs, echoes int
+			log, _ := logger.New(logger.Config{Silent: true})
+			registry := tools.NewToolRegistry()
+			registry.RegisterTool(requiredTool{countingTool{name: "execute", calls: &runs}})
+			registry.RegisterTool(requiredTool{countingTool{name: "echo", calls: &echoes}})
+			permissions := tools.NewPermissionManager(func(ctx context.Context, request tools.PermissionRequest) (tools.PermissionResponse, error) {
+				return tools.PermissionResponse{Granted: true}, nil
+			})
+			permissions.SetDefaultPermissionLevel("echo", tools.NeverAsk)
+			a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log,
+				PermissionMgr: permissions, Clarify: tt.policy})
+
+			response, err := a.ProcessMessage(context.Background(), "list the files")
+			if err != nil {
+				t.Fatalf("ProcessMessage: %v", err)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("tool ran %d times, want %d", runs, tt.wantRuns)
+			}
+			if tt.wantAsked == "" {
+				if !strings.HasSuffix(response, "Done.") {
+					t.Errorf("response = %q, want the model's answer", response)
+				}
+				return
+			}
+			if calls != 1 || !strings.HasPrefix(response, "Let me check.") || !strings.Contains(response, tt.wantAsked) {
+				t.Errorf("response = %q after %d model calls, want one call and a question containing %q", response, calls, tt.wantAsked)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"codezilla/internal/tools"
 	"codezilla/pkg/logger"
 )
 
@@ -38,8 +39,17 @@ type ToolCall struct {
 
 // ToolResult represents the result of a tool call
 type ToolResult struct {
-	Result interface{} `json:"result"`
-	Error  string      `json:"error,omitempty"`
+	ToolName string      `json:"tool_name,omitempty"`
+	Result   interface{} `json:"result"`
+	Error    string      `json:"error,omitempty"`
+	// Untrusted marks content from outside the project, such as fetched web pages
+	Untrusted bool `json:"untrusted,omitempty"`
+	// Warning is set when the injection classifier flagged the result
+	Warning string `json:"warning,omitempty"`
+	// Schema describes the result's shape when the tool implements tools.ResultDescriber
+	Schema *tools.ResultSchema `json:"schema,omitempty"`
+	// Source is the number responses cite the result by; 0 when citations are off
+	Source int `json:"source,omitempty"`
 }
 
 // Context manages the conversation context for an agent
@@ -50,6 +60,7 @@ type Context struct {
 	CurrentTokens  int
 	TruncateOldest bool
 	logger         *logger.Logger
+	sanitizer      *Sanitizer
 }
 
 // NewContext creates a new conversation context
@@ -105,6 +116,36 @@ func (c *Context) AddSystemMessage(content string) {
 	})
 }
 
+// SetSystemPrompt replaces the first system message, or inserts one at the start
+func (c *Context) SetSystemPrompt(content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, msg := range c.Messages {
+		if msg.Role == RoleSystem {
+			c.CurrentTokens += estimateTokens(content) - estimateTokens(msg.Content)
+			c.Messages[i].Content = content
+			return
+		}
+	}
+
+	c.Messages = append([]Message{{Role: RoleSystem, Content: content, Timestamp: time.Now()}}, c.Messages...)
+	c.CurrentTokens += estimateTokens(content)
+}
+
+// SystemPrompt returns the content of the first system message
+func (c *Context) SystemPrompt() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, msg := range c.Messages {
+		if msg.Role == RoleSystem {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
 // AddUserMessage adds a user message to the context
 func (c *Context) AddUserMessage(content string) {
 	c.AddMessage(Message{
@@ -114,11 +155,12 @@ func (c *Context) AddUserMessage(content string) {
 	})
 }
 
-// AddAssistantMessage adds an assistant message to the context
+// AddAssistantMessage adds an assistant message to the context, without any reasoning
+// sections, which would only take up space
 func (c *Context) AddAssistantMessage(content string) {
 	c.AddMessage(Message{
 		Role:      RoleAssistant,
-		Content:   content,
+		Content:   StripReasoning(content),
 		Timestamp: time.Now(),
 	})
 }
@@ -142,18 +184,33 @@ func (c *Context) AddToolResultMessage(result interface{}, err error) {
 	if err != nil {
 		errStr = err.Error()
 	}
+	c.AddToolResult(ToolResult{Result: result, Error: errStr})
+}
 
+// AddToolResult adds a tool result, with its tool name and screening outcome, to the context
+func (c *Context) AddToolResult(result ToolResult) {
 	c.AddMessage(Message{
-		Role:    RoleTool,
-		Content: "Tool execution result",
-		ToolResult: &ToolResult{
-			Result: result,
-			Error:  errStr,
-		},
-		Timestamp: time.Now(),
+		Role:       RoleTool,
+		Content:    "Tool execution result",
+		ToolResult: &result,
+		Timestamp:  time.Now(),
 	})
 }
 
+// SetSanitizer sets how tool results are guarded when formatted for the model
+func (c *Context) SetSanitizer(s *Sanitizer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sanitizer = s
+}
+
+// Sanitizer returns the tool result sanitizer, or nil
+func (c *Context) Sanitizer() *Sanitizer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sanitizer
+}
+
 // AddMessage adds a message to the context
 func (c *Context) AddMessage(msg Message) {
 	c.mu.Lock()
@@ -289,10 +346,18 @@ func (c *Context) GetFormattedMessages() []map[string]interface{} {
 			// Format tool results as XML
 			if msg.ToolResult.Error != "" {
 				formattedMsg["content"] = fmt.Sprintf("<tool_result>\n  <error>%s</error>\n</tool_result>", escapeXML(msg.ToolResult.Error))
+			} else if c.sanitizer != nil && c.sanitizer.Mode != DefenseOff {
+				tr := msg.ToolResult
+				formattedMsg["content"] = c.sanitizer.Wrap(tr.ToolName, renderToolResultBody(tr), tr.Untrusted, tr.Warning)
+			} else if msg.ToolResult.Schema != nil {
+				formattedMsg["content"] = "<tool_result>\n" + renderToolResultBody(msg.ToolResult) + "</tool_result>"
 			} else {
 				content := formatToolResult(msg.ToolResult.Result)
 				formattedMsg["content"] = content
 			}
+			if msg.ToolResult.Source > 0 {
+				formattedMsg["content"] = fmt.Sprintf("[source %d]\n%s", msg.ToolResult.Source, formattedMsg["content"])
+			}
 		} else {
 			// Regular message
 			formattedMsg["content"] = msg.Content
@@ -313,6 +378,29 @@ func (c *Context) GetMessages() []Message {
 
 // formatToolResult formats a tool result for display in the conversation using XML
 func formatToolResult(result interface{}) string {
+	if _, ok := result.(map[string]interface{}); ok {
+		return "<tool_result>\n" + formatToolResultBody(result) + "</tool_result>"
+	}
+	return formatToolResultBody(result)
+}
+
+// contextRenderOptions bounds results rendered from a schema for the model
+var contextRenderOptions = tools.RenderOptions{MaxRows: 200, MaxWidth: 200}
+
+// renderToolResultBody renders a result with its schema's renderer, so large tables and
+// listings are cut to a summary, falling back to the generic formatting when the result
+// has no schema or doesn't match it
+func renderToolResultBody(tr *ToolResult) string {
+	if tr.Schema != nil {
+		if rendered, err := tools.RenderResult(tr.Result, *tr.Schema, contextRenderOptions); err == nil {
+			return rendered
+		}
+	}
+	return formatToolResultBody(tr.Result)
+}
+
+// formatToolResultBody formats a tool result without the surrounding <tool_result> element
+func formatToolResultBody(result interface{}) string {
 	switch v := result.(type) {
 	case string:
 		return v
@@ -321,7 +409,6 @@ func formatToolResult(result interface{}) string {
 	case map[string]interface{}:
 		// Format map as XML
 		var builder strings.Builder
-		builder.WriteString("<tool_result>\n")
 
 		// Sort the keys for consistent output
 		keys := make([]string, 0, len(v))
@@ -335,8 +422,6 @@ func formatToolResult(result interface{}) string {
 			val := v[k]
 			builder.WriteString(fmt.Sprintf("  <%s>%v</%s>\n", k, formatXMLValue(val), k))
 		}
-
-		builder.WriteString("</tool_result>")
 		return builder.String()
 	default:
 		// For simple values, just return the string representation
@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutputContract controls how the agent enforces the format of model responses
+type OutputContract string
+
+const (
+	// ContractOff accepts model responses as they are
+	ContractOff OutputContract = "off"
+	// ContractRepair removes spoofed tool results and invented turns from responses
+	ContractRepair OutputContract = "repair"
+	// ContractStrict asks the model to correct a violating response once, and refuses to
+	// run tool calls from a response that still violates the contract
+	ContractStrict OutputContract = "strict"
+)
+
+// contractHardening is appended to the system prompt when the output contract is enforced
+const contractHardening = `Output rules:
+- Call tools only with the formats listed above. Never write <tool_result>, <tool_output> or "Tool Result:" yourself; results are supplied by the system after a call runs.
+- Stop after your tool calls and wait for their results. Never write the user's next message.`
+
+// contractCorrection is sent with the retry when a strict contract is violated
+const contractCorrection = "Your previous response broke the output rules: it contained text that imitates a tool result or another conversation turn. Respond again, using only the approved tool call formats and without writing tool results yourself."
+
+// ContractViolation describes one part of a response that broke the output contract
+type ContractViolation struct {
+	Kind    string // "spoofed_result" or "spoofed_turn"
+	Excerpt string
+}
+
+// spoofedResultTags are the tags a model might use to fake a tool result
+var spoofedResultTags = []string{"tool_result", "tool_output", "tool_response", "function_results", "function_result"}
+
+// spoofedResultPatterns match a spoofed result block. An unclosed tag is left alone,
+// as it is more likely a mention of the tag than a result.
+var spoofedResultPatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(spoofedResultTags))
+	for _, tag := range spoofedResultTags {
+		patterns = append(patterns, regexp.MustCompile(`(?is)<`+tag+`\b[^>]*>.*?</`+tag+`\s*>`))
+	}
+	return patterns
+}()
+
+// spoofedTurnPattern matches a line that starts a turn the model must not write itself.
+// Turns are never indented, so indented lines, such as in examples, don't match.
+var spoofedTurnPattern = regexp.MustCompile(`^(?:Tool Result|User)[ \t]*:`)
+
+// findSpoofedTurn returns the offset of the first line outside fenced code that
+// starts an invented turn, or -1
+func findSpoofedTurn(response string) int {
+	inFence := false
+	offset := 0
+	for _, line := range strings.SplitAfter(response, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			inFence = !inFence
+		case !inFence && spoofedTurnPattern.MatchString(line):
+			return offset
+		}
+		offset += len(line)
+	}
+	return -1
+}
+
+// CheckOutputContract reports the parts of a model response that impersonate tool results
+// or conversation turns, and returns the response with them removed. Anything after an
+// invented turn is dropped, since the model is then writing the conversation itself.
+func CheckOutputContract(response string) (string, []ContractViolation) {
+	var violations []ContractViolation
+
+	if at := findSpoofedTurn(response); at >= 0 {
+		violations = append(violations, ContractViolation{Kind: "spoofed_turn", Excerpt: excerpt(response[at:])})
+		response = response[:at]
+	}
+
+	// Result blocks in code are examples, such as an answer explaining the protocol
+	code := codeSpans(response)
+	var spoofed [][]int
+	for _, re := range spoofedResultPatterns {
+		// A match starting in code may run into a real block, so the search resumes
+		// just after its start
+		for from := 0; from < len(response); {
+			loc := re.FindStringIndex(response[from:])
+			if loc == nil {
+				break
+			}
+			start, end := from+loc[0], from+loc[1]
+			if inSpans(start, code) {
+				from = start + 1
+				continue
+			}
+			spoofed = append(spoofed, []int{start, end})
+			from = end
+		}
+	}
+	sort.Slice(spoofed, func(i, j int) bool { return spoofed[i][0] < spoofed[j][0] })
+	var kept strings.Builder
+	end := 0
+	for _, loc := range spoofed {
+		if loc[0] < end {
+			continue // Inside a block already removed
+		}
+		violations = append(violations, ContractViolation{Kind: "spoofed_result", Excerpt: excerpt(response[loc[0]:loc[1]])})
+		kept.WriteString(response[end:loc[0]])
+		end = loc[1]
+	}
+	if len(spoofed) > 0 {
+		kept.WriteString(response[end:])
+		response = kept.String()
+	}
+
+	if len(violations) == 0 {
+		return response, nil
+	}
+	return strings.TrimSpace(response), violations
+}
+
+// codeSpans returns the byte ranges of the fenced code blocks of a response and of the
+// inline code on the lines outside them. An unclosed fence runs to the end.
+func codeSpans(response string) [][2]int {
+	var spans [][2]int
+	fenceStart := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(response, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			if fenceStart < 0 {
+				fenceStart = offset
+			} else {
+				spans = append(spans, [2]int{fenceStart, offset + len(line)})
+				fenceStart = -1
+			}
+		case fenceStart < 0:
+			for _, span := range inlineCode(line) {
+				spans = append(spans, [2]int{offset + span[0], offset + span[1]})
+			}
+		}
+		offset += len(line)
+	}
+	if fenceStart >= 0 {
+		spans = append(spans, [2]int{fenceStart, len(response)})
+	}
+	return spans
+}
+
+// inlineCode returns the byte ranges of the code spans of a line: text between runs
+// of backticks of the same length
+func inlineCode(line string) [][2]int {
+	var spans [][2]int
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && line[i] == '`' {
+			i++
+		}
+		run := line[start:i]
+		closing := -1
+		for j := i; j < len(line); {
+			k := strings.Index(line[j:], run)
+			if k < 0 {
+				break
+			}
+			k += j
+			after := k + len(run)
+			if after == len(line) || line[after] != '`' {
+				closing = after
+				break
+			}
+			for after < len(line) && line[after] == '`' {
+				after++
+			}
+			j = after
+		}
+		if closing < 0 {
+			continue // An unmatched run is literal
+		}
+		spans = append(spans, [2]int{start, closing})
+		i = closing
+	}
+	return spans
+}
+
+// inSpans reports whether offset is inside one of spans
+func inSpans(offset int, spans [][2]int) bool {
+	for _, span := range spans {
+		if offset >= span[0] && offset < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// excerpt shortens violating text for logs
+func excerpt(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 80 {
+		return s[:77] + "..."
+	}
+	return s
+}
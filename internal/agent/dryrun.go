@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"codezilla/internal/tools"
+)
+
+// dryRunNote is returned to the model in place of the result of a call that wasn't run
+const dryRunNote = "Dry run: this call was not executed. Continue as if it succeeded, and say which parts of your answer depend on its outcome."
+
+// simulateTool shows a state-changing tool call and its predicted effect instead of
+// running it. The model gets a placeholder result saying the call didn't run.
+func (a *agent) simulateTool(ctx context.Context, toolName string, params map[string]interface{}) (interface{}, error) {
+	if a.toolRegistry == nil {
+		return nil, ErrToolNotFound
+	}
+	tool, found := a.toolRegistry.GetTool(toolName)
+	if !found || tool == nil {
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, toolName)
+	}
+	params = tools.CoerceParams(params, tool.ParameterSchema())
+	if err := tools.ValidateToolParams(tool, params); err != nil {
+		return nil, err
+	}
+
+	effect := tools.PredictEffect(ctx, tool, params)
+	a.logger.Info("Dry run: not executing tool", "tool", toolName, "params", params)
+
+	if a.tracing() {
+		a.tracef("\n==== DRY RUN: NOT EXECUTED ====\n")
+		a.tracef("Tool: %s\n", toolName)
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			a.tracef("  %s: %s\n", k, agentFormatXMLValue(params[k]))
+		}
+		a.tracef("Predicted effect: %s\n", effect)
+		a.tracef("===============================\n\n")
+	} else {
+		a.notef("○ %s %s (not executed: %s)", toolName, summarizeParams(params, 60), effect)
+	}
+
+	a.simulated++
+	return map[string]interface{}{
+		"executed":         false,
+		"predicted_effect": effect,
+		"note":             dryRunNote,
+	}, nil
+}
+
+// markHypothetical labels an answer that assumes the outcome of calls that weren't run
+func markHypothetical(response string, simulated int) string {
+	if simulated == 0 {
+		return response
+	}
+	calls := "1 state-changing tool call was"
+	if simulated > 1 {
+		calls = fmt.Sprintf("%d state-changing tool calls were", simulated)
+	}
+	return fmt.Sprintf("[Hypothetical — dry run: %s shown but not executed]\n\n%s", calls, response)
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// countingTool counts its executions under the given name
+type countingTool struct {
+	echoTool
+	name  string
+	calls *int
+}
+
+func (t countingTool) Name() string { return t.name }
+func (t countingTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	*t.calls++
+	return "contents", nil
+}
+
+func TestDryRun(t *testing.T) {
+	responses := []string{
+		"<tool><name>listFiles</name><params><path>.</path></params></tool>\n" +
+			"<tool><name>fileWrite</name><params><path>main.go</path></params></tool>",
+		"I wrote main.go.",
+	}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := responses[min(calls, len(responses)-1)]
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+
+	var reads, writes int
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(countingTool{name: "listFiles", calls: &reads})
+	registry.RegisterTool(countingTool{name: "fileWrite", calls: &writes})
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log})
+	a.SetDryRun(true)
+
+	response, err := a.ProcessMessage(context.Background(), "write main.go")
+	if err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if reads != 1 || writes != 0 {
+		t.Errorf("executed %d reads and %d writes, want the read only", reads, writes)
+	}
+	want := "[Hypothetical — dry run: 1 state-changing tool call was shown but not executed]\n\nI wrote main.go."
+	if response != want {
+		t.Errorf("response = %q, want %q", response, want)
+	}
+
+	var placeholder bool
+	for _, msg := range a.Messages() {
+		if msg.ToolResult != nil && strings.Contains(formatToolResult(msg.ToolResult.Result), "not executed") {
+			placeholder = true
+		}
+	}
+	if !placeholder {
+		t.Error("the model was not told the write didn't run")
+	}
+
+	// Answers without simulated calls are not marked
+	a.SetDryRun(false)
+	calls = 1
+	if response, _ := a.ProcessMessage(context.Background(), "and now?"); strings.HasPrefix(response, "[Hypothetical") {
+		t.Errorf("response marked hypothetical with dry run off: %q", response)
+	}
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// maxExplainedValueChars bounds each parameter value shown to the model when
+// explaining a call, so a file's contents don't crowd out the question
+const maxExplainedValueChars = 500
+
+// explainPrompt asks the model for a short, plain explanation of a tool call
+const explainPrompt = `You explain tool calls to a user who must approve them. In one plain-English sentence, say what the call will do and its blast radius: what it can change or break, and whether that is easy to undo. Do not repeat the command itself, give advice or use Markdown.`
+
+// ExplainToolCall asks the model for a one-sentence explanation of what a tool call
+// will do and what it may affect, to show when asking the user's permission
+func ExplainToolCall(ctx context.Context, llm tools.LLMClient, toolName, description string, params map[string]interface{}) (string, error) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "Tool: %s\n", toolName)
+	if description != "" {
+		fmt.Fprintf(&request, "Summary: %s\n", description)
+	}
+	request.WriteString("Parameters:\n")
+	for _, name := range names {
+		value, ok := params[name].(string)
+		if !ok {
+			data, _ := json.Marshal(params[name])
+			value = string(data)
+		}
+		if runes := []rune(value); len(runes) > maxExplainedValueChars {
+			value = string(runes[:maxExplainedValueChars]) + "..."
+		}
+		fmt.Fprintf(&request, "- %s: %s\n", name, value)
+	}
+
+	response, err := llm.GenerateResponse(ctx, []tools.LLMMessage{
+		{Role: "system", Content: explainPrompt},
+		{Role: "user", Content: request.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+	// Keep the first line, in case the model says more than asked
+	explanation, _, _ := strings.Cut(strings.TrimSpace(StripReasoning(response)), "\n")
+	explanation = strings.TrimSpace(explanation)
+	if explanation == "" {
+		return "", fmt.Errorf("model returned an empty explanation")
+	}
+	return explanation, nil
+}
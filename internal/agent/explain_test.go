@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplainToolCall(t *testing.T) {
+	llm := &fakeLLM{response: "<think>rm is recursive here</think>\nDeletes the build directory and everything in it, which cannot be undone.\n\nBe careful."}
+	params := map[string]interface{}{"command": "rm -rf build", "timeout": 30, "content": strings.Repeat("x", 2000)}
+
+	explanation, err := ExplainToolCall(context.Background(), llm, "execute", "Execute command: rm -rf build", params)
+	if err != nil {
+		t.Fatalf("ExplainToolCall() error = %v", err)
+	}
+	if explanation != "Deletes the build directory and everything in it, which cannot be undone." {
+		t.Errorf("explanation = %q, want the first sentence without reasoning", explanation)
+	}
+
+	request := llm.request[1].Content
+	for _, want := range []string{"Tool: execute", "- command: rm -rf build", "- timeout: 30"} {
+		if !strings.Contains(request, want) {
+			t.Errorf("request does not contain %q:\n%s", want, request)
+		}
+	}
+	if len(request) > 1000 {
+		t.Errorf("request is %d characters long, want long values shortened", len(request))
+	}
+
+	if _, err := ExplainToolCall(context.Background(), &fakeLLM{response: "  "}, "execute", "", params); err == nil {
+		t.Error("ExplainToolCall() should fail on an empty response")
+	}
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codezilla/internal/hooks"
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+func TestToolHooks(t *testing.T) {
+	dir := t.TempDir()
+	var executes, writes int
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(countingTool{name: "execute", calls: &executes})
+	registry.RegisterTool(countingTool{name: "fileWrite", calls: &writes})
+	runner := hooks.New(map[string][]string{
+		"before_tool:execute": {"echo not today; exit 1"},
+		hooks.AfterFileWrite:  {`echo "$CODEZILLA_FILE" >> formatted.txt`},
+	}, dir)
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, ToolRegistry: registry, Logger: log, Hooks: runner})
+
+	ctx := context.Background()
+	_, err := a.ExecuteTool(ctx, "execute", map[string]interface{}{"path": "ls"})
+	if !errors.Is(err, ErrToolExecutionFailed) || executes != 0 {
+		t.Errorf("blocked call: error = %v, executions = %d; want it blocked", err, executes)
+	}
+
+	if _, err := a.ExecuteTool(ctx, "fileWrite", map[string]interface{}{"file_path": "main.go"}); err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	formatted, _ := os.ReadFile(filepath.Join(dir, "formatted.txt"))
+	if writes != 1 || string(formatted) != "main.go\n" {
+		t.Errorf("writes = %d, after_file_write got %q; want main.go", writes, formatted)
+	}
+}
+
+func TestEditCheckerReportsToModel(t *testing.T) {
+	var writes int
+	var checked []string
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(countingTool{name: "fileWrite", calls: &writes})
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, ToolRegistry: registry, Logger: log,
+		EditChecker: func(ctx context.Context, files []string) string {
+			checked = append(checked, files...)
+			return "Problems found after writing; fix them before finishing:\nmain.go (go vet):\n  unreachable code"
+		},
+	})
+
+	result, err := a.ExecuteTool(context.Background(), "fileWrite", map[string]interface{}{"file_path": "main.go"})
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if len(checked) != 1 || checked[0] != "main.go" {
+		t.Errorf("checked files = %v, want [main.go]", checked)
+	}
+	if !strings.Contains(fmt.Sprint(result), "unreachable code") {
+		t.Errorf("result = %v, want the problems appended", result)
+	}
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutputLevel controls how much of its work the agent prints to stderr
+type OutputLevel string
+
+const (
+	// OutputQuiet prints nothing but warnings
+	OutputQuiet OutputLevel = "quiet"
+	// OutputNormal prints one line per tool call
+	OutputNormal OutputLevel = "normal"
+	// OutputTrace prints the prompts sent, tool parameters and results, and timings
+	OutputTrace OutputLevel = "trace"
+)
+
+// OutputLevels lists the output levels, from least to most output
+var OutputLevels = []OutputLevel{OutputQuiet, OutputNormal, OutputTrace}
+
+// traceLines is how many lines of a traced payload are shown; the rest are collapsed
+// into a count, as the full payload is in the log
+const traceLines = 12
+
+// ParseOutputLevel returns the output level named s
+func ParseOutputLevel(s string) (OutputLevel, bool) {
+	for _, level := range OutputLevels {
+		if string(level) == s {
+			return level, true
+		}
+	}
+	return "", false
+}
+
+// tracing reports whether the agent prints its work in detail
+func (a *agent) tracing() bool {
+	return a.config != nil && a.config.Output == OutputTrace
+}
+
+// stderr is where the agent prints its work
+func (a *agent) stderr() io.Writer {
+	if a.out != nil {
+		return a.out
+	}
+	return os.Stderr
+}
+
+// notef prints a line at the normal and trace levels
+func (a *agent) notef(format string, args ...interface{}) {
+	if a.config == nil || a.config.Output == OutputQuiet {
+		return
+	}
+	fmt.Fprintf(a.stderr(), format+"\n", args...)
+}
+
+// warnf prints a warning at every level
+func (a *agent) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(a.stderr(), "Warning: "+format+"\n", args...)
+}
+
+// Output returns a writer for the live output of tool calls, such as a running
+// command's; what is written is dropped at the quiet level
+func (a *agent) Output() io.Writer {
+	return liveOutput{a}
+}
+
+// liveOutput writes to the agent's stderr unless the output level is quiet. The level
+// is checked on every write, so /verbose applies to commands already running.
+type liveOutput struct {
+	a *agent
+}
+
+func (w liveOutput) Write(p []byte) (int, error) {
+	if w.a.config == nil || w.a.config.Output == OutputQuiet {
+		return len(p), nil
+	}
+	return w.a.stderr().Write(p)
+}
+
+// tracef prints at the trace level only
+func (a *agent) tracef(format string, args ...interface{}) {
+	if a.tracing() {
+		fmt.Fprintf(a.stderr(), format, args...)
+	}
+}
+
+// traceBlock prints a titled payload at the trace level, collapsed to its first
+// traceLines lines
+func (a *agent) traceBlock(title, payload string) {
+	if !a.tracing() {
+		return
+	}
+	fmt.Fprintf(a.stderr(), "\n==== %s ====\n%s\n\n", title, collapse(payload, traceLines))
+}
+
+// collapse keeps the first n lines of text, noting how many were left out
+func collapse(text string, n int) string {
+	text = strings.TrimRight(text, "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) <= n {
+		return text
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n… %d more lines (full text in the log)", len(lines)-n)
+}
+
+// callLine summarizes a finished tool call in one line, as shown at the normal level
+func callLine(toolName string, params map[string]interface{}, duration time.Duration, err error) string {
+	mark := "•"
+	if err != nil {
+		mark = "✗"
+	}
+	line := mark + " " + toolName
+	if summary := summarizeParams(params, 60); summary != "" {
+		line += " " + summary
+	}
+	if err != nil {
+		line += ": " + firstLine(err.Error())
+	}
+	return fmt.Sprintf("%s (%s)", line, formatDuration(duration))
+}
+
+// summarizeParams writes params as key=value pairs, leaving out internal ones and
+// shortening the result to width characters
+func summarizeParams(params map[string]interface{}, width int) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if !strings.HasPrefix(k, "_") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+firstLine(fmt.Sprint(params[k])))
+	}
+	summary := strings.Join(parts, " ")
+	if runes := []rune(summary); len(runes) > width {
+		summary = string(runes[:width-1]) + "…"
+	}
+	return summary
+}
+
+// firstLine returns the first line of s, marking that there were more
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + "…"
+	}
+	return s
+}
+
+// formatDuration rounds d for display, such as 12ms or 1.4s
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// lastTurn returns the newest turn of a prompt, which is what changed since the
+// previous call; the earlier turns were traced before
+func lastTurn(prompt string) string {
+	prompt = strings.TrimSuffix(strings.TrimRight(prompt, " "), "Assistant:")
+	start := 0
+	for _, marker := range []string{"\n\nUser: ", "\n\nAssistant: ", "\n\nTool Result: "} {
+		if i := strings.LastIndex(strings.TrimRight(prompt, "\n"), marker); i >= 0 && i+2 > start {
+			start = i + 2
+		}
+	}
+	return strings.TrimSpace(prompt[start:])
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputLevels(t *testing.T) {
+	tests := []struct {
+		level     OutputLevel
+		wantNote  bool
+		wantTrace bool
+	}{
+		{OutputQuiet, false, false},
+		{OutputNormal, true, false},
+		{OutputTrace, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			var out bytes.Buffer
+			a := &agent{config: &Config{Output: tt.level}, out: &out}
+			a.notef("note")
+			a.traceBlock("TITLE", "payload")
+			a.warnf("hook failed")
+			a.Output().Write([]byte("command output"))
+			if got := strings.Contains(out.String(), "note"); got != tt.wantNote {
+				t.Errorf("note printed = %v, want %v", got, tt.wantNote)
+			}
+			if got := strings.Contains(out.String(), "payload"); got != tt.wantTrace {
+				t.Errorf("trace printed = %v, want %v", got, tt.wantTrace)
+			}
+			if got := strings.Contains(out.String(), "command output"); got != tt.wantNote {
+				t.Errorf("live output printed = %v, want %v", got, tt.wantNote)
+			}
+			if !strings.Contains(out.String(), "Warning: hook failed") {
+				t.Error("warnings should be printed at every level")
+			}
+		})
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	if got := collapse("a\nb\n", 2); got != "a\nb" {
+		t.Errorf("collapse() = %q, want it unchanged", got)
+	}
+	got := collapse("a\nb\nc\nd", 2)
+	if want := "a\nb\n… 2 more lines (full text in the log)"; got != want {
+		t.Errorf("collapse() = %q, want %q", got, want)
+	}
+}
+
+func TestCallLine(t *testing.T) {
+	params := map[string]interface{}{"path": "main.go", "_internal": true}
+	if got, want := callLine("fileRead", params, 12*time.Millisecond, nil), "• fileRead path=main.go (12ms)"; got != want {
+		t.Errorf("callLine() = %q, want %q", got, want)
+	}
+	err := errors.New("no such file\nmore detail")
+	if got, want := callLine("fileRead", params, 1430*time.Millisecond, err), "✗ fileRead path=main.go: no such file… (1.4s)"; got != want {
+		t.Errorf("callLine() = %q, want %q", got, want)
+	}
+	long := map[string]interface{}{"command": strings.Repeat("x", 100)}
+	if got := summarizeParams(long, 20); len([]rune(got)) != 20 || !strings.HasSuffix(got, "…") {
+		t.Errorf("summarizeParams() = %q, want 20 characters ending in …", got)
+	}
+}
+
+func TestLastTurn(t *testing.T) {
+	prompt := "User: list files\n\nAssistant: <tool>...</tool>\n\nTool Result: a.go\nb.go\n\nAssistant: "
+	if got, want := lastTurn(prompt), "Tool Result: a.go\nb.go"; got != want {
+		t.Errorf("lastTurn() = %q, want %q", got, want)
+	}
+}
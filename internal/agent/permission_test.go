@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+func TestEditedToolCallRunsAndIsRecorded(t *testing.T) {
+	responses := []string{"<tool><name>echo</name><params><path>a.txt</path></params></tool>", "Done."}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := responses[min(calls, len(responses)-1)]
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+
+	asked := 0
+	permissions := tools.NewPermissionManager(func(ctx context.Context, request tools.PermissionRequest) (tools.PermissionResponse, error) {
+		if asked++; asked == 1 {
+			return tools.PermissionResponse{Params: map[string]interface{}{"path": "b.txt"}}, nil
+		}
+		return tools.PermissionResponse{Granted: true}, nil
+	})
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(echoTool{})
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log, PermissionMgr: permissions})
+
+	if _, err := a.ProcessMessage(context.Background(), "read a.txt"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	var call *ToolCall
+	var result *ToolResult
+	for _, msg := range a.Messages() {
+		if msg.ToolCall != nil {
+			call = msg.ToolCall
+		}
+		if msg.ToolResult != nil {
+			result = msg.ToolResult
+		}
+	}
+	if call == nil || call.Params["path"] != "b.txt" {
+		t.Errorf("recorded call = %+v, want the edited path", call)
+	}
+	if result == nil || result.Result != "b.txt" {
+		t.Errorf("result = %+v, want the tool run with the edited path", result)
+	}
+}
+
+func TestEditedWriteCountsTheFileWritten(t *testing.T) {
+	dir := t.TempDir()
+	proposed, edited := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(proposed, []byte("one\ntwo\nthree\n"), 0644)
+	os.WriteFile(edited, []byte("one\n"), 0644)
+
+	responses := []string{"<tool><name>fileWrite</name><params><file_path>" + proposed + "</file_path><content>new\n</content><skip_diff>true</skip_diff></params></tool>", "Done."}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := responses[min(calls, len(responses)-1)]
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+
+	asked := 0
+	permissions := tools.NewPermissionManager(func(ctx context.Context, request tools.PermissionRequest) (tools.PermissionResponse, error) {
+		if asked++; asked == 1 {
+			return tools.PermissionResponse{Params: map[string]interface{}{"file_path": edited, "content": "one\ntwo\n", "skip_diff": true}}, nil
+		}
+		return tools.PermissionResponse{Granted: true}, nil
+	})
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(tools.NewFileWriteTool())
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log, PermissionMgr: permissions})
+
+	if _, err := a.ProcessMessage(context.Background(), "update a.txt"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	want := []ChangedFile{{Path: edited, Kind: tools.FileModified, Added: 1, Counted: true}}
+	if got := a.ChangedFiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"codezilla/internal/tools"
+)
+
+const (
+	// maxPrefetchFiles bounds the mentioned files read ahead for one message
+	maxPrefetchFiles = 5
+	// maxPrefetchFileSize skips files too large to be worth reading speculatively
+	maxPrefetchFileSize = 256 * 1024
+	// prefetchTimeout bounds each speculative call
+	prefetchTimeout = 5 * time.Second
+)
+
+// prefetchPathPattern matches words that could be file paths, e.g. "main.go" or
+// "internal/agent/agent.go" in "see internal/agent/agent.go:120"
+var prefetchPathPattern = regexp.MustCompile(`[A-Za-z0-9_./-]*[A-Za-z0-9_]\.[A-Za-z0-9]+|[A-Za-z0-9_.-]+/[A-Za-z0-9_./-]+`)
+
+// prefetchResult is a speculative tool call, ready once done is closed
+type prefetchResult struct {
+	done   chan struct{}
+	result interface{}
+	err    error
+}
+
+// prefetcher runs cheap read-only calls the model is likely to make, such as reading
+// the files named in the user's message, while the model is still generating. A
+// matching call is then answered from the staged result instead of running again.
+type prefetcher struct {
+	mu      sync.Mutex
+	results map[string]*prefetchResult
+	wg      sync.WaitGroup
+}
+
+func newPrefetcher() *prefetcher {
+	return &prefetcher{results: make(map[string]*prefetchResult)}
+}
+
+// prefetchCalls returns the calls worth staging for message: fileRead for existing
+// files it mentions. Only read-only tools are staged, since staged calls run before
+// the permission check, hooks and dry-run mode that apply when they are served.
+func prefetchCalls(message string) []ToolCall {
+	var calls []ToolCall
+	seen := make(map[string]bool)
+	for _, word := range prefetchPathPattern.FindAllString(message, -1) {
+		path := filepath.Clean(strings.TrimRight(word, "."))
+		if seen[path] || len(seen) >= maxPrefetchFiles {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxPrefetchFileSize {
+			continue
+		}
+		seen[path] = true
+		calls = append(calls, ToolCall{ToolName: "fileRead", Params: map[string]interface{}{"file_path": path}})
+	}
+	return calls
+}
+
+// start stages calls in the background
+func (p *prefetcher) start(ctx context.Context, registry tools.ToolRegistry, calls []ToolCall) {
+	for _, call := range calls {
+		tool, ok := registry.GetTool(call.ToolName)
+		if !ok {
+			continue
+		}
+
+		key := prefetchKey(call.ToolName, call.Params)
+		r := &prefetchResult{done: make(chan struct{})}
+		p.mu.Lock()
+		p.results[key] = r
+		p.mu.Unlock()
+
+		p.wg.Add(1)
+		go func(tool tools.Tool, params map[string]interface{}) {
+			defer p.wg.Done()
+			defer close(r.done)
+			callCtx, cancel := context.WithTimeout(ctx, prefetchTimeout)
+			defer cancel()
+			r.result, r.err = tool.Execute(callCtx, params)
+		}(tool, call.Params)
+	}
+}
+
+// take returns the staged result of a call, waiting for it if it is still running.
+// Failed calls are not served, so the real call reports its own error.
+func (p *prefetcher) take(toolName string, params map[string]interface{}) (interface{}, bool) {
+	p.mu.Lock()
+	r, ok := p.results[prefetchKey(toolName, params)]
+	p.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	<-r.done
+	if r.err != nil {
+		return nil, false
+	}
+	return r.result, true
+}
+
+// reset waits for running calls and drops every staged result. It is called before
+// a call that may change state, so stale results are never served.
+func (p *prefetcher) reset() {
+	p.wg.Wait()
+	p.mu.Lock()
+	p.results = make(map[string]*prefetchResult)
+	p.mu.Unlock()
+}
+
+// prefetchKey identifies a call by its tool and parameters. Parameters are marshaled
+// with sorted keys, and file paths are cleaned so "./main.go" matches "main.go".
+func prefetchKey(toolName string, params map[string]interface{}) string {
+	normalized := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if path, ok := v.(string); ok && k == "file_path" {
+			v = filepath.Clean(path)
+		}
+		normalized[k] = v
+	}
+	data, _ := json.Marshal(normalized)
+	return toolName + " " + string(data)
+}
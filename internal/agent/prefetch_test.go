@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+func TestPrefetch(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(root+"/main.go", []byte("package main\n"), 0644)
+	os.Mkdir(root+"/.git", 0755) // Commands such as git status are never staged
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(root)
+
+	calls := prefetchCalls("the panic is in ./main.go:12, not in missing.go or e.g. this")
+	if len(calls) != 1 || calls[0].ToolName != "fileRead" || calls[0].Params["file_path"] != "main.go" {
+		t.Fatalf("prefetchCalls() = %+v, want a fileRead of main.go", calls)
+	}
+
+	responses := []string{
+		"<tool><name>fileRead</name><params><file_path>main.go</file_path></params></tool>",
+		"<tool><name>fileWrite</name><params><file_path>main.go</file_path></params></tool>",
+		"<tool><name>fileRead</name><params><file_path>main.go</file_path></params></tool>",
+		"Fixed.",
+	}
+	n := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := responses[min(n, len(responses)-1)]
+		n++
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+
+	var reads, writes int
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(countingTool{name: "fileRead", calls: &reads})
+	registry.RegisterTool(countingTool{name: "fileWrite", calls: &writes})
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log, Prefetch: true})
+
+	if _, err := a.ProcessMessage(context.Background(), "fix ./main.go"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	// The first read is served from the prefetch; the write discards it, so the second
+	// read runs again
+	if reads != 2 || writes != 1 {
+		t.Errorf("executed %d reads and %d writes, want 2 and 1", reads, writes)
+	}
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// WriteQuota limits the files the agent changes over the agent's lifetime, as a
+// safety valve against runaway edits. Zero fields are unlimited.
+type WriteQuota struct {
+	MaxFiles int   // Distinct files created, modified or deleted
+	MaxBytes int64 // Bytes written to files
+}
+
+// writeUsage is the file changes made so far
+type writeUsage struct {
+	files map[string]bool // Absolute paths of the files changed
+	bytes int64
+	// Changes already allowed by the user, on top of the quota
+	grantedFiles int
+	grantedBytes int64
+}
+
+// QuotaError is returned with a partial response when the agent has changed more
+// files than its write quota allows. Call Continue to resume it, allowing another
+// quota's worth of changes.
+type QuotaError struct {
+	Reason string
+}
+
+func (e *QuotaError) Error() string {
+	return "write quota exceeded: " + e.Reason
+}
+
+// exceeded returns why the quota doesn't allow a call that changes files, or "" when
+// it may run. The files the file tools write are known beforehand, so they are
+// counted before the call runs; what a command changes is only known afterwards, so
+// it runs only while there is room left.
+func (q WriteQuota) exceeded(u *writeUsage, toolName string, params map[string]interface{}) string {
+	if u == nil {
+		return ""
+	}
+	maxFiles, maxBytes := u.grantedFiles+q.MaxFiles, u.grantedBytes+q.MaxBytes
+	planned := tools.WrittenFiles(toolName, params)
+	if len(planned) == 0 {
+		if q.MaxFiles > 0 && len(u.files) >= maxFiles {
+			return fmt.Sprintf("%d files changed, the limit is %d", len(u.files), maxFiles)
+		}
+		if q.MaxBytes > 0 && u.bytes >= maxBytes {
+			return fmt.Sprintf("%d bytes written, the limit is %d", u.bytes, maxBytes)
+		}
+		return ""
+	}
+
+	files, bytes := len(u.files), u.bytes
+	for _, path := range planned {
+		path = absPath(path)
+		if !u.files[path] {
+			files++
+		}
+		bytes += plannedSize(toolName, params, path)
+	}
+	if q.MaxFiles > 0 && files > maxFiles {
+		return fmt.Sprintf("the call would change %d files in all, the limit is %d", files, maxFiles)
+	}
+	if q.MaxBytes > 0 && bytes > maxBytes {
+		return fmt.Sprintf("the call would write %d bytes in all, the limit is %d", bytes, maxBytes)
+	}
+	return ""
+}
+
+// plannedSize estimates the size of the file at path after a file tool writes it:
+// the content given to fileWrite, or the current size for edits
+func plannedSize(toolName string, params map[string]interface{}, path string) int64 {
+	if content, ok := params["content"].(string); ok && toolName == "fileWrite" {
+		return int64(len(content))
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// grant allows another quota's worth of changes beyond those made so far
+func (u *writeUsage) grant() {
+	u.grantedFiles = len(u.files)
+	u.grantedBytes = u.bytes
+}
+
+// record counts the files changed by a successful tool call: those the file tools
+// and renameSymbol wrote, and those found changed after a command
+func (u *writeUsage) record(toolName string, params map[string]interface{}, result interface{}) {
+	for _, path := range editedFiles(toolName, params, result) {
+		// These tools rewrite whole files
+		var size int64
+		if info, err := os.Stat(absPath(path)); err == nil {
+			size = info.Size()
+		}
+		u.add(path, size)
+	}
+	if toolName == "execute" {
+		fields, _ := result.(map[string]interface{})
+		changes, _ := fields["changed_files"].([]tools.FileChange)
+		for _, c := range changes {
+			u.add(c.Path, c.Size)
+		}
+	}
+}
+
+// add counts a change to the file at path that left size bytes in it
+func (u *writeUsage) add(path string, size int64) {
+	path = absPath(path)
+	if u.files == nil {
+		u.files = make(map[string]bool)
+	}
+	u.files[path] = true
+	u.bytes += size
+}
+
+// summary describes the changes made when the quota ran out
+func (u *writeUsage) summary(reason string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Paused before changing more files: the write quota doesn't allow it (%s).\n", reason)
+	paths := make([]string, 0, len(u.files))
+	for path := range u.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	const shown = 20
+	b.WriteString("\nFiles changed so far:\n")
+	for i, path := range paths {
+		if i == shown {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(paths)-shown)
+			break
+		}
+		b.WriteString("- " + path + "\n")
+	}
+	fmt.Fprintf(&b, "\n%d files, %d bytes written.", len(u.files), u.bytes)
+	return b.String()
+}
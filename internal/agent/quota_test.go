@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// writeTool writes its content to file_path, under the name of the file write tool
+type writeTool struct{}
+
+func (writeTool) Name() string        { return "fileWrite" }
+func (writeTool) Description() string { return "Writes a file" }
+func (writeTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{Type: "object", Properties: map[string]tools.JSONSchema{
+		"file_path": {Type: "string"},
+		"content":   {Type: "string"},
+	}}
+}
+func (writeTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path, _ := params["file_path"].(string)
+	content, _ := params["content"].(string)
+	return "written", os.WriteFile(path, []byte(content), 0644)
+}
+
+func TestWriteQuotaPausesAgent(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		path := filepath.Join(dir, "file"+strconv.Itoa(calls)+".txt")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"response": "Writing.\n<tool><name>fileWrite</name><params><file_path>" + path + "</file_path><content>hello</content></params></tool>",
+			"done":     true,
+		})
+	}))
+	defer server.Close()
+
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(writeTool{})
+	a := NewAgent(&Config{
+		Model:        "test",
+		MaxTokens:    4000,
+		OllamaURL:    server.URL,
+		ToolRegistry: registry,
+		Logger:       log,
+		WriteQuota:   WriteQuota{MaxFiles: 2},
+	})
+
+	written := func() int {
+		entries, _ := os.ReadDir(dir)
+		return len(entries)
+	}
+
+	// The call that would go over the quota waits for the user
+	response, err := a.ProcessMessage(context.Background(), "hi")
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("expected a QuotaError, got %v", err)
+	}
+	if quotaErr.Reason != "the call would change 3 files in all, the limit is 2" {
+		t.Errorf("reason = %q", quotaErr.Reason)
+	}
+	if got := written(); got != 2 {
+		t.Errorf("%d files written before pausing, want 2", got)
+	}
+	if !strings.Contains(response, "file2.txt") {
+		t.Errorf("summary should list the files changed:\n%s", response)
+	}
+
+	// Continuing allows another quota's worth of changes
+	if _, err := a.Continue(context.Background()); !errors.As(err, &quotaErr) {
+		t.Fatalf("expected Continue to stop at the quota again, got %v", err)
+	}
+	if quotaErr.Reason != "the call would change 5 files in all, the limit is 4" {
+		t.Errorf("reason = %q", quotaErr.Reason)
+	}
+	if got := written(); got != 4 {
+		t.Errorf("%d files written after continuing, want 4", got)
+	}
+}
+
+func TestWriteQuotaExceeded(t *testing.T) {
+	usage := &writeUsage{}
+	usage.record("execute", nil, map[string]interface{}{"changed_files": []tools.FileChange{
+		{Path: "a.go", Kind: tools.FileModified, Size: 600},
+		{Path: "b.go", Kind: tools.FileCreated, Size: 500},
+		{Path: "c.go", Kind: tools.FileDeleted},
+	}})
+	write := func(path, content string) map[string]interface{} {
+		return map[string]interface{}{"file_path": path, "content": content}
+	}
+
+	tests := []struct {
+		quota  WriteQuota
+		tool   string
+		params map[string]interface{}
+		want   string
+	}{
+		{WriteQuota{}, "execute", nil, ""},
+		{WriteQuota{MaxFiles: 4, MaxBytes: 1200}, "execute", nil, ""},
+		{WriteQuota{MaxFiles: 3}, "execute", nil, "3 files changed, the limit is 3"},
+		{WriteQuota{MaxBytes: 1100}, "execute", nil, "1100 bytes written, the limit is 1100"},
+		{WriteQuota{MaxFiles: 3}, "fileWrite", write("a.go", "x"), ""},
+		{WriteQuota{MaxFiles: 3}, "fileWrite", write("d.go", "x"), "the call would change 4 files in all, the limit is 3"},
+		{WriteQuota{MaxBytes: 1200}, "fileWrite", write("a.go", strings.Repeat("x", 200)), "the call would write 1300 bytes in all, the limit is 1200"},
+	}
+	for _, tt := range tests {
+		if got := tt.quota.exceeded(usage, tt.tool, tt.params); got != tt.want {
+			t.Errorf("%+v %s: exceeded = %q, want %q", tt.quota, tt.tool, got, tt.want)
+		}
+	}
+
+	usage.grant()
+	if got := (WriteQuota{MaxFiles: 2}).exceeded(usage, "execute", nil); got != "" {
+		t.Errorf("after grant: exceeded = %q, want none", got)
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"codezilla/pkg/style"
+)
+
+// leadingThink matches a reasoning section some models, such as qwen3 and deepseek-r1,
+// emit before answering
+var leadingThink = regexp.MustCompile(`(?is)^\s*<(?:think|thinking)>(.*?)</(?:think|thinking)>`)
+
+// SplitReasoning separates the reasoning sections a response opens with from the
+// answer. Only well-formed blocks at the start are taken: a stray closing tag or a
+// block left open further on is part of the answer, so none of it is dropped.
+func SplitReasoning(response string) (answer string, reasoning []string) {
+	answer = response
+	found := false
+	for {
+		loc := leadingThink.FindStringSubmatchIndex(answer)
+		if loc == nil {
+			break
+		}
+		found = true
+		if r := strings.TrimSpace(answer[loc[2]:loc[3]]); r != "" {
+			reasoning = append(reasoning, r)
+		}
+		answer = answer[loc[1]:]
+	}
+	if !found {
+		return response, nil
+	}
+	return strings.TrimSpace(answer), reasoning
+}
+
+// StripReasoning returns a response without its reasoning sections
+func StripReasoning(response string) string {
+	answer, _ := SplitReasoning(response)
+	return answer
+}
+
+// printReasoning shows the model's reasoning, dimmed when colors are on
+func printReasoning(w io.Writer, reasoning []string) {
+	text := strings.Join(reasoning, "\n\n")
+	if style.UseColors {
+		text = style.ColorCodeDim + text + style.ColorCodeReset
+	}
+	fmt.Fprintf(w, "\n==== REASONING ====\n%s\n===================\n\n", text)
+}
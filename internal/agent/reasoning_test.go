@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitReasoning(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		wantAnswer    string
+		wantReasoning []string
+	}{
+		{"no reasoning", "Hello", "Hello", nil},
+		{"think block", "<think>\nThe user wants a file.\n</think>\n\nReading it now.", "Reading it now.", []string{"The user wants a file."}},
+		{"empty block", "<think>\n\n</think>\n\nDone.", "Done.", nil},
+		{"two leading blocks", "<think>First.</think>\n<thinking>Second.</thinking>Done.", "Done.", []string{"First.", "Second."}},
+		{"stray close tag", "Check the tests first.</think>The tests pass.", "Check the tests first.</think>The tests pass.", nil},
+		{"unclosed block", "Answer.\n<thinking>still going", "Answer.\n<thinking>still going", nil},
+		{"block after the answer", "Use <think>x</think> tags.", "Use <think>x</think> tags.", nil},
+		{
+			"before a tool call",
+			"<think>I should list files</think><tool><name>listFiles</name><params></params></tool>",
+			"<tool><name>listFiles</name><params></params></tool>",
+			[]string{"I should list files"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, reasoning := SplitReasoning(tt.response)
+			if answer != tt.wantAnswer || !reflect.DeepEqual(reasoning, tt.wantReasoning) {
+				t.Errorf("SplitReasoning() = %q, %q; want %q, %q", answer, reasoning, tt.wantAnswer, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestContextExcludesReasoning(t *testing.T) {
+	plain, withReasoning := NewContext(4000), NewContext(4000)
+	plain.AddAssistantMessage("The answer is 42.")
+	withReasoning.AddAssistantMessage("<think>Let me work through this at length before answering.</think>The answer is 42.")
+	if withReasoning.CurrentTokens != plain.CurrentTokens {
+		t.Errorf("CurrentTokens = %d, want %d as without reasoning", withReasoning.CurrentTokens, plain.CurrentTokens)
+	}
+	if got := withReasoning.GetMessages()[0].Content; got != "The answer is 42." {
+		t.Errorf("Content = %q, want the answer only", got)
+	}
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reflectAfterFailures is how many failures in a row trigger a reflection step
+const reflectAfterFailures = 2
+
+// reflectionPrompt asks the model to step back before its next attempt; %s says what failed
+const reflectionPrompt = "Your recent attempts failed: %s. Before trying again, reflect briefly: what went wrong, and what different approach will you take? Answer in a few sentences and do not call any tools yet."
+
+// failureTracker counts failures in a row: per tool, and of responses breaking the output contract
+type failureTracker struct {
+	toolStreaks    map[string]int
+	lastErrors     map[string]string
+	contractStreak int
+}
+
+func newFailureTracker() *failureTracker {
+	return &failureTracker{toolStreaks: make(map[string]int), lastErrors: make(map[string]string)}
+}
+
+// recordTool records a tool call's outcome; a success resets the tool's streak
+func (f *failureTracker) recordTool(toolName string, err error) {
+	if err == nil {
+		delete(f.toolStreaks, toolName)
+		delete(f.lastErrors, toolName)
+		return
+	}
+	f.toolStreaks[toolName]++
+	f.lastErrors[toolName] = err.Error()
+}
+
+// recordContract records whether a response broke the output contract
+func (f *failureTracker) recordContract(violated bool) {
+	if violated {
+		f.contractStreak++
+	} else {
+		f.contractStreak = 0
+	}
+}
+
+// due describes the repeated failures that call for a reflection, or returns "" when
+// there are none. The streaks it reports start over, so each reflection gets a fresh try.
+func (f *failureTracker) due() string {
+	var reasons []string
+	for name, streak := range f.toolStreaks {
+		if streak >= reflectAfterFailures {
+			reasons = append(reasons, fmt.Sprintf("%s failed %d times in a row (last error: %s)",
+				name, streak, excerpt(f.lastErrors[name])))
+			delete(f.toolStreaks, name)
+		}
+	}
+	if f.contractStreak >= reflectAfterFailures {
+		reasons = append(reasons, fmt.Sprintf("%d responses in a row broke the output rules", f.contractStreak))
+		f.contractStreak = 0
+	}
+	sort.Strings(reasons)
+	return strings.Join(reasons, "; ")
+}
+
+// reflect asks the model what went wrong and adds its answer to the context, so the
+// next attempt builds on it. Tool calls in the answer are dropped.
+func (a *agent) reflect(ctx context.Context, reason string) {
+	a.logger.Info("Repeated failures, asking the model to reflect", "reason", reason)
+	response, err := a.generate(ctx, fmt.Sprintf(reflectionPrompt, reason))
+	if err != nil {
+		a.logger.Error("Failed to generate reflection", "error", err)
+		return
+	}
+	reflection := response
+	if calls := a.extractAllToolCalls(response); len(calls) > 0 {
+		reflection = calls[len(calls)-1].remainingText
+	}
+	reflection = strings.TrimSpace(reflection)
+	if reflection == "" {
+		return
+	}
+
+	a.traceBlock("REFLECTION", "Reason: "+reason+"\n"+reflection)
+	a.AddAssistantMessage("Reflection: " + reflection)
+}
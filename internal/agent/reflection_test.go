@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// failingTool always fails
+type failingTool struct{ echoTool }
+
+func (failingTool) Name() string { return "flaky" }
+func (failingTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestReflectionAfterRepeatedToolFailures(t *testing.T) {
+	var systems []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			System string `json:"system"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		systems = append(systems, req.System)
+
+		response := "Trying again.\n<tool><name>flaky</name><params><path>x</path></params></tool>"
+		switch {
+		case strings.Contains(req.System, "reflect briefly"):
+			response = "The service is down; I will read the cached file instead."
+		case len(systems) > 3:
+			response = "Done."
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+
+	log, _ := logger.New(logger.Config{Silent: true})
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(failingTool{})
+	a := NewAgent(&Config{Model: "test", MaxTokens: 4000, OllamaURL: server.URL, ToolRegistry: registry, Logger: log})
+
+	if _, err := a.ProcessMessage(context.Background(), "fetch it"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+
+	// Initial response, follow-up after the first failure, reflection after the second, follow-up
+	if len(systems) != 4 {
+		t.Fatalf("expected 4 model calls, got %d", len(systems))
+	}
+	for i, system := range systems {
+		if got, want := strings.Contains(system, "reflect briefly"), i == 2; got != want {
+			t.Errorf("call %d: reflection prompt sent = %v, want %v", i, got, want)
+		}
+	}
+	if !strings.Contains(systems[2], "flaky failed 2 times in a row (last error: tool execution failed: flaky: connection refused)") {
+		t.Errorf("reflection prompt doesn't describe the failure:\n%s", systems[2])
+	}
+
+	var reflected bool
+	for _, msg := range a.Messages() {
+		if msg.Role == "assistant" && strings.HasPrefix(msg.Content, "Reflection: The service is down") {
+			reflected = true
+		}
+	}
+	if !reflected {
+		t.Error("reflection was not added to the context")
+	}
+}
+
+func TestFailureTrackerDue(t *testing.T) {
+	f := newFailureTracker()
+	fail := errors.New("boom")
+
+	f.recordTool("fileRead", fail)
+	f.recordTool("fileRead", nil)
+	f.recordTool("fileRead", fail)
+	if got := f.due(); got != "" {
+		t.Errorf("due after interrupted failures = %q, want none", got)
+	}
+
+	f.recordTool("fileRead", fail)
+	f.recordContract(true)
+	f.recordContract(true)
+	want := "2 responses in a row broke the output rules; fileRead failed 2 times in a row (last error: boom)"
+	if got := f.due(); got != want {
+		t.Errorf("due = %q, want %q", got, want)
+	}
+	if got := f.due(); got != "" {
+		t.Errorf("due after a reflection = %q, want the streaks to start over", got)
+	}
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"codezilla/internal/tools"
+)
+
+// maxRefreshedContent bounds the file contents put back into earlier reads of an
+// edited file; reads of larger files are marked stale instead
+const maxRefreshedContent = 32 * 1024
+
+// editedFiles returns the files a successful tool call wrote: those named by the file
+// tools and those listed by an applied renameSymbol
+func editedFiles(toolName string, params map[string]interface{}, result interface{}) []string {
+	files := tools.WrittenFiles(toolName, params)
+	if toolName == "renameSymbol" {
+		fields, _ := result.(map[string]interface{})
+		if applied, _ := fields["applied"].(bool); applied {
+			renamed, _ := fields["files"].([]string)
+			files = append(files, renamed...)
+		}
+	}
+	return files
+}
+
+// refreshEditedFiles brings earlier reads of the files a tool call wrote up to date,
+// so later steps don't reason about the content before the edit
+func (a *agent) refreshEditedFiles(toolName string, params map[string]interface{}, result interface{}) {
+	for _, path := range editedFiles(toolName, params, result) {
+		content, err := os.ReadFile(absPath(path))
+		if err != nil {
+			content = nil
+		}
+		if n := a.context.RefreshFile(path, content); n > 0 {
+			a.logger.Debug("Refreshed earlier reads of edited file", "file", path, "reads", n)
+		}
+	}
+}
+
+// RefreshFile updates the results of earlier fileRead calls for the file at path
+// after it was written. They are replaced with content, or marked stale when content
+// is nil (the file is gone) or too large to repeat. It returns how many were updated.
+func (c *Context) RefreshFile(path string, content []byte) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	target := absPath(path)
+	var replacement string
+	if content != nil && len(content) <= maxRefreshedContent {
+		replacement = "(Current contents: the file was edited after this read)\n" + string(content)
+	} else {
+		replacement = fmt.Sprintf("(Stale: %s was edited after this read; read it again for its current contents)", path)
+	}
+
+	updated := 0
+	for i := 1; i < len(c.Messages); i++ {
+		call, result := c.Messages[i-1].ToolCall, c.Messages[i].ToolResult
+		if call == nil || result == nil || call.ToolName != "fileRead" || result.Error != "" {
+			continue
+		}
+		if file, _ := call.Params["file_path"].(string); file == "" || absPath(file) != target {
+			continue
+		}
+		if s, _ := result.Result.(string); s == replacement {
+			continue
+		}
+		refreshed := *result
+		refreshed.Result = replacement
+		c.CurrentTokens += estimateValueTokens(refreshed.Result) - estimateValueTokens(result.Result)
+		c.Messages[i].ToolResult = &refreshed
+		updated++
+	}
+	return updated
+}
+
+// absPath resolves path as the file tools do, expanding project root names, or
+// returns it unchanged when that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(tools.ExpandRootPath(path)); err == nil {
+		return abs
+	}
+	return path
+}
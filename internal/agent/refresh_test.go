@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextRefreshFile(t *testing.T) {
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "main.go")
+	other := filepath.Join(dir, "other.go")
+
+	c := NewContext(100000)
+	c.AddUserMessage("fix main.go")
+	c.AddToolCallMessage("fileRead", map[string]interface{}{"file_path": edited})
+	c.AddToolResult(ToolResult{ToolName: "fileRead", Result: "package main // old"})
+	c.AddToolCallMessage("fileRead", map[string]interface{}{"file_path": other})
+	c.AddToolResult(ToolResult{ToolName: "fileRead", Result: "package other"})
+	c.AddToolCallMessage("fileRead", map[string]interface{}{"file_path": filepath.Join(dir, "sub", "..", "main.go")})
+	c.AddToolResult(ToolResult{ToolName: "fileRead", Result: "package main // old"})
+
+	results := func() []string {
+		var out []string
+		for _, msg := range c.GetMessages() {
+			if msg.ToolResult != nil {
+				out = append(out, msg.ToolResult.Result.(string))
+			}
+		}
+		return out
+	}
+
+	if n := c.RefreshFile(edited, []byte("package main // new")); n != 2 {
+		t.Fatalf("RefreshFile updated %d reads, want 2", n)
+	}
+	got := results()
+	for _, i := range []int{0, 2} {
+		if !strings.HasSuffix(got[i], "package main // new") {
+			t.Errorf("read %d = %q, want the current contents", i, got[i])
+		}
+	}
+	if got[1] != "package other" {
+		t.Errorf("read of another file changed: %q", got[1])
+	}
+
+	// Refreshing with the same content changes nothing
+	if n := c.RefreshFile(edited, []byte("package main // new")); n != 0 {
+		t.Errorf("second RefreshFile updated %d reads, want 0", n)
+	}
+
+	// Deleted and large files are marked stale
+	os.Remove(edited)
+	if n := c.RefreshFile(edited, nil); n != 2 {
+		t.Fatalf("RefreshFile of a deleted file updated %d reads, want 2", n)
+	}
+	if got := results()[0]; !strings.HasPrefix(got, "(Stale: ") {
+		t.Errorf("read of deleted file = %q, want it marked stale", got)
+	}
+	c.RefreshFile(other, make([]byte, maxRefreshedContent+1))
+	if got := results()[1]; !strings.HasPrefix(got, "(Stale: ") {
+		t.Errorf("read of large file = %q, want it marked stale", got)
+	}
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BuiltinSnippets are prompt snippets available without configuration.
+// Snippets defined in the config file with the same name replace them.
+var BuiltinSnippets = map[string]string{
+	"go-style":       `Follow idiomatic Go: gofmt formatting, short lowercase package names, errors returned as the last value and wrapped with %w, table-driven tests, and doc comments on exported identifiers.`,
+	"security-focus": `Pay particular attention to security. Point out injection risks, unsafe file or command handling, secrets in code, missing input validation and overly broad permissions, and prefer safe defaults in any code you write.`,
+	"terse":          `Be terse. Answer in as few words as possible, skip pleasantries and restating the question, and show only the code that changes.`,
+}
+
+// PromptComposer assembles the system prompt from a base prompt and named snippets
+// that can be toggled at runtime
+type PromptComposer struct {
+	base     string
+	snippets map[string]string
+	active   []string
+}
+
+// NewPromptComposer creates a composer with the built-in snippets, the custom ones
+// from the config, and the given snippets enabled
+func NewPromptComposer(base string, custom map[string]string, active []string) (*PromptComposer, error) {
+	c := &PromptComposer{base: base, snippets: make(map[string]string)}
+	for name, text := range BuiltinSnippets {
+		c.snippets[name] = text
+	}
+	for name, text := range custom {
+		c.snippets[name] = text
+	}
+	for _, name := range active {
+		if err := c.Add(name); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// SetBase replaces the base prompt, such as when the session moves to another directory
+func (c *PromptComposer) SetBase(base string) {
+	c.base = base
+}
+
+// Add enables a snippet. Enabling an already active snippet is a no-op.
+func (c *PromptComposer) Add(name string) error {
+	if _, ok := c.snippets[name]; !ok {
+		return fmt.Errorf("unknown prompt snippet %q (available: %s)", name, strings.Join(c.Names(), ", "))
+	}
+	for _, a := range c.active {
+		if a == name {
+			return nil
+		}
+	}
+	c.active = append(c.active, name)
+	return nil
+}
+
+// Remove disables a snippet
+func (c *PromptComposer) Remove(name string) error {
+	for i, a := range c.active {
+		if a == name {
+			c.active = append(c.active[:i], c.active[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("prompt snippet %q is not active", name)
+}
+
+// Clear disables all snippets
+func (c *PromptComposer) Clear() {
+	c.active = nil
+}
+
+// Set replaces the active snippets, leaving them unchanged if any name is unknown
+func (c *PromptComposer) Set(names []string) error {
+	for _, name := range names {
+		if _, ok := c.snippets[name]; !ok {
+			return fmt.Errorf("unknown prompt snippet %q", name)
+		}
+	}
+	c.active = nil
+	for _, name := range names {
+		c.Add(name)
+	}
+	return nil
+}
+
+// Active returns the enabled snippets in the order they were added
+func (c *PromptComposer) Active() []string {
+	return append([]string{}, c.active...)
+}
+
+// Names returns all available snippet names, sorted
+func (c *PromptComposer) Names() []string {
+	names := make([]string, 0, len(c.snippets))
+	for name := range c.snippets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snippet returns the text of a snippet
+func (c *PromptComposer) Snippet(name string) (string, bool) {
+	text, ok := c.snippets[name]
+	return text, ok
+}
+
+// Compose returns the base prompt followed by the active snippets
+func (c *PromptComposer) Compose() string {
+	if len(c.active) == 0 {
+		return c.base
+	}
+
+	var b strings.Builder
+	b.WriteString(c.base)
+	b.WriteString("\n\n## Additional Instructions\n")
+	for _, name := range c.active {
+		fmt.Fprintf(&b, "\n### %s\n%s\n", name, strings.TrimSpace(c.snippets[name]))
+	}
+	return b.String()
+}
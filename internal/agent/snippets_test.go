@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptComposer(t *testing.T) {
+	custom := map[string]string{
+		"terse":       "Custom terse.",
+		"tests-first": "Write tests first.",
+	}
+	c, err := NewPromptComposer("Base prompt.", custom, []string{"tests-first"})
+	if err != nil {
+		t.Fatalf("NewPromptComposer() error = %v", err)
+	}
+
+	if err := c.Add("terse"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add("terse"); err != nil {
+		t.Fatalf("Add() of an active snippet error = %v", err)
+	}
+	if err := c.Add("nope"); err == nil {
+		t.Fatalf("Add() of unknown snippet should fail")
+	}
+
+	got := c.Compose()
+	if !strings.HasPrefix(got, "Base prompt.") {
+		t.Errorf("Compose() should start with the base prompt:\n%s", got)
+	}
+	first, second := strings.Index(got, "Write tests first."), strings.Index(got, "Custom terse.")
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("Compose() should list snippets in the order added, with custom text overriding built-ins:\n%s", got)
+	}
+	if strings.Count(got, "Custom terse.") != 1 {
+		t.Errorf("Compose() repeated a snippet:\n%s", got)
+	}
+
+	if err := c.Remove("tests-first"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := c.Remove("tests-first"); err == nil {
+		t.Fatalf("Remove() of an inactive snippet should fail")
+	}
+	if active := c.Active(); len(active) != 1 || active[0] != "terse" {
+		t.Errorf("Active() = %v, want [terse]", active)
+	}
+
+	if err := c.Set([]string{"go-style", "missing"}); err == nil {
+		t.Fatalf("Set() with an unknown snippet should fail")
+	}
+	if active := c.Active(); len(active) != 1 || active[0] != "terse" {
+		t.Errorf("failed Set() changed active snippets to %v", active)
+	}
+
+	c.Clear()
+	if got := c.Compose(); got != "Base prompt." {
+		t.Errorf("Compose() after Clear() = %q, want the base prompt", got)
+	}
+}
+
+func TestNewPromptComposerUnknownSnippet(t *testing.T) {
+	if _, err := NewPromptComposer("base", nil, []string{"does-not-exist"}); err == nil {
+		t.Fatalf("NewPromptComposer() should reject unknown active snippets")
+	}
+}
@@ -0,0 +1,20 @@
+package agent
+
+import "context"
+
+// attachStackTrace runs parseStackTrace on a stack trace in the user's message and adds
+// the result, with the code around the project frames, to the context
+func (a *agent) attachStackTrace(ctx context.Context, message string) {
+	tool, ok := a.toolRegistry.GetTool("parseStackTrace")
+	if !ok {
+		return
+	}
+	result, err := tool.Execute(ctx, map[string]interface{}{"trace": message})
+	if err != nil {
+		a.logger.Debug("Failed to resolve stack trace", "error", err)
+		return
+	}
+	// The trace itself is already in the user's message
+	a.context.AddToolCallMessage(tool.Name(), map[string]interface{}{"trace": "(stack trace from the user's message)"})
+	a.context.AddToolResult(a.toolResult(ctx, tool.Name(), result, nil))
+}
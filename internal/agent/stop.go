@@ -0,0 +1,30 @@
+package agent
+
+import "strings"
+
+// DefaultStopSequences end generation when the model goes on to write the next turn of
+// the transcript itself, since the prompt lays the conversation out as "User:",
+// "Assistant:" and "Tool Result:" turns
+var DefaultStopSequences = []string{"\nUser:", "\nTool Result:"}
+
+// TrimExtraTurns cuts a response at the first stop sequence, for servers or models
+// that ignore the stop option. A sequence starting with a newline also matches at
+// the very start of the response.
+func TrimExtraTurns(response string, stops []string) string {
+	cut := len(response)
+	for _, stop := range stops {
+		if stop == "" {
+			continue
+		}
+		if i := strings.Index(response, stop); i >= 0 && i < cut {
+			cut = i
+		}
+		if trimmed := strings.TrimLeft(stop, "\r\n"); trimmed != stop && strings.HasPrefix(response, trimmed) {
+			cut = 0
+		}
+	}
+	if cut == len(response) {
+		return response
+	}
+	return strings.TrimRight(response[:cut], " \t\r\n")
+}
@@ -0,0 +1,26 @@
+package agent
+
+import "testing"
+
+func TestTrimExtraTurns(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"no extra turn", "Done.\nUser names are unique.", "Done.\nUser names are unique."},
+		{"invented user turn", "Here is the fix.\n\nUser: thanks\n\nAssistant: you're welcome", "Here is the fix."},
+		{"invented tool result", "<tool>x</tool>\nTool Result: ok\nUser: hi", "<tool>x</tool>"},
+		{"turn at the start", "User: what now?", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrimExtraTurns(tt.response, DefaultStopSequences); got != tt.want {
+				t.Errorf("TrimExtraTurns() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := TrimExtraTurns("a\nUser: b", nil); got != "a\nUser: b" {
+		t.Errorf("TrimExtraTurns() without stops = %q, want the response unchanged", got)
+	}
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// maxTranscriptChars bounds the conversation text sent to the model for summarizing.
+// When the conversation is longer, the oldest messages are shortened first.
+const maxTranscriptChars = 24000
+
+// summaryPrompt instructs the model how to summarize a session
+const summaryPrompt = `You summarize coding sessions so they can be continued later from the summary alone.
+Write a concise Markdown summary with these sections:
+
+## Goal
+What the user is trying to achieve.
+
+## Decisions
+Choices made and why, as bullet points.
+
+## Changed Files
+Files created or modified and what changed in each.
+
+## Open Questions
+Anything unresolved, failing, or still to do.
+
+Be specific (names, paths, commands) and omit pleasantries. Leave a section out if it would be empty.`
+
+// Summarize asks the model for a summary of the conversation: decisions made, files changed and open questions
+func Summarize(ctx context.Context, llm tools.LLMClient, messages []Message) (string, error) {
+	transcript := buildTranscript(messages)
+	if transcript == "" {
+		return "", fmt.Errorf("nothing to summarize yet")
+	}
+
+	var request strings.Builder
+	request.WriteString("Summarize this session.\n\n")
+	if files := ChangedFiles(messages); len(files) > 0 {
+		fmt.Fprintf(&request, "Files written by tools during the session: %s\n\n", strings.Join(files, ", "))
+	}
+	request.WriteString("Transcript:\n")
+	request.WriteString(transcript)
+
+	response, err := llm.GenerateResponse(ctx, []tools.LLMMessage{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: request.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(StripReasoning(response))
+	if summary == "" {
+		return "", fmt.Errorf("model returned an empty summary")
+	}
+	return summary, nil
+}
+
+// ChangedFiles returns the files successfully written by file-editing tool calls, sorted
+func ChangedFiles(messages []Message) []string {
+	seen := make(map[string]bool)
+	for i, msg := range messages {
+		if msg.ToolCall == nil {
+			continue
+		}
+		// Skip calls whose result reported an error
+		if i+1 < len(messages) && messages[i+1].ToolResult != nil && messages[i+1].ToolResult.Error != "" {
+			continue
+		}
+
+		switch msg.ToolCall.ToolName {
+		case "fileWrite":
+			if path, ok := msg.ToolCall.Params["file_path"].(string); ok && path != "" {
+				seen[path] = true
+			}
+		case "multiEdit":
+			edits, _ := msg.ToolCall.Params["edits"].([]interface{})
+			for _, e := range edits {
+				if edit, ok := e.(map[string]interface{}); ok {
+					if path, ok := edit["file_path"].(string); ok && path != "" {
+						seen[path] = true
+					}
+				}
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for path := range seen {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// buildTranscript renders user, assistant and tool messages as plain text,
+// shortening the oldest messages first when it exceeds maxTranscriptChars
+func buildTranscript(messages []Message) string {
+	var entries []string
+	for _, msg := range messages {
+		switch {
+		case msg.ToolCall != nil:
+			entries = append(entries, fmt.Sprintf("[tool call] %s %v", msg.ToolCall.ToolName, msg.ToolCall.Params))
+		case msg.ToolResult != nil:
+			if msg.ToolResult.Error != "" {
+				entries = append(entries, "[tool error] "+msg.ToolResult.Error)
+			} else {
+				entries = append(entries, fmt.Sprintf("[tool result] %v", msg.ToolResult.Result))
+			}
+		case msg.Role == RoleUser:
+			entries = append(entries, "User: "+msg.Content)
+		case msg.Role == RoleAssistant:
+			entries = append(entries, "Assistant: "+msg.Content)
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+
+	// Shorten from the oldest message forward until the transcript fits
+	total := 0
+	for _, e := range entries {
+		total += len(e) + 2
+	}
+	const shortened = 300
+	for i := 0; i < len(entries) && total > maxTranscriptChars; i++ {
+		if len(entries[i]) > shortened {
+			total -= len(entries[i]) - shortened
+			entries[i] = entries[i][:shortened] + " ..."
+		}
+	}
+
+	return strings.Join(entries, "\n\n")
+}
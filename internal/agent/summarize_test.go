@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"codezilla/internal/tools"
+)
+
+// fakeLLM records the request and returns a canned response
+type fakeLLM struct {
+	response string
+	request  []tools.LLMMessage
+}
+
+func (f *fakeLLM) GenerateResponse(ctx context.Context, messages []tools.LLMMessage) (string, error) {
+	f.request = messages
+	return f.response, nil
+}
+
+func TestChangedFiles(t *testing.T) {
+	messages := []Message{
+		{Role: RoleUser, Content: "fix it"},
+		{Role: RoleAssistant, ToolCall: &ToolCall{ToolName: "fileWrite", Params: map[string]interface{}{"file_path": "b.go"}}},
+		{Role: RoleTool, ToolResult: &ToolResult{Result: "ok"}},
+		{Role: RoleAssistant, ToolCall: &ToolCall{ToolName: "fileWrite", Params: map[string]interface{}{"file_path": "failed.go"}}},
+		{Role: RoleTool, ToolResult: &ToolResult{Error: "permission denied"}},
+		{Role: RoleAssistant, ToolCall: &ToolCall{ToolName: "multiEdit", Params: map[string]interface{}{
+			"edits": []interface{}{
+				map[string]interface{}{"file_path": "a.go"},
+				map[string]interface{}{"file_path": "b.go"},
+			},
+		}}},
+		{Role: RoleTool, ToolResult: &ToolResult{Result: "ok"}},
+		{Role: RoleAssistant, ToolCall: &ToolCall{ToolName: "fileRead", Params: map[string]interface{}{"file_path": "c.go"}}},
+	}
+
+	got := strings.Join(ChangedFiles(messages), ",")
+	if got != "a.go,b.go" {
+		t.Fatalf("ChangedFiles() = %s, want a.go,b.go", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	llm := &fakeLLM{response: "<think>hmm</think>\n## Goal\nShip it."}
+	messages := []Message{
+		{Role: RoleSystem, Content: "system prompt"},
+		{Role: RoleUser, Content: "add a flag"},
+		{Role: RoleAssistant, ToolCall: &ToolCall{ToolName: "fileWrite", Params: map[string]interface{}{"file_path": "main.go"}}},
+		{Role: RoleTool, ToolResult: &ToolResult{Result: "ok"}},
+		{Role: RoleAssistant, Content: "Done."},
+	}
+
+	summary, err := Summarize(context.Background(), llm, messages)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if summary != "## Goal\nShip it." {
+		t.Errorf("Summarize() = %q, want think block stripped", summary)
+	}
+
+	request := llm.request[len(llm.request)-1].Content
+	for _, want := range []string{"User: add a flag", "Assistant: Done.", "Files written by tools during the session: main.go"} {
+		if !strings.Contains(request, want) {
+			t.Errorf("request missing %q:\n%s", want, request)
+		}
+	}
+	if strings.Contains(request, "system prompt") {
+		t.Errorf("request should not include the system prompt:\n%s", request)
+	}
+
+	if _, err := Summarize(context.Background(), llm, messages[:1]); err == nil {
+		t.Errorf("Summarize() with no conversation should fail")
+	}
+}
+
+func TestBuildTranscriptShortensOldestFirst(t *testing.T) {
+	long := strings.Repeat("x", maxTranscriptChars)
+	messages := []Message{
+		{Role: RoleUser, Content: long},
+		{Role: RoleAssistant, Content: "recent answer"},
+	}
+	got := buildTranscript(messages)
+	if len(got) > maxTranscriptChars {
+		t.Errorf("transcript length = %d, want at most %d", len(got), maxTranscriptChars)
+	}
+	if !strings.HasSuffix(got, "Assistant: recent answer") {
+		t.Errorf("recent messages should be kept in full")
+	}
+}
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// statsWindow is how many recent calls per tool the hints are based on
+	statsWindow = 50
+	// minCallsForHints is how many calls a tool needs before hints mention it
+	minCallsForHints = 5
+	// maxToolHints caps the hints added to the prompt
+	maxToolHints = 5
+)
+
+// toolAlternatives pairs tools with cheaper tools that answer common questions as well
+var toolAlternatives = []struct {
+	slow, fast, purpose string
+}{
+	{"projectScanAnalyzer", "listFiles", "finding files by name"},
+	{"projectScanAnalyzer", "fileRead", "reading a file you already know"},
+	{"execute", "listFiles", "listing directories"},
+	{"execute", "fileRead", "reading files"},
+}
+
+// ToolOutcome is one recorded tool call
+type ToolOutcome struct {
+	OK         bool  `json:"ok"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// ToolUsage is the call history of one tool
+type ToolUsage struct {
+	Calls    int           `json:"calls"`
+	Failures int           `json:"failures"`
+	Recent   []ToolOutcome `json:"recent"` // Last statsWindow calls, oldest first
+}
+
+// recentStats returns the failure count and mean latency of the recent calls
+func (u *ToolUsage) recentStats() (failures int, mean time.Duration) {
+	if len(u.Recent) == 0 {
+		return 0, 0
+	}
+	var total int64
+	for _, o := range u.Recent {
+		if !o.OK {
+			failures++
+		}
+		total += o.DurationMs
+	}
+	return failures, time.Duration(total/int64(len(u.Recent))) * time.Millisecond
+}
+
+// ToolStats tracks tool success rates and latencies across sessions
+type ToolStats struct {
+	mu    sync.Mutex
+	path  string
+	Tools map[string]*ToolUsage `json:"tools"`
+}
+
+// LoadToolStats reads tool stats from path, starting empty if the file doesn't exist.
+// An empty path keeps the stats in memory only.
+func LoadToolStats(path string) (*ToolStats, error) {
+	stats := &ToolStats{path: path, Tools: make(map[string]*ToolUsage)}
+	if path == "" {
+		return stats, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tool stats: %w", err)
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse tool stats %s: %w", path, err)
+	}
+	if stats.Tools == nil {
+		stats.Tools = make(map[string]*ToolUsage)
+	}
+	return stats, nil
+}
+
+// Record adds a tool call's outcome and saves the stats
+func (s *ToolStats) Record(toolName string, duration time.Duration, ok bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := s.Tools[toolName]
+	if usage == nil {
+		usage = &ToolUsage{}
+		s.Tools[toolName] = usage
+	}
+	usage.Calls++
+	if !ok {
+		usage.Failures++
+	}
+	usage.Recent = append(usage.Recent, ToolOutcome{OK: ok, DurationMs: duration.Milliseconds()})
+	if len(usage.Recent) > statsWindow {
+		usage.Recent = usage.Recent[len(usage.Recent)-statsWindow:]
+	}
+	return s.save()
+}
+
+// save writes the stats to disk; the caller holds the lock
+func (s *ToolStats) save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create tool stats directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tool stats: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tool stats: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Hints returns short guidance for the model based on how the available tools have
+// performed: tools that often fail, and slow tools with a faster alternative
+func (s *ToolStats) Hints(available []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	isAvailable := make(map[string]bool, len(available))
+	for _, name := range available {
+		isAvailable[name] = true
+	}
+
+	type failing struct {
+		name           string
+		failures, runs int
+	}
+	var failingTools []failing
+	means := make(map[string]time.Duration)
+	for name, usage := range s.Tools {
+		if !isAvailable[name] || len(usage.Recent) < minCallsForHints {
+			continue
+		}
+		failures, mean := usage.recentStats()
+		means[name] = mean
+		if failures*5 >= len(usage.Recent)*2 {
+			failingTools = append(failingTools, failing{name, failures, len(usage.Recent)})
+		}
+	}
+	sort.Slice(failingTools, func(i, j int) bool {
+		a, b := failingTools[i], failingTools[j]
+		if a.failures*b.runs != b.failures*a.runs {
+			return a.failures*b.runs > b.failures*a.runs
+		}
+		return a.name < b.name
+	})
+
+	var hints []string
+	for _, alt := range toolAlternatives {
+		slow, slowOK := means[alt.slow]
+		fast, fastOK := means[alt.fast]
+		if slowOK && fastOK && slow >= 2*time.Second && fast*4 <= slow {
+			hints = append(hints, fmt.Sprintf("Prefer %s over %s for %s (about %s vs %s per call).",
+				alt.fast, alt.slow, alt.purpose, roundDuration(fast), roundDuration(slow)))
+		}
+	}
+	for _, f := range failingTools {
+		hints = append(hints, fmt.Sprintf("%s failed in %d of its last %d calls; check its required parameters before calling it.",
+			f.name, f.failures, f.runs))
+	}
+	if len(hints) > maxToolHints {
+		hints = hints[:maxToolHints]
+	}
+	return hints
+}
+
+// FormatToolHints formats hints as a prompt section, or returns "" when there are none
+func FormatToolHints(hints []string) string {
+	if len(hints) == 0 {
+		return ""
+	}
+	return "Tool usage hints (from past sessions):\n- " + strings.Join(hints, "\n- ")
+}
+
+// roundDuration shortens a duration for display
+func roundDuration(d time.Duration) string {
+	if d >= time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToolStatsHints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats", "tool_stats.json")
+	stats, err := LoadToolStats(path)
+	if err != nil {
+		t.Fatalf("LoadToolStats failed: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		stats.Record("projectScanAnalyzer", 30*time.Second, true)
+		stats.Record("listFiles", 40*time.Millisecond, true)
+		stats.Record("fileWrite", 10*time.Millisecond, i%2 == 0)
+		stats.Record("fileRead", 5*time.Millisecond, true)
+	}
+	stats.Record("execute", time.Minute, false) // too few calls to judge
+
+	want := []string{
+		"Prefer listFiles over projectScanAnalyzer for finding files by name (about 40ms vs 30s per call).",
+		"Prefer fileRead over projectScanAnalyzer for reading a file you already know (about 5ms vs 30s per call).",
+		"fileWrite failed in 3 of its last 6 calls; check its required parameters before calling it.",
+	}
+	available := []string{"projectScanAnalyzer", "listFiles", "fileWrite", "fileRead", "execute"}
+	if got := stats.Hints(available); !reflect.DeepEqual(got, want) {
+		t.Errorf("Hints() = %q, want %q", got, want)
+	}
+
+	// Hints only mention tools that are registered
+	if got := stats.Hints([]string{"fileWrite"}); len(got) != 1 {
+		t.Errorf("expected only the fileWrite hint, got %q", got)
+	}
+
+	// Stats survive a restart
+	reloaded, err := LoadToolStats(path)
+	if err != nil {
+		t.Fatalf("reloading stats failed: %v", err)
+	}
+	if got := reloaded.Hints(available); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded Hints() = %q, want %q", got, want)
+	}
+}
+
+func TestToolStatsWindow(t *testing.T) {
+	stats, _ := LoadToolStats("")
+	for i := 0; i < statsWindow; i++ {
+		stats.Record("execute", time.Second, false)
+	}
+	for i := 0; i < statsWindow; i++ {
+		stats.Record("execute", time.Second, true)
+	}
+
+	usage := stats.Tools["execute"]
+	if usage.Calls != 2*statsWindow || usage.Failures != statsWindow || len(usage.Recent) != statsWindow {
+		t.Errorf("usage = %d calls, %d failures, %d recent", usage.Calls, usage.Failures, len(usage.Recent))
+	}
+	if hints := stats.Hints([]string{"execute"}); len(hints) != 0 {
+		t.Errorf("old failures outside the window still produce hints: %q", hints)
+	}
+}
+
+func TestRecordToolStatsSkipsCallsThatNeverRan(t *testing.T) {
+	stats, _ := LoadToolStats("")
+	a := &agent{config: &Config{ToolStats: stats}}
+
+	a.recordToolStats("nope", time.Millisecond, ErrToolNotFound)
+	a.recordToolStats("execute", time.Millisecond, errors.New("exit status 1"))
+	if _, ok := stats.Tools["nope"]; ok {
+		t.Error("unknown tool was recorded")
+	}
+	if usage := stats.Tools["execute"]; usage == nil || usage.Failures != 1 {
+		t.Errorf("failed execute call was not recorded: %+v", usage)
+	}
+}
@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"regexp"
+	"strings"
+
+	"codezilla/pkg/logger"
+)
+
+// toolCallSpan is a tool call found in a response and the bytes it occupies
+type toolCallSpan struct {
+	call       *ToolCall
+	start, end int
+}
+
+// shellFenceLanguages are code fence languages run with the execute tool
+var shellFenceLanguages = map[string]bool{
+	"bash": true, "sh": true, "shell": true, "terminal": true, "console": true,
+}
+
+// toolTagPattern matches an opening <tool> tag (not <tool_result>, <tools>, ...)
+var toolTagPattern = regexp.MustCompile(`(?i)<tool(?:\s[^<>]*)?>`)
+
+// toolCloseTagPattern matches a closing </tool> tag
+var toolCloseTagPattern = regexp.MustCompile(`(?i)</tool\s*>`)
+
+// parseNextToolCall finds the first tool call in a response. It scans the text once,
+// stepping over whole code fences, so tool markup quoted in a code sample for another
+// language is never mistaken for a call. Each candidate is decoded with the decoder for
+// its format first (encoding/json, encoding/xml), and only XML that fails to decode -
+// typically a command containing unescaped & or < - goes through the lenient parser.
+func parseNextToolCall(response string, log *logger.Logger) (toolCallSpan, bool) {
+	pos := 0
+	for pos < len(response) {
+		fenceAt := indexFrom(response, pos, "```")
+		tagAt := -1
+		if loc := toolTagPattern.FindStringIndex(response[pos:]); loc != nil {
+			tagAt = pos + loc[0]
+		}
+
+		switch {
+		case fenceAt < 0 && tagAt < 0:
+			return toolCallSpan{}, false
+
+		case fenceAt >= 0 && (tagAt < 0 || fenceAt < tagAt):
+			f, ok := readFence(response, fenceAt)
+			if !ok {
+				// An unclosed fence is still being written; nothing in it is a complete call
+				return toolCallSpan{}, false
+			}
+			if call := decodeFence(f, log); call != nil {
+				return toolCallSpan{call: call, start: fenceAt, end: f.end}, true
+			}
+			pos = f.end
+
+		default:
+			end, ok := readToolElement(response, tagAt)
+			if !ok {
+				return toolCallSpan{}, false
+			}
+			if call := decodeToolElement(response[tagAt:end], log); call != nil {
+				return toolCallSpan{call: call, start: tagAt, end: end}, true
+			}
+			log.Debug("Ignoring malformed tool element", "xml", response[tagAt:end])
+			pos = end
+		}
+	}
+	return toolCallSpan{}, false
+}
+
+// fence is a fenced code block
+type fence struct {
+	info string // Text after the opening backticks, e.g. "json" or "go title=main.go"
+	body string
+	end  int // Offset just past the closing backticks
+}
+
+// readFence reads the code fence opening at start. A fence whose info string contains the
+// closing backticks is an inline code span and is returned with an empty body.
+func readFence(s string, start int) (fence, bool) {
+	bodyStart := start + 3
+	nl := strings.IndexByte(s[bodyStart:], '\n')
+	if nl < 0 {
+		if i := strings.Index(s[bodyStart:], "```"); i >= 0 {
+			return fence{end: bodyStart + i + 3}, true
+		}
+		return fence{}, false
+	}
+	info := s[bodyStart : bodyStart+nl]
+	if i := strings.Index(info, "```"); i >= 0 {
+		return fence{end: bodyStart + i + 3}, true
+	}
+	bodyStart += nl + 1
+
+	closeAt := strings.Index(s[bodyStart:], "```")
+	if closeAt < 0 {
+		return fence{}, false
+	}
+	return fence{
+		info: strings.TrimSpace(info),
+		body: s[bodyStart : bodyStart+closeAt],
+		end:  bodyStart + closeAt + 3,
+	}, true
+}
+
+// decodeFence returns the tool call a code fence holds, if any. Fences annotated with a
+// file path (```bash title=setup.sh) are files to write, not calls.
+func decodeFence(f fence, log *logger.Logger) *ToolCall {
+	lang := strings.ToLower(f.info)
+	if strings.ContainsAny(lang, " \t:=") {
+		return nil
+	}
+	body := strings.TrimSpace(f.body)
+
+	switch {
+	case shellFenceLanguages[lang]:
+		if body == "" {
+			return nil
+		}
+		log.Debug("Found shell code block", "language", lang, "command", body)
+		return &ToolCall{ToolName: "execute", Params: map[string]interface{}{"command": body}}
+
+	case lang == "json":
+		return decodeJSONToolCall(body)
+
+	case lang == "xml":
+		if loc := toolTagPattern.FindStringIndex(body); loc != nil {
+			if end, ok := readToolElement(body, loc[0]); ok {
+				return decodeToolElement(body[loc[0]:end], log)
+			}
+			return nil
+		}
+		return decodeToolElement("<tool>"+body+"</tool>", log)
+
+	case lang == "":
+		// Models sometimes wrap a call in a bare fence
+		if strings.HasPrefix(body, "{") {
+			return decodeJSONToolCall(body)
+		}
+		if loc := toolTagPattern.FindStringIndex(body); loc != nil && loc[0] == 0 {
+			if end, ok := readToolElement(body, 0); ok {
+				return decodeToolElement(body[:end], log)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeJSONToolCall decodes {"tool": ..., "params": {...}}. "name" and "arguments" are
+// accepted in place of "tool" and "params".
+func decodeJSONToolCall(s string) *ToolCall {
+	var call struct {
+		Tool      string                 `json:"tool"`
+		Name      string                 `json:"name"`
+		Params    map[string]interface{} `json:"params"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.Unmarshal([]byte(s), &call); err != nil {
+		return nil
+	}
+	name := call.Tool
+	if name == "" {
+		name = call.Name
+	}
+	params := call.Params
+	if params == nil {
+		params = call.Arguments
+	}
+	if name == "" || params == nil {
+		return nil
+	}
+	return &ToolCall{ToolName: name, Params: params}
+}
+
+// readToolElement returns the end of the <tool> element opening at start
+func readToolElement(s string, start int) (int, bool) {
+	open := toolTagPattern.FindStringIndex(s[start:])
+	if open == nil || open[0] != 0 {
+		return 0, false
+	}
+	bodyStart := start + open[1]
+	loc := toolCloseTagPattern.FindStringIndex(s[bodyStart:])
+	if loc == nil {
+		return 0, false
+	}
+	return bodyStart + loc[1], true
+}
+
+// decodeToolElement decodes a complete <tool>...</tool> element
+func decodeToolElement(elem string, log *logger.Logger) *ToolCall {
+	var xmlCall XMLToolCall
+	if err := xml.Unmarshal([]byte(elem), &xmlCall); err == nil && strings.TrimSpace(xmlCall.Name) != "" {
+		params, err := parseXMLParams(xmlCall.Params.XMLData, log)
+		if err == nil {
+			return &ToolCall{ToolName: strings.TrimSpace(xmlCall.Name), Params: params}
+		}
+	} else if err != nil {
+		log.Debug("Tool element is not well-formed XML, using lenient parser", "error", err)
+	}
+
+	body := elem[strings.IndexByte(elem, '>')+1:]
+	body = strings.TrimSpace(body[:toolCloseTagPattern.FindStringIndex(body)[0]])
+
+	// Legacy format: a JSON object inside <tool>
+	if strings.HasPrefix(body, "{") {
+		return decodeJSONToolCall(body)
+	}
+
+	name := elementText(body, "name", false)
+	if name == "" {
+		// Legacy <n> tag
+		name = elementText(body, "n", false)
+	}
+	if name == "" {
+		return nil
+	}
+	params := make(map[string]interface{})
+	if section := elementText(body, "params", true); section != "" {
+		if parsed := extractXMLParams(section, log); parsed != nil {
+			params = parsed
+		}
+	}
+	return &ToolCall{ToolName: name, Params: params}
+}
+
+// elementText returns the trimmed text of the first element with the given name, without
+// decoding it. With last set the element runs to the last closing tag, so values that
+// contain markup don't end it early.
+func elementText(s, name string, last bool) string {
+	open := regexp.MustCompile(`<` + regexp.QuoteMeta(name) + `(?:\s[^>]*)?>`)
+	loc := open.FindStringIndex(s)
+	if loc == nil {
+		return ""
+	}
+	closing := "</" + name + ">"
+	var closeAt int
+	if last {
+		closeAt = strings.LastIndex(s, closing)
+	} else {
+		closeAt = indexFrom(s, loc[1], closing)
+	}
+	if closeAt < loc[1] {
+		return ""
+	}
+	return strings.TrimSpace(s[loc[1]:closeAt])
+}
+
+// indexFrom is strings.Index starting at offset from
+func indexFrom(s string, from int, substr string) int {
+	if i := strings.Index(s[from:], substr); i >= 0 {
+		return from + i
+	}
+	return -1
+}
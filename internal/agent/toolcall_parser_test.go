@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"codezilla/pkg/logger"
+)
+
+// corpusExpectations lists the calls expected from each model output in testdata/toolcalls
+var corpusExpectations = map[string]struct {
+	tools  []string
+	params map[string]interface{} // Params of the first call that must match
+}{
+	"think_then_xml.txt": {
+		tools:  []string{"execute"},
+		params: map[string]interface{}{"command": "go build ./... && go test ./... 2>&1 | tail -20"},
+	},
+	"fenced_xml.txt": {
+		tools:  []string{"fileRead"},
+		params: map[string]interface{}{"file_path": "internal/agent/agent.go", "start_line": 120},
+	},
+	"bare_fence_json.txt": {
+		tools:  []string{"grepSearch"},
+		params: map[string]interface{}{"pattern": "func NewAgent", "include": "*.go"},
+	},
+	"code_sample_then_call.txt": {
+		tools:  []string{"listFiles"},
+		params: map[string]interface{}{"dir": "internal/agent"},
+	},
+	"annotated_bash.txt": {},
+	"truncated.txt":      {},
+	"multiple_calls.txt": {
+		tools:  []string{"fileRead", "fileRead", "execute"},
+		params: map[string]interface{}{"file_path": "go.mod"},
+	},
+	"legacy_json_in_tool.txt": {
+		tools:  []string{"execute"},
+		params: map[string]interface{}{"command": "git status --short"},
+	},
+	"cdata_content.txt": {
+		tools: []string{"fileWrite"},
+		params: map[string]interface{}{
+			"file_path": "cmp.go",
+			"content":   "package cmp\n\nfunc Less(a, b int) bool { return a < b && a != b }",
+		},
+	},
+	"inline_code.txt": {
+		tools:  []string{"execute"},
+		params: map[string]interface{}{"command": "du -sh ."},
+	},
+	"no_params.txt": {
+		tools:  []string{"todo_analyze"},
+		params: map[string]interface{}{},
+	},
+}
+
+func readToolCallCorpus(t testing.TB) map[string]string {
+	files, err := filepath.Glob(filepath.Join("testdata", "toolcalls", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no tool call corpus found: %v", err)
+	}
+	corpus := make(map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corpus[filepath.Base(file)] = string(data)
+	}
+	return corpus
+}
+
+func TestParseToolCallCorpus(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+	a := &agent{logger: log}
+
+	for name, response := range readToolCallCorpus(t) {
+		t.Run(name, func(t *testing.T) {
+			want, ok := corpusExpectations[name]
+			if !ok {
+				t.Fatalf("no expectation for corpus file %s", name)
+			}
+
+			calls := a.extractAllToolCalls(response)
+			var got []string
+			for _, c := range calls {
+				got = append(got, c.toolCall.ToolName)
+			}
+			if strings.Join(got, ",") != strings.Join(want.tools, ",") {
+				t.Fatalf("tools = %v, want %v", got, want.tools)
+			}
+			if want.params != nil && !reflect.DeepEqual(calls[0].toolCall.Params, want.params) {
+				t.Errorf("params = %#v, want %#v", calls[0].toolCall.Params, want.params)
+			}
+		})
+	}
+}
+
+func TestReadFence(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		info  string
+		body  string
+		ok    bool
+	}{
+		{"labeled", "```json\n{}\n``` after", "json", "{}\n", true},
+		{"closing on content line", "```sh\nls```", "sh", "ls", true},
+		{"inline span", "```ls -la``` and more", "", "", true},
+		{"unclosed", "```bash\nrm -rf", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := readFence(tt.input, 0)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if f.info != tt.info || f.body != tt.body {
+				t.Errorf("fence = (%q, %q), want (%q, %q)", f.info, f.body, tt.info, tt.body)
+			}
+		})
+	}
+}
+
+func FuzzExtractAllToolCalls(f *testing.F) {
+	for _, response := range readToolCallCorpus(f) {
+		f.Add(response)
+	}
+	f.Add("<tool><tool></tool>")
+	f.Add("```\n```json\n{\"tool\":\"x\",\"params\":{}}\n```")
+	f.Add("<TOOL ><n>x</n><params><a>&</a></params></TOOL>")
+
+	log, _ := logger.New(logger.Config{Silent: true})
+	a := &agent{logger: log}
+
+	f.Fuzz(func(t *testing.T, response string) {
+		previous := response
+		for _, c := range a.extractAllToolCalls(response) {
+			if c.toolCall == nil || c.toolCall.ToolName == "" {
+				t.Fatalf("extracted a call without a tool name from %q", response)
+			}
+			if c.toolCall.Params == nil {
+				t.Fatalf("extracted %s with nil params from %q", c.toolCall.ToolName, response)
+			}
+			// Every extraction must consume input, or the agent loop would never end
+			if len(c.remainingText) >= len(previous) {
+				t.Fatalf("extraction did not shrink the response: %q", previous)
+			}
+			previous = c.remainingText
+		}
+	})
+}
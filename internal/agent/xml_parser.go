@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bytes"
 	"encoding/xml"
 	"io"
 	"strconv"
@@ -9,16 +10,24 @@ import (
 	"codezilla/pkg/logger"
 )
 
-// parseXMLParams parses parameters from XML data with support for arrays
-func parseXMLParams(xmlData []byte, logger *logger.Logger) (map[string]interface{}, error) {
-	params := make(map[string]interface{})
-
-	// Use a custom XML decoder that handles arrays
-	decoder := xml.NewDecoder(strings.NewReader(string(xmlData)))
+// xmlNode is an element read from the params of a tool call
+type xmlNode struct {
+	name     string
+	text     strings.Builder
+	children []*xmlNode
+}
 
-	// Stack to track nested elements
-	var elementStack []string
-	var currentKey string
+// parseXMLParams parses parameters from XML data. Elements without children are scalars;
+// elements with children become arrays when every child is an <item> or the children all
+// repeat one name, and objects otherwise. A name repeated at the same level is an array:
+//
+//	<excludePatterns><item>*.log</item><item>tmp/**</item></excludePatterns>
+//	<items><content>Write tests</content><priority>high</priority></items>
+//	<items><content>Fix bug</content><priority>low</priority></items>
+func parseXMLParams(xmlData []byte, logger *logger.Logger) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlData))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
 
 	for {
 		token, err := decoder.Token()
@@ -32,41 +41,84 @@ func parseXMLParams(xmlData []byte, logger *logger.Logger) (map[string]interface
 
 		switch t := token.(type) {
 		case xml.StartElement:
-			name := t.Name.Local
-			elementStack = append(elementStack, name)
-			currentKey = name
+			node := &xmlNode{name: t.Name.Local}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
 
 		case xml.EndElement:
-			if len(elementStack) > 0 {
-				elementStack = elementStack[:len(elementStack)-1]
-			}
-			if len(elementStack) > 0 {
-				currentKey = elementStack[len(elementStack)-1]
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
 			}
 
 		case xml.CharData:
-			value := strings.TrimSpace(string(t))
-			if value != "" && currentKey != "" {
-				// Convert value to appropriate type
-				var finalValue interface{} = value
-
-				// Try boolean
-				if value == "true" || value == "false" {
-					finalValue = value == "true"
-				} else if intVal, err := strconv.Atoi(value); err == nil {
-					// Try integer
-					finalValue = intVal
-				} else if floatVal, err := strconv.ParseFloat(value, 64); err == nil && strings.Contains(value, ".") {
-					// Try float (only if it contains a decimal point)
-					finalValue = floatVal
-				}
-
-				// Store the value
-				params[currentKey] = finalValue
-			}
+			stack[len(stack)-1].text.Write(t)
 		}
 	}
 
+	params := xmlObject(root.children)
 	logger.Debug("Parsed XML params", "params", params)
 	return params, nil
 }
+
+// xmlValue converts an element to a scalar, array or object
+func xmlValue(n *xmlNode) interface{} {
+	if len(n.children) == 0 {
+		return xmlScalar(strings.TrimSpace(n.text.String()))
+	}
+	if isXMLList(n.children) {
+		list := make([]interface{}, 0, len(n.children))
+		for _, c := range n.children {
+			list = append(list, xmlValue(c))
+		}
+		return list
+	}
+	return xmlObject(n.children)
+}
+
+// isXMLList reports whether children are the items of an array
+func isXMLList(children []*xmlNode) bool {
+	for _, c := range children[1:] {
+		if c.name != children[0].name {
+			return false
+		}
+	}
+	return children[0].name == "item" || len(children) > 1
+}
+
+// xmlObject converts elements to an object, collecting repeated names into arrays
+func xmlObject(children []*xmlNode) map[string]interface{} {
+	obj := make(map[string]interface{})
+	repeated := make(map[string]bool)
+	for _, c := range children {
+		value := xmlValue(c)
+		existing, seen := obj[c.name]
+		switch {
+		case !seen:
+			obj[c.name] = value
+		case repeated[c.name]:
+			obj[c.name] = append(existing.([]interface{}), value)
+		default:
+			obj[c.name] = []interface{}{existing, value}
+			repeated[c.name] = true
+		}
+	}
+	return obj
+}
+
+// xmlScalar infers the type of an element's text. Only text that converts back
+// unchanged is typed, so "1.10" or "007" stay strings.
+func xmlScalar(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	if i, err := strconv.Atoi(value); err == nil && strconv.Itoa(i) == value {
+		return i
+	}
+	if strings.Contains(value, ".") {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == value {
+			return f
+		}
+	}
+	return value
+}
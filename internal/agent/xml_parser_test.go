@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"codezilla/pkg/logger"
+)
+
+func TestParseXMLParamsNested(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+
+	tests := []struct {
+		name string
+		xml  string
+		want map[string]interface{}
+	}{
+		{
+			name: "scalars",
+			xml:  "<path>a.go</path><limit>20</limit><ratio>0.5</ratio><recursive>true</recursive><version>1.10</version><empty></empty>",
+			want: map[string]interface{}{"path": "a.go", "limit": 20, "ratio": 0.5, "recursive": true, "version": "1.10", "empty": ""},
+		},
+		{
+			name: "item list",
+			xml:  "<excludePatterns>\n  <item>*.log</item>\n  <item>tmp/**</item>\n</excludePatterns>",
+			want: map[string]interface{}{"excludePatterns": []interface{}{"*.log", "tmp/**"}},
+		},
+		{
+			name: "single item",
+			xml:  "<names><item>HOME</item></names>",
+			want: map[string]interface{}{"names": []interface{}{"HOME"}},
+		},
+		{
+			name: "repeated children",
+			xml:  "<packages><package>cobra</package><package>viper</package></packages>",
+			want: map[string]interface{}{"packages": []interface{}{"cobra", "viper"}},
+		},
+		{
+			name: "repeated parameter of objects",
+			xml:  "<items><content>Design</content><priority>high</priority></items><items><content>Build</content><dependencies><item>task_1</item></dependencies></items>",
+			want: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"content": "Design", "priority": "high"},
+				map[string]interface{}{"content": "Build", "dependencies": []interface{}{"task_1"}},
+			}},
+		},
+		{
+			name: "object",
+			xml:  "<options><depth>2</depth><follow>false</follow></options>",
+			want: map[string]interface{}{"options": map[string]interface{}{"depth": 2, "follow": false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseXMLParams([]byte(tt.xml), log)
+			if err != nil {
+				t.Fatalf("parseXMLParams failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package analytics keeps opt-in usage counts (sessions, requests and their outcomes,
+// tools and models used) in a local file, for the user's own /dashboard. Nothing is
+// ever sent anywhere, and no prompts, answers or file contents are recorded.
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Request outcomes
+const (
+	Succeeded = "succeeded"
+	Failed    = "failed"  // The request ended with an error
+	Aborted   = "aborted" // The user stopped the request, or declined to continue it
+)
+
+// DefaultRetentionDays is how long daily counts are kept
+const DefaultRetentionDays = 90
+
+// dayFormat keys the daily counts
+const dayFormat = "2006-01-02"
+
+// Day holds the counts of one day
+type Day struct {
+	Sessions     int            `json:"sessions"`
+	Requests     map[string]int `json:"requests,omitempty"` // By outcome
+	Tools        map[string]int `json:"tools,omitempty"`
+	ToolFailures map[string]int `json:"tool_failures,omitempty"`
+	Models       map[string]int `json:"models,omitempty"` // Requests by model
+}
+
+// Store holds daily usage counts and saves them to a local file
+type Store struct {
+	mu        sync.Mutex
+	path      string
+	retention int
+	Days      map[string]*Day `json:"days"`
+}
+
+// Open reads the counts in path, starting empty if the file doesn't exist. Days older
+// than retentionDays (DefaultRetentionDays when 0) are dropped on the next save. An
+// empty path keeps the counts in memory only.
+func Open(path string, retentionDays int) (*Store, error) {
+	if retentionDays <= 0 {
+		retentionDays = DefaultRetentionDays
+	}
+	s := &Store{path: path, retention: retentionDays, Days: make(map[string]*Day)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage analytics: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse usage analytics %s: %w", path, err)
+	}
+	if s.Days == nil {
+		s.Days = make(map[string]*Day)
+	}
+	return s, nil
+}
+
+// RecordSession counts an interactive session started at now. A nil store records nothing.
+func (s *Store) RecordSession(now time.Time) error {
+	return s.record(now, func(d *Day) { d.Sessions++ })
+}
+
+// RecordRequest counts a request answered by model with its outcome
+func (s *Store) RecordRequest(now time.Time, model, outcome string) error {
+	return s.record(now, func(d *Day) {
+		d.Requests = increment(d.Requests, outcome)
+		if model != "" {
+			d.Models = increment(d.Models, model)
+		}
+	})
+}
+
+// RecordTool counts a tool call and whether it failed
+func (s *Store) RecordTool(now time.Time, tool string, ok bool) error {
+	return s.record(now, func(d *Day) {
+		d.Tools = increment(d.Tools, tool)
+		if !ok {
+			d.ToolFailures = increment(d.ToolFailures, tool)
+		}
+	})
+}
+
+// increment adds one to a count, creating the map when needed
+func increment(counts map[string]int, key string) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[key]++
+	return counts
+}
+
+// record updates the counts of now's day and saves them
+func (s *Store) record(now time.Time, update func(*Day)) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := now.Format(dayFormat)
+	day := s.Days[key]
+	if day == nil {
+		day = &Day{}
+		s.Days[key] = day
+	}
+	update(day)
+	s.prune(now)
+	return s.save()
+}
+
+// prune drops days past the retention period; the caller holds the lock
+func (s *Store) prune(now time.Time) {
+	cutoff := now.AddDate(0, 0, -s.retention).Format(dayFormat)
+	for key := range s.Days {
+		if key < cutoff {
+			delete(s.Days, key)
+		}
+	}
+}
+
+// save writes the counts to disk; the caller holds the lock
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage analytics directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage analytics: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write usage analytics: %w", err)
+	}
+	return nil
+}
+
+// Summary is the usage over a range of days
+type Summary struct {
+	From, To     time.Time
+	Days         []DayCount // Every day in the range, oldest first
+	Sessions     int
+	Requests     map[string]int // By outcome
+	Tools        []Count        // Most used first
+	ToolFailures map[string]int
+	Models       []Count // Most used first
+}
+
+// DayCount is the sessions and requests of one day
+type DayCount struct {
+	Date     time.Time
+	Sessions int
+	Requests int
+}
+
+// Count is how often a tool or model was used
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Summarize totals the last days days up to now
+func (s *Store) Summarize(now time.Time, days int) Summary {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	sum := Summary{From: to.AddDate(0, 0, -(days - 1)), To: to, Requests: make(map[string]int), ToolFailures: make(map[string]int)}
+	if s == nil {
+		return sum
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tools, models := make(map[string]int), make(map[string]int)
+	for date := sum.From; !date.After(to); date = date.AddDate(0, 0, 1) {
+		count := DayCount{Date: date}
+		if day := s.Days[date.Format(dayFormat)]; day != nil {
+			count.Sessions = day.Sessions
+			for outcome, n := range day.Requests {
+				count.Requests += n
+				sum.Requests[outcome] += n
+			}
+			for name, n := range day.Tools {
+				tools[name] += n
+			}
+			for name, n := range day.ToolFailures {
+				sum.ToolFailures[name] += n
+			}
+			for name, n := range day.Models {
+				models[name] += n
+			}
+			sum.Sessions += day.Sessions
+		}
+		sum.Days = append(sum.Days, count)
+	}
+	sum.Tools = sortedCounts(tools)
+	sum.Models = sortedCounts(models)
+	return sum
+}
+
+// sortedCounts returns counts most used first, then by name
+func sortedCounts(counts map[string]int) []Count {
+	list := make([]Count, 0, len(counts))
+	for name, n := range counts {
+		list = append(list, Count{Name: name, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// TotalRequests returns the number of requests of every outcome
+func (s Summary) TotalRequests() int {
+	total := 0
+	for _, n := range s.Requests {
+		total += n
+	}
+	return total
+}
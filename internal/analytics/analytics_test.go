@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreRecordsAndSummarizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analytics.json")
+	s, err := Open(path, 30)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 10, 0, 0, 0, time.UTC) }
+	s.RecordSession(day(1))
+	s.RecordRequest(day(1), "qwen", Succeeded)
+	s.RecordRequest(day(1), "qwen", Failed)
+	s.RecordTool(day(1), "fileRead", true)
+	s.RecordTool(day(1), "execute", false)
+	s.RecordSession(day(3))
+	s.RecordRequest(day(3), "llama", Aborted)
+	s.RecordTool(day(3), "execute", true)
+
+	// Counts survive a restart
+	s, err = Open(path, 30)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	sum := s.Summarize(day(3), 3)
+	if sum.Sessions != 2 || sum.TotalRequests() != 3 || sum.Requests[Aborted] != 1 {
+		t.Errorf("summary = %+v", sum)
+	}
+	var perDay []int
+	for _, d := range sum.Days {
+		perDay = append(perDay, d.Requests)
+	}
+	if !reflect.DeepEqual(perDay, []int{2, 0, 1}) {
+		t.Errorf("requests per day = %v, want [2 0 1]", perDay)
+	}
+	if want := []Count{{"execute", 2}, {"fileRead", 1}}; !reflect.DeepEqual(sum.Tools, want) {
+		t.Errorf("tools = %v, want %v", sum.Tools, want)
+	}
+	if sum.ToolFailures["execute"] != 1 || len(sum.Models) != 2 {
+		t.Errorf("tool failures = %v, models = %v", sum.ToolFailures, sum.Models)
+	}
+
+	// Old days are dropped on the next save
+	s.RecordSession(day(1).AddDate(0, 2, 0))
+	if _, ok := s.Days["2026-03-01"]; ok {
+		t.Error("days past retention were kept")
+	}
+}
+
+func TestFormatDashboard(t *testing.T) {
+	s, _ := Open("", 0)
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		s.RecordRequest(now, "qwen", Succeeded)
+	}
+	s.RecordSession(now)
+	s.RecordRequest(now.AddDate(0, 0, -1), "qwen", Failed)
+	s.RecordTool(now, "execute", false)
+	s.RecordTool(now, "execute", true)
+
+	out := FormatDashboard(s.Summarize(now, 2))
+	for _, want := range []string{
+		"1 sessions, 5 requests",
+		"Mon Mar 02 " + strings.Repeat("█", barWidth) + " 4 (1 sessions)",
+		"succeeded  " + strings.Repeat("█", 24) + strings.Repeat(" ", 6) + " 4 (80%)",
+		"execute " + strings.Repeat("█", barWidth) + " 2, 50% failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dashboard is missing %q:\n%s", want, out)
+		}
+	}
+	if out := FormatDashboard((*Store)(nil).Summarize(now, 7)); !strings.Contains(out, "Nothing recorded") {
+		t.Errorf("empty dashboard = %q", out)
+	}
+}
+
+func TestBar(t *testing.T) {
+	tests := []struct {
+		n, most int
+		want    string
+	}{
+		{0, 0, ""},
+		{0, 10, ""},
+		{1, 1000, "▏"},
+		{5, 10, strings.Repeat("█", 15)},
+		{1, 16, "█▉"},
+	}
+	for _, tt := range tests {
+		if got := strings.TrimRight(bar(tt.n, tt.most), " "); got != tt.want {
+			t.Errorf("bar(%d, %d) = %q, want %q", tt.n, tt.most, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// barWidth is the width of the longest bar in a chart
+	barWidth = 30
+	// maxChartRows caps the tools and models charted
+	maxChartRows = 10
+)
+
+// barEighths draws the fractional end of a bar in eighths of a cell
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// FormatDashboard renders a summary as terminal charts: requests per day, request
+// outcomes, and the tools and models used most
+func FormatDashboard(sum Summary) string {
+	var b strings.Builder
+	total := sum.TotalRequests()
+	fmt.Fprintf(&b, "📊 Usage from %s to %s\n", sum.From.Format("Jan 2"), sum.To.Format("Jan 2, 2006"))
+	fmt.Fprintf(&b, "%d sessions, %d requests\n", sum.Sessions, total)
+	if total == 0 && sum.Sessions == 0 {
+		b.WriteString("\nNothing recorded in this period yet.\n")
+		return b.String()
+	}
+
+	b.WriteString("\nRequests per day\n")
+	most := 0
+	for _, d := range sum.Days {
+		most = max(most, d.Requests)
+	}
+	for _, d := range sum.Days {
+		fmt.Fprintf(&b, "  %s %s %s\n", d.Date.Format("Mon Jan 02"), bar(d.Requests, most), dayLabel(d))
+	}
+
+	if total > 0 {
+		b.WriteString("\nOutcomes\n")
+		for _, outcome := range []string{Succeeded, Failed, Aborted} {
+			n := sum.Requests[outcome]
+			fmt.Fprintf(&b, "  %-10s %s %d (%.0f%%)\n", outcome, bar(n, total), n, 100*float64(n)/float64(total))
+		}
+	}
+
+	if len(sum.Tools) > 0 {
+		b.WriteString("\nTools\n")
+		b.WriteString(countChart(sum.Tools, func(c Count) string {
+			if failed := sum.ToolFailures[c.Name]; failed > 0 {
+				return fmt.Sprintf("%d, %.0f%% failed", c.Count, 100*float64(failed)/float64(c.Count))
+			}
+			return fmt.Sprint(c.Count)
+		}))
+	}
+	if len(sum.Models) > 0 {
+		b.WriteString("\nModels\n")
+		b.WriteString(countChart(sum.Models, func(c Count) string { return fmt.Sprint(c.Count) }))
+	}
+	return b.String()
+}
+
+// dayLabel describes a day's activity next to its bar
+func dayLabel(d DayCount) string {
+	if d.Requests == 0 && d.Sessions == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d (%d sessions)", d.Requests, d.Sessions)
+}
+
+// countChart charts the most used entries of a list sorted by count
+func countChart(counts []Count, label func(Count) string) string {
+	var b strings.Builder
+	width := 0
+	for i, c := range counts {
+		if i == maxChartRows {
+			break
+		}
+		width = max(width, len(c.Name))
+	}
+	for i, c := range counts {
+		if i == maxChartRows {
+			fmt.Fprintf(&b, "  … and %d more\n", len(counts)-maxChartRows)
+			break
+		}
+		fmt.Fprintf(&b, "  %-*s %s %s\n", width, c.Name, bar(c.Count, counts[0].Count), label(c))
+	}
+	return b.String()
+}
+
+// bar draws n as a bar scaled so that most fills barWidth cells, padded to barWidth
+func bar(n, most int) string {
+	if most <= 0 {
+		return strings.Repeat(" ", barWidth)
+	}
+	eighths := n * barWidth * 8 / most
+	if n > 0 && eighths == 0 {
+		eighths = 1
+	}
+	full, part := eighths/8, eighths%8
+	s := strings.Repeat("█", full) + barEighths[part]
+	cells := full
+	if part > 0 {
+		cells++
+	}
+	return s + strings.Repeat(" ", barWidth-cells)
+}
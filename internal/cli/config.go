@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"codezilla/internal/i18n"
+	"codezilla/internal/secrets"
 )
 
 // Config holds the application configuration
@@ -16,12 +20,94 @@ type Config struct {
 	MaxTokens    int     `json:"max_tokens"`
 	SystemPrompt string  `json:"system_prompt"`
 
-	// Authentication
+	// ModelProfiles hold per-model settings by model name, such as request options
+	ModelProfiles map[string]ModelProfile `json:"model_profiles,omitempty"`
+
+	// Language of menus, messages and the default system prompt, which asks the model to
+	// answer in it: a code such as "de", or "auto" to follow LANG. English by default.
+	Language string `json:"language"`
+
+	// Prompt snippets appended to the system prompt
+	PromptSnippets map[string]string   `json:"prompt_snippets,omitempty"` // Custom snippets by name (override built-ins)
+	PromptProfiles map[string][]string `json:"prompt_profiles,omitempty"` // Named sets of snippets
+	PromptProfile  string              `json:"prompt_profile,omitempty"`  // Profile enabled at startup
+	ActiveSnippets []string            `json:"active_snippets,omitempty"` // Snippets enabled at startup in addition to the profile
+
+	// Prompts of the projectScanAnalyzer tool
+	AnalysisProfiles       map[string]string `json:"analysis_profiles,omitempty"`        // Custom analysis rubrics by name (override built-ins)
+	AnalysisPromptTemplate string            `json:"analysis_prompt_template,omitempty"` // Replaces the built-in file analysis prompt
+
+	// ApplyMode controls code blocks annotated with a file path: "ask" offers to apply them after each answer, "off" only on /apply
+	ApplyMode string `json:"apply_mode"`
+
+	// ShadowMode is how /shadow copies the project: "auto" uses a git worktree in git
+	// repositories and a plain copy elsewhere, "worktree" or "copy" force one
+	ShadowMode string `json:"shadow_mode"`
+
+	// TaskBranches gives each task its own git branch: "off", "branch" creates it in the
+	// working tree, "worktree" checks it out in a new worktree beside the repository
+	TaskBranches string `json:"task_branches"`
+
+	// ExecutePTY controls pseudo-terminal execution: "off", "allow" lets commands request a PTY,
+	// "takeover" also forwards the user's keystrokes to the command
+	ExecutePTY string `json:"execute_pty"`
+	// ExecuteTrackChanges finds the files each command changes: "off", "git" compares git
+	// status before and after, "mtime" compares file modification times, "auto" uses git
+	// in git repositories and mtime elsewhere
+	ExecuteTrackChanges string `json:"execute_track_changes"`
+
+	// InjectionDefense guards tool results before they re-enter the prompt: "off", "delimit"
+	// wraps them in data blocks and screens external content, "strict" screens every result
+	InjectionDefense string `json:"injection_defense"`
+	// InjectionClassifier screens tool results for prompt injection: "off", "heuristic" or "model"
+	InjectionClassifier string `json:"injection_classifier"`
+	// OutputContract checks that model responses don't impersonate tool results: "off", "repair"
+	// strips spoofed results, "strict" also asks the model to retry and refuses its tool calls
+	OutputContract string `json:"output_contract"`
+	// StopSequences end generation when the model writes one, so it can't go on to
+	// invent the user's next message; invented turns are also cut from responses
+	StopSequences []string `json:"stop_sequences"`
+
+	// DisallowedLicenses are flagged by the licenseInventory tool, e.g. ["GPL-3.0", "AGPL-3.0"]
+	DisallowedLicenses []string `json:"disallowed_licenses,omitempty"`
+
+	// Prefetch reads files named in a message and runs git status while the model
+	// generates, so those tool calls are answered without waiting
+	Prefetch bool `json:"prefetch"`
+
+	// EditChecks runs the project's formatter on files the assistant writes and, with
+	// "lint", a quick lint, feeding problems back to the model: "off", "format" or "lint"
+	EditChecks string `json:"edit_checks"`
+
+	// PrioritizeChangedFiles puts files changed in the git working tree or on the
+	// current branch first in project scans and search results
+	PrioritizeChangedFiles bool `json:"prioritize_changed_files"`
+
+	// LanguageGuidance appends conventions for the project's detected languages and its
+	// task runner targets to the system prompt
+	LanguageGuidance bool `json:"language_guidance"`
+
+	// Authentication. Values of the form "secret:<name>" are read from the secrets backend.
 	OllamaAPIKey   string            `json:"ollama_api_key,omitempty"`
 	OllamaAuthType string            `json:"ollama_auth_type,omitempty"` // "bearer", "basic", or "custom"
 	OllamaUsername string            `json:"ollama_username,omitempty"`
 	OllamaPassword string            `json:"ollama_password,omitempty"`
 	OllamaHeaders  map[string]string `json:"ollama_headers,omitempty"`
+	SecretsBackend string            `json:"secrets_backend,omitempty"` // auto, keychain, libsecret, wincred, file or none
+
+	// TLS verification of an HTTPS ollama_url: a PEM file of extra CA certificates to
+	// trust, or skipping verification altogether (testing only)
+	OllamaCACert             string `json:"ollama_ca_cert,omitempty"`
+	OllamaInsecureSkipVerify bool   `json:"ollama_insecure_skip_verify,omitempty"`
+
+	// OllamaURLs are more Ollama servers to spread requests over along with
+	// ollama_url. OllamaBalance is "round_robin" to take turns among the healthy ones,
+	// or "model" to take turns among those that have the requested model.
+	OllamaURLs    []string `json:"ollama_urls,omitempty"`
+	OllamaBalance string   `json:"ollama_balance,omitempty"`
+
+	// OllamaConnections tunes the HTTP connections kept open to Ollama
+	OllamaConnections ConnectionSettings `json:"ollama_connections"`
 
 	// Log configuration
 	LogFile   string `json:"log_file"`
@@ -33,20 +119,129 @@ type Config struct {
 	MaxContextChars int    `json:"max_context_chars"`
 	HistoryFile     string `json:"history_file"`
 
+	// Input history
+	HistoryMaxEntries int  `json:"history_max_entries"`
+	HistoryPerProject bool `json:"history_per_project"` // Keep a separate history per working directory
+
+	// Session persistence
+	PersistSessions bool   `json:"persist_sessions"`
+	SessionsDir     string `json:"sessions_dir"`
+	TitleModel      string `json:"title_model,omitempty"` // Model used to name sessions (defaults to default_model)
+	// ResumeRefreshFiles gives the model the current contents of files a resumed
+	// session refers to that changed since it was saved
+	ResumeRefreshFiles bool `json:"resume_refresh_files"`
+
+	// ToolStatsFile records tool success rates and latencies across sessions, which are
+	// turned into tool usage hints in the prompt. Empty disables tracking.
+	ToolStatsFile string `json:"tool_stats_file"`
+
+	// TemplatesDir holds user templates for "codezilla new" and the scaffold tool, one
+	// directory per template; they override built-in templates of the same name
+	TemplatesDir string `json:"templates_dir"`
+
+	// SnippetsFile holds the code snippets saved with /snippet add, offered to the
+	// model through the getSnippet tool
+	SnippetsFile string `json:"snippets_file"`
+
 	// Permission settings
 	DangerousToolsWarn  bool              `json:"dangerous_tools_warn"`
 	AlwaysAskPermission bool              `json:"always_ask_permission"`
 	ToolPermissions     map[string]string `json:"tool_permissions"`
 
+	// ExplainCalls shows a one-sentence explanation of what a call will do, written by
+	// the model, when asking permission for a dangerous tool
+	ExplainCalls ExplainSettings `json:"explain_calls"`
+
+	// ClarifyToolCalls asks the user instead of running a best guess when a tool call
+	// is uncertain, such as a misspelled tool name or a missing required parameter:
+	// "off", "always_ask" for tools that ask permission every time, "ask_once" for
+	// tools that ask at all, or "all"
+	ClarifyToolCalls string `json:"clarify_tool_calls"`
+
 	// UI settings
 	ForceColor bool `json:"force_color"`
 	NoColor    bool `json:"no_color"`
+	Verbose    bool `json:"verbose"` // Show the agent's intermediate steps, such as reflections after repeated failures
+	// Output is how much of the agent's work is shown while it answers: "quiet",
+	// "normal" for a line per tool call, or "trace" for the prompts sent, tool
+	// parameters and results, and timings. Verbose selects trace.
+	Output string `json:"output"`
+
+	// Citations asks the model to cite the tool results its answers rest on, listed as
+	// footnotes under each answer and saved with the session
+	Citations bool `json:"citations"`
+	// ChangesSummary lists the files changed while answering under each answer, with
+	// the lines added and removed
+	ChangesSummary bool `json:"changes_summary"`
+	// CheckReferences warns about file paths and symbols mentioned in answers that
+	// don't exist in the project
+	CheckReferences bool `json:"check_references"`
+	// ShowReasoning shows the <think> sections of reasoning models, dimmed, in verbose
+	// mode; they are never part of the answer or the context
+	ShowReasoning bool `json:"show_reasoning"`
+
+	// Unicode is "full" to print emoji and box-drawing characters, or "ascii" to print
+	// ASCII equivalents, independent of color
+	Unicode string `json:"unicode"`
+
+	// KeyBindings rebinds input actions (submit, newline, cancel, history_search, clear,
+	// editor) to keys such as "ctrl-s" or "alt-enter"; /keys lists them
+	KeyBindings map[string]string `json:"key_bindings,omitempty"`
+
+	// EditMode is "emacs" for readline-style editing of the input line, or "vi" for
+	// modal editing with word motions and yank/paste registers
+	EditMode string `json:"edit_mode"`
 
 	// Working directory
 	WorkingDirectory string `json:"working_directory"`
 
+	// Roots are additional project directories by name, such as a shared library next
+	// to a service. Tools accept paths in them as name:path, like lib:pkg/foo.go, and
+	// the search index covers them; relative directories are resolved against the
+	// working directory.
+	Roots map[string]string `json:"roots,omitempty"`
+
 	// Analyzer settings
 	AnalyzerSettings AnalyzerSettings `json:"analyzer_settings"`
+
+	// Budget limits the work done for one request
+	Budget BudgetSettings `json:"budget"`
+	// WriteQuota limits the files changed in one run
+	WriteQuota WriteQuotaSettings `json:"write_quota"`
+
+	// Forge configures the GitHub/GitLab issue and pull request tools
+	Forge ForgeSettings `json:"forge"`
+
+	// LLMCache caches the answers of idempotent internal model calls
+	LLMCache LLMCacheSettings `json:"llm_cache"`
+
+	// Analytics keeps opt-in usage counts in a local file for /dashboard
+	Analytics AnalyticsSettings `json:"analytics"`
+
+	// SelfUpdate allows `codezilla self-update`; turn it off where installs are managed
+	SelfUpdate bool `json:"self_update"`
+	// UpdateFeed is the latest release in the GitHub API format, for mirrors
+	UpdateFeed string `json:"update_feed,omitempty"`
+
+	// BuildCheck runs the project's build after the assistant edits files
+	BuildCheck BuildCheckSettings `json:"build_check"`
+
+	// Hooks are shell commands run on lifecycle events, keyed by event: on_session_start,
+	// before_tool, after_tool, after_file_write or on_response. Tool events can be
+	// narrowed to one tool, as in "before_tool:execute". Each command gets the event
+	// as JSON on stdin; a before_tool hook that fails blocks the call.
+	Hooks map[string][]string `json:"hooks,omitempty"`
+
+	// path is the file the configuration was loaded from
+	path string
+	// fileValues holds values as written in the file for fields replaced at load time
+	// (environment overrides and secret references), so saving never writes them out
+	fileValues map[string]interface{}
+}
+
+// Path returns the file the configuration was loaded from, or "" for defaults
+func (c *Config) Path() string {
+	return c.path
 }
 
 // AnalyzerSettings contains configuration for the file analyzer
@@ -58,6 +253,82 @@ type AnalyzerSettings struct {
 	MaxFileSize        int64   `json:"max_file_size"`       // Maximum file size to analyze
 }
 
+// ModelProfile holds the settings of one model. Options are Ollama request options,
+// such as num_ctx or stop, sent with every request to the model; they replace options
+// codezilla sets itself, such as temperature.
+type ModelProfile struct {
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// ExplainSettings choose the permission requests that come with an explanation. Tools
+// marked dangerous, such as addDependency, are always explained when enabled.
+type ExplainSettings struct {
+	Enabled bool     `json:"enabled"`
+	Tools   []string `json:"tools,omitempty"` // Further tools to explain, such as execute
+	Model   string   `json:"model,omitempty"` // A smaller, faster model for explanations (defaults to default_model)
+}
+
+// ConnectionSettings tune the HTTP connections to Ollama. Keeping enough of them open
+// saves a connection, and over HTTPS a handshake, on most requests when many run at
+// once, as in file analysis.
+type ConnectionSettings struct {
+	MaxIdle            int  `json:"max_idle"`             // Idle connections kept open; 0 means Go's default of 2
+	IdleTimeoutSeconds int  `json:"idle_timeout_seconds"` // How long an idle connection is kept
+	KeepAlive          bool `json:"keep_alive"`           // Reuse connections between requests
+	HTTP2              bool `json:"http2"`                // Use HTTP/2 when an HTTPS server offers it
+}
+
+// BudgetSettings limits one request. When a limit is reached the agent stops between
+// steps, summarizes its progress and asks whether to continue. Zero means unlimited.
+type BudgetSettings struct {
+	MaxSeconds  int `json:"max_seconds"`   // Wall time per request
+	MaxLLMCalls int `json:"max_llm_calls"` // Model calls per request
+	MaxTokens   int `json:"max_tokens"`    // Prompt and completion tokens per request
+}
+
+// WriteQuotaSettings limits the files changed in one run of Codezilla. When a limit is
+// exceeded the agent pauses before its next change and asks whether to continue.
+// Zero means unlimited.
+type WriteQuotaSettings struct {
+	MaxFiles int   `json:"max_files"` // Files created, modified or deleted
+	MaxBytes int64 `json:"max_bytes"` // Bytes written to files
+}
+
+// LLMCacheSettings configures the response cache for file analysis, summaries,
+// classification and the review, triage and changelog workflows. Chat turns are
+// never cached.
+type LLMCacheSettings struct {
+	Enabled    bool   `json:"enabled"`
+	Dir        string `json:"dir"`         // Directory holding one file per cached response
+	TTLSeconds int    `json:"ttl_seconds"` // Age after which a response is asked for again; 0 keeps responses forever
+}
+
+// AnalyticsSettings configures the local usage counts shown by /dashboard: sessions
+// and requests per day, request outcomes, and the tools and models used. They are
+// off unless enabled, never leave the machine and hold no prompts or answers.
+type AnalyticsSettings struct {
+	Enabled       bool   `json:"enabled"`
+	File          string `json:"file"`
+	RetentionDays int    `json:"retention_days"` // Days of counts kept
+}
+
+// BuildCheckSettings make the assistant prove its edits compile. Once it answers
+// after writing files, the build command runs; a failure is handed back to the
+// model to fix, up to MaxAttempts times, and the answer says how the build ended.
+type BuildCheckSettings struct {
+	Enabled     bool   `json:"enabled"`
+	Command     string `json:"command,omitempty"` // Shell command; detected from the project when empty
+	MaxAttempts int    `json:"max_attempts"`      // Failed builds handed back to the model
+}
+
+// ForgeSettings selects the forge hosting the project. Without a token the gh or glab
+// CLI is used with its own login.
+type ForgeSettings struct {
+	Provider string `json:"provider"`          // "auto" (from the origin remote), "github" or "gitlab"
+	APIURL   string `json:"api_url,omitempty"` // REST API base URL, for self-hosted instances
+	Token    string `json:"token,omitempty"`   // API token, usually a "secret:<name>" reference
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	// Get current working directory
@@ -66,24 +337,61 @@ func DefaultConfig() *Config {
 		cwd = "."
 	}
 
-	systemPrompt := fmt.Sprintf(`You are Codezilla, a helpful AI assistant powered by Ollama. You have access to various tools that allow you to interact with the local system, read and write files, execute commands, and more.
-
-Current working directory: %s
-
-When the user refers to "the project", "this project", "search", or uses relative paths, assume they mean the current working directory and its contents. Always strive to be helpful, accurate, and safe in your responses.`, cwd)
-
 	return &Config{
-		DefaultModel:        "qwen3:14b",
-		OllamaURL:           "http://localhost:11434/api",
-		Temperature:         0.7,
-		MaxTokens:           1024 * 32,
-		SystemPrompt:        systemPrompt,
-		LogFile:             filepath.Join("logs", "codezilla.log"),
-		LogLevel:            "info",
-		LogSilent:           false,
-		RetainContext:       true,
-		MaxContextChars:     50000,
-		HistoryFile:         filepath.Join(getConfigDir(), "history"),
+		DefaultModel:           "qwen3:14b",
+		OllamaURL:              "http://localhost:11434/api",
+		Temperature:            0.7,
+		MaxTokens:              1024 * 32,
+		SystemPrompt:           i18n.Translate(i18n.Fallback, "prompt.system", cwd),
+		Language:               i18n.Fallback,
+		LanguageGuidance:       true,
+		Prefetch:               true,
+		PrioritizeChangedFiles: true,
+		ApplyMode:              "ask",
+		EditChecks:             "lint",
+		ShadowMode:             "auto",
+		TaskBranches:           "off",
+		ExecutePTY:             "allow",
+		ExecuteTrackChanges:    "auto",
+		InjectionDefense:       "delimit",
+		InjectionClassifier:    "off",
+		OutputContract:         "repair",
+		ShowReasoning:          true,
+		Citations:              true,
+		CheckReferences:        true,
+		ChangesSummary:         true,
+		ClarifyToolCalls:       "always_ask",
+		Output:                 "normal",
+		ExplainCalls:           ExplainSettings{Tools: []string{"execute"}},
+		StopSequences:          []string{"\nUser:", "\nTool Result:"},
+		LogFile:                filepath.Join("logs", "codezilla.log"),
+		LogLevel:               "info",
+		LogSilent:              false,
+		RetainContext:          true,
+		MaxContextChars:        50000,
+		HistoryFile:            filepath.Join(getConfigDir(), "history"),
+		HistoryMaxEntries:      DefaultHistoryMaxEntries,
+		HistoryPerProject:      true,
+		SecretsBackend:         secrets.BackendAuto,
+		PersistSessions:        true,
+		ResumeRefreshFiles:     true,
+		SelfUpdate:             true,
+		Forge:                  ForgeSettings{Provider: "auto"},
+		Analytics: AnalyticsSettings{
+			File:          filepath.Join(getConfigDir(), "analytics.json"),
+			RetentionDays: 90,
+		},
+		BuildCheck: BuildCheckSettings{
+			MaxAttempts: 3,
+		},
+		LLMCache: LLMCacheSettings{
+			Dir:        filepath.Join(getConfigDir(), "cache", "llm"),
+			TTLSeconds: 7 * 24 * 60 * 60,
+		},
+		SessionsDir:         filepath.Join(getConfigDir(), "sessions"),
+		ToolStatsFile:       filepath.Join(getConfigDir(), "tool_stats.json"),
+		TemplatesDir:        filepath.Join(getConfigDir(), "templates"),
+		SnippetsFile:        filepath.Join(getConfigDir(), "snippets.json"),
 		DangerousToolsWarn:  true,
 		AlwaysAskPermission: false,
 		ToolPermissions: map[string]string{
@@ -95,7 +403,15 @@ When the user refers to "the project", "this project", "search", or uses relativ
 		},
 		ForceColor:       false,
 		NoColor:          false,
+		Unicode:          "full",
+		EditMode:         EditModeEmacs,
 		WorkingDirectory: cwd,
+		OllamaConnections: ConnectionSettings{
+			MaxIdle:            32,
+			IdleTimeoutSeconds: 90,
+			KeepAlive:          true,
+			HTTP2:              true,
+		},
 		AnalyzerSettings: AnalyzerSettings{
 			UseLLM:             true,
 			Concurrency:        5,
@@ -110,8 +426,9 @@ When the user refers to "the project", "this project", "search", or uses relativ
 func LoadConfig(path string) (*Config, error) {
 	config := DefaultConfig()
 
-	// If path doesn't exist, return default config
+	// If path doesn't exist, return default config; saving will create the file
 	if _, err := os.Stat(path); os.IsNotExist(err) {
+		config.path = path
 		return config, nil
 	}
 
@@ -121,9 +438,9 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse JSON
-	if err := json.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	// Parse and validate the file (JSON, YAML or TOML), reporting every problem at once
+	if err := parseConfig(path, data, config); err != nil {
+		return nil, err
 	}
 
 	// Ensure tool permissions map is initialized
@@ -138,12 +455,19 @@ func LoadConfig(path string) (*Config, error) {
 	}
 	config.WorkingDirectory = cwd
 
-	// Update system prompt with current working directory
-	config.SystemPrompt = fmt.Sprintf(`You are Codezilla, a helpful AI assistant powered by Ollama. You have access to various tools that allow you to interact with the local system, read and write files, execute commands, and more.
-
-Current working directory: %s
-
-When the user refers to "the project", "this project", "search", or uses relative paths, assume they mean the current working directory and its contents. Always strive to be helpful, accurate, and safe in your responses.`, cwd)
+	// Update system prompt with current working directory, in the configured language
+	config.SystemPrompt = i18n.Translate(i18n.Resolve(config.Language), "prompt.system", cwd)
+
+	config.path = path
+	config.fileValues = map[string]interface{}{
+		"ollama_api_key":   config.OllamaAPIKey,
+		"ollama_auth_type": config.OllamaAuthType,
+		"ollama_username":  config.OllamaUsername,
+		"ollama_password":  config.OllamaPassword,
+		"ollama_url":       config.OllamaURL,
+		"ollama_headers":   copyHeaders(config.OllamaHeaders),
+		"forge":            config.Forge,
+	}
 
 	// Check environment variables for authentication (these override config file)
 	if apiKey := os.Getenv("OLLAMA_API_KEY"); apiKey != "" {
@@ -163,31 +487,203 @@ When the user refers to "the project", "this project", "search", or uses relativ
 		config.OllamaURL = baseURL
 	}
 
+	if err := resolveSecrets(config); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
-// SaveConfig saves configuration to a file
+// SecretRefPrefix marks a config value that should be read from the secrets backend
+const SecretRefPrefix = "secret:"
+
+// OpenSecrets opens the secrets backend selected in the configuration
+func OpenSecrets(config *Config) (secrets.Store, error) {
+	return secrets.Open(config.SecretsBackend, getConfigDir())
+}
+
+// resolveSecrets replaces "secret:<name>" references with values from the secrets backend
+func resolveSecrets(config *Config) error {
+	var store secrets.Store
+	lookup := func(ref string) (string, error) {
+		name := strings.TrimPrefix(ref, SecretRefPrefix)
+		if store == nil {
+			var err error
+			if store, err = OpenSecrets(config); err != nil {
+				return "", err
+			}
+			if store == nil {
+				return "", fmt.Errorf("config references secret %q but secrets_backend is \"none\"", name)
+			}
+		}
+		value, err := store.Get(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret %q from %s: %w", name, store.Name(), err)
+		}
+		return value, nil
+	}
+
+	for _, field := range []*string{&config.OllamaAPIKey, &config.OllamaPassword, &config.Forge.Token} {
+		if !strings.HasPrefix(*field, SecretRefPrefix) {
+			continue
+		}
+		value, err := lookup(*field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	for k, v := range config.OllamaHeaders {
+		if strings.HasPrefix(v, SecretRefPrefix) {
+			value, err := lookup(v)
+			if err != nil {
+				return err
+			}
+			config.OllamaHeaders[k] = value
+		}
+	}
+
+	return nil
+}
+
+// copyHeaders returns a copy of a header map so later changes don't alias it
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
+
+// SaveConfig saves configuration to a file in the format given by its extension.
+// The file is replaced atomically, keys the Config type doesn't know about are kept,
+// values that came from the environment or the secrets backend are written back
+// exactly as they appeared in the file, and YAML/TOML comments are kept where possible.
 func SaveConfig(config *Config, path string) error {
+	format := configFormatFor(path)
+
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(config, "", "  ")
+	// Start from the existing file so unknown fields survive
+	fields := make(map[string]json.RawMessage)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if fields, err = decodeConfigFields(format, existing); err != nil {
+			return fmt.Errorf("refusing to overwrite unparseable config file %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Marshal to JSON and overlay the known fields
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(data, &known); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	for key, value := range config.fileValues {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		known[key] = raw
+	}
+	defaults, err := json.Marshal(DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var defaultFields map[string]json.RawMessage
+	if err := json.Unmarshal(defaults, &defaultFields); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	for key, value := range known {
+		// Don't introduce fields the file never had while they are empty or at their default
+		current, inFile := fields[key]
+		if !inFile && (isEmptyJSON(value) || sameJSON(value, defaultFields[key])) {
+			continue
+		}
+		if inFile {
+			value = pruneDefaults(value, current, defaultFields[key])
+		}
+		fields[key] = value
+	}
+
+	data, err = encodeConfig(format, existing, fields)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Write to file with secure permissions
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	// Write to file with secure permissions via a temporary file and rename
+	if err := writeFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
+// pruneDefaults drops entries of an object value that are absent from the file's
+// version of it and still at their default, so nested sections stay as written
+func pruneDefaults(value, inFile, defaults json.RawMessage) json.RawMessage {
+	var v, f, d map[string]json.RawMessage
+	if json.Unmarshal(value, &v) != nil || json.Unmarshal(inFile, &f) != nil || v == nil || f == nil {
+		return value
+	}
+	json.Unmarshal(defaults, &d)
+
+	for key, entry := range v {
+		if _, ok := f[key]; !ok && (isEmptyJSON(entry) || sameJSON(entry, d[key])) {
+			delete(v, key)
+		}
+	}
+	pruned, err := json.Marshal(v)
+	if err != nil {
+		return value
+	}
+	return pruned
+}
+
+// isEmptyJSON reports whether raw is an empty string, null, or empty object
+func isEmptyJSON(raw json.RawMessage) bool {
+	switch string(raw) {
+	case `""`, "null", "{}":
+		return true
+	}
+	return false
+}
+
+// writeFileAtomic replaces path with data so readers never see a partial file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
 // getConfigDir returns the directory for configuration files
 func getConfigDir() string {
 	// Get user config directory
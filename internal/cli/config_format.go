@@ -0,0 +1,486 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFormat is the file format of a configuration file
+type configFormat string
+
+const (
+	formatJSON configFormat = "json"
+	formatYAML configFormat = "yaml"
+	formatTOML configFormat = "toml"
+)
+
+// ConfigFileNames lists the config file names looked for, in order of preference
+var ConfigFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// configFormatFor detects the format of a config file from its extension, defaulting to JSON
+func configFormatFor(path string) configFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+// decodeConfigFields decodes a config file into its top-level fields as JSON values,
+// so every format shares the JSON validation and decoding path
+func decodeConfigFields(format configFormat, data []byte) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if format == formatJSON {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+
+	values := make(map[string]interface{})
+	switch format {
+	case formatYAML:
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	case formatTOML:
+		if err := toml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		fields[key] = raw
+	}
+	return fields, nil
+}
+
+// yamlErrorLine extracts the line number from a yaml.v3 error message
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// syntaxErrorLine returns the line a decode error refers to, or 0 if unknown
+func syntaxErrorLine(data []byte, err error) int {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return lineAt(data, e.Offset)
+	case toml.ParseError:
+		return e.Position.Line
+	case *toml.ParseError:
+		return e.Position.Line
+	}
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+// yamlKeyLine returns the line of a nested key in a YAML document, or 0 if not found
+func yamlKeyLine(data []byte, keys ...string) int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+	node, line := doc.Content[0], 0
+	for _, key := range keys {
+		keyNode, valueNode := yamlLookup(node, key)
+		if keyNode == nil {
+			return 0
+		}
+		node, line = valueNode, keyNode.Line
+	}
+	return line
+}
+
+// yamlLookup finds key in a mapping node
+func yamlLookup(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// tomlKeyLine returns the line of a top-level key or a key inside a [section], or 0 if not found
+func tomlKeyLine(data []byte, keys ...string) int {
+	if len(keys) == 0 || len(keys) > 2 {
+		return 0
+	}
+	section, key := "", keys[0]
+	if len(keys) == 2 {
+		section, key = keys[0], keys[1]
+	}
+
+	current := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			current = strings.Trim(strings.SplitN(trimmed, "#", 2)[0], "[] \t")
+			if len(keys) == 1 && current == key {
+				return i + 1
+			}
+			continue
+		}
+		name, _, ok := splitTOMLAssignment(line)
+		if !ok {
+			continue
+		}
+		if (current == section && name == key) || (current == "" && section != "" && name == section+"."+key) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// splitTOMLAssignment splits a "key = value" line into its key and the text after "="
+func splitTOMLAssignment(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", "", false
+	}
+	eq := strings.Index(line, "=")
+	if eq < 0 {
+		return "", "", false
+	}
+	name := strings.TrimSpace(line[:eq])
+	name = strings.Trim(name, `"'`)
+	return name, line[eq+1:], true
+}
+
+// jsonValue converts a raw JSON value into plain Go values, keeping integers as int64
+func jsonValue(raw json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertNumbers(v), nil
+}
+
+// convertNumbers replaces json.Number values with int64 or float64
+func convertNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = convertNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = convertNumbers(item)
+		}
+	}
+	return v
+}
+
+// sameJSON reports whether two raw JSON values are equal after normalization
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// sortedKeys returns the keys of a raw field map in sorted order
+func sortedKeys(fields map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeConfig renders merged fields in the given format. existing is the current file
+// content; for YAML and TOML it is updated in place so comments survive where possible.
+func encodeConfig(format configFormat, existing []byte, fields map[string]json.RawMessage) ([]byte, error) {
+	switch format {
+	case formatYAML:
+		return encodeYAML(existing, fields)
+	case formatTOML:
+		return encodeTOML(existing, fields)
+	default:
+		data, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+}
+
+// encodeYAML updates the existing YAML document node by node, leaving unchanged
+// values (and their comments) untouched
+func encodeYAML(existing []byte, fields map[string]json.RawMessage) ([]byte, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := yaml.Unmarshal(existing, &doc); err != nil {
+			return nil, err
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file must contain a mapping at the top level")
+	}
+
+	for _, key := range sortedKeys(fields) {
+		if err := mergeYAMLValue(root, key, fields[key]); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeYAMLValue sets key in a mapping node, descending into nested mappings
+func mergeYAMLValue(mapping *yaml.Node, key string, raw json.RawMessage) error {
+	_, valueNode := yamlLookup(mapping, key)
+
+	if valueNode != nil {
+		var current interface{}
+		if err := valueNode.Decode(&current); err == nil {
+			if currentRaw, err := json.Marshal(current); err == nil && sameJSON(currentRaw, raw) {
+				return nil
+			}
+		}
+
+		// Merge objects key by key so comments on unchanged entries survive
+		var nested map[string]json.RawMessage
+		if valueNode.Kind == yaml.MappingNode && json.Unmarshal(raw, &nested) == nil && nested != nil {
+			for _, k := range sortedKeys(nested) {
+				if err := mergeYAMLValue(valueNode, k, nested[k]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	value, err := jsonValue(raw)
+	if err != nil {
+		return err
+	}
+	newNode := &yaml.Node{}
+	if err := newNode.Encode(value); err != nil {
+		return err
+	}
+
+	if valueNode != nil {
+		newNode.LineComment = valueNode.LineComment
+		newNode.FootComment = valueNode.FootComment
+		*valueNode = *newNode
+		return nil
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		newNode,
+	)
+	return nil
+}
+
+// encodeTOML rewrites changed scalar values in place, keeping comments and layout.
+// Changes that can't be expressed as single-line edits fall back to re-encoding the whole file.
+func encodeTOML(existing []byte, fields map[string]json.RawMessage) ([]byte, error) {
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if data, ok := editTOML(existing, fields); ok {
+			return data, nil
+		}
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for key, raw := range fields {
+		if string(raw) == "null" {
+			continue
+		}
+		value, err := jsonValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		values[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// editTOML applies changed scalar fields to existing TOML text line by line
+func editTOML(existing []byte, fields map[string]json.RawMessage) ([]byte, bool) {
+	current, err := decodeConfigFields(formatTOML, existing)
+	if err != nil {
+		return nil, false
+	}
+	lines := strings.Split(string(existing), "\n")
+
+	var inserts []string
+	for _, key := range sortedKeys(fields) {
+		raw := fields[key]
+		old, inFile := current[key]
+		if inFile && sameJSON(old, raw) {
+			continue
+		}
+
+		// Nested tables are edited entry by entry
+		var nested, oldNested map[string]json.RawMessage
+		if json.Unmarshal(raw, &nested) == nil && nested != nil {
+			if !inFile || json.Unmarshal(old, &oldNested) != nil || oldNested == nil {
+				return nil, false
+			}
+			for _, sub := range sortedKeys(nested) {
+				if prev, ok := oldNested[sub]; ok && sameJSON(prev, nested[sub]) {
+					continue
+				}
+				if !replaceTOMLValue(existing, lines, nested[sub], key, sub) {
+					return nil, false
+				}
+			}
+			for sub := range oldNested {
+				if _, ok := nested[sub]; !ok {
+					return nil, false
+				}
+			}
+			continue
+		}
+
+		if inFile {
+			if !replaceTOMLValue(existing, lines, raw, key) {
+				return nil, false
+			}
+			continue
+		}
+
+		encoded, ok := encodeTOMLScalar(raw)
+		if !ok {
+			return nil, false
+		}
+		inserts = append(inserts, key+" = "+encoded)
+	}
+
+	// New top-level keys go before the first table header
+	if len(inserts) > 0 {
+		at := len(lines)
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "[") {
+				at = i
+				break
+			}
+		}
+		for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+			at--
+		}
+		lines = append(lines[:at], append(inserts, lines[at:]...)...)
+	}
+
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// replaceTOMLValue replaces the value of the key on its line, keeping any trailing comment
+func replaceTOMLValue(data []byte, lines []string, raw json.RawMessage, keys ...string) bool {
+	n := tomlKeyLine(data, keys...)
+	if n == 0 {
+		return false
+	}
+	encoded, ok := encodeTOMLScalar(raw)
+	if !ok {
+		return false
+	}
+
+	line := lines[n-1]
+	_, rest, ok := splitTOMLAssignment(line)
+	if !ok {
+		return false
+	}
+	valueText, comment := splitTOMLComment(rest)
+
+	// Only single-line values can be replaced in place
+	var probe map[string]interface{}
+	if _, err := toml.Decode("v = "+valueText, &probe); err != nil {
+		return false
+	}
+
+	prefix := line[:len(line)-len(rest)]
+	leading := rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	newLine := prefix + leading + encoded
+	if comment != "" {
+		newLine += " " + comment
+	}
+	lines[n-1] = newLine
+	return true
+}
+
+// splitTOMLComment separates a value from a trailing "# comment" outside of quotes
+func splitTOMLComment(s string) (string, string) {
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return strings.TrimSpace(s[:i]), s[i:]
+		}
+	}
+	return strings.TrimSpace(s), ""
+}
+
+// encodeTOMLScalar renders a raw JSON scalar as a TOML value
+func encodeTOMLScalar(raw json.RawMessage) (string, bool) {
+	value, err := jsonValue(raw)
+	if err != nil || value == nil {
+		return "", false
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]interface{}{"v": value}); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(buf.String(), "v = ")), true
+}
@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveConfigPreservesFileValues(t *testing.T) {
+	t.Setenv("OLLAMA_API_KEY", "from-env")
+	t.Setenv("OLLAMA_USERNAME", "")
+	t.Setenv("OLLAMA_PASSWORD", "")
+	t.Setenv("OLLAMA_BASE_URL", "")
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{
+  "default_model": "qwen2.5-coder:3b",
+  "_note": "keep me",
+  "ollama_api_key": ""
+}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.Path() != path {
+		t.Fatalf("Path() = %q, want %q", config.Path(), path)
+	}
+	if config.OllamaAPIKey != "from-env" {
+		t.Fatalf("OllamaAPIKey = %q, want environment override", config.OllamaAPIKey)
+	}
+
+	config.DefaultModel = "llama3"
+	if err := SaveConfig(config, config.Path()); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved config is not valid JSON: %v", err)
+	}
+	if saved["default_model"] != "llama3" {
+		t.Errorf("default_model = %v, want llama3", saved["default_model"])
+	}
+	if saved["_note"] != "keep me" {
+		t.Errorf("comment field _note = %v, want it preserved", saved["_note"])
+	}
+	if saved["ollama_api_key"] != "" {
+		t.Errorf("ollama_api_key = %v, environment value must not be written", saved["ollama_api_key"])
+	}
+	if _, ok := saved["ollama_username"]; ok {
+		t.Errorf("empty optional field ollama_username should not be added")
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only config.json (no leftover temp files)", len(entries))
+	}
+}
+
+func TestSaveConfigRefusesUnparseableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	broken := []byte(`{"default_model": `)
+	if err := os.WriteFile(path, broken, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := SaveConfig(DefaultConfig(), path); err == nil {
+		t.Fatalf("SaveConfig() should refuse to overwrite a file it cannot parse")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != string(broken) {
+		t.Fatalf("config file was modified: %q", data)
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+  "default_model": "qwen2.5-coder:3b",
+  "temprature": 0.5,
+  "ollama_url": "localhost:11434",
+  "max_tokens": "lots",
+  "tool_permissions": {
+    "execute": "sometimes"
+  },
+  "analyzer_settings": {
+    "concurrency": 0
+  },
+  "hooks": {
+    "after_file_write": ["gofmt -w \"$CODEZILLA_FILE\""],
+    "after_file_writes": ["true"]
+  },
+  "model_profiles": {
+    "qwen2.5-coder:3b": {
+      "options": {"num_ctx": 8192, "stop": ["<|im_end|>"]}
+    },
+    "llama3": {
+      "options": {
+        "num_ctxx": 8192,
+        "top_k": 1.5
+      }
+    }
+  },
+  "roots": {"lib": "../not-checked-out-yet"},
+  "_comment": "ignored"
+}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err := LoadConfig(path)
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("LoadConfig() error = %v, want *ConfigError", err)
+	}
+
+	// Structural problems are reported together before values are checked
+	want := []ConfigIssue{
+		{Key: "temprature", Line: 3, Suggestion: `did you mean "temperature"?`},
+		{Key: "max_tokens", Line: 5},
+	}
+	if len(cfgErr.Issues) != len(want) {
+		t.Fatalf("got %d issues, want %d:\n%v", len(cfgErr.Issues), len(want), err)
+	}
+	for i, w := range want {
+		got := cfgErr.Issues[i]
+		if got.Key != w.Key || got.Line != w.Line || (w.Suggestion != "" && got.Suggestion != w.Suggestion) {
+			t.Errorf("issue %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if !strings.Contains(err.Error(), `| "temprature": 0.5,`) {
+		t.Errorf("error should quote the offending line:\n%v", err)
+	}
+
+	// Once the structure is valid, value problems are reported
+	data = strings.Replace(data, `"temprature"`, `"temperature"`, 1)
+	data = strings.Replace(data, `"lots"`, `4000`, 1)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	_, err = LoadConfig(path)
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("LoadConfig() error = %v, want *ConfigError", err)
+	}
+	keys := make([]string, len(cfgErr.Issues))
+	for i, issue := range cfgErr.Issues {
+		keys[i] = fmt.Sprintf("%s@%d", issue.Key, issue.Line)
+	}
+	wantKeys := "ollama_url@4,tool_permissions.execute@7,analyzer_settings.concurrency@10,hooks.after_file_writes@14,model_profiles.llama3.options.num_ctxx@22,model_profiles.llama3.options.top_k@23"
+	if got := strings.Join(keys, ","); got != wantKeys {
+		t.Errorf("issues = %s, want %s", got, wantKeys)
+	}
+}
+
+func TestLoadConfigSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{\n  \"default_model\": \"x\"\n  \"temperature\": 1\n}"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err := LoadConfig(path)
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) || len(cfgErr.Issues) != 1 || cfgErr.Issues[0].Line != 3 {
+		t.Fatalf("LoadConfig() error = %v, want a syntax error on line 3", err)
+	}
+}
+
+func TestConfigYAMLRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := `# Model used for new sessions
+default_model: qwen2.5-coder:3b # small and fast
+temperature: 0.5
+analyzer_settings:
+  # Files analyzed in parallel
+  concurrency: 4
+`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.DefaultModel != "qwen2.5-coder:3b" || config.Temperature != 0.5 || config.AnalyzerSettings.Concurrency != 4 {
+		t.Fatalf("LoadConfig() = model %q, temperature %v, concurrency %d", config.DefaultModel, config.Temperature, config.AnalyzerSettings.Concurrency)
+	}
+
+	config.DefaultModel = "llama3"
+	if err := SaveConfig(config, path); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	saved := string(data)
+	for _, want := range []string{"# Model used for new sessions", "default_model: llama3 # small and fast", "# Files analyzed in parallel", "concurrency: 4"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved YAML missing %q:\n%s", want, saved)
+		}
+	}
+	if strings.Contains(saved, "system_prompt") {
+		t.Errorf("saved YAML should not add default fields:\n%s", saved)
+	}
+
+	reloaded, err := LoadConfig(path)
+	if err != nil || reloaded.DefaultModel != "llama3" {
+		t.Fatalf("reloaded config = %v, %v", reloaded, err)
+	}
+}
+
+func TestConfigTOMLRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	original := `# Codezilla settings
+default_model = "qwen2.5-coder:3b" # small and fast
+max_tokens = 4000
+
+[analyzer_settings]
+concurrency = 4 # files in parallel
+`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.MaxTokens != 4000 || config.AnalyzerSettings.Concurrency != 4 {
+		t.Fatalf("LoadConfig() = max_tokens %d, concurrency %d", config.MaxTokens, config.AnalyzerSettings.Concurrency)
+	}
+
+	config.DefaultModel = "llama3"
+	config.AnalyzerSettings.Concurrency = 8
+	if err := SaveConfig(config, path); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := `# Codezilla settings
+default_model = "llama3" # small and fast
+max_tokens = 4000
+
+[analyzer_settings]
+concurrency = 8 # files in parallel
+`
+	if string(data) != want {
+		t.Errorf("saved TOML =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestConfigValidationLinesYAMLAndTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		key  string
+		line int
+	}{
+		{"config.yaml", "default_model: x\nanalyzer_settings:\n  concurency: 2\n", "analyzer_settings.concurency", 3},
+		{"config.toml", "default_model = \"x\"\n\n[analyzer_settings]\nconcurency = 2\n", "analyzer_settings.concurency", 4},
+		{"config.yaml", "default_model: x\ntemperature: 9\n", "temperature", 2},
+		{"config.toml", "default_model = \"x\"\nollama_url = \"nope\"\n", "ollama_url", 2},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		_, err := LoadConfig(path)
+		var cfgErr *ConfigError
+		if !errors.As(err, &cfgErr) || len(cfgErr.Issues) != 1 {
+			t.Errorf("%s: LoadConfig() error = %v, want one issue", tt.name, err)
+			continue
+		}
+		if got := cfgErr.Issues[0]; got.Key != tt.key || got.Line != tt.line {
+			t.Errorf("%s: issue = %s@%d, want %s@%d", tt.name, got.Key, got.Line, tt.key, tt.line)
+		}
+	}
+}
@@ -0,0 +1,552 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"codezilla/internal/hooks"
+	"codezilla/internal/i18n"
+	"codezilla/internal/secrets"
+	"codezilla/internal/tools"
+	"codezilla/llm/ollama"
+)
+
+// ConfigIssue is a single problem found in a configuration file
+type ConfigIssue struct {
+	Key        string // Dotted key path, e.g. "analyzer_settings.concurrency"
+	Line       int    // 1-based line in the file, 0 when unknown
+	Message    string
+	Suggestion string
+}
+
+// ConfigError reports every problem found in a configuration file
+type ConfigError struct {
+	Path   string
+	Issues []ConfigIssue
+	lines  []string
+}
+
+// Error formats all issues with the offending line and a suggested fix
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	if len(e.Issues) == 1 {
+		fmt.Fprintf(&b, "invalid config %s: 1 problem", e.Path)
+	} else {
+		fmt.Fprintf(&b, "invalid config %s: %d problems", e.Path, len(e.Issues))
+	}
+	for _, issue := range e.Issues {
+		b.WriteString("\n  ")
+		if issue.Line > 0 {
+			fmt.Fprintf(&b, "line %d: ", issue.Line)
+		}
+		if issue.Key != "" {
+			fmt.Fprintf(&b, "%s: ", issue.Key)
+		}
+		b.WriteString(issue.Message)
+		if issue.Line > 0 && issue.Line <= len(e.lines) {
+			fmt.Fprintf(&b, "\n      | %s", strings.TrimSpace(e.lines[issue.Line-1]))
+		}
+		if issue.Suggestion != "" {
+			fmt.Fprintf(&b, "\n      fix: %s", issue.Suggestion)
+		}
+	}
+	return b.String()
+}
+
+// modelNamePattern matches Ollama model names such as "qwen2.5-coder:3b" or "library/llama3:latest"
+var modelNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*(:[A-Za-z0-9._-]+)?$`)
+
+// configValidator collects issues while a configuration file is parsed
+type configValidator struct {
+	path   string
+	format configFormat
+	data   []byte
+	issues []ConfigIssue
+}
+
+// parseConfig decodes data into config, validating structure and values.
+// It returns a *ConfigError listing every problem found.
+func parseConfig(path string, data []byte, config *Config) error {
+	v := &configValidator{path: path, format: configFormatFor(path), data: data}
+
+	raw, err := decodeConfigFields(v.format, data)
+	if err != nil {
+		v.addSyntaxError(err)
+		return v.err()
+	}
+
+	v.checkKeys(raw, reflect.TypeOf(Config{}), nil)
+	if len(v.issues) > 0 {
+		return v.err()
+	}
+
+	jsonData, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(jsonData, config)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	v.checkValues(config)
+	return v.err()
+}
+
+// err returns the collected issues as an error, or nil if there are none
+func (v *configValidator) err() error {
+	if len(v.issues) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		return v.issues[i].Line < v.issues[j].Line
+	})
+	return &ConfigError{
+		Path:   v.path,
+		Issues: v.issues,
+		lines:  strings.Split(string(v.data), "\n"),
+	}
+}
+
+// add records an issue for the key path
+func (v *configValidator) add(keys []string, message, suggestion string) {
+	v.issues = append(v.issues, ConfigIssue{
+		Key:        strings.Join(keys, "."),
+		Line:       v.line(keys...),
+		Message:    message,
+		Suggestion: suggestion,
+	})
+}
+
+// addSyntaxError records a decode error at its position
+func (v *configValidator) addSyntaxError(err error) {
+	issue := ConfigIssue{
+		Line:       syntaxErrorLine(v.data, err),
+		Message:    fmt.Sprintf("invalid %s: %v", strings.ToUpper(string(v.format)), err),
+		Suggestion: "check for a missing comma, quote, bracket or bad indentation near this line",
+	}
+	if issue.Line == 0 {
+		issue.Suggestion = "the file must contain a single top-level object"
+	}
+	v.issues = append(v.issues, issue)
+}
+
+// checkKeys reports unknown keys and values of the wrong type
+func (v *configValidator) checkKeys(raw map[string]json.RawMessage, typ reflect.Type, parent []string) {
+	fields := jsonFields(typ)
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// Keys starting with "_" or "//" are comments by convention
+		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, "//") {
+			continue
+		}
+
+		keys := append(append([]string{}, parent...), name)
+		field, ok := fields[name]
+		if !ok {
+			suggestion := `remove it, or prefix it with "_" to keep it as a comment`
+			if closest := closestMatch(name, mapKeys(fields)); closest != "" {
+				suggestion = fmt.Sprintf("did you mean %q?", closest)
+			}
+			v.add(keys, "unknown key", suggestion)
+			continue
+		}
+
+		// Descend into nested settings so their keys are checked too
+		if field.Kind() == reflect.Struct {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(raw[name], &nested); err != nil {
+				v.add(keys, "expected an object", "")
+				continue
+			}
+			v.checkKeys(nested, field, keys)
+			continue
+		}
+
+		if err := json.Unmarshal(raw[name], reflect.New(field).Interface()); err != nil {
+			v.add(keys, fmt.Sprintf("expected %s, got %s", describeType(field), strings.TrimSpace(string(raw[name]))), "")
+		}
+	}
+}
+
+// checkValues validates ranges and formats of decoded values
+func (v *configValidator) checkValues(c *Config) {
+	if c.DefaultModel == "" {
+		v.add([]string{"default_model"}, "must not be empty", `set a model such as "qwen2.5-coder:3b"; /models lists installed models`)
+	} else if !modelNamePattern.MatchString(c.DefaultModel) {
+		v.add([]string{"default_model"}, fmt.Sprintf("%q is not a valid model name", c.DefaultModel), `use the form "name" or "name:tag", e.g. "qwen2.5-coder:3b"`)
+	}
+	if c.ExplainCalls.Model != "" && !modelNamePattern.MatchString(c.ExplainCalls.Model) {
+		v.add([]string{"explain_calls", "model"}, fmt.Sprintf("%q is not a valid model name", c.ExplainCalls.Model), `use the form "name" or "name:tag", or remove it to use default_model`)
+	}
+	if c.TitleModel != "" && !modelNamePattern.MatchString(c.TitleModel) {
+		v.add([]string{"title_model"}, fmt.Sprintf("%q is not a valid model name", c.TitleModel), `use the form "name" or "name:tag", or remove it to use default_model`)
+	}
+
+	if u, err := url.Parse(c.OllamaURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add([]string{"ollama_url"}, fmt.Sprintf("%q is not a valid http(s) URL", c.OllamaURL), `use a URL such as "http://localhost:11434/api"`)
+	}
+	for i, raw := range c.OllamaURLs {
+		if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add([]string{"ollama_urls", strconv.Itoa(i)}, fmt.Sprintf("%q is not a valid http(s) URL", raw), `use a URL such as "http://gpu-box:11434/api"`)
+		}
+	}
+	if c.UpdateFeed != "" {
+		if u, err := url.Parse(c.UpdateFeed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add([]string{"update_feed"}, fmt.Sprintf("%q is not a valid http(s) URL", c.UpdateFeed), "use the URL of the latest release in the GitHub API format, or remove it to use GitHub")
+		}
+	}
+	v.checkEnum([]string{"clarify_tool_calls"}, c.ClarifyToolCalls, []string{"off", "always_ask", "ask_once", "all"}, false)
+	v.checkEnum([]string{"output"}, c.Output, []string{"quiet", "normal", "trace"}, true)
+	v.checkEnum([]string{"ollama_balance"}, c.OllamaBalance, []string{ollama.BalanceRoundRobin, ollama.BalanceModel}, true)
+	if c.OllamaCACert != "" {
+		if _, err := (ollama.TLSOptions{CACertFile: c.OllamaCACert}).Config(); err != nil {
+			v.add([]string{"ollama_ca_cert"}, err.Error(), "point it at a PEM file with the CA certificate of the server")
+		}
+	}
+
+	if c.Temperature < 0 || c.Temperature > 2 {
+		v.add([]string{"temperature"}, fmt.Sprintf("%g is out of range", c.Temperature), "use a value between 0 and 2 (0.7 is a good default)")
+	}
+	if c.MaxTokens <= 0 {
+		v.add([]string{"max_tokens"}, fmt.Sprintf("%d must be positive", c.MaxTokens), "use a value such as 4000")
+	}
+	if c.MaxContextChars < 0 {
+		v.add([]string{"max_context_chars"}, fmt.Sprintf("%d must not be negative", c.MaxContextChars), "")
+	}
+	if c.HistoryMaxEntries < 0 {
+		v.add([]string{"history_max_entries"}, fmt.Sprintf("%d must not be negative", c.HistoryMaxEntries), fmt.Sprintf("use 0 for the default of %d", DefaultHistoryMaxEntries))
+	}
+
+	v.checkEnum([]string{"apply_mode"}, c.ApplyMode, []string{"ask", "off"}, true)
+	v.checkEnum([]string{"language"}, c.Language, append([]string{i18n.Auto}, i18n.Languages()...), true)
+	v.checkEnum([]string{"edit_mode"}, c.EditMode, []string{EditModeEmacs, EditModeVi}, true)
+	v.checkEnum([]string{"unicode"}, c.Unicode, []string{"full", "ascii"}, true)
+	v.checkEnum([]string{"edit_checks"}, c.EditChecks, []string{"off", "format", "lint"}, true)
+	v.checkEnum([]string{"shadow_mode"}, c.ShadowMode, []string{"auto", "worktree", "copy"}, true)
+	v.checkEnum([]string{"task_branches"}, c.TaskBranches, []string{"off", "branch", "worktree"}, true)
+	v.checkEnum([]string{"forge", "provider"}, c.Forge.Provider, []string{"auto", "github", "gitlab"}, true)
+	v.checkEnum([]string{"execute_pty"}, c.ExecutePTY, []string{"off", "allow", "takeover"}, true)
+	v.checkEnum([]string{"execute_track_changes"}, c.ExecuteTrackChanges, []string{"off", "auto", "git", "mtime"}, true)
+	v.checkEnum([]string{"injection_defense"}, c.InjectionDefense, []string{"off", "delimit", "strict"}, true)
+	v.checkEnum([]string{"injection_classifier"}, c.InjectionClassifier, []string{"off", "heuristic", "model"}, true)
+	v.checkEnum([]string{"output_contract"}, c.OutputContract, []string{"off", "repair", "strict"}, true)
+	v.checkEnum([]string{"log_level"}, c.LogLevel, []string{"debug", "info", "warn", "error"}, false)
+	v.checkEnum([]string{"ollama_auth_type"}, c.OllamaAuthType, []string{"bearer", "basic", "custom"}, true)
+	v.checkEnum([]string{"secrets_backend"}, c.SecretsBackend, []string{
+		secrets.BackendAuto, secrets.BackendKeychain, secrets.BackendLibsecret,
+		secrets.BackendWincred, secrets.BackendFile, secrets.BackendNone,
+	}, true)
+
+	toolNames := make([]string, 0, len(c.ToolPermissions))
+	for tool := range c.ToolPermissions {
+		toolNames = append(toolNames, tool)
+	}
+	sort.Strings(toolNames)
+	for _, tool := range toolNames {
+		v.checkEnum([]string{"tool_permissions", tool}, c.ToolPermissions[tool], []string{"never_ask", "ask_once", "always_ask"}, false)
+	}
+
+	rootNames := make([]string, 0, len(c.Roots))
+	for name := range c.Roots {
+		rootNames = append(rootNames, name)
+	}
+	sort.Strings(rootNames)
+	for _, name := range rootNames {
+		// Whether the directory exists is checked when the app starts, since it
+		// may be created later or live on a drive that is not mounted yet
+		if !tools.ValidRootName(name) {
+			v.add([]string{"roots", name}, "invalid root name", "use a letter followed by letters, digits, '-' or '_', two characters at least")
+		} else if strings.TrimSpace(c.Roots[name]) == "" {
+			v.add([]string{"roots", name}, "empty directory", "set the path of the root, relative to the working directory or absolute")
+		}
+	}
+
+	actions := make([]string, 0, len(c.KeyBindings))
+	for action := range c.KeyBindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	bindingsValid := true
+	for _, action := range actions {
+		key := c.KeyBindings[action]
+		if _, ok := DefaultKeyBindings[action]; !ok {
+			suggestion := fmt.Sprintf("use one of %s", strings.Join(KeyActions, ", "))
+			if closest := closestMatch(action, KeyActions); closest != "" {
+				suggestion = fmt.Sprintf("did you mean %q?", closest)
+			}
+			v.add([]string{"key_bindings", action}, "unknown action", suggestion)
+			bindingsValid = false
+		} else if _, err := ParseKey(key); err != nil {
+			v.add([]string{"key_bindings", action}, err.Error(), `use "enter", "tab", "ctrl-<letter>", "alt-enter" or "alt-<key>"`)
+			bindingsValid = false
+		}
+	}
+	if bindingsValid {
+		if _, err := NewKeymap(c.KeyBindings); err != nil {
+			v.add([]string{"key_bindings"}, err.Error(), "bind each key to one action")
+		}
+	}
+
+	if c.PromptProfile != "" {
+		if _, ok := c.PromptProfiles[c.PromptProfile]; !ok {
+			profiles := make([]string, 0, len(c.PromptProfiles))
+			for name := range c.PromptProfiles {
+				profiles = append(profiles, name)
+			}
+			sort.Strings(profiles)
+			suggestion := "define it under prompt_profiles or remove prompt_profile"
+			if closest := closestMatch(c.PromptProfile, profiles); closest != "" {
+				suggestion = fmt.Sprintf("did you mean %q?", closest)
+			}
+			v.add([]string{"prompt_profile"}, fmt.Sprintf("unknown profile %q", c.PromptProfile), suggestion)
+		}
+	}
+
+	a := c.AnalyzerSettings
+	if a.Concurrency < 1 || a.Concurrency > 64 {
+		v.add([]string{"analyzer_settings", "concurrency"}, fmt.Sprintf("%d is out of range", a.Concurrency), "use a value between 1 and 64")
+	}
+	if a.RelevanceThreshold < 0 || a.RelevanceThreshold > 1 {
+		v.add([]string{"analyzer_settings", "relevance_threshold"}, fmt.Sprintf("%g is out of range", a.RelevanceThreshold), "use a value between 0 and 1")
+	}
+	if a.AnalysisTimeout <= 0 {
+		v.add([]string{"analyzer_settings", "analysis_timeout"}, fmt.Sprintf("%d must be positive", a.AnalysisTimeout), "use a number of seconds such as 30")
+	}
+	if a.MaxFileSize <= 0 {
+		v.add([]string{"analyzer_settings", "max_file_size"}, fmt.Sprintf("%d must be positive", a.MaxFileSize), "use a size in bytes such as 1048576")
+	}
+
+	for key, value := range map[string]int{
+		"max_seconds":   c.Budget.MaxSeconds,
+		"max_llm_calls": c.Budget.MaxLLMCalls,
+		"max_tokens":    c.Budget.MaxTokens,
+	} {
+		if value < 0 {
+			v.add([]string{"budget", key}, fmt.Sprintf("%d must not be negative", value), "use 0 for no limit")
+		}
+	}
+	if c.WriteQuota.MaxFiles < 0 {
+		v.add([]string{"write_quota", "max_files"}, fmt.Sprintf("%d must not be negative", c.WriteQuota.MaxFiles), "use 0 for no limit")
+	}
+	if c.WriteQuota.MaxBytes < 0 {
+		v.add([]string{"write_quota", "max_bytes"}, fmt.Sprintf("%d must not be negative", c.WriteQuota.MaxBytes), "use 0 for no limit")
+	}
+	if c.OllamaConnections.MaxIdle < 0 {
+		v.add([]string{"ollama_connections", "max_idle"}, fmt.Sprintf("%d must not be negative", c.OllamaConnections.MaxIdle), "use a value at least as large as analyzer_settings.concurrency")
+	}
+	if c.OllamaConnections.IdleTimeoutSeconds < 0 {
+		v.add([]string{"ollama_connections", "idle_timeout_seconds"}, fmt.Sprintf("%d must not be negative", c.OllamaConnections.IdleTimeoutSeconds), "use 0 to keep idle connections until the server closes them")
+	}
+	if c.LLMCache.TTLSeconds < 0 {
+		v.add([]string{"llm_cache", "ttl_seconds"}, fmt.Sprintf("%d must not be negative", c.LLMCache.TTLSeconds), "use 0 to keep responses until the cache directory is removed")
+	}
+	if c.Analytics.RetentionDays < 0 {
+		v.add([]string{"analytics", "retention_days"}, fmt.Sprintf("%d must not be negative", c.Analytics.RetentionDays), "use a number of days such as 90")
+	}
+	if c.BuildCheck.MaxAttempts < 0 {
+		v.add([]string{"build_check", "max_attempts"}, fmt.Sprintf("%d must not be negative", c.BuildCheck.MaxAttempts), "use 0 to only report a failed build")
+	}
+	events := make([]string, 0, len(c.Hooks))
+	for event := range c.Hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		if !hooks.ValidEvent(event) {
+			suggestion := fmt.Sprintf("use one of %s; tool events can name a tool, as in \"before_tool:execute\"", strings.Join(hooks.Events, ", "))
+			if closest := closestMatch(event, hooks.Events); closest != "" {
+				suggestion = fmt.Sprintf("did you mean %q?", closest)
+			}
+			v.add([]string{"hooks", event}, "unknown event", suggestion)
+			continue
+		}
+		for _, command := range c.Hooks[event] {
+			if strings.TrimSpace(command) == "" {
+				v.add([]string{"hooks", event}, "has an empty command", "remove it or give the shell command to run")
+			}
+		}
+	}
+	for _, stop := range c.StopSequences {
+		if stop == "" {
+			v.add([]string{"stop_sequences"}, "contains an empty sequence", "remove it; use [] to send no stop sequences")
+			break
+		}
+	}
+	models := make([]string, 0, len(c.ModelProfiles))
+	for model := range c.ModelProfiles {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		if !modelNamePattern.MatchString(model) {
+			v.add([]string{"model_profiles", model}, fmt.Sprintf("%q is not a valid model name", model), `use the form "name" or "name:tag"`)
+		}
+		options := c.ModelProfiles[model].Options
+		names := make([]string, 0, len(options))
+		for name := range options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			keys := []string{"model_profiles", model, "options", name}
+			if _, known := ollama.KnownOptions[name]; !known {
+				suggestion := "remove it, or check the option name in the Ollama documentation"
+				if closest := closestMatch(name, ollama.KnownOptionNames()); closest != "" {
+					suggestion = fmt.Sprintf("did you mean %q?", closest)
+				}
+				v.add(keys, "unknown option", suggestion)
+				continue
+			}
+			if err := ollama.CheckOption(name, options[name]); err != nil {
+				v.add(keys, err.Error(), "check the value type in the Ollama documentation")
+			}
+		}
+	}
+	if c.AnalysisPromptTemplate != "" && !strings.Contains(c.AnalysisPromptTemplate, "{{content}}") {
+		v.add([]string{"analysis_prompt_template"}, "does not contain {{content}}, so files would never be shown to the model", "add {{content}} where the file should go, or remove the key to use the built-in template")
+	}
+}
+
+// checkEnum reports a value that is not one of allowed
+func (v *configValidator) checkEnum(keys []string, value string, allowed []string, optional bool) {
+	if value == "" && optional {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	suggestion := "use one of: " + strings.Join(allowed, ", ")
+	if closest := closestMatch(value, allowed); closest != "" {
+		suggestion = fmt.Sprintf("did you mean %q? (allowed: %s)", closest, strings.Join(allowed, ", "))
+	}
+	v.add(keys, fmt.Sprintf("invalid value %q", value), suggestion)
+}
+
+// line returns the line on which the nested key path appears, or 0 if not found
+func (v *configValidator) line(keys ...string) int {
+	switch v.format {
+	case formatYAML:
+		return yamlKeyLine(v.data, keys...)
+	case formatTOML:
+		return tomlKeyLine(v.data, keys...)
+	}
+
+	offset := 0
+	for _, key := range keys {
+		pattern := regexp.MustCompile(`"` + regexp.QuoteMeta(key) + `"\s*:`)
+		loc := pattern.FindIndex(v.data[offset:])
+		if loc == nil {
+			return 0
+		}
+		offset += loc[0]
+	}
+	return lineAt(v.data, int64(offset))
+}
+
+// lineAt converts a byte offset into a 1-based line number
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return strings.Count(string(data[:offset]), "\n") + 1
+}
+
+// jsonFields maps the JSON names of a struct's exported fields to their types
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// describeType names a Go type the way it appears in JSON
+func describeType(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice:
+		return "a list"
+	}
+	return typ.String()
+}
+
+// mapKeys returns the keys of a field map
+func mapKeys(m map[string]reflect.Type) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// closestMatch returns the candidate nearest to s by edit distance, if it is close enough
+func closestMatch(s string, candidates []string) string {
+	best, bestDist := "", -1
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(s), strings.ToLower(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if bestDist < 0 || bestDist > 3 || bestDist > len(s)/2 {
+		return ""
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
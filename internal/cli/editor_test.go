@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano")
+	if got := EditorCommand(); got != "nano" {
+		t.Errorf("EditorCommand() = %q, want $EDITOR", got)
+	}
+	t.Setenv("VISUAL", "code --wait")
+	if got := EditorCommand(); got != "code --wait" {
+		t.Errorf("EditorCommand() = %q, want $VISUAL first", got)
+	}
+}
+
+func TestEditText(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the editor")
+	}
+	// The "editor" appends a line to the file it is given
+	editor := filepath.Join(t.TempDir(), "editor")
+	script := "#!/bin/sh\nprintf 'and more\\n\\n' >> \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+
+	got, err := EditText("a long spec\n")
+	if err != nil {
+		t.Fatalf("EditText() error = %v", err)
+	}
+	if want := "a long spec\nand more"; got != want {
+		t.Errorf("EditText() = %q, want %q", got, want)
+	}
+
+	t.Setenv("VISUAL", filepath.Join(t.TempDir(), "missing-editor"))
+	if _, err := EditText(""); err == nil {
+		t.Error("EditText() should fail when the editor cannot run")
+	}
+}
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +11,9 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"unicode/utf8"
+
+	"codezilla/pkg/style"
 
 	"github.com/mattn/go-runewidth"
 	"golang.org/x/term"
@@ -21,10 +26,21 @@ type FixedInput struct {
 	historyFile  string
 	history      []string
 	historyIndex int
+	historyMax   int // Maximum number of history entries kept
 	mu           sync.Mutex
+	saveMu       sync.Mutex // Serializes history file writes
 	rawMode      bool
 	fd           int
-	currentLines int // Track how many lines the current input spans
+	currentLines int    // Track how many lines the current input spans
+	lastSearch   string // Last reverse search query, reused by Ctrl-R on an empty query
+	vi           viEditor
+
+	// Lines typed while a prompt is processed, see StartQueue
+	queueMu   sync.Mutex
+	queued    []string
+	queueStop chan struct{}
+	queueDone chan struct{}
+	onQueue   func(line string, queued int)
 }
 
 // SetPrompt updates the prompt string
@@ -38,25 +54,37 @@ func stripANSI(str string) string {
 	return ansiRegex.ReplaceAllString(str, "")
 }
 
-// promptDisplayWidth calculates the actual display width of the prompt
+// displayWidth calculates the actual display width of a prompt
 // accounting for ANSI codes and multi-width characters like emoji
-func (fi *FixedInput) promptDisplayWidth() int {
+func displayWidth(prompt string) int {
 	// Remove ANSI escape sequences first
-	clean := stripANSI(fi.prompt)
+	clean := stripANSI(prompt)
 	// Calculate display width using runewidth
 	return runewidth.StringWidth(clean)
 }
 
-// NewFixedInput creates a new input reader with history support but no multi-line bugs
-func NewFixedInput(prompt string, historyFile string) (*FixedInput, error) {
+// lineBreakMarker stands for a line break typed with the newline key, which is sent
+// with the input but would break the redrawing of the line if printed
+const lineBreakMarker = "↵"
+
+// DefaultHistoryMaxEntries is used when no history limit is configured
+const DefaultHistoryMaxEntries = 500
+
+// NewFixedInput creates a new input reader with history support but no multi-line bugs.
+// historyMax caps the number of de-duplicated entries kept (DefaultHistoryMaxEntries if <= 0).
+func NewFixedInput(prompt string, historyFile string, historyMax int) (*FixedInput, error) {
 	fd := int(os.Stdin.Fd())
+	if historyMax <= 0 {
+		historyMax = DefaultHistoryMaxEntries
+	}
 
 	input := &FixedInput{
 		prompt:       prompt,
 		reader:       bufio.NewReader(os.Stdin),
 		historyFile:  historyFile,
-		history:      make([]string, 0, 500),
+		history:      make([]string, 0, historyMax),
 		historyIndex: -1,
+		historyMax:   historyMax,
 		fd:           fd,
 		rawMode:      term.IsTerminal(fd),
 		currentLines: 1,
@@ -72,6 +100,13 @@ func NewFixedInput(prompt string, historyFile string) (*FixedInput, error) {
 
 // ReadLine reads a line of input - simple and reliable
 func (fi *FixedInput) ReadLine() (string, error) {
+	if onQueue := fi.pauseQueue(); onQueue != nil {
+		// An answer asked for while busy, such as a confirmation, is read directly
+		defer fi.StartQueue(onQueue)
+	} else if line, ok := fi.readQueued(); ok {
+		return line, nil
+	}
+
 	// If not in a terminal, just do simple reading
 	if !fi.rawMode {
 		return fi.readSimple()
@@ -103,6 +138,49 @@ func (fi *FixedInput) ReadLine() (string, error) {
 	historySize := len(fi.history)
 	fi.mu.Unlock()
 	savedLine := ""
+	keys := CurrentKeymap()
+
+	// historyPrev and historyNext replace the line with an older or newer entry,
+	// keeping the line being typed to come back to
+	historyPrev := func() bool {
+		if historySize == 0 || fi.historyIndex <= 0 {
+			return false
+		}
+		// Save current line if first time
+		if fi.historyIndex == historySize {
+			savedLine = string(line)
+		}
+		fi.historyIndex--
+		fi.mu.Lock()
+		line = []rune(fi.history[fi.historyIndex])
+		fi.mu.Unlock()
+		pos = len(line)
+		return true
+	}
+	historyNext := func() bool {
+		if historySize == 0 || fi.historyIndex >= historySize {
+			return false
+		}
+		fi.historyIndex++
+		if fi.historyIndex == historySize {
+			// Restore saved line
+			line = []rune(savedLine)
+		} else {
+			fi.mu.Lock()
+			line = []rune(fi.history[fi.historyIndex])
+			fi.mu.Unlock()
+		}
+		pos = len(line)
+		return true
+	}
+
+	// Vi mode starts every line in insert mode, shown with a bar cursor
+	viMode := CurrentEditMode() == EditModeVi
+	if viMode {
+		fi.vi.reset()
+		fmt.Print(cursorBar)
+		defer fmt.Print(cursorDefault)
+	}
 
 	for {
 		// Read one byte
@@ -116,21 +194,123 @@ func (fi *FixedInput) ReadLine() (string, error) {
 			return "", err
 		}
 
-		switch b[0] {
-		case '\r', '\n': // Enter
-			fmt.Print("\r\n")
-			result := string(line)
-			if result != "" {
-				fi.addHistory(result)
+		// Alt combinations arrive as ESC followed by the key; cursor keys as ESC [
+		key := string(b)
+		if b[0] == 0x1B && fi.reader.Buffered() > 0 {
+			if next, err := fi.reader.Peek(1); err == nil && keys.Action(key+string(next)) != "" {
+				fi.reader.ReadByte()
+				key += string(next)
 			}
-			fi.currentLines = 1 // Reset for next input
-			return result, nil
+		}
+
+		// Keys bound in the config come before the editing keys
+		if action := keys.Action(key); action != "" {
+			switch action {
+			case ActionSubmit:
+				fmt.Print("\r\n")
+				result := string(line)
+				if result != "" {
+					fi.addHistory(result)
+				}
+				fi.currentLines = 1 // Reset for next input
+				return result, nil
+
+			case ActionNewline:
+				line = append(line[:pos], append([]rune{'\n'}, line[pos:]...)...)
+				pos++
+				fi.redrawLine(line, pos)
 
-		case 0x03: // Ctrl-C
-			fmt.Print("^C\r\n")
-			fi.currentLines = 1 // Reset for next input
-			return "", io.EOF
+			case ActionCancel:
+				fmt.Print("^C\r\n")
+				fi.currentLines = 1 // Reset for next input
+				return "", io.EOF
 
+			case ActionClear:
+				fmt.Print("\033[2J\033[H")
+				fi.currentLines = 1
+				fi.redrawLine(line, pos)
+
+			case ActionEditor:
+				// The editor gets the terminal as it was before raw mode
+				fmt.Print("\r\n")
+				if err := term.Restore(fi.fd, oldState); err != nil {
+					return "", fmt.Errorf("failed to restore terminal: %w", err)
+				}
+				text, editErr := EditText(string(line))
+				fi.currentLines = 1 // Reset for next input
+				if editErr == nil && strings.TrimSpace(text) != "" {
+					// Show what is sent as if it had been typed
+					fmt.Println(fi.prompt + text)
+					fi.addHistory(text)
+					return text, nil
+				}
+				if _, err := term.MakeRaw(fi.fd); err != nil {
+					return "", err
+				}
+				if editErr != nil {
+					fmt.Printf("%v\r\n", editErr)
+				}
+				fi.redrawLine(line, pos)
+
+			case ActionHistorySearch:
+				found, searchAction, err := fi.reverseSearch(line, keys)
+				if err != nil {
+					return "", err
+				}
+				switch searchAction {
+				case searchSubmit:
+					fmt.Print("\r\n")
+					result := string(found)
+					if result != "" {
+						fi.addHistory(result)
+					}
+					fi.currentLines = 1 // Reset for next input
+					return result, nil
+				case searchAccept:
+					line = found
+					pos = len(line)
+				}
+				fi.redrawLine(line, pos)
+			}
+			continue
+		}
+
+		if viMode {
+			if b[0] == 0x1B && fi.reader.Buffered() == 0 {
+				// A lone ESC leaves insert mode, or drops a partly typed command
+				if fi.vi.normal {
+					fi.vi.clearPending()
+				} else {
+					pos = fi.vi.escape(line, pos)
+					fmt.Print(cursorBlock)
+					fi.redrawLine(line, pos)
+				}
+				continue
+			}
+			if fi.vi.normal && (b[0] >= 32 && b[0] < 127 || b[0] == 0x7F || b[0] == 0x08) {
+				r := rune(b[0])
+				if r == 0x7F || r == 0x08 {
+					r = 'h' // Backspace moves left
+				}
+				var cmd viCommand
+				line, pos, cmd = fi.vi.normalKey(r, line, pos)
+				switch cmd {
+				case viHistoryPrev:
+					historyPrev()
+				case viHistoryNext:
+					historyNext()
+				}
+				if fi.vi.normal {
+					pos = fi.vi.clamp(line, pos)
+				} else {
+					fmt.Print(cursorBar)
+				}
+				fi.redrawLine(line, pos)
+				continue
+			}
+		}
+
+		switch b[0] {
 		case 0x04: // Ctrl-D
 			if len(line) == 0 {
 				fmt.Print("\r\n")
@@ -180,10 +360,6 @@ func (fi *FixedInput) ReadLine() (string, error) {
 				}
 			}
 
-		case 0x0C: // Ctrl-L - clear screen
-			fmt.Print("\033[2J\033[H")
-			fi.redrawLine(line, pos)
-
 		case 0x7F, 0x08: // Backspace
 			if pos > 0 {
 				line = append(line[:pos-1], line[pos:]...)
@@ -198,31 +374,12 @@ func (fi *FixedInput) ReadLine() (string, error) {
 			if n == 2 && seq[0] == '[' {
 				switch seq[1] {
 				case 'A': // Up arrow - previous history
-					if historySize > 0 && fi.historyIndex > 0 {
-						// Save current line if first time
-						if fi.historyIndex == historySize {
-							savedLine = string(line)
-						}
-						fi.historyIndex--
-						fi.mu.Lock()
-						line = []rune(fi.history[fi.historyIndex])
-						fi.mu.Unlock()
-						pos = len(line)
+					if historyPrev() {
 						fi.redrawLine(line, pos)
 					}
 
 				case 'B': // Down arrow - next history
-					if historySize > 0 && fi.historyIndex < historySize {
-						fi.historyIndex++
-						if fi.historyIndex == historySize {
-							// Restore saved line
-							line = []rune(savedLine)
-						} else {
-							fi.mu.Lock()
-							line = []rune(fi.history[fi.historyIndex])
-							fi.mu.Unlock()
-						}
-						pos = len(line)
+					if historyNext() {
 						fi.redrawLine(line, pos)
 					}
 
@@ -273,6 +430,11 @@ func (fi *FixedInput) ReadLine() (string, error) {
 
 // redrawLine redraws the current line with proper wrapping support
 func (fi *FixedInput) redrawLine(line []rune, pos int) {
+	fi.render(fi.prompt, line, pos, -1, -1)
+}
+
+// render draws prompt and line, highlighting line[hlStart:hlEnd] when hlStart >= 0
+func (fi *FixedInput) render(prompt string, line []rune, pos, hlStart, hlEnd int) {
 	// Get terminal dimensions
 	termWidth := 80 // Default
 	if width, _, err := term.GetSize(fi.fd); err == nil && width > 0 {
@@ -280,7 +442,7 @@ func (fi *FixedInput) redrawLine(line []rune, pos int) {
 	}
 
 	// Calculate prompt width
-	promptLen := fi.promptDisplayWidth()
+	promptLen := displayWidth(prompt)
 
 	// Calculate total content including prompt
 	totalLen := promptLen + len(line)
@@ -312,11 +474,17 @@ func (fi *FixedInput) redrawLine(line []rune, pos int) {
 	fmt.Print("\r")
 
 	// Print prompt
-	fmt.Print(fi.prompt)
+	fmt.Print(prompt)
 
-	// Print the line with wrapping
-	text := string(line)
-	fmt.Print(text)
+	// Print the line with wrapping, line breaks shown as one marker cell each
+	show := func(runes []rune) string {
+		return strings.ReplaceAll(string(runes), "\n", style.Text(lineBreakMarker))
+	}
+	if hlStart >= 0 && hlEnd <= len(line) && hlStart < hlEnd {
+		fmt.Print(show(line[:hlStart]) + "\033[7m" + show(line[hlStart:hlEnd]) + "\033[0m" + show(line[hlEnd:]))
+	} else {
+		fmt.Print(show(line))
+	}
 
 	// Update the number of lines we're using
 	fi.currentLines = numLines
@@ -383,18 +551,26 @@ func (fi *FixedInput) loadHistory() {
 	}
 	defer file.Close()
 
+	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line != "" {
-			fi.history = append(fi.history, line)
+			lines = append(lines, line)
 		}
 	}
+
+	fi.history = dedupHistory(lines, fi.historyMax)
 }
 
 func (fi *FixedInput) saveHistory() error {
+	fi.saveMu.Lock()
+	defer fi.saveMu.Unlock()
+
+	// Snapshot so input is never blocked on disk IO
 	fi.mu.Lock()
-	defer fi.mu.Unlock()
+	entries := dedupHistory(fi.history, fi.historyMax)
+	fi.mu.Unlock()
 
 	// Ensure directory exists
 	dir := filepath.Dir(fi.historyFile)
@@ -402,20 +578,30 @@ func (fi *FixedInput) saveHistory() error {
 		return err
 	}
 
-	file, err := os.Create(fi.historyFile)
+	// Write to a temporary file and rename it into place so a crash or a
+	// concurrent save never leaves a truncated history file behind
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(fi.historyFile)+".tmp-*")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Keep last 500 entries
-	start := 0
-	if len(fi.history) > 500 {
-		start = len(fi.history) - 500
+	w := bufio.NewWriter(tmp)
+	for _, entry := range entries {
+		fmt.Fprintln(w, entry)
 	}
-
-	for i := start; i < len(fi.history); i++ {
-		fmt.Fprintln(file, fi.history[i])
+	err = w.Flush()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), fi.historyFile)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
 	}
 
 	return nil
@@ -425,12 +611,23 @@ func (fi *FixedInput) addHistory(line string) {
 	fi.mu.Lock()
 	defer fi.mu.Unlock()
 
-	// Don't add duplicates
-	if len(fi.history) > 0 && fi.history[len(fi.history)-1] == line {
+	// Entries containing newlines cannot be stored one per line
+	if strings.ContainsAny(line, "\r\n") {
 		return
 	}
 
+	// Move an existing identical entry to the end instead of duplicating it
+	for i, entry := range fi.history {
+		if entry == line {
+			fi.history = append(fi.history[:i], fi.history[i+1:]...)
+			break
+		}
+	}
+
 	fi.history = append(fi.history, line)
+	if len(fi.history) > fi.historyMax {
+		fi.history = fi.history[len(fi.history)-fi.historyMax:]
+	}
 	fi.historyIndex = len(fi.history)
 
 	// Save asynchronously
@@ -441,3 +638,226 @@ func (fi *FixedInput) addHistory(line string) {
 		}
 	}()
 }
+
+// dedupHistory keeps the most recent occurrence of each entry, oldest first, capped to limit entries
+func dedupHistory(entries []string, limit int) []string {
+	seen := make(map[string]bool, len(entries))
+	result := make([]string, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		if seen[entries[i]] {
+			continue
+		}
+		seen[entries[i]] = true
+		result = append(result, entries[i])
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+
+	// Restore chronological order
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// ProjectHistoryFile returns the history file used for a project directory.
+// Each project gets its own file next to the global one, keyed by a hash of its path.
+func ProjectHistoryFile(historyFile, projectDir string) string {
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		projectDir = abs
+	}
+	sum := sha256.Sum256([]byte(projectDir))
+	return filepath.Join(filepath.Dir(historyFile), "projects", hex.EncodeToString(sum[:8])+".history")
+}
+
+// searchAction tells ReadLine what to do when a reverse search ends
+type searchAction int
+
+const (
+	searchCancel searchAction = iota // Restore the line as it was before the search
+	searchAccept                     // Put the match in the line for editing
+	searchSubmit                     // Submit the match immediately
+)
+
+// reverseSearch runs an incremental Ctrl-R search over the history, like bash and zsh.
+// Typing refines the query, Ctrl-R cycles to older matches, Enter submits the match,
+// Esc or cursor keys accept it for editing and Ctrl-G or Ctrl-C cancel. Ctrl-R, Enter
+// and Ctrl-C are the default keys of the history_search, submit and cancel actions.
+func (fi *FixedInput) reverseSearch(original []rune, keys *Keymap) ([]rune, searchAction, error) {
+	fi.mu.Lock()
+	history := make([]string, len(fi.history))
+	copy(history, fi.history)
+	fi.mu.Unlock()
+
+	query := ""
+	matchIdx := -1
+	failed := false
+
+	// search looks for query at or before index from
+	search := func(from int) {
+		if query == "" {
+			matchIdx, failed = -1, false
+			return
+		}
+		if idx := searchHistory(history, query, from); idx >= 0 {
+			matchIdx, failed = idx, false
+		} else {
+			failed = true
+		}
+	}
+
+	draw := func() {
+		label := "(reverse-i-search)"
+		if failed {
+			label = "(failed reverse-i-search)"
+		}
+		prompt := fmt.Sprintf("%s`%s': ", label, query)
+
+		if matchIdx < 0 {
+			fi.render(prompt, nil, 0, -1, -1)
+			return
+		}
+		// Highlight the query inside the match, with the cursor at its start
+		entry := history[matchIdx]
+		match := []rune(entry)
+		idx := strings.LastIndex(entry, query)
+		if failed || idx < 0 {
+			fi.render(prompt, match, len(match), -1, -1)
+			return
+		}
+		start := len([]rune(entry[:idx]))
+		end := start + len([]rune(query))
+		fi.render(prompt, match, start, start, end)
+	}
+
+	current := func() []rune {
+		if matchIdx < 0 {
+			return original
+		}
+		return []rune(history[matchIdx])
+	}
+
+	draw()
+
+	for {
+		b := make([]byte, 1)
+		if _, err := fi.reader.Read(b); err != nil {
+			if err == io.EOF {
+				return original, searchCancel, nil
+			}
+			return nil, searchCancel, err
+		}
+
+		action := keys.Action(string(b))
+		switch {
+		case action == ActionSubmit: // Enter - submit the match
+			return current(), searchSubmit, nil
+
+		case action == ActionCancel, b[0] == 0x07: // Ctrl-C, Ctrl-G - cancel
+			return original, searchCancel, nil
+
+		case action == ActionHistorySearch: // Ctrl-R - next older match
+			if query == "" && fi.lastSearch != "" {
+				query = fi.lastSearch
+				search(len(history) - 1)
+			} else if matchIdx > 0 {
+				// Skip older entries identical to the current match
+				idx := searchHistory(history, query, matchIdx-1)
+				for idx >= 0 && history[idx] == history[matchIdx] {
+					idx = searchHistory(history, query, idx-1)
+				}
+				if idx >= 0 {
+					matchIdx, failed = idx, false
+				} else {
+					failed = true
+				}
+			} else {
+				failed = query != ""
+			}
+
+		case b[0] == 0x7F, b[0] == 0x08: // Backspace - shorten the query and search again from the newest entry
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				search(len(history) - 1)
+			}
+
+		case b[0] == 0x1B: // ESC or a cursor key - accept the match for editing
+			fi.skipEscapeSequence()
+			if query != "" {
+				fi.lastSearch = query
+			}
+			return current(), searchAccept, nil
+
+		default:
+			if b[0] >= 32 && b[0] != 0x7F {
+				r := rune(b[0])
+				if b[0] >= utf8.RuneSelf {
+					// The first byte of a multi-byte character; read it whole
+					fi.reader.UnreadByte()
+					read, _, err := fi.reader.ReadRune()
+					if err != nil {
+						return nil, searchCancel, err
+					}
+					if read == utf8.RuneError {
+						continue
+					}
+					r = read
+				}
+				query += string(r)
+				// Keep the current match if it still matches, like bash does
+				from := len(history) - 1
+				if matchIdx >= 0 {
+					from = matchIdx
+				}
+				search(from)
+			} else {
+				// Other control keys accept the match
+				if query != "" {
+					fi.lastSearch = query
+				}
+				return current(), searchAccept, nil
+			}
+		}
+
+		if query != "" {
+			fi.lastSearch = query
+		}
+		draw()
+	}
+}
+
+// skipEscapeSequence consumes the rest of an escape sequence once its ESC has been
+// read: ESC [ followed by parameters up to a final byte, as in ESC [ 3 ~, or ESC O and
+// one more byte. The bytes after ESC are waited for once the sequence has started,
+// since a terminal may deliver it in pieces; a lone ESC consumes nothing.
+func (fi *FixedInput) skipEscapeSequence() {
+	if fi.reader.Buffered() == 0 {
+		return
+	}
+	if next, err := fi.reader.Peek(1); err != nil || next[0] != '[' && next[0] != 'O' {
+		return
+	}
+	fi.reader.ReadByte()
+	const maxSequence = 16
+	for i := 0; i < maxSequence; i++ {
+		c, err := fi.reader.ReadByte()
+		if err != nil || c >= 0x40 && c <= 0x7E {
+			return
+		}
+	}
+}
+
+// searchHistory returns the index of the newest entry at or before from containing query, or -1
+func searchHistory(history []string, query string, from int) int {
+	if from >= len(history) {
+		from = len(history) - 1
+	}
+	for i := from; i >= 0; i-- {
+		if strings.Contains(history[i], query) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchHistory(t *testing.T) {
+	history := []string{"git status", "go test ./...", "git commit -m fix", "go build"}
+
+	tests := []struct {
+		query string
+		from  int
+		want  int
+	}{
+		{"git", 3, 2},
+		{"git", 1, 0},
+		{"go", 10, 3},
+		{"test", 3, 1},
+		{"docker", 3, -1},
+		{"git", -1, -1},
+	}
+	for _, tt := range tests {
+		if got := searchHistory(history, tt.query, tt.from); got != tt.want {
+			t.Errorf("searchHistory(%q, %d) = %d, want %d", tt.query, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestDedupHistory(t *testing.T) {
+	got := dedupHistory([]string{"a", "b", "a", "c", "b", "d"}, 3)
+	want := []string{"c", "b", "d"}
+	if len(got) != len(want) {
+		t.Fatalf("dedupHistory = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dedupHistory = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestHistorySaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	fi := &FixedInput{historyFile: file, historyMax: 3}
+	for _, line := range []string{"one", "two", "one", "three", "four"} {
+		fi.mu.Lock()
+		fi.history = append(fi.history, line)
+		fi.mu.Unlock()
+	}
+	if err := fi.saveHistory(); err != nil {
+		t.Fatalf("saveHistory failed: %v", err)
+	}
+
+	loaded := &FixedInput{historyFile: file, historyMax: 10}
+	loaded.loadHistory()
+	want := []string{"one", "three", "four"}
+	if strings.Join(loaded.history, ",") != strings.Join(want, ",") {
+		t.Errorf("loaded history = %v, want %v", loaded.history, want)
+	}
+}
+
+func TestProjectHistoryFile(t *testing.T) {
+	a := ProjectHistoryFile("/home/u/.config/codezilla/history", "/src/a")
+	b := ProjectHistoryFile("/home/u/.config/codezilla/history", "/src/b")
+	if a == b {
+		t.Error("different projects should use different history files")
+	}
+	if filepath.Dir(a) != "/home/u/.config/codezilla/projects" {
+		t.Errorf("unexpected project history location: %s", a)
+	}
+}
+
+// chunkReader returns one chunk per Read, like a terminal delivering keys in pieces
+type chunkReader []string
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(*r) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*r)[0])
+	(*r)[0] = (*r)[0][n:]
+	if (*r)[0] == "" {
+		*r = (*r)[1:]
+	}
+	return n, nil
+}
+
+func TestReverseSearch(t *testing.T) {
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	history := []string{"make build", "grüße an alle", "go test ./..."}
+	tests := []struct {
+		name   string
+		chunks chunkReader
+		want   string
+		action searchAction
+		rest   string
+	}{
+		{"utf-8 query", chunkReader{"grü\r"}, "grüße an alle", searchSubmit, ""},
+		{"split multi-byte character", chunkReader{"gr\xc3", "\xbc\r"}, "grüße an alle", searchSubmit, ""},
+		{"delete key in pieces", chunkReader{"mak\x1b[", "3~x"}, "make build", searchAccept, "x"},
+		{"cursor key", chunkReader{"go\x1bOD"}, "go test ./...", searchAccept, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fi := &FixedInput{history: history, reader: bufio.NewReader(&tt.chunks), currentLines: 1}
+			got, action, err := fi.reverseSearch(nil, mustKeymap(DefaultKeyBindings))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want || action != tt.action {
+				t.Errorf("reverseSearch() = %q, %v; want %q, %v", string(got), action, tt.want, tt.action)
+			}
+			if rest, _ := io.ReadAll(fi.reader); string(rest) != tt.rest {
+				t.Errorf("left %q unread, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
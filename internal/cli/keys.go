@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Input actions that can be bound to keys in the config
+const (
+	ActionSubmit        = "submit"         // Send the line
+	ActionNewline       = "newline"        // Insert a line break without sending
+	ActionCancel        = "cancel"         // Discard the line, like Ctrl-C
+	ActionHistorySearch = "history_search" // Search the history backwards
+	ActionClear         = "clear"          // Clear the screen
+	ActionEditor        = "editor"         // Compose the input in $EDITOR
+)
+
+// KeyActions are the bindable actions, in the order /keys lists them
+var KeyActions = []string{ActionSubmit, ActionNewline, ActionCancel, ActionHistorySearch, ActionClear, ActionEditor}
+
+// keyActionDescriptions describe the actions for /keys
+var keyActionDescriptions = map[string]string{
+	ActionSubmit:        "Send the input",
+	ActionNewline:       "Insert a line break",
+	ActionCancel:        "Discard the input",
+	ActionHistorySearch: "Search the input history",
+	ActionClear:         "Clear the screen",
+	ActionEditor:        "Edit the input in $EDITOR and send it",
+}
+
+// DefaultKeyBindings are the keys of actions not bound in the config
+var DefaultKeyBindings = map[string]string{
+	ActionSubmit:        "enter",
+	ActionNewline:       "alt-enter",
+	ActionCancel:        "ctrl-c",
+	ActionHistorySearch: "ctrl-r",
+	ActionClear:         "ctrl-l",
+	ActionEditor:        "alt-e",
+}
+
+// KeyBinding is the key bound to an action
+type KeyBinding struct {
+	Action      string
+	Key         string
+	Description string
+	Custom      bool // Bound in the config rather than by default
+}
+
+// Keymap maps the bytes a terminal sends for a key to the action bound to it
+type Keymap struct {
+	bindings []KeyBinding
+	actions  map[string]string // Key sequence -> action
+}
+
+// ParseKey returns the bytes a terminal sends for a key name such as "enter", "tab",
+// "ctrl-s", "alt-enter" or "alt-x". Names are case-insensitive and may use "+".
+func ParseKey(name string) (string, error) {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "+", "-")
+	prefix := ""
+	if rest, ok := strings.CutPrefix(key, "alt-"); ok {
+		prefix, key = "\x1b", rest
+	}
+	switch {
+	case key == "enter" || key == "return":
+		return prefix + "\r", nil
+	case key == "tab":
+		return prefix + "\t", nil
+	case strings.HasPrefix(key, "ctrl-") && len(key) == len("ctrl-")+1:
+		c := key[len(key)-1]
+		if c >= 'a' && c <= 'z' {
+			return prefix + string(rune(c-'a'+1)), nil
+		}
+	case prefix != "" && len(key) == 1 && key[0] > ' ' && key[0] < 0x7f && key[0] != '[':
+		return prefix + key, nil
+	}
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// NewKeymap binds the actions to the keys in bindings, and to their default keys
+// otherwise. Unknown actions and keys, and keys bound to two actions, are errors.
+func NewKeymap(bindings map[string]string) (*Keymap, error) {
+	for action := range bindings {
+		if _, ok := DefaultKeyBindings[action]; !ok {
+			return nil, fmt.Errorf("unknown key action %q", action)
+		}
+	}
+	km := &Keymap{actions: make(map[string]string)}
+	for _, action := range KeyActions {
+		name, custom := bindings[action]
+		if !custom {
+			name = DefaultKeyBindings[action]
+		}
+		seq, err := ParseKey(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", action, err)
+		}
+		if other, ok := km.actions[seq]; ok {
+			return nil, fmt.Errorf("%s: %s is also bound to %s", action, name, other)
+		}
+		km.actions[seq] = action
+		km.bindings = append(km.bindings, KeyBinding{Action: action, Key: name, Description: keyActionDescriptions[action], Custom: custom})
+	}
+	return km, nil
+}
+
+// Action returns the action bound to a key sequence, or "" when there is none.
+// Terminals send Enter as either CR or LF, so LF falls back to the binding of CR.
+func (km *Keymap) Action(seq string) string {
+	if action, ok := km.actions[seq]; ok {
+		return action
+	}
+	if strings.HasSuffix(seq, "\n") {
+		return km.actions[strings.TrimSuffix(seq, "\n")+"\r"]
+	}
+	return ""
+}
+
+// Bindings returns the key of every action, in the order of KeyActions
+func (km *Keymap) Bindings() []KeyBinding {
+	return append([]KeyBinding(nil), km.bindings...)
+}
+
+var (
+	keymapMu sync.RWMutex
+	keymap   = mustKeymap(nil)
+)
+
+// mustKeymap creates a keymap that is known to be valid
+func mustKeymap(bindings map[string]string) *Keymap {
+	km, err := NewKeymap(bindings)
+	if err != nil {
+		panic(err)
+	}
+	return km
+}
+
+// SetKeyBindings makes bindings the keys used by every input reader. On an error the
+// current keys are kept.
+func SetKeyBindings(bindings map[string]string) error {
+	km, err := NewKeymap(bindings)
+	if err != nil {
+		return err
+	}
+	keymapMu.Lock()
+	keymap = km
+	keymapMu.Unlock()
+	return nil
+}
+
+// CurrentKeymap returns the keys used by input readers
+func CurrentKeymap() *Keymap {
+	keymapMu.RLock()
+	defer keymapMu.RUnlock()
+	return keymap
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"enter", "\r", false},
+		{"Tab", "\t", false},
+		{"ctrl-r", "\x12", false},
+		{"Ctrl+S", "\x13", false},
+		{"alt-enter", "\x1b\r", false},
+		{"alt-x", "\x1bx", false},
+		{"ctrl-", "", true},
+		{"ctrl-1", "", true},
+		{"f5", "", true},
+		{"alt-[", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseKey(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseKey(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewKeymap(t *testing.T) {
+	km, err := NewKeymap(map[string]string{ActionSubmit: "ctrl-s", ActionNewline: "enter"})
+	if err != nil {
+		t.Fatalf("NewKeymap() error = %v", err)
+	}
+	for seq, want := range map[string]string{
+		"\x13":   ActionSubmit,
+		"\r":     ActionNewline,
+		"\n":     ActionNewline, // LF is Enter too
+		"\x1b\r": "",            // alt-enter is no longer bound
+		"\x12":   ActionHistorySearch,
+		"a":      "",
+	} {
+		if got := km.Action(seq); got != want {
+			t.Errorf("Action(%q) = %q, want %q", seq, got, want)
+		}
+	}
+
+	bindings := km.Bindings()
+	if len(bindings) != len(KeyActions) || bindings[0].Action != ActionSubmit || !bindings[0].Custom || bindings[2].Custom {
+		t.Errorf("Bindings() = %+v", bindings)
+	}
+
+	for _, bad := range []map[string]string{
+		{"submitt": "enter"},
+		{ActionClear: "ctrl-"},
+		{ActionClear: "ctrl-r"}, // Taken by history_search
+	} {
+		if _, err := NewKeymap(bad); err == nil {
+			t.Errorf("NewKeymap(%v) should fail", bad)
+		}
+	}
+}
+
+func TestSetKeyBindingsKeepsKeysOnError(t *testing.T) {
+	defer SetKeyBindings(nil)
+
+	if err := SetKeyBindings(map[string]string{ActionCancel: "ctrl-g"}); err != nil {
+		t.Fatalf("SetKeyBindings() error = %v", err)
+	}
+	if err := SetKeyBindings(map[string]string{ActionCancel: "nope"}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("SetKeyBindings() error = %v, want unknown key", err)
+	}
+	if got := CurrentKeymap().Action("\x07"); got != ActionCancel {
+		t.Errorf("cancel should still be ctrl-g, Action = %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cli
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// inputReady reports whether fd has input to read within timeout. In the terminal's
+// normal line mode that means a whole line has been typed.
+func inputReady(fd int, timeout time.Duration) bool {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	ready, err := unix.Poll(fds, int(timeout/time.Millisecond))
+	return err == nil && ready > 0
+}
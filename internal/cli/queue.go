@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// queuePollInterval is how often the queue checks for a typed line, and so how long
+// StopQueue may wait
+const queuePollInterval = 100 * time.Millisecond
+
+// StartQueue collects the lines the user submits while a prompt is being processed.
+// The terminal stays in its normal line mode, so each line is queued when Enter is
+// pressed; onQueue is called with it and the number of lines waiting. Once the queue
+// is stopped, ReadLine returns the queued lines in order before reading new input.
+func (fi *FixedInput) StartQueue(onQueue func(line string, queued int)) {
+	if !fi.rawMode {
+		// Piped input is read in order anyway
+		return
+	}
+	fi.StopQueue()
+	fi.queueMu.Lock()
+	defer fi.queueMu.Unlock()
+	fi.onQueue = onQueue
+	fi.queueStop = make(chan struct{})
+	fi.queueDone = make(chan struct{})
+	go fi.collect(fi.queueStop, fi.queueDone, onQueue)
+}
+
+// StopQueue stops collecting lines; the lines already queued are kept for ReadLine
+func (fi *FixedInput) StopQueue() {
+	fi.pauseQueue()
+}
+
+// Queued returns the lines waiting to be read
+func (fi *FixedInput) Queued() []string {
+	fi.queueMu.Lock()
+	defer fi.queueMu.Unlock()
+	return append([]string(nil), fi.queued...)
+}
+
+// pauseQueue stops collecting lines, waiting for the collector to finish, and returns
+// the callback to start again with, or nil when the queue was not running
+func (fi *FixedInput) pauseQueue() func(string, int) {
+	fi.queueMu.Lock()
+	stop, done, onQueue := fi.queueStop, fi.queueDone, fi.onQueue
+	fi.queueStop, fi.queueDone, fi.onQueue = nil, nil, nil
+	fi.queueMu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	<-done
+	if onQueue == nil {
+		onQueue = func(string, int) {}
+	}
+	return onQueue
+}
+
+// popQueued removes and returns the oldest queued line
+func (fi *FixedInput) popQueued() (string, bool) {
+	fi.queueMu.Lock()
+	defer fi.queueMu.Unlock()
+	if len(fi.queued) == 0 {
+		return "", false
+	}
+	line := fi.queued[0]
+	fi.queued = fi.queued[1:]
+	return line, true
+}
+
+// collect queues typed lines until stop is closed. It only reads once a whole line is
+// available, so no keystroke is consumed after stop.
+func (fi *FixedInput) collect(stop <-chan struct{}, done chan<- struct{}, onQueue func(string, int)) {
+	defer close(done)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if fi.reader.Buffered() == 0 && !inputReady(fi.fd, queuePollInterval) {
+			continue
+		}
+
+		line, err := fi.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fi.addHistory(line)
+		fi.queueMu.Lock()
+		fi.queued = append(fi.queued, line)
+		n := len(fi.queued)
+		fi.queueMu.Unlock()
+		if onQueue != nil {
+			onQueue(line, n)
+		}
+	}
+}
+
+// ReadAnswer reads the answer to a question asked while a prompt is processed, such as
+// a permission request, in the terminal's normal line mode. Unlike ReadLine it prints
+// no prompt, doesn't add the answer to the history and never takes a queued line as
+// the answer.
+func (fi *FixedInput) ReadAnswer() (string, error) {
+	if onQueue := fi.pauseQueue(); onQueue != nil {
+		defer fi.StartQueue(onQueue)
+	}
+	line, err := fi.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readQueued returns the next queued line, shown after the prompt as if just typed
+func (fi *FixedInput) readQueued() (string, bool) {
+	line, ok := fi.popQueued()
+	if ok {
+		fmt.Println(fi.prompt + line)
+	}
+	return line, ok
+}
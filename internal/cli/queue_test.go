@@ -0,0 +1,118 @@
+//go:build !windows
+
+package cli
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueCollectsLinesInOrder(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	fi := &FixedInput{
+		reader:      bufio.NewReader(r),
+		fd:          int(r.Fd()),
+		rawMode:     true,
+		historyFile: filepath.Join(t.TempDir(), "history"),
+		historyMax:  10,
+	}
+	announced := make(chan int, 3)
+	fi.StartQueue(func(line string, queued int) { announced <- queued })
+
+	w.WriteString("first\n\nsecond\n")
+	for want := 1; want <= 2; want++ {
+		select {
+		case got := <-announced:
+			if got != want {
+				t.Errorf("announced %d queued, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("line %d was not queued", want)
+		}
+	}
+	fi.StopQueue()
+
+	// A line typed after the queue stopped is left for the line editor
+	w.WriteString("later\n")
+	time.Sleep(2 * queuePollInterval)
+	if got := fi.Queued(); len(got) != 2 {
+		t.Fatalf("Queued() = %q, want the two lines typed while busy", got)
+	}
+
+	for _, want := range []string{"first", "second"} {
+		got, err := fi.ReadLine()
+		if err != nil || got != want {
+			t.Errorf("ReadLine() = %q, %v; want %q", got, err, want)
+		}
+	}
+	if len(fi.Queued()) != 0 {
+		t.Errorf("queue should be empty, got %q", fi.Queued())
+	}
+}
+
+func TestReadAnswerKeepsQueue(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	fi := &FixedInput{
+		reader:      bufio.NewReader(r),
+		fd:          int(r.Fd()),
+		rawMode:     true,
+		historyFile: filepath.Join(t.TempDir(), "history"),
+		historyMax:  10,
+	}
+	announced := make(chan int, 2)
+	fi.StartQueue(func(line string, queued int) { announced <- queued })
+	defer fi.StopQueue()
+
+	w.WriteString("queued\n")
+	select {
+	case <-announced:
+	case <-time.After(2 * time.Second):
+		t.Fatal("line was not queued")
+	}
+
+	// The answer is typed once ReadAnswer has stopped the queue
+	go func() {
+		time.Sleep(3 * queuePollInterval)
+		w.WriteString("y\n")
+	}()
+	if got, err := fi.ReadAnswer(); err != nil || got != "y" {
+		t.Fatalf("ReadAnswer() = %q, %v; want the typed answer", got, err)
+	}
+	if got := fi.Queued(); len(got) != 1 || got[0] != "queued" {
+		t.Errorf("Queued() = %q, want the queued line kept", got)
+	}
+	fi.mu.Lock()
+	history := append([]string(nil), fi.history...)
+	fi.mu.Unlock()
+	for _, entry := range history {
+		if entry == "y" {
+			t.Errorf("history %q has the answer", history)
+		}
+	}
+
+	// The queue runs again after the answer
+	w.WriteString("after\n")
+	select {
+	case got := <-announced:
+		if got != 2 {
+			t.Errorf("announced %d queued, want 2", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queue did not restart after the answer")
+	}
+}
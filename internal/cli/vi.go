@@ -0,0 +1,420 @@
+package cli
+
+import (
+	"unicode"
+)
+
+// Input editing modes
+const (
+	EditModeEmacs = "emacs" // Ctrl-A, Ctrl-E, Ctrl-W and the other readline keys
+	EditModeVi    = "vi"    // Modal editing as in vi, starting each line in insert mode
+)
+
+var editMode = EditModeEmacs
+
+// SetEditMode selects the editing mode of every input reader; anything but
+// EditModeVi selects emacs-style editing
+func SetEditMode(mode string) {
+	keymapMu.Lock()
+	defer keymapMu.Unlock()
+	if mode != EditModeVi {
+		mode = EditModeEmacs
+	}
+	editMode = mode
+}
+
+// CurrentEditMode returns the editing mode of input readers
+func CurrentEditMode() string {
+	keymapMu.RLock()
+	defer keymapMu.RUnlock()
+	return editMode
+}
+
+// Cursor shapes showing the vi mode, where the terminal supports them
+const (
+	cursorBlock   = "\033[2 q"
+	cursorBar     = "\033[6 q"
+	cursorDefault = "\033[0 q"
+)
+
+// viCommand is what a normal-mode key asks ReadLine to do besides editing the line
+type viCommand int
+
+const (
+	viNone        viCommand = iota
+	viHistoryPrev           // k
+	viHistoryNext           // j
+)
+
+// unnamedRegister is the register used when none is selected with "x
+const unnamedRegister = '"'
+
+// viEditor keeps the vi state of an input reader. Registers outlive the line they
+// were filled on, so text yanked from one input can be pasted into the next.
+type viEditor struct {
+	normal    bool
+	count     int  // Count typed before a command, 0 for none
+	pending   rune // Operator (d, c, y) waiting for its motion, or r waiting for its character
+	opCount   int  // Count typed before the pending operator
+	selecting bool // " typed, waiting for a register name
+	register  rune // Register selected for the next yank, delete or paste
+	registers map[rune][]rune
+	undoLine  []rune // Line before the last change, for u
+	undoPos   int
+}
+
+// reset starts a new line in insert mode
+func (v *viEditor) reset() {
+	v.normal = false
+	v.clearPending()
+	v.undoLine, v.undoPos = nil, 0
+}
+
+// clearPending forgets a partly typed command
+func (v *viEditor) clearPending() {
+	v.count, v.pending, v.opCount, v.selecting, v.register = 0, 0, 0, false, 0
+}
+
+// escape leaves insert mode, moving the cursor onto the last inserted character
+func (v *viEditor) escape(line []rune, pos int) int {
+	v.normal = true
+	v.clearPending()
+	if pos > 0 {
+		pos--
+	}
+	return pos
+}
+
+// save remembers the line before a change so u can restore it
+func (v *viEditor) save(line []rune, pos int) {
+	v.undoLine = append([]rune(nil), line...)
+	v.undoPos = pos
+}
+
+// setRegister stores text in the selected register. An uppercase name appends to its
+// lowercase register, and the unnamed register always gets the text too.
+func (v *viEditor) setRegister(text []rune) {
+	if v.registers == nil {
+		v.registers = make(map[rune][]rune)
+	}
+	text = append([]rune(nil), text...)
+	switch name := v.register; {
+	case name >= 'A' && name <= 'Z':
+		lower := unicode.ToLower(name)
+		v.registers[lower] = append(v.registers[lower], text...)
+		text = v.registers[lower]
+	case name >= 'a' && name <= 'z':
+		v.registers[name] = text
+	}
+	v.registers[unnamedRegister] = text
+}
+
+// getRegister returns the text of the selected register
+func (v *viEditor) getRegister() []rune {
+	name := unicode.ToLower(v.register)
+	if name == 0 {
+		name = unnamedRegister
+	}
+	return v.registers[name]
+}
+
+// normalKey runs a normal-mode key on line and returns the edited line and cursor
+func (v *viEditor) normalKey(r rune, line []rune, pos int) ([]rune, int, viCommand) {
+	if v.selecting {
+		v.selecting = false
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			v.register = r
+		}
+		return line, pos, viNone
+	}
+	if v.pending == 'r' {
+		v.pending = 0
+		if pos < len(line) {
+			v.save(line, pos)
+			line = append([]rune(nil), line...)
+			line[pos] = r
+		}
+		v.clearPending()
+		return line, pos, viNone
+	}
+
+	// Counts: 0 is a motion unless it continues a count
+	if (r >= '1' && r <= '9') || (r == '0' && v.count > 0) {
+		v.count = v.count*10 + int(r-'0')
+		return line, pos, viNone
+	}
+	count := max(v.count, 1)
+	v.count = 0
+
+	if v.pending != 0 {
+		line, pos = v.operate(r, line, pos, count)
+		return line, v.clamp(line, pos), viNone
+	}
+
+	cmd := viNone
+	switch r {
+	case '"':
+		v.selecting = true
+		return line, pos, viNone
+	case 'd', 'c', 'y':
+		v.pending, v.opCount = r, count
+		return line, pos, viNone
+	case 'r':
+		v.pending = 'r'
+		return line, pos, viNone
+
+	case 'i':
+		v.save(line, pos)
+		v.normal = false
+	case 'a':
+		v.save(line, pos)
+		v.normal = false
+		pos = min(pos+1, len(line))
+	case 'I':
+		v.save(line, pos)
+		v.normal = false
+		pos = firstNonBlank(line)
+	case 'A':
+		v.save(line, pos)
+		v.normal = false
+		pos = len(line)
+
+	case 'x':
+		line, pos = v.deleteRange(line, pos, min(pos+count, len(line)))
+	case 'X':
+		start := max(pos-count, 0)
+		line, pos = v.deleteRange(line, start, pos)
+	case 'D':
+		line, pos = v.deleteRange(line, pos, len(line))
+	case 'C':
+		line, pos = v.deleteRange(line, pos, len(line))
+		v.normal = false
+	case 's':
+		line, pos = v.deleteRange(line, pos, min(pos+count, len(line)))
+		v.normal = false
+	case 'S':
+		line, pos = v.deleteRange(line, 0, len(line))
+		v.normal = false
+
+	case 'p', 'P':
+		text := v.getRegister()
+		if len(text) > 0 {
+			v.save(line, pos)
+			at := pos
+			if r == 'p' && len(line) > 0 {
+				at++
+			}
+			var paste []rune
+			for i := 0; i < count; i++ {
+				paste = append(paste, text...)
+			}
+			line = append(append(append([]rune(nil), line[:at]...), paste...), line[at:]...)
+			pos = at + len(paste) - 1
+		}
+	case '~':
+		if pos < len(line) {
+			v.save(line, pos)
+			line = append([]rune(nil), line...)
+			for i := pos; i < min(pos+count, len(line)); i++ {
+				if unicode.IsUpper(line[i]) {
+					line[i] = unicode.ToLower(line[i])
+				} else {
+					line[i] = unicode.ToUpper(line[i])
+				}
+			}
+			pos = min(pos+count, len(line))
+		}
+	case 'u':
+		if v.undoLine != nil {
+			undoLine, undoPos := v.undoLine, v.undoPos
+			v.save(line, pos)
+			line, pos = undoLine, undoPos
+		}
+
+	case 'k':
+		cmd = viHistoryPrev
+	case 'j':
+		cmd = viHistoryNext
+
+	default:
+		if target, _, ok := viMotion(r, line, pos, count); ok {
+			pos = target
+		}
+	}
+	v.register = 0
+	if v.normal {
+		pos = v.clamp(line, pos)
+	}
+	return line, pos, cmd
+}
+
+// operate applies the pending operator over the motion r
+func (v *viEditor) operate(r rune, line []rune, pos, count int) ([]rune, int) {
+	op := v.pending
+	count *= max(v.opCount, 1)
+	v.pending, v.opCount = 0, 0
+	defer func() { v.register = 0 }()
+
+	start, end := pos, pos
+	switch {
+	case r == op: // dd, cc, yy take the whole line
+		start, end = 0, len(line)
+	case op == 'c' && (r == 'w' || r == 'W') && pos < len(line):
+		// cw changes to the end of the word under the cursor, as in vi
+		big := r == 'W'
+		target := pos
+		if class := wordClass(line[pos], big); class != 0 {
+			for target+1 < len(line) && wordClass(line[target+1], big) == class {
+				target++
+			}
+		}
+		for i := 1; i < count; i++ {
+			target = wordEnd(line, target, big)
+		}
+		end = target + 1
+	default:
+		target, inclusive, ok := viMotion(r, line, pos, count)
+		if !ok {
+			return line, pos
+		}
+		start, end = min(pos, target), max(pos, target)
+		if inclusive {
+			end = min(end+1, len(line))
+		}
+	}
+
+	switch op {
+	case 'y':
+		v.setRegister(line[start:end])
+		if r == op {
+			return line, pos
+		}
+		return line, start
+	case 'c':
+		line, pos = v.deleteRange(line, start, end)
+		v.normal = false
+		return line, pos
+	default:
+		return v.deleteRange(line, start, end)
+	}
+}
+
+// deleteRange removes line[start:end] into the selected register
+func (v *viEditor) deleteRange(line []rune, start, end int) ([]rune, int) {
+	if start >= end {
+		return line, start
+	}
+	v.save(line, start)
+	v.setRegister(line[start:end])
+	return append(append([]rune(nil), line[:start]...), line[end:]...), start
+}
+
+// clamp keeps the normal-mode cursor on a character
+func (v *viEditor) clamp(line []rune, pos int) int {
+	if v.normal && pos >= len(line) {
+		pos = len(line) - 1
+	}
+	return max(pos, 0)
+}
+
+// viMotion returns where a motion key moves the cursor count times, and whether the
+// character it lands on is part of the range an operator works on
+func viMotion(r rune, line []rune, pos, count int) (target int, inclusive bool, ok bool) {
+	target = pos
+	for i := 0; i < count; i++ {
+		switch r {
+		case 'h':
+			target = max(target-1, 0)
+		case 'l', ' ':
+			target = min(target+1, len(line))
+		case '0':
+			return 0, false, true
+		case '^':
+			return firstNonBlank(line), false, true
+		case '$':
+			return max(len(line)-1, 0), true, true
+		case 'w', 'W':
+			target = nextWordStart(line, target, r == 'W')
+		case 'b', 'B':
+			target = prevWordStart(line, target, r == 'B')
+		case 'e', 'E':
+			target = wordEnd(line, target, r == 'E')
+			inclusive = true
+		default:
+			return pos, false, false
+		}
+	}
+	return target, inclusive, true
+}
+
+// wordClass sorts characters for word motions: 0 for blanks, 1 for letters, digits and
+// underscores, 2 for other punctuation. Big words (W, B, E) only split on blanks.
+func wordClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// nextWordStart returns the start of the word after pos, or the end of the line
+func nextWordStart(line []rune, pos int, big bool) int {
+	if pos >= len(line) {
+		return len(line)
+	}
+	i := pos
+	if c := wordClass(line[i], big); c != 0 {
+		for i < len(line) && wordClass(line[i], big) == c {
+			i++
+		}
+	}
+	for i < len(line) && wordClass(line[i], big) == 0 {
+		i++
+	}
+	return i
+}
+
+// prevWordStart returns the start of the word before pos, or of the word pos is in
+func prevWordStart(line []rune, pos int, big bool) int {
+	i := min(pos, len(line))
+	for i > 0 && wordClass(line[i-1], big) == 0 {
+		i--
+	}
+	if i == 0 {
+		return 0
+	}
+	c := wordClass(line[i-1], big)
+	for i > 0 && wordClass(line[i-1], big) == c {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns the last character of the word after pos, or of the word pos is in
+func wordEnd(line []rune, pos int, big bool) int {
+	i := pos + 1
+	for i < len(line) && wordClass(line[i], big) == 0 {
+		i++
+	}
+	if i >= len(line) {
+		return max(len(line)-1, 0)
+	}
+	c := wordClass(line[i], big)
+	for i+1 < len(line) && wordClass(line[i+1], big) == c {
+		i++
+	}
+	return i
+}
+
+// firstNonBlank returns the position of the first character that is not a blank
+func firstNonBlank(line []rune) int {
+	for i, r := range line {
+		if !unicode.IsSpace(r) {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package cli
+
+import "testing"
+
+// runVi types keys in normal mode on line, starting with the cursor at pos
+func runVi(v *viEditor, line string, pos int, keys string) (string, int) {
+	runes := []rune(line)
+	v.normal = true
+	for _, r := range keys {
+		runes, pos, _ = v.normalKey(r, runes, pos)
+	}
+	return string(runes), pos
+}
+
+func TestViNormalMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		pos     int
+		keys    string
+		want    string
+		wantPos int
+		insert  bool // Left in insert mode
+	}{
+		{"word forward", "go test ./...", 0, "w", "go test ./...", 3, false},
+		{"word forward over punctuation", "go test ./...", 3, "w", "go test ./...", 8, false},
+		{"big word forward", "a.b c", 0, "W", "a.b c", 4, false},
+		{"word back", "go test ./...", 8, "b", "go test ./...", 3, false},
+		{"word end", "go test", 0, "e", "go test", 1, false},
+		{"count", "one two three four", 0, "3w", "one two three four", 14, false},
+		{"end of line", "hello", 0, "$", "hello", 4, false},
+		{"first non-blank", "  hi", 3, "^", "  hi", 2, false},
+		{"delete char", "hello", 1, "x", "hllo", 1, false},
+		{"delete char at end", "hello", 4, "x", "hell", 3, false},
+		{"delete before", "hello", 2, "X", "hllo", 1, false},
+		{"delete word", "one two three", 0, "dw", "two three", 0, false},
+		{"delete two words", "one two three", 0, "d2w", "three", 0, false},
+		{"delete to end", "one two three", 4, "D", "one ", 3, false},
+		{"delete to word end", "one two", 0, "de", " two", 0, false},
+		{"delete word back", "one two", 4, "db", "two", 0, false},
+		{"delete line", "one two", 3, "dd", "", 0, false},
+		{"change word", "one two", 0, "cw", " two", 0, true},
+		{"change word from its last letter", "one two", 2, "cw", "on two", 2, true},
+		{"change to end", "one two", 4, "C", "one ", 4, true},
+		{"change line", "one two", 2, "cc", "", 0, true},
+		{"append", "ab", 0, "a", "ab", 1, true},
+		{"append at end", "ab", 0, "A", "ab", 2, true},
+		{"replace", "cat", 0, "rb", "bat", 0, false},
+		{"toggle case", "abc", 0, "~~", "ABc", 2, false},
+		{"yank and paste", "ab", 0, "ylp", "aab", 1, false},
+		{"yank word and paste before", "go test", 3, "yw0P", "testgo test", 3, false},
+		{"delete and paste", "abc", 0, "xp", "bac", 1, false},
+		{"undo", "one two", 0, "dwu", "one two", 0, false},
+		{"undo undo", "one two", 0, "dwuu", "two", 0, false},
+		{"named register", "one two", 0, `"adw"bdw"aP`, "one ", 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &viEditor{}
+			got, pos := runVi(v, tt.line, tt.pos, tt.keys)
+			if got != tt.want || pos != tt.wantPos || v.normal == tt.insert {
+				t.Errorf("%q on %q = %q at %d (insert %v), want %q at %d (insert %v)",
+					tt.keys, tt.line, got, pos, !v.normal, tt.want, tt.wantPos, tt.insert)
+			}
+		})
+	}
+}
+
+func TestViRegisters(t *testing.T) {
+	v := &viEditor{}
+	runVi(v, "one two", 0, `"ayw`)
+	runVi(v, "one two", 4, `"Ayw`)
+	if got := string(v.registers['a']); got != "one two" {
+		t.Errorf(`register a = %q, want "one two"`, got)
+	}
+
+	// Registers outlive the line they were filled on
+	v.reset()
+	if got, _ := runVi(v, "x", 0, `"ap`); got != "xone two" {
+		t.Errorf("paste from register a = %q, want %q", got, "xone two")
+	}
+}
+
+func TestViHistoryKeys(t *testing.T) {
+	v := &viEditor{normal: true}
+	if _, _, cmd := v.normalKey('k', nil, 0); cmd != viHistoryPrev {
+		t.Errorf("k = %v, want viHistoryPrev", cmd)
+	}
+	if _, _, cmd := v.normalKey('j', nil, 0); cmd != viHistoryNext {
+		t.Errorf("j = %v, want viHistoryNext", cmd)
+	}
+}
@@ -1,29 +1,67 @@
 package core
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"codezilla/internal/agent"
+	"codezilla/internal/analytics"
 	"codezilla/internal/cli"
+	"codezilla/internal/hooks"
+	"codezilla/internal/i18n"
+	"codezilla/internal/platform"
+	"codezilla/internal/project"
+	"codezilla/internal/scaffold"
+	"codezilla/internal/search"
+	"codezilla/internal/session"
 	"codezilla/internal/tools"
 	"codezilla/internal/ui"
+	"codezilla/internal/workflow"
+	"codezilla/internal/workspace"
+	"codezilla/llm/cache"
 	"codezilla/llm/ollama"
 	"codezilla/pkg/logger"
+	"codezilla/pkg/style"
 )
 
 // App represents the core application logic, independent of UI
 type App struct {
 	config     *cli.Config
+	configPath string // File the config was loaded from; "" when running on defaults
 	logger     *logger.Logger
 	agent      agent.Agent
 	llmClient  ollama.Client
+	llmCache   *cache.Cache // Responses of idempotent internal calls; nil when disabled
 	contextMgr *cli.SimpleContextManager
 	tools      tools.ToolRegistry
-	ui         ui.UI
+	notes      *tools.NotesStore
+	snippets   *tools.SnippetStore
+	// searchIndex is the project's full-text index, shared by /search and codeSearch
+	searchIndex *search.Index
+	processes   *tools.ProcessManager
+	prompt      *agent.PromptComposer
+	ui          ui.UI
+	// hooks run the user's commands on lifecycle events; nil when none are configured
+	hooks *hooks.Runner
+	// analytics counts usage for /dashboard; nil unless the user opted in
+	analytics *analytics.Store
+
+	// lastResponse is the most recent assistant answer, used by /save-code
+	lastResponse string
+
+	// shadow is the workspace copy a /shadow task runs in, nil otherwise
+	shadow *workspace.Shadow
+	// task is the task branch the agent commits to, nil when none is active
+	task *workspace.Task
+
+	// Session persistence (sessions is nil when disabled)
+	sessions  *session.Store
+	session   *session.Session
+	sessionMu sync.Mutex
 }
 
 // NewApp creates a new application instance
@@ -40,23 +78,11 @@ func NewApp(config *cli.Config, ui ui.UI) (*App, error) {
 	}
 
 	// Initialize LLM client with authentication
-	clientOptions := []func(*ollama.ClientOptions){
-		ollama.WithBaseURL(config.OllamaURL),
-	}
-
-	// Add authentication if configured
-	if config.OllamaAPIKey != "" {
-		clientOptions = append(clientOptions, ollama.WithAPIKey(config.OllamaAPIKey))
-	} else if config.OllamaUsername != "" && config.OllamaPassword != "" {
-		clientOptions = append(clientOptions, ollama.WithBasicAuth(config.OllamaUsername, config.OllamaPassword))
-	}
-
-	if len(config.OllamaHeaders) > 0 {
-		clientOptions = append(clientOptions, ollama.WithHeaders(config.OllamaHeaders))
+	llmClient, err := NewLLMClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama client: %w", err)
 	}
 
-	llmClient := ollama.NewClient(clientOptions...)
-
 	// Test connection
 	ctx := context.Background()
 	ui.Print("Checking Ollama connection... ")
@@ -66,41 +92,82 @@ func NewApp(config *cli.Config, ui ui.UI) (*App, error) {
 		return nil, fmt.Errorf("cannot connect to Ollama at %s: %w", config.OllamaURL, err)
 	}
 	ui.Success("Connected")
+	if balancer, ok := llmClient.(*ollama.Balancer); ok {
+		for _, status := range balancer.Status(ctx) {
+			if !status.Healthy {
+				ui.Warning("Ollama server %s is not responding; requests go to the others", status.URL)
+			}
+		}
+	}
 
 	// Initialize tool registry
 	toolRegistry := tools.NewToolRegistry()
 
 	// Create permission manager with interactive callback
 	permissionMgr := tools.NewPermissionManager(func(ctx context.Context, request tools.PermissionRequest) (tools.PermissionResponse, error) {
+		// Explained while the thinking indicator still runs
+		explanation := explainRequest(ctx, config, llmClient, log, request)
+
 		// Hide thinking indicator before showing permission request
 		ui.HideThinking()
 
 		// Show permission request to user
-		ui.Warning("\n🔧 Tool Permission Request:")
-		ui.Print("Tool: %s\n", request.ToolContext.ToolName)
-		ui.Print("Description: %s\n", request.Description)
+		if request.Invalid != "" {
+			ui.Error("%s", i18n.T("permission.rejected", request.Invalid))
+		}
+		ui.Warning("\n%s", i18n.T("permission.title"))
+		ui.Print("%s\n", i18n.T("permission.tool", request.ToolContext.ToolName))
+		ui.Print("%s\n", i18n.T("permission.description", request.Description))
+		if explanation != "" {
+			ui.Print("%s\n", i18n.T("permission.explanation", explanation))
+		}
+		if request.Dangerous && config.DangerousToolsWarn {
+			ui.Error("%s", i18n.T("permission.dangerous"))
+		}
+		if request.Preview != "" {
+			ui.Print("\n%s\n", request.Preview)
+		}
 		ui.Print("\n")
 
+		// Destructive calls need the confirmation word typed out, whatever the permission level
+		if request.Destructive != "" {
+			ui.Error("%s", i18n.T("permission.destructive", request.Destructive))
+			ui.Print("%s ", i18n.T("ui.destructive_confirm", tools.DestructiveConfirmation))
+			response, err := ui.ReadAnswer()
+			if err != nil {
+				return tools.PermissionResponse{Granted: false}, fmt.Errorf("failed to read response: %w", err)
+			}
+			ui.ShowThinking()
+			return tools.PermissionResponse{Granted: strings.TrimSpace(response) == tools.DestructiveConfirmation}, nil
+		}
+
 		// Ask for permission with a simple prompt
-		ui.Print("Allow this action? (y/n/always): ")
+		ui.Print("%s ", i18n.T("permission.prompt"))
 
-		// Read response directly without the usual prompt
-		scanner := bufio.NewScanner(os.Stdin)
-		if !scanner.Scan() {
-			return tools.PermissionResponse{Granted: false}, fmt.Errorf("failed to read response")
+		// Read the answer through the UI, which holds back lines queued meanwhile and
+		// keeps the answer out of the history
+		response, err := ui.ReadAnswer()
+		if err != nil {
+			return tools.PermissionResponse{Granted: false}, fmt.Errorf("failed to read response: %w", err)
 		}
-		response := scanner.Text()
-
-		response = strings.ToLower(strings.TrimSpace(response))
 
 		// Show thinking indicator again after permission
 		ui.ShowThinking()
 
-		switch response {
-		case "y", "yes":
+		switch {
+		case i18n.IsYes(response):
 			return tools.PermissionResponse{Granted: true, RememberMe: false}, nil
-		case "always", "a":
+		case i18n.IsAlways(response):
 			return tools.PermissionResponse{Granted: true, RememberMe: true}, nil
+		case i18n.IsEdit(response):
+			// The request is shown again with the edited parameters
+			ui.HideThinking()
+			edited, err := editParams(ui, request.ToolContext.Params)
+			ui.ShowThinking()
+			if err != nil {
+				return tools.PermissionResponse{Granted: false}, err
+			}
+			return tools.PermissionResponse{Params: edited}, nil
 		default:
 			return tools.PermissionResponse{Granted: false, RememberMe: false}, nil
 		}
@@ -122,37 +189,297 @@ func NewApp(config *cli.Config, ui ui.UI) (*App, error) {
 		permissionMgr.SetDefaultPermissionLevel(toolName, level)
 	}
 
+	// Scratchpad notes shared across agent iterations of the current session, kept
+	// in the session when it is saved
+	notes := tools.NewNotesStore()
+
+	// Background processes started by the model, stopped when the app exits
+	processes := tools.NewProcessManager()
+
+	// Code snippets saved by the user across sessions
+	snippets, err := tools.LoadSnippetStore(config.SnippetsFile)
+	if err != nil {
+		log.Warn("Saved snippets unavailable", "error", err)
+		snippets, _ = tools.LoadSnippetStore("")
+	}
+
+	// Internal calls such as file analysis answer repeated questions from the cache
+	llmCache := NewLLMCache(config)
+
+	// Additional project roots, reachable from tools as name:path
+	tools.SetRoots(config.WorkingDirectory, config.Roots)
+	for _, name := range tools.MissingRoots() {
+		ui.Warning("Root %s: %s is not a directory; paths under %s: fail until it exists", name, tools.Roots()[name], name)
+	}
+
+	// Full-text index of the project, built on first search
+	searchIndex := search.New(config.WorkingDirectory)
+	searchIndex.SetRoots(tools.Roots())
+	searchIndex.PrioritizeChanged(config.PrioritizeChangedFiles)
+
 	// Register tools after permission manager is configured
-	registerTools(toolRegistry, llmClient, config, log, permissionMgr)
+	registerTools(toolRegistry, llmClient, llmCache, config, log, permissionMgr, notes, snippets, searchIndex, processes)
+
+	// Assemble the system prompt from the base prompt and enabled snippets
+	activeSnippets := config.ActiveSnippets
+	if config.PromptProfile != "" {
+		activeSnippets = append(append([]string{}, config.PromptProfiles[config.PromptProfile]...), activeSnippets...)
+	}
+	prompt, err := agent.NewPromptComposer(basePrompt(config, log), config.PromptSnippets, activeSnippets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt snippets: %w", err)
+	}
+
+	// User commands run on lifecycle events, such as formatting files after edits
+	hookRunner := hooks.New(config.Hooks, config.WorkingDirectory)
+
+	// Local usage counts, kept only when the user opted in
+	var usage *analytics.Store
+	if config.Analytics.Enabled {
+		if usage, err = analytics.Open(config.Analytics.File, config.Analytics.RetentionDays); err != nil {
+			log.Warn("Usage analytics disabled", "error", err)
+		}
+	}
 
 	// Initialize agent
 	agentConfig := &agent.Config{
 		Model:         config.DefaultModel,
-		SystemPrompt:  config.SystemPrompt,
+		SystemPrompt:  prompt.Compose(),
 		Temperature:   float64(config.Temperature),
 		MaxTokens:     config.MaxTokens,
 		Logger:        log,
 		ToolRegistry:  toolRegistry,
 		PermissionMgr: permissionMgr,
+		Client:        llmClient,
+
+		InjectionDefense: agent.InjectionDefense(config.InjectionDefense),
+		OutputContract:   agent.OutputContract(config.OutputContract),
+		StopSequences:    config.StopSequences,
+		Clarify:          clarifyPolicy(config.ClarifyToolCalls),
+		Output:           outputLevel(config),
+		ShowReasoning:    config.ShowReasoning,
+		Citations:        config.Citations,
+		Prefetch:         config.Prefetch,
+		Hooks:            hookRunner,
+		Analytics:        usage,
+		WorkingDirectory: func() string {
+			return config.WorkingDirectory
+		},
+		EditChecker: func(ctx context.Context, files []string) string {
+			return project.FormatEditChecks(config.WorkingDirectory, project.CheckEdits(ctx, config.WorkingDirectory, files, config.EditChecks))
+		},
+		BuildAttempts: config.BuildCheck.MaxAttempts,
+		Budget: agent.Budget{
+			MaxWallTime: time.Duration(config.Budget.MaxSeconds) * time.Second,
+			MaxLLMCalls: config.Budget.MaxLLMCalls,
+			MaxTokens:   config.Budget.MaxTokens,
+		},
+		WriteQuota: agent.WriteQuota{
+			MaxFiles: config.WriteQuota.MaxFiles,
+			MaxBytes: config.WriteQuota.MaxBytes,
+		},
+	}
+	if config.BuildCheck.Enabled {
+		command := config.BuildCheck.Command
+		if command == "" {
+			command = project.DetectBuildCommand(config.WorkingDirectory)
+		}
+		if command == "" {
+			log.Warn("Build check disabled: no build command found; set build_check.command")
+		} else {
+			agentConfig.BuildChecker = func(ctx context.Context) (string, string, error) {
+				output, err := project.RunBuild(ctx, config.WorkingDirectory, command)
+				return command, output, err
+			}
+		}
+	}
+	if config.ToolStatsFile != "" {
+		stats, err := agent.LoadToolStats(config.ToolStatsFile)
+		if err != nil {
+			log.Warn("Tool usage hints disabled", "error", err)
+		} else {
+			agentConfig.ToolStats = stats
+		}
+	}
+	switch config.InjectionClassifier {
+	case "heuristic":
+		agentConfig.InjectionClassifier = agent.HeuristicClassifier{}
+	case "model":
+		agentConfig.InjectionClassifier = &agent.ModelClassifier{Client: NewLLMClientAdapter(llmClient, config.DefaultModel).WithCache(llmCache)}
 	}
 	agentInstance := agent.NewAgent(agentConfig)
 
 	// Initialize context manager
 	contextMgr := cli.NewSimpleContextManager(10)
 
-	return &App{
-		config:     config,
-		logger:     log,
-		agent:      agentInstance,
-		llmClient:  llmClient,
-		contextMgr: contextMgr,
-		tools:      toolRegistry,
-		ui:         ui,
-	}, nil
+	var sessions *session.Store
+	if config.PersistSessions && config.SessionsDir != "" {
+		sessions = session.NewStore(config.SessionsDir)
+	}
+
+	currentSession := session.New(config.DefaultModel, config.WorkingDirectory)
+	hookRunner.SetSessionID(currentSession.ID)
+
+	app := &App{
+		config:      config,
+		configPath:  config.Path(),
+		logger:      log,
+		agent:       agentInstance,
+		llmClient:   llmClient,
+		llmCache:    llmCache,
+		contextMgr:  contextMgr,
+		tools:       toolRegistry,
+		notes:       notes,
+		snippets:    snippets,
+		searchIndex: searchIndex,
+		processes:   processes,
+		prompt:      prompt,
+		ui:          ui,
+		sessions:    sessions,
+		session:     currentSession,
+		hooks:       hookRunner,
+		analytics:   usage,
+	}
+	if tool, ok := toolRegistry.GetTool("execute"); ok && config.ExecuteTrackChanges != workspace.TrackOff {
+		if executeTool, ok := tool.(*tools.ExecuteTool); ok {
+			executeTool.TrackChanges = app.trackCommandChanges
+		}
+	}
+
+	// Stream command output, diffs and analysis progress live alongside the tool
+	// execution trace, at the agent's output level
+	commandOutput := style.NewWriter(agentInstance.Output())
+	streamOutput := func(_, chunk string) {
+		fmt.Fprint(commandOutput, chunk)
+	}
+	for _, name := range []string{"execute", "addDependency", "removeDependency", "fileWrite", "projectScanAnalyzer"} {
+		tool, _ := toolRegistry.GetTool(name)
+		switch tool := tool.(type) {
+		case *tools.ExecuteTool:
+			tool.OnOutput = streamOutput
+		case *tools.DependencyTool:
+			tool.OnOutput = streamOutput
+		case *tools.FileWriteTool:
+			tool.Output = agentInstance.Output()
+		case *tools.ProjectScanAnalyzer:
+			tool.Output = agentInstance.Output()
+		}
+	}
+	return app, nil
+}
+
+// basePrompt returns the system prompt for the project in config.WorkingDirectory,
+// before snippets: the configured prompt with guidance for the project's languages,
+// its additional roots and the platform
+func basePrompt(config *cli.Config, log *logger.Logger) string {
+	base := config.SystemPrompt
+	if config.LanguageGuidance {
+		langs := project.DetectLanguages(config.WorkingDirectory)
+		if guidance := project.Guidance(config.WorkingDirectory, langs); guidance != "" {
+			base += "\n\n" + guidance
+		}
+		if tasks := project.TasksGuidance(project.DiscoverTasks(config.WorkingDirectory)); tasks != "" {
+			base += "\n\n" + tasks
+		}
+		log.Debug("Detected project languages", "languages", langs)
+	}
+	if guidance := tools.RootsGuidance(); guidance != "" {
+		base += "\n\n" + guidance
+	}
+	if p := platform.Current(); p.OS != "linux" {
+		// Models write Unix commands unless told otherwise
+		base += "\n\n" + p.Guidance()
+	}
+	return base
+}
+
+// NewLLMClient creates an Ollama client from the configuration, including
+// authentication and TLS verification
+func NewLLMClient(config *cli.Config) (ollama.Client, error) {
+	clientOptions := []func(*ollama.ClientOptions){
+		ollama.WithBaseURL(config.OllamaURL),
+	}
+
+	// Add authentication if configured
+	if config.OllamaAPIKey != "" {
+		clientOptions = append(clientOptions, ollama.WithAPIKey(config.OllamaAPIKey))
+	} else if config.OllamaUsername != "" && config.OllamaPassword != "" {
+		clientOptions = append(clientOptions, ollama.WithBasicAuth(config.OllamaUsername, config.OllamaPassword))
+	}
+
+	if len(config.OllamaHeaders) > 0 {
+		clientOptions = append(clientOptions, ollama.WithHeaders(config.OllamaHeaders))
+	}
+
+	clientOptions = append(clientOptions, ollama.WithTransportOptions(ollama.TransportOptions{
+		MaxIdleConns:      config.OllamaConnections.MaxIdle,
+		IdleConnTimeout:   time.Duration(config.OllamaConnections.IdleTimeoutSeconds) * time.Second,
+		DisableKeepAlives: !config.OllamaConnections.KeepAlive,
+		DisableHTTP2:      !config.OllamaConnections.HTTP2,
+	}))
+
+	tlsConfig, err := ollama.TLSOptions{
+		CACertFile:         config.OllamaCACert,
+		InsecureSkipVerify: config.OllamaInsecureSkipVerify,
+	}.Config()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		clientOptions = append(clientOptions, ollama.WithTLSConfig(tlsConfig))
+	}
+
+	if len(config.ModelProfiles) > 0 {
+		modelOptions := make(map[string]map[string]interface{}, len(config.ModelProfiles))
+		for model, profile := range config.ModelProfiles {
+			modelOptions[model] = profile.Options
+		}
+		clientOptions = append(clientOptions, ollama.WithModelOptions(modelOptions))
+	}
+
+	if len(config.OllamaURLs) > 0 {
+		urls := append([]string{config.OllamaURL}, config.OllamaURLs...)
+		return ollama.NewBalancer(urls, config.OllamaBalance, clientOptions...), nil
+	}
+	return ollama.NewClient(clientOptions...), nil
+}
+
+// clarifyPolicy turns the clarify_tool_calls setting into the agent's policy
+func clarifyPolicy(setting string) agent.ClarifyPolicy {
+	switch setting {
+	case "always_ask":
+		return agent.ClarifyPolicy{Enabled: true, Level: tools.AlwaysAsk}
+	case "ask_once":
+		return agent.ClarifyPolicy{Enabled: true, Level: tools.AskOnce}
+	case "all":
+		return agent.ClarifyPolicy{Enabled: true, Level: tools.NeverAsk}
+	default:
+		return agent.ClarifyPolicy{}
+	}
+}
+
+// outputLevel returns the agent output level configured, with verbose meaning trace
+func outputLevel(config *cli.Config) agent.OutputLevel {
+	if config.Verbose {
+		return agent.OutputTrace
+	}
+	if level, ok := agent.ParseOutputLevel(config.Output); ok {
+		return level
+	}
+	return agent.OutputNormal
 }
 
 // Close cleans up application resources
 func (app *App) Close() error {
+	if app.processes != nil {
+		app.processes.StopAll()
+	}
+	tools.PauseTodoTime(time.Now())
+	app.saveTodoState()
+	if app.llmCache != nil && app.logger != nil {
+		hits, misses := app.llmCache.Stats()
+		app.logger.Debug("LLM response cache", "hits", hits, "misses", misses)
+	}
 	if app.logger != nil {
 		return app.logger.Close()
 	}
@@ -161,12 +488,25 @@ func (app *App) Close() error {
 
 // Run starts the main application loop
 func (app *App) Run(ctx context.Context) error {
-	// Show UI elements
+	app.showStart()
+	if err := app.analytics.RecordSession(time.Now()); err != nil {
+		app.logger.Warn("Failed to save usage analytics", "error", err)
+	}
+	if err := app.hooks.Run(ctx, hooks.Payload{Event: hooks.SessionStart}); err != nil {
+		app.ui.Warning("%v", err)
+	}
+	return app.loop(ctx)
+}
+
+// showStart shows the banner and welcome message
+func (app *App) showStart() {
 	app.ui.Clear()
 	app.ui.ShowBanner()
 	app.ui.ShowWelcome(app.config.DefaultModel, app.config.OllamaURL, app.config.RetainContext)
+}
 
-	// Main loop
+// loop reads and handles input until the user exits
+func (app *App) loop(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
@@ -175,7 +515,7 @@ func (app *App) Run(ctx context.Context) error {
 			// Read input (single-line, Enter submits immediately)
 			input, err := app.ui.ReadLine()
 			if err != nil {
-				app.ui.Info("Goodbye!")
+				app.ui.Info("%s", i18n.T("ui.goodbye"))
 				return nil
 			}
 
@@ -192,16 +532,40 @@ func (app *App) Run(ctx context.Context) error {
 				continue
 			}
 
-			// Process with AI
-			if err := app.processInput(ctx, input); err != nil {
-				app.ui.Error("Failed to process: %v", err)
-			}
+			app.submitPrompt(ctx, input)
 		}
 	}
 }
 
+// submitPrompt sends a prompt typed or composed by the user to the model. Prompts
+// typed meanwhile are queued and read by the loop in order afterwards.
+func (app *App) submitPrompt(ctx context.Context, input string) {
+	if app.config.ExecutePTY != string(tools.PTYTakeover) {
+		// With takeover, keystrokes belong to the running command
+		app.ui.StartQueue(func(line string, queued int) {
+			app.ui.Info("Queued (%d waiting): %s", queued, line)
+		})
+		defer app.ui.StopQueue()
+	}
+
+	// Each new task gets its own branch when task branches are on
+	if app.task == nil && app.config.TaskBranches != workspace.TaskBranchesOff && app.config.TaskBranches != "" {
+		app.startTask(ctx, input)
+	}
+
+	// Process with AI
+	if err := app.processInput(ctx, input); err != nil {
+		app.ui.Error("%s", i18n.T("ui.process_failed", err))
+	}
+	app.commitTaskChanges(ctx, input)
+	app.saveTodoState()
+}
+
 // processInput processes user input with the AI
-func (app *App) processInput(ctx context.Context, input string) error {
+func (app *App) processInput(ctx context.Context, input string) (err error) {
+	outcome := analytics.Succeeded
+	defer func() { app.recordRequest(outcome, err) }()
+
 	// Show thinking indicator
 	app.ui.ShowThinking()
 	defer app.ui.HideThinking()
@@ -217,8 +581,27 @@ func (app *App) processInput(ctx context.Context, input string) error {
 
 	// Process with agent
 	response, err := app.agent.ProcessMessage(ctx, input)
-	if err != nil {
-		return err
+	for err != nil {
+		var budgetErr *agent.BudgetError
+		var quotaErr *agent.QuotaError
+		question := "ui.budget_continue"
+		if errors.As(err, &quotaErr) {
+			question = "ui.quota_continue"
+		} else if !errors.As(err, &budgetErr) {
+			return err
+		}
+		// Show what was done and let the user decide whether to go on
+		app.ui.HideThinking()
+		app.ui.ShowResponse(response)
+		more, confirmErr := app.ui.Confirm(i18n.T(question))
+		if confirmErr != nil || !more {
+			outcome = analytics.Aborted
+			app.lastResponse = response
+			app.recordExchange(input, response)
+			return nil
+		}
+		app.ui.ShowThinking()
+		response, err = app.agent.Continue(ctx)
 	}
 
 	// Add response to context
@@ -227,8 +610,24 @@ func (app *App) processInput(ctx context.Context, input string) error {
 		app.agent.AddAssistantMessage(response)
 	}
 
-	// Display response
-	app.ui.ShowResponse(response)
+	// Display response, with the files changed on the way
+	shown := response
+	if app.config.ChangesSummary && !app.agent.DryRun() {
+		if summary := app.changesSummary(app.agent.ChangedFiles()); summary != "" {
+			shown += "\n\n" + summary
+		}
+	}
+	app.ui.ShowResponse(shown)
+	app.checkReferences(response)
+	app.lastResponse = response
+	if err := app.hooks.Run(ctx, hooks.Payload{Event: hooks.Response, Response: response}); err != nil {
+		app.ui.Warning("%v", err)
+	}
+	if app.config.ApplyMode != ApplyModeOff && !app.agent.DryRun() && app.shadow == nil {
+		app.offerApply(response, false)
+	}
+
+	app.recordExchange(input, response)
 
 	return nil
 }
@@ -245,7 +644,7 @@ func (app *App) handleCommand(ctx context.Context, cmd string) bool {
 		app.ui.ShowHelp()
 
 	case "/exit", "/quit", "/q":
-		app.ui.Success("Goodbye!")
+		app.ui.Success("%s", i18n.T("ui.goodbye"))
 		return true
 
 	case "/clear", "/c":
@@ -268,19 +667,188 @@ func (app *App) handleCommand(ctx context.Context, cmd string) bool {
 	case "/tools":
 		app.showTools()
 
+	case "/notes":
+		app.handleNotesCommand(parts)
+
+	case "/search":
+		app.handleSearchCommand(parts)
+
+	case "/snippet", "/snippets":
+		app.handleSnippetCommand(ctx, parts)
+
+	case "/summarize":
+		app.handleSummarizeCommand(ctx, parts)
+
+	case "/todo", "/todos":
+		app.handleTodoCommand(ctx, parts)
+
+	case "/apply":
+		if app.agent.DryRun() {
+			app.ui.Warning("Dry run is on: code blocks are not applied (turn it off with /dryrun off)")
+		} else {
+			app.offerApply(app.lastResponse, true)
+		}
+
+	case "/save-code":
+		app.handleSaveCodeCommand(ctx, parts)
+
+	case "/dryrun":
+		app.handleDryRunCommand(parts)
+
+	case "/verbose":
+		app.handleVerboseCommand(parts)
+
+	case "/task":
+		if len(parts) < 2 {
+			app.showTask()
+		} else {
+			app.startTask(ctx, strings.TrimSpace(strings.TrimPrefix(cmd, parts[0])))
+		}
+
+	case "/finish":
+		app.finishTask(ctx)
+
+	case "/work":
+		if len(parts) != 2 {
+			app.ui.Warning("Usage: /work <issue-url|#number>")
+		} else {
+			app.workOnIssue(ctx, parts[1])
+		}
+
+	case "/shadow":
+		if len(parts) < 2 {
+			app.ui.Warning("Usage: /shadow <task>")
+		} else {
+			app.runInShadow(ctx, strings.TrimSpace(strings.TrimPrefix(cmd, parts[0])))
+		}
+
+	case "/prompt":
+		app.handlePromptCommand(parts)
+
+	case "/sessions":
+		app.handleSessionsCommand(parts)
+
+	case "/rename":
+		if app.sessions == nil {
+			app.ui.Warning("Session persistence is disabled (persist_sessions in config)")
+		} else if len(parts) < 2 {
+			app.ui.Warning("Usage: /rename <title>")
+		} else {
+			app.renameSession("", strings.Join(parts[1:], " "))
+		}
+
+	case "/dashboard":
+		app.handleDashboardCommand(parts)
+
+	case "/share":
+		app.handleShareCommand(parts)
+
+	case "/keys":
+		app.showKeyBindings()
+
+	case "/checkpoint":
+		app.handleCheckpointCommand(ctx, parts)
+
+	case "/fork":
+		app.handleForkCommand(ctx, parts)
+
+	case "/edit":
+		app.handleEditCommand(ctx, strings.TrimSpace(strings.TrimPrefix(cmd, parts[0])))
+
 	case "/reset":
 		app.contextMgr.Clear()
 		app.agent.ClearContext()
-		app.ui.Success("Conversation reset")
+		app.startNewSession()
+		app.ui.Success("%s", i18n.T("ui.conversation_reset"))
 
 	default:
-		app.ui.Warning("Unknown command: %s", parts[0])
-		app.ui.Info("Type /help for available commands")
+		app.ui.Warning("%s", i18n.T("ui.unknown_command", parts[0]))
+		app.ui.Info("%s", i18n.T("ui.help_hint"))
 	}
 
 	return false
 }
 
+// handleDryRunCommand toggles dry-run mode, or sets it with on/off
+func (app *App) handleDryRunCommand(parts []string) {
+	enabled := !app.agent.DryRun()
+	if len(parts) > 1 {
+		switch parts[1] {
+		case "on":
+			enabled = true
+		case "off":
+			enabled = false
+		default:
+			app.ui.Warning("Usage: /dryrun [on|off]")
+			return
+		}
+	}
+	app.agent.SetDryRun(enabled)
+	if enabled {
+		app.ui.Success("Dry run on: state-changing tool calls are shown but not executed, and answers that depend on them are marked hypothetical")
+	} else {
+		app.ui.Success("Dry run off: tool calls are executed again")
+	}
+}
+
+// handleVerboseCommand sets the agent's output level, or cycles through the levels
+func (app *App) handleVerboseCommand(parts []string) {
+	levels := agent.OutputLevels
+	current := app.agent.OutputLevel()
+	level := levels[0]
+	for i, l := range levels {
+		if l == current {
+			level = levels[(i+1)%len(levels)]
+		}
+	}
+	if len(parts) > 1 {
+		var ok bool
+		if level, ok = agent.ParseOutputLevel(parts[1]); !ok {
+			app.ui.Warning("Usage: /verbose [quiet|normal|trace]")
+			return
+		}
+	}
+	app.agent.SetOutputLevel(level)
+	switch level {
+	case agent.OutputQuiet:
+		app.ui.Success("Output quiet: only answers and warnings are shown")
+	case agent.OutputNormal:
+		app.ui.Success("Output normal: one line per tool call")
+	case agent.OutputTrace:
+		app.ui.Success("Output trace: prompts, tool parameters and results, and timings are shown, long ones collapsed")
+	}
+}
+
+// handleEditCommand composes a prompt in the user's editor, starting from text, and
+// sends it once the editor exits
+func (app *App) handleEditCommand(ctx context.Context, text string) {
+	prompt, err := cli.EditText(text)
+	if err != nil {
+		app.ui.Error("%v", err)
+		return
+	}
+	if strings.TrimSpace(prompt) == "" {
+		app.ui.Info("Nothing sent: the prompt was left empty")
+		return
+	}
+	app.ui.Println("%s", prompt)
+	app.submitPrompt(ctx, prompt)
+}
+
+// showKeyBindings lists the keys bound to input actions
+func (app *App) showKeyBindings() {
+	app.ui.Println("\nKey bindings:")
+	for _, b := range cli.CurrentKeymap().Bindings() {
+		source := ""
+		if b.Custom {
+			source = " (key_bindings)"
+		}
+		app.ui.Println("  %-16s %-12s %s%s", b.Action, b.Key, b.Description, source)
+	}
+	app.ui.Println("Rebind them under key_bindings in the config, as in {\"newline\": \"ctrl-j\"}")
+	app.ui.Println("Editing mode: %s (edit_mode)\n", cli.CurrentEditMode())
+}
+
 // showModels displays available models
 func (app *App) showModels(ctx context.Context) {
 	models, err := app.llmClient.ListModels(ctx)
@@ -322,6 +890,21 @@ func (app *App) changeModel(ctx context.Context, modelName string) {
 	app.config.DefaultModel = modelName
 	app.agent.SetModel(modelName)
 	app.ui.Success("Switched to model: %s", modelName)
+	app.saveConfig()
+}
+
+// saveConfig writes the current configuration back to the file it was loaded from
+func (app *App) saveConfig() {
+	if app.configPath == "" {
+		app.ui.Warning("Configuration was not loaded from a file, changes apply to this session only")
+		return
+	}
+	if err := cli.SaveConfig(app.config, app.configPath); err != nil {
+		app.ui.Error("Failed to save configuration: %v", err)
+		app.logger.Error("Failed to save configuration", "path", app.configPath, "error", err)
+		return
+	}
+	app.logger.Info("Configuration saved", "path", app.configPath)
 }
 
 // handleContextCommand handles context-related commands
@@ -354,6 +937,32 @@ func (app *App) handleContextCommand(parts []string) {
 	}
 }
 
+// handleNotesCommand shows or clears the session scratchpad
+func (app *App) handleNotesCommand(parts []string) {
+	if len(parts) > 1 {
+		switch parts[1] {
+		case "clear":
+			n := app.notes.Clear()
+			app.sessionMu.Lock()
+			app.session.Notes = nil
+			if app.sessions != nil && len(app.session.Messages) > 0 {
+				if err := app.sessions.Save(app.session); err != nil {
+					app.logger.Warn("Failed to save session", "error", err)
+				}
+			}
+			app.sessionMu.Unlock()
+			app.ui.Success("Cleared %d notes", n)
+		default:
+			app.ui.Warning("Usage: /notes [clear]")
+		}
+		return
+	}
+
+	app.ui.Println("\nSession notes:")
+	app.ui.Println("%s", app.notes.Format())
+	app.ui.Println("")
+}
+
 // showTools displays available tools
 func (app *App) showTools() {
 	var toolInfos []ui.ToolInfo
@@ -376,24 +985,71 @@ func (app *App) showTools() {
 }
 
 // registerTools registers all available tools
-func registerTools(registry tools.ToolRegistry, llmClient ollama.Client, config *cli.Config, logger *logger.Logger, permissionMgr tools.ToolPermissionManager) {
+func registerTools(registry tools.ToolRegistry, llmClient ollama.Client, llmCache *cache.Cache, config *cli.Config, logger *logger.Logger, permissionMgr tools.ToolPermissionManager, notes *tools.NotesStore, snippets *tools.SnippetStore, searchIndex *search.Index, processes *tools.ProcessManager) {
 	// File operation tools
 	registry.RegisterTool(tools.NewFileReadTool())
 	registry.RegisterTool(tools.NewFileWriteTool())
+	registry.RegisterTool(tools.NewMultiEditTool())
 	registry.RegisterTool(tools.NewListFilesTool())
+	registry.RegisterTool(search.NewTool(searchIndex))
+	registry.RegisterTool(search.NewDefinitionTool(searchIndex))
+	registry.RegisterTool(tools.NewRenameSymbolTool())
+	registry.RegisterTool(tools.NewStackTraceTool())
+	registry.RegisterTool(tools.NewLogAnalysisTool())
+	registry.RegisterTool(tools.NewDataQueryTool())
+	registry.RegisterTool(tools.NewTablePreviewTool())
+	registry.RegisterTool(tools.NewAPISpecTool())
+	registry.RegisterTool(tools.NewListTasksTool())
+	registry.RegisterTool(scaffold.NewTool(config.TemplatesDir))
 
 	// Create analyzer factory and register analyzer tool
-	llmAdapter := NewLLMClientAdapter(llmClient)
+	llmAdapter := NewLLMClientAdapter(llmClient, config.DefaultModel).WithCache(llmCache)
 	analyzerFactory := tools.NewAnalyzerFactory(llmAdapter, logger)
 
 	// Register the analyzer (formerly V2)
-	registry.RegisterTool(analyzerFactory.CreateProjectScanAnalyzer())
+	projectScanAnalyzer := analyzerFactory.CreateProjectScanAnalyzer()
+	projectScanAnalyzer.PrioritizeChanged = config.PrioritizeChangedFiles
+	projectScanAnalyzer.SetAnalysisPrompts(tools.NewAnalysisPrompts(config.AnalysisPromptTemplate, config.AnalysisProfiles))
+	projectScanAnalyzer.SetOutliner(search.SectionStarts)
+	registry.RegisterTool(projectScanAnalyzer)
 
 	// Set default permissions for project scanning tool to never ask (always allow)
 	// This tool is safe to run automatically as it only reads files without modifying anything
 	permissionMgr.SetDefaultPermissionLevel("projectScanAnalyzer", tools.NeverAsk)
 
-	registry.RegisterTool(tools.NewExecuteTool(30))
+	// Command output is streamed through the agent once it exists, see NewApp
+	executeTool := tools.NewExecuteTool(30 * time.Second)
+	executeTool.PTY = tools.PTYPolicy(config.ExecutePTY)
+	registry.RegisterTool(executeTool)
+
+	// Read-only environment inspection
+	registry.RegisterTool(tools.NewEnvTool())
+	registry.RegisterTool(tools.NewPlatformTool())
+
+	// Dependency management through the project's package manager
+	registry.RegisterTool(tools.NewAddDependencyTool())
+	registry.RegisterTool(tools.NewRemoveDependencyTool())
+
+	// Background processes such as dev servers
+	registry.RegisterTool(tools.NewStartProcessTool(processes))
+	registry.RegisterTool(tools.NewStopProcessTool(processes))
+	registry.RegisterTool(tools.NewListProcessTool(processes))
+
+	// Workflow tools
+	registry.RegisterTool(workflow.NewChangelogTool(llmAdapter, logger))
+	registry.RegisterTool(workflow.NewVulnCheckTool())
+	registry.RegisterTool(workflow.NewLicenseTool(config.DisallowedLicenses))
+	forge := workflow.ForgeConfig{Provider: config.Forge.Provider, APIURL: config.Forge.APIURL, Token: config.Forge.Token}
+	for _, tool := range workflow.NewForgeTools(forge) {
+		registry.RegisterTool(tool)
+	}
+	registry.RegisterTool(workflow.NewCITriageTool(llmAdapter, logger, forge))
+
+	// Session scratchpad
+	registry.RegisterTool(tools.NewNotesTool(notes))
+
+	// User snippets, inserted verbatim
+	registry.RegisterTool(tools.NewGetSnippetTool(snippets))
 
 	// Todo management tools
 	for _, tool := range tools.GetTodoTools() {
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"codezilla/internal/hooks"
+	"codezilla/internal/tools"
+)
+
+// Apply modes for code blocks annotated with a file path
+const (
+	ApplyModeAsk = "ask" // Offer to apply after each answer
+	ApplyModeOff = "off" // Only apply on /apply
+)
+
+// fileBlock is a code block that targets a file in the working directory
+type fileBlock struct {
+	path  string // Absolute path
+	block tools.CodeBlock
+}
+
+// fileBlocks returns the code blocks in response annotated with a file path.
+// When several blocks target the same file, the last one wins.
+func (app *App) fileBlocks(response string) ([]fileBlock, []string) {
+	var blocks []fileBlock
+	var rejected []string
+	index := make(map[string]int)
+
+	for _, block := range tools.ExtractFencedBlocks(response) {
+		target := block.TargetPath()
+		if target == "" {
+			continue
+		}
+		path, err := app.resolveProjectPath(target)
+		if err != nil {
+			rejected = append(rejected, fmt.Sprintf("%s (%v)", target, err))
+			continue
+		}
+		if i, ok := index[path]; ok {
+			blocks[i].block = block
+			continue
+		}
+		index[path] = len(blocks)
+		blocks = append(blocks, fileBlock{path: path, block: block})
+	}
+	return blocks, rejected
+}
+
+// resolveProjectPath resolves a path from an answer, refusing anything outside the
+// working directory and the additional project roots
+func (app *App) resolveProjectPath(target string) (string, error) {
+	root := app.config.WorkingDirectory
+	path := tools.ExpandRootPath(target)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+
+	if !tools.InProjectRoots(root, path) {
+		return "", fmt.Errorf("outside the working directory")
+	}
+	return tools.ValidateAndCleanPath(path)
+}
+
+// offerApply previews the file blocks of an answer as one diff and applies them if the user agrees
+func (app *App) offerApply(response string, explicit bool) {
+	blocks, rejected := app.fileBlocks(response)
+	for _, r := range rejected {
+		app.ui.Warning("Ignoring code block for %s", r)
+	}
+	if len(blocks) == 0 {
+		if explicit {
+			app.ui.Info("The last answer has no code blocks annotated with a file path (e.g. ```go title=main.go)")
+		}
+		return
+	}
+
+	tx := tools.NewEditTransaction()
+	for _, fb := range blocks {
+		content := fb.block.Content
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if err := tx.Stage(fb.path, content); err != nil {
+			app.ui.Error("Cannot apply block for %s: %v", fb.path, err)
+			return
+		}
+	}
+
+	files := tx.Files()
+	if len(files) == 0 {
+		if explicit {
+			app.ui.Info("Files already match the code blocks in the last answer")
+		}
+		return
+	}
+
+	app.ui.Println("")
+	app.ui.Info("The answer contains code for %d file(s). Each block replaces the whole file:", len(files))
+	app.ui.Print("%s\n", tx.Diff())
+	for _, fb := range blocks {
+		if warning := partialBlockWarning(tx, fb); warning != "" {
+			app.ui.Warning("%s", warning)
+		}
+	}
+
+	ok, err := app.ui.Confirm("Apply these changes?")
+	if err != nil || !ok {
+		app.ui.Info("Not applied. Use /apply to review them again")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		app.ui.Error("Failed to apply changes: %v", err)
+		return
+	}
+	app.ui.Success("Applied changes to %d file(s)", len(files))
+	for _, file := range files {
+		if err := app.hooks.Run(context.Background(), hooks.Payload{Event: hooks.AfterFileWrite, File: file}); err != nil {
+			app.ui.Warning("%v", err)
+		}
+	}
+}
+
+// partialBlockWarning flags blocks much shorter than the file they would replace,
+// which are usually excerpts rather than complete files
+func partialBlockWarning(tx *tools.EditTransaction, fb fileBlock) string {
+	original, ok := tx.Original(fb.path)
+	if !ok {
+		return ""
+	}
+	oldLines := strings.Count(original, "\n")
+	newLines := strings.Count(fb.block.Content, "\n") + 1
+	if oldLines >= 20 && newLines*2 < oldLines {
+		return fmt.Sprintf("%s: the block has %d lines but the file has %d; it may be an excerpt", fb.path, newLines, oldLines)
+	}
+	return ""
+}
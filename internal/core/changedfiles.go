@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"codezilla/internal/agent"
+	"codezilla/internal/tools"
+)
+
+// changeLetters abbreviate kinds of change as git status does
+var changeLetters = map[string]string{
+	tools.FileCreated:  "A",
+	tools.FileModified: "M",
+	tools.FileDeleted:  "D",
+}
+
+// changesSummary describes the files changed while answering, to show under the
+// answer, or returns "" when none were. Each line of the block is
+// "<A|M|D> +<added> -<removed> <path>", with "?" for counts that aren't known.
+func (app *App) changesSummary(files []agent.ChangedFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	counts := map[string]int{}
+	added, removed := 0, 0
+	for _, f := range files {
+		counts[f.Kind]++
+		added += f.Added
+		removed += f.Removed
+	}
+	var parts []string
+	for _, kind := range []string{tools.FileCreated, tools.FileModified, tools.FileDeleted} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Files changed: %s (+%d -%d)\n```changes\n", strings.Join(parts, ", "), added, removed)
+	for _, f := range files {
+		delta := "+? -?"
+		if f.Counted {
+			delta = fmt.Sprintf("+%d -%d", f.Added, f.Removed)
+		}
+		fmt.Fprintf(&b, "%s %s %s\n", changeLetters[f.Kind], delta, app.relPaths([]string{f.Path})[0])
+	}
+	b.WriteString("```")
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"codezilla/internal/session"
+	"codezilla/internal/workspace"
+)
+
+// handleCheckpointCommand lists the checkpoints of the current session, or saves the
+// conversation and project files under a name to fork from later
+func (app *App) handleCheckpointCommand(ctx context.Context, parts []string) {
+	if app.sessions == nil {
+		app.ui.Warning("Session persistence is disabled (persist_sessions in config)")
+		return
+	}
+	if len(parts) < 2 {
+		app.listCheckpoints()
+		return
+	}
+	name := parts[1]
+	if !session.ValidCheckpointName(name) {
+		app.ui.Warning("Checkpoint names may use letters, digits, '-', '_' and single dots")
+		return
+	}
+
+	cp, err := app.saveCheckpoint(ctx, name)
+	if err != nil {
+		app.ui.Error("Failed to save checkpoint: %v", err)
+		return
+	}
+	app.ui.Success("Saved checkpoint %q (%d messages)", name, cp.Messages)
+	app.ui.Info("Start a new session from it with /fork %s", name)
+}
+
+// saveCheckpoint snapshots the project files and records them with the current
+// length of the conversation, replacing any checkpoint with the same name
+func (app *App) saveCheckpoint(ctx context.Context, name string) (session.Checkpoint, error) {
+	return app.recordCheckpoint(name, func(ref, copyDir string) (workspace.Snapshot, error) {
+		return workspace.TakeSnapshot(ctx, app.config.WorkingDirectory, ref, copyDir, app.lastCopy(name))
+	})
+}
+
+// lastCopy returns the project copy of the newest checkpoint of the current session
+// other than name, which a new copy shares its unchanged files with. It is empty in
+// git repositories, where checkpoints are commits.
+func (app *App) lastCopy(name string) string {
+	var last session.Checkpoint
+	for _, cp := range app.session.Checkpoints {
+		if cp.Name != name && cp.Files.Dir != "" && cp.Created.After(last.Created) {
+			last = cp
+		}
+	}
+	return last.Files.Dir
+}
+
+// recordCheckpoint saves the snapshot taken by snapshot, given the ref and copy
+// directory reserved for the checkpoint, as a checkpoint of the current session
+func (app *App) recordCheckpoint(name string, snapshot func(ref, copyDir string) (workspace.Snapshot, error)) (session.Checkpoint, error) {
+	app.sessionMu.Lock()
+	defer app.sessionMu.Unlock()
+
+	id := app.session.ID
+	ref := "refs/codezilla/checkpoints/" + id + "/" + name
+	copyDir := filepath.Join(app.sessions.Dir(), "checkpoints", id, name)
+	files, err := snapshot(ref, copyDir)
+	if err != nil {
+		return session.Checkpoint{}, err
+	}
+
+	cp := session.Checkpoint{
+		Name:     name,
+		Created:  time.Now(),
+		Messages: len(app.session.Messages),
+		Notes:    app.notes.List(),
+		Files:    files,
+	}
+	app.session.Notes = cp.Notes
+	app.session.SetCheckpoint(cp)
+	return cp, app.sessions.Save(app.session)
+}
+
+// listCheckpoints shows the checkpoints of the current session
+func (app *App) listCheckpoints() {
+	app.sessionMu.Lock()
+	checkpoints := append([]session.Checkpoint(nil), app.session.Checkpoints...)
+	app.sessionMu.Unlock()
+
+	if len(checkpoints) == 0 {
+		app.ui.Info("No checkpoints in this session yet. Save one with /checkpoint <name>")
+		return
+	}
+	app.ui.Println("\nCheckpoints:")
+	for _, cp := range checkpoints {
+		app.ui.Println("  %-24s  %3d msgs  %s", cp.Name, cp.Messages, cp.Created.Format("2006-01-02 15:04"))
+	}
+	app.ui.Println("")
+	app.ui.Info("Fork a new session from one with /fork <name>")
+}
+
+// handleForkCommand starts a new session from a checkpoint of the current one: the
+// conversation is cut back to the checkpoint and the project files are restored. The
+// current state is saved as a checkpoint first, so the fork can be undone.
+func (app *App) handleForkCommand(ctx context.Context, parts []string) {
+	if app.sessions == nil {
+		app.ui.Warning("Session persistence is disabled (persist_sessions in config)")
+		return
+	}
+	if len(parts) < 2 {
+		app.ui.Warning("Usage: /fork <checkpoint>")
+		return
+	}
+	name := parts[1]
+
+	app.sessionMu.Lock()
+	origin := app.session
+	forked, err := origin.Fork(name)
+	app.sessionMu.Unlock()
+	if err != nil {
+		app.ui.Error("Failed to fork: %v (list checkpoints with /checkpoint)", err)
+		return
+	}
+	cp, _ := origin.Checkpoint(name)
+
+	backup := "before-fork-" + name
+	if _, err := app.saveCheckpoint(ctx, backup); err != nil {
+		app.ui.Error("Failed to save the current state before forking: %v", err)
+		return
+	}
+	if err := cp.Files.Restore(ctx, app.config.WorkingDirectory); err != nil {
+		app.ui.Error("Failed to restore project files: %v", err)
+		app.ui.Info("Session %s keeps the state before the fork as checkpoint %q", origin.ID, backup)
+		return
+	}
+	if err := app.sessions.Save(forked); err != nil {
+		app.ui.Error("Failed to save forked session: %v", err)
+		return
+	}
+
+	app.switchSession(forked)
+	app.ui.Success("Forked session %s from checkpoint %q (%d messages)", forked.ID, name, len(forked.Messages))
+	app.ui.Info("The previous state is checkpoint %q of session %s", backup, origin.ID)
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// handleSaveCodeCommand writes a code block from the last answer to a file through fileWrite,
+// so the usual permission prompt and path checks apply
+func (app *App) handleSaveCodeCommand(ctx context.Context, parts []string) {
+	blocks := tools.ExtractFencedBlocks(app.lastResponse)
+	if len(blocks) == 0 {
+		app.ui.Warning("The last answer has no code blocks")
+		return
+	}
+
+	if len(parts) < 3 {
+		app.listCodeBlocks(blocks)
+		if len(parts) == 2 {
+			app.ui.Warning("Usage: /save-code <n> <path>")
+		}
+		return
+	}
+
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n < 1 || n > len(blocks) {
+		app.ui.Error("Code block must be a number from 1 to %d", len(blocks))
+		return
+	}
+	block := blocks[n-1]
+	path := strings.Join(parts[2:], " ")
+
+	content := block.Content
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	_, err = app.agent.ExecuteTool(ctx, "fileWrite", map[string]interface{}{
+		"file_path": path,
+		"content":   content,
+	})
+	if err != nil {
+		app.ui.Error("Failed to save code block %d: %v", n, err)
+		return
+	}
+	app.ui.Success("Saved code block %d (%d lines) to %s", n, strings.Count(content, "\n"), path)
+}
+
+// listCodeBlocks shows the numbered code blocks of the last answer
+func (app *App) listCodeBlocks(blocks []tools.CodeBlock) {
+	app.ui.Println("\nCode blocks in the last answer:")
+	for i, block := range blocks {
+		language := block.Language
+		if language == "" {
+			language = "text"
+		}
+		lines := strings.Count(block.Content, "\n") + 1
+		app.ui.Println("  %d. %-12s %3d lines  %s", i+1, language, lines, firstLine(block.Content, 50))
+	}
+	app.ui.Println("")
+	app.ui.Info("Save one with /save-code <n> <path>")
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"codezilla/internal/analytics"
+)
+
+// defaultDashboardDays is the period /dashboard covers without an argument
+const defaultDashboardDays = 14
+
+// recordRequest counts a finished request for the usage dashboard. Requests that
+// failed because the user cancelled them count as aborted.
+func (app *App) recordRequest(outcome string, err error) {
+	if err != nil {
+		outcome = analytics.Failed
+		if errors.Is(err, context.Canceled) {
+			outcome = analytics.Aborted
+		}
+	}
+	if err := app.analytics.RecordRequest(time.Now(), app.config.DefaultModel, outcome); err != nil {
+		app.logger.Warn("Failed to save usage analytics", "error", err)
+	}
+}
+
+// handleDashboardCommand charts the local usage counts: /dashboard [days]
+func (app *App) handleDashboardCommand(parts []string) {
+	if app.analytics == nil {
+		app.ui.Warning("Usage analytics are off. Set analytics.enabled to true in the config to start counting; the counts stay on this machine")
+		return
+	}
+	days := defaultDashboardDays
+	if len(parts) > 1 {
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n <= 0 {
+			app.ui.Warning("Usage: /dashboard [days]")
+			return
+		}
+		days = n
+	}
+	app.ui.Print("%s\n", analytics.FormatDashboard(app.analytics.Summarize(time.Now(), days)))
+}
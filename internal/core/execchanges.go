@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"codezilla/internal/platform"
+	"codezilla/internal/tools"
+	"codezilla/internal/workspace"
+)
+
+// execCheckpointPrefix names the checkpoints saved before commands that change files
+const execCheckpointPrefix = "before-exec-"
+
+// trackCommandChanges records the project files before a command runs. The function
+// it returns reports the files the command changed and, when sessions are saved,
+// keeps the files as they were before it as a checkpoint so the command can be undone.
+func (app *App) trackCommandChanges(ctx context.Context) func(context.Context) []tools.FileChange {
+	state, err := workspace.CaptureFileState(ctx, app.config.WorkingDirectory, app.config.ExecuteTrackChanges)
+	if err != nil {
+		if !errors.Is(err, workspace.ErrNotTracked) {
+			app.logger.Warn("Not tracking files changed by command", "error", err)
+		} else {
+			app.logger.Debug("Not tracking files changed by command", "reason", err)
+		}
+		return nil
+	}
+
+	return func(ctx context.Context) []tools.FileChange {
+		changes, err := state.Changes(ctx)
+		if err != nil {
+			app.logger.Warn("Failed to find files changed by command", "error", err)
+			return nil
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		app.ui.Info("The command %s", tools.DescribeFileChanges(changes))
+
+		if app.sessions == nil {
+			return fromWorkingDirectory(changes, state.Root(), app.config.WorkingDirectory)
+		}
+		name := app.nextExecCheckpoint()
+		_, err = app.recordCheckpoint(name, func(ref, _ string) (workspace.Snapshot, error) {
+			return state.Snapshot(ctx, ref, changes)
+		})
+		switch {
+		case errors.Is(err, workspace.ErrNotTracked):
+			app.logger.Debug("No checkpoint before command", "reason", err)
+		case err != nil:
+			app.logger.Warn("Failed to save checkpoint before command", "error", err)
+		default:
+			app.ui.Info("Go back to before it with /fork %s", name)
+		}
+		return fromWorkingDirectory(changes, state.Root(), app.config.WorkingDirectory)
+	}
+}
+
+// fromWorkingDirectory rewrites the paths of changes, relative to root, as the model
+// and the agent read them: relative to dir, or absolute for files outside it
+func fromWorkingDirectory(changes []tools.FileChange, root, dir string) []tools.FileChange {
+	rewritten := make([]tools.FileChange, len(changes))
+	for i, c := range changes {
+		path := filepath.Join(root, filepath.FromSlash(c.Path))
+		if rel, err := filepath.Rel(dir, path); err == nil && platform.Current().Within(path, dir) {
+			path = rel
+		}
+		c.Path = path
+		rewritten[i] = c
+	}
+	return rewritten
+}
+
+// nextExecCheckpoint returns the name for the next checkpoint saved before a command
+func (app *App) nextExecCheckpoint() string {
+	app.sessionMu.Lock()
+	defer app.sessionMu.Unlock()
+	last := 0
+	for _, cp := range app.session.Checkpoints {
+		if n, err := strconv.Atoi(strings.TrimPrefix(cp.Name, execCheckpointPrefix)); err == nil && strings.HasPrefix(cp.Name, execCheckpointPrefix) {
+			last = max(last, n)
+		}
+	}
+	return fmt.Sprintf("%s%d", execCheckpointPrefix, last+1)
+}
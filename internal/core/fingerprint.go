@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codezilla/internal/tools"
+	"codezilla/internal/workspace"
+)
+
+// maxRefreshedBytes bounds the file contents added to the context when a resumed
+// session refers to files that changed
+const maxRefreshedBytes = 16 * 1024
+
+// fingerprint records the state of the project for the current session: the commit
+// checked out and the files read or written in the conversation, along with those
+// recorded before (resumed sessions don't replay their tool calls)
+func (app *App) fingerprint(previous *workspace.Fingerprint) *workspace.Fingerprint {
+	var files []string
+	if previous != nil {
+		for path := range previous.Files {
+			files = append(files, path)
+		}
+	}
+	for _, msg := range app.agent.Messages() {
+		if msg.ToolCall == nil {
+			continue
+		}
+		files = append(files, tools.WrittenFiles(msg.ToolCall.ToolName, msg.ToolCall.Params)...)
+		for _, key := range []string{"file_path", "path"} {
+			if path, ok := msg.ToolCall.Params[key].(string); ok && path != "" {
+				files = append(files, path)
+			}
+		}
+	}
+	f := workspace.TakeFingerprint(context.Background(), app.config.WorkingDirectory, files)
+	return &f
+}
+
+// reportDrift warns that the project changed since a resumed session was saved and,
+// when enabled, gives the model the current contents of the changed files
+func (app *App) reportDrift(d workspace.Drift) {
+	if d.Empty() {
+		return
+	}
+	app.ui.Warning("The workspace changed since this session was saved:")
+	if d.OldBranch != d.NewBranch && d.OldBranch != "" && d.NewBranch != "" {
+		app.ui.Println("  Branch changed from %s to %s", d.OldBranch, d.NewBranch)
+	}
+	if d.OldHead != d.NewHead && d.OldHead != "" && d.NewHead != "" {
+		if d.NewCommits > 0 {
+			app.ui.Println("  HEAD moved from %s to %s (%d new commits)", shortCommit(d.OldHead), shortCommit(d.NewHead), d.NewCommits)
+		} else {
+			app.ui.Println("  HEAD moved from %s to %s, which doesn't build on it", shortCommit(d.OldHead), shortCommit(d.NewHead))
+		}
+	}
+	if len(d.Modified) > 0 {
+		app.ui.Println("  Modified: %s", strings.Join(app.relPaths(d.Modified), ", "))
+	}
+	if len(d.Deleted) > 0 {
+		app.ui.Println("  Deleted: %s", strings.Join(app.relPaths(d.Deleted), ", "))
+	}
+	if len(d.Modified) == 0 && len(d.Deleted) == 0 {
+		return
+	}
+	if !app.config.ResumeRefreshFiles {
+		app.ui.Info("Files quoted in the conversation may be out of date")
+		return
+	}
+	app.agent.AddUserMessage(app.refreshMessage(d))
+	app.ui.Info("Gave the model the current contents of the changed files")
+}
+
+// refreshMessage tells the model which files changed since the conversation was
+// recorded, with the current contents of the modified ones
+func (app *App) refreshMessage(d workspace.Drift) string {
+	var b strings.Builder
+	b.WriteString("Since this conversation was recorded, files it refers to have changed. Anything quoted from them above may be out of date.\n")
+	if len(d.Deleted) > 0 {
+		fmt.Fprintf(&b, "\nDeleted: %s\n", strings.Join(app.relPaths(d.Deleted), ", "))
+	}
+	budget := maxRefreshedBytes
+	for i, path := range d.Modified {
+		name := app.relPaths([]string{path})[0]
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if budget <= 0 {
+			fmt.Fprintf(&b, "\nAlso modified, read again before relying on them: %s\n", strings.Join(app.relPaths(d.Modified[i:]), ", "))
+			break
+		}
+		content := string(data)
+		if len(content) > budget {
+			content = content[:budget] + "\n... (truncated)"
+		}
+		budget -= len(data)
+		fmt.Fprintf(&b, "\nCurrent contents of %s:\n```\n%s\n```\n", name, strings.TrimRight(content, "\n"))
+	}
+	return b.String()
+}
+
+// relPaths returns paths relative to the working directory where they are inside it
+func (app *App) relPaths(paths []string) []string {
+	rel := make([]string, len(paths))
+	for i, path := range paths {
+		rel[i] = path
+		if r, err := filepath.Rel(app.config.WorkingDirectory, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel[i] = r
+		}
+	}
+	return rel
+}
+
+// shortCommit abbreviates a commit ID for display
+func shortCommit(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -1,19 +1,27 @@
 package core
 
 import (
+	"codezilla/internal/cli"
 	"codezilla/internal/tools"
+	"codezilla/llm/cache"
 	"codezilla/llm/ollama"
 	"context"
+	"time"
 )
 
 // LLMClientAdapter adapts ollama.Client to tools.LLMClient
 type LLMClientAdapter struct {
 	client ollama.Client
+	model  string
+	cache  *cache.Cache // nil when responses are not cached
 }
 
-// NewLLMClientAdapter creates a new adapter
-func NewLLMClientAdapter(client ollama.Client) *LLMClientAdapter {
-	return &LLMClientAdapter{client: client}
+// NewLLMClientAdapter creates a new adapter that sends requests to the given model
+func NewLLMClientAdapter(client ollama.Client, model string) *LLMClientAdapter {
+	if model == "" {
+		model = "qwen3:14b"
+	}
+	return &LLMClientAdapter{client: client, model: model}
 }
 
 // GenerateResponse adapts the GenerateResponse call
@@ -29,16 +37,44 @@ func (a *LLMClientAdapter) GenerateResponse(ctx context.Context, messages []tool
 		}
 	}
 
-	// Use the default model for analysis
-	resp, err := a.client.Generate(ctx, ollama.GenerateRequest{
-		Model:  "qwen3:14b",
+	req := ollama.GenerateRequest{
+		Model:  a.model,
 		Prompt: prompt,
 		Stream: false,
-	})
+	}
+	var key string
+	if a.cache != nil {
+		key = cache.Key(req)
+		if response, ok := a.cache.Get(key); ok {
+			return response, nil
+		}
+	}
+
+	resp, err := a.client.Generate(ctx, req)
 
 	if err != nil {
 		return "", err
 	}
 
+	if a.cache != nil {
+		// A failed write only costs a repeated call later
+		_ = a.cache.Put(key, a.model, resp.Response)
+	}
 	return resp.Response, nil
 }
+
+// WithCache makes the adapter answer repeated prompts from c. Only use it for
+// idempotent calls, where the same input should get the same answer.
+func (a *LLMClientAdapter) WithCache(c *cache.Cache) *LLMClientAdapter {
+	a.cache = c
+	return a
+}
+
+// NewLLMCache returns the response cache for internal model calls, or nil when
+// llm_cache is disabled
+func NewLLMCache(config *cli.Config) *cache.Cache {
+	if !config.LLMCache.Enabled || config.LLMCache.Dir == "" {
+		return nil
+	}
+	return cache.New(config.LLMCache.Dir, time.Duration(config.LLMCache.TTLSeconds)*time.Second)
+}
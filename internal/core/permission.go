@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"codezilla/internal/agent"
+	"codezilla/internal/cli"
+	"codezilla/internal/tools"
+	"codezilla/internal/ui"
+	"codezilla/llm/ollama"
+	"codezilla/pkg/logger"
+)
+
+// explainTimeout bounds the model call explaining a permission request, so a slow
+// model never holds up the prompt for long
+const explainTimeout = 20 * time.Second
+
+// explainRequest returns the model's one-sentence explanation of a permission request,
+// or "" when the tool isn't one to explain or no explanation came in time
+func explainRequest(ctx context.Context, config *cli.Config, client ollama.Client, log *logger.Logger, request tools.PermissionRequest) string {
+	settings := config.ExplainCalls
+	toolName := request.ToolContext.ToolName
+	if !settings.Enabled || (!request.Dangerous && !slices.Contains(settings.Tools, toolName)) {
+		return ""
+	}
+	model := settings.Model
+	if model == "" {
+		model = config.DefaultModel
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, explainTimeout)
+	defer cancel()
+	explanation, err := agent.ExplainToolCall(ctx, NewLLMClientAdapter(client, model), toolName, request.Description, request.ToolContext.Params)
+	if err != nil {
+		log.Warn("Failed to explain tool call", "tool", toolName, "error", err)
+		return ""
+	}
+	return explanation
+}
+
+// editParams asks for a new value of each parameter of a tool call, keeping the old
+// one when the answer is empty. Values that aren't strings are read as JSON, so a
+// number stays a number. Multi-line values, such as file contents, are kept as is.
+func editParams(u ui.UI, params map[string]interface{}) (map[string]interface{}, error) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	u.Print("Enter new values, or press Enter to keep a value:\n")
+	edited := make(map[string]interface{}, len(params))
+	for _, name := range names {
+		value := params[name]
+		edited[name] = value
+		current, isString := value.(string)
+		if !isString {
+			data, _ := json.Marshal(value)
+			current = string(data)
+		}
+		if strings.Contains(current, "\n") {
+			u.Print("  %s: %d lines, kept\n", name, strings.Count(current, "\n")+1)
+			continue
+		}
+
+		u.Print("  %s [%s]: ", name, current)
+		answer, err := u.ReadAnswer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if strings.TrimSpace(answer) == "" {
+			continue
+		}
+		if isString {
+			edited[name] = answer
+			continue
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(answer), &parsed); err != nil {
+			parsed = answer
+		}
+		edited[name] = parsed
+	}
+	return edited, nil
+}
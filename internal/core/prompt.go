@@ -0,0 +1,125 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// handlePromptCommand shows the assembled system prompt or toggles prompt snippets
+func (app *App) handlePromptCommand(parts []string) {
+	if len(parts) < 2 {
+		app.listPromptSnippets()
+		return
+	}
+
+	switch parts[1] {
+	case "show":
+		app.ui.Println("\n%s\n", app.agent.SystemPrompt())
+
+	case "list":
+		app.listPromptSnippets()
+
+	case "add":
+		if len(parts) < 3 {
+			app.ui.Warning("Usage: /prompt add <snippet>...")
+			return
+		}
+		for _, name := range parts[2:] {
+			if err := app.prompt.Add(name); err != nil {
+				app.ui.Error("%v", err)
+				return
+			}
+		}
+		app.applyPrompt()
+		app.ui.Success("Enabled: %s", strings.Join(parts[2:], ", "))
+
+	case "remove", "rm":
+		if len(parts) < 3 {
+			app.ui.Warning("Usage: /prompt remove <snippet>...")
+			return
+		}
+		for _, name := range parts[2:] {
+			if err := app.prompt.Remove(name); err != nil {
+				app.ui.Error("%v", err)
+				return
+			}
+		}
+		app.applyPrompt()
+		app.ui.Success("Disabled: %s", strings.Join(parts[2:], ", "))
+
+	case "clear":
+		app.prompt.Clear()
+		app.applyPrompt()
+		app.ui.Success("All prompt snippets disabled")
+
+	case "use":
+		if len(parts) < 3 {
+			app.ui.Warning("Usage: /prompt use <profile>")
+			return
+		}
+		snippets, ok := app.config.PromptProfiles[parts[2]]
+		if !ok {
+			app.ui.Error("Unknown prompt profile: %s", parts[2])
+			return
+		}
+		if err := app.prompt.Set(snippets); err != nil {
+			app.ui.Error("%v", err)
+			return
+		}
+		app.applyPrompt()
+		app.ui.Success("Using prompt profile %s: %s", parts[2], strings.Join(snippets, ", "))
+
+	default:
+		app.ui.Warning("Usage: /prompt [show|list|add <snippet>|remove <snippet>|clear|use <profile>]")
+	}
+}
+
+// applyPrompt sends the recomposed system prompt to the agent
+func (app *App) applyPrompt() {
+	app.agent.SetSystemPrompt(app.prompt.Compose())
+}
+
+// listPromptSnippets shows available snippets and profiles, marking active snippets
+func (app *App) listPromptSnippets() {
+	active := make(map[string]bool)
+	for _, name := range app.prompt.Active() {
+		active[name] = true
+	}
+
+	app.ui.Println("\nPrompt snippets:")
+	for _, name := range app.prompt.Names() {
+		marker := " "
+		if active[name] {
+			marker = "*"
+		}
+		text, _ := app.prompt.Snippet(name)
+		app.ui.Println("%s %-16s %s", marker, name, firstLine(text, 60))
+	}
+
+	if len(app.config.PromptProfiles) > 0 {
+		names := make([]string, 0, len(app.config.PromptProfiles))
+		for name := range app.config.PromptProfiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		app.ui.Println("\nProfiles:")
+		for _, name := range names {
+			app.ui.Println("  %-16s %s", name, strings.Join(app.config.PromptProfiles[name], ", "))
+		}
+	}
+	app.ui.Println("")
+	app.ui.Info("Toggle with /prompt add|remove <snippet>, view the result with /prompt show")
+}
+
+// firstLine returns the first line of text, shortened to at most n runes
+func firstLine(text string, n int) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n-3]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,19 @@
+package core
+
+import "strings"
+
+// checkReferences warns about files and symbols the response mentions that don't
+// exist in the project, so the user doesn't go looking for them
+func (app *App) checkReferences(response string) {
+	if !app.config.CheckReferences || app.searchIndex == nil {
+		return
+	}
+	missing, err := app.searchIndex.MissingReferences(response)
+	if err != nil {
+		app.logger.Debug("Failed to check references in response", "error", err)
+		return
+	}
+	if len(missing) > 0 {
+		app.ui.Warning("Not found in this repo: %s", strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"strings"
+
+	"codezilla/internal/search"
+	"codezilla/pkg/style"
+)
+
+// handleSearchCommand searches the project's files through the index
+func (app *App) handleSearchCommand(parts []string) {
+	if len(parts) < 2 {
+		app.ui.Warning("Usage: /search <query>")
+		return
+	}
+
+	query := strings.Join(parts[1:], " ")
+	results, err := app.searchIndex.Search(query, search.Options{})
+	if err != nil {
+		app.ui.Error("Search failed: %v", err)
+		return
+	}
+	if len(results) == 0 {
+		app.ui.Info("No files match %s", query)
+		return
+	}
+
+	app.ui.Println("")
+	for _, r := range results {
+		signals := ""
+		if len(r.Signals) > 0 {
+			signals = ", " + strings.Join(r.Signals, ", ")
+		}
+		app.ui.Println("%s (%d matches, score %.2f%s)", r.Path, r.Matches, r.Score, signals)
+		for _, line := range r.Lines {
+			app.ui.Println("  %5d: %s", line.Line, highlightRanges(line.Text, line.Ranges))
+		}
+	}
+	app.ui.Println("")
+}
+
+// highlightRanges colors the matched parts of text
+func highlightRanges(text string, ranges [][2]int) string {
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[0] < last {
+			continue
+		}
+		b.WriteString(text[last:r[0]])
+		b.WriteString(style.ColorYellow(text[r[0]:r[1]]))
+		last = r[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
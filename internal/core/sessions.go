@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"codezilla/internal/session"
+)
+
+// recordExchange adds a completed exchange to the current session and persists it
+func (app *App) recordExchange(input, response string) {
+	if app.sessions == nil {
+		return
+	}
+
+	app.sessionMu.Lock()
+	app.session.AddMessage("user", input)
+	app.session.AddMessage("assistant", response)
+	for _, c := range app.agent.Citations() {
+		last := &app.session.Messages[len(app.session.Messages)-1]
+		last.Citations = append(last.Citations, session.Citation{
+			Number:    c.Number,
+			Tool:      c.Tool,
+			Path:      c.Path,
+			Command:   c.Command,
+			StartLine: c.StartLine,
+			EndLine:   c.EndLine,
+		})
+	}
+	app.session.Notes = app.notes.List()
+	app.session.Fingerprint = app.fingerprint(app.session.Fingerprint)
+	firstExchange := len(app.session.Messages) == 2
+	if app.session.Title == "" {
+		app.session.SetTitle(app.session.FallbackTitle(), false)
+	}
+	err := app.sessions.Save(app.session)
+	current := app.session
+	app.sessionMu.Unlock()
+
+	if err != nil {
+		app.logger.Warn("Failed to save session", "error", err)
+		return
+	}
+
+	// Name the session in the background so the prompt is not delayed
+	if firstExchange {
+		go app.generateSessionTitle(current, input, response)
+	}
+}
+
+// generateSessionTitle replaces the fallback title with a model generated one
+func (app *App) generateSessionTitle(s *session.Session, input, response string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	model := app.config.TitleModel
+	if model == "" {
+		model = app.config.DefaultModel
+	}
+
+	title, err := session.GenerateTitle(ctx, NewLLMClientAdapter(app.llmClient, model), input, response)
+	if err != nil {
+		app.logger.Debug("Failed to generate session title", "error", err)
+		return
+	}
+
+	app.sessionMu.Lock()
+	defer app.sessionMu.Unlock()
+	if s.SetTitle(title, false) {
+		if err := app.sessions.Save(s); err != nil {
+			app.logger.Warn("Failed to save session title", "error", err)
+		}
+	}
+}
+
+// startNewSession replaces the current session with an empty one
+func (app *App) startNewSession() {
+	app.sessionMu.Lock()
+	defer app.sessionMu.Unlock()
+	app.session = session.New(app.config.DefaultModel, app.config.WorkingDirectory)
+	app.notes.Clear()
+	app.hooks.SetSessionID(app.session.ID)
+}
+
+// handleSessionsCommand lists, resumes, renames or deletes persisted sessions
+func (app *App) handleSessionsCommand(parts []string) {
+	if app.sessions == nil {
+		app.ui.Warning("Session persistence is disabled (persist_sessions in config)")
+		return
+	}
+
+	if len(parts) < 2 {
+		app.listSessions()
+		return
+	}
+
+	switch parts[1] {
+	case "resume":
+		if len(parts) < 3 {
+			app.ui.Warning("Usage: /sessions resume <id>")
+			return
+		}
+		app.resumeSession(parts[2])
+
+	case "rename":
+		if len(parts) < 4 {
+			app.ui.Warning("Usage: /sessions rename <id> <title>")
+			return
+		}
+		app.renameSession(parts[2], strings.Join(parts[3:], " "))
+
+	case "delete":
+		if len(parts) < 3 {
+			app.ui.Warning("Usage: /sessions delete <id>")
+			return
+		}
+		if err := app.sessions.Delete(parts[2]); err != nil {
+			app.ui.Error("Failed to delete session: %v", err)
+			return
+		}
+		app.ui.Success("Session deleted")
+
+	default:
+		app.ui.Warning("Usage: /sessions [resume <id>|rename <id> <title>|delete <id>]")
+	}
+}
+
+// listSessions shows the most recent sessions
+func (app *App) listSessions() {
+	infos, err := app.sessions.List()
+	if err != nil {
+		app.ui.Error("Failed to list sessions: %v", err)
+		return
+	}
+	if len(infos) == 0 {
+		app.ui.Info("No saved sessions yet")
+		return
+	}
+
+	app.sessionMu.Lock()
+	currentID := app.session.ID
+	app.sessionMu.Unlock()
+
+	const maxListed = 20
+	app.ui.Println("\nSessions (most recent first):")
+	for i, info := range infos {
+		if i == maxListed {
+			app.ui.Println("  ... and %d more", len(infos)-maxListed)
+			break
+		}
+		marker := " "
+		if info.ID == currentID {
+			marker = "*"
+		}
+		title := info.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		app.ui.Println("%s %s  %-40s  %3d msgs  %s",
+			marker, info.ID, title, info.MessageCount, info.Updated.Format("2006-01-02 15:04"))
+	}
+	app.ui.Println("")
+	app.ui.Info("Resume with /sessions resume <id> (a unique ID prefix is enough)")
+}
+
+// resumeSession loads a stored session and replays it into the conversation context
+func (app *App) resumeSession(id string) {
+	loaded, err := app.sessions.Load(id)
+	if err != nil {
+		app.ui.Error("Failed to load session: %v", err)
+		return
+	}
+
+	app.switchSession(loaded)
+
+	title := loaded.Title
+	if title == "" {
+		title = loaded.FallbackTitle()
+	}
+	app.ui.Success("Resumed session %s: %s (%d messages)", loaded.ID, title, len(loaded.Messages))
+	if loaded.Fingerprint != nil {
+		app.reportDrift(loaded.Fingerprint.Compare(context.Background(), app.config.WorkingDirectory))
+	}
+}
+
+// switchSession makes s the current session, replaying its messages into the
+// conversation context and restoring its notes
+func (app *App) switchSession(s *session.Session) {
+	app.contextMgr.Clear()
+	app.agent.ClearContext()
+	app.notes.Replace(s.Notes)
+	for _, msg := range s.Messages {
+		switch msg.Role {
+		case "user":
+			app.contextMgr.AddMessage("User", msg.Content)
+			app.agent.AddUserMessage(msg.Content)
+		case "assistant":
+			app.contextMgr.AddMessage("Assistant", msg.Content)
+			app.agent.AddAssistantMessage(msg.Content)
+		}
+	}
+
+	app.sessionMu.Lock()
+	app.session = s
+	app.sessionMu.Unlock()
+	app.hooks.SetSessionID(s.ID)
+}
+
+// renameSession sets a manual title, updating the current session in memory if it is the one renamed
+func (app *App) renameSession(id, title string) {
+	app.sessionMu.Lock()
+	defer app.sessionMu.Unlock()
+
+	// Compare full IDs, since the command accepts a unique prefix; the current
+	// session is not on disk until its first exchange
+	if id != "" {
+		if fullID, err := app.sessions.Resolve(id); err == nil {
+			id = fullID
+		} else if errors.Is(err, session.ErrNotFound) && strings.HasPrefix(app.session.ID, id) {
+			id = app.session.ID
+		}
+	}
+
+	if id == app.session.ID || (id == "" && len(app.session.Messages) > 0) {
+		if !app.session.SetTitle(title, true) {
+			app.ui.Warning("Title must not be empty")
+			return
+		}
+		if err := app.sessions.Save(app.session); err != nil {
+			app.ui.Error("Failed to save session: %v", err)
+			return
+		}
+		app.ui.Success("Session renamed to: %s", app.session.Title)
+		return
+	}
+
+	if id == "" {
+		app.ui.Warning("Nothing to rename yet: the current session has no messages")
+		return
+	}
+
+	renamed, err := app.sessions.Rename(id, title)
+	if err != nil {
+		app.ui.Error("Failed to rename session: %v", err)
+		return
+	}
+	app.ui.Success("Session %s renamed to: %s", renamed.ID, renamed.Title)
+}
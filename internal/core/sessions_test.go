@@ -0,0 +1,85 @@
+package core
+
+import (
+	"testing"
+
+	"codezilla/internal/session"
+	"codezilla/internal/tools"
+)
+
+func TestRenameCurrentSessionByPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		saved bool
+	}{
+		{"saved", true},
+		{"not saved yet", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, t.TempDir())
+			app.sessions = session.NewStore(t.TempDir())
+			other := &session.Session{ID: "20250101-090000-aaaa"}
+			if err := app.sessions.Save(other); err != nil {
+				t.Fatal(err)
+			}
+			app.session = &session.Session{ID: "20250102-100000-bbbb"}
+			if tt.saved {
+				app.session.AddMessage("user", "hello")
+				if err := app.sessions.Save(app.session); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			app.renameSession("20250102", "Release notes")
+
+			if app.session.Title != "Release notes" {
+				t.Errorf("current session title = %q, want it renamed in memory", app.session.Title)
+			}
+			stored, err := app.sessions.Load(app.session.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Title != "Release notes" {
+				t.Errorf("stored title = %q, want %q", stored.Title, "Release notes")
+			}
+			if reloaded, _ := app.sessions.Load(other.ID); reloaded.Title != "" {
+				t.Errorf("other session was renamed to %q", reloaded.Title)
+			}
+		})
+	}
+}
+
+func TestNotesFollowTheSession(t *testing.T) {
+	app := newTestApp(t, t.TempDir())
+	app.sessions = session.NewStore(t.TempDir())
+	// Sessions past their first exchange are not named in the background
+	started := func() {
+		app.session.AddMessage("user", "hi")
+		app.session.AddMessage("assistant", "hello")
+	}
+	app.session = session.New("test", t.TempDir())
+	started()
+	app.notes.Append("auth.go already inspected")
+	app.recordExchange("where is the login handler?", "in auth.go")
+	first := app.session
+
+	app.startNewSession()
+	if notes := app.notes.List(); len(notes) != 0 {
+		t.Errorf("a new session should start without notes, got %v", notes)
+	}
+	started()
+	app.notes.Append("cache is cold on startup")
+	app.recordExchange("why is startup slow?", "the cache is cold")
+
+	app.resumeSession(first.ID)
+	notes := app.notes.List()
+	if len(notes) != 1 || notes[0].Text != "auth.go already inspected" {
+		t.Errorf("resuming should restore the session's notes, got %v", notes)
+	}
+
+	app.switchSession(&session.Session{ID: "20250101-090000-aaaa", Notes: []tools.Note{{Text: "from the checkpoint"}}})
+	if notes := app.notes.List(); len(notes) != 1 || notes[0].Text != "from the checkpoint" {
+		t.Errorf("switching should replace the notes, got %v", notes)
+	}
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"codezilla/internal/workspace"
+)
+
+// shadowNote tells the model where a /shadow task runs
+const shadowNote = "(You are working in a temporary copy of the project at %s, which is the current directory. Use relative paths; your changes are reviewed before they reach the real project.)\n\n"
+
+// runInShadow runs a task against a temporary copy of the project, then shows the
+// diff against the real workspace and applies or discards it in one step
+func (app *App) runInShadow(ctx context.Context, task string) {
+	root := app.config.WorkingDirectory
+	app.ui.Info("Creating a shadow copy of %s...", root)
+	shadow, err := workspace.New(ctx, root, app.config.ShadowMode)
+	if err != nil {
+		app.ui.Error("Failed to create shadow workspace: %v", err)
+		return
+	}
+	defer func() {
+		if err := shadow.Close(); err != nil {
+			app.logger.Warn("Failed to remove shadow workspace", "dir", shadow.Dir, "error", err)
+		}
+	}()
+
+	if err := app.setWorkingDirectory(shadow.Dir); err != nil {
+		app.ui.Error("Failed to enter shadow workspace: %v", err)
+		return
+	}
+	app.shadow = shadow
+	app.logger.Info("Running task in shadow workspace", "dir", shadow.Dir)
+	taskErr := app.processInput(ctx, fmt.Sprintf(shadowNote, shadow.Dir)+task)
+	app.shadow = nil
+	if err := app.setWorkingDirectory(root); err != nil {
+		app.ui.Error("Failed to return to %s: %v", root, err)
+		return
+	}
+	if taskErr != nil {
+		app.ui.Error("Task failed: %v", taskErr)
+	}
+
+	changes, err := shadow.Changes()
+	if err != nil {
+		app.ui.Error("Failed to compare shadow workspace: %v", err)
+		return
+	}
+	if len(changes) == 0 {
+		app.ui.Info("The task made no changes to the project")
+		return
+	}
+	tx, err := shadow.Transaction(changes)
+	if err != nil {
+		app.ui.Error("Failed to prepare shadow changes: %v", err)
+		return
+	}
+
+	app.ui.Println("")
+	app.ui.Info("Changes made in the shadow workspace:")
+	app.ui.Print("%s\n", tx.Diff())
+	ok, err := app.ui.Confirm("Apply these changes to the project?")
+	if err != nil || !ok {
+		app.ui.Info("Discarded the shadow workspace changes")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		app.ui.Error("Failed to apply changes: %v", err)
+		return
+	}
+	app.ui.Success("Applied changes to %d file(s)", len(changes))
+}
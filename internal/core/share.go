@@ -0,0 +1,149 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codezilla/internal/agent"
+	"codezilla/internal/secrets"
+	"codezilla/internal/session"
+	"codezilla/internal/tools"
+)
+
+// handleShareCommand writes the conversation, secrets redacted, to a Markdown or HTML
+// file for a bug report or a teammate: /share [md|html] [file]
+func (app *App) handleShareCommand(parts []string) {
+	format, path := session.ShareMarkdown, ""
+	for _, arg := range parts[1:] {
+		switch strings.ToLower(arg) {
+		case session.ShareMarkdown, "markdown":
+			format = session.ShareMarkdown
+		case session.ShareHTML:
+			format = session.ShareHTML
+		default:
+			if path != "" {
+				app.ui.Warning("Usage: /share [md|html] [file]")
+				return
+			}
+			path = arg
+		}
+	}
+	if path != "" && format == session.ShareMarkdown && strings.EqualFold(filepath.Ext(path), ".html") {
+		format = session.ShareHTML
+	}
+
+	shared := app.sharedSession()
+	if len(shared.Messages) == 0 {
+		app.ui.Warning("Nothing to share yet")
+		return
+	}
+	doc, err := shared.Share(format, time.Now())
+	if err != nil {
+		app.ui.Error("%v", err)
+		return
+	}
+	if path == "" {
+		path = fmt.Sprintf("codezilla-session-%s.%s", shared.ID, format)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(app.config.WorkingDirectory, path)
+	}
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		app.ui.Error("Failed to write %s: %v", path, err)
+		return
+	}
+	app.ui.Success("Shared %d messages to %s", len(shared.Messages), path)
+	app.ui.Info("Secrets were redacted; review the file before sending it anywhere")
+}
+
+// sharedSession returns a redacted copy of the conversation. The agent's context is
+// used when it holds the whole conversation, as it also has the tool calls; when
+// context is not retained between requests the persisted session is used instead.
+func (app *App) sharedSession() *session.Session {
+	redactor := secrets.NewRedactor(app.knownSecrets()...)
+	home, _ := os.UserHomeDir()
+	redact := func(text string) string {
+		if home != "" && home != "/" {
+			text = strings.ReplaceAll(text, home, "~")
+		}
+		return redactor.Redact(text)
+	}
+
+	app.sessionMu.Lock()
+	current := *app.session
+	current.Messages = append([]session.Message(nil), app.session.Messages...)
+	app.sessionMu.Unlock()
+
+	shared := &session.Session{
+		ID:         current.ID,
+		Title:      redact(current.Title),
+		Model:      app.config.DefaultModel,
+		WorkingDir: redact(app.config.WorkingDirectory),
+		Created:    current.Created,
+	}
+	messages := conversationMessages(app.agent.Messages())
+	if countRole(current.Messages, "user") > countRole(messages, "user") {
+		messages = current.Messages
+	}
+	for _, m := range messages {
+		m.Content = redact(m.Content)
+		shared.Messages = append(shared.Messages, m)
+	}
+	return shared
+}
+
+// knownSecrets returns the credentials Codezilla knows about: those in the config,
+// resolved from secret references, and environment variables named like secrets
+func (app *App) knownSecrets() []string {
+	values := []string{app.config.OllamaAPIKey, app.config.OllamaPassword, app.config.Forge.Token}
+	for _, v := range app.config.OllamaHeaders {
+		values = append(values, v)
+	}
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok && tools.IsSecretEnvName(name) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// conversationMessages converts the agent's context to session messages, leaving out
+// the system prompt and showing tool calls with their parameters
+func conversationMessages(messages []agent.Message) []session.Message {
+	var out []session.Message
+	for _, m := range messages {
+		msg := session.Message{Role: string(m.Role), Content: m.Content, Timestamp: m.Timestamp}
+		switch {
+		case m.Role == agent.RoleSystem:
+			continue
+		case m.ToolCall != nil:
+			params, _ := json.MarshalIndent(m.ToolCall.Params, "", "  ")
+			msg.Role, msg.Content = "tool_call", m.ToolCall.ToolName+" "+string(params)
+		case m.ToolResult != nil:
+			msg.Content = fmt.Sprint(m.ToolResult.Result)
+			if m.ToolResult.Error != "" {
+				msg.Content = "Error: " + m.ToolResult.Error
+			}
+			if m.ToolResult.ToolName != "" {
+				msg.Content = m.ToolResult.ToolName + ": " + msg.Content
+			}
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// countRole counts the messages with a role
+func countRole(messages []session.Message, role string) int {
+	n := 0
+	for _, m := range messages {
+		if m.Role == role {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// handleSnippetCommand manages the user's code snippets
+func (app *App) handleSnippetCommand(ctx context.Context, parts []string) {
+	if len(parts) < 2 || parts[1] == "list" {
+		app.listSnippets()
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		if len(parts) < 3 {
+			app.ui.Warning("Usage: /snippet add <name> [<n>|<file>[:<start>-<end>]] [description]")
+			return
+		}
+		app.addSnippet(parts[2], parts[3:])
+
+	case "show":
+		if len(parts) != 3 {
+			app.ui.Warning("Usage: /snippet show <name>")
+			return
+		}
+		snippet, err := app.snippets.Get(parts[2])
+		if err != nil {
+			app.ui.Error("%v", err)
+			return
+		}
+		app.ui.ShowResponse(fmt.Sprintf("```%s\n%s\n```", snippet.Language, strings.TrimRight(snippet.Code, "\n")))
+
+	case "use":
+		if len(parts) < 4 {
+			app.ui.Warning("Usage: /snippet use <name> <what to do with it>")
+			return
+		}
+		snippet, err := app.snippets.Get(parts[2])
+		if err != nil {
+			app.ui.Error("%v", err)
+			return
+		}
+		if err := app.processInput(ctx, snippetPrompt(snippet, strings.Join(parts[3:], " "))); err != nil {
+			app.ui.Error("Failed to process: %v", err)
+		}
+
+	case "remove", "rm":
+		if len(parts) != 3 {
+			app.ui.Warning("Usage: /snippet remove <name>")
+			return
+		}
+		if err := app.snippets.Remove(parts[2]); err != nil {
+			app.ui.Error("%v", err)
+			return
+		}
+		app.ui.Success("Removed snippet %s", parts[2])
+
+	default:
+		app.ui.Warning("Usage: /snippet [list|add|show|use|remove]")
+	}
+}
+
+// addSnippet saves a code block of the last answer, or lines of a file, as a snippet.
+// Without a source the last answer must have exactly one code block.
+func (app *App) addSnippet(name string, args []string) {
+	snippet := tools.Snippet{Name: name}
+	source := ""
+	if len(args) > 0 {
+		source, args = args[0], args[1:]
+	}
+
+	if n, err := strconv.Atoi(source); err == nil || source == "" {
+		blocks := tools.ExtractFencedBlocks(app.lastResponse)
+		switch {
+		case len(blocks) == 0:
+			app.ui.Warning("The last answer has no code blocks; give a file to take the snippet from")
+			return
+		case source == "" && len(blocks) > 1:
+			app.listCodeBlocks(blocks)
+			app.ui.Warning("Usage: /snippet add %s <n> [description]", name)
+			return
+		case source == "":
+			n = 1
+		case n < 1 || n > len(blocks):
+			app.ui.Error("Code block must be a number from 1 to %d", len(blocks))
+			return
+		}
+		snippet.Code = blocks[n-1].Content
+		snippet.Language = blocks[n-1].Language
+	} else {
+		code, err := readLines(source)
+		if err != nil {
+			app.ui.Error("%v", err)
+			return
+		}
+		snippet.Code = code
+		path, _, _ := strings.Cut(source, ":")
+		snippet.Language = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	snippet.Description = strings.Join(args, " ")
+
+	if err := app.snippets.Add(snippet); err != nil {
+		app.ui.Error("Failed to save snippet: %v", err)
+		return
+	}
+	app.ui.Success("Saved snippet %s (%d lines)", name, strings.Count(strings.TrimRight(snippet.Code, "\n"), "\n")+1)
+}
+
+// listSnippets shows the saved snippets
+func (app *App) listSnippets() {
+	list := app.snippets.List()
+	if len(list) == 0 {
+		app.ui.Info("No snippets saved yet. Use /snippet add <name> to save a code block of the last answer")
+		return
+	}
+	app.ui.Println("\nSnippets:")
+	for _, snippet := range list {
+		language := snippet.Language
+		if language == "" {
+			language = "text"
+		}
+		detail := snippet.Description
+		if detail == "" {
+			detail = firstLine(snippet.Code, 50)
+		}
+		app.ui.Println("  %-20s %-8s %s", snippet.Name, language, detail)
+	}
+	app.ui.Println("")
+}
+
+// readLines reads a file, or the lines of a "path:start-end" range
+func readLines(source string) (string, error) {
+	path, lineRange, hasRange := strings.Cut(source, ":")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !hasRange {
+		return string(data), nil
+	}
+
+	startText, endText, _ := strings.Cut(lineRange, "-")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return "", fmt.Errorf("invalid line range %q, expected <start>-<end>", lineRange)
+	}
+	end := start
+	if endText != "" {
+		if end, err = strconv.Atoi(endText); err != nil {
+			return "", fmt.Errorf("invalid line range %q, expected <start>-<end>", lineRange)
+		}
+	}
+	lines := strings.Split(string(data), "\n")
+	if start < 1 || end < start || end > len(lines) {
+		return "", fmt.Errorf("line range %s is outside %s (%d lines)", lineRange, path, len(lines))
+	}
+	return strings.Join(lines[start-1:end], "\n") + "\n", nil
+}
+
+// snippetPrompt asks the model to carry out request with the snippet inserted verbatim
+func snippetPrompt(snippet tools.Snippet, request string) string {
+	return fmt.Sprintf("%s\n\nUse my saved snippet %q for this. Insert it verbatim, changing only identifiers, names and values that must differ here; keep its structure, error handling and comments as they are.\n\n```%s\n%s\n```",
+		request, snippet.Name, snippet.Language, strings.TrimRight(snippet.Code, "\n"))
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"codezilla/internal/agent"
+)
+
+// summaryPrefix introduces a summary that replaced the conversation context
+const summaryPrefix = "Summary of the conversation so far:\n\n"
+
+// handleSummarizeCommand summarizes the session and optionally replaces the context with the summary
+func (app *App) handleSummarizeCommand(ctx context.Context, parts []string) {
+	replace := false
+	if len(parts) > 1 {
+		if parts[1] != "replace" {
+			app.ui.Warning("Usage: /summarize [replace]")
+			return
+		}
+		replace = true
+	}
+
+	messages := app.agent.Messages()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	app.ui.ShowThinking()
+	summary, err := agent.Summarize(ctx, NewLLMClientAdapter(app.llmClient, app.config.DefaultModel).WithCache(app.llmCache), messages)
+	app.ui.HideThinking()
+	if err != nil {
+		app.ui.Error("Failed to summarize: %v", err)
+		return
+	}
+
+	app.ui.ShowResponse(summary)
+
+	if !replace {
+		app.ui.Info("Use /summarize replace to continue from this summary with a fresh context")
+		return
+	}
+
+	app.contextMgr.Clear()
+	app.agent.ClearContext()
+	app.contextMgr.AddMessage("Assistant", summaryPrefix+summary)
+	app.agent.AddAssistantMessage(summaryPrefix + summary)
+	app.ui.Success("Context replaced with the summary (%d messages condensed)", len(messages))
+}
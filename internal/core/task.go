@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codezilla/internal/i18n"
+	"codezilla/internal/platform"
+	"codezilla/internal/tools"
+	"codezilla/internal/workspace"
+)
+
+// maxCommitSubject caps commit subjects taken from prompts
+const maxCommitSubject = 72
+
+// setWorkingDirectory moves the session to dir, such as a task's worktree: tools
+// resolve relative paths against the process working directory, and edit checks,
+// build checks, change tracking and hooks run in config.WorkingDirectory. The system
+// prompt, the additional roots and the search index follow, so the model sees and
+// works on the files in dir rather than those it left.
+func (app *App) setWorkingDirectory(dir string) error {
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	from := app.config.WorkingDirectory
+	app.config.WorkingDirectory = dir
+	app.config.SystemPrompt = i18n.Translate(i18n.Resolve(app.config.Language), "prompt.system", dir)
+	app.hooks.SetDir(dir)
+
+	tools.SetRoots(dir, movedRoots(tools.Roots(), from, dir))
+	if app.searchIndex != nil {
+		app.searchIndex.SetRoot(dir)
+		app.searchIndex.SetRoots(tools.Roots())
+	}
+	if app.prompt != nil {
+		app.prompt.SetBase(basePrompt(app.config, app.logger))
+		app.applyPrompt()
+	}
+	return nil
+}
+
+// movedRoots returns the additional roots with those inside from moved to the same
+// place under to, such as into a shadow copy of the project. Roots elsewhere are
+// shared by both directories and kept.
+func movedRoots(roots map[string]string, from, to string) map[string]string {
+	moved := make(map[string]string, len(roots))
+	for name, root := range roots {
+		if platform.Current().Within(root, from) {
+			rel, _ := filepath.Rel(from, root)
+			root = filepath.Join(to, rel)
+		}
+		moved[name] = root
+	}
+	return moved
+}
+
+// startTask creates a branch for a task named after title and switches to it
+func (app *App) startTask(ctx context.Context, title string) {
+	if app.task != nil {
+		app.ui.Warning("Task %q is still active on %s; use /finish first", app.task.Title, app.task.Branch)
+		return
+	}
+	title = firstLine(title, maxCommitSubject)
+	task, err := workspace.StartTask(ctx, app.config.WorkingDirectory, title, app.config.TaskBranches == workspace.TaskBranchesWorktree)
+	if err != nil {
+		app.ui.Error("Failed to start task branch: %v", err)
+		return
+	}
+	if task.Dir != app.config.WorkingDirectory {
+		if err := app.setWorkingDirectory(task.Dir); err != nil {
+			app.ui.Error("Failed to enter worktree %s: %v", task.Dir, err)
+			return
+		}
+	}
+	app.task = task
+	app.logger.Info("Started task", "title", title, "branch", task.Branch, "dir", task.Dir)
+	app.ui.Success("Working on branch %s (from %s)", task.Branch, task.Base)
+	if task.Dir != task.Root {
+		app.ui.Info("Worktree: %s", task.Dir)
+	}
+}
+
+// commitTaskChanges commits the changes made while answering input to the task branch
+func (app *App) commitTaskChanges(ctx context.Context, input string) {
+	if app.task == nil {
+		return
+	}
+	committed, err := app.task.Commit(ctx, firstLine(input, maxCommitSubject))
+	if err != nil {
+		app.ui.Warning("Failed to commit changes to %s: %v", app.task.Branch, err)
+		return
+	}
+	if committed {
+		app.ui.Info("Committed changes to %s", app.task.Branch)
+		tools.RecordTodoCommit(ctx, app.task.Dir)
+	}
+}
+
+// showTask shows the active task and its commits
+func (app *App) showTask() {
+	if app.task == nil {
+		app.ui.Info("No active task. Start one with /task <title>")
+		return
+	}
+	app.ui.Info("Task %q on branch %s (from %s)", app.task.Title, app.task.Branch, app.task.Base)
+	commits, _ := app.task.Commits(context.Background())
+	for _, c := range commits {
+		app.ui.Print("  - %s\n", c)
+	}
+}
+
+// finishTask walks through squashing the task's commits and preparing a pull request,
+// then leaves the task branch
+func (app *App) finishTask(ctx context.Context) {
+	task := app.task
+	if task == nil {
+		app.ui.Warning("No active task to finish")
+		return
+	}
+	if _, err := task.Commit(ctx, "Final changes for "+task.Title); err != nil {
+		app.ui.Error("Failed to commit pending changes: %v", err)
+		return
+	}
+
+	commits, err := task.Commits(ctx)
+	if err != nil {
+		app.ui.Error("Failed to list task commits: %v", err)
+		return
+	}
+	if len(commits) == 0 {
+		app.ui.Info("The task made no commits")
+	} else {
+		app.ui.Info("%s has %d commit(s):", task.Branch, len(commits))
+		for _, c := range commits {
+			app.ui.Print("  - %s\n", c)
+		}
+		if stat, _ := task.DiffStat(ctx); stat != "" {
+			app.ui.Print("\n%s\n\n", stat)
+		}
+
+		if len(commits) > 1 {
+			if ok, _ := app.ui.Confirm("Squash them into one commit?"); ok {
+				app.ui.Print("Commit message [%s]: ", task.Title)
+				message, _ := app.ui.ReadAnswer()
+				if message = strings.TrimSpace(message); message == "" {
+					message = task.Title
+				}
+				if err := task.Squash(ctx, message); err != nil {
+					app.ui.Error("Failed to squash commits: %v", err)
+					return
+				}
+				app.ui.Success("Squashed into one commit")
+			}
+		}
+
+		if title, body, err := task.PullRequest(ctx); err == nil {
+			app.ui.Println("")
+			app.ui.Info("Pull request draft:")
+			app.ui.Print("Title: %s\n\n%s\n", title, body)
+			app.ui.Info("Push the branch with: git push -u origin %s", task.Branch)
+		}
+	}
+
+	if ok, _ := app.ui.Confirm("Leave the task and return to " + task.Base + "?"); !ok {
+		app.ui.Info("Still on %s; run /finish again when you are done", task.Branch)
+		return
+	}
+	if task.Dir != task.Root {
+		if err := app.setWorkingDirectory(task.Root); err != nil {
+			app.ui.Error("Failed to return to %s: %v", task.Root, err)
+			return
+		}
+	}
+	if err := task.Close(ctx); err != nil {
+		app.ui.Error("Failed to leave the task branch: %v", err)
+	}
+	app.task = nil
+	app.ui.Success("Finished task; %s keeps its commits", task.Branch)
+}
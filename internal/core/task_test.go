@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codezilla/internal/agent"
+	"codezilla/internal/cli"
+	"codezilla/internal/search"
+	"codezilla/internal/tools"
+	"codezilla/internal/ui"
+	"codezilla/internal/workspace"
+	"codezilla/pkg/logger"
+)
+
+// newTestApp returns an app for the project at root with only what switching
+// directories needs
+func newTestApp(t *testing.T, root string) *App {
+	t.Helper()
+	log, _ := logger.New(logger.Config{Silent: true})
+	minimal, err := ui.NewMinimalUI("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := cli.DefaultConfig()
+	config.WorkingDirectory = root
+	prompt, err := agent.NewPromptComposer(basePrompt(config, log), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &App{
+		config:      config,
+		logger:      log,
+		agent:       agent.NewAgent(&agent.Config{Model: "test", Logger: log}),
+		searchIndex: search.New(root),
+		notes:       tools.NewNotesStore(),
+		contextMgr:  cli.NewSimpleContextManager(10),
+		prompt:      prompt,
+		ui:          minimal,
+	}
+}
+
+func TestWorktreeTaskWritesToWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "project")
+	os.MkdirAll(root, 0755)
+	for _, args := range [][]string{{"init", "-q"}, {"commit", "-q", "--allow-empty", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApp(t, root)
+	app.config.TaskBranches = workspace.TaskBranchesWorktree
+	app.startTask(ctx, "add notes")
+	if app.task == nil {
+		t.Fatal("startTask did not start a task")
+	}
+	dir := app.task.Dir
+	if dir == root {
+		t.Fatalf("task dir = %s, want a worktree", dir)
+	}
+
+	if _, err := tools.NewFileWriteTool().Execute(ctx, map[string]interface{}{"file_path": "notes.txt", "content": "hello\n", "skip_diff": true}); err != nil {
+		t.Fatalf("fileWrite: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("file written during the task should be in the worktree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("file written during the task should not be in the original checkout")
+	}
+	if !strings.Contains(app.agent.SystemPrompt(), dir) {
+		t.Errorf("system prompt should name the worktree %s", dir)
+	}
+	if got := app.searchIndex.Root(); got != dir {
+		t.Errorf("search index root = %s, want %s", got, dir)
+	}
+
+	if err := app.setWorkingDirectory(root); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(app.agent.SystemPrompt(), dir) || app.searchIndex.Root() != root {
+		t.Error("switching back should point the prompt and index at the original checkout")
+	}
+}
+
+func TestShadowSwitchesRootsAndIndex(t *testing.T) {
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Cleanup(func() { tools.SetRoots("", nil) })
+
+	ctx := context.Background()
+	root := t.TempDir()
+	shared := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	tools.SetRoots(root, map[string]string{"docs": "docs", "shared": shared})
+
+	app := newTestApp(t, root)
+	shadow, err := workspace.New(ctx, root, workspace.ModeCopy)
+	if err != nil {
+		t.Fatalf("workspace.New: %v", err)
+	}
+	defer shadow.Close()
+	if err := app.setWorkingDirectory(shadow.Dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tools.NewFileWriteTool().Execute(ctx, map[string]interface{}{"file_path": "docs:guide.md", "content": "# Guide\n", "skip_diff": true}); err != nil {
+		t.Fatalf("fileWrite: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(shadow.Dir, "docs", "guide.md")); err != nil {
+		t.Errorf("a root inside the project should move into the shadow: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "docs", "guide.md")); !os.IsNotExist(err) {
+		t.Error("writing to a root inside the project bypassed the shadow")
+	}
+	if got := tools.Roots()["shared"]; got != shared {
+		t.Errorf("root outside the project = %s, want it kept at %s", got, shared)
+	}
+	if app.searchIndex.Root() != shadow.Dir || !strings.Contains(app.agent.SystemPrompt(), shadow.Dir) {
+		t.Error("the search index and system prompt should point at the shadow")
+	}
+
+	if err := app.setWorkingDirectory(root); err != nil {
+		t.Fatal(err)
+	}
+	if got := tools.Roots()["docs"]; got != filepath.Join(root, "docs") {
+		t.Errorf("returning from the shadow should move the root back, got %s", got)
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"codezilla/internal/tools"
+)
+
+// handleTodoCommand shows the current todo plan or its stats
+func (app *App) handleTodoCommand(ctx context.Context, parts []string) {
+	planID := ""
+	if len(parts) > 2 {
+		planID = parts[2]
+	}
+	if len(parts) < 2 || parts[1] == "list" {
+		app.showTodoPlan(ctx, planID)
+		return
+	}
+
+	switch parts[1] {
+	case "stats":
+		plan, err := tools.TodoPlanByID(planID)
+		if err != nil {
+			app.ui.Info("No todo plan yet; the assistant creates one for multi-step tasks")
+			return
+		}
+		app.ui.ShowResponse(tools.FormatTodoStats(plan.Stats(time.Now())))
+
+	default:
+		app.ui.Warning("Usage: /todo [list|stats] [plan-id]")
+	}
+}
+
+// showTodoPlan shows a plan's items through the todo_list tool
+func (app *App) showTodoPlan(ctx context.Context, planID string) {
+	plan, err := tools.TodoPlanByID(planID)
+	if err != nil {
+		app.ui.Info("No todo plan yet; the assistant creates one for multi-step tasks")
+		return
+	}
+	tool, ok := app.tools.GetTool("todo_list")
+	if !ok {
+		app.ui.Warning("The todo tools are not registered")
+		return
+	}
+	result, err := tool.Execute(ctx, map[string]interface{}{"plan_id": plan.ID})
+	if err != nil {
+		app.ui.Error("Failed to list todo items: %v", err)
+		return
+	}
+	app.ui.ShowResponse(fmt.Sprint(result))
+}
+
+// saveTodoState keeps the todo plans, with the time tracked on them, for the next session
+func (app *App) saveTodoState() {
+	if err := tools.SaveTodoState(); err != nil && app.logger != nil {
+		app.logger.Warn("Failed to save todo plans", "error", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package doctor checks the external programs Codezilla's tools rely on and reports
+// the features that are degraded without them.
+package doctor
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusMissing Status = "missing"
+	StatusOld     Status = "outdated" // Installed, but older than MinVersion
+)
+
+// Requirement is an external program and what depends on it
+type Requirement struct {
+	Binary      string   `json:"binary"`
+	VersionArgs []string `json:"-"` // Arguments that print the version; none when it can't
+	MinVersion  string   `json:"min_version,omitempty"`
+	// Alternatives can stand in for the binary, such as glab for gh
+	Alternatives []string `json:"alternatives,omitempty"`
+	// OS limits the check to one operating system, as in runtime.GOOS
+	OS       string `json:"-"`
+	Degraded string `json:"degraded"` // What doesn't work, or works worse, without it
+	Fix      string `json:"fix"`
+}
+
+// Result is the outcome of checking a requirement
+type Result struct {
+	Requirement
+	Status  Status `json:"status"`
+	Found   string `json:"found,omitempty"` // Binary found, when it's an alternative
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Requirements are the programs the tools use, by how much they matter
+var Requirements = []Requirement{
+	{
+		Binary: "git", VersionArgs: []string{"--version"}, MinVersion: "2.20",
+		Degraded: "task branches, review, changelog and the diff-aware tools don't work, and checkpoints are kept as file copies",
+		Fix:      "install git 2.20 or later from https://git-scm.com/downloads",
+	},
+	{
+		Binary: "gopls", VersionArgs: []string{"version"},
+		Degraded: "renameSymbol renames Go identifiers by text instead of by type information",
+		Fix:      "go install golang.org/x/tools/gopls@latest",
+	},
+	{
+		Binary: "gh", VersionArgs: []string{"--version"}, Alternatives: []string{"glab"},
+		Degraded: "issue, pull request and CI tools need a forge token in the config",
+		Fix:      "install the GitHub CLI (https://cli.github.com) or GitLab CLI (https://gitlab.com/gitlab-org/cli) and log in, or set forge.token",
+	},
+	{
+		Binary: "govulncheck", VersionArgs: []string{"-version"},
+		Degraded: "audit skips Go dependencies",
+		Fix:      "go install golang.org/x/vuln/cmd/govulncheck@latest",
+	},
+	{
+		Binary: "npm", VersionArgs: []string{"--version"},
+		Degraded: "audit skips npm dependencies, and dependency tools can't manage npm projects",
+		Fix:      "install Node.js from https://nodejs.org",
+	},
+	{
+		Binary: "goimports", Alternatives: []string{"gofmt"},
+		Degraded: "Go files the assistant edits aren't formatted",
+		Fix:      "go install golang.org/x/tools/cmd/goimports@latest",
+	},
+	{
+		Binary: "ruff", VersionArgs: []string{"--version"}, Alternatives: []string{"black"},
+		Degraded: "Python files the assistant edits aren't formatted or linted",
+		Fix:      "pip install ruff",
+	},
+	{
+		Binary: "secret-tool", OS: "linux",
+		Degraded: "secrets are kept in the encrypted file instead of the desktop keyring",
+		Fix:      "install libsecret-tools (apt) or libsecret (dnf, pacman)",
+	},
+}
+
+// Checker checks requirements
+type Checker struct {
+	OS       string                                                        // Operating system, as in runtime.GOOS
+	LookPath func(file string) (string, error)                             // exec.LookPath when nil
+	Output   func(ctx context.Context, name string, args ...string) string // Runs a version command
+}
+
+// Check checks each requirement that applies to the operating system
+func (c *Checker) Check(ctx context.Context, reqs []Requirement) []Result {
+	lookPath := c.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	output := c.Output
+	if output == nil {
+		output = commandOutput
+	}
+
+	var results []Result
+	for _, req := range reqs {
+		if req.OS != "" && req.OS != c.OS {
+			continue
+		}
+		result := Result{Requirement: req, Status: StatusMissing}
+		for _, bin := range append([]string{req.Binary}, req.Alternatives...) {
+			path, err := lookPath(bin)
+			if err != nil {
+				continue
+			}
+			result.Status, result.Found, result.Path = StatusOK, bin, path
+			if bin == req.Binary && len(req.VersionArgs) > 0 {
+				result.Version = ParseVersion(output(ctx, path, req.VersionArgs...))
+				if req.MinVersion != "" && result.Version != "" && compareVersions(result.Version, req.MinVersion) < 0 {
+					result.Status = StatusOld
+				}
+			}
+			break
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// commandOutput runs a command briefly and returns its output, or "" on failure
+func commandOutput(ctx context.Context, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// versionPattern matches a dotted version number, such as 2.43.0
+var versionPattern = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?`)
+
+// ParseVersion returns the first version number in the output of a version command,
+// such as 2.43.0 from "git version 2.43.0"
+func ParseVersion(output string) string {
+	return versionPattern.FindString(output)
+}
+
+// compareVersions compares dotted version numbers, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	installed := map[string]string{
+		"git":   "git version 2.17.1",
+		"glab":  "glab version 1.36.0",
+		"gopls": "golang.org/x/tools/gopls v0.15.3",
+	}
+	checker := &Checker{
+		OS: "darwin",
+		LookPath: func(file string) (string, error) {
+			if _, ok := installed[file]; ok {
+				return "/usr/bin/" + file, nil
+			}
+			return "", errors.New("not found")
+		},
+		Output: func(ctx context.Context, name string, args ...string) string {
+			return installed[name[len("/usr/bin/"):]]
+		},
+	}
+	reqs := []Requirement{
+		{Binary: "git", VersionArgs: []string{"--version"}, MinVersion: "2.20"},
+		{Binary: "gopls", VersionArgs: []string{"version"}, MinVersion: "0.9"},
+		{Binary: "gh", VersionArgs: []string{"--version"}, Alternatives: []string{"glab"}},
+		{Binary: "ruff"},
+		{Binary: "secret-tool", OS: "linux"},
+	}
+
+	results := checker.Check(context.Background(), reqs)
+	want := []struct {
+		binary, found, version string
+		status                 Status
+	}{
+		{"git", "git", "2.17.1", StatusOld},
+		{"gopls", "gopls", "0.15.3", StatusOK},
+		{"gh", "glab", "", StatusOK},
+		{"ruff", "", "", StatusMissing},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d (secret-tool only applies on linux)", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Binary != w.binary || r.Found != w.found || r.Version != w.version || r.Status != w.status {
+			t.Errorf("result %d = %s found %q version %q %s; want %s found %q version %q %s",
+				i, r.Binary, r.Found, r.Version, r.Status, w.binary, w.found, w.version, w.status)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.43.0", "2.20", 1},
+		{"2.9", "2.20", -1},
+		{"2.20.0", "2.20", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
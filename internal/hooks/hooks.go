@@ -0,0 +1,201 @@
+// Package hooks runs user-defined shell commands on agent lifecycle events, such as
+// formatting a file after every edit or vetoing a command before it runs
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"codezilla/internal/platform"
+)
+
+// Lifecycle events. Tool events can be narrowed to one tool by appending its name,
+// as in "before_tool:execute".
+const (
+	// SessionStart fires once when an interactive session begins
+	SessionStart = "on_session_start"
+	// BeforeTool fires after a tool call is approved and before it runs. A hook that
+	// exits with a non-zero status blocks the call; its output is the reason given.
+	BeforeTool = "before_tool"
+	// AfterTool fires after a tool call succeeds
+	AfterTool = "after_tool"
+	// AfterFileWrite fires for each file written by a tool or /apply
+	AfterFileWrite = "after_file_write"
+	// Response fires after each answer is shown
+	Response = "on_response"
+)
+
+// DefaultTimeout bounds each hook command
+const DefaultTimeout = 60 * time.Second
+
+// maxOutput caps the output of a failed hook kept in its error
+const maxOutput = 4000
+
+// Events lists the lifecycle events hooks can be defined for
+var Events = []string{SessionStart, BeforeTool, AfterTool, AfterFileWrite, Response}
+
+// ValidEvent reports whether key names an event, optionally narrowed to a tool
+func ValidEvent(key string) bool {
+	event, tool, narrowed := strings.Cut(key, ":")
+	if narrowed {
+		return (event == BeforeTool || event == AfterTool) && tool != ""
+	}
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload describes an event. It is written to each hook's stdin as JSON.
+type Payload struct {
+	Event     string                 `json:"event"`
+	SessionID string                 `json:"session_id,omitempty"`
+	Cwd       string                 `json:"cwd"`
+	Tool      string                 `json:"tool,omitempty"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	File      string                 `json:"file,omitempty"`
+	Result    string                 `json:"result,omitempty"`
+	Response  string                 `json:"response,omitempty"`
+}
+
+// Error is a hook command that failed or exited with a non-zero status
+type Error struct {
+	Event   string
+	Command string
+	Output  string // Combined stdout and stderr, trimmed
+	Err     error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s hook %q failed: %v", e.Event, e.Command, e.Err)
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Runner runs the hooks configured for each event
+type Runner struct {
+	hooks   map[string][]string
+	dir     string
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// New creates a runner for hooks keyed by event, running them in dir. It returns nil
+// when no hooks are configured; a nil runner runs nothing.
+func New(hooks map[string][]string, dir string) *Runner {
+	if len(hooks) == 0 {
+		return nil
+	}
+	return &Runner{hooks: hooks, dir: dir, Timeout: DefaultTimeout}
+}
+
+// SetSessionID sets the session ID passed to hooks that are not given one
+func (r *Runner) SetSessionID(id string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.sessionID = id
+	r.mu.Unlock()
+}
+
+// SetDir changes the directory hooks run in, such as when the session moves to a
+// worktree or a shadow copy of the project
+func (r *Runner) SetDir(dir string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.dir = dir
+	r.mu.Unlock()
+}
+
+// Has reports whether any hook would run for the event and tool
+func (r *Runner) Has(event, tool string) bool {
+	return len(r.commands(event, tool)) > 0
+}
+
+// commands returns the hooks for an event, those for all tools first
+func (r *Runner) commands(event, tool string) []string {
+	if r == nil {
+		return nil
+	}
+	commands := r.hooks[event]
+	if tool != "" {
+		commands = append(append([]string(nil), commands...), r.hooks[event+":"+tool]...)
+	}
+	return commands
+}
+
+// Run runs the hooks of an event in order with the payload on stdin, stopping at the
+// first that fails. The event and tool come from the payload.
+func (r *Runner) Run(ctx context.Context, payload Payload) error {
+	commands := r.commands(payload.Event, payload.Tool)
+	if len(commands) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	if payload.Cwd == "" {
+		payload.Cwd = r.dir
+	}
+	if payload.SessionID == "" {
+		payload.SessionID = r.sessionID
+	}
+	r.mu.Unlock()
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", payload.Event, err)
+	}
+	for _, command := range commands {
+		if err := r.run(ctx, command, payload, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs one hook command through the shell
+func (r *Runner) run(ctx context.Context, command string, payload Payload, input []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	cmd := platform.Current().Command(ctx, command)
+	cmd.Dir = payload.Cwd
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		"CODEZILLA_EVENT="+payload.Event,
+		"CODEZILLA_TOOL="+payload.Tool,
+		"CODEZILLA_FILE="+payload.File,
+		"CODEZILLA_SESSION_ID="+payload.SessionID,
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", r.Timeout)
+		}
+		out := strings.TrimSpace(output.String())
+		if len(out) > maxOutput {
+			out = out[:maxOutput] + "..."
+		}
+		return &Error{Event: payload.Event, Command: command, Output: out, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidEvent(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"on_session_start", true},
+		{"after_file_write", true},
+		{"before_tool", true},
+		{"before_tool:execute", true},
+		{"after_tool:fileWrite", true},
+		{"before_tool:", false},
+		{"after_file_write:fileWrite", false},
+		{"on_exit", false},
+	}
+	for _, tt := range tests {
+		if got := ValidEvent(tt.key); got != tt.want {
+			t.Errorf("ValidEvent(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRunnerPassesEvent(t *testing.T) {
+	dir := t.TempDir()
+	r := New(map[string][]string{
+		AfterFileWrite: {`cat > event.json`, `printf '%s %s' "$CODEZILLA_EVENT" "$CODEZILLA_FILE" > env.txt`},
+	}, dir)
+	r.SetSessionID("s1")
+
+	if err := r.Run(context.Background(), Payload{Event: AfterFileWrite, Tool: "fileWrite", File: "main.go"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "event.json"))
+	if err != nil {
+		t.Fatalf("hook did not get the event: %v", err)
+	}
+	var got Payload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("stdin is not JSON: %v\n%s", err, data)
+	}
+	if got.Event != AfterFileWrite || got.File != "main.go" || got.SessionID != "s1" || got.Cwd != dir {
+		t.Errorf("payload = %+v", got)
+	}
+	if env, _ := os.ReadFile(filepath.Join(dir, "env.txt")); string(env) != "after_file_write main.go" {
+		t.Errorf("environment = %q", env)
+	}
+}
+
+func TestRunnerSetDir(t *testing.T) {
+	dir, moved := t.TempDir(), t.TempDir()
+	r := New(map[string][]string{Response: {`touch ran`}}, dir)
+	r.SetDir(moved)
+	if err := r.Run(context.Background(), Payload{Event: Response}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(moved, "ran")); err != nil {
+		t.Errorf("hook did not run in the new directory: %v", err)
+	}
+}
+
+func TestRunnerToolHooks(t *testing.T) {
+	dir := t.TempDir()
+	r := New(map[string][]string{
+		BeforeTool:                {"echo all >> calls.txt"},
+		BeforeTool + ":execute":   {`grep -q '"rm -rf' && { echo "no recursive deletes"; exit 2; }; exit 0`},
+		BeforeTool + ":fileWrite": {"echo write >> calls.txt"},
+	}, dir)
+
+	ctx := context.Background()
+	if !r.Has(BeforeTool, "execute") || r.Has(AfterTool, "execute") {
+		t.Error("Has() does not match the configured hooks")
+	}
+	if err := r.Run(ctx, Payload{Event: BeforeTool, Tool: "execute", Params: map[string]interface{}{"command": "ls"}}); err != nil {
+		t.Errorf("allowed command: Run() error = %v", err)
+	}
+	err := r.Run(ctx, Payload{Event: BeforeTool, Tool: "execute", Params: map[string]interface{}{"command": "rm -rf /"}})
+	var hookErr *Error
+	if !errors.As(err, &hookErr) || hookErr.Output != "no recursive deletes" {
+		t.Errorf("blocked command: Run() error = %v", err)
+	}
+	if err := r.Run(ctx, Payload{Event: BeforeTool, Tool: "fileRead"}); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls.txt"))
+	if got := strings.Fields(string(calls)); strings.Join(got, " ") != "all all all" {
+		t.Errorf("hooks run = %v, want the general hook once per call", got)
+	}
+}
+
+func TestNilRunner(t *testing.T) {
+	r := New(nil, ".")
+	if r != nil {
+		t.Fatalf("New(nil) = %v, want nil", r)
+	}
+	r.SetSessionID("s1")
+	if err := r.Run(context.Background(), Payload{Event: Response}); err != nil || r.Has(Response, "") {
+		t.Errorf("nil runner ran hooks: %v", err)
+	}
+}
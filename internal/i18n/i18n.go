@@ -0,0 +1,164 @@
+// Package i18n translates user-facing strings and the default system prompt. Messages
+// live in one JSON file per language under locales/, keyed by message ID; a message
+// missing from a locale falls back to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Auto picks the language from the LC_ALL, LC_MESSAGES and LANG environment variables
+const Auto = "auto"
+
+// Fallback is the language used for messages missing from the active locale
+const Fallback = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	loadOnce sync.Once
+	locales  map[string]map[string]string
+
+	mu      sync.RWMutex
+	current = Fallback
+)
+
+// load reads the embedded locale files
+func load() {
+	loadOnce.Do(func() {
+		locales = make(map[string]map[string]string)
+		entries, err := localeFiles.ReadDir("locales")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: reading locales: %v", err))
+		}
+		for _, entry := range entries {
+			data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+			if err != nil {
+				panic(fmt.Sprintf("i18n: reading %s: %v", entry.Name(), err))
+			}
+			messages := make(map[string]string)
+			if err := json.Unmarshal(data, &messages); err != nil {
+				panic(fmt.Sprintf("i18n: parsing %s: %v", entry.Name(), err))
+			}
+			locales[strings.TrimSuffix(entry.Name(), ".json")] = messages
+		}
+	})
+}
+
+// Languages returns the codes of the available languages, sorted
+func Languages() []string {
+	load()
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Resolve returns the available language for a setting: a code such as "de" or
+// "pt_BR.UTF-8", or Auto to read it from the environment. Unknown and empty
+// languages resolve to Fallback.
+func Resolve(language string) string {
+	load()
+	if language == Auto {
+		language = ""
+		for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if value := os.Getenv(name); value != "" {
+				language = value
+				break
+			}
+		}
+	}
+	// "de_DE.UTF-8" and "de-DE" are German
+	code := strings.ToLower(language)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := locales[code]; ok {
+		return code
+	}
+	return Fallback
+}
+
+// SetLanguage makes the language of a setting the one T translates to, and returns it
+func SetLanguage(language string) string {
+	code := Resolve(language)
+	mu.Lock()
+	current = code
+	mu.Unlock()
+	return code
+}
+
+// Language returns the language T translates to
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T translates a message into the current language, formatting it with args
+func T(key string, args ...interface{}) string {
+	return Translate(Language(), key, args...)
+}
+
+// Translate translates a message into language, formatting it with args. A message
+// missing from the locale is taken from English, and an unknown one is its key.
+func Translate(language, key string, args ...interface{}) string {
+	load()
+	message, ok := locales[language][key]
+	if !ok {
+		if message, ok = locales[Fallback][key]; !ok {
+			message = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// IsYes reports whether a confirmation answer means yes, in English or the current
+// language
+func IsYes(answer string) bool {
+	return answerIn(answer, "confirm.yes")
+}
+
+// IsNo reports whether a confirmation answer means no, in English or the current
+// language
+func IsNo(answer string) bool {
+	return answerIn(answer, "confirm.no")
+}
+
+// IsAlways reports whether a permission answer means allow always, in English or
+// the current language
+func IsAlways(answer string) bool {
+	return answerIn(answer, "permission.always")
+}
+
+// IsEdit reports whether a permission answer means edit the parameters, in English
+// or the current language
+func IsEdit(answer string) bool {
+	return answerIn(answer, "permission.edit")
+}
+
+// answerIn reports whether answer is one of the comma-separated words of a message
+func answerIn(answer, key string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	for _, words := range []string{Translate(Fallback, key), T(key)} {
+		for _, word := range strings.Split(words, ",") {
+			if answer == strings.TrimSpace(word) {
+				return true
+			}
+		}
+	}
+	return false
+}
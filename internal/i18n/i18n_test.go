@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// verbPattern matches the formatting verbs of a message
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestLocalesMatchEnglish(t *testing.T) {
+	load()
+	english := locales[Fallback]
+	for _, code := range Languages() {
+		for key, message := range english {
+			translated, ok := locales[code][key]
+			if !ok {
+				t.Errorf("%s is missing %q", code, key)
+				continue
+			}
+			if got, want := verbPattern.FindAllString(translated, -1), verbPattern.FindAllString(message, -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %q has verbs %v, want %v", code, key, got, want)
+			}
+		}
+		for key := range locales[code] {
+			if _, ok := english[key]; !ok {
+				t.Errorf("%s has %q, which English lacks", code, key)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		language, lang, want string
+	}{
+		{"de", "", "de"},
+		{"fr_FR.UTF-8", "", "fr"},
+		{"es-MX", "", "es"},
+		{"xx", "", "en"},
+		{Auto, "de_AT.UTF-8", "de"},
+		{"", "de_AT.UTF-8", "en"},
+		{Auto, "C", "en"},
+		{Auto, "", "en"},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.lang)
+		if got := Resolve(tt.language); got != tt.want {
+			t.Errorf("Resolve(%q) with LANG=%q = %q, want %q", tt.language, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage(Fallback)
+	if got := Translate("de", "ui.unknown_command", "/foo"); got != "Unbekannter Befehl: /foo" {
+		t.Errorf("Translate(de) = %q", got)
+	}
+	if got := Translate("de", "no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key = %q, want the key", got)
+	}
+
+	SetLanguage("es")
+	if got := T("ui.goodbye"); got != "¡Hasta luego!" {
+		t.Errorf("T() = %q", got)
+	}
+	for answer, want := range map[string]bool{"sí": true, "S": true, "yes": true, "n": false, "nein": false} {
+		if got := IsYes(answer); got != want {
+			t.Errorf("IsYes(%q) = %v, want %v", answer, got, want)
+		}
+	}
+	if !IsNo("no") || IsNo("si") {
+		t.Error("IsNo() does not match Spanish answers")
+	}
+	if !IsAlways("siempre") || !IsAlways("a") || IsAlways("s") {
+		t.Error("IsAlways() does not match Spanish answers")
+	}
+	if !IsEdit("Editar") || !IsEdit("e") || IsEdit("n") {
+		t.Error("IsEdit() does not match Spanish answers")
+	}
+}
@@ -0,0 +1,138 @@
+// Package platform describes the operating system Codezilla runs on: the shell that
+// runs commands and how file paths are written and compared.
+package platform
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Platform is an operating system and the conventions commands and paths follow on it
+type Platform struct {
+	OS   string `json:"os"`   // As in runtime.GOOS: linux, darwin, windows, ...
+	Arch string `json:"arch"` // As in runtime.GOARCH
+	// Shell runs commands, with ShellArgs before the command: sh -c on Unix,
+	// PowerShell (or cmd.exe when it is missing) on Windows
+	Shell     string   `json:"shell"`
+	ShellArgs []string `json:"-"`
+	// PathSeparator separates directories in paths, ListSeparator paths in PATH
+	PathSeparator string `json:"path_separator"`
+	ListSeparator string `json:"list_separator"`
+	// CaseInsensitive is set where file names differ in case only in name, as on
+	// Windows and, by default, macOS
+	CaseInsensitive bool   `json:"case_insensitive"`
+	LineEnding      string `json:"line_ending"`
+	HomeDir         string `json:"home_dir,omitempty"`
+	TempDir         string `json:"temp_dir"`
+}
+
+var (
+	current     Platform
+	currentOnce sync.Once
+)
+
+// Current returns the platform Codezilla runs on
+func Current() Platform {
+	currentOnce.Do(func() {
+		current = Detect(runtime.GOOS, runtime.GOARCH, exec.LookPath)
+		current.HomeDir, _ = os.UserHomeDir()
+		current.TempDir = os.TempDir()
+	})
+	return current
+}
+
+// Detect describes the platform goos, picking the shell among those lookPath finds
+func Detect(goos, arch string, lookPath func(string) (string, error)) Platform {
+	p := Platform{
+		OS:            goos,
+		Arch:          arch,
+		Shell:         "sh",
+		ShellArgs:     []string{"-c"},
+		PathSeparator: "/",
+		ListSeparator: ":",
+		LineEnding:    "\n",
+	}
+	switch goos {
+	case "windows":
+		p.PathSeparator, p.ListSeparator, p.LineEnding = `\`, ";", "\r\n"
+		p.CaseInsensitive = true
+		p.Shell, p.ShellArgs = "cmd.exe", []string{"/C"}
+		for _, shell := range []string{"pwsh", "powershell"} {
+			if _, err := lookPath(shell); err == nil {
+				p.Shell, p.ShellArgs = shell, []string{"-NoProfile", "-NonInteractive", "-Command"}
+				break
+			}
+		}
+	case "darwin", "ios":
+		p.CaseInsensitive = true
+	}
+	return p
+}
+
+// Command returns the command that runs a command line in the platform's shell
+func (p Platform) Command(ctx context.Context, command string) *exec.Cmd {
+	args := append(append([]string{}, p.ShellArgs...), command)
+	return exec.CommandContext(ctx, p.Shell, args...)
+}
+
+// SamePath reports whether two paths name the same file, ignoring case where file
+// names are case-insensitive
+func (p Platform) SamePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if p.CaseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// Within reports whether path is dir or inside it, comparing as SamePath does
+func (p Platform) Within(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	if p.CaseInsensitive {
+		path, dir = strings.ToLower(path), strings.ToLower(dir)
+	}
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+// Guidance tells the model how to write commands and paths for the platform
+func (p Platform) Guidance() string {
+	var b strings.Builder
+	b.WriteString("Commands run on " + p.Name() + " in " + p.Shell)
+	switch {
+	case p.OS != "windows":
+	case p.Shell == "cmd.exe":
+		b.WriteString(": use cmd syntax, such as dir, type, del and set, not Unix commands")
+	default:
+		b.WriteString(": use PowerShell syntax, such as Get-ChildItem, Get-Content, Remove-Item and $env:NAME, not Unix commands")
+	}
+	b.WriteString(".")
+	if p.CaseInsensitive {
+		b.WriteString(" File names are case-insensitive.")
+	}
+	return b.String()
+}
+
+// Name returns the platform's name for people, such as "macOS (arm64)"
+func (p Platform) Name() string {
+	name := p.OS
+	switch p.OS {
+	case "linux":
+		name = "Linux"
+	case "darwin":
+		name = "macOS"
+	case "windows":
+		name = "Windows"
+	}
+	return name + " (" + p.Arch + ")"
+}
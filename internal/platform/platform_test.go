@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	found := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+	tests := []struct {
+		name            string
+		goos            string
+		lookPath        func(string) (string, error)
+		shell           string
+		separator       string
+		caseInsensitive bool
+	}{
+		{"linux", "linux", found(), "sh", "/", false},
+		{"macOS", "darwin", found(), "sh", "/", true},
+		{"windows with pwsh", "windows", found("pwsh", "powershell"), "pwsh", `\`, true},
+		{"windows with powershell", "windows", found("powershell"), "powershell", `\`, true},
+		{"windows without powershell", "windows", found(), "cmd.exe", `\`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Detect(tt.goos, "amd64", tt.lookPath)
+			if p.Shell != tt.shell || p.PathSeparator != tt.separator || p.CaseInsensitive != tt.caseInsensitive {
+				t.Errorf("Detect(%s) = shell %s, separator %s, case-insensitive %v; want %s, %s, %v",
+					tt.goos, p.Shell, p.PathSeparator, p.CaseInsensitive, tt.shell, tt.separator, tt.caseInsensitive)
+			}
+			if len(p.ShellArgs) == 0 {
+				t.Error("no arguments to pass the command to the shell")
+			}
+		})
+	}
+}
+
+func TestWithin(t *testing.T) {
+	dir := filepath.FromSlash("/home/user/project")
+	sensitive := Platform{}
+	insensitive := Platform{CaseInsensitive: true}
+	tests := []struct {
+		path     string
+		platform Platform
+		want     bool
+	}{
+		{"/home/user/project", sensitive, true},
+		{"/home/user/project/main.go", sensitive, true},
+		{"/home/user/project2/main.go", sensitive, false},
+		{"/home/user/Project/main.go", sensitive, false},
+		{"/home/user/Project/main.go", insensitive, true},
+		{"/home/user", insensitive, false},
+	}
+	for _, tt := range tests {
+		if got := tt.platform.Within(filepath.FromSlash(tt.path), dir); got != tt.want {
+			t.Errorf("Within(%s) with case-insensitive %v = %v, want %v", tt.path, tt.platform.CaseInsensitive, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codezilla/internal/platform"
+)
+
+const (
+	// buildTimeout bounds one build check
+	buildTimeout = 5 * time.Minute
+	// maxBuildOutput is how much of a failed build's output is kept, from its end
+	maxBuildOutput = 4000
+)
+
+// DetectBuildCommand returns a command that checks the project at root compiles, or ""
+// when none is known. A "build" task in the Makefile, Taskfile, package.json or
+// justfile wins; otherwise the language's own tooling is used: go build and go vet,
+// cargo check, tsc, or compiling the Python sources.
+func DetectBuildCommand(root string) string {
+	for _, task := range DiscoverTasks(root) {
+		if task.Name == "build" {
+			return task.Command
+		}
+	}
+	switch {
+	case fileExists(filepath.Join(root, "go.mod")):
+		return "go build ./... && go vet ./..."
+	case fileExists(filepath.Join(root, "Cargo.toml")):
+		return "cargo check --quiet"
+	case fileExists(filepath.Join(root, "tsconfig.json")):
+		return nodeExec(root) + " tsc --noEmit"
+	case fileExists(filepath.Join(root, "pyproject.toml")), fileExists(filepath.Join(root, "setup.py")), fileExists(filepath.Join(root, "requirements.txt")):
+		return "python3 -m compileall -q -x '(^|/)(\\.|venv|node_modules|__pycache__)' ."
+	}
+	return ""
+}
+
+// nodeExec returns the command running a package binary with the project's package manager
+func nodeExec(root string) string {
+	switch nodePackageManager(root) {
+	case "pnpm":
+		return "pnpm exec"
+	case "yarn":
+		return "yarn"
+	case "bun":
+		return "bunx"
+	}
+	return "npx --no-install"
+}
+
+// RunBuild runs a build command through the shell at root. When the build fails it
+// returns the end of its output, where compilers put the errors that matter, with the
+// error.
+func RunBuild(ctx context.Context, root, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+	cmd := platform.Current().Command(ctx, command)
+	cmd.Dir = root
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return "", nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", buildTimeout)
+	}
+	output := strings.TrimSpace(string(out))
+	if len(output) > maxBuildOutput {
+		output = "..." + output[len(output)-maxBuildOutput:]
+		if i := strings.Index(output, "\n"); i >= 0 {
+			output = "..." + output[i:]
+		}
+	}
+	if output == "" {
+		output = err.Error()
+	}
+	return output, err
+}
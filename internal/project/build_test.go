@@ -0,0 +1,46 @@
+package project
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectBuildCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"go", map[string]string{"go.mod": "module m\n"}, "go build ./... && go vet ./..."},
+		{"make build wins", map[string]string{"go.mod": "module m\n", "Makefile": "build:\n\tgo build -o bin/app .\n"}, "make build"},
+		{"npm script", map[string]string{"package.json": `{"scripts": {"build": "tsc"}}`, "yarn.lock": ""}, "yarn run build"},
+		{"typescript", map[string]string{"tsconfig.json": "{}", "pnpm-lock.yaml": ""}, "pnpm exec tsc --noEmit"},
+		{"rust", map[string]string{"Cargo.toml": "[package]\n"}, "cargo check --quiet"},
+		{"unknown", map[string]string{"README.md": "# hi\n"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+			if got := DetectBuildCommand(root); got != tt.want {
+				t.Errorf("DetectBuildCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBuild(t *testing.T) {
+	root := t.TempDir()
+	if output, err := RunBuild(context.Background(), root, "true"); err != nil || output != "" {
+		t.Errorf("passing build = %q, %v", output, err)
+	}
+	output, err := RunBuild(context.Background(), root, "echo compiling; echo 'main.go:3: undefined: foo' >&2; exit 2")
+	if err == nil || output != "compiling\nmain.go:3: undefined: foo" {
+		t.Errorf("failing build = %q, %v", output, err)
+	}
+	output, _ = RunBuild(context.Background(), root, "seq 1 5000; exit 1")
+	if !strings.HasPrefix(output, "...\n") || !strings.HasSuffix(output, "\n5000") || len(output) > maxBuildOutput+4 {
+		t.Errorf("long output kept %d bytes, starting %q", len(output), output[:10])
+	}
+}
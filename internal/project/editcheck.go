@@ -0,0 +1,362 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Edit check modes: what runs on files after the assistant writes them
+const (
+	EditChecksOff    = "off"
+	EditChecksFormat = "format" // Run the language's formatter
+	EditChecksLint   = "lint"   // Run the formatter, then a quick lint
+)
+
+const (
+	// editCheckTimeout bounds each formatter and linter run
+	editCheckTimeout = 30 * time.Second
+	// maxEditProblems caps the problems reported per file
+	maxEditProblems = 20
+)
+
+// prettierConfigs mark a project formatted with prettier
+var prettierConfigs = []string{".prettierrc", ".prettierrc.json", ".prettierrc.yaml", ".prettierrc.yml", ".prettierrc.js", ".prettierrc.cjs", ".prettierrc.mjs", "prettier.config.js", "prettier.config.cjs", "prettier.config.mjs"}
+
+// eslintConfigs mark a project linted with eslint
+var eslintConfigs = []string{"eslint.config.js", "eslint.config.mjs", "eslint.config.cjs", "eslint.config.ts", ".eslintrc", ".eslintrc.js", ".eslintrc.cjs", ".eslintrc.json", ".eslintrc.yaml", ".eslintrc.yml"}
+
+// EditCheck is what the formatter and linter made of a written file
+type EditCheck struct {
+	File      string
+	Formatter string // Formatter run on the file, "" when none applies
+	Formatted bool   // The formatter changed the file
+	Linter    string
+	Problems  []string // Errors reported by the formatter or linter
+}
+
+// CheckEdits formats the files the assistant wrote with the project's formatter and,
+// in lint mode, runs a quick lint on them. Only tools the project uses are run:
+// gofmt (goimports when installed) and go vet for Go; ruff or black and ruff or
+// py_compile for Python; and prettier and eslint for JavaScript and TypeScript when
+// the project has them installed and configured. Files of other languages are
+// skipped. Relative paths are taken from root, and go vet's findings are limited to
+// the lines git shows as changed in each file.
+func CheckEdits(ctx context.Context, root string, files []string, mode string) []EditCheck {
+	if mode != EditChecksFormat && mode != EditChecksLint {
+		return nil
+	}
+	var checks []EditCheck
+	vetted := make(map[string]vetRun)
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(root, file)
+		}
+		file = filepath.Clean(file)
+		check := EditCheck{File: file}
+		if args := formatCommand(root, file); args != nil {
+			check.Formatter = filepath.Base(args[0])
+			before, _ := os.ReadFile(file)
+			if out, err := runEditCheck(ctx, filepath.Dir(file), args); err != nil {
+				check.Problems = append(check.Problems, problemLines(out, err)...)
+			}
+			after, _ := os.ReadFile(file)
+			check.Formatted = !bytes.Equal(before, after)
+		}
+		// A file the formatter cannot parse has nothing more to learn from a linter
+		if mode == EditChecksLint && len(check.Problems) == 0 {
+			args := lintCommand(root, file)
+			if args != nil {
+				check.Linter = strings.Join(append([]string{filepath.Base(args[0])}, args[1:len(args)-1]...), " ")
+			}
+			switch {
+			case args == nil:
+			case args[0] == "go":
+				// go vet checks the whole package, so it runs once per directory and
+				// each file gets the findings on its changed lines
+				dir := filepath.Dir(file)
+				run, ok := vetted[dir]
+				if !ok {
+					run.out, run.err = runEditCheck(ctx, dir, args)
+					vetted[dir] = run
+				}
+				if run.err != nil {
+					if out := vetFindings(run.out, dir, file, changedLines(ctx, file)); out != "" {
+						check.Problems = append(check.Problems, problemLines(out, run.err)...)
+					}
+				}
+			default:
+				if out, err := runEditCheck(ctx, filepath.Dir(file), args); err != nil {
+					check.Problems = append(check.Problems, problemLines(out, err)...)
+				}
+			}
+		}
+		if check.Formatter != "" || check.Linter != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// vetRun is the output of go vet on one directory
+type vetRun struct {
+	out string
+	err error
+}
+
+// vetPosition matches the file and line go vet reports a finding at
+var vetPosition = regexp.MustCompile(`^(?:vet: )?([^\s:][^:]*\.go):(\d+)(?::\d+)?: `)
+
+// vetFindings keeps the findings in go vet's output that are in file and, when lines
+// is not nil, on one of those lines. Lines continuing a finding follow it. Output
+// without any positions, such as a module error, is kept whole.
+func vetFindings(output, dir, file string, lines [][2]int) string {
+	var kept []string
+	positioned, keep := false, false
+	for _, line := range strings.Split(output, "\n") {
+		if m := vetPosition.FindStringSubmatch(line); m != nil {
+			positioned = true
+			path := m[1]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			n, _ := strconv.Atoi(m[2])
+			keep = filepath.Clean(path) == file && (lines == nil || inRanges(n, lines))
+		} else if strings.HasPrefix(line, "# ") {
+			keep = false
+		}
+		if keep {
+			kept = append(kept, line)
+		}
+	}
+	if !positioned {
+		return output
+	}
+	return strings.Join(kept, "\n")
+}
+
+// inRanges reports whether line is in one of ranges
+func inRanges(line int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if line >= r[0] && line <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// hunkHeader matches a unified diff hunk header, capturing the new-file start and
+// line count
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// changedLines returns the lines of file that differ from git's HEAD, or nil when
+// all of it counts as changed: outside a repository, or for a file git doesn't track
+func changedLines(ctx context.Context, file string) [][2]int {
+	dir := filepath.Dir(file)
+	if _, err := runEditCheck(ctx, dir, []string{"git", "ls-files", "--error-unmatch", "--", filepath.Base(file)}); err != nil {
+		return nil
+	}
+	diff, err := runEditCheck(ctx, dir, []string{"git", "diff", "--no-color", "--no-ext-diff", "--unified=0", "HEAD", "--", filepath.Base(file)})
+	if err != nil {
+		return nil
+	}
+	ranges := [][2]int{}
+	for _, line := range strings.Split(diff, "\n") {
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		// A deletion is marked by the lines around it
+		if count == 0 {
+			ranges = append(ranges, [2]int{start, start + 1})
+			continue
+		}
+		ranges = append(ranges, [2]int{start, start + count - 1})
+	}
+	return ranges
+}
+
+// formatCommand returns the command formatting file in place, or nil when the
+// project has no formatter for it
+func formatCommand(root, file string) []string {
+	switch filepath.Ext(file) {
+	case ".go":
+		if _, err := exec.LookPath("goimports"); err == nil {
+			return []string{"goimports", "-w", file}
+		}
+		if _, err := exec.LookPath("gofmt"); err == nil {
+			return []string{"gofmt", "-w", file}
+		}
+	case ".py":
+		if usesRuff(root) {
+			return []string{"ruff", "format", "--quiet", file}
+		}
+		if usesBlack(root) {
+			return []string{"black", "--quiet", file}
+		}
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".css", ".scss", ".vue":
+		prettier := filepath.Join(root, "node_modules", ".bin", "prettier")
+		if fileExists(prettier) && usesPrettier(root) {
+			return []string{prettier, "--write", "--log-level", "warn", file}
+		}
+	}
+	return nil
+}
+
+// lintCommand returns a quick lint of file, with the file as its last argument, or
+// nil when the project has no linter for it
+func lintCommand(root, file string) []string {
+	switch filepath.Ext(file) {
+	case ".go":
+		if _, err := exec.LookPath("go"); err == nil && inGoModule(filepath.Dir(file)) {
+			return []string{"go", "vet", "."}
+		}
+	case ".py":
+		if _, err := exec.LookPath("ruff"); err == nil {
+			return []string{"ruff", "check", "--quiet", file}
+		}
+		for _, python := range []string{"python3", "python"} {
+			if _, err := exec.LookPath(python); err == nil {
+				return []string{python, "-m", "py_compile", file}
+			}
+		}
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".vue":
+		eslint := filepath.Join(root, "node_modules", ".bin", "eslint")
+		if fileExists(eslint) && hasAny(root, eslintConfigs) {
+			return []string{eslint, file}
+		}
+	}
+	return nil
+}
+
+// inGoModule reports whether dir is inside a Go module, which go vet needs
+func inGoModule(dir string) bool {
+	for {
+		if fileExists(filepath.Join(dir, "go.mod")) {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// usesRuff reports whether ruff is installed and configured for the project
+func usesRuff(root string) bool {
+	if _, err := exec.LookPath("ruff"); err != nil {
+		return false
+	}
+	if hasAny(root, []string{"ruff.toml", ".ruff.toml"}) {
+		return true
+	}
+	pyproject, _ := os.ReadFile(filepath.Join(root, "pyproject.toml"))
+	return strings.Contains(string(pyproject), "[tool.ruff")
+}
+
+// usesBlack reports whether black is installed and configured for the project
+func usesBlack(root string) bool {
+	if _, err := exec.LookPath("black"); err != nil {
+		return false
+	}
+	pyproject, _ := os.ReadFile(filepath.Join(root, "pyproject.toml"))
+	return strings.Contains(string(pyproject), "[tool.black]")
+}
+
+// usesPrettier reports whether the project configures prettier
+func usesPrettier(root string) bool {
+	if hasAny(root, prettierConfigs) {
+		return true
+	}
+	pkg, _ := os.ReadFile(filepath.Join(root, "package.json"))
+	return bytes.Contains(pkg, []byte(`"prettier"`))
+}
+
+// hasAny reports whether any of names exists in dir
+func hasAny(dir string, names []string) bool {
+	for _, name := range names {
+		if fileExists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// runEditCheck runs a formatter or linter in dir and returns its combined output
+func runEditCheck(ctx context.Context, dir string, args []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, editCheckTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", editCheckTimeout)
+	}
+	return string(out), err
+}
+
+// problemLines returns the lines of a failed check worth showing, leaving out go
+// vet's package headers
+func problemLines(output string, err error) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		if len(lines) == maxEditProblems {
+			lines = append(lines, "...")
+			break
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, err.Error())
+	}
+	return lines
+}
+
+// FormatEditChecks describes the checks for the model: which files were
+// reformatted and the problems it should fix before finishing. It returns "" when
+// there is nothing to report.
+func FormatEditChecks(root string, checks []EditCheck) string {
+	var formatted []string
+	var problems strings.Builder
+	for _, c := range checks {
+		name := c.File
+		if rel, err := filepath.Rel(root, c.File); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		if c.Formatted {
+			formatted = append(formatted, fmt.Sprintf("%s (%s)", name, c.Formatter))
+		}
+		if len(c.Problems) > 0 {
+			tool := c.Linter
+			if tool == "" {
+				tool = c.Formatter
+			}
+			fmt.Fprintf(&problems, "%s (%s):\n  %s\n", name, tool, strings.Join(c.Problems, "\n  "))
+		}
+	}
+	var b strings.Builder
+	if len(formatted) > 0 {
+		fmt.Fprintf(&b, "Reformatted after writing: %s. Read the file again before editing it further.\n", strings.Join(formatted, ", "))
+	}
+	if problems.Len() > 0 {
+		fmt.Fprintf(&b, "Problems found after writing; fix them before finishing:\n%s", problems.String())
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,104 @@
+package project
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckEdits(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":    "module example.com/m\n\ngo 1.21\n",
+		"main.go":   "package main\nimport \"fmt\"\nfunc main() {\nfmt.Println(\"hi\")\n}\n",
+		"printf.go": "package main\n\nimport \"fmt\"\n\nfunc greet() { fmt.Printf(\"%d\\n\", \"x\") }\n",
+		"broken.go": "package main\n\nfunc broken( {\n",
+		"notes.txt": "not code\n",
+	})
+	ctx := context.Background()
+	files := []string{filepath.Join(root, "main.go"), filepath.Join(root, "notes.txt")}
+
+	checks := CheckEdits(ctx, root, files, EditChecksFormat)
+	if len(checks) != 1 || !checks[0].Formatted || checks[0].Linter != "" || len(checks[0].Problems) != 0 {
+		t.Fatalf("format checks = %+v, want main.go reformatted only", checks)
+	}
+	if data, _ := os.ReadFile(files[0]); !strings.Contains(string(data), "\tfmt.Println") {
+		t.Errorf("main.go was not formatted:\n%s", data)
+	}
+
+	checks = CheckEdits(ctx, root, []string{filepath.Join(root, "broken.go")}, EditChecksLint)
+	if len(checks) != 1 || len(checks[0].Problems) == 0 || checks[0].Linter != "" {
+		t.Fatalf("broken file checks = %+v, want a formatter error and no lint", checks)
+	}
+
+	os.Remove(filepath.Join(root, "broken.go"))
+	checks = CheckEdits(ctx, root, []string{filepath.Join(root, "printf.go"), filepath.Join(root, "main.go")}, EditChecksLint)
+	if len(checks) != 2 || checks[0].Linter != "go vet" || len(checks[0].Problems) == 0 || len(checks[1].Problems) != 0 {
+		t.Fatalf("lint checks = %+v, want the go vet finding on printf.go only", checks)
+	}
+	report := FormatEditChecks(root, checks)
+	for _, want := range []string{"Problems found after writing", "printf.go (go vet):", "Printf format %d"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+
+	checks = CheckEdits(ctx, root, []string{"printf.go"}, EditChecksLint)
+	if len(checks) != 1 || checks[0].File != filepath.Join(root, "printf.go") || len(checks[0].Problems) == 0 {
+		t.Errorf("relative path checks = %+v, want printf.go under root checked", checks)
+	}
+
+	if checks := CheckEdits(ctx, root, files, EditChecksOff); checks != nil {
+		t.Errorf("off mode ran checks: %+v", checks)
+	}
+	if report := FormatEditChecks(root, nil); report != "" {
+		t.Errorf("FormatEditChecks(nil) = %q, want empty", report)
+	}
+}
+
+func TestCheckEditsChangedLines(t *testing.T) {
+	for _, program := range []string{"go", "git"} {
+		if _, err := exec.LookPath(program); err != nil {
+			t.Skipf("%s is not installed", program)
+		}
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":    "module example.com/m\n\ngo 1.21\n",
+		"printf.go": "package main\n\nimport \"fmt\"\n\nfunc greet() { fmt.Printf(\"%d\\n\", \"x\") }\n",
+	})
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	ctx := context.Background()
+	file := filepath.Join(root, "printf.go")
+
+	// The finding was committed, so an edit elsewhere in the file doesn't report it
+	writeFiles(t, root, map[string]string{
+		"printf.go": "package main\n\nimport \"fmt\"\n\nfunc greet() { fmt.Printf(\"%d\\n\", \"x\") }\n\nfunc main() { greet() }\n",
+	})
+	if checks := CheckEdits(ctx, root, []string{file}, EditChecksLint); len(checks) != 1 || len(checks[0].Problems) != 0 {
+		t.Fatalf("checks = %+v, want no findings outside the changed lines", checks)
+	}
+
+	writeFiles(t, root, map[string]string{
+		"printf.go": "package main\n\nimport \"fmt\"\n\nfunc greet() { fmt.Printf(\"%d\\n\", \"y\") }\n",
+	})
+	if checks := CheckEdits(ctx, root, []string{file}, EditChecksLint); len(checks) != 1 || len(checks[0].Problems) == 0 {
+		t.Fatalf("checks = %+v, want the finding on the changed line", checks)
+	}
+}
@@ -0,0 +1,236 @@
+// Package project inspects the working directory to tailor the assistant to it.
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Language is a programming language found in the project
+type Language struct {
+	Name  string
+	Files int
+	Share float64 // Fraction of recognized source files
+}
+
+// extensionLanguages maps file extensions to language names
+var extensionLanguages = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".rs":    "Rust",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".swift": "Swift",
+}
+
+// markerLanguages maps manifest files to the language they imply
+var markerLanguages = map[string]string{
+	"go.mod":           "Go",
+	"pyproject.toml":   "Python",
+	"requirements.txt": "Python",
+	"setup.py":         "Python",
+	"package.json":     "JavaScript",
+	"tsconfig.json":    "TypeScript",
+	"Cargo.toml":       "Rust",
+}
+
+// skippedDirs are never scanned
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+const (
+	// maxScannedFiles bounds the scan on very large trees
+	maxScannedFiles = 5000
+	// primaryShare is the minimum share for a language to count as primary
+	primaryShare = 0.15
+	// maxPrimary is the most languages reported as primary
+	maxPrimary = 3
+)
+
+// DetectLanguages returns the project's primary languages, most used first.
+// A language counts when it makes up a meaningful share of source files or
+// has a manifest (go.mod, package.json, ...) at the project root.
+func DetectLanguages(root string) []Language {
+	counts := make(map[string]int)
+	total, scanned := 0, 0
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || skippedDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if scanned++; scanned > maxScannedFiles {
+			return filepath.SkipAll
+		}
+		if lang, ok := extensionLanguages[strings.ToLower(filepath.Ext(name))]; ok {
+			counts[lang]++
+			total++
+		}
+		return nil
+	})
+
+	var langs []Language
+	for name, files := range counts {
+		share := float64(files) / float64(total)
+		langs = append(langs, Language{Name: name, Files: files, Share: share})
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if langs[i].Files != langs[j].Files {
+			return langs[i].Files > langs[j].Files
+		}
+		return langs[i].Name < langs[j].Name
+	})
+
+	markers := make(map[string]bool)
+	for file, lang := range markerLanguages {
+		if _, err := os.Stat(filepath.Join(root, file)); err == nil {
+			markers[lang] = true
+		}
+	}
+
+	var primary []Language
+	for _, lang := range langs {
+		if len(primary) < maxPrimary && (lang.Share >= primaryShare || markers[lang.Name]) {
+			primary = append(primary, lang)
+		}
+	}
+	return primary
+}
+
+// Guidance returns ecosystem-specific instructions for the detected languages,
+// or "" when none of them has guidance
+func Guidance(root string, langs []Language) string {
+	var blocks []string
+	for _, lang := range langs {
+		if block := languageGuidance(root, lang.Name); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	var names []string
+	for _, lang := range langs {
+		names = append(names, lang.Name)
+	}
+	return fmt.Sprintf("## Project Conventions\nThis project is primarily written in %s. Follow the conventions of its ecosystem:\n\n%s",
+		strings.Join(names, ", "), strings.Join(blocks, "\n\n"))
+}
+
+// languageGuidance returns the guidance block for a single language
+func languageGuidance(root, lang string) string {
+	switch lang {
+	case "Go":
+		return "### Go\n" +
+			"- Format code with gofmt and keep imports grouped (standard library first).\n" +
+			"- Run tests with `go test ./...` and check with `go vet ./...`.\n" +
+			"- Return errors rather than panicking and wrap them with `fmt.Errorf(\"...: %w\", err)`.\n" +
+			"- Put tests in `_test.go` files next to the code they test."
+	case "Python":
+		return pythonGuidance(root)
+	case "JavaScript", "TypeScript":
+		return nodeGuidance(root, lang)
+	case "Rust":
+		return "### Rust\n" +
+			"- Use `cargo build`, `cargo test` and `cargo clippy`; format with `cargo fmt`.\n" +
+			"- Prefer `Result` and the `?` operator over `unwrap()` outside tests."
+	}
+	return ""
+}
+
+// pythonGuidance describes the Python toolchain in use (poetry, uv or pip)
+func pythonGuidance(root string) string {
+	var b strings.Builder
+	b.WriteString("### Python\n")
+
+	pyproject, _ := os.ReadFile(filepath.Join(root, "pyproject.toml"))
+	switch {
+	case strings.Contains(string(pyproject), "[tool.poetry"):
+		b.WriteString("- Dependencies are managed with Poetry: use `poetry add` and run commands with `poetry run` (e.g. `poetry run pytest`).\n")
+	case fileExists(filepath.Join(root, "uv.lock")):
+		b.WriteString("- Dependencies are managed with uv: use `uv add` and run commands with `uv run` (e.g. `uv run pytest`).\n")
+	case fileExists(filepath.Join(root, "requirements.txt")):
+		b.WriteString("- Dependencies are listed in requirements.txt: install with `pip install -r requirements.txt` inside a virtual environment.\n")
+	}
+	b.WriteString("- Follow PEP 8, add type hints to new functions, and write tests with pytest.")
+	return b.String()
+}
+
+// nodeGuidance lists the package manager and npm scripts of a JavaScript/TypeScript project
+func nodeGuidance(root, lang string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n", lang)
+
+	manager := nodePackageManager(root)
+	fmt.Fprintf(&b, "- Use %s for dependencies and scripts; don't mix package managers.\n", manager)
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil && json.Unmarshal(data, &pkg) == nil && len(pkg.Scripts) > 0 {
+		names := make([]string, 0, len(pkg.Scripts))
+		for name := range pkg.Scripts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "- Prefer the project's scripts (`%s run <script>`): %s.\n", manager, strings.Join(names, ", "))
+	}
+	if lang == "TypeScript" {
+		b.WriteString("- Keep code type-safe: avoid `any` and make sure `tsc` passes.")
+	} else {
+		b.WriteString("- Match the existing module style (ES modules or CommonJS).")
+	}
+	return b.String()
+}
+
+// nodePackageManager returns the package manager whose lockfile is at root, or npm
+func nodePackageManager(root string) string {
+	switch {
+	case fileExists(filepath.Join(root, "pnpm-lock.yaml")):
+		return "pnpm"
+	case fileExists(filepath.Join(root, "yarn.lock")):
+		return "yarn"
+	case fileExists(filepath.Join(root, "bun.lockb")):
+		return "bun"
+	}
+	return "npm"
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+func TestDetectLanguages(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                   "module example\n",
+		"main.go":                  "package main\n",
+		"internal/a.go":            "package internal\n",
+		"internal/b.go":            "package internal\n",
+		"internal/c.go":            "package internal\n",
+		"web/app.js":               "",
+		"node_modules/dep/x.js":    "",
+		"node_modules/dep/y.js":    "",
+		".git/hooks/pre-commit.py": "",
+		"README.md":                "",
+	})
+
+	langs := DetectLanguages(root)
+	if len(langs) != 2 || langs[0].Name != "Go" || langs[0].Files != 4 || langs[1].Name != "JavaScript" {
+		t.Fatalf("DetectLanguages() = %+v, want Go (4 files) then JavaScript", langs)
+	}
+}
+
+func TestDetectLanguagesMinorLanguageNeedsManifest(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"tools/gen.py": ""}
+	for i := 0; i < 10; i++ {
+		files[filepath.Join("src", string(rune('a'+i))+".rs")] = ""
+	}
+	writeFiles(t, root, files)
+
+	langs := DetectLanguages(root)
+	if len(langs) != 1 || langs[0].Name != "Rust" {
+		t.Fatalf("DetectLanguages() = %+v, want only Rust", langs)
+	}
+}
+
+func TestGuidance(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json":   `{"scripts": {"test": "jest", "lint": "eslint ."}}`,
+		"yarn.lock":      "",
+		"pyproject.toml": "[tool.poetry]\nname = \"x\"\n",
+	})
+
+	got := Guidance(root, []Language{{Name: "JavaScript"}, {Name: "Python"}, {Name: "Haskell"}})
+	for _, want := range []string{"JavaScript, Python, Haskell", "Use yarn", "lint, test", "poetry run"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Guidance() missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := Guidance(root, []Language{{Name: "Haskell"}}); got != "" {
+		t.Errorf("Guidance() for a language without guidance = %q, want empty", got)
+	}
+}
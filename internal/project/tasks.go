@@ -0,0 +1,231 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxGuidanceTasks caps the tasks named in the system prompt
+const maxGuidanceTasks = 15
+
+// Task is a build, test or other project command defined in a task runner file
+type Task struct {
+	Name        string `json:"name"`
+	Command     string `json:"command"` // How to run it, e.g. "make test" or "npm run lint"
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source"` // The file defining it
+}
+
+var (
+	// makeTarget matches "target: deps ## description"; ":=" and "::=" are assignments
+	makeTarget = regexp.MustCompile(`^([A-Za-z0-9_./-]+(?:\s+[A-Za-z0-9_./-]+)*)\s*::?(?:[^=:]|$)(.*)`)
+	// justRecipe matches "recipe param='default': deps"; ":=" is an assignment
+	justRecipe = regexp.MustCompile(`^@?([A-Za-z0-9_-]+)((?:\s+[^\s:]+)*)\s*:(?:[^=]|$)`)
+)
+
+// DiscoverTasks lists the tasks defined at the project root by a Makefile, Taskfile,
+// package.json scripts and a justfile, in that order
+func DiscoverTasks(root string) []Task {
+	var tasks []Task
+	for _, name := range []string{"GNUmakefile", "makefile", "Makefile"} {
+		if fileExists(filepath.Join(root, name)) {
+			tasks = append(tasks, makeTasks(filepath.Join(root, name), name)...)
+			break
+		}
+	}
+	for _, name := range []string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml"} {
+		if fileExists(filepath.Join(root, name)) {
+			tasks = append(tasks, taskfileTasks(filepath.Join(root, name), name)...)
+			break
+		}
+	}
+	tasks = append(tasks, npmTasks(root)...)
+	for _, name := range []string{"justfile", "Justfile", ".justfile"} {
+		if fileExists(filepath.Join(root, name)) {
+			tasks = append(tasks, justTasks(filepath.Join(root, name), name)...)
+			break
+		}
+	}
+	return tasks
+}
+
+// commentText returns the text of a "#" comment line, or "" for other lines
+func commentText(line string) string {
+	if !strings.HasPrefix(line, "#") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimLeft(line, "#"))
+}
+
+// makeTasks reads the explicit targets of a Makefile. A target's description is its
+// trailing "## text" or the comment right above it.
+func makeTasks(path, source string) []Task {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var tasks []Task
+	seen := make(map[string]bool)
+	comment := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if text := commentText(line); text != "" {
+			comment = text
+			continue
+		}
+		m := makeTarget.FindStringSubmatch(line)
+		if m == nil {
+			comment = ""
+			continue
+		}
+		description := comment
+		if _, trailing, ok := strings.Cut(m[2], "##"); ok {
+			description = strings.TrimSpace(trailing)
+		}
+		comment = ""
+		for _, target := range strings.Fields(m[1]) {
+			// Skip special targets (.PHONY), pattern rules and file targets
+			if strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%/") || seen[target] {
+				continue
+			}
+			seen[target] = true
+			tasks = append(tasks, Task{Name: target, Command: "make " + target, Description: description, Source: source})
+		}
+	}
+	return tasks
+}
+
+// taskfileTasks reads the tasks of a go-task Taskfile, skipping internal ones
+func taskfileTasks(path, source string) []Task {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var file struct {
+		Tasks map[string]struct {
+			Desc     string `yaml:"desc"`
+			Summary  string `yaml:"summary"`
+			Internal bool   `yaml:"internal"`
+		} `yaml:"tasks"`
+	}
+	if yaml.Unmarshal(data, &file) != nil {
+		return nil
+	}
+
+	var tasks []Task
+	for _, name := range sortedTaskNames(file.Tasks) {
+		t := file.Tasks[name]
+		if t.Internal {
+			continue
+		}
+		description := t.Desc
+		if description == "" {
+			description, _, _ = strings.Cut(strings.TrimSpace(t.Summary), "\n")
+		}
+		tasks = append(tasks, Task{Name: name, Command: "task " + name, Description: description, Source: source})
+	}
+	return tasks
+}
+
+// npmTasks lists package.json scripts; the script's own command describes it
+func npmTasks(root string) []Task {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	manager := nodePackageManager(root)
+	var tasks []Task
+	for _, name := range sortedTaskNames(pkg.Scripts) {
+		tasks = append(tasks, Task{Name: name, Command: manager + " run " + name, Description: pkg.Scripts[name], Source: "package.json"})
+	}
+	return tasks
+}
+
+// justTasks reads the public recipes of a justfile with the comment above each
+func justTasks(path, source string) []Task {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var tasks []Task
+	comment, private := "", false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if text := commentText(line); text != "" {
+			comment = text
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			// Attributes such as [private] apply to the next recipe
+			private = private || strings.Contains(line, "private")
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			// Recipe body
+			continue
+		}
+		m := justRecipe.FindStringSubmatch(line)
+		if m == nil {
+			comment, private = "", false
+			continue
+		}
+		if !private && !strings.HasPrefix(m[1], "_") {
+			command := "just " + m[1]
+			if params := strings.TrimSpace(m[2]); params != "" {
+				command += " <" + strings.Join(strings.Fields(params), "> <") + ">"
+			}
+			tasks = append(tasks, Task{Name: m[1], Command: command, Description: comment, Source: source})
+		}
+		comment, private = "", false
+	}
+	return tasks
+}
+
+// sortedTaskNames returns the keys of m in order
+func sortedTaskNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TasksGuidance tells the model which project commands exist, so it runs them instead
+// of guessing build and test commands. It returns "" when there are none.
+func TasksGuidance(tasks []Task) string {
+	if len(tasks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Project Tasks\nUse the project's own commands for building, testing and linting:\n")
+	for i, t := range tasks {
+		if i == maxGuidanceTasks {
+			fmt.Fprintf(&b, "- ... and %d more (use the listTasks tool to see them all)\n", len(tasks)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- `%s`", t.Command)
+		if t.Description != "" && t.Source != "package.json" {
+			fmt.Fprintf(&b, ": %s", t.Description)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
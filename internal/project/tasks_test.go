@@ -0,0 +1,95 @@
+package project
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverTasks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Makefile": `BIN := app
+VERSION ::= 1.0
+.PHONY: build test
+
+# Build the binary
+build: deps
+	go build -o $(BIN)
+
+test: ## Run the tests
+	go test ./...
+
+%.o: %.c
+	cc -c $<
+
+bin/app: main.go
+
+lint vet:
+	golangci-lint run
+`,
+		"Taskfile.yml": `version: '3'
+tasks:
+  fmt:
+    desc: Format the code
+    cmds: [gofmt -w .]
+  release:
+    summary: |
+      Tag and publish a release
+
+      Requires a clean tree.
+  helper:
+    internal: true
+`,
+		"package.json": `{"scripts": {"dev": "vite", "test": "vitest run"}}`,
+		"yarn.lock":    "",
+		"justfile": `set dotenv-load
+alias b := build
+
+# Deploy to an environment
+deploy env='staging':
+    ./deploy.sh {{env}}
+
+[private]
+secret:
+    echo hidden
+
+_helper:
+    echo hidden
+`,
+	})
+
+	var got []string
+	for _, task := range DiscoverTasks(root) {
+		got = append(got, task.Command+" | "+task.Description+" | "+task.Source)
+	}
+	want := []string{
+		"make build | Build the binary | Makefile",
+		"make test | Run the tests | Makefile",
+		"make lint |  | Makefile",
+		"make vet |  | Makefile",
+		"task fmt | Format the code | Taskfile.yml",
+		"task release | Tag and publish a release | Taskfile.yml",
+		"yarn run dev | vite | package.json",
+		"yarn run test | vitest run | package.json",
+		"just deploy <env='staging'> | Deploy to an environment | justfile",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiscoverTasks() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTasksGuidance(t *testing.T) {
+	if got := TasksGuidance(nil); got != "" {
+		t.Errorf("TasksGuidance(nil) = %q, want empty", got)
+	}
+
+	tasks := []Task{
+		{Name: "test", Command: "make test", Description: "Run the tests", Source: "Makefile"},
+		{Name: "dev", Command: "npm run dev", Description: "vite", Source: "package.json"},
+	}
+	got := TasksGuidance(tasks)
+	if !strings.Contains(got, "- `make test`: Run the tests\n- `npm run dev`") || strings.Contains(got, "vite") {
+		t.Errorf("TasksGuidance() = %q", got)
+	}
+}
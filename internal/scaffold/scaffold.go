@@ -0,0 +1,293 @@
+// Package scaffold creates new files from templates with variable substitution.
+package scaffold
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// builtinFS holds the templates shipped with codezilla
+//
+//go:embed templates
+var builtinFS embed.FS
+
+// templateSuffix is stripped from file names, so templates of source files are not
+// compiled or linted as part of this repository
+const templateSuffix = ".tmpl"
+
+// Variable is a value a template asks for
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is used when no value is given; it may refer to earlier variables,
+	// e.g. "{{snake .name}}"
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// Template is a set of files to create, described by a template.json next to a
+// files directory. File paths and contents are Go text/templates.
+type Template struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Variables   []Variable `json:"variables,omitempty"`
+	Source      string     `json:"source"` // "built-in" or the template's directory
+	files       fs.FS
+}
+
+// Registry holds the built-in templates and those in the user's templates directory
+type Registry struct {
+	templates map[string]*Template
+}
+
+// Load reads the built-in templates and the ones in userDir, which take precedence
+// over built-ins of the same name. A missing userDir is not an error.
+func Load(userDir string) (*Registry, error) {
+	r := &Registry{templates: make(map[string]*Template)}
+	builtins, err := fs.Sub(builtinFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadDir(builtins, "built-in"); err != nil {
+		return nil, err
+	}
+	if userDir != "" {
+		if _, err := os.Stat(userDir); err == nil {
+			if err := r.loadDir(os.DirFS(userDir), userDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// loadDir reads every template directory in fsys
+func (r *Registry) loadDir(fsys fs.FS, source string) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(e.Name(), "template.json"))
+		if err != nil {
+			continue
+		}
+		t := &Template{Name: e.Name(), Source: source}
+		if err := json.Unmarshal(data, t); err != nil {
+			return fmt.Errorf("template %s: invalid template.json: %w", e.Name(), err)
+		}
+		t.Name = e.Name()
+		if source != "built-in" {
+			t.Source = filepath.Join(source, e.Name())
+		}
+		if t.files, err = fs.Sub(fsys, path.Join(e.Name(), "files")); err != nil {
+			return err
+		}
+		r.templates[t.Name] = t
+	}
+	return nil
+}
+
+// List returns the templates sorted by name
+func (r *Registry) List() []*Template {
+	list := make([]*Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns the named template
+func (r *Registry) Get(name string) (*Template, error) {
+	t, ok := r.templates[name]
+	if !ok {
+		var names []string
+		for _, t := range r.List() {
+			names = append(names, t.Name)
+		}
+		return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return t, nil
+}
+
+// Resolve completes vars with the template's defaults, failing when a required
+// variable has no value or a variable is unknown
+func (t *Template) Resolve(vars map[string]string) (map[string]string, error) {
+	known := make(map[string]bool)
+	resolved := make(map[string]string, len(t.Variables))
+	for _, v := range t.Variables {
+		known[v.Name] = true
+		value, ok := vars[v.Name]
+		if !ok || value == "" {
+			if v.Required {
+				return nil, fmt.Errorf("template %s needs a value for %q (%s)", t.Name, v.Name, v.Description)
+			}
+			var err error
+			if value, err = renderString(v.Name, v.Default, resolved); err != nil {
+				return nil, fmt.Errorf("default of %q: %w", v.Name, err)
+			}
+		}
+		resolved[v.Name] = value
+	}
+	for name := range vars {
+		if !known[name] {
+			return nil, fmt.Errorf("template %s has no variable %q", t.Name, name)
+		}
+	}
+	return resolved, nil
+}
+
+// Render resolves vars and returns the content of every file the template creates,
+// keyed by slash-separated path relative to the destination
+func (t *Template) Render(vars map[string]string) (map[string]string, error) {
+	resolved, err := t.Resolve(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	err = fs.WalkDir(t.files, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		target, err := renderString(p, strings.TrimSuffix(p, templateSuffix), resolved)
+		if err != nil {
+			return err
+		}
+		target = path.Clean(target)
+		if path.IsAbs(target) || target == ".." || strings.HasPrefix(target, "../") {
+			return fmt.Errorf("%s renders to %s, which is outside the destination", p, target)
+		}
+		data, err := fs.ReadFile(t.files, p)
+		if err != nil {
+			return err
+		}
+		content, err := renderString(p, string(data), resolved)
+		if err != nil {
+			return err
+		}
+		files[target] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	return files, nil
+}
+
+// renderString executes text as a template over vars
+func renderString(name, text string, vars map[string]string) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// funcs are available in templates to derive identifiers from a name
+var funcs = template.FuncMap{
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"snake":  func(s string) string { return strings.Join(words(s), "_") },
+	"kebab":  func(s string) string { return strings.Join(words(s), "-") },
+	"camel":  func(s string) string { return lowerFirst(pascal(s)) },
+	"pascal": pascal,
+	"title":  func(s string) string { return strings.Join(capitalize(words(s)), " ") },
+	"package": func(s string) string {
+		return strings.Join(words(s), "")
+	},
+}
+
+// words splits a name such as "userProfile", "user_profile" or "User Profile" into
+// lower-case words
+func words(s string) []string {
+	var out []string
+	var current []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(current) > 0 {
+			out = append(out, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			// A new word starts at "Profile" in "userProfile" and "Parser" in "HTTPParser"
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return out
+}
+
+func capitalize(ws []string) []string {
+	out := make([]string, len(ws))
+	for i, w := range ws {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		out[i] = string(r)
+	}
+	return out
+}
+
+func pascal(s string) string {
+	return strings.Join(capitalize(words(s)), "")
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// ParseAssignments turns "key=value" arguments into variables. Arguments without "="
+// fill the template's required variables in order.
+func ParseAssignments(t *Template, args []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	var positional []string
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			vars[key] = value
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	for _, v := range t.Variables {
+		if len(positional) == 0 {
+			break
+		}
+		if _, set := vars[v.Name]; v.Required && !set {
+			vars[v.Name], positional = positional[0], positional[1:]
+		}
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s (use name=value)", strings.Join(positional, " "))
+	}
+	return vars, nil
+}
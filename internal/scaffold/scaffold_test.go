@@ -0,0 +1,117 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"userProfile", []string{"user", "profile"}},
+		{"user_profile", []string{"user", "profile"}},
+		{"User Profile", []string{"user", "profile"}},
+		{"HTTPParser", []string{"http", "parser"}},
+		{"v2-api", []string{"v2", "api"}},
+	}
+	for _, tt := range tests {
+		if got := words(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("words(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinTemplatesRender(t *testing.T) {
+	registry, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, tmpl := range registry.List() {
+		vars := map[string]string{}
+		for _, v := range tmpl.Variables {
+			if v.Required {
+				vars[v.Name] = "userStore"
+			}
+		}
+		files, err := tmpl.Render(vars)
+		if err != nil {
+			t.Errorf("%s: Render() error = %v", tmpl.Name, err)
+			continue
+		}
+		if len(files) == 0 {
+			t.Errorf("%s: Render() created no files", tmpl.Name)
+		}
+	}
+
+	tmpl, _ := registry.Get("go-package")
+	files, err := tmpl.Render(map[string]string{"name": "userStore"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	test, ok := files["internal/userstore/userstore_test.go"]
+	if !ok || !strings.Contains(test, "package userstore") || !strings.Contains(test, "func TestUserStore(") {
+		t.Errorf("Render() = %v", files)
+	}
+}
+
+func TestUserTemplates(t *testing.T) {
+	userDir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(userDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write("handler/template.json", `{"description": "HTTP handler", "variables": [{"name": "name", "required": true}, {"name": "route", "default": "/{{kebab .name}}"}]}`)
+	write("handler/files/handlers/{{snake .name}}.go.tmpl", "// {{pascal .name}}Handler serves {{.route}}\n")
+	write("go-test/template.json", `{"description": "Overridden"}`)
+	write("escape/template.json", `{"description": "Bad"}`)
+	write("escape/files/{{\"..\"}}/x.tmpl", "x")
+
+	registry, err := Load(userDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if tmpl, _ := registry.Get("go-test"); tmpl.Description != "Overridden" || tmpl.Source != filepath.Join(userDir, "go-test") {
+		t.Errorf("user template did not override the built-in: %+v", tmpl)
+	}
+
+	tmpl, err := registry.Get("handler")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	vars, err := ParseAssignments(tmpl, []string{"userProfile"})
+	if err != nil {
+		t.Fatalf("ParseAssignments() error = %v", err)
+	}
+	dir := t.TempDir()
+	tx, err := Stage(tmpl, vars, dir, false)
+	if err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "handlers", "user_profile.go"))
+	if err != nil || string(data) != "// UserProfileHandler serves /user-profile\n" {
+		t.Errorf("created file = %q, %v", data, err)
+	}
+
+	if _, err := Stage(tmpl, vars, dir, false); err == nil || !strings.Contains(err.Error(), "already exist") {
+		t.Errorf("Stage() over existing files error = %v", err)
+	}
+	if _, err := tmpl.Render(map[string]string{}); err == nil {
+		t.Error("expected an error for a missing required variable")
+	}
+	if _, err := tmpl.Render(map[string]string{"name": "x", "nmae": "y"}); err == nil {
+		t.Error("expected an error for an unknown variable")
+	}
+	escape, _ := registry.Get("escape")
+	if _, err := escape.Render(nil); err == nil || !strings.Contains(err.Error(), "outside the destination") {
+		t.Errorf("Render() error = %v, want one about leaving the destination", err)
+	}
+}
@@ -0,0 +1,164 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// Stage renders t into dir as a transaction. Existing files are only replaced when
+// overwrite is set.
+func Stage(t *Template, vars map[string]string, dir string, overwrite bool) (*tools.EditTransaction, error) {
+	files, err := t.Render(vars)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	tx := tools.NewEditTransaction()
+	var existing []string
+	for _, p := range paths {
+		target := filepath.Join(dir, filepath.FromSlash(p))
+		if _, err := os.Stat(target); err == nil && !overwrite {
+			existing = append(existing, target)
+			continue
+		}
+		if err := tx.Stage(target, files[p]); err != nil {
+			return nil, err
+		}
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("files already exist: %s", strings.Join(existing, ", "))
+	}
+	return tx, nil
+}
+
+// Tool lets the agent create files from templates
+type Tool struct {
+	templatesDir string
+}
+
+// NewTool creates a scaffold tool using the built-in templates and those in templatesDir
+func NewTool(templatesDir string) *Tool {
+	return &Tool{templatesDir: templatesDir}
+}
+
+// Name returns the tool name
+func (t *Tool) Name() string {
+	return "scaffold"
+}
+
+// Description returns the tool description
+func (t *Tool) Description() string {
+	return "Creates new files (packages, commands, tests, modules) from the project's templates with variable substitution, so new code follows a consistent layout. Call it without a template to list the templates and their variables"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *Tool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"template": {
+				Type:        "string",
+				Description: "Template name; omit to list the available templates",
+			},
+			"variables": {
+				Type:                 "object",
+				Description:          "Template variables, e.g. {\"name\": \"userstore\"}",
+				AdditionalProperties: &tools.JSONSchema{Type: "string"},
+			},
+			"dir": {
+				Type:        "string",
+				Description: "Directory to create the files in (default: current directory)",
+			},
+			"overwrite": {
+				Type:        "boolean",
+				Description: "Replace files that already exist (default: false)",
+			},
+		},
+	}
+}
+
+// Preview shows the files that would be created
+func (t *Tool) Preview(ctx context.Context, params map[string]interface{}) (string, error) {
+	if template, _ := params["template"].(string); template == "" {
+		return "List templates", nil
+	}
+	tx, err := t.stage(params)
+	if err != nil {
+		return "", err
+	}
+	return tx.Diff(), nil
+}
+
+// Execute lists the templates or creates files from one
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	if template, _ := params["template"].(string); template == "" {
+		registry, err := Load(t.templatesDir)
+		if err != nil {
+			return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to load templates", Err: err}
+		}
+		return map[string]interface{}{"templates": registry.List()}, nil
+	}
+
+	tx, err := t.stage(params)
+	if err != nil {
+		return nil, err
+	}
+	files := tx.Files()
+	if err := tx.Commit(); err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to create files", Err: err}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"files":   files,
+		"count":   len(files),
+	}, nil
+}
+
+// stage renders the requested template without writing anything
+func (t *Tool) stage(params map[string]interface{}) (*tools.EditTransaction, error) {
+	name, _ := params["template"].(string)
+	dir, _ := params["dir"].(string)
+	if dir == "" {
+		dir = "."
+	}
+	overwrite, _ := params["overwrite"].(bool)
+
+	vars := make(map[string]string)
+	if raw, ok := params["variables"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			vars[k] = fmt.Sprint(v)
+		}
+	}
+
+	cleanDir, err := tools.ValidateAndCleanPath(dir)
+	if err != nil {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	registry, err := Load(t.templatesDir)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to load templates", Err: err}
+	}
+	tmpl, err := registry.Get(name)
+	if err != nil {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	tx, err := Stage(tmpl, vars, cleanDir, overwrite)
+	if err != nil {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	return tx, nil
+}
@@ -0,0 +1,524 @@
+// Package search provides full-text search over a project's files, backed by a
+// trigram index stored in the user's cache directory.
+package search
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"codezilla/internal/tools"
+)
+
+const (
+	// indexVersion changes whenever the on-disk format does, forcing a rebuild
+	indexVersion = 2
+	// maxIndexedFiles bounds the index on very large trees
+	maxIndexedFiles = 50000
+	// maxIndexedFileSize skips generated and data files that are rarely searched for code
+	maxIndexedFileSize = 1 << 20
+	// binarySniffSize is how much of a file is checked for NUL bytes
+	binarySniffSize = 8000
+)
+
+// IndexDir is where the indexes of all projects are kept, each in a directory named
+// after a hash of the project's path. It is outside the projects, so indexing never
+// leaves untracked files in them.
+var IndexDir = defaultIndexDir()
+
+// defaultIndexDir returns the index directory under the user's cache directory
+func defaultIndexDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "codezilla", "index")
+}
+
+// skippedDirs are never indexed, in addition to hidden directories
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// fileEntry is an indexed file. Removed files keep their ID with an empty path until
+// the index is rebuilt.
+type fileEntry struct {
+	Path    string // Slash-separated, relative to the root; "name:path" in additional roots
+	Size    int64
+	ModTime int64 // Unix nanoseconds
+}
+
+// indexData is the persisted form of the index
+type indexData struct {
+	Version  int
+	Files    []fileEntry
+	Postings map[uint32][]uint32 // Trigram -> sorted IDs of the files containing it
+	Symbols  map[uint32][]symbolEntry
+}
+
+// Index maps the trigrams of every text file under a root to the files containing
+// them. Searches use it to find candidate files and then read only those, so file
+// contents are never held in memory. It also keeps the symbols defined in source
+// files in the languages symbolsByExt covers.
+type Index struct {
+	root  string
+	roots map[string]string // Additional roots by name, indexed alongside root
+	now   func() time.Time  // Clock used to rank recently changed files
+	// changed returns the files changed in git, ranked higher; nil turns that off
+	changed func() map[string]bool
+
+	mu       sync.Mutex
+	loaded   bool
+	files    []fileEntry
+	byPath   map[string]uint32
+	postings map[uint32][]uint32
+	symbols  map[uint32][]symbolEntry
+}
+
+// New creates an index of the files under root. Nothing is read until the index is
+// first refreshed.
+func New(root string) *Index {
+	return &Index{root: root, now: time.Now}
+}
+
+// PrioritizeChanged sets whether files changed in the git working tree or on the
+// current branch rank higher in searches
+func (ix *Index) PrioritizeChanged(on bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !on {
+		ix.changed = nil
+		return
+	}
+	ix.changed = func() map[string]bool {
+		changed, _ := tools.ChangedFiles(context.Background(), ix.root)
+		for _, dir := range ix.roots {
+			more, _ := tools.ChangedFiles(context.Background(), dir)
+			for path := range more {
+				if changed == nil {
+					changed = make(map[string]bool)
+				}
+				changed[path] = true
+			}
+		}
+		return changed
+	}
+}
+
+// SetRoots sets additional directories to index by name. Their files are listed
+// qualified with the name, such as lib:pkg/foo.go.
+func (ix *Index) SetRoots(roots map[string]string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.roots = roots
+}
+
+// rootDir returns the directory of the additional root with the given name
+func (ix *Index) rootDir(name string) (string, bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	dir, ok := ix.roots[name]
+	return dir, ok
+}
+
+// rootNames returns the names of the additional roots, sorted
+func (ix *Index) rootNames() []string {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	names := make([]string, 0, len(ix.roots))
+	for name := range ix.roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Root returns the directory the index covers
+func (ix *Index) Root() string {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.root
+}
+
+// SetRoot moves the index to the files under root, such as a task's worktree. What
+// was indexed under the previous root is dropped and the index is loaded again on the
+// next refresh.
+func (ix *Index) SetRoot(root string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.root = root
+	ix.loaded = false
+	ix.files, ix.byPath, ix.postings, ix.symbols = nil, nil, nil, nil
+}
+
+// Stats describes the size of the index
+type Stats struct {
+	Files    int `json:"files"`
+	Trigrams int `json:"trigrams"`
+	Postings int `json:"postings"`
+}
+
+// Stats returns the number of indexed files, distinct trigrams and postings
+func (ix *Index) Stats() Stats {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	s := Stats{Files: len(ix.byPath), Trigrams: len(ix.postings)}
+	for _, ids := range ix.postings {
+		s.Postings += len(ids)
+	}
+	return s
+}
+
+// Refresh brings the index up to date with the files on disk, re-reading only files
+// whose size or modification time changed, and saves it when anything changed. It
+// returns the number of files added, changed or removed.
+func (ix *Index) Refresh() (int, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.loaded {
+		ix.load()
+		ix.loaded = true
+	}
+
+	current := make(map[string]fileEntry)
+	if err := ix.scan(ix.root, "", current); err != nil {
+		return 0, err
+	}
+	// In name order, so the same files are left out when the index is full
+	names := make([]string, 0, len(ix.roots))
+	for name := range ix.roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ix.scan(ix.roots[name], name+":", current); err != nil {
+			return 0, err
+		}
+	}
+
+	// Drop removed and changed files from the postings, then add the new contents
+	stale := make(map[uint32]bool)
+	var reindex []string
+	removed := 0
+	for path, id := range ix.byPath {
+		entry, ok := current[path]
+		switch {
+		case !ok:
+			stale[id] = true
+			ix.files[id] = fileEntry{}
+			delete(ix.byPath, path)
+			delete(ix.symbols, id)
+			removed++
+		case entry != ix.files[id]:
+			stale[id] = true
+			reindex = append(reindex, path)
+		}
+	}
+	for path := range current {
+		if _, ok := ix.byPath[path]; !ok {
+			reindex = append(reindex, path)
+		}
+	}
+	changed := len(reindex) + removed
+	if changed == 0 {
+		return 0, nil
+	}
+
+	// Rebuild from scratch once removed files take up most of the ID space
+	if len(ix.files)-len(ix.byPath) > len(ix.files)/2 {
+		ix.files, ix.byPath, ix.postings = nil, make(map[string]uint32), make(map[uint32][]uint32)
+		ix.symbols = make(map[uint32][]symbolEntry)
+		stale = nil
+		reindex = reindex[:0]
+		for path := range current {
+			reindex = append(reindex, path)
+		}
+	}
+	if len(stale) > 0 {
+		for tri, ids := range ix.postings {
+			kept := ids[:0]
+			for _, id := range ids {
+				if !stale[id] {
+					kept = append(kept, id)
+				}
+			}
+			if len(kept) == 0 {
+				delete(ix.postings, tri)
+			} else {
+				ix.postings[tri] = kept
+			}
+		}
+	}
+
+	sort.Strings(reindex)
+	touched := make(map[uint32]bool)
+	for _, path := range reindex {
+		entry := current[path]
+		id, ok := ix.byPath[path]
+		if !ok {
+			id = uint32(len(ix.files))
+			ix.files = append(ix.files, entry)
+			ix.byPath[path] = id
+		}
+		ix.files[id] = entry
+		delete(ix.symbols, id)
+
+		data, err := os.ReadFile(ix.abs(path))
+		if err != nil || isBinary(data) {
+			continue
+		}
+		if symbols := extractSymbols(path, data); len(symbols) > 0 {
+			ix.symbols[id] = symbols
+		}
+		for tri := range trigrams(data) {
+			ix.postings[tri] = append(ix.postings[tri], id)
+			touched[tri] = true
+		}
+	}
+	// Changed files keep their ID, which may now be out of order
+	if len(stale) > 0 {
+		for tri := range touched {
+			ids := ix.postings[tri]
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		}
+	}
+
+	return changed, ix.save()
+}
+
+// scan adds the files under root to current, keyed by their path relative to root
+// after prefix
+func (ix *Index) scan(root, prefix string, current map[string]fileEntry) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(current) >= maxIndexedFiles {
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxIndexedFileSize {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rel = prefix + filepath.ToSlash(rel)
+		current[rel] = fileEntry{Path: rel, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return nil
+}
+
+// abs returns the absolute path of an indexed file
+func (ix *Index) abs(path string) string {
+	if name, rest, ok := strings.Cut(path, ":"); ok {
+		if dir, ok := ix.roots[name]; ok {
+			return filepath.Join(dir, filepath.FromSlash(rest))
+		}
+	}
+	return filepath.Join(ix.root, filepath.FromSlash(path))
+}
+
+// load reads the saved index, starting empty when there is none or it is unreadable
+func (ix *Index) load() {
+	ix.files, ix.byPath, ix.postings = nil, make(map[string]uint32), make(map[uint32][]uint32)
+	ix.symbols = make(map[uint32][]symbolEntry)
+
+	f, err := os.Open(filepath.Join(ix.dir(), "trigrams.gob"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var data indexData
+	if err := gob.NewDecoder(f).Decode(&data); err != nil || data.Version != indexVersion {
+		return
+	}
+	ix.files = data.Files
+	if data.Postings != nil {
+		ix.postings = data.Postings
+	}
+	if data.Symbols != nil {
+		ix.symbols = data.Symbols
+	}
+	for id, entry := range ix.files {
+		if entry.Path != "" {
+			ix.byPath[entry.Path] = uint32(id)
+		}
+	}
+}
+
+// dir returns the directory the index of the root is kept in; the caller holds the lock
+func (ix *Index) dir() string {
+	root, err := filepath.Abs(ix.root)
+	if err != nil {
+		root = ix.root
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(IndexDir, hex.EncodeToString(sum[:8]))
+}
+
+// save writes the index; the caller holds the lock
+func (ix *Index) save() error {
+	dir := ix.dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	var buf bytes.Buffer
+	data := indexData{Version: indexVersion, Files: ix.files, Postings: ix.postings, Symbols: ix.symbols}
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".trigrams.gob.tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, "trigrams.gob")); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// candidates returns the IDs of the files containing every trigram of s, or nil with
+// all set when s is too short to narrow the search. Trigrams with non-ASCII bytes are
+// skipped, since only ASCII letters are case-folded in the index.
+func (ix *Index) candidates(s string) (ids []uint32, all bool) {
+	first := true
+	for tri := range trigrams([]byte(s)) {
+		if tri&0x808080 != 0 {
+			continue
+		}
+		postings := ix.postings[tri]
+		if first {
+			ids = append([]uint32(nil), postings...)
+			first = false
+		} else {
+			ids = intersect(ids, postings)
+		}
+		if len(ids) == 0 {
+			return nil, false
+		}
+	}
+	return ids, first
+}
+
+// allFiles returns the IDs of every indexed file
+func (ix *Index) allFiles() []uint32 {
+	ids := make([]uint32, 0, len(ix.byPath))
+	for _, id := range ix.byPath {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// trigrams returns the distinct trigrams of data, with ASCII letters lower-cased so
+// searches are case-insensitive
+func trigrams(data []byte) map[uint32]struct{} {
+	set := make(map[uint32]struct{})
+	if len(data) < 3 {
+		return set
+	}
+	lower := func(b byte) uint32 {
+		if 'A' <= b && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		return uint32(b)
+	}
+	a, b := lower(data[0]), lower(data[1])
+	for i := 2; i < len(data); i++ {
+		c := lower(data[i])
+		set[a<<16|b<<8|c] = struct{}{}
+		a, b = b, c
+	}
+	return set
+}
+
+// isBinary reports whether data looks like a binary file
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffSize {
+		data = data[:binarySniffSize]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// intersect returns the IDs in both sorted lists
+func intersect(a, b []uint32) []uint32 {
+	out := a[:0]
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// union returns the IDs in either sorted list
+func union(a, b []uint32) []uint32 {
+	out := make([]uint32, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			out = append(out, a[i])
+			i++
+		case i == len(a) || a[i] > b[j]:
+			out = append(out, b[j])
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
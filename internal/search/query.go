@@ -0,0 +1,490 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLinesPerFile bounds the matching lines returned for one file
+	maxLinesPerFile = 5
+	// maxMatchesPerFile stops counting matches in a file early; a file reaching it is
+	// reported with that many matches
+	maxMatchesPerFile = 100
+	// maxLineLength truncates long matching lines
+	maxLineLength = 200
+	// maxPatternLength bounds regular expressions, whose compiled size grows with them
+	maxPatternLength = 1000
+	// regexPrefix marks a query that is a regular expression
+	regexPrefix = "regex:"
+)
+
+// term is a single search term
+type term struct {
+	text string // Lower-cased text, or the pattern of a regex term
+	// re is set for prefix and regex terms; for prefix terms, the match is its first group
+	re    *regexp.Regexp
+	group int
+	// literals are substrings of every match, used to narrow the files to read
+	literals []string
+}
+
+// Query is a parsed search query. Every clause must match a file; a clause matches
+// when any of its terms does. Files matching an excluded term are dropped.
+type Query struct {
+	Clauses  [][]term
+	Excluded []term
+}
+
+// ParseQuery parses a query of space-separated terms, all of which must match.
+// "a OR b" matches either term, "-a" or "NOT a" excludes files containing a,
+// "quoted text" matches a phrase, and "a*" matches words starting with a. Matching
+// is case-insensitive. A query starting with "regex:" is a single regular expression
+// in Go syntax, matched case-sensitively unless it starts with (?i).
+func ParseQuery(s string) (*Query, error) {
+	if pattern, ok := strings.CutPrefix(strings.TrimSpace(s), regexPrefix); ok {
+		t, err := parseRegex(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return &Query{Clauses: [][]term{{t}}}, nil
+	}
+
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case !tok.quoted && tok.text == "OR":
+			if len(q.Clauses) == 0 || i+1 == len(tokens) {
+				return nil, fmt.Errorf("OR needs a term on both sides")
+			}
+			i++
+			next, negated := parseTerm(tokens[i])
+			if negated {
+				return nil, fmt.Errorf("cannot combine OR with an excluded term")
+			}
+			last := len(q.Clauses) - 1
+			q.Clauses[last] = append(q.Clauses[last], next)
+		case !tok.quoted && tok.text == "NOT":
+			if i+1 == len(tokens) {
+				return nil, fmt.Errorf("NOT needs a term")
+			}
+			i++
+			t, _ := parseTerm(tokens[i])
+			q.Excluded = append(q.Excluded, t)
+		default:
+			t, negated := parseTerm(tok)
+			if negated {
+				q.Excluded = append(q.Excluded, t)
+			} else {
+				q.Clauses = append(q.Clauses, []term{t})
+			}
+		}
+	}
+	if len(q.Clauses) == 0 {
+		return nil, fmt.Errorf("query %q has no terms to search for", s)
+	}
+	return q, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits s on spaces, keeping quoted phrases together
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		negated := strings.HasPrefix(s, `-"`)
+		if negated || s[0] == '"' {
+			start := 1
+			if negated {
+				start = 2
+			}
+			end := strings.IndexByte(s[start:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in %q", s)
+			}
+			text := s[start : start+end]
+			if negated {
+				text = "-" + text
+			}
+			tokens = append(tokens, token{text: text, quoted: true})
+			s = s[start+end+1:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		tokens = append(tokens, token{text: s[:end]})
+		s = s[end:]
+	}
+	return tokens, nil
+}
+
+// parseTerm turns a token into a term, reporting whether it was negated with "-"
+func parseTerm(tok token) (term, bool) {
+	text := tok.text
+	negated := strings.HasPrefix(text, "-") && len(text) > 1
+	if negated {
+		text = text[1:]
+	}
+	t := term{text: strings.ToLower(text)}
+	if !tok.quoted && strings.HasSuffix(t.text, "*") && len(t.text) > 1 {
+		t.text = strings.TrimSuffix(t.text, "*")
+		t.re = regexp.MustCompile(`(?:^|[^\pL\pN_])(` + regexp.QuoteMeta(t.text) + `)`)
+		t.group = 1
+	}
+	t.literals = []string{t.text}
+	return t, negated
+}
+
+// parseRegex compiles a regex term
+func parseRegex(pattern string) (term, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return term{}, fmt.Errorf("empty regular expression")
+	}
+	if len(pattern) > maxPatternLength {
+		return term{}, fmt.Errorf("regular expression is longer than %d characters", maxPatternLength)
+	}
+	// Files are searched whole, so ^ and $ anchor lines, as in grep
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		return term{}, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	parsed, err := syntax.Parse("(?m)"+pattern, syntax.Perl)
+	if err != nil {
+		return term{}, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return term{text: pattern, re: re, literals: requiredLiterals(parsed.Simplify())}, nil
+}
+
+// requiredLiterals returns literal strings that every match of re contains
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var out []string
+		for _, sub := range re.Sub {
+			out = append(out, requiredLiterals(sub)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// find returns the byte ranges of up to limit matches of t in s; lower is s
+// lower-cased, searched by plain terms
+func (t term) find(s, lower string, limit int) [][2]int {
+	var ranges [][2]int
+	if t.re == nil {
+		for offset := 0; len(ranges) < limit; {
+			i := strings.Index(lower[offset:], t.text)
+			if i < 0 || t.text == "" {
+				break
+			}
+			start := offset + i
+			ranges = append(ranges, [2]int{start, start + len(t.text)})
+			offset = start + len(t.text)
+		}
+		return ranges
+	}
+
+	target := s
+	if t.group > 0 {
+		target = lower
+	}
+	for _, m := range t.re.FindAllStringSubmatchIndex(target, limit) {
+		if m[2*t.group] >= 0 {
+			ranges = append(ranges, [2]int{m[2*t.group], m[2*t.group+1]})
+		}
+	}
+	return ranges
+}
+
+// LineMatch is a line containing a match
+type LineMatch struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	// Ranges are the byte offsets of the matches in Text, for highlighting
+	Ranges [][2]int `json:"ranges,omitempty"`
+}
+
+// Result is a file matching a query
+type Result struct {
+	Path    string      `json:"path"`
+	Score   float64     `json:"score"`
+	Matches int         `json:"matches"`
+	Lines   []LineMatch `json:"lines"`
+	// Signals name what raised the score besides the matches themselves: "name" when
+	// a term is in the file name, "definition" when a match is on a line defining a
+	// symbol, "recent" when the file changed in the last week, "changed" when it is
+	// changed in git
+	Signals []string `json:"signals,omitempty"`
+
+	nameMatch  bool
+	definition bool
+}
+
+// Options narrows a search
+type Options struct {
+	// Path limits the search to files under this slash-separated directory or file
+	Path string
+	// Limit is the maximum number of files returned (default 20)
+	Limit int
+}
+
+// Search refreshes the index and returns the files matching query, best first
+func (ix *Index) Search(query string, opts Options) ([]Result, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ix.Refresh(); err != nil {
+		return nil, err
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	prefix := strings.Trim(filepath.ToSlash(filepath.Clean(opts.Path)), "/")
+	if prefix == "." {
+		prefix = ""
+	}
+
+	ix.mu.Lock()
+	ids := ix.allFiles()
+	docFreq := make(map[string]int)
+	for _, clause := range q.Clauses {
+		var matched []uint32
+		for _, t := range clause {
+			found := ix.allFiles()
+			for _, literal := range t.literals {
+				if ids, all := ix.candidates(literal); !all {
+					found = intersect(found, ids)
+				}
+			}
+			docFreq[t.text] = len(found)
+			matched = union(matched, found)
+		}
+		ids = intersect(ids, matched)
+	}
+	entries := make([]fileEntry, 0, len(ids))
+	symbols := make(map[string][]symbolEntry)
+	for _, id := range ids {
+		entries = append(entries, ix.files[id])
+		symbols[ix.files[id].Path] = ix.symbols[id]
+	}
+	total := len(ix.byPath)
+	changedFiles := ix.changed
+	ix.mu.Unlock()
+
+	var changed map[string]bool
+	if changedFiles != nil && len(entries) > 0 {
+		changed = changedFiles()
+	}
+
+	var results []Result
+	now := ix.now()
+	for _, entry := range entries {
+		path := entry.Path
+		if prefix != "" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		// Candidates contain every trigram of the terms; reading the file confirms it
+		data, err := os.ReadFile(ix.abs(path))
+		if err != nil {
+			continue
+		}
+		if r, ok := q.match(path, string(data), symbols[path], docFreq, total); ok {
+			r.rank(time.Unix(0, entry.ModTime), now, changed[ix.abs(path)])
+			results = append(results, r)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, nil
+}
+
+// match checks content against the query and scores it by term frequency, weighted
+// by how rare each term is across the project. Counting stops at maxMatchesPerFile.
+// Definitions come from the file's symbols, or from definitionPattern for languages
+// without them.
+func (q *Query) match(path, content string, symbols []symbolEntry, docFreq map[string]int, total int) (Result, bool) {
+	lower := strings.ToLower(content)
+	for _, t := range q.Excluded {
+		if len(t.find(content, lower, 1)) > 0 {
+			return Result{}, false
+		}
+	}
+
+	r := Result{Path: path}
+	base := path[strings.LastIndex(path, "/")+1:]
+	lowerBase := strings.ToLower(base)
+	var matched []term
+	for _, clause := range q.Clauses {
+		clauseMatched := false
+		for _, t := range clause {
+			n := len(t.find(content, lower, maxMatchesPerFile))
+			if n == 0 {
+				continue
+			}
+			clauseMatched = true
+			matched = append(matched, t)
+			r.Matches += n
+			idf := math.Log(1 + float64(total)/float64(docFreq[t.text]+1))
+			r.Score += (1 + math.Log(float64(n))) * idf
+			if len(t.find(base, lowerBase, 1)) > 0 {
+				r.Score += idf
+				r.nameMatch = true
+			}
+		}
+		if !clauseMatched {
+			return Result{}, false
+		}
+	}
+
+	// Lines defining a matched symbol come first, as they are usually what is wanted
+	var lines, definitions []LineMatch
+	defined := definitionLines(symbols)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		lowerLine := strings.ToLower(line)
+		var ranges [][2]int
+		for _, t := range matched {
+			ranges = append(ranges, t.find(line, lowerLine, maxMatchesPerFile)...)
+		}
+		if len(ranges) == 0 {
+			continue
+		}
+		sort.Slice(ranges, func(a, b int) bool { return ranges[a][0] < ranges[b][0] })
+		var isDefinition bool
+		if defined != nil {
+			isDefinition = definesName(line, defined[i+1], ranges)
+		} else {
+			isDefinition = definesMatch(line, ranges)
+		}
+		text, ranges := truncateLine(line, ranges)
+		match := LineMatch{Line: i + 1, Text: text, Ranges: ranges}
+		if isDefinition && len(definitions) < maxLinesPerFile {
+			definitions = append(definitions, match)
+		} else if len(lines) < maxLinesPerFile {
+			lines = append(lines, match)
+		}
+		if len(definitions) == maxLinesPerFile {
+			break
+		}
+	}
+	if len(definitions) > 0 {
+		r.definition = true
+	}
+	r.Lines = append(definitions, lines...)
+	if len(r.Lines) > maxLinesPerFile {
+		r.Lines = r.Lines[:maxLinesPerFile]
+	}
+	sort.Slice(r.Lines, func(a, b int) bool { return r.Lines[a].Line < r.Lines[b].Line })
+	return r, true
+}
+
+// definitionPattern matches lines that define a function, type, class or constant in
+// common languages, such as "func (s *Server) Start(", "class Parser:" or
+// "export const handler =", capturing the defined name
+var definitionPattern = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:pub(?:\([a-z]+\))?\s+)?(?:async\s+)?(?:func|function|type|class|interface|struct|enum|trait|impl|def|fn|const|let|var|module|object)\s*(?:\([^)]*\)\s*)?([A-Za-z_$][\w$]*)`)
+
+// definesMatch reports whether line defines a symbol whose name one of the match
+// ranges covers, so "func Parse(" counts as a definition of Parse but not of a
+// parameter type mentioned later on the line
+func definesMatch(line string, ranges [][2]int) bool {
+	loc := definitionPattern.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return false
+	}
+	for _, rg := range ranges {
+		if rg[0] < loc[3] && rg[1] > loc[2] {
+			return true
+		}
+	}
+	return false
+}
+
+// Ranking weights applied on top of the term score
+const (
+	definitionBoost = 1.5 // A match on a line defining a symbol
+	recentBoost     = 0.2 // Up to this share for files changed in the last week
+	recentWindow    = 7 * 24 * time.Hour
+	changedBoost    = 1.25 // A file changed in git, when PrioritizeChanged is on
+	depthPenalty    = 0.05 // Per directory level
+)
+
+// rank adjusts the term score for definitions, recent and git changes and path
+// depth, and records the signals that raised it
+func (r *Result) rank(modTime, now time.Time, changed bool) {
+	if r.nameMatch {
+		r.Signals = append(r.Signals, "name")
+	}
+	if r.definition {
+		r.Score *= definitionBoost
+		r.Signals = append(r.Signals, "definition")
+	}
+	if age := now.Sub(modTime); age >= 0 && age < recentWindow {
+		r.Score *= 1 + recentBoost*(1-float64(age)/float64(recentWindow))
+		r.Signals = append(r.Signals, "recent")
+	}
+	if changed {
+		r.Score *= changedBoost
+		r.Signals = append(r.Signals, "changed")
+	}
+	r.Score /= 1 + depthPenalty*float64(strings.Count(r.Path, "/"))
+	r.Score = math.Round(r.Score*100) / 100
+}
+
+// truncateLine shortens long lines without splitting a UTF-8 character, dropping the
+// match ranges past the cut
+func truncateLine(line string, ranges [][2]int) (string, [][2]int) {
+	if len(line) <= maxLineLength {
+		return line, ranges
+	}
+	cut := maxLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	kept := ranges[:0]
+	for _, rg := range ranges {
+		if rg[0] >= cut {
+			break
+		}
+		rg[1] = min(rg[1], cut)
+		kept = append(kept, rg)
+	}
+	return line[:cut] + "...", kept
+}
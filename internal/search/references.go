@@ -0,0 +1,169 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Patterns for the references checked in answers
+var (
+	// pathRef matches file paths with an extension, optionally followed by a line
+	pathRef = regexp.MustCompile(`(?:[A-Za-z]:|~)?[\w./@+-]*\.[A-Za-z][A-Za-z0-9]{0,5}(?::\d+(?:-\d+)?)?`)
+	// symbolRef matches identifiers in backticks, such as `ParseConfig` or `Server.Start()`
+	symbolRef = regexp.MustCompile("`((?:[A-Za-z_]\\w*\\.)?[A-Za-z_]\\w*)(?:\\(\\))?`")
+	// plannedRef marks lines about files or symbols that are to be created or are gone
+	plannedRef = regexp.MustCompile(`(?i)\b(create[sd]?|creating|add(?:s|ed|ing)?|new|rename[sd]?|delete[sd]?|deleting|remove[sd]?|removing|would|could|should)\b`)
+)
+
+// sourceExtensions are the extensions that mark a bare file name, without a
+// directory, as a reference to a project file
+var sourceExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".rs": true,
+	".java": true, ".kt": true, ".rb": true, ".php": true, ".c": true, ".h": true, ".cpp": true,
+	".hpp": true, ".cs": true, ".swift": true, ".scala": true, ".sh": true, ".sql": true,
+	".proto": true, ".yaml": true, ".yml": true, ".toml": true, ".json": true, ".md": true,
+}
+
+// MissingReferences returns the file paths and symbols mentioned in text, usually a
+// model's answer, that don't exist in the project: files neither on disk nor in the
+// index, and backticked CamelCase or snake_case names that are neither defined nor
+// used in any indexed file. Code blocks, URLs and lines about creating, adding or
+// removing things are skipped, since they may name things that don't exist yet.
+func (ix *Index) MissingReferences(text string) ([]string, error) {
+	if _, err := ix.Refresh(); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var missing []string
+	for _, line := range proseLines(text) {
+		if plannedRef.MatchString(line) {
+			continue
+		}
+		for _, loc := range pathRef.FindAllStringIndex(line, -1) {
+			ref := line[loc[0]:loc[1]]
+			if strings.Contains(ref, "://") || loc[0] > 0 && strings.ContainsRune(":/", rune(line[loc[0]-1])) || strings.Contains(line[loc[1]:min(loc[1]+3, len(line))], "://") {
+				continue // Part of a URL
+			}
+			path := strings.TrimRight(ref, ".")
+			if i := strings.Index(path, ":"); i > 1 {
+				path = path[:i] // Line number
+			}
+			if seen[path] || !looksLikeFile(path) {
+				continue
+			}
+			seen[path] = true
+			if !ix.fileExists(path) {
+				missing = append(missing, path)
+			}
+		}
+		for _, m := range symbolRef.FindAllStringSubmatch(line, -1) {
+			name := m[1]
+			if seen[name] || !looksLikeSymbol(name) {
+				continue
+			}
+			seen[name] = true
+			found, err := ix.FindDefinitions(name, "", "", 1)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) > 0 {
+				continue
+			}
+			// Names used but not defined in a covered language, such as config keys
+			base := name[strings.LastIndex(name, ".")+1:]
+			results, err := ix.Search(`"`+base+`"`, Options{Limit: 1})
+			if err != nil {
+				return nil, err
+			}
+			if len(results) == 0 {
+				missing = append(missing, "`"+name+"`")
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// proseLines returns the lines of text outside fenced code blocks
+func proseLines(text string) []string {
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// looksLikeFile reports whether a matched token is a file path rather than a
+// version, a domain or an abbreviation: it has a directory or a source extension
+func looksLikeFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	return strings.Contains(path, "/") || sourceExtensions[ext]
+}
+
+// looksLikeSymbol reports whether a backticked name is distinctive enough to be a
+// project symbol: CamelCase with a lower-case letter, or snake_case. Single words
+// are too often keywords, commands or standard library names.
+func looksLikeSymbol(name string) bool {
+	base := name[strings.LastIndex(name, ".")+1:]
+	if len(base) < 4 {
+		return false
+	}
+	if strings.Contains(name, ".") && !isUpper(name[0]) {
+		return false // pkg.Func of a package that may not be in the project
+	}
+	if strings.Contains(base, "_") {
+		return strings.Trim(base, "_") != "" && strings.ToUpper(base) != base
+	}
+	upper := 0
+	for i := 1; i < len(base); i++ {
+		if isUpper(base[i]) {
+			upper++
+		}
+	}
+	return upper > 0 && strings.ToUpper(base) != base
+}
+
+// fileExists reports whether path names a file on disk, relative to the project or
+// an additional root, or the end of an indexed path
+func (ix *Index) fileExists(path string) bool {
+	local := path
+	if strings.HasPrefix(local, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			local = filepath.Join(home, local[2:])
+		}
+	}
+	if filepath.IsAbs(local) {
+		_, err := os.Stat(local)
+		return err == nil
+	}
+	if _, err := os.Stat(ix.abs(local)); err == nil {
+		return true
+	}
+
+	clean := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for indexed := range ix.byPath {
+		if indexed == clean || strings.HasSuffix(indexed, "/"+clean) {
+			return true
+		}
+	}
+	return false
+}
+
+func isUpper(b byte) bool {
+	return b >= 'A' && b <= 'Z'
+}
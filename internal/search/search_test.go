@@ -0,0 +1,368 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "codezilla-index-test-*")
+	if err != nil {
+		panic(err)
+	}
+	IndexDir = dir
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func paths(results []Result) []string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Path)
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"config/load.go":      "package config\n\n// LoadConfig reads the config\nfunc LoadConfig() error {\n\treturn ErrMissing\n}\n",
+		"config/load_test.go": "package config\n\nfunc TestLoadConfig(t *testing.T) { LoadConfig() }\n",
+		"server/handler.go":   "package server\n\n// handleRequest serves a request\nfunc handleRequest() { log(\"connection refused\") }\n",
+		"node_modules/x.js":   "LoadConfig",
+		"image.png":           "LoadConfig\x00\x01",
+	})
+	ix := New(root)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"loadconfig", []string{"config/load.go", "config/load_test.go"}},
+		{"LoadConfig -testing", []string{"config/load.go"}},
+		{"LoadConfig NOT testing", []string{"config/load.go"}},
+		{"package handl*", []string{"server/handler.go"}},
+		{"errmissing OR request", []string{"server/handler.go", "config/load.go"}},
+		{`"connection refused"`, []string{"server/handler.go"}},
+		{`"refused connection"`, nil},
+		{"andl*", nil},
+		{`regex:func Load\w+\(`, []string{"config/load.go"}},
+		{`regex:Test\w+\(t \*testing`, []string{"config/load_test.go"}},
+		{`regex:loadconfig`, nil},
+		{`regex:(?i)^func loadconfig`, []string{"config/load.go"}},
+		{`regex:^package server$`, []string{"server/handler.go"}},
+		{`regex:^\treturn ErrMissing$`, []string{"config/load.go"}},
+		{`regex:^ErrMissing`, nil},
+		{`regex:connection (refused|reset)`, []string{"server/handler.go"}},
+	}
+	for _, tt := range tests {
+		results, err := ix.Search(tt.query, Options{})
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", tt.query, err)
+		}
+		if got := paths(results); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	results, _ := ix.Search("LoadConfig", Options{Path: "config/load.go"})
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Lines, []LineMatch{
+		{Line: 3, Text: "// LoadConfig reads the config", Ranges: [][2]int{{3, 13}}},
+		{Line: 4, Text: "func LoadConfig() error {", Ranges: [][2]int{{5, 15}}},
+	}) {
+		t.Errorf("Search() with a path = %+v", results)
+	}
+
+	results, _ = ix.Search(`regex:handle\w+`, Options{})
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Lines[1], LineMatch{Line: 4, Text: `func handleRequest() { log("connection refused") }`, Ranges: [][2]int{{5, 18}}}) {
+		t.Errorf("Search() with a regex = %+v", results)
+	}
+
+	for _, query := range []string{"", "-only", "a OR", `"open`, "regex:(", "regex: "} {
+		if _, err := ix.Search(query, Options{}); err == nil {
+			t.Errorf("Search(%q) expected an error", query)
+		}
+	}
+}
+
+func TestRanking(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"parser.go":             "package p\n\n// Parser reads input\ntype Parser struct{}\n",
+		"internal/a/b/usage.go": "package b\n\nvar p Parser // Parser\nfunc use(p Parser) {}\n",
+		"docs/notes.md":         "The parser is described here.\nParser, parser, parser.\n",
+		"old.go":                "package p\n\nfunc (Parser) Old() {}\n",
+	})
+	now := time.Now()
+	old := now.Add(-30 * 24 * time.Hour)
+	for _, name := range []string{"parser.go", "internal/a/b/usage.go", "docs/notes.md", "old.go"} {
+		os.Chtimes(filepath.Join(root, name), old, old)
+	}
+	os.Chtimes(filepath.Join(root, "old.go"), now, now)
+
+	ix := New(root)
+	ix.now = func() time.Time { return now }
+	results, err := ix.Search("parser", Options{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	want := []string{"parser.go", "docs/notes.md", "internal/a/b/usage.go", "old.go"}
+	if got := paths(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Search() = %v, want %v (%+v)", got, want, results)
+	}
+	if !reflect.DeepEqual(results[0].Signals, []string{"name", "definition"}) || results[0].Lines[0].Line != 4 && results[0].Lines[1].Line != 4 {
+		t.Errorf("top result = %+v", results[0])
+	}
+	if !reflect.DeepEqual(results[3].Signals, []string{"recent"}) {
+		t.Errorf("signals of old.go = %v, want recent", results[3].Signals)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("results are not sorted by score: %v", results)
+		}
+	}
+}
+
+func TestRequiredLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`func \w+Config\(`, []string{"func ", "Config("}},
+		{`(?i)errMissing`, []string{"errmissing"}},
+		{`(foo)+bar`, []string{"foo", "bar"}},
+		{`a|b`, nil},
+		{`x*yz`, []string{"yz"}},
+	}
+	for _, tt := range tests {
+		term, err := parseRegex(tt.pattern)
+		if err != nil {
+			t.Fatalf("parseRegex(%q) error = %v", tt.pattern, err)
+		}
+		var got []string
+		for _, l := range term.literals {
+			got = append(got, strings.ToLower(l))
+		}
+		var want []string
+		for _, l := range tt.want {
+			want = append(want, strings.ToLower(l))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("requiredLiterals(%q) = %q, want %q", tt.pattern, got, want)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.go": "alpha", "b.go": "beta"})
+
+	ix := New(root)
+	if n, err := ix.Refresh(); err != nil || n != 2 {
+		t.Fatalf("Refresh() = %d, %v, want 2 new files", n, err)
+	}
+	if n, _ := ix.Refresh(); n != 0 {
+		t.Errorf("Refresh() without changes = %d, want 0", n)
+	}
+
+	// A fresh index loads the saved one and only picks up the changes
+	writeFiles(t, root, map[string]string{"a.go": "gamma"})
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(root, "a.go"), future, future)
+	os.Remove(filepath.Join(root, "b.go"))
+	reopened := New(root)
+	if n, err := reopened.Refresh(); err != nil || n != 2 {
+		t.Fatalf("Refresh() after changes = %d, %v, want 2", n, err)
+	}
+	for query, want := range map[string][]string{"alpha": nil, "beta": nil, "gamma": {"a.go"}} {
+		if got, _ := reopened.Search(query, Options{}); !reflect.DeepEqual(paths(got), want) {
+			t.Errorf("Search(%q) = %v, want %v", query, paths(got), want)
+		}
+	}
+	if s := reopened.Stats(); s.Files != 1 {
+		t.Errorf("Stats() = %+v, want 1 file", s)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".codezilla")); !os.IsNotExist(err) {
+		t.Error("the index should be kept outside the project")
+	}
+}
+
+func TestExtractSymbols(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    []symbolEntry
+	}{
+		{"a.go", "package a\n\nfunc (s *Server[T]) Start() {}\nfunc New() {}\nconst (\n\tMax = 1\n\tmin = 2\n)\ntype Config struct {\n\tName string\n}\n", []symbolEntry{
+			{Name: "Start", Kind: "method", Container: "Server", Line: 3},
+			{Name: "New", Kind: "function", Line: 4},
+			{Name: "Max", Kind: "const", Line: 6},
+			{Name: "min", Kind: "const", Line: 7},
+			{Name: "Config", Kind: "type", Line: 9},
+		}},
+		{"a.py", "MAX_SIZE = 10\nclass Parser:\n    def parse(self):\n        pass\nasync def main():\n", []symbolEntry{
+			{Name: "MAX_SIZE", Kind: "const", Line: 1},
+			{Name: "Parser", Kind: "class", Line: 2},
+			{Name: "parse", Kind: "method", Line: 3},
+			{Name: "main", Kind: "function", Line: 5},
+		}},
+		{"a.ts", "export interface Props {}\nexport const handler = async (req) => {}\nexport default class App {}\nconst limit = 3\n", []symbolEntry{
+			{Name: "Props", Kind: "interface", Line: 1},
+			{Name: "handler", Kind: "function", Line: 2},
+			{Name: "App", Kind: "class", Line: 3},
+			{Name: "limit", Kind: "const", Line: 4},
+		}},
+		{"a.c", "#define MAX 3\nstatic int parse(const char *s) {\n\tif (x) {\n}\nstruct node {\n", []symbolEntry{
+			{Name: "MAX", Kind: "macro", Line: 1},
+			{Name: "parse", Kind: "function", Line: 2},
+			{Name: "node", Kind: "type", Line: 5},
+		}},
+		{"a.md", "func Nope() {}\n", nil},
+	}
+	for _, tt := range tests {
+		if got := extractSymbols(tt.path, []byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractSymbols(%s) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFindDefinitions(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"server/server.go": "package server\n\ntype Server struct{}\n\nfunc (s *Server) Start() error { return nil }\n",
+		"client/client.go": "package client\n\nfunc (c *Client) Start() {}\n\nfunc start() {}\n",
+		"main.go":          "package main\n\nfunc main() { server.Start() }\n",
+	})
+	ix := New(root)
+
+	tests := []struct {
+		name, kind, path string
+		want             []string
+	}{
+		{"Start", "", "", []string{"client/client.go:3", "server/server.go:5"}},
+		{"Server.Start", "", "", []string{"server/server.go:5"}},
+		{"Start", "", "server", []string{"server/server.go:5"}},
+		{"start", "", "", []string{"client/client.go:5"}},
+		{"server", "", "", []string{"server/server.go:3"}},
+		{"Server", "function", "", nil},
+		{"Missing", "", "", nil},
+	}
+	for _, tt := range tests {
+		found, err := ix.FindDefinitions(tt.name, tt.kind, tt.path, 0)
+		if err != nil {
+			t.Fatalf("FindDefinitions(%q) error = %v", tt.name, err)
+		}
+		var got []string
+		for _, s := range found {
+			got = append(got, fmt.Sprintf("%s:%d", s.Path, s.Line))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindDefinitions(%q, %q, %q) = %v, want %v", tt.name, tt.kind, tt.path, got, tt.want)
+		}
+	}
+
+	found, _ := ix.FindDefinitions("Server.Start", "", "", 0)
+	if len(found) != 1 || found[0].Text != "func (s *Server) Start() error { return nil }" || found[0].Kind != "method" {
+		t.Errorf("FindDefinitions() = %+v", found)
+	}
+
+	tool := NewDefinitionTool(ix)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": 42}); err == nil {
+		t.Error("Execute() with a name that isn't a string should fail")
+	}
+}
+
+func TestSectionStarts(t *testing.T) {
+	tests := []struct {
+		path, content string
+		want          []int
+	}{
+		{"a.go", "package a\n\nconst (\n\tA = 1\n)\n\n// F does f\nfunc F() {}\n\ntype T struct{}\n", []int{8, 10}},
+		{"a.py", "class A:\n    def f(self):\n        pass\n\ndef g():\n    pass\n", []int{1, 5}},
+		{"a.md", "# Title\n", nil},
+	}
+	for _, tt := range tests {
+		if got := SectionStarts(tt.path, []byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SectionStarts(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRoots(t *testing.T) {
+	root, lib := t.TempDir(), t.TempDir()
+	writeFiles(t, root, map[string]string{"service/main.go": "package main\n\nfunc main() { foo.Parse() }\n"})
+	writeFiles(t, lib, map[string]string{"pkg/foo/parse.go": "package foo\n\nfunc Parse() {}\n"})
+	ix := New(root)
+	ix.SetRoots(map[string]string{"lib": lib})
+
+	if got, _ := ix.Search("Parse", Options{}); !reflect.DeepEqual(paths(got), []string{"lib:pkg/foo/parse.go", "service/main.go"}) {
+		t.Errorf("Search() = %v, want files from both roots", paths(got))
+	}
+	if got, _ := ix.Search("Parse", Options{Path: "lib:pkg"}); !reflect.DeepEqual(paths(got), []string{"lib:pkg/foo/parse.go"}) {
+		t.Errorf("Search() under lib:pkg = %v", paths(got))
+	}
+	found, _ := ix.FindDefinitions("Parse", "", "", 0)
+	if len(found) != 1 || found[0].Path != "lib:pkg/foo/parse.go" || found[0].Text != "func Parse() {}" {
+		t.Errorf("FindDefinitions() = %+v", found)
+	}
+
+	for path, want := range map[string]string{
+		"service":                             "service",
+		"lib:pkg/foo":                         "lib:pkg/foo",
+		filepath.Join(lib, "pkg"):             "lib:pkg",
+		filepath.Join(root, "service", "..."): "service/...",
+	} {
+		if got, err := relative(ix, path); err != nil || got != want {
+			t.Errorf("relative(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := relative(ix, filepath.Dir(lib)); err == nil {
+		t.Error("paths outside every root should be refused")
+	}
+}
+
+func TestMissingReferences(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"config/load.go":   "package config\n\n// LoadConfig reads the config\nfunc LoadConfig() error {\n\treturn nil\n}\n",
+		"server/server.go": "package server\n\ntype Server struct{}\n\nfunc (s *Server) Start() {}\n",
+		"config.json":      `{"write_quota": 1}`,
+	})
+	ix := New(root)
+
+	answer := "The config is read by `LoadConfig` in config/load.go:4, which `Server.Start()` calls.\n" +
+		"Set `write_quota` in config.json. The retry logic lives in `RetryWithBackoff` (internal/retry/retry.go).\n" +
+		"See load.go and https://example.com/docs/setup.md, or run `go test` for v1.2 of e.g. the `main` package.\n" +
+		"You could create `NewLoader` in config/loader.go.\n" +
+		"```go\nfunc Missing() { other.go }\n```\n" +
+		"`ParseFlags` is gone; server/main.go too.\n"
+
+	missing, err := ix.MissingReferences(answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"`ParseFlags`", "`RetryWithBackoff`", "internal/retry/retry.go", "server/main.go"}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("MissingReferences = %q, want %q", missing, want)
+	}
+}
@@ -0,0 +1,327 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxSymbolsPerFile bounds the definitions kept for one file, such as a generated one
+const maxSymbolsPerFile = 2000
+
+// symbolEntry is a definition found in an indexed file
+type symbolEntry struct {
+	Name      string
+	Kind      string
+	Container string // Receiver or enclosing type, when known
+	Line      int
+}
+
+// Symbol is a definition returned by FindDefinitions
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Container string `json:"container,omitempty"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+}
+
+// symbolPattern finds one kind of definition. The identifier is the "name" group and
+// an enclosing type, when the syntax shows it, the "container" group.
+type symbolPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+func patterns(defs ...string) []symbolPattern {
+	var out []symbolPattern
+	for i := 0; i < len(defs); i += 2 {
+		out = append(out, symbolPattern{kind: defs[i], re: regexp.MustCompile(defs[i+1])})
+	}
+	return out
+}
+
+var (
+	goSymbols = patterns(
+		"method", `^func\s+\(\s*(?:\w+\s+)?\*?(?P<container>\w+)(?:\[[^\]]*\])?\s*\)\s*(?P<name>\w+)`,
+		"function", `^func\s+(?P<name>\w+)`,
+		"type", `^type\s+(?P<name>\w+)`,
+		"const", `^const\s+(?P<name>\w+)`,
+		"var", `^var\s+(?P<name>\w+)`,
+	)
+	pythonSymbols = patterns(
+		"class", `^\s*class\s+(?P<name>\w+)`,
+		"method", `^\s+(?:async\s+)?def\s+(?P<name>\w+)`,
+		"function", `^(?:async\s+)?def\s+(?P<name>\w+)`,
+		"const", `^(?P<name>[A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=`,
+	)
+	jsSymbols = patterns(
+		"function", `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>[\w$]+)`,
+		"class", `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>[\w$]+)`,
+		"interface", `^\s*(?:export\s+)?interface\s+(?P<name>[\w$]+)`,
+		"type", `^\s*(?:export\s+)?type\s+(?P<name>[\w$]+)\s*(?:<[^=]*>)?\s*=`,
+		"enum", `^\s*(?:export\s+)?(?:const\s+)?enum\s+(?P<name>[\w$]+)`,
+		"function", `^\s*(?:export\s+)?(?:const|let|var)\s+(?P<name>[\w$]+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[\w$]+\s*=>)`,
+		"const", `^(?:export\s+)?(?:const|let|var)\s+(?P<name>[\w$]+)`,
+	)
+	rustSymbols = patterns(
+		"function", `^\s*(?:pub(?:\([\w:]+\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"\w+"\s+)?fn\s+(?P<name>\w+)`,
+		"struct", `^\s*(?:pub(?:\([\w:]+\))?\s+)?struct\s+(?P<name>\w+)`,
+		"enum", `^\s*(?:pub(?:\([\w:]+\))?\s+)?enum\s+(?P<name>\w+)`,
+		"trait", `^\s*(?:pub(?:\([\w:]+\))?\s+)?(?:unsafe\s+)?trait\s+(?P<name>\w+)`,
+		"type", `^\s*(?:pub(?:\([\w:]+\))?\s+)?type\s+(?P<name>\w+)`,
+		"const", `^\s*(?:pub(?:\([\w:]+\))?\s+)?(?:const|static)\s+(?:mut\s+)?(?P<name>\w+)`,
+		"module", `^\s*(?:pub(?:\([\w:]+\))?\s+)?mod\s+(?P<name>\w+)`,
+		"macro", `^\s*macro_rules!\s*(?P<name>\w+)`,
+	)
+	javaSymbols = patterns(
+		"class", `^\s*(?:(?:public|protected|private|abstract|static|final|sealed|data|open|internal)\s+)*(?:class|record|object)\s+(?P<name>\w+)`,
+		"interface", `^\s*(?:(?:public|protected|private|abstract|static|sealed|fun)\s+)*interface\s+(?P<name>\w+)`,
+		"enum", `^\s*(?:(?:public|protected|private|static)\s+)*enum\s+(?:class\s+)?(?P<name>\w+)`,
+		"function", `^\s*(?:(?:public|protected|private|internal|override|suspend|inline|open)\s+)*fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(?P<name>\w+)`,
+		"method", `^\s*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default)\s+)+(?:<[^>]*>\s*)?[\w.<>\[\], ?]+\s+(?P<name>\w+)\s*\(`,
+	)
+	cSymbols = patterns(
+		"macro", `^\s*#\s*define\s+(?P<name>\w+)`,
+		"type", `^\s*(?:typedef\s+)?(?:struct|union|enum|class)\s+(?P<name>\w+)\s*(?:[:{]|$)`,
+		"type", `^\s*typedef\s+.*?\b(?P<name>\w+)\s*;\s*$`,
+		"function", `^(?:[A-Za-z_][\w:<>,*&]*\s+)+\**&?(?:(?P<container>\w+)::)?(?P<name>~?\w+)\s*\([^;]*$`,
+	)
+	rubySymbols = patterns(
+		"class", `^\s*class\s+(?:\w+::)*(?P<name>\w+)`,
+		"module", `^\s*module\s+(?:\w+::)*(?P<name>\w+)`,
+		"method", `^\s*def\s+(?:self\.)?(?P<name>\w+[?!=]?)`,
+	)
+)
+
+// symbolsByExt maps file extensions to the definitions extracted from them
+var symbolsByExt = map[string][]symbolPattern{
+	".go":   goSymbols,
+	".py":   pythonSymbols,
+	".js":   jsSymbols,
+	".jsx":  jsSymbols,
+	".mjs":  jsSymbols,
+	".cjs":  jsSymbols,
+	".ts":   jsSymbols,
+	".tsx":  jsSymbols,
+	".rs":   rustSymbols,
+	".java": javaSymbols,
+	".kt":   javaSymbols,
+	".cs":   javaSymbols,
+	".c":    cSymbols,
+	".h":    cSymbols,
+	".cc":   cSymbols,
+	".cpp":  cSymbols,
+	".hpp":  cSymbols,
+	".rb":   rubySymbols,
+}
+
+// cKeywords are statements the C function pattern can mistake for a definition
+var cKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true, "else": true}
+
+// goBlockPattern opens a parenthesized Go declaration, e.g. "const (", and
+// goBlockEntryPattern matches the names declared inside it
+var (
+	goBlockPattern      = regexp.MustCompile(`^(type|const|var)\s*\(\s*$`)
+	goBlockEntryPattern = regexp.MustCompile(`^\s+(?P<name>[A-Za-z_]\w*)\b`)
+)
+
+// extractSymbols returns the definitions in a file, using patterns chosen by its
+// extension. Files in languages without patterns have no symbols.
+func extractSymbols(path string, data []byte) []symbolEntry {
+	ext := strings.ToLower(filepath.Ext(path))
+	defs, ok := symbolsByExt[ext]
+	if !ok {
+		return nil
+	}
+
+	var symbols []symbolEntry
+	goBlock := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxIndexedFileSize)
+	for line := 1; scanner.Scan() && len(symbols) < maxSymbolsPerFile; line++ {
+		text := scanner.Text()
+
+		// Go groups declarations in blocks that the line patterns cannot see into
+		if ext == ".go" {
+			if goBlock != "" {
+				if strings.HasPrefix(text, ")") {
+					goBlock = ""
+				} else if m := goBlockEntryPattern.FindStringSubmatch(text); m != nil && strings.HasPrefix(text, "\t") && !strings.HasPrefix(text, "\t\t") {
+					symbols = append(symbols, symbolEntry{Name: m[1], Kind: goBlock, Line: line})
+				}
+				continue
+			}
+			if m := goBlockPattern.FindStringSubmatch(text); m != nil {
+				goBlock = m[1]
+				continue
+			}
+		}
+
+		for _, def := range defs {
+			m := def.re.FindStringSubmatch(text)
+			if m == nil {
+				continue
+			}
+			s := symbolEntry{Kind: def.kind, Line: line}
+			for i, group := range def.re.SubexpNames() {
+				switch group {
+				case "name":
+					s.Name = m[i]
+				case "container":
+					s.Container = m[i]
+				}
+			}
+			if s.Name == "" || cKeywords[s.Name] {
+				break
+			}
+			symbols = append(symbols, s)
+			break
+		}
+	}
+	return symbols
+}
+
+// SectionStarts returns the lines, 1-based, where the top-level definitions of a file
+// start, found by the patterns of the symbol table. Files in languages without
+// patterns have none.
+func SectionStarts(path string, data []byte) []int {
+	lines := bytes.Split(data, []byte("\n"))
+	var starts []int
+	for _, s := range extractSymbols(path, data) {
+		if s.Line > len(lines) || len(starts) > 0 && starts[len(starts)-1] == s.Line {
+			continue
+		}
+		// Indented definitions, such as methods in a class, stay with their parent
+		if line := lines[s.Line-1]; len(line) > 0 && line[0] != ' ' && line[0] != '\t' {
+			starts = append(starts, s.Line)
+		}
+	}
+	return starts
+}
+
+// FindDefinitions refreshes the index and returns where name is defined. A name of
+// the form "Type.Method" matches methods of that type only. Exact matches are
+// preferred; without any, names differing only in case are returned. Kind and path,
+// when set, narrow the results to that kind of symbol and files under that
+// slash-separated path.
+func (ix *Index) FindDefinitions(name, kind, path string, limit int) ([]Symbol, error) {
+	if _, err := ix.Refresh(); err != nil {
+		return nil, err
+	}
+	container := ""
+	if i := strings.LastIndex(name, "."); i > 0 && i < len(name)-1 {
+		container, name = name[:i], name[i+1:]
+		container = container[strings.LastIndex(container, ".")+1:]
+	}
+	prefix := strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	if prefix == "." {
+		prefix = ""
+	}
+
+	ix.mu.Lock()
+	var exact, folded []Symbol
+	for id, symbols := range ix.symbols {
+		file := ix.files[id].Path
+		if file == "" || (prefix != "" && file != prefix && !strings.HasPrefix(file, prefix+"/")) {
+			continue
+		}
+		for _, s := range symbols {
+			if kind != "" && s.Kind != kind {
+				continue
+			}
+			if container != "" && !strings.EqualFold(s.Container, container) {
+				continue
+			}
+			symbol := Symbol{Name: s.Name, Kind: s.Kind, Container: s.Container, Path: file, Line: s.Line}
+			switch {
+			case s.Name == name:
+				exact = append(exact, symbol)
+			case strings.EqualFold(s.Name, name):
+				folded = append(folded, symbol)
+			}
+		}
+	}
+	ix.mu.Unlock()
+
+	found := exact
+	if len(found) == 0 {
+		found = folded
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Path != found[j].Path {
+			return found[i].Path < found[j].Path
+		}
+		return found[i].Line < found[j].Line
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	ix.fillText(found)
+	return found, nil
+}
+
+// fillText sets the source line of each symbol, reading every file once
+func (ix *Index) fillText(symbols []Symbol) {
+	var lines []string
+	current := ""
+	for i := range symbols {
+		if symbols[i].Path != current {
+			current = symbols[i].Path
+			data, err := os.ReadFile(ix.abs(current))
+			if err != nil {
+				lines = nil
+				continue
+			}
+			lines = strings.Split(string(data), "\n")
+		}
+		if n := symbols[i].Line; n <= len(lines) {
+			symbols[i].Text, _ = truncateLine(strings.TrimSpace(lines[n-1]), nil)
+		}
+	}
+}
+
+// definitionLines returns the names defined on each line of a file's symbols
+func definitionLines(symbols []symbolEntry) map[int][]string {
+	if len(symbols) == 0 {
+		return nil
+	}
+	lines := make(map[int][]string, len(symbols))
+	for _, s := range symbols {
+		lines[s.Line] = append(lines[s.Line], s.Name)
+	}
+	return lines
+}
+
+// definesName reports whether one of names appears as a whole word in line at a
+// position covered by a match range
+func definesName(line string, names []string, ranges [][2]int) bool {
+	for _, name := range names {
+		for from := 0; ; {
+			i := strings.Index(line[from:], name)
+			if i < 0 {
+				break
+			}
+			start, end := from+i, from+i+len(name)
+			from = end
+			if (start > 0 && isWordByte(line[start-1])) || (end < len(line) && isWordByte(line[end])) {
+				continue
+			}
+			for _, rg := range ranges {
+				if rg[0] < end && rg[1] > start {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '$' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
@@ -0,0 +1,207 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// Tool lets the agent search the project's files through the index
+type Tool struct {
+	index *Index
+}
+
+// NewTool creates a codeSearch tool backed by index
+func NewTool(index *Index) *Tool {
+	return &Tool{index: index}
+}
+
+// Name returns the tool name
+func (t *Tool) Name() string {
+	return "codeSearch"
+}
+
+// Description returns the tool description
+func (t *Tool) Description() string {
+	return "Searches the contents of the project's files through an index and returns the best matching files with their matching lines. All terms must match; use \"a OR b\" for either, -term to exclude, \"quoted phrase\" for exact text and prefix* for words starting with prefix; matching is case-insensitive. Start the query with regex: to search for a Go regular expression instead. Results are ranked best first with a score, boosted when a term is in the file name, a match is on the line defining that symbol or the file changed recently (listed in signals). Matching lines come with the byte ranges of the matches, definition lines first"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *Tool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"query": {
+				Type:        "string",
+				Description: "Search query, e.g. `ParseConfig -test`, `\"connection refused\" OR timeout` or `regex:func \\w+Handler\\(`",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Only search files under this directory or file, such as pkg or lib:pkg in the root named lib (default: the whole project, all roots included)",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: "Maximum number of files to return (default: 20)",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+// Execute runs the search
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	query, _ := params["query"].(string)
+	opts := Options{}
+	if limit, ok := params["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	if path, _ := params["path"].(string); path != "" {
+		rel, err := relative(t.index, path)
+		if err != nil {
+			return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+		opts.Path = rel
+	}
+
+	if _, err := ParseQuery(query); err != nil {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	results, err := t.index.Search(query, opts)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "search failed", Err: err}
+	}
+	return map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+		"indexed": t.index.Stats().Files,
+	}, nil
+}
+
+// DefinitionTool lets the agent look up where a symbol is defined through the
+// index's symbol table
+type DefinitionTool struct {
+	index *Index
+}
+
+// NewDefinitionTool creates a findDefinition tool backed by index
+func NewDefinitionTool(index *Index) *DefinitionTool {
+	return &DefinitionTool{index: index}
+}
+
+// Name returns the tool name
+func (t *DefinitionTool) Name() string {
+	return "findDefinition"
+}
+
+// Description returns the tool description
+func (t *DefinitionTool) Description() string {
+	return "Finds where a function, method, type, class or constant is defined in the project, from a symbol table kept with the search index (Go, Python, JavaScript/TypeScript, Rust, Java/Kotlin/C#, C/C++ and Ruby). Use Type.Method to find a method of one type. Returns the file, line, kind and source line of each definition; names differing only in case are returned when there is no exact match"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *DefinitionTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"name": {
+				Type:        "string",
+				Description: "Symbol name, e.g. `ParseConfig` or `Server.Start`",
+			},
+			"kind": {
+				Type:        "string",
+				Description: "Only return definitions of this kind",
+				Enum:        []interface{}{"function", "method", "type", "class", "interface", "struct", "enum", "trait", "const", "var", "module", "macro"},
+			},
+			"path": {
+				Type:        "string",
+				Description: "Only search files under this directory or file, such as pkg or lib:pkg in the root named lib (default: the whole project, all roots included)",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: "Maximum number of definitions to return (default: 20)",
+			},
+		},
+		Required: []string{"name"},
+	}
+}
+
+// Execute looks up the definitions
+func (t *DefinitionTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	name, ok := params["name"].(string)
+	if !ok {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: "name must be a string"}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: "name must not be empty"}
+	}
+	kind, _ := params["kind"].(string)
+	limit := 20
+	if l, ok := params["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+	rel := ""
+	if path, _ := params["path"].(string); path != "" {
+		var err error
+		if rel, err = relative(t.index, path); err != nil {
+			return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+	}
+
+	definitions, err := t.index.FindDefinitions(name, kind, rel, limit)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "lookup failed", Err: err}
+	}
+	result := map[string]interface{}{
+		"definitions": definitions,
+		"count":       len(definitions),
+	}
+	if len(definitions) == 0 {
+		result["message"] = fmt.Sprintf("No definition of %s found; try codeSearch for other mentions", name)
+	}
+	return result, nil
+}
+
+// relative converts path to a slash-separated path relative to the index root, or
+// qualified with the name of the additional root containing it, such as lib:pkg
+func relative(index *Index, path string) (string, error) {
+	if name, rest, ok := strings.Cut(path, ":"); ok {
+		if dir, ok := index.rootDir(name); ok {
+			path = filepath.Join(dir, filepath.FromSlash(rest))
+		}
+	}
+	abs := path
+	if !filepath.IsAbs(path) {
+		abs = filepath.Join(index.Root(), path)
+	}
+	if rel, ok := within(index.Root(), abs); ok {
+		return rel, nil
+	}
+	for _, name := range index.rootNames() {
+		dir, _ := index.rootDir(name)
+		if rel, ok := within(dir, abs); ok {
+			return name + ":" + rel, nil
+		}
+	}
+	return "", fmt.Errorf("path %s is outside the project", path)
+}
+
+// within returns path relative to dir, slash-separated, when it is inside dir
+func within(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
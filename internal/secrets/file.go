@@ -0,0 +1,289 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// PassphraseEnv names the environment variable holding the file store passphrase.
+// Without it, a random key is kept in a separate 0600 key file.
+const PassphraseEnv = "CODEZILLA_SECRETS_PASSPHRASE"
+
+// pbkdf2Iterations is the key derivation work factor for passphrases
+const pbkdf2Iterations = 210000
+
+// FileStore keeps secrets in an AES-GCM encrypted file. It is the fallback
+// when no OS credential store is available.
+type FileStore struct {
+	path    string
+	keyPath string
+	mu      sync.Mutex
+}
+
+// encryptedFile is the on-disk format of the secrets file
+type encryptedFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"` // "pbkdf2-sha256" or "keyfile"
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// NewFileStore creates a file store in dir
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{
+		path:    filepath.Join(dir, "secrets.enc"),
+		keyPath: filepath.Join(dir, "secrets.key"),
+	}
+}
+
+// Name returns the backend name
+func (s *FileStore) Name() string {
+	return BackendFile
+}
+
+// Get returns the secret stored under key
+func (s *FileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, _, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores value under key
+func (s *FileStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, salt, err := s.load()
+	if err != nil {
+		return err
+	}
+	values[key] = value
+	return s.save(values, salt)
+}
+
+// Delete removes the secret stored under key
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, salt, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return ErrNotFound
+	}
+	delete(values, key)
+	return s.save(values, salt)
+}
+
+// Keys returns the names of all stored secrets
+func (s *FileStore) Keys() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// load decrypts the secrets file, returning an empty map if it does not exist
+func (s *FileStore) load() (map[string]string, []byte, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+
+	key, err := s.key(file.KDF, file.Salt, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt secrets file (wrong passphrase or key file?)")
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse decrypted secrets: %w", err)
+	}
+	return values, file.Salt, nil
+}
+
+// save encrypts values and writes the secrets file atomically
+func (s *FileStore) save(values map[string]string, salt []byte) error {
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+
+	kdf := "keyfile"
+	if os.Getenv(PassphraseEnv) != "" {
+		kdf = "pbkdf2-sha256"
+	}
+	key, err := s.key(kdf, salt, true)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(encryptedFile{
+		Version: 1,
+		KDF:     kdf,
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data)
+}
+
+// key returns the encryption key, derived from the passphrase or read from the key file
+func (s *FileStore) key(kdf string, salt []byte, create bool) ([]byte, error) {
+	if kdf == "pbkdf2-sha256" {
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("secrets file is passphrase protected: set %s", PassphraseEnv)
+		}
+		return pbkdf2SHA256([]byte(passphrase), salt, pbkdf2Iterations, 32), nil
+	}
+
+	key, err := os.ReadFile(s.keyPath)
+	if err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if !create {
+		return nil, fmt.Errorf("secrets key file %s is missing or invalid", s.keyPath)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(s.keyPath, key); err != nil {
+		return nil, fmt.Errorf("failed to write secrets key file: %w", err)
+	}
+	return key, nil
+}
+
+// newGCM creates an AES-256-GCM cipher
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a key from a password (RFC 8018)
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	var derived []byte
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+		t := make([]byte, len(u))
+		copy(t, u)
+
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		derived = append(derived, t...)
+	}
+	return derived[:keyLen]
+}
+
+// writeFileAtomic writes data with 0600 permissions via a temporary file
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	got := hex.EncodeToString(pbkdf2SHA256([]byte("password"), []byte("salt"), 4096, 32))
+	want := "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"
+	if got != want {
+		t.Fatalf("pbkdf2SHA256() = %s, want %s", got, want)
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	dir := t.TempDir()
+	store := NewFileStore(dir)
+
+	if _, err := store.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := store.Set("ollama_api_key", "sk-123"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("token", "abc"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// A fresh store must read what the first one wrote
+	got, err := NewFileStore(dir).Get("ollama_api_key")
+	if err != nil || got != "sk-123" {
+		t.Fatalf("Get() = %q, %v, want sk-123", got, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "secrets.enc"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "sk-123") {
+		t.Fatalf("secrets file contains the plaintext value")
+	}
+	for _, name := range []string{"secrets.enc", "secrets.key"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Stat(%s) error = %v", name, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s permissions = %o, want 600", name, perm)
+		}
+	}
+
+	if err := store.Delete("token"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	keys, err := store.Keys()
+	if err != nil || len(keys) != 1 || keys[0] != "ollama_api_key" {
+		t.Fatalf("Keys() = %v, %v, want [ollama_api_key]", keys, err)
+	}
+}
+
+func TestFileStorePassphrase(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	if err := NewFileStore(dir).Set("token", "abc"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "secrets.key")); !os.IsNotExist(err) {
+		t.Fatalf("passphrase mode should not write a key file")
+	}
+	if got, err := NewFileStore(dir).Get("token"); err != nil || got != "abc" {
+		t.Fatalf("Get() = %q, %v, want abc", got, err)
+	}
+
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := NewFileStore(dir).Get("token"); err == nil {
+		t.Fatalf("Get() with wrong passphrase should fail")
+	}
+
+	t.Setenv(PassphraseEnv, "")
+	if _, err := NewFileStore(dir).Get("token"); err == nil || !strings.Contains(err.Error(), PassphraseEnv) {
+		t.Fatalf("Get() without passphrase error = %v, want hint about %s", err, PassphraseEnv)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{"ollama_api_key", false},
+		{"github.token-2", false},
+		{"", true},
+		{"has space", true},
+		{"semi;colon", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateKey(tt.key); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychainStore keeps secrets in the macOS login keychain via the security tool
+type keychainStore struct{}
+
+// Name returns the backend name
+func (s *keychainStore) Name() string {
+	return BackendKeychain
+}
+
+// Get returns the secret stored under key
+func (s *keychainStore) Get(key string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", key, "-w").Output()
+	if err != nil {
+		// Exit status 44 means the item could not be found
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 44 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("keychain lookup failed: %w", err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// Set stores value under key
+func (s *keychainStore) Set(key, value string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	// Feed the command through interactive mode so the value never appears in
+	// the process list; -X takes the password hex encoded, avoiding any quoting
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		Service, key, hex.EncodeToString([]byte(value))))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("keychain store failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Delete removes the secret stored under key
+func (s *keychainStore) Delete(key string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", Service, "-a", key).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 44 {
+		return ErrNotFound
+	}
+	return err
+}
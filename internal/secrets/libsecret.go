@@ -0,0 +1,53 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// libsecretStore keeps secrets in the freedesktop secret service (GNOME Keyring, KWallet)
+// via secret-tool
+type libsecretStore struct{}
+
+// Name returns the backend name
+func (s *libsecretStore) Name() string {
+	return BackendLibsecret
+}
+
+// Get returns the secret stored under key
+func (s *libsecretStore) Get(key string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", Service, "account", key)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits 1 without output when nothing matches
+		if _, ok := err.(*exec.ExitError); ok && stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret-tool lookup failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// Set stores value under key
+func (s *libsecretStore) Set(key, value string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", Service+" "+key, "service", Service, "account", key)
+	cmd.Stdin = strings.NewReader(value)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("secret-tool store failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Delete removes the secret stored under key
+func (s *libsecretStore) Delete(key string) error {
+	if _, err := s.Get(key); err != nil {
+		return err
+	}
+	return exec.Command("secret-tool", "clear", "service", Service, "account", key).Run()
+}
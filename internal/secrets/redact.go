@@ -0,0 +1,76 @@
+package secrets
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minRedactedLength is the shortest known value redacted; shorter ones would match
+// ordinary words
+const minRedactedLength = 6
+
+// credentialPatterns match credentials in text even when their value is not known:
+// well-known token formats, private keys and URL passwords
+var credentialPatterns = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), "<redacted private key>"},
+	{regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}`), "<redacted>"},
+	{regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr|github_pat|glpat|xox[abprs])[-_][A-Za-z0-9_-]{16,}`), "<redacted>"},
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`), "<redacted>"},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), "<redacted jwt>"},
+	{regexp.MustCompile(`(?i)(\b(?:bearer|basic|token)\s+)[A-Za-z0-9._~+/=-]{16,}`), "${1}<redacted>"},
+	{regexp.MustCompile(`(://[^:/@\s]+:)[^@/\s]+@`), "${1}<redacted>@"},
+}
+
+// assignmentPattern matches a value assigned to a credential-like name, as in
+// password=..., "api_key": "..." or TOKEN: ...
+var assignmentPattern = regexp.MustCompile(`(?i)(\b[\w.-]*(?:secret|token|passw(?:or)?d|pwd|api_?key|access_?key|private_?key|credential)[\w.-]*["']?\s*[:=]\s*)("[^"\n]+"|'[^'\n]+'|[^\s"',;]{4,})`)
+
+// numberPattern matches values such as token counts, which are not secrets
+var numberPattern = regexp.MustCompile(`^["']?[\d.,_]+["']?$`)
+
+// Redactor hides credentials in text before it leaves the machine
+type Redactor struct {
+	known []string
+}
+
+// NewRedactor creates a redactor that hides the given values, such as resolved API
+// keys and passwords, as well as anything that looks like a credential
+func NewRedactor(values ...string) *Redactor {
+	r := &Redactor{}
+	seen := make(map[string]bool)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) >= minRedactedLength && !seen[v] {
+			seen[v] = true
+			r.known = append(r.known, v)
+		}
+	}
+	// Longest first, so a value containing another is hidden whole
+	sort.Slice(r.known, func(i, j int) bool { return len(r.known[i]) > len(r.known[j]) })
+	return r
+}
+
+// Redact returns text with known values and credential-like strings replaced
+func (r *Redactor) Redact(text string) string {
+	for _, v := range r.known {
+		text = strings.ReplaceAll(text, v, "<redacted>")
+	}
+	for _, p := range credentialPatterns {
+		text = p.pattern.ReplaceAllString(text, p.replace)
+	}
+	return assignmentPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := assignmentPattern.FindStringSubmatch(match)
+		value := m[2]
+		if numberPattern.MatchString(value) || strings.Contains(value, "<redacted") {
+			return match
+		}
+		if quote := value[0]; quote == '"' || quote == '\'' {
+			return m[1] + string(quote) + "<redacted>" + string(quote)
+		}
+		return m[1] + "<redacted>"
+	})
+}
@@ -0,0 +1,107 @@
+// Package secrets stores credentials such as API keys outside the plaintext config file.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service is the name secrets are stored under in OS credential stores
+const Service = "codezilla"
+
+// Backend names accepted by Open
+const (
+	BackendAuto      = "auto"
+	BackendKeychain  = "keychain"
+	BackendLibsecret = "libsecret"
+	BackendWincred   = "wincred"
+	BackendFile      = "file"
+	BackendNone      = "none"
+)
+
+var (
+	// ErrNotFound is returned when a secret does not exist
+	ErrNotFound = errors.New("secret not found")
+	// ErrUnavailable is returned when a backend cannot be used on this system
+	ErrUnavailable = errors.New("secrets backend unavailable")
+)
+
+// Store reads and writes named secrets
+type Store interface {
+	// Name returns the backend name
+	Name() string
+	// Get returns the secret stored under key, or ErrNotFound
+	Get(key string) (string, error)
+	// Set stores value under key, replacing any existing value
+	Set(key, value string) error
+	// Delete removes the secret stored under key
+	Delete(key string) error
+}
+
+// ValidateKey checks that a secret name is safe to pass to every backend
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("secret name must not be empty")
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			return fmt.Errorf("invalid secret name %q: use letters, digits, '_', '-' and '.'", key)
+		}
+	}
+	return nil
+}
+
+// Open returns the requested backend. With BackendAuto (or ""), the OS credential
+// store is used when available and the encrypted file in dir otherwise.
+func Open(backend, dir string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendAuto:
+		if store := osStore(); store != nil {
+			return store, nil
+		}
+		return NewFileStore(dir), nil
+	case BackendKeychain:
+		if _, err := exec.LookPath("security"); err != nil || runtime.GOOS != "darwin" {
+			return nil, fmt.Errorf("%w: keychain requires macOS", ErrUnavailable)
+		}
+		return &keychainStore{}, nil
+	case BackendLibsecret:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, fmt.Errorf("%w: secret-tool (libsecret-tools) is not installed", ErrUnavailable)
+		}
+		return &libsecretStore{}, nil
+	case BackendWincred:
+		if !wincredAvailable() {
+			return nil, fmt.Errorf("%w: Windows Credential Manager requires Windows", ErrUnavailable)
+		}
+		return &wincredStore{}, nil
+	case BackendFile:
+		return NewFileStore(dir), nil
+	case BackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q (expected auto, keychain, libsecret, wincred, file or none)", backend)
+	}
+}
+
+// osStore returns the native credential store for this OS, or nil if there is none
+func osStore() Store {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return &keychainStore{}
+		}
+	case "windows":
+		if wincredAvailable() {
+			return &wincredStore{}
+		}
+	default:
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return &libsecretStore{}
+		}
+	}
+	return nil
+}
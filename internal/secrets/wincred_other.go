@@ -0,0 +1,31 @@
+//go:build !windows
+
+package secrets
+
+// wincredAvailable reports whether the Credential Manager API can be loaded
+func wincredAvailable() bool {
+	return false
+}
+
+// wincredStore is only functional on Windows
+type wincredStore struct{}
+
+// Name returns the backend name
+func (s *wincredStore) Name() string {
+	return BackendWincred
+}
+
+// Get always fails outside Windows
+func (s *wincredStore) Get(key string) (string, error) {
+	return "", ErrUnavailable
+}
+
+// Set always fails outside Windows
+func (s *wincredStore) Set(key, value string) error {
+	return ErrUnavailable
+}
+
+// Delete always fails outside Windows
+func (s *wincredStore) Delete(key string) error {
+	return ErrUnavailable
+}
@@ -0,0 +1,124 @@
+//go:build windows
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential mirrors the Win32 CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// wincredAvailable reports whether the Credential Manager API can be loaded
+func wincredAvailable() bool {
+	return advapi32.Load() == nil
+}
+
+// wincredStore keeps secrets in the Windows Credential Manager
+type wincredStore struct{}
+
+// Name returns the backend name
+func (s *wincredStore) Name() string {
+	return BackendWincred
+}
+
+// target returns the credential target name for key
+func (s *wincredStore) target(key string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(Service + ":" + key)
+}
+
+// Get returns the secret stored under key
+func (s *wincredStore) Get(key string) (string, error) {
+	target, err := s.target(key)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	ret, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if errors.Is(callErr, errorNotFound) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("CredRead failed: %w", callErr)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+// Set stores value under key
+func (s *wincredStore) Set(key, value string) error {
+	target, err := s.target(key)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(value)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	ret, _, callErr := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return fmt.Errorf("CredWrite failed: %w", callErr)
+	}
+	return nil
+}
+
+// Delete removes the secret stored under key
+func (s *wincredStore) Delete(key string) error {
+	target, err := s.target(key)
+	if err != nil {
+		return err
+	}
+	ret, _, callErr := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if ret == 0 {
+		if errors.Is(callErr, errorNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("CredDelete failed: %w", callErr)
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"codezilla/internal/tools"
+	"codezilla/internal/workspace"
+)
+
+var (
+	// ErrNotFound is returned when no session matches an ID
+	ErrNotFound = errors.New("session not found")
+	// ErrAmbiguous is returned when an ID prefix matches several sessions
+	ErrAmbiguous = errors.New("session ID prefix is ambiguous")
+)
+
+// Message is a persisted conversation message
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Timestamp time.Time  `json:"timestamp"`
+	Citations []Citation `json:"citations,omitempty"` // Sources an assistant message cites
+}
+
+// Citation is a tool result an assistant message cites, by its footnote number
+type Citation struct {
+	Number    int    `json:"number"`
+	Tool      string `json:"tool"`
+	Path      string `json:"path,omitempty"`
+	Command   string `json:"command,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// Session is a persisted conversation
+type Session struct {
+	ID          string       `json:"id"`
+	Title       string       `json:"title"`
+	ManualTitle bool         `json:"manual_title,omitempty"`
+	Model       string       `json:"model"`
+	WorkingDir  string       `json:"working_dir"`
+	Created     time.Time    `json:"created"`
+	Updated     time.Time    `json:"updated"`
+	Messages    []Message    `json:"messages"`
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
+	ForkedFrom  string       `json:"forked_from,omitempty"` // "<session ID>@<checkpoint>" for forked sessions
+	Notes       []tools.Note `json:"notes,omitempty"`       // Scratchpad notes of the session
+	// Fingerprint is the state of the project when the session was last saved
+	Fingerprint *workspace.Fingerprint `json:"fingerprint,omitempty"`
+}
+
+// Checkpoint is a named point in a session that a new session can be forked from
+type Checkpoint struct {
+	Name     string             `json:"name"`
+	Created  time.Time          `json:"created"`
+	Messages int                `json:"messages"`        // Number of session messages at the checkpoint
+	Notes    []tools.Note       `json:"notes,omitempty"` // Scratchpad notes at the checkpoint
+	Files    workspace.Snapshot `json:"files"`
+}
+
+// Info is the summary of a session shown in listings
+type Info struct {
+	ID           string
+	Title        string
+	WorkingDir   string
+	Updated      time.Time
+	MessageCount int
+}
+
+// New creates an empty session with a fresh ID
+func New(model, workingDir string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         newID(now),
+		Model:      model,
+		WorkingDir: workingDir,
+		Created:    now,
+		Updated:    now,
+	}
+}
+
+// newID returns a sortable, human-readable session ID
+func newID(now time.Time) string {
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return now.Format("20060102-150405")
+	}
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// AddMessage appends a message to the session
+func (s *Session) AddMessage(role, content string) {
+	now := time.Now()
+	s.Messages = append(s.Messages, Message{Role: role, Content: content, Timestamp: now})
+	s.Updated = now
+}
+
+// SetTitle sets the session title. Automatic titles never replace a title set by the user.
+func (s *Session) SetTitle(title string, manual bool) bool {
+	title = strings.TrimSpace(title)
+	if title == "" || (!manual && s.ManualTitle) {
+		return false
+	}
+	s.Title = title
+	s.ManualTitle = s.ManualTitle || manual
+	return true
+}
+
+var checkpointNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// ValidCheckpointName reports whether name can name a checkpoint: letters, digits,
+// "-", "_" and single dots, so it is also usable in git ref and file names
+func ValidCheckpointName(name string) bool {
+	return checkpointNamePattern.MatchString(name) && !strings.HasSuffix(name, ".lock")
+}
+
+// Checkpoint returns the checkpoint with the given name
+func (s *Session) Checkpoint(name string) (Checkpoint, bool) {
+	for _, cp := range s.Checkpoints {
+		if cp.Name == name {
+			return cp, true
+		}
+	}
+	return Checkpoint{}, false
+}
+
+// SetCheckpoint adds a checkpoint, replacing any with the same name
+func (s *Session) SetCheckpoint(cp Checkpoint) {
+	for i := range s.Checkpoints {
+		if s.Checkpoints[i].Name == cp.Name {
+			s.Checkpoints[i] = cp
+			return
+		}
+	}
+	s.Checkpoints = append(s.Checkpoints, cp)
+	s.Updated = time.Now()
+}
+
+// Fork creates a new session holding the conversation and notes up to the named
+// checkpoint, along with the checkpoints taken before it
+func (s *Session) Fork(name string) (*Session, error) {
+	cp, ok := s.Checkpoint(name)
+	if !ok {
+		return nil, fmt.Errorf("no checkpoint named %q", name)
+	}
+	forked := New(s.Model, s.WorkingDir)
+	forked.Messages = append([]Message(nil), s.Messages[:min(cp.Messages, len(s.Messages))]...)
+	forked.Notes = append([]tools.Note(nil), cp.Notes...)
+	for _, other := range s.Checkpoints {
+		if !other.Created.After(cp.Created) {
+			forked.Checkpoints = append(forked.Checkpoints, other)
+		}
+	}
+	forked.ForkedFrom = s.ID + "@" + name
+	title := s.Title
+	if title == "" {
+		title = s.FallbackTitle()
+	}
+	if title != "" {
+		forked.SetTitle(title+" (fork: "+name+")", s.ManualTitle)
+	}
+	return forked, nil
+}
+
+// FallbackTitle derives a title from the first user message
+func (s *Session) FallbackTitle() string {
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			return TruncateTitle(msg.Content)
+		}
+	}
+	return ""
+}
+
+// TruncateTitle shortens text to a single line suitable for a title
+func TruncateTitle(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	const maxLen = 60
+	if runes := []rune(text); len(runes) > maxLen {
+		return strings.TrimSpace(string(runes[:maxLen-3])) + "..."
+	}
+	return text
+}
+
+// Store persists sessions as JSON files in a directory
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore creates a session store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory sessions are stored in
+func (st *Store) Dir() string {
+	return st.dir
+}
+
+// path returns the file path of a session
+func (st *Store) path(id string) string {
+	return filepath.Join(st.dir, id+".json")
+}
+
+// Save writes a session to disk atomically
+func (st *Store) Save(s *Session) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if err := os.MkdirAll(st.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(st.dir, "."+s.ID+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), st.path(s.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Load reads a session by ID or unique ID prefix
+func (st *Store) Load(id string) (*Session, error) {
+	fullID, err := st.Resolve(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(st.path(fullID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", fullID, err)
+	}
+	return &s, nil
+}
+
+// Rename sets a manual title on a stored session
+func (st *Store) Rename(id, title string) (*Session, error) {
+	s, err := st.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.SetTitle(title, true) {
+		return nil, fmt.Errorf("title must not be empty")
+	}
+	return s, st.Save(s)
+}
+
+// Delete removes a stored session
+func (st *Store) Delete(id string) error {
+	fullID, err := st.Resolve(id)
+	if err != nil {
+		return err
+	}
+	return os.Remove(st.path(fullID))
+}
+
+// List returns summaries of all stored sessions, most recently updated first
+func (st *Store) List() ([]Info, error) {
+	ids, err := st.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Info, 0, len(ids))
+	for _, id := range ids {
+		s, err := st.Load(id)
+		if err != nil {
+			// Skip unreadable files rather than failing the whole listing
+			continue
+		}
+		title := s.Title
+		if title == "" {
+			title = s.FallbackTitle()
+		}
+		infos = append(infos, Info{
+			ID:           s.ID,
+			Title:        title,
+			WorkingDir:   s.WorkingDir,
+			Updated:      s.Updated,
+			MessageCount: len(s.Messages),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Updated.After(infos[j].Updated)
+	})
+	return infos, nil
+}
+
+// ids returns the IDs of all stored sessions
+func (st *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(st.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	var ids []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	return ids, nil
+}
+
+// Resolve expands an ID prefix to the full ID of a stored session
+func (st *Store) Resolve(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "", ErrNotFound
+	}
+
+	ids, err := st.ids()
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, candidate := range ids {
+		if candidate == id {
+			return candidate, nil
+		}
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, id)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %s matches %d sessions", ErrAmbiguous, id, len(matches))
+	}
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"codezilla/internal/tools"
+)
+
+func TestStoreSaveListRename(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	first := New("qwen3:14b", "/work/a")
+	first.ID = "20260101-100000-aaaa"
+	first.AddMessage("user", "Why does the parser panic on empty input?")
+	first.AddMessage("assistant", "Because of an unchecked index.")
+	if err := store.Save(first); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	second := New("qwen3:14b", "/work/b")
+	second.ID = "20260102-100000-bbbb"
+	second.SetTitle("Add retry logic", false)
+	second.AddMessage("user", "add retries")
+	if err := store.Save(second); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	infos, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(infos))
+	}
+	if infos[0].ID != second.ID {
+		t.Errorf("expected most recent session first, got %s", infos[0].ID)
+	}
+	if infos[1].Title != "Why does the parser panic on empty input?" {
+		t.Errorf("expected fallback title from first message, got %q", infos[1].Title)
+	}
+
+	renamed, err := store.Rename("20260101", "Parser panic")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if !renamed.ManualTitle {
+		t.Error("expected renamed session to have a manual title")
+	}
+	if renamed.SetTitle("Automatic title", false) {
+		t.Error("automatic titles must not replace manual ones")
+	}
+
+	if _, err := store.Load("2026"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ambiguous prefix error, got %v", err)
+	}
+	if _, err := store.Load("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Fix parser panic", "Fix parser panic"},
+		{"<think>\nthe user asks about...\n</think>\n\n\"Debugging the Parser.\"", "Debugging the Parser"},
+		{"Title: **Add retries**\nextra", "Add retries"},
+	}
+	for _, tt := range tests {
+		if got := CleanTitle(tt.in); got != tt.want {
+			t.Errorf("CleanTitle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFork(t *testing.T) {
+	s := New("qwen3:14b", "/work/a")
+	s.SetTitle("Refactor the parser", true)
+	s.AddMessage("user", "split the parser")
+	s.AddMessage("assistant", "done")
+	s.SetCheckpoint(Checkpoint{Name: "split", Created: time.Now(), Messages: len(s.Messages), Notes: []tools.Note{{Text: "parser.go split in two"}}})
+	s.AddMessage("user", "now try a generator")
+	s.AddMessage("assistant", "it got worse")
+	s.SetCheckpoint(Checkpoint{Name: "generator", Created: time.Now().Add(time.Second), Messages: len(s.Messages)})
+
+	forked, err := s.Fork("split")
+	if err != nil {
+		t.Fatalf("Fork failed: %v", err)
+	}
+	if forked.ID == s.ID {
+		t.Error("a fork needs its own ID")
+	}
+	if len(forked.Messages) != 2 || forked.Messages[1].Content != "done" {
+		t.Errorf("fork should hold the conversation up to the checkpoint, got %v", forked.Messages)
+	}
+	if len(forked.Notes) != 1 || forked.Notes[0].Text != "parser.go split in two" {
+		t.Errorf("fork should hold the notes at the checkpoint, got %v", forked.Notes)
+	}
+	if len(forked.Checkpoints) != 1 || forked.Checkpoints[0].Name != "split" {
+		t.Errorf("fork should keep only earlier checkpoints, got %v", forked.Checkpoints)
+	}
+	if forked.Title != "Refactor the parser (fork: split)" || forked.ForkedFrom != s.ID+"@split" {
+		t.Errorf("fork title/origin = %q, %q", forked.Title, forked.ForkedFrom)
+	}
+
+	forked.AddMessage("user", "try a table instead")
+	if len(s.Messages) != 4 {
+		t.Error("forked messages must not alias the original session")
+	}
+	if _, err := s.Fork("missing"); err == nil {
+		t.Error("forking an unknown checkpoint should fail")
+	}
+}
+
+func TestValidCheckpointName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"before-refactor", true},
+		{"v1.2_try", true},
+		{"", false},
+		{"two words", false},
+		{"a..b", false},
+		{"../escape", false},
+		{"name.lock", false},
+	}
+	for _, tt := range tests {
+		if got := ValidCheckpointName(tt.name); got != tt.want {
+			t.Errorf("ValidCheckpointName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
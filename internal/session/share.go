@@ -0,0 +1,111 @@
+package session
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// Share formats
+const (
+	ShareMarkdown = "md"
+	ShareHTML     = "html"
+)
+
+// shareRoles names the message roles shown in a shared conversation
+var shareRoles = map[string]string{
+	"user":      "User",
+	"assistant": "Assistant",
+	"tool_call": "Tool call",
+	"tool":      "Tool result",
+}
+
+// Share renders the session as a single self-contained document for bug reports or
+// teammates: Markdown, or an HTML page with its styles inline. Callers redact the
+// session first; Share writes what it is given.
+func (s *Session) Share(format string, now time.Time) (string, error) {
+	switch format {
+	case ShareMarkdown, "markdown", "":
+		return s.shareMarkdown(now), nil
+	case ShareHTML:
+		return s.shareHTML(now), nil
+	}
+	return "", fmt.Errorf("unknown share format %q: use md or html", format)
+}
+
+// shareTitle returns the title shown at the top of a shared session
+func (s *Session) shareTitle() string {
+	if s.Title != "" {
+		return s.Title
+	}
+	return "Codezilla session " + s.ID
+}
+
+// shareDetails lists the session's metadata as label, value pairs
+func (s *Session) shareDetails(now time.Time) [][2]string {
+	return [][2]string{
+		{"Session", s.ID},
+		{"Model", s.Model},
+		{"Working directory", s.WorkingDir},
+		{"Started", s.Created.Format(time.RFC3339)},
+		{"Shared", now.Format(time.RFC3339)},
+	}
+}
+
+func (s *Session) shareMarkdown(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.shareTitle())
+	for _, d := range s.shareDetails(now) {
+		fmt.Fprintf(&b, "- **%s:** %s\n", d[0], d[1])
+	}
+	b.WriteString("\n> Secrets were redacted before sharing.\n")
+	for _, m := range s.Messages {
+		fmt.Fprintf(&b, "\n## %s\n\n", shareRole(m.Role))
+		if m.Role == "user" || m.Role == "assistant" {
+			b.WriteString(strings.TrimSpace(m.Content) + "\n")
+			continue
+		}
+		// Tool output is shown verbatim; a longer fence keeps backticks inside intact
+		fence := "```"
+		for strings.Contains(m.Content, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&b, "%s\n%s\n%s\n", fence, strings.TrimRight(m.Content, "\n"), fence)
+	}
+	return b.String()
+}
+
+// shareStyle is the inline stylesheet of shared HTML sessions
+const shareStyle = `body{font-family:-apple-system,"Segoe UI",sans-serif;max-width:60rem;margin:2rem auto;padding:0 1rem;color:#1f2328;background:#fff}
+dl{display:grid;grid-template-columns:max-content 1fr;gap:.2rem 1rem;color:#59636e}dt{font-weight:600}dd{margin:0}
+.note{color:#59636e;font-style:italic}
+.msg{border:1px solid #d1d9e0;border-radius:6px;margin:1rem 0}
+.msg h2{font-size:.85rem;margin:0;padding:.4rem .8rem;border-bottom:1px solid #d1d9e0;background:#f6f8fa}
+.msg pre{margin:0;padding:.8rem;white-space:pre-wrap;word-wrap:break-word;font-family:ui-monospace,Menlo,monospace;font-size:.85rem}
+.user h2{background:#ddf4ff}.assistant h2{background:#dafbe1}.tool_call pre,.tool pre{background:#f6f8fa;max-height:30rem;overflow:auto}`
+
+func (s *Session) shareHTML(now time.Time) string {
+	var b strings.Builder
+	title := html.EscapeString(s.shareTitle())
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", title, shareStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<dl>\n", title)
+	for _, d := range s.shareDetails(now) {
+		fmt.Fprintf(&b, "<dt>%s</dt><dd>%s</dd>\n", d[0], html.EscapeString(d[1]))
+	}
+	b.WriteString("</dl>\n<p class=\"note\">Secrets were redacted before sharing.</p>\n")
+	for _, m := range s.Messages {
+		fmt.Fprintf(&b, "<section class=\"msg %s\">\n<h2>%s</h2>\n<pre>%s</pre>\n</section>\n",
+			html.EscapeString(m.Role), html.EscapeString(shareRole(m.Role)), html.EscapeString(strings.TrimSpace(m.Content)))
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// shareRole returns the heading of a message with the given role
+func shareRole(role string) string {
+	if label, ok := shareRoles[role]; ok {
+		return label
+	}
+	return role
+}
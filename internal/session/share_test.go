@@ -0,0 +1,44 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShare(t *testing.T) {
+	s := New("qwen", "~/src/app")
+	s.Title = "Fix <login>"
+	s.AddMessage("user", "why does login fail?")
+	s.AddMessage("tool_call", "fileRead {\"file_path\": \"login.go\"}")
+	s.AddMessage("tool", "fileRead: ```go\nfunc login() {}\n```")
+	s.AddMessage("assistant", "The session cookie is never set.")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	md, err := s.Share(ShareMarkdown, now)
+	if err != nil {
+		t.Fatalf("Share(md) error = %v", err)
+	}
+	for _, want := range []string{"# Fix <login>\n", "- **Model:** qwen", "## User\n\nwhy does login fail?", "## Tool result\n\n````\nfileRead: ```go", "## Assistant\n\nThe session cookie"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+
+	page, err := s.Share(ShareHTML, now)
+	if err != nil {
+		t.Fatalf("Share(html) error = %v", err)
+	}
+	for _, want := range []string{"<title>Fix &lt;login&gt;</title>", "<style>", `<section class="msg tool_call">`, "<h2>Tool call</h2>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html is missing %q", want)
+		}
+	}
+	if strings.Contains(page, "<login>") || strings.Contains(page, "<script") || strings.Contains(page, "http") {
+		t.Error("html is not escaped or not self-contained")
+	}
+
+	if _, err := s.Share("pdf", now); err == nil {
+		t.Error("Share(pdf) succeeded")
+	}
+}
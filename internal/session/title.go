@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"strings"
+
+	"codezilla/internal/agent"
+	"codezilla/internal/tools"
+)
+
+// GenerateTitle asks the model for a short title summarizing the first exchange
+func GenerateTitle(ctx context.Context, llm tools.LLMClient, userMessage, assistantMessage string) (string, error) {
+	const maxExcerpt = 1500
+	if len(userMessage) > maxExcerpt {
+		userMessage = userMessage[:maxExcerpt]
+	}
+	if len(assistantMessage) > maxExcerpt {
+		assistantMessage = assistantMessage[:maxExcerpt]
+	}
+
+	messages := []tools.LLMMessage{
+		{
+			Role:    "system",
+			Content: "You write titles for chat sessions. Reply with the title only: 3 to 7 words, no quotes, no trailing punctuation.",
+		},
+		{
+			Role:    "user",
+			Content: "Write a title for this conversation.\n\nUser: " + userMessage + "\n\nAssistant: " + assistantMessage,
+		},
+	}
+
+	response, err := llm.GenerateResponse(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	return CleanTitle(response), nil
+}
+
+// CleanTitle normalizes a model generated title
+func CleanTitle(response string) string {
+	response = agent.StripReasoning(response)
+
+	title := ""
+	for _, line := range strings.Split(response, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			title = line
+			break
+		}
+	}
+
+	title = strings.TrimPrefix(title, "Title:")
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*#.")
+	return TruncateTitle(title)
+}
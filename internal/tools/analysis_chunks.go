@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxAnalysisChunkSize is the most content sent to the model in one analysis call
+	maxAnalysisChunkSize = 4000
+	// maxAnalysisChunks bounds the calls made for one file; the chunks mentioning the
+	// query most are analyzed when a file has more
+	maxAnalysisChunks = 8
+	// maxMergedFindings bounds the findings and issues kept from all chunks
+	maxMergedFindings = 10
+)
+
+// contentChunk is a run of whole lines of a file
+type contentChunk struct {
+	StartLine int // 1-based, inclusive
+	EndLine   int
+	Text      string
+}
+
+// Outliner returns the lines, 1-based, where the top-level definitions of a file
+// start, or nil when it doesn't know the file's language
+type Outliner func(path string, content []byte) []int
+
+// splitIntoChunks splits content into chunks of at most maxSize bytes, cutting at
+// the section starts where possible so functions stay whole. Sections larger than
+// maxSize are cut between lines, and lines larger than maxSize are cut between
+// characters.
+func splitIntoChunks(content string, starts []int, maxSize int) []contentChunk {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	isStart := make(map[int]bool, len(starts))
+	for _, n := range starts {
+		isStart[n] = true
+	}
+
+	// Group lines into sections starting at each definition
+	var sections []contentChunk
+	for i, line := range lines {
+		if len(sections) == 0 || isStart[i+1] {
+			sections = append(sections, contentChunk{StartLine: i + 1})
+		}
+		s := &sections[len(sections)-1]
+		s.EndLine = i + 1
+		s.Text += line
+	}
+
+	var chunks []contentChunk
+	add := func(c contentChunk) {
+		if n := len(chunks); n > 0 && len(chunks[n-1].Text)+len(c.Text) <= maxSize {
+			chunks[n-1].EndLine = c.EndLine
+			chunks[n-1].Text += c.Text
+			return
+		}
+		chunks = append(chunks, c)
+	}
+	for _, s := range sections {
+		if len(s.Text) <= maxSize {
+			add(s)
+			continue
+		}
+		// Too large for one chunk: start a new chunk and fill it line by line
+		chunks = append(chunks, contentChunk{StartLine: s.StartLine, EndLine: s.StartLine - 1})
+		for i, line := range strings.SplitAfter(s.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			n := s.StartLine + i
+			for len(line) > maxSize {
+				cut := maxSize
+				for cut > 0 && !utf8.RuneStart(line[cut]) {
+					cut--
+				}
+				if cut == 0 {
+					cut = maxSize
+				}
+				chunks = append(chunks, contentChunk{StartLine: n, EndLine: n, Text: line[:cut]})
+				line = line[cut:]
+			}
+			add(contentChunk{StartLine: n, EndLine: n, Text: line})
+		}
+	}
+	// Drop the empty chunk left when a large section starts with an overlong line
+	kept := chunks[:0]
+	for _, c := range chunks {
+		if c.Text != "" {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// selectChunks keeps at most limit chunks, preferring those mentioning the words of
+// the query most, and returns them in file order
+func selectChunks(chunks []contentChunk, userQuery string, limit int) []contentChunk {
+	if len(chunks) <= limit {
+		return chunks
+	}
+	var words []string
+	for _, w := range strings.Fields(strings.ToLower(userQuery)) {
+		if len(w) > 2 {
+			words = append(words, w)
+		}
+	}
+	scores := make([]int, len(chunks))
+	for i, c := range chunks {
+		lower := strings.ToLower(c.Text)
+		for _, w := range words {
+			scores[i] += strings.Count(lower, w)
+		}
+	}
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	order = order[:limit]
+	sort.Ints(order)
+
+	selected := make([]contentChunk, 0, limit)
+	for _, i := range order {
+		selected = append(selected, chunks[i])
+	}
+	return selected
+}
+
+// mergeChunkAnalyses combines the analyses of a file's chunks into one. Relevance
+// is that of the most relevant chunk, whose summary leads; findings and issues are
+// labeled with their lines and taken from the most relevant chunks first.
+func mergeChunkAnalyses(chunks []contentChunk, analyses []*FileAnalysis, totalChunks int) *FileAnalysis {
+	order := make([]int, 0, len(analyses))
+	for i, a := range analyses {
+		if a != nil {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return analyses[order[a]].Relevance > analyses[order[b]].Relevance })
+
+	merged := &FileAnalysis{
+		Metadata: map[string]string{
+			"analyzer": "llm",
+			"chunks":   fmt.Sprintf("%d", len(order)),
+		},
+	}
+	if totalChunks > len(chunks) {
+		merged.Metadata["chunks_skipped"] = fmt.Sprintf("%d", totalChunks-len(chunks))
+	}
+	seen := make(map[string]bool)
+	label := func(c contentChunk, items []string, into *[]string) {
+		for _, item := range items {
+			if len(*into) >= maxMergedFindings || seen[item] {
+				continue
+			}
+			seen[item] = true
+			*into = append(*into, fmt.Sprintf("lines %d-%d: %s", c.StartLine, c.EndLine, item))
+		}
+	}
+	for n, i := range order {
+		a, c := analyses[i], chunks[i]
+		if n == 0 {
+			merged.Summary = a.Summary
+			merged.Relevance = a.Relevance
+		}
+		label(c, a.KeyFindings, &merged.KeyFindings)
+		label(c, a.Issues, &merged.Issues)
+		label(c, a.CodeSmells, &merged.CodeSmells)
+		for _, dep := range a.Dependencies {
+			if !seen["dep "+dep] {
+				seen["dep "+dep] = true
+				merged.Dependencies = append(merged.Dependencies, dep)
+			}
+		}
+		mergeQueryAnalyses(merged, c, a.Queries)
+	}
+	return merged
+}
+
+// mergeQueryAnalyses adds a chunk's answers to the queries of a multi-query scan,
+// keeping the highest relevance for each query
+func mergeQueryAnalyses(merged *FileAnalysis, c contentChunk, queries []QueryAnalysis) {
+	for i, q := range queries {
+		if i == len(merged.Queries) {
+			merged.Queries = append(merged.Queries, QueryAnalysis{Query: q.Query})
+		}
+		m := &merged.Queries[i]
+		if q.Relevance > m.Relevance {
+			m.Relevance = q.Relevance
+		}
+		for _, f := range q.KeyFindings {
+			if len(m.KeyFindings) < maxQueryFindings {
+				m.KeyFindings = append(m.KeyFindings, fmt.Sprintf("lines %d-%d: %s", c.StartLine, c.EndLine, f))
+			}
+		}
+		for _, issue := range q.Issues {
+			if len(m.Issues) < maxQueryFindings {
+				m.Issues = append(m.Issues, fmt.Sprintf("lines %d-%d: %s", c.StartLine, c.EndLine, issue))
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"codezilla/pkg/logger"
+)
+
+func TestSplitIntoChunks(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&b, "func f%d() {\n%s}\n\n", i, strings.Repeat("\tx++\n", 10))
+	}
+	content := b.String()
+
+	chunks := splitIntoChunks(content, funcStarts(content), 150)
+	var joined string
+	next := 1
+	for _, c := range chunks {
+		if len(c.Text) > 150 {
+			t.Errorf("chunk %d-%d has %d bytes", c.StartLine, c.EndLine, len(c.Text))
+		}
+		if c.StartLine != next {
+			t.Errorf("chunk starts at line %d, want %d", c.StartLine, next)
+		}
+		if !strings.HasPrefix(c.Text, "func ") {
+			t.Errorf("chunk %d-%d does not start at a function: %q", c.StartLine, c.EndLine, c.Text)
+		}
+		next = c.EndLine + 1
+		joined += c.Text
+	}
+	if joined != content || len(chunks) != 3 {
+		t.Errorf("splitIntoChunks() gave %d chunks that do not cover the content", len(chunks))
+	}
+
+	// Sections and lines larger than a chunk are cut
+	chunks = splitIntoChunks("a\n"+strings.Repeat("b", 25)+"\nc\n", nil, 10)
+	var texts []string
+	for _, c := range chunks {
+		texts = append(texts, fmt.Sprintf("%d-%d:%q", c.StartLine, c.EndLine, c.Text))
+	}
+	want := `1-1:"a\n" 2-2:"bbbbbbbbbb" 2-2:"bbbbbbbbbb" 2-3:"bbbbb\nc\n"`
+	if got := strings.Join(texts, " "); got != want {
+		t.Errorf("splitIntoChunks() = %s, want %s", got, want)
+	}
+
+	// Long lines are cut between characters
+	for _, c := range splitIntoChunks(strings.Repeat("é", 12)+"\n", nil, 5) {
+		if !utf8.ValidString(c.Text) {
+			t.Errorf("chunk %q splits a character", c.Text)
+		}
+	}
+}
+
+// funcStarts is an outline of Go test content: the lines starting a function
+func funcStarts(content string) []int {
+	var starts []int
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "func ") {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// chunkLLM answers each analysis call with the lines it was given
+type chunkLLM struct {
+	calls int
+}
+
+var sectionPattern = regexp.MustCompile(`lines (\d+)-(\d+)`)
+
+func (c *chunkLLM) GenerateResponse(ctx context.Context, messages []LLMMessage) (string, error) {
+	c.calls++
+	prompt := messages[len(messages)-1].Content
+	relevance := 0.2
+	if strings.Contains(prompt, "needle()") {
+		relevance = 0.9
+	}
+	section := sectionPattern.FindString(prompt)
+	return fmt.Sprintf(`{"summary": "part %s", "key_findings": ["finding in %s", "shared"], "relevance": %g, "dependencies": ["fmt"]}`, section, section, relevance), nil
+}
+
+func TestLLMFileAnalyzerChunks(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+	llm := &chunkLLM{}
+	analyzer := NewLLMFileAnalyzer(llm, log)
+	analyzer.outline = func(path string, content []byte) []int { return funcStarts(string(content)) }
+
+	var b strings.Builder
+	for i := 0; i < 20; i++ {
+		body := strings.Repeat("\tx++\n", 500)
+		if i == 12 {
+			body += "\tneedle()\n"
+		}
+		fmt.Fprintf(&b, "func f%d() {\n%s}\n", i, body)
+	}
+	analysis, err := analyzer.AnalyzeFile(context.Background(), "big.go", b.String(), "where is needle called")
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if llm.calls != maxAnalysisChunks {
+		t.Errorf("AnalyzeFile() made %d calls, want %d", llm.calls, maxAnalysisChunks)
+	}
+	if analysis.Relevance != 0.9 || analysis.Summary != "part lines 6025-6527" {
+		t.Errorf("AnalyzeFile() = relevance %v, summary %q; want the chunk with the needle first", analysis.Relevance, analysis.Summary)
+	}
+	if !strings.HasPrefix(analysis.KeyFindings[0], "lines 6025-6527: finding in") || len(analysis.Dependencies) != 1 {
+		t.Errorf("AnalyzeFile() findings = %q, dependencies = %q", analysis.KeyFindings, analysis.Dependencies)
+	}
+	shared := 0
+	for _, f := range analysis.KeyFindings {
+		if strings.HasSuffix(f, ": shared") {
+			shared++
+		}
+	}
+	if shared != 1 || analysis.Metadata["chunks"] != "8" || analysis.Metadata["chunks_skipped"] != "12" {
+		t.Errorf("AnalyzeFile() findings = %q, metadata = %v", analysis.KeyFindings, analysis.Metadata)
+	}
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultAnalysisTemplate is the prompt for analyzing one file. {{query}}, {{file}},
+// {{content}} and {{rubric}} are replaced with the user's query, the file path, the
+// file contents and the rubric of the analysis profile.
+const DefaultAnalysisTemplate = `Analyze the following file with respect to this query: "{{query}}"
+
+File: {{file}}
+
+Content:
+{{content}}
+
+{{rubric}}
+
+Format your response as JSON with these fields:
+- summary: string
+- key_findings: array of strings
+- relevance: number between 0 and 1
+- issues: array of strings (optional)
+- dependencies: array of strings (optional)
+- code_smells: array of strings (optional)`
+
+// DefaultAnalysisProfile is the profile used when a scan does not name one
+const DefaultAnalysisProfile = "default"
+
+// BuiltinAnalysisProfiles are the analysis rubrics available without configuration.
+// Profiles defined in the config file with the same name replace them.
+var BuiltinAnalysisProfiles = map[string]string{
+	DefaultAnalysisProfile: `Provide a structured analysis with:
+1. A brief summary (1-2 sentences)
+2. Key findings relevant to the query (list 2-5 items)
+3. Relevance score (0-1) based on how well this file matches the query
+4. Any issues, problems, or code smells found (if applicable)
+5. Key dependencies or relationships with other parts of the codebase (if identifiable)`,
+	"concurrency": `Focus on concurrency. Provide:
+1. A brief summary of how the file uses goroutines, threads, locks, channels or async code (1-2 sentences)
+2. Key findings relevant to the query (list 2-5 items)
+3. Relevance score (0-1) based on how well this file matches the query
+4. Issues: data races, deadlocks, missing synchronization, leaked goroutines or tasks, and unsafe shared state, each with the function involved
+5. Dependencies on other concurrent parts of the codebase (if identifiable)`,
+	"security": `Focus on security. Provide:
+1. A brief summary of what the file exposes or handles (1-2 sentences)
+2. Key findings relevant to the query (list 2-5 items)
+3. Relevance score (0-1) based on how well this file matches the query
+4. Issues: injection, unsafe file or command handling, secrets in code, missing input validation or authorization, and weak cryptography, each with the function involved
+5. Dependencies on external input, services or libraries (if identifiable)`,
+	"performance": `Focus on performance. Provide:
+1. A brief summary of the file's hot paths (1-2 sentences)
+2. Key findings relevant to the query (list 2-5 items)
+3. Relevance score (0-1) based on how well this file matches the query
+4. Issues: needless allocations or copies, quadratic loops, repeated I/O or queries, missing caching and blocking calls, each with the function involved
+5. Dependencies on slow resources such as databases, networks or disks (if identifiable)`,
+	"errors": `Focus on error handling. Provide:
+1. A brief summary of how the file reports and handles failures (1-2 sentences)
+2. Key findings relevant to the query (list 2-5 items)
+3. Relevance score (0-1) based on how well this file matches the query
+4. Issues: ignored or swallowed errors, missing context in error messages, panics on bad input and resources not released on failure, each with the function involved
+5. Dependencies on callers or callees that must handle these errors (if identifiable)`,
+}
+
+// AnalysisPrompts holds the file analysis prompt template and the profiles whose
+// rubrics it can be filled with
+type AnalysisPrompts struct {
+	template string
+	profiles map[string]string
+}
+
+// NewAnalysisPrompts creates the prompts from a template, DefaultAnalysisTemplate
+// when empty, and the built-in profiles plus the custom ones from the config
+func NewAnalysisPrompts(template string, custom map[string]string) *AnalysisPrompts {
+	if template == "" {
+		template = DefaultAnalysisTemplate
+	}
+	p := &AnalysisPrompts{template: template, profiles: make(map[string]string)}
+	for name, rubric := range BuiltinAnalysisProfiles {
+		p.profiles[name] = rubric
+	}
+	for name, rubric := range custom {
+		p.profiles[name] = rubric
+	}
+	return p
+}
+
+// Names returns the profile names, sorted
+func (p *AnalysisPrompts) Names() []string {
+	names := make([]string, 0, len(p.profiles))
+	for name := range p.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rubric returns the rubric of a profile, or of the default profile when name is empty
+func (p *AnalysisPrompts) Rubric(name string) (string, error) {
+	if name == "" {
+		name = DefaultAnalysisProfile
+	}
+	rubric, ok := p.profiles[name]
+	if !ok {
+		return "", fmt.Errorf("unknown analysis profile %q (available: %s)", name, strings.Join(p.Names(), ", "))
+	}
+	return rubric, nil
+}
+
+// Render fills in the template. Placeholders inside the substituted values, such as
+// "{{query}}" in a file's content, are left alone.
+func (p *AnalysisPrompts) Render(query, file, content, rubric string) string {
+	return strings.NewReplacer(
+		"{{query}}", query,
+		"{{file}}", file,
+		"{{content}}", content,
+		"{{rubric}}", rubric,
+	).Replace(p.template)
+}
+
+// analysisProfileKey carries the name of the profile a scan uses to the analyzers,
+// whose interface has no room for it
+type analysisProfileKey struct{}
+
+// withAnalysisProfile returns a context for analyzing files with the named profile
+func withAnalysisProfile(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, analysisProfileKey{}, name)
+}
+
+// analysisProfile returns the profile name set by withAnalysisProfile, if any
+func analysisProfile(ctx context.Context) string {
+	name, _ := ctx.Value(analysisProfileKey{}).(string)
+	return name
+}
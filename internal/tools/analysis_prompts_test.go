@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"codezilla/pkg/logger"
+)
+
+func TestAnalysisPrompts(t *testing.T) {
+	p := NewAnalysisPrompts("Q={{query}} F={{file}} R={{rubric}}\n{{content}}", map[string]string{
+		"sql":         "Look for unparameterized queries",
+		"concurrency": "Custom concurrency rubric",
+	})
+
+	if rubric, err := p.Rubric(""); err != nil || rubric != BuiltinAnalysisProfiles[DefaultAnalysisProfile] {
+		t.Errorf("Rubric(\"\") = %q, %v, want the default rubric", rubric, err)
+	}
+	if rubric, _ := p.Rubric("concurrency"); rubric != "Custom concurrency rubric" {
+		t.Errorf("Rubric(concurrency) = %q, want the custom rubric to replace the built-in one", rubric)
+	}
+	if _, err := p.Rubric("nope"); err == nil || !strings.Contains(err.Error(), "sql") {
+		t.Errorf("Rubric(nope) error = %v, want one listing the profiles", err)
+	}
+
+	got := p.Render("races", "a.go", "x := {{query}}", "R1")
+	if want := "Q=races F=a.go R=R1\nx := {{query}}"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+// promptRecorder records the prompts it is sent
+type promptRecorder struct {
+	prompts []string
+}
+
+func (r *promptRecorder) GenerateResponse(ctx context.Context, messages []LLMMessage) (string, error) {
+	r.prompts = append(r.prompts, messages[len(messages)-1].Content)
+	return `{"summary": "ok", "key_findings": [], "relevance": 0.5}`, nil
+}
+
+func TestAnalysisProfileParameter(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+	llm := &promptRecorder{}
+	analyzer := NewProjectScanAnalyzer(llm, log)
+	analyzer.SetAnalysisPrompts(NewAnalysisPrompts("", map[string]string{"sql": "SQL RUBRIC"}))
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db.go"), []byte("package db\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := analyzer.Execute(context.Background(), map[string]interface{}{
+		"dir": dir, "userQuery": "queries", "analysisProfile": "nope", "showProgress": false,
+	})
+	var invalid *ErrInvalidToolParams
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Execute() with an unknown profile error = %v, want ErrInvalidToolParams", err)
+	}
+
+	if _, err := analyzer.Execute(context.Background(), map[string]interface{}{
+		"dir": dir, "userQuery": "queries", "analysisProfile": "sql", "showProgress": false,
+	}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(llm.prompts) != 1 || !strings.Contains(llm.prompts[0], "SQL RUBRIC") || !strings.Contains(llm.prompts[0], "package db") {
+		t.Errorf("prompts = %q, want one with the sql rubric and the file", llm.prompts)
+	}
+}
+
+// queryLLM answers multi-query prompts, finding "cache" relevant only in files using it
+type queryLLM struct {
+	calls int
+}
+
+func (q *queryLLM) GenerateResponse(ctx context.Context, messages []LLMMessage) (string, error) {
+	q.calls++
+	prompt := messages[len(messages)-1].Content
+	cache, retry := 0.1, 0.9
+	if strings.Contains(prompt, "cache.Get") {
+		cache = 0.8
+	}
+	// Only the first two answers are given; the third query is filled in
+	return fmt.Sprintf(`{"summary": "ok", "relevance": 0.3, "queries": [
+		{"query": "caching", "relevance": %g, "key_findings": ["uses the cache"]},
+		{"query": "retries", "relevance": %g}
+	]}`, cache, retry), nil
+}
+
+func TestMultiQueryScan(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+	llm := &queryLLM{}
+	analyzer := NewProjectScanAnalyzer(llm, log)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.go": "cache.Get(key)\n", "b.go": "retry()\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := analyzer.Execute(context.Background(), map[string]interface{}{
+		"dir":          dir,
+		"userQuery":    "where are results cached",
+		"queries":      []interface{}{"how are retries done", "what is logged"},
+		"showProgress": false,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	result := out.(*EnhancedProjectScanResult)
+	if llm.calls != 2 {
+		t.Errorf("made %d LLM calls, want one per file", llm.calls)
+	}
+	want := []QueryFiles{
+		{Query: "where are results cached", Files: []string{filepath.Join(dir, "a.go")}},
+		{Query: "how are retries done", Files: []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}},
+		{Query: "what is logged", Files: []string{}},
+	}
+	if !reflect.DeepEqual(result.QueryFiles, want) {
+		t.Errorf("QueryFiles = %+v, want %+v", result.QueryFiles, want)
+	}
+	first := result.FileResults[0].Analysis
+	if first.Relevance != 0.9 || len(first.Queries) != 3 || first.Queries[0].Query != "where are results cached" || first.Queries[2].Relevance != 0 {
+		t.Errorf("analysis of a.go = %+v", first)
+	}
+}
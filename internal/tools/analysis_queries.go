@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxQueryFindings bounds the findings kept for each query of a multi-query scan
+const maxQueryFindings = 5
+
+// QueryAnalysis is the part of a file's analysis answering one query of a scan
+// with several queries
+type QueryAnalysis struct {
+	Query       string   `json:"query"`
+	Relevance   float64  `json:"relevance"`
+	KeyFindings []string `json:"key_findings,omitempty"`
+	Issues      []string `json:"issues,omitempty"`
+}
+
+// QueryFiles lists the files relevant to one query of a scan, most relevant first
+type QueryFiles struct {
+	Query string   `json:"query"`
+	Files []string `json:"files"`
+}
+
+// analysisQueriesKey carries the queries of a multi-query scan to the analyzers
+type analysisQueriesKey struct{}
+
+// withAnalysisQueries returns a context for analyzing files against several queries
+func withAnalysisQueries(ctx context.Context, queries []string) context.Context {
+	return context.WithValue(ctx, analysisQueriesKey{}, queries)
+}
+
+// analysisQueries returns the queries set by withAnalysisQueries, or nil for a scan
+// with a single query
+func analysisQueries(ctx context.Context) []string {
+	queries, _ := ctx.Value(analysisQueriesKey{}).([]string)
+	if len(queries) < 2 {
+		return nil
+	}
+	return queries
+}
+
+// combineQueries joins several queries into the one query analyzers are given
+func combineQueries(queries []string) string {
+	parts := make([]string, len(queries))
+	for i, q := range queries {
+		parts[i] = fmt.Sprintf("(%d) %s", i+1, q)
+	}
+	return fmt.Sprintf("%d separate questions: %s", len(queries), strings.Join(parts, " "))
+}
+
+// multiQueryInstructions asks the model for a separate answer to each query
+func multiQueryInstructions(n int) string {
+	return fmt.Sprintf(`The query consists of %d separate questions. Judge the file against each of them and also return:
+- queries: array with one object per question, in the same order, with fields query (the question), relevance (number between 0 and 1), key_findings (array of strings) and issues (array of strings, optional)
+The top-level relevance is that of the question the file matches best.`, n)
+}
+
+// alignQueryAnalyses returns one analysis per query, in order, taking those the model
+// returned by position and filling in any it left out with zero relevance
+func alignQueryAnalyses(queries []string, got []QueryAnalysis) []QueryAnalysis {
+	aligned := make([]QueryAnalysis, len(queries))
+	for i, q := range queries {
+		aligned[i] = QueryAnalysis{Query: q}
+		if i < len(got) {
+			aligned[i].Relevance = clampRelevance(got[i].Relevance)
+			aligned[i].KeyFindings = got[i].KeyFindings
+			aligned[i].Issues = got[i].Issues
+		}
+	}
+	return aligned
+}
+
+// keywordQueryAnalyses scores content against each query the way the fallback
+// analyzers score a single query: 0.8 when it contains the query, 0.5 otherwise
+func keywordQueryAnalyses(queries []string, content string) []QueryAnalysis {
+	if len(queries) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(content)
+	analyses := make([]QueryAnalysis, len(queries))
+	for i, q := range queries {
+		analyses[i] = QueryAnalysis{Query: q, Relevance: 0.5}
+		if strings.Contains(lower, strings.ToLower(q)) {
+			analyses[i].Relevance = 0.8
+		}
+	}
+	return analyses
+}
+
+// maxQueryRelevance returns the relevance of the best matching query
+func maxQueryRelevance(analyses []QueryAnalysis) float64 {
+	best := 0.0
+	for _, q := range analyses {
+		if q.Relevance > best {
+			best = q.Relevance
+		}
+	}
+	return best
+}
+
+// filesByQuery lists for each query the files at or above the threshold for it
+func filesByQuery(queries []string, results []FileResult, threshold float64) []QueryFiles {
+	byQuery := make([]QueryFiles, len(queries))
+	for i, q := range queries {
+		type scored struct {
+			path      string
+			relevance float64
+		}
+		var files []scored
+		for _, r := range results {
+			if i < len(r.Analysis.Queries) && r.Analysis.Queries[i].Relevance >= threshold {
+				files = append(files, scored{r.Path, r.Analysis.Queries[i].Relevance})
+			}
+		}
+		sort.SliceStable(files, func(a, b int) bool { return files[a].relevance > files[b].relevance })
+		byQuery[i] = QueryFiles{Query: q, Files: []string{}}
+		for _, f := range files {
+			byQuery[i].Files = append(byQuery[i].Files, f.path)
+		}
+	}
+	return byQuery
+}
+
+// clampRelevance keeps a relevance score within 0 and 1
+func clampRelevance(r float64) float64 {
+	if r < 0 {
+		return 0
+	}
+	if r > 1 {
+		return 1
+	}
+	return r
+}
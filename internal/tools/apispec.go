@@ -0,0 +1,735 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxSpecFiles caps the spec files summarized when a directory is searched
+	maxSpecFiles = 20
+	// maxSpecEndpoints caps the endpoints returned, to keep large specs compact
+	maxSpecEndpoints = 300
+)
+
+// httpMethods are the operations an OpenAPI path item can have
+var httpMethods = []string{"get", "put", "post", "delete", "patch", "head", "options", "trace"}
+
+// APIEndpoint is an HTTP operation of an OpenAPI spec or an RPC of a protobuf service
+type APIEndpoint struct {
+	File      string            `json:"file,omitempty"`
+	Method    string            `json:"method"` // HTTP method, or "rpc" for gRPC methods without an HTTP binding
+	Path      string            `json:"path"`   // URL path, or /package.Service/Method
+	Name      string            `json:"name,omitempty"`
+	Summary   string            `json:"summary,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Params    []string          `json:"params,omitempty"`
+	Request   string            `json:"request,omitempty"`
+	Responses map[string]string `json:"responses,omitempty"` // Status code (or "response" for RPCs) -> type
+}
+
+// APIType is a schema of an OpenAPI spec or a protobuf message or enum
+type APIType struct {
+	File   string   `json:"file,omitempty"`
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`             // object, message or enum
+	Fields []string `json:"fields,omitempty"` // "name: type", or the values of an enum
+}
+
+// APISpec is the compact summary of one spec file
+type APISpec struct {
+	File      string        `json:"file"`
+	Format    string        `json:"format"` // e.g. "openapi 3.0.3", "swagger 2.0", "proto3"
+	Title     string        `json:"title,omitempty"`
+	Version   string        `json:"version,omitempty"`
+	Package   string        `json:"package,omitempty"`
+	Endpoints []APIEndpoint `json:"-"`
+	Types     []APIType     `json:"-"`
+}
+
+// ParseAPISpec parses an OpenAPI/Swagger document (JSON or YAML) or a .proto file
+func ParseAPISpec(path string, data []byte) (*APISpec, error) {
+	if strings.EqualFold(filepath.Ext(path), ".proto") {
+		return parseProto(path, data), nil
+	}
+	doc, _, err := DecodeDataFile(data, dataFormatFor(path))
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok || (root["openapi"] == nil && root["swagger"] == nil) {
+		return nil, fmt.Errorf("%s is not an OpenAPI or Swagger document", path)
+	}
+	return parseOpenAPI(path, root), nil
+}
+
+// parseOpenAPI summarizes an OpenAPI 3 or Swagger 2 document
+func parseOpenAPI(path string, root map[string]interface{}) *APISpec {
+	spec := &APISpec{File: path, Format: fmt.Sprintf("openapi %v", root["openapi"])}
+	if root["openapi"] == nil {
+		spec.Format = fmt.Sprintf("swagger %v", root["swagger"])
+	}
+	if info, ok := root["info"].(map[string]interface{}); ok {
+		spec.Title = fmt.Sprint(valueOr(info["title"], ""))
+		spec.Version = fmt.Sprint(valueOr(info["version"], ""))
+	}
+
+	paths, _ := root["paths"].(map[string]interface{})
+	for _, p := range sortedKeys(paths) {
+		item, _ := paths[p].(map[string]interface{})
+		shared, _ := item["parameters"].([]interface{})
+		for _, method := range httpMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			e := APIEndpoint{File: path, Method: strings.ToUpper(method), Path: p}
+			e.Name, _ = op["operationId"].(string)
+			e.Summary, _ = op["summary"].(string)
+			if tags, ok := op["tags"].([]interface{}); ok {
+				for _, t := range tags {
+					e.Tags = append(e.Tags, fmt.Sprint(t))
+				}
+			}
+			params, _ := op["parameters"].([]interface{})
+			for _, param := range append(append([]interface{}{}, shared...), params...) {
+				pm, _ := resolveRef(root, param).(map[string]interface{})
+				if pm == nil {
+					continue
+				}
+				if pm["in"] == "body" {
+					// Swagger 2 puts the request body among the parameters
+					e.Request = schemaType(pm["schema"])
+					continue
+				}
+				desc := fmt.Sprintf("%v (%v, %s", pm["name"], pm["in"], schemaType(valueOr(pm["schema"], pm)))
+				if pm["required"] == true {
+					desc += ", required"
+				}
+				e.Params = append(e.Params, desc+")")
+			}
+			if body, ok := resolveRef(root, op["requestBody"]).(map[string]interface{}); ok {
+				e.Request = contentType(body)
+			}
+			if responses, ok := op["responses"].(map[string]interface{}); ok {
+				e.Responses = make(map[string]string)
+				for code, r := range responses {
+					rm, _ := resolveRef(root, r).(map[string]interface{})
+					typ := contentType(rm)
+					if typ == "" && rm != nil && rm["schema"] != nil {
+						typ = schemaType(rm["schema"])
+					}
+					e.Responses[code] = typ
+				}
+			}
+			spec.Endpoints = append(spec.Endpoints, e)
+		}
+	}
+
+	schemas, _ := root["definitions"].(map[string]interface{})
+	if components, ok := root["components"].(map[string]interface{}); ok {
+		schemas, _ = components["schemas"].(map[string]interface{})
+	}
+	for _, name := range sortedKeys(schemas) {
+		s, _ := schemas[name].(map[string]interface{})
+		t := APIType{File: path, Name: name, Kind: "object"}
+		if enum, ok := s["enum"].([]interface{}); ok {
+			t.Kind = "enum"
+			for _, v := range enum {
+				t.Fields = append(t.Fields, fmt.Sprint(v))
+			}
+		}
+		t.Fields = append(t.Fields, schemaFields(s)...)
+		spec.Types = append(spec.Types, t)
+	}
+	return spec
+}
+
+// valueOr returns v, or fallback when v is nil
+func valueOr(v, fallback interface{}) interface{} {
+	if v == nil {
+		return fallback
+	}
+	return v
+}
+
+// resolveRef follows a local $ref ("#/components/parameters/id") within the document
+func resolveRef(root map[string]interface{}, v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	ref, ok := m["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") {
+		return v
+	}
+	var current interface{} = root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		cm, ok := current.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		current = cm[strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")]
+	}
+	return current
+}
+
+// contentType returns the schema type of a request body or response's content,
+// preferring JSON
+func contentType(m map[string]interface{}) string {
+	content, _ := m["content"].(map[string]interface{})
+	if media, ok := content["application/json"].(map[string]interface{}); ok {
+		return schemaType(media["schema"])
+	}
+	for _, mediaType := range sortedKeys(content) {
+		if media, ok := content[mediaType].(map[string]interface{}); ok && media["schema"] != nil {
+			return schemaType(media["schema"])
+		}
+	}
+	return ""
+}
+
+// schemaType renders a schema as a short type expression such as "User", "[]Pet",
+// "map[string]integer" or "string(date-time)"
+func schemaType(v interface{}) string {
+	s, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	for _, combinator := range []string{"allOf", "oneOf", "anyOf"} {
+		if list, ok := s[combinator].([]interface{}); ok {
+			var parts []string
+			for _, item := range list {
+				parts = append(parts, schemaType(item))
+			}
+			sep := "|"
+			if combinator == "allOf" {
+				sep = "&"
+			}
+			return strings.Join(parts, sep)
+		}
+	}
+	typ, _ := s["type"].(string)
+	switch typ {
+	case "array":
+		return "[]" + schemaType(s["items"])
+	case "object", "":
+		if extra, ok := s["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + schemaType(extra)
+		}
+		if typ == "" && s["properties"] == nil {
+			return ""
+		}
+		return "object"
+	}
+	if format, ok := s["format"].(string); ok {
+		return typ + "(" + format + ")"
+	}
+	return typ
+}
+
+// schemaFields lists an object schema's properties as "name: type", marking required ones
+func schemaFields(s map[string]interface{}) []string {
+	required := make(map[string]bool)
+	if list, ok := s["required"].([]interface{}); ok {
+		for _, r := range list {
+			required[fmt.Sprint(r)] = true
+		}
+	}
+	var fields []string
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, part := range all {
+			pm, _ := part.(map[string]interface{})
+			if ref := schemaType(pm); pm["$ref"] != nil {
+				fields = append(fields, "(includes "+ref+")")
+			} else {
+				fields = append(fields, schemaFields(pm)...)
+			}
+		}
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	for _, name := range sortedKeys(props) {
+		field := name + ": " + schemaType(props[name])
+		if required[name] {
+			field += " (required)"
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// protoParser reads the declarations of a .proto file from its tokens
+type protoParser struct {
+	toks []string
+	pos  int
+	spec *APISpec
+}
+
+// tokenizeProto splits protobuf source into identifiers (with dots), numbers, quoted
+// strings and single punctuation characters, dropping comments
+func tokenizeProto(src string) []string {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				return toks
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return toks
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end++; end > len(src) {
+				end = len(src)
+			}
+			toks = append(toks, src[i:end])
+			i = end
+		case isProtoIdent(c):
+			end := i
+			for end < len(src) && isProtoIdent(src[end]) {
+				end++
+			}
+			toks = append(toks, src[i:end])
+			i = end
+		default:
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks
+}
+
+func isProtoIdent(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '+' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parseProto summarizes the package, messages, enums and services of a .proto file
+func parseProto(path string, data []byte) *APISpec {
+	p := &protoParser{toks: tokenizeProto(string(data)), spec: &APISpec{File: path, Format: "proto2"}}
+	for p.pos < len(p.toks) {
+		switch p.next() {
+		case "syntax", "edition":
+			p.expect("=")
+			p.spec.Format = unquote(p.next())
+			p.skipStatement()
+		case "package":
+			p.spec.Package = p.next()
+			p.skipStatement()
+		case "message":
+			p.parseMessage("")
+		case "enum":
+			p.parseEnum("")
+		case "service":
+			p.parseService()
+		case ";":
+		default:
+			p.skipStatement()
+		}
+	}
+	return p.spec
+}
+
+func (p *protoParser) next() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	p.pos++
+	return p.toks[p.pos-1]
+}
+
+func (p *protoParser) peek() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+// expect consumes tok if it is next
+func (p *protoParser) expect(tok string) bool {
+	if p.peek() == tok {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipStatement consumes the rest of a statement: up to a ";" or past a {...} block
+func (p *protoParser) skipStatement() {
+	depth := 0
+	for p.pos < len(p.toks) {
+		switch p.next() {
+		case ";":
+			if depth == 0 {
+				return
+			}
+		case "{":
+			depth++
+		case "}":
+			if depth--; depth <= 0 {
+				return
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseMessage(prefix string) {
+	t := APIType{File: p.spec.File, Name: prefix + p.next(), Kind: "message"}
+	p.expect("{")
+	t.Fields = p.parseFields(t.Name, "")
+	p.spec.Types = append(p.spec.Types, t)
+}
+
+// parseFields reads message body declarations up to the closing brace, returning the
+// fields and recording nested messages and enums
+func (p *protoParser) parseFields(message, oneof string) []string {
+	var fields []string
+	for p.pos < len(p.toks) {
+		tok := p.next()
+		switch tok {
+		case "}":
+			return fields
+		case ";":
+		case "message":
+			p.parseMessage(message + ".")
+		case "enum":
+			p.parseEnum(message + ".")
+		case "oneof":
+			name := p.next()
+			p.expect("{")
+			fields = append(fields, p.parseFields(message, name)...)
+		case "option", "reserved", "extensions", "extend", "group":
+			p.skipStatement()
+		case "map":
+			// map<Key, Value> name = N;
+			p.expect("<")
+			key := p.next()
+			p.expect(",")
+			value := p.next()
+			p.expect(">")
+			fields = append(fields, p.field(p.next(), "map<"+key+", "+value+">", oneof))
+			p.skipStatement()
+		default:
+			label := ""
+			if tok == "repeated" || tok == "optional" || tok == "required" {
+				label, tok = tok, p.next()
+			}
+			typ := tok
+			if label == "repeated" {
+				typ = "[]" + typ
+			}
+			field := p.field(p.next(), typ, oneof)
+			if label == "optional" {
+				field += " (optional)"
+			}
+			fields = append(fields, field)
+			p.skipStatement()
+		}
+	}
+	return fields
+}
+
+// field formats a message field, noting the oneof it belongs to
+func (p *protoParser) field(name, typ, oneof string) string {
+	if oneof != "" {
+		return fmt.Sprintf("%s: %s (oneof %s)", name, typ, oneof)
+	}
+	return name + ": " + typ
+}
+
+func (p *protoParser) parseEnum(prefix string) {
+	t := APIType{File: p.spec.File, Name: prefix + p.next(), Kind: "enum"}
+	p.expect("{")
+	for p.pos < len(p.toks) {
+		tok := p.next()
+		switch tok {
+		case "}":
+			p.spec.Types = append(p.spec.Types, t)
+			return
+		case ";":
+		case "option", "reserved":
+			p.skipStatement()
+		default:
+			t.Fields = append(t.Fields, tok)
+			p.skipStatement()
+		}
+	}
+	p.spec.Types = append(p.spec.Types, t)
+}
+
+func (p *protoParser) parseService() {
+	service := p.next()
+	if p.spec.Package != "" {
+		service = p.spec.Package + "." + service
+	}
+	p.expect("{")
+	for p.pos < len(p.toks) {
+		switch p.next() {
+		case "}":
+			return
+		case ";":
+		case "rpc":
+			p.parseRPC(service)
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+// parseRPC reads "rpc Name ([stream] Req) returns ([stream] Resp)" and its google.api.http
+// binding, if any
+func (p *protoParser) parseRPC(service string) {
+	name := p.next()
+	e := APIEndpoint{File: p.spec.File, Method: "rpc", Path: "/" + service + "/" + name, Name: name}
+	messageType := func() string {
+		p.expect("(")
+		typ := p.next()
+		if typ == "stream" {
+			typ = "stream " + p.next()
+		}
+		p.expect(")")
+		return typ
+	}
+	e.Request = messageType()
+	p.expect("returns")
+	e.Responses = map[string]string{"response": messageType()}
+
+	if p.expect("{") {
+		depth := 1
+		for depth > 0 && p.pos < len(p.toks) {
+			tok := p.next()
+			switch tok {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			case "get", "put", "post", "delete", "patch":
+				if p.expect(":") && strings.HasPrefix(p.peek(), `"`) && e.Method == "rpc" {
+					e.Method = strings.ToUpper(tok)
+					e.Path = unquote(p.next())
+				}
+			}
+		}
+	} else {
+		p.expect(";")
+	}
+	p.spec.Endpoints = append(p.spec.Endpoints, e)
+}
+
+// FilterAPISpec keeps the endpoints and types that touch a resource: types whose name
+// contains it or that have a field of such a type, and endpoints whose path, name or
+// tags mention it or that take or return one of those types
+func FilterAPISpec(endpoints []APIEndpoint, types []APIType, resource string) ([]APIEndpoint, []APIType) {
+	term := strings.ToLower(resource)
+	matches := func(s string) bool { return strings.Contains(strings.ToLower(s), term) }
+
+	touching := make(map[string]bool)
+	var keptTypes []APIType
+	for _, t := range types {
+		if matches(t.Name) {
+			touching[t.Name] = true
+		}
+	}
+	for _, t := range types {
+		keep := touching[t.Name]
+		for _, f := range t.Fields {
+			if _, typ, ok := strings.Cut(f, ": "); ok && matches(typ) {
+				keep = true
+			}
+		}
+		if keep {
+			touching[t.Name] = true
+			keptTypes = append(keptTypes, t)
+		}
+	}
+
+	usesType := func(typ string) bool {
+		for _, part := range strings.FieldsFunc(typ, func(r rune) bool { return strings.ContainsRune("[]|&<>, ", r) }) {
+			part = strings.TrimPrefix(part, "stream")
+			if touching[part] || touching[part[strings.LastIndex(part, ".")+1:]] {
+				return true
+			}
+		}
+		return matches(typ)
+	}
+	var keptEndpoints []APIEndpoint
+	for _, e := range endpoints {
+		keep := matches(e.Path) || matches(e.Name) || usesType(e.Request)
+		for _, tag := range e.Tags {
+			keep = keep || matches(tag)
+		}
+		for _, typ := range e.Responses {
+			keep = keep || usesType(typ)
+		}
+		if keep {
+			keptEndpoints = append(keptEndpoints, e)
+		}
+	}
+	return keptEndpoints, keptTypes
+}
+
+// findSpecFiles looks for OpenAPI/Swagger documents and .proto files under root
+func findSpecFiles(root string) []string {
+	var files []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || locatorSkippedDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(files) >= maxSpecFiles {
+			return filepath.SkipAll
+		}
+		name := strings.ToLower(d.Name())
+		isOpenAPI := (strings.HasPrefix(name, "openapi") || strings.HasPrefix(name, "swagger")) && dataFormatFor(name) != ""
+		if isOpenAPI || strings.HasSuffix(name, ".proto") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+// APISpecTool summarizes OpenAPI/Swagger specs and protobuf definitions
+type APISpecTool struct{}
+
+// NewAPISpecTool creates a new API spec tool
+func NewAPISpecTool() *APISpecTool {
+	return &APISpecTool{}
+}
+
+// Name returns the tool name
+func (t *APISpecTool) Name() string {
+	return "apiSpec"
+}
+
+// Description returns the tool description
+func (t *APISpecTool) Description() string {
+	return "Summarizes OpenAPI/Swagger specs and .proto files as compact lists of endpoints (method, path, parameters, request and response types) and schemas/messages with their fields. Give a resource name (e.g. \"User\") to list only the endpoints and types that touch it. Use it to answer API questions and to write client code that matches the spec"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *APISpecTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"path": {
+				Type:        "string",
+				Description: "Spec file, or a directory to search for openapi*/swagger* documents and .proto files (default: current directory)",
+			},
+			"resource": {
+				Type:        "string",
+				Description: "Only include endpoints and types that touch this resource or type name",
+			},
+		},
+	}
+}
+
+// ResultSchema describes the summary as a table of endpoints
+func (t *APISpecTool) ResultSchema() ResultSchema {
+	return ResultSchema{
+		Kind:    ResultTable,
+		Rows:    "endpoints",
+		Columns: []string{"method", "path", "name", "request"},
+		Summary: []string{"summary"},
+	}
+}
+
+// Execute summarizes the specs
+func (t *APISpecTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	path, _ := params["path"].(string)
+	if path == "" {
+		path = "."
+	}
+	resource, _ := params["resource"].(string)
+
+	cleanPath, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	info, err := os.Stat(cleanPath)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to access path", Err: err}
+	}
+	files := []string{cleanPath}
+	if info.IsDir() {
+		if files = findSpecFiles(cleanPath); len(files) == 0 {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: fmt.Sprintf("no OpenAPI, Swagger or .proto files found under %s", cleanPath)}
+		}
+	}
+
+	var specs []*APISpec
+	var endpoints []APIEndpoint
+	var types []APIType
+	var skipped []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		spec, err := ParseAPISpec(file, data)
+		if err != nil {
+			if !info.IsDir() {
+				return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to parse spec", Err: err}
+			}
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		specs = append(specs, spec)
+		endpoints = append(endpoints, spec.Endpoints...)
+		types = append(types, spec.Types...)
+	}
+	if resource != "" {
+		endpoints, types = FilterAPISpec(endpoints, types, resource)
+	}
+
+	summary := fmt.Sprintf("%d endpoints and %d types in %d spec files", len(endpoints), len(types), len(specs))
+	if resource != "" {
+		summary = fmt.Sprintf("%d endpoints and %d types touch %q in %d spec files", len(endpoints), len(types), resource, len(specs))
+	}
+	result := map[string]interface{}{
+		"specs":     specs,
+		"endpoints": endpoints,
+		"types":     types,
+	}
+	if len(endpoints) > maxSpecEndpoints {
+		result["endpoints"] = endpoints[:maxSpecEndpoints]
+		summary += fmt.Sprintf(" (showing the first %d endpoints; pass a resource to narrow)", maxSpecEndpoints)
+	}
+	if len(skipped) > 0 {
+		result["skipped"] = skipped
+	}
+	result["summary"] = summary
+	return result, nil
+}
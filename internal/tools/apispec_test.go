@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const sampleOpenAPI = `openapi: 3.0.3
+info:
+  title: Shop
+  version: "1.2"
+paths:
+  /users/{id}:
+    parameters:
+      - $ref: '#/components/parameters/UserID'
+    get:
+      operationId: getUser
+      tags: [users]
+      responses:
+        "200":
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/User'}
+        "404":
+          description: not found
+  /teams:
+    post:
+      operationId: createTeam
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Team'}
+      responses:
+        "201":
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Team'}
+  /health:
+    get:
+      parameters:
+        - {name: verbose, in: query, schema: {type: boolean}}
+      responses:
+        "200": {description: ok}
+components:
+  parameters:
+    UserID: {name: id, in: path, required: true, schema: {type: integer, format: int64}}
+  schemas:
+    User:
+      type: object
+      required: [id]
+      properties:
+        id: {type: integer, format: int64}
+        email: {type: string}
+    Team:
+      type: object
+      properties:
+        members: {type: array, items: {$ref: '#/components/schemas/User'}}
+        labels: {type: object, additionalProperties: {type: string}}
+    Status:
+      type: string
+      enum: [active, disabled]
+`
+
+const sampleProto = `syntax = "proto3";
+package shop.v1;
+
+import "google/api/annotations.proto";
+
+// UserService manages users
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User) {
+    option (google.api.http) = { get: "/v1/users/{id}" };
+  }
+  rpc WatchUsers(WatchRequest) returns (stream User);
+}
+
+message User {
+  int64 id = 1;
+  repeated string emails = 2 [deprecated = true];
+  map<string, string> labels = 3;
+  optional string nickname = 4;
+  oneof contact {
+    string phone = 5;
+    Address address = 6;
+  }
+  message Address { string city = 1; }
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+    ROLE_ADMIN = 1;
+  }
+}
+
+message GetUserRequest { int64 id = 1; }
+message WatchRequest {}
+`
+
+func TestParseOpenAPI(t *testing.T) {
+	spec, err := ParseAPISpec("openapi.yaml", []byte(sampleOpenAPI))
+	if err != nil {
+		t.Fatalf("ParseAPISpec() error = %v", err)
+	}
+	if spec.Format != "openapi 3.0.3" || spec.Title != "Shop" || spec.Version != "1.2" {
+		t.Errorf("spec = %+v", spec)
+	}
+	if len(spec.Endpoints) != 3 {
+		t.Fatalf("Endpoints = %+v", spec.Endpoints)
+	}
+
+	get := spec.Endpoints[2]
+	want := APIEndpoint{
+		File: "openapi.yaml", Method: "GET", Path: "/users/{id}", Name: "getUser", Tags: []string{"users"},
+		Params:    []string{"id (path, integer(int64), required)"},
+		Responses: map[string]string{"200": "User", "404": ""},
+	}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("endpoint = %+v\nwant %+v", get, want)
+	}
+	if spec.Endpoints[1].Request != "Team" || spec.Endpoints[0].Params[0] != "verbose (query, boolean)" {
+		t.Errorf("endpoints = %+v", spec.Endpoints)
+	}
+
+	types := map[string]APIType{}
+	for _, ty := range spec.Types {
+		types[ty.Name] = ty
+	}
+	if got := types["Team"].Fields; !reflect.DeepEqual(got, []string{"labels: map[string]string", "members: []User"}) {
+		t.Errorf("Team fields = %v", got)
+	}
+	if got := types["User"].Fields; !reflect.DeepEqual(got, []string{"email: string", "id: integer(int64) (required)"}) {
+		t.Errorf("User fields = %v", got)
+	}
+	if got := types["Status"]; got.Kind != "enum" || !reflect.DeepEqual(got.Fields, []string{"active", "disabled"}) {
+		t.Errorf("Status = %+v", got)
+	}
+}
+
+func TestParseProto(t *testing.T) {
+	spec, err := ParseAPISpec("user.proto", []byte(sampleProto))
+	if err != nil {
+		t.Fatalf("ParseAPISpec() error = %v", err)
+	}
+	if spec.Format != "proto3" || spec.Package != "shop.v1" {
+		t.Errorf("spec = %+v", spec)
+	}
+
+	wantEndpoints := []APIEndpoint{
+		{File: "user.proto", Method: "GET", Path: "/v1/users/{id}", Name: "GetUser", Request: "GetUserRequest", Responses: map[string]string{"response": "User"}},
+		{File: "user.proto", Method: "rpc", Path: "/shop.v1.UserService/WatchUsers", Name: "WatchUsers", Request: "WatchRequest", Responses: map[string]string{"response": "stream User"}},
+	}
+	if !reflect.DeepEqual(spec.Endpoints, wantEndpoints) {
+		t.Errorf("Endpoints = %+v\nwant %+v", spec.Endpoints, wantEndpoints)
+	}
+
+	var names []string
+	for _, ty := range spec.Types {
+		names = append(names, ty.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"User.Address", "User.Role", "User", "GetUserRequest", "WatchRequest"}) {
+		t.Errorf("types = %v", names)
+	}
+	wantFields := []string{
+		"id: int64", "emails: []string", "labels: map<string, string>", "nickname: string (optional)",
+		"phone: string (oneof contact)", "address: Address (oneof contact)",
+	}
+	if !reflect.DeepEqual(spec.Types[2].Fields, wantFields) {
+		t.Errorf("User fields = %v", spec.Types[2].Fields)
+	}
+	if !reflect.DeepEqual(spec.Types[1].Fields, []string{"ROLE_UNSPECIFIED", "ROLE_ADMIN"}) {
+		t.Errorf("Role values = %v", spec.Types[1].Fields)
+	}
+}
+
+func TestAPISpecToolResource(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "openapi.yaml"), []byte(sampleOpenAPI), 0644)
+	os.MkdirAll(filepath.Join(dir, "proto"), 0755)
+	os.WriteFile(filepath.Join(dir, "proto", "user.proto"), []byte(sampleProto), 0644)
+	os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("a: 1\n"), 0644)
+
+	result, err := NewAPISpecTool().Execute(context.Background(), map[string]interface{}{"path": dir, "resource": "user"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	m := result.(map[string]interface{})
+	if specs := m["specs"].([]*APISpec); len(specs) != 2 {
+		t.Fatalf("specs = %v", specs)
+	}
+
+	var paths []string
+	for _, e := range m["endpoints"].([]APIEndpoint) {
+		paths = append(paths, e.Method+" "+e.Path)
+	}
+	// /teams touches users through Team.members; /health does not
+	want := []string{"POST /teams", "GET /users/{id}", "GET /v1/users/{id}", "rpc /shop.v1.UserService/WatchUsers"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("endpoints = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BaselineFile is where accepted findings are recorded, relative to the project root.
+// It is meant to be committed, so everyone sees only the issues they introduce.
+var BaselineFile = filepath.Join(".codezilla", "baseline.json")
+
+// baselineVersion is bumped when the fingerprints change meaning
+const baselineVersion = 1
+
+// BaselineEntry is an accepted finding. Only the fingerprint is matched; the rest
+// is kept so the file can be reviewed.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Tool        string `json:"tool"` // What reported it, such as "audit" or "scan"
+	Rule        string `json:"rule"`
+	File        string `json:"file,omitempty"`
+	Message     string `json:"message"`
+}
+
+// Baseline holds the findings accepted as known, so later runs only report new ones
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+
+	known map[string]bool
+}
+
+// LoadBaseline reads the baseline of the project in root. A project without one has
+// an empty baseline.
+func LoadBaseline(root string) (*Baseline, error) {
+	b := &Baseline{Version: baselineVersion}
+	data, err := os.ReadFile(filepath.Join(root, BaselineFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", BaselineFile, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("%s has version %d, expected %d; recreate it with -update-baseline", BaselineFile, b.Version, baselineVersion)
+	}
+	return b, nil
+}
+
+// Filter returns the issues not in the baseline and how many were left out
+func (b *Baseline) Filter(root string, issues []CodeIssue) ([]CodeIssue, int) {
+	if b.known == nil {
+		b.known = make(map[string]bool, len(b.Findings))
+		for _, f := range b.Findings {
+			b.known[f.Fingerprint] = true
+		}
+	}
+	kept := make([]CodeIssue, 0, len(issues))
+	for _, issue := range issues {
+		if !b.known[issue.Fingerprint(root)] {
+			kept = append(kept, issue)
+		}
+	}
+	return kept, len(issues) - len(kept)
+}
+
+// Update replaces the findings accepted from tool with issues, keeping those of
+// other tools
+func (b *Baseline) Update(root, tool string, issues []CodeIssue) {
+	findings := make([]BaselineEntry, 0, len(b.Findings)+len(issues))
+	for _, f := range b.Findings {
+		if f.Tool != tool {
+			findings = append(findings, f)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		fp := issue.Fingerprint(root)
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		findings = append(findings, BaselineEntry{
+			Fingerprint: fp,
+			Tool:        tool,
+			Rule:        issue.Rule,
+			File:        relativeIssuePath(root, issue.File),
+			Message:     issue.Message,
+		})
+	}
+	// Sorted so the committed file changes as little as possible
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, c := findings[i], findings[j]
+		if a.Tool != c.Tool {
+			return a.Tool < c.Tool
+		}
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		return a.Fingerprint < c.Fingerprint
+	})
+	b.Findings = findings
+	b.known = nil
+}
+
+// Save writes the baseline of the project in root
+func (b *Baseline) Save(root string) error {
+	path := filepath.Join(root, BaselineFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+	b.Version = baselineVersion
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	root := t.TempDir()
+	known := CodeIssue{File: filepath.Join(root, "a.go"), Line: 4, Rule: "error-handling", Message: "Error from Close is ignored"}
+	fresh := CodeIssue{File: filepath.Join(root, "a.go"), Line: 9, Rule: "security", Message: "Token written to the log"}
+	vuln := CodeIssue{File: "go.mod", Rule: "GO-2023-2102", Message: "golang.org/x/net: HTTP/2 rapid reset"}
+
+	baseline, err := LoadBaseline(root)
+	if err != nil || len(baseline.Findings) != 0 {
+		t.Fatalf("LoadBaseline() without a file = %+v, %v; want an empty baseline", baseline, err)
+	}
+	baseline.Update(root, "scan", []CodeIssue{known, known})
+	baseline.Update(root, "audit", []CodeIssue{vuln})
+	if err := baseline.Save(root); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadBaseline(root)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if len(loaded.Findings) != 2 || loaded.Findings[0].Tool != "audit" || loaded.Findings[1].File != "a.go" {
+		t.Fatalf("saved findings = %+v", loaded.Findings)
+	}
+
+	// The known issue moved down a few lines is still known
+	moved := known
+	moved.Line = 20
+	kept, suppressed := loaded.Filter(root, []CodeIssue{moved, fresh, vuln})
+	if suppressed != 2 || len(kept) != 1 || kept[0].Message != fresh.Message {
+		t.Errorf("Filter() = %+v, %d suppressed; want only the new issue", kept, suppressed)
+	}
+
+	// Updating one tool's findings keeps the other's
+	loaded.Update(root, "scan", nil)
+	if len(loaded.Findings) != 1 || loaded.Findings[0].Tool != "audit" {
+		t.Errorf("after clearing scan findings: %+v", loaded.Findings)
+	}
+	if kept, _ := loaded.Filter(root, []CodeIssue{known}); len(kept) != 1 {
+		t.Error("Filter() still suppresses a finding removed by Update()")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, BaselineFile), []byte(`{"version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(root); err == nil {
+		t.Error("LoadBaseline() accepted an unknown version")
+	}
+}
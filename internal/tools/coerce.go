@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CoerceParams converts parameter values to the types in a tool's schema. XML carries no
+// types, and parameters edited by the user are typed text, so a lone element meant as an
+// array, a number meant as a string or a JSON array written inside an element all need
+// converting before the tool sees them.
+func CoerceParams(params map[string]interface{}, schema JSONSchema) map[string]interface{} {
+	if params == nil || schema.Properties == nil {
+		return params
+	}
+	coerced := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		if prop, ok := schema.Properties[name]; ok {
+			value = coerceValue(value, prop)
+		}
+		coerced[name] = value
+	}
+	return coerced
+}
+
+// coerceValue converts one value to the type in its schema, leaving it unchanged when
+// it can't be converted
+func coerceValue(value interface{}, schema JSONSchema) interface{} {
+	switch schema.Type {
+	case "string":
+		switch v := value.(type) {
+		case bool, int, float64:
+			return fmt.Sprint(v)
+		}
+
+	// Numbers become float64, as JSON numbers decode, which is what tools read
+	case "integer":
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case string:
+			if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return float64(i)
+			}
+		}
+
+	case "number":
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f
+			}
+		}
+
+	case "boolean":
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b
+			}
+		}
+
+	case "array":
+		if s, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(s), "[") {
+			var decoded []interface{}
+			if err := json.Unmarshal([]byte(s), &decoded); err == nil {
+				value = decoded
+			}
+		}
+		// <patterns><pattern>*.log</pattern></patterns> holds a single item
+		if obj, ok := value.(map[string]interface{}); ok && len(obj) == 1 && (schema.Items == nil || schema.Items.Type != "object") {
+			for _, v := range obj {
+				value = v
+			}
+		}
+		list, ok := value.([]interface{})
+		if !ok {
+			if _, isStrings := value.([]string); isStrings || value == nil {
+				return value
+			}
+			list = []interface{}{value}
+		}
+		if schema.Items != nil {
+			for i, item := range list {
+				list[i] = coerceValue(item, *schema.Items)
+			}
+		}
+		return list
+
+	case "object":
+		if s, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(s), "{") {
+			var decoded map[string]interface{}
+			if err := json.Unmarshal([]byte(s), &decoded); err == nil {
+				value = decoded
+			}
+		}
+		if obj, ok := value.(map[string]interface{}); ok {
+			return CoerceParams(obj, schema)
+		}
+	}
+	return value
+}
+
+// checkParamTypes reports the first parameter whose value doesn't have the type in the
+// schema, such as text left in an integer parameter that CoerceParams couldn't convert
+func checkParamTypes(params map[string]interface{}, schema JSONSchema) error {
+	for name, value := range params {
+		prop, ok := schema.Properties[name]
+		if !ok || value == nil {
+			continue
+		}
+		var valid bool
+		switch prop.Type {
+		case "string":
+			_, valid = value.(string)
+		case "integer":
+			f, isNumber := value.(float64)
+			valid = isNumber && f == float64(int64(f))
+		case "number":
+			_, valid = value.(float64)
+		case "boolean":
+			_, valid = value.(bool)
+		case "array":
+			switch value.(type) {
+			case []interface{}, []string:
+				valid = true
+			}
+		case "object":
+			_, valid = value.(map[string]interface{})
+		default:
+			valid = true
+		}
+		if !valid {
+			return fmt.Errorf("parameter %s must be of type %s", name, prop.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoerceParams(t *testing.T) {
+	schema := JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"version":  {Type: "string"},
+			"limit":    {Type: "integer"},
+			"patterns": {Type: "array", Items: &JSONSchema{Type: "string"}},
+			"items": {
+				Type: "array",
+				Items: &JSONSchema{
+					Type: "object",
+					Properties: map[string]JSONSchema{
+						"content":      {Type: "string"},
+						"dependencies": {Type: "array", Items: &JSONSchema{Type: "string"}},
+					},
+				},
+			},
+			"edits": {Type: "array"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		param string
+		value interface{}
+		want  interface{}
+	}{
+		{"number as string", "version", 2, "2"},
+		{"string as integer", "limit", "40", float64(40)},
+		{"int as integer", "limit", 7, float64(7)},
+		{"lone value as array", "patterns", "*.log", []interface{}{"*.log"}},
+		{"wrapper element as array", "patterns", map[string]interface{}{"pattern": "*.log"}, []interface{}{"*.log"}},
+		{"numbers in string array", "patterns", []interface{}{1, "b"}, []interface{}{"1", "b"}},
+		{
+			"lone object as array with nested coercion", "items",
+			map[string]interface{}{"content": 42, "dependencies": "task_1"},
+			[]interface{}{map[string]interface{}{"content": "42", "dependencies": []interface{}{"task_1"}}},
+		},
+		{"JSON text as array", "edits", `[{"file_path": "a.go"}]`, []interface{}{map[string]interface{}{"file_path": "a.go"}}},
+		{"unknown parameter", "other", "7", "7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CoerceParams(map[string]interface{}{tt.param: tt.value}, schema)
+			if !reflect.DeepEqual(got[tt.param], tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.param, got[tt.param], tt.want)
+			}
+		})
+	}
+}
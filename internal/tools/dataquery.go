@@ -0,0 +1,497 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// maxDataQueryChars caps the encoded size of a query result; larger results are
+// replaced by an outline so the model can narrow the query
+const maxDataQueryChars = 20000
+
+// queryStep is one step of a data query path
+type queryStep struct {
+	key      string // Map key, for key steps
+	index    int    // Array index, for index steps; negative counts from the end
+	isIndex  bool
+	wildcard bool // Every element of an array or value of a map
+	// filter keeps the array elements whose field compares to literal
+	filter             bool
+	field, op, literal string
+}
+
+// DataQuery is a parsed query: a path into a document, optionally followed by a
+// function (keys, length or type) applied to each result
+type DataQuery struct {
+	steps    []queryStep
+	function string
+}
+
+// ParseDataQuery parses a jq/JSONPath-like query such as ".server.port",
+// "$.items[0].name", "services[*].image", `.deps["left-pad"]`, ".users[?role==admin].name"
+// or ".scripts | keys"
+func ParseDataQuery(query string) (*DataQuery, error) {
+	q := &DataQuery{}
+	path, function, hasFunction := strings.Cut(query, "|")
+	if hasFunction {
+		q.function = strings.TrimSpace(function)
+		switch q.function {
+		case "keys", "length", "type":
+		default:
+			return nil, fmt.Errorf("unknown function %q (supported: keys, length, type)", q.function)
+		}
+	}
+
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	for i := 0; i < len(path); {
+		switch c := path[i]; {
+		case c == '.':
+			i++
+			if i < len(path) && path[i] == '*' {
+				q.steps = append(q.steps, queryStep{wildcard: true})
+				i++
+			}
+		case c == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ at offset %d", i)
+			}
+			step, err := parseBracketStep(path[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			q.steps = append(q.steps, step)
+			i += end + 1
+		default:
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			key := strings.TrimSpace(path[i:end])
+			if key == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			q.steps = append(q.steps, queryStep{key: key})
+			i = end
+		}
+	}
+	return q, nil
+}
+
+// parseBracketStep parses the inside of [...]: an index, a quoted key, * or a filter
+func parseBracketStep(s string) (queryStep, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "" || s == "*":
+		return queryStep{wildcard: true}, nil
+	case strings.HasPrefix(s, "?"):
+		expr := strings.TrimSpace(strings.Trim(strings.TrimPrefix(s, "?"), "()"))
+		for _, op := range []string{"==", "!="} {
+			if field, literal, ok := strings.Cut(expr, op); ok {
+				field = strings.TrimPrefix(strings.TrimSpace(field), "@")
+				return queryStep{filter: true, field: strings.TrimPrefix(field, "."), op: op, literal: unquote(strings.TrimSpace(literal))}, nil
+			}
+		}
+		return queryStep{}, fmt.Errorf("invalid filter %q (use [?field==value] or [?field!=value])", s)
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		return queryStep{key: unquote(s)}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return queryStep{}, fmt.Errorf("invalid index %q", s)
+	}
+	return queryStep{index: n, isIndex: true}, nil
+}
+
+// unquote strips matching single or double quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// multiple reports whether the query can select more than one value
+func (q *DataQuery) multiple() bool {
+	for _, s := range q.steps {
+		if s.wildcard || s.filter {
+			return true
+		}
+	}
+	return false
+}
+
+// Eval runs the query against a decoded document. A query that selects a single
+// value returns it; one with a wildcard or filter returns a list.
+func (q *DataQuery) Eval(doc interface{}) (interface{}, error) {
+	values := []interface{}{doc}
+	walked := ""
+	for _, step := range q.steps {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, step.apply(v)...)
+		}
+		if len(next) == 0 && !q.multiple() {
+			return nil, missingError(step, values[0], walked)
+		}
+		walked += step.String()
+		values = next
+	}
+
+	if q.function != "" {
+		for i, v := range values {
+			result, err := applyQueryFunction(q.function, v)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = result
+		}
+	}
+	if !q.multiple() {
+		return values[0], nil
+	}
+	if values == nil {
+		values = []interface{}{}
+	}
+	return values, nil
+}
+
+// apply returns the values a step selects from v
+func (s queryStep) apply(v interface{}) []interface{} {
+	switch {
+	case s.wildcard:
+		switch t := v.(type) {
+		case []interface{}:
+			return t
+		case map[string]interface{}:
+			keys := sortedKeys(t)
+			values := make([]interface{}, len(keys))
+			for i, k := range keys {
+				values[i] = t[k]
+			}
+			return values
+		}
+	case s.filter:
+		list, _ := v.([]interface{})
+		var values []interface{}
+		for _, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field, exists := m[s.field]
+			equal := exists && fmt.Sprint(field) == s.literal
+			if equal == (s.op == "==") {
+				values = append(values, item)
+			}
+		}
+		return values
+	case s.isIndex:
+		list, _ := v.([]interface{})
+		i := s.index
+		if i < 0 {
+			i += len(list)
+		}
+		if i >= 0 && i < len(list) {
+			return []interface{}{list[i]}
+		}
+	default:
+		if m, ok := v.(map[string]interface{}); ok {
+			if value, ok := m[s.key]; ok {
+				return []interface{}{value}
+			}
+		}
+	}
+	return nil
+}
+
+// String formats the step in query syntax
+func (s queryStep) String() string {
+	switch {
+	case s.wildcard:
+		return "[*]"
+	case s.filter:
+		return fmt.Sprintf("[?%s%s%s]", s.field, s.op, s.literal)
+	case s.isIndex:
+		return fmt.Sprintf("[%d]", s.index)
+	}
+	return "." + s.key
+}
+
+// missingError explains why step selected nothing from v, listing what is there instead
+func missingError(step queryStep, v interface{}, at string) error {
+	if at == "" {
+		at = "."
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if step.isIndex {
+			return fmt.Errorf("%s is an object, not an array; keys: %s", at, strings.Join(sortedKeys(t), ", "))
+		}
+		return fmt.Errorf("key %q not found at %s; keys: %s", step.key, at, strings.Join(sortedKeys(t), ", "))
+	case []interface{}:
+		if step.isIndex {
+			return fmt.Errorf("index %d out of range at %s (length %d)", step.index, at, len(t))
+		}
+		return fmt.Errorf("%s is an array of length %d; use an index or [*]", at, len(t))
+	}
+	return fmt.Errorf("%s is a %s, not an object or array", at, valueType(v))
+}
+
+// applyQueryFunction applies keys, length or type to v
+func applyQueryFunction(function string, v interface{}) (interface{}, error) {
+	switch function {
+	case "type":
+		return valueType(v), nil
+	case "keys":
+		if m, ok := v.(map[string]interface{}); ok {
+			return sortedKeys(m), nil
+		}
+		return nil, fmt.Errorf("keys needs an object, got %s", valueType(v))
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return len(t), nil
+	case []interface{}:
+		return len(t), nil
+	case string:
+		return len([]rune(t)), nil
+	case nil:
+		return 0, nil
+	}
+	return nil, fmt.Errorf("length needs an object, array or string, got %s", valueType(v))
+}
+
+// valueType names the JSON type of v
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case int, int64, uint64, float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dataFormatFor detects a structured file's format from its extension
+func dataFormatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// DecodeDataFile decodes JSON, YAML or TOML into maps, slices and scalars. An empty
+// format is guessed by trying JSON, then YAML. A YAML stream with several documents
+// decodes to an array of them.
+func DecodeDataFile(data []byte, format string) (interface{}, string, error) {
+	if format == "" {
+		var doc interface{}
+		if json.Unmarshal(data, &doc) == nil {
+			return doc, "json", nil
+		}
+		format = "yaml"
+	}
+
+	switch format {
+	case "json":
+		var doc interface{}
+		err := json.Unmarshal(data, &doc)
+		return doc, format, err
+	case "toml":
+		doc := make(map[string]interface{})
+		err := toml.Unmarshal(data, &doc)
+		return normalizeData(doc), format, err
+	case "yaml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		var docs []interface{}
+		for {
+			var doc interface{}
+			if err := decoder.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, format, err
+			}
+			docs = append(docs, normalizeData(doc))
+		}
+		if len(docs) == 1 {
+			return docs[0], format, nil
+		}
+		return docs, format, nil
+	}
+	return nil, format, fmt.Errorf("unsupported format %q", format)
+}
+
+// normalizeData converts the map and slice types YAML and TOML decoders produce to
+// the ones encoding/json does, so queries see one shape
+func normalizeData(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, value := range t {
+			t[k] = normalizeData(value)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, value := range t {
+			m[fmt.Sprint(k)] = normalizeData(value)
+		}
+		return m
+	case []map[string]interface{}:
+		list := make([]interface{}, len(t))
+		for i, value := range t {
+			list[i] = normalizeData(value)
+		}
+		return list
+	case []interface{}:
+		for i, value := range t {
+			t[i] = normalizeData(value)
+		}
+		return t
+	}
+	return v
+}
+
+// outlineValue describes a value too large to return: its type, size and keys
+func outlineValue(v interface{}) map[string]interface{} {
+	outline := map[string]interface{}{"type": valueType(v)}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		outline["keys"] = sortedKeys(t)
+	case []interface{}:
+		outline["length"] = len(t)
+		if len(t) > 0 {
+			outline["first_element"] = valueType(t[0])
+			if m, ok := t[0].(map[string]interface{}); ok {
+				outline["first_element_keys"] = sortedKeys(m)
+			}
+		}
+	}
+	return outline
+}
+
+// DataQueryTool answers questions about JSON, YAML and TOML files by querying them
+type DataQueryTool struct{}
+
+// NewDataQueryTool creates a new data query tool
+func NewDataQueryTool() *DataQueryTool {
+	return &DataQueryTool{}
+}
+
+// Name returns the tool name
+func (t *DataQueryTool) Name() string {
+	return "dataQuery"
+}
+
+// Description returns the tool description
+func (t *DataQueryTool) Description() string {
+	return "Queries a JSON, YAML or TOML file with a jq/JSONPath-like path and returns just the selected values, e.g. \".server.port\", \"services[*].image\", \".users[?role==admin].name\" or \".scripts | keys\". Use it to look up config values instead of reading the whole file"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *DataQueryTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"path": {
+				Type:        "string",
+				Description: "Path to the JSON, YAML or TOML file",
+			},
+			"query": {
+				Type:        "string",
+				Description: "Path into the document: .key, [index], [\"key\"], [*] for all elements, [?field==value] to filter arrays, optionally followed by | keys, | length or | type. Defaults to the whole document",
+			},
+			"format": {
+				Type:        "string",
+				Description: "File format when the extension does not tell",
+				Enum:        []interface{}{"json", "yaml", "toml"},
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// Execute runs the query
+func (t *DataQueryTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	path, _ := params["path"].(string)
+	queryText, _ := params["query"].(string)
+	format, _ := params["format"].(string)
+
+	query, err := ParseDataQuery(queryText)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("invalid query: %v", err)}
+	}
+	cleanPath, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	data, err := os.ReadFile(cleanPath)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to read file", Err: err}
+	}
+	if format == "" {
+		format = dataFormatFor(cleanPath)
+	}
+
+	doc, format, err := DecodeDataFile(data, format)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: fmt.Sprintf("failed to parse %s", format), Err: err}
+	}
+	value, err := query.Eval(doc)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "query matched nothing", Err: err}
+	}
+
+	result := map[string]interface{}{
+		"path":   cleanPath,
+		"format": format,
+		"query":  queryText,
+	}
+	if encoded, err := json.Marshal(value); err == nil && len(encoded) > maxDataQueryChars {
+		result["truncated"] = true
+		result["outline"] = outlineValue(value)
+		result["note"] = "The result is too large to return; narrow the query using the keys in the outline"
+		return result, nil
+	}
+	result["result"] = value
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataQueryEval(t *testing.T) {
+	doc, _, err := DecodeDataFile([]byte(`
+server:
+  port: 8080
+  hosts: [a.example.com, b.example.com]
+users:
+  - name: ann
+    role: admin
+  - name: bob
+    role: dev
+  - name: cid
+    role: admin
+scripts:
+  test: go test ./...
+  build: go build
+"left-pad": 1.3
+`), "yaml")
+	if err != nil {
+		t.Fatalf("DecodeDataFile() error = %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  interface{}
+	}{
+		{".server.port", 8080},
+		{"$.server.hosts[1]", "b.example.com"},
+		{"server.hosts[-1]", "b.example.com"},
+		{".users[*].name", []interface{}{"ann", "bob", "cid"}},
+		{".users[?role==admin].name", []interface{}{"ann", "cid"}},
+		{".users[?(@.role != 'admin')].name", []interface{}{"bob"}},
+		{`["left-pad"]`, 1.3},
+		{".scripts | keys", []string{"build", "test"}},
+		{".users | length", 3},
+		{".server | type", "object"},
+		{".users[?role==nobody]", []interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseDataQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseDataQuery() error = %v", err)
+			}
+			got, err := q.Eval(doc)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	q, _ := ParseDataQuery(".server.prot")
+	if _, err := q.Eval(doc); err == nil || !strings.Contains(err.Error(), "keys: hosts, port") {
+		t.Errorf("Eval() error = %v, want one listing the available keys", err)
+	}
+	if _, err := ParseDataQuery(".a | sum"); err == nil {
+		t.Error("expected an error for an unknown function")
+	}
+}
+
+func TestDecodeDataFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		query  string
+		want   interface{}
+	}{
+		{"json", `{"a": {"b": [1, 2]}}`, "json", ".a.b[0]", float64(1)},
+		{"toml", "[server]\nport = 9000\n", "toml", ".server.port", int64(9000)},
+		{"toml array of tables", "[[bin]]\nname = \"x\"\n", "toml", ".bin[0].name", "x"},
+		{"guessed yaml", "a: b\n", "", ".a", "b"},
+		{"yaml stream", "kind: A\n---\nkind: B\n", "yaml", "[*].kind", []interface{}{"A", "B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, _, err := DecodeDataFile([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("DecodeDataFile() error = %v", err)
+			}
+			q, _ := ParseDataQuery(tt.query)
+			got, err := q.Eval(doc)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataQueryToolTruncatesLargeResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.json")
+	os.WriteFile(path, []byte(`{"items": ["`+strings.Repeat("x", maxDataQueryChars)+`"]}`), 0644)
+
+	result, err := NewDataQueryTool().Execute(context.Background(), map[string]interface{}{"path": path})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	m := result.(map[string]interface{})
+	if m["truncated"] != true || !reflect.DeepEqual(m["outline"].(map[string]interface{})["keys"], []string{"items"}) {
+		t.Errorf("result = %v", m)
+	}
+}
@@ -0,0 +1,508 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// packageManager describes how to add and remove dependencies with one package manager
+type packageManager struct {
+	name     string
+	marker   string // File whose presence selects this manager
+	manifest string
+	lockfile string
+	add      func(packages []string, dev bool) []string
+	remove   func(packages []string) []string
+	versions func(dir string) map[string]string
+}
+
+// packageManagers are checked in order when detecting the project's package manager.
+// Lockfile markers come before the generic manifests they accompany.
+var packageManagers = []packageManager{
+	{
+		name: "go", marker: "go.mod", manifest: "go.mod", lockfile: "go.sum",
+		add:      func(p []string, _ bool) []string { return append([]string{"go", "get"}, p...) },
+		remove:   func(p []string) []string { return append([]string{"go", "get"}, withSuffix(p, "@none")...) },
+		versions: goModVersions,
+	},
+	{
+		name: "pnpm", marker: "pnpm-lock.yaml", manifest: "package.json", lockfile: "pnpm-lock.yaml",
+		add:      func(p []string, dev bool) []string { return append(flagIf([]string{"pnpm", "add"}, dev, "-D"), p...) },
+		remove:   func(p []string) []string { return append([]string{"pnpm", "remove"}, p...) },
+		versions: packageJSONVersions,
+	},
+	{
+		name: "yarn", marker: "yarn.lock", manifest: "package.json", lockfile: "yarn.lock",
+		add: func(p []string, dev bool) []string {
+			return append(flagIf([]string{"yarn", "add"}, dev, "--dev"), p...)
+		},
+		remove:   func(p []string) []string { return append([]string{"yarn", "remove"}, p...) },
+		versions: packageJSONVersions,
+	},
+	{
+		name: "npm", marker: "package.json", manifest: "package.json", lockfile: "package-lock.json",
+		add: func(p []string, dev bool) []string {
+			return append(flagIf([]string{"npm", "install"}, dev, "--save-dev"), p...)
+		},
+		remove:   func(p []string) []string { return append([]string{"npm", "uninstall"}, p...) },
+		versions: packageJSONVersions,
+	},
+	{
+		name: "uv", marker: "uv.lock", manifest: "pyproject.toml", lockfile: "uv.lock",
+		add:      func(p []string, dev bool) []string { return append(flagIf([]string{"uv", "add"}, dev, "--dev"), p...) },
+		remove:   func(p []string) []string { return append([]string{"uv", "remove"}, p...) },
+		versions: func(dir string) map[string]string { return tomlLockVersions(filepath.Join(dir, "uv.lock")) },
+	},
+	{
+		name: "poetry", marker: "poetry.lock", manifest: "pyproject.toml", lockfile: "poetry.lock",
+		add: func(p []string, dev bool) []string {
+			return append(flagIf([]string{"poetry", "add"}, dev, "--group", "dev"), p...)
+		},
+		remove:   func(p []string) []string { return append([]string{"poetry", "remove"}, p...) },
+		versions: func(dir string) map[string]string { return tomlLockVersions(filepath.Join(dir, "poetry.lock")) },
+	},
+	{
+		name: "pip", marker: "requirements.txt",
+		add: func(p []string, _ bool) []string {
+			return append([]string{pythonCommand(), "-m", "pip", "install"}, p...)
+		},
+		remove: func(p []string) []string {
+			return append([]string{pythonCommand(), "-m", "pip", "uninstall", "-y"}, p...)
+		},
+		versions: pipVersions,
+	},
+}
+
+// packageSpecPattern matches package names with an optional version (pkg, pkg@1.2, pkg==1.2, @scope/pkg@^1)
+var packageSpecPattern = regexp.MustCompile(`^@?[A-Za-z0-9][A-Za-z0-9._/\-]*(\[[A-Za-z0-9,_\-]+\])?([@=<>~^!]{1,2}[A-Za-z0-9.*+_\-^~<>=,]*)?$`)
+
+// DependencyTool adds or removes project dependencies with the project's package manager
+type DependencyTool struct {
+	// Remove selects removeDependency instead of addDependency
+	Remove bool
+	// WorkingDir is the project directory (optional, defaults to the current directory)
+	WorkingDir string
+	// Timeout limits how long the package manager may run
+	Timeout time.Duration
+	// OnOutput, if set, receives the package manager's output live
+	OnOutput OutputFunc
+}
+
+// NewAddDependencyTool creates the addDependency tool
+func NewAddDependencyTool() *DependencyTool {
+	return &DependencyTool{Timeout: 5 * time.Minute}
+}
+
+// NewRemoveDependencyTool creates the removeDependency tool
+func NewRemoveDependencyTool() *DependencyTool {
+	return &DependencyTool{Remove: true, Timeout: 5 * time.Minute}
+}
+
+// Name returns the tool name
+func (t *DependencyTool) Name() string {
+	if t.Remove {
+		return "removeDependency"
+	}
+	return "addDependency"
+}
+
+// Description returns the tool description
+func (t *DependencyTool) Description() string {
+	if t.Remove {
+		return "Removes dependencies with the project's package manager (go, npm, pnpm, yarn, uv, poetry or pip) and reports the removed and updated packages and the lockfile change"
+	}
+	return "Adds dependencies with the project's package manager (go get, npm install, pnpm add, yarn add, uv add, poetry add or pip install) and reports the added and updated packages and the lockfile change"
+}
+
+// Dangerous reports that installing packages runs third-party code
+func (t *DependencyTool) Dangerous() bool {
+	return true
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *DependencyTool) ParameterSchema() JSONSchema {
+	names := make([]interface{}, len(packageManagers))
+	for i, pm := range packageManagers {
+		names[i] = pm.name
+	}
+
+	schema := JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"packages": {
+				Type:        "array",
+				Description: "Packages, optionally with a version (github.com/pkg/errors@v0.9.1, lodash@^4, requests==2.32.0)",
+				Items:       &JSONSchema{Type: "string"},
+			},
+			"manager": {
+				Type:        "string",
+				Description: "Package manager to use (default: detected from the project files)",
+				Enum:        names,
+			},
+		},
+		Required: []string{"packages"},
+	}
+	if !t.Remove {
+		schema.Properties["dev"] = JSONSchema{
+			Type:        "boolean",
+			Description: "Add as a development dependency (npm, pnpm, yarn, uv, poetry)",
+		}
+	}
+	return schema
+}
+
+// Execute runs the package manager and summarizes what changed
+func (t *DependencyTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	var packages []string
+	if list, ok := params["packages"].([]interface{}); ok {
+		for _, p := range list {
+			spec, _ := p.(string)
+			if !packageSpecPattern.MatchString(spec) {
+				return nil, &ErrInvalidToolParams{
+					ToolName: t.Name(),
+					Message:  fmt.Sprintf("invalid package %q", spec),
+				}
+			}
+			packages = append(packages, spec)
+		}
+	}
+	if len(packages) == 0 {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: "at least one package is required"}
+	}
+
+	dir := t.WorkingDir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	name, _ := params["manager"].(string)
+	pm, err := detectPackageManager(dir, name)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	dev, _ := params["dev"].(bool)
+
+	args := pm.add(packages, dev)
+	if t.Remove {
+		args = pm.remove(packages)
+	}
+
+	before := pm.versions(dir)
+	lockBefore := readOptional(dir, pm.lockfile)
+
+	execCtx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(execCtx, args[0], args[1:]...)
+	cmd.Dir = dir
+	output := newTailBuffer(16 * 1024)
+	var w io.Writer = output
+	if t.OnOutput != nil {
+		w = io.MultiWriter(output, &streamWriter{stream: "stdout", fn: t.OnOutput, mu: new(sync.Mutex)})
+	}
+	cmd.Stdout, cmd.Stderr = w, w
+
+	start := time.Now()
+	runErr := cmd.Run()
+
+	result := map[string]interface{}{
+		"manager":     pm.name,
+		"command":     strings.Join(args, " "),
+		"duration_ms": time.Since(start).Milliseconds(),
+		"output":      strings.TrimRight(output.String(), "\n"),
+		"success":     runErr == nil,
+	}
+	if runErr != nil {
+		result["error"] = runErr.Error()
+		if execCtx.Err() == context.DeadlineExceeded {
+			result["error"] = fmt.Sprintf("%s timed out after %s", pm.name, t.Timeout)
+		}
+	}
+
+	added, updated, removed := diffVersions(before, pm.versions(dir))
+	result["added"] = added
+	result["updated"] = updated
+	result["removed"] = removed
+	if pm.lockfile != "" {
+		result["lockfile"] = summarizeLockfileChange(pm.lockfile, lockBefore, readOptional(dir, pm.lockfile))
+	}
+	return result, nil
+}
+
+// detectPackageManager picks the named manager, or the single one the project uses
+func detectPackageManager(dir, name string) (packageManager, error) {
+	if name != "" {
+		for _, pm := range packageManagers {
+			if pm.name == name {
+				return pm, nil
+			}
+		}
+		return packageManager{}, fmt.Errorf("unknown package manager %q", name)
+	}
+
+	var found []packageManager
+	ecosystems := make(map[string]bool)
+	for _, pm := range packageManagers {
+		if _, err := os.Stat(filepath.Join(dir, pm.marker)); err != nil {
+			continue
+		}
+		// A lockfile marker already chose the manager for its manifest
+		ecosystem := pm.manifest
+		if ecosystem == "" {
+			ecosystem = "pyproject.toml"
+		}
+		if ecosystems[ecosystem] {
+			continue
+		}
+		ecosystems[ecosystem] = true
+		found = append(found, pm)
+	}
+
+	switch len(found) {
+	case 0:
+		return packageManager{}, fmt.Errorf("no package manager detected in %s; set manager", dir)
+	case 1:
+		return found[0], nil
+	default:
+		names := make([]string, len(found))
+		for i, pm := range found {
+			names[i] = pm.name
+		}
+		return packageManager{}, fmt.Errorf("several package managers detected (%s); set manager", strings.Join(names, ", "))
+	}
+}
+
+// diffVersions compares dependency versions before and after a change
+func diffVersions(before, after map[string]string) (added, updated, removed []string) {
+	added, updated, removed = []string{}, []string{}, []string{}
+	for name, v := range after {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			added = append(added, fmt.Sprintf("%s %s", name, v))
+		case old != v:
+			updated = append(updated, fmt.Sprintf("%s %s -> %s", name, old, v))
+		}
+	}
+	for name, v := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, fmt.Sprintf("%s %s", name, v))
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(updated)
+	sort.Strings(removed)
+	return added, updated, removed
+}
+
+// summarizeLockfileChange reports the lines added to and removed from a lockfile,
+// ignoring order so regenerated lockfiles don't show up as rewritten
+func summarizeLockfileChange(name, before, after string) map[string]interface{} {
+	counts := make(map[string]int)
+	for _, line := range strings.Split(before, "\n") {
+		counts[line]--
+	}
+	for _, line := range strings.Split(after, "\n") {
+		counts[line]++
+	}
+
+	var plus, minus []string
+	for line, n := range counts {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		for ; n > 0; n-- {
+			plus = append(plus, "+ "+line)
+		}
+		for ; n < 0; n++ {
+			minus = append(minus, "- "+line)
+		}
+	}
+	sort.Strings(plus)
+	sort.Strings(minus)
+
+	changes := append(minus, plus...)
+	const maxLines = 40
+	if len(changes) > maxLines {
+		changes = append(changes[:maxLines], fmt.Sprintf("... %d more changed lines", len(changes)-maxLines))
+	}
+	return map[string]interface{}{
+		"file":          name,
+		"changed":       len(plus)+len(minus) > 0,
+		"lines_added":   len(plus),
+		"lines_removed": len(minus),
+		"diff":          strings.Join(changes, "\n"),
+	}
+}
+
+// GoRequirement is a module required in go.mod
+type GoRequirement struct {
+	Path     string
+	Version  string
+	Indirect bool // Marked "// indirect"
+}
+
+// GoModRequirements returns the modules required in the go.mod in dir
+func GoModRequirements(dir string) ([]GoRequirement, error) {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var requirements []GoRequirement
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := strings.HasSuffix(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		case !inBlock:
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) == 2 {
+			requirements = append(requirements, GoRequirement{Path: fields[0], Version: fields[1], Indirect: indirect})
+		}
+	}
+	return requirements, scanner.Err()
+}
+
+// goModVersions returns the required modules in go.mod
+func goModVersions(dir string) map[string]string {
+	versions := make(map[string]string)
+	requirements, _ := GoModRequirements(dir)
+	for _, r := range requirements {
+		versions[r.Path] = r.Version
+	}
+	return versions
+}
+
+// packageJSONVersions returns the declared dependencies, resolved through package-lock.json when present
+func packageJSONVersions(dir string) map[string]string {
+	versions := make(map[string]string)
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		json.Unmarshal(data, &manifest)
+	}
+	for name, v := range manifest.Dependencies {
+		versions[name] = v
+	}
+	for name, v := range manifest.DevDependencies {
+		versions[name] = v
+	}
+
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package-lock.json")); err == nil && json.Unmarshal(data, &lock) == nil {
+		for name := range versions {
+			if pkg, ok := lock.Packages["node_modules/"+name]; ok && pkg.Version != "" {
+				versions[name] = pkg.Version
+			}
+		}
+	}
+	return versions
+}
+
+// tomlLockVersions returns the packages in a uv.lock or poetry.lock file
+func tomlLockVersions(path string) map[string]string {
+	versions := make(map[string]string)
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+		} `toml:"package"`
+	}
+	if _, err := toml.DecodeFile(path, &lock); err != nil {
+		return versions
+	}
+	for _, pkg := range lock.Package {
+		versions[pkg.Name] = pkg.Version
+	}
+	return versions
+}
+
+// pipVersions returns the packages installed in the active Python environment
+func pipVersions(dir string) map[string]string {
+	versions := make(map[string]string)
+	cmd := exec.Command(pythonCommand(), "-m", "pip", "freeze")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return versions
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if name, version, ok := strings.Cut(strings.TrimSpace(line), "=="); ok {
+			versions[strings.ToLower(name)] = version
+		}
+	}
+	return versions
+}
+
+// pythonCommand returns python3 when available, python otherwise
+func pythonCommand() string {
+	if _, err := exec.LookPath("python3"); err == nil {
+		return "python3"
+	}
+	return "python"
+}
+
+// readOptional returns the contents of dir/name, or "" if it can't be read
+func readOptional(dir, name string) string {
+	if name == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// withSuffix appends suffix to each element
+func withSuffix(values []string, suffix string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = v + suffix
+	}
+	return out
+}
+
+// flagIf appends flags to args when cond is set
+func flagIf(args []string, cond bool, flags ...string) []string {
+	if cond {
+		return append(args, flags...)
+	}
+	return args
+}
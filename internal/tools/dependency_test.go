@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectPackageManager(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    string
+		wantErr bool
+	}{
+		{"go module", []string{"go.mod"}, "go", false},
+		{"npm", []string{"package.json"}, "npm", false},
+		{"pnpm lockfile wins", []string{"package.json", "pnpm-lock.yaml"}, "pnpm", false},
+		{"uv over requirements", []string{"pyproject.toml", "uv.lock", "requirements.txt"}, "uv", false},
+		{"ambiguous", []string{"go.mod", "package.json"}, "", true},
+		{"none", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				os.WriteFile(filepath.Join(dir, f), nil, 0644)
+			}
+			pm, err := detectPackageManager(dir, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if pm.name != tt.want {
+				t.Errorf("manager = %q, want %q", pm.name, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoModVersionsAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example
+
+go 1.23
+
+require github.com/a/one v1.0.0
+
+require (
+	github.com/b/two v0.2.0 // indirect
+	github.com/c/three v3.1.0
+)
+`), 0644)
+
+	before := goModVersions(dir)
+	want := map[string]string{
+		"github.com/a/one":   "v1.0.0",
+		"github.com/b/two":   "v0.2.0",
+		"github.com/c/three": "v3.1.0",
+	}
+	if !reflect.DeepEqual(before, want) {
+		t.Fatalf("goModVersions = %v, want %v", before, want)
+	}
+
+	after := map[string]string{
+		"github.com/a/one":  "v1.1.0",
+		"github.com/b/two":  "v0.2.0",
+		"github.com/d/four": "v0.0.1",
+	}
+	added, updated, removed := diffVersions(before, after)
+	if !reflect.DeepEqual(added, []string{"github.com/d/four v0.0.1"}) ||
+		!reflect.DeepEqual(updated, []string{"github.com/a/one v1.0.0 -> v1.1.0"}) ||
+		!reflect.DeepEqual(removed, []string{"github.com/c/three v3.1.0"}) {
+		t.Errorf("diffVersions = %v, %v, %v", added, updated, removed)
+	}
+}
+
+func TestSummarizeLockfileChange(t *testing.T) {
+	summary := summarizeLockfileChange("go.sum", "a 1\nb 1\n", "b 1\na 2\nc 1\n")
+	if summary["lines_added"] != 2 || summary["lines_removed"] != 1 {
+		t.Errorf("unexpected counts: %v", summary)
+	}
+	if summary["diff"] != "- a 1\n+ a 2\n+ c 1" {
+		t.Errorf("diff = %q", summary["diff"])
+	}
+}
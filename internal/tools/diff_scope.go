@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultDiffContext is how many lines around each change a diff-scoped scan shows
+	defaultDiffContext = 10
+	// defaultDiffQuery is the query of a diff-scoped scan that does not give one
+	defaultDiffQuery = "Review these changes for bugs, regressions, missing error handling and risky code"
+)
+
+// hunkHeaderPattern matches a unified diff hunk header, capturing the old and new
+// line counts and the new-file start
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// diffFile is a file changed by a diff, with the new-file lines the diff adds or
+// changes. A deletion is marked by the line after it.
+type diffFile struct {
+	Path  string   // Slash-separated, as in the diff
+	Lines [][2]int // 1-based inclusive ranges, in order
+}
+
+// parseUnifiedDiff returns the files a unified diff changes, leaving out deleted files
+func parseUnifiedDiff(diff string) []diffFile {
+	var files []diffFile
+	var current *diffFile
+	newLine := 0
+	// Lines left in the current hunk, so content lines starting with "+++" or "---"
+	// are not taken for file headers
+	oldLeft, newLeft := 0, 0
+	mark := func(n int) {
+		if n < 1 {
+			n = 1
+		}
+		if k := len(current.Lines); k > 0 && current.Lines[k-1][1] >= n-1 {
+			if n > current.Lines[k-1][1] {
+				current.Lines[k-1][1] = n
+			}
+			return
+		}
+		current.Lines = append(current.Lines, [2]int{n, n})
+	}
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		if current != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				mark(newLine)
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				mark(newLine)
+				oldLeft--
+			case strings.HasPrefix(line, " ") || line == "":
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = nil
+			path := strings.TrimSpace(strings.TrimPrefix(line, "+++ "))
+			if i := strings.IndexByte(path, '\t'); i >= 0 {
+				path = path[:i]
+			}
+			if path == "/dev/null" {
+				continue
+			}
+			files = append(files, diffFile{Path: strings.TrimPrefix(path, "b/")})
+			current = &files[len(files)-1]
+		case current != nil && strings.HasPrefix(line, "@@"):
+			if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+				oldLeft, newLeft = count(m[1]), count(m[3])
+				newLine, _ = strconv.Atoi(m[2])
+				// A hunk that only deletes gives the line before the deletion
+				if newLeft == 0 {
+					newLine++
+				}
+			}
+		}
+	}
+
+	// Files whose only change was their mode or name have nothing to analyze
+	kept := files[:0]
+	for _, f := range files {
+		if len(f.Lines) > 0 {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// diffExcerpt shows the changed lines of a file with context lines around them.
+// Every line starts with its line number in the new file, and changed lines are
+// marked with +, so findings can point at new-file lines.
+func diffExcerpt(path, content string, changed [][2]int, contextLines int) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	isChanged := func(n int) bool {
+		for _, r := range changed {
+			if n >= r[0] && n <= r[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changed parts of %s. Each line starts with its line number in the new version of the file, and changed lines are marked with +. Cite these line numbers in findings and issues.\n\n", path)
+	last := 0
+	for _, r := range changed {
+		start, end := r[0]-contextLines, r[1]+contextLines
+		if start <= last {
+			start = last + 1
+		}
+		if start < 1 {
+			start = 1
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if start > end {
+			continue
+		}
+		if last > 0 && start > last+1 {
+			b.WriteString("   ...\n")
+		}
+		for n := start; n <= end; n++ {
+			marker := " "
+			if isChanged(n) {
+				marker = "+"
+			}
+			fmt.Fprintf(&b, "%5d %s| %s\n", n, marker, lines[n-1])
+		}
+		last = end
+	}
+	return b.String()
+}
+
+// diffScope finds the files a diff changes under dir, from a patch or else from
+// git diff against ref, and returns their paths with excerpts of the changes and
+// the changed lines of each
+func diffScope(ctx context.Context, dir, ref, patch string, contextLines int) ([]string, map[string]string, map[string][][2]int, error) {
+	if patch == "" {
+		// The ref comes from the model; one starting with "-" would be read as an
+		// option, such as --output writing a file anywhere
+		if strings.HasPrefix(ref, "-") {
+			return nil, nil, nil, fmt.Errorf("invalid diffRef %q: must not start with \"-\"", ref)
+		}
+		var err error
+		// --relative gives paths relative to dir and leaves out changes outside it
+		patch, err = runGit(ctx, dir, "diff", "--no-color", "--no-ext-diff", "--relative", "--unified=0", "--end-of-options", ref, "--")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var files []string
+	excerpts := make(map[string]string)
+	changed := make(map[string][][2]int)
+	for _, f := range parseUnifiedDiff(patch) {
+		rel := filepath.Clean(filepath.FromSlash(f.Path))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		path := filepath.Join(dir, rel)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, path)
+		excerpts[path] = diffExcerpt(f.Path, string(content), f.Lines, contextLines)
+		changed[path] = f.Lines
+	}
+	return files, excerpts, changed, nil
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"codezilla/pkg/logger"
+)
+
+const testPatch = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4,2 @@ import "fmt"
++// added
++var x = 1
+@@ -10 +12 @@ func main() {
+-	fmt.Println("old")
++	fmt.Println("new")
+@@ -20,2 +21,0 @@ func main() {
+--- removed line that looks like a header
+-	removed()
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++one
+++++ two
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	got := parseUnifiedDiff(testPatch)
+	want := []diffFile{
+		{Path: "main.go", Lines: [][2]int{{4, 5}, {12, 12}, {22, 22}}},
+		{Path: "new.txt", Lines: [][2]int{{1, 2}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseUnifiedDiff() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffExcerpt(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, "line")
+	}
+	got := diffExcerpt("a.go", strings.Join(lines, "\n")+"\n", [][2]int{{2, 2}, {5, 5}, {28, 28}}, 1)
+	want := []string{
+		"    1  | line",
+		"    2 +| line",
+		"    3  | line",
+		"    4  | line",
+		"    5 +| line",
+		"    6  | line",
+		"   ...",
+		"   27  | line",
+		"   28 +| line",
+		"   29  | line",
+	}
+	body := strings.SplitN(got, "\n\n", 2)[1]
+	if strings.TrimSuffix(body, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffExcerpt() =\n%s\nwant\n%s", body, strings.Join(want, "\n"))
+	}
+}
+
+func TestDiffScopedScan(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+	llm := &promptRecorder{}
+	analyzer := NewProjectScanAnalyzer(llm, log)
+
+	dir := t.TempDir()
+	var main strings.Builder
+	for i := 1; i <= 40; i++ {
+		main.WriteString("// line\n")
+	}
+	for name, content := range map[string]string{"main.go": main.String(), "new.txt": "one\n+++ two\n", "untouched.go": "package x\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := analyzer.Execute(context.Background(), map[string]interface{}{
+		"dir": dir, "patch": testPatch, "diffContext": float64(2), "showProgress": false, "relevanceThreshold": float64(0),
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	result := out.(*EnhancedProjectScanResult)
+	if result.UserQuery != defaultDiffQuery || len(llm.prompts) != 2 {
+		t.Fatalf("query = %q, %d prompts; want the default review query and one prompt per changed file", result.UserQuery, len(llm.prompts))
+	}
+	var mainPrompt string
+	for _, p := range llm.prompts {
+		if strings.Contains(p, "Changed parts of main.go") {
+			mainPrompt = p
+		}
+	}
+	if !strings.Contains(mainPrompt, "   12 +| // line") || strings.Contains(mainPrompt, "   30  |") {
+		t.Errorf("prompt for main.go should show only the changed lines with context:\n%s", mainPrompt)
+	}
+	for _, r := range result.FileResults {
+		if filepath.Base(r.Path) == "main.go" && !reflect.DeepEqual(r.ChangedLines, [][2]int{{4, 5}, {12, 12}, {22, 22}}) {
+			t.Errorf("ChangedLines = %v", r.ChangedLines)
+		}
+	}
+}
+
+func TestDiffScopeRejectsOptionRefs(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "written")
+	_, _, _, err := diffScope(context.Background(), dir, "--output="+target, "", 3)
+	if err == nil {
+		t.Fatal("diffScope() accepted a ref starting with -")
+	}
+	if _, statErr := os.Stat(target); statErr == nil {
+		t.Errorf("git wrote %s", target)
+	}
+}
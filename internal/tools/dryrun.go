@@ -0,0 +1,64 @@
+package tools
+
+import "context"
+
+// ChangesState reports whether a call may change files, processes or other state.
+// Tools not known to be read-only are assumed to.
+func ChangesState(toolName string, params map[string]interface{}) bool {
+	switch toolName {
+	case "fileRead", "listFiles", "codeSearch", "findDefinition", "parseStackTrace", "analyzeLog", "env", "listProcess",
+		"dataQuery", "previewTable", "apiSpec", "listTasks", "getSnippet",
+		"licenseInventory", "vulnCheck", "todo_list", "todo_analyze",
+		"listIssues", "readIssue", "readPullRequest", "triageCI":
+		return false
+	case "generateChangelog":
+		// Only writes CHANGELOG.md when asked to
+		write, _ := params["write"].(bool)
+		return write
+	case "projectScanAnalyzer":
+		// Only writes files when asked for SARIF output or a new baseline
+		sarifFile, _ := params["sarifFile"].(string)
+		updateBaseline, _ := params["updateBaseline"].(bool)
+		return sarifFile != "" || updateBaseline
+	case "scaffold":
+		// Only lists templates when none is given
+		template, _ := params["template"].(string)
+		return template != ""
+	default:
+		return true
+	}
+}
+
+// WrittenFiles returns the files a call writes, for the tools whose parameters name
+// them: fileWrite and multiEdit. Paths are expanded as the tools expand them, so
+// lib:pkg/foo.go and ~/notes.md name the files actually written.
+func WrittenFiles(toolName string, params map[string]interface{}) []string {
+	var files []string
+	switch toolName {
+	case "fileWrite":
+		if path, _ := params["file_path"].(string); path != "" {
+			files = append(files, ExpandPath(path))
+		}
+	case "multiEdit":
+		edits, _ := params["edits"].([]interface{})
+		for _, e := range edits {
+			edit, _ := e.(map[string]interface{})
+			if path, _ := edit["file_path"].(string); path != "" && !containsString(files, ExpandPath(path)) {
+				files = append(files, ExpandPath(path))
+			}
+		}
+	}
+	return files
+}
+
+// PredictEffect describes what a call would do without running it: a one-line
+// description, followed by the tool's preview when it implements Previewer
+func PredictEffect(ctx context.Context, tool Tool, params map[string]interface{}) string {
+	effect := generateDescription(tool, params)
+	if previewer, ok := tool.(Previewer); ok {
+		if preview, err := previewer.Preview(ctx, params); err == nil && preview != "" {
+			effect += "\n" + preview
+		}
+	}
+	return effect
+}
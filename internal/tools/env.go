@@ -0,0 +1,401 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvVar is a variable defined in a .env file
+type EnvVar struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// secretNamePattern matches variable names that usually hold credentials
+var secretNamePattern = regexp.MustCompile(`(?i)(secret|token|passw(or)?d|pwd|api_?key|access_?key|private|credential|auth|cookie|session|signature|dsn)`)
+
+// urlCredentialsPattern matches the password part of user:password@host in URLs
+var urlCredentialsPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@/\s]+@`)
+
+// envReferencePatterns find environment variable references in source and config files
+var envReferencePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`os\.(?:Getenv|LookupEnv|Setenv)\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`), // Go
+	regexp.MustCompile(`process\.env\.([A-Za-z_][A-Za-z0-9_]*)`),                         // JavaScript
+	regexp.MustCompile(`process\.env\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),         // JavaScript
+	regexp.MustCompile(`os\.environ(?:\.get)?[\[(]\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),  // Python
+	regexp.MustCompile(`os\.getenv\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),                // Python
+	regexp.MustCompile(`env(?:::var)?!?\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),                 // Rust
+	regexp.MustCompile(`ENV\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),                  // Ruby
+	regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?:[:?\-=+][^}]*)?\}`),               // shell, compose, YAML
+}
+
+// ParseDotEnv parses a .env file: KEY=VALUE lines with optional "export", quotes and comments
+func ParseDotEnv(r io.Reader) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		vars = append(vars, EnvVar{Name: name, Value: unquoteEnvValue(strings.TrimSpace(value)), Line: lineNum})
+	}
+	return vars, scanner.Err()
+}
+
+// unquoteEnvValue strips quotes from a .env value, or a trailing comment from an unquoted one
+func unquoteEnvValue(value string) string {
+	if len(value) >= 2 {
+		switch value[0] {
+		case '"':
+			if end := strings.LastIndexByte(value, '"'); end > 0 {
+				return strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1:end])
+			}
+		case '\'':
+			if end := strings.LastIndexByte(value, '\''); end > 0 {
+				return value[1:end]
+			}
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// IsSecretEnvName reports whether a variable name suggests it holds a credential
+func IsSecretEnvName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+// RedactEnvValue hides credentials in a variable's value
+func RedactEnvValue(name, value string) string {
+	if value == "" {
+		return ""
+	}
+	if IsSecretEnvName(name) {
+		return fmt.Sprintf("<redacted, %d chars>", len(value))
+	}
+	return urlCredentialsPattern.ReplaceAllString(value, "${1}<redacted>@")
+}
+
+// EnvTool inspects environment variables without changing anything
+type EnvTool struct{}
+
+// NewEnvTool creates a new env tool
+func NewEnvTool() *EnvTool {
+	return &EnvTool{}
+}
+
+// Name returns the tool name
+func (t *EnvTool) Name() string {
+	return "env"
+}
+
+// Description returns the tool description
+func (t *EnvTool) Description() string {
+	return "Read-only view of environment variables. get lists the current environment, dotenv parses the project's .env files, references reports which variables a file or directory uses and whether they are set. Secret values are always redacted"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *EnvTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"action": {
+				Type:        "string",
+				Description: "The action to perform",
+				Enum:        []interface{}{"get", "dotenv", "references"},
+			},
+			"names": {
+				Type:        "array",
+				Description: "Variables to show for get (default: all)",
+				Items:       &JSONSchema{Type: "string"},
+			},
+			"prefix": {
+				Type:        "string",
+				Description: "Only show variables starting with this prefix for get",
+			},
+			"path": {
+				Type:        "string",
+				Description: "The .env file for dotenv (default: all .env* files in the working directory), or the file or directory to scan for references (default: the working directory)",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+// Execute performs the requested env action
+func (t *EnvTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	action, _ := params["action"].(string)
+	switch action {
+	case "get":
+		return t.get(params), nil
+	case "dotenv":
+		return t.dotenv(params)
+	case "references":
+		return t.references(ctx, params)
+	default:
+		return nil, &ErrInvalidToolParams{
+			ToolName: t.Name(),
+			Message:  fmt.Sprintf("unknown action: %s (expected get, dotenv or references)", action),
+		}
+	}
+}
+
+// get returns the current environment with secrets redacted
+func (t *EnvTool) get(params map[string]interface{}) map[string]interface{} {
+	prefix, _ := params["prefix"].(string)
+	wanted := make(map[string]bool)
+	if names, ok := params["names"].([]interface{}); ok {
+		for _, n := range names {
+			if s, ok := n.(string); ok {
+				wanted[s] = true
+			}
+		}
+	}
+
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if (len(wanted) > 0 && !wanted[name]) || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		vars[name] = RedactEnvValue(name, value)
+	}
+
+	var missing []string
+	for name := range wanted {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	result := map[string]interface{}{
+		"variables": vars,
+		"count":     len(vars),
+	}
+	if len(missing) > 0 {
+		result["unset"] = missing
+	}
+	return result
+}
+
+// dotenv parses .env files and compares them with the environment and with .env.example
+func (t *EnvTool) dotenv(params map[string]interface{}) (interface{}, error) {
+	var files []string
+	if path, _ := params["path"].(string); path != "" {
+		files = []string{path}
+	} else {
+		files = dotEnvFiles(".")
+		if len(files) == 0 {
+			return map[string]interface{}{"files": []interface{}{}, "message": "no .env files in the working directory"}, nil
+		}
+	}
+
+	defined := make(map[string]bool)
+	var example []EnvVar
+	var results []map[string]interface{}
+	for _, file := range files {
+		path, err := ValidateAndCleanPath(file)
+		if err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: "invalid path", Err: err}
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to open .env file", Err: err}
+		}
+		vars, err := ParseDotEnv(f)
+		f.Close()
+		if err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: fmt.Sprintf("failed to parse %s", file), Err: err}
+		}
+
+		isExample := strings.Contains(filepath.Base(path), "example") || strings.Contains(filepath.Base(path), "sample")
+		if isExample {
+			example = append(example, vars...)
+		}
+
+		entries := make([]map[string]interface{}, 0, len(vars))
+		for _, v := range vars {
+			if !isExample {
+				defined[v.Name] = true
+			}
+			_, inEnv := os.LookupEnv(v.Name)
+			entries = append(entries, map[string]interface{}{
+				"name":           v.Name,
+				"value":          RedactEnvValue(v.Name, v.Value),
+				"line":           v.Line,
+				"in_environment": inEnv,
+			})
+		}
+		results = append(results, map[string]interface{}{
+			"file":      file,
+			"variables": entries,
+		})
+	}
+
+	result := map[string]interface{}{"files": results}
+	if len(example) > 0 && len(defined) > 0 {
+		var missing []string
+		for _, v := range example {
+			if _, inEnv := os.LookupEnv(v.Name); !defined[v.Name] && !inEnv {
+				missing = append(missing, v.Name)
+			}
+		}
+		result["missing_from_example"] = missing
+	}
+	return result, nil
+}
+
+// references reports the environment variables used by a file or directory
+func (t *EnvTool) references(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	root, _ := params["path"].(string)
+	if root == "" {
+		root = "."
+	}
+	root, err := ValidateAndCleanPath(root)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "invalid path", Err: err}
+	}
+
+	// Variables defined in .env files next to the scanned path count as configured
+	dotenvDir := root
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		dotenvDir = filepath.Dir(root)
+	}
+	defined := make(map[string]string)
+	for _, file := range dotEnvFiles(dotenvDir) {
+		if f, err := os.Open(file); err == nil {
+			vars, _ := ParseDotEnv(f)
+			f.Close()
+			for _, v := range vars {
+				defined[v.Name] = filepath.Base(file)
+			}
+		}
+	}
+
+	refs := make(map[string][]string)
+	scanned := 0
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if scanned >= 5000 {
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > 1024*1024 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || isBinaryContent(data) {
+			return nil
+		}
+		scanned++
+
+		rel, _ := filepath.Rel(root, path)
+		if rel == "." {
+			rel = filepath.Base(path)
+		}
+		seen := make(map[string]bool)
+		for _, re := range envReferencePatterns {
+			for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+				if !seen[m[1]] {
+					seen[m[1]] = true
+					refs[m[1]] = append(refs[m[1]], rel)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to scan files", Err: err}
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unset []string
+	variables := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		_, inEnv := os.LookupEnv(name)
+		entry := map[string]interface{}{
+			"name":           name,
+			"files":          refs[name],
+			"in_environment": inEnv,
+		}
+		if file, ok := defined[name]; ok {
+			entry["defined_in"] = file
+		} else if !inEnv {
+			unset = append(unset, name)
+		}
+		variables = append(variables, entry)
+	}
+
+	return map[string]interface{}{
+		"path":          root,
+		"files_scanned": scanned,
+		"variables":     variables,
+		"unset":         unset,
+	}, nil
+}
+
+// dotEnvFiles returns the .env files in dir
+func dotEnvFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, ".env*"))
+	var files []string
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+			files = append(files, m)
+		}
+	}
+	return files
+}
+
+// isBinaryContent reports whether data looks like a binary file
+func isBinaryContent(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	for _, b := range data {
+		if b == 0 {
+			return true
+		}
+	}
+	return false
+}
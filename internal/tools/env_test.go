@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	input := `# database
+export DB_HOST=localhost
+DB_PASSWORD="p@ss \"word\""
+GREETING='hello # not a comment'
+PORT=8080 # trailing comment
+EMPTY=
+`
+	vars, err := ParseDotEnv(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDotEnv failed: %v", err)
+	}
+
+	want := []EnvVar{
+		{"DB_HOST", "localhost", 2},
+		{"DB_PASSWORD", `p@ss "word"`, 3},
+		{"GREETING", "hello # not a comment", 4},
+		{"PORT", "8080", 5},
+		{"EMPTY", "", 6},
+	}
+	if len(vars) != len(want) {
+		t.Fatalf("got %d vars, want %d: %v", len(vars), len(want), vars)
+	}
+	for i, w := range want {
+		if vars[i] != w {
+			t.Errorf("var %d = %+v, want %+v", i, vars[i], w)
+		}
+	}
+
+	if _, err := ParseDotEnv(strings.NewReader("not a variable")); err == nil {
+		t.Errorf("expected an error for a line without =")
+	}
+}
+
+func TestRedactEnvValue(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"GITHUB_TOKEN", "ghp_abc", "<redacted, 7 chars>"},
+		{"DB_PASSWORD", "hunter2", "<redacted, 7 chars>"},
+		{"DATABASE_URL", "postgres://app:hunter2@db:5432/app", "postgres://app:<redacted>@db:5432/app"},
+		{"HOME", "/home/dev", "/home/dev"},
+	}
+	for _, tt := range tests {
+		if got := RedactEnvValue(tt.name, tt.value); got != tt.want {
+			t.Errorf("RedactEnvValue(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnvToolReferences(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte(`port := os.Getenv("APP_PORT")
+key, _ := os.LookupEnv("APP_SECRET_KEY")`), 0644)
+	os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("image: app:${APP_VERSION:-latest}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_PORT=8080\n"), 0644)
+
+	result, err := NewEnvTool().Execute(context.Background(), map[string]interface{}{
+		"action": "references",
+		"path":   dir,
+	})
+	if err != nil {
+		t.Fatalf("references failed: %v", err)
+	}
+	res := result.(map[string]interface{})
+
+	vars := res["variables"].([]map[string]interface{})
+	if len(vars) != 3 {
+		t.Fatalf("got %d variables, want 3: %v", len(vars), vars)
+	}
+	if vars[0]["name"] != "APP_PORT" || vars[0]["defined_in"] != ".env" {
+		t.Errorf("APP_PORT should be defined in .env: %v", vars[0])
+	}
+	unset := strings.Join(res["unset"].([]string), ",")
+	if unset != "APP_SECRET_KEY,APP_VERSION" {
+		t.Errorf("unset = %q", unset)
+	}
+}
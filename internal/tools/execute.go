@@ -1,15 +1,18 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"codezilla/internal/platform"
 )
 
 // ExecuteTool allows executing shell commands
@@ -22,6 +25,29 @@ type ExecuteTool struct {
 	WorkingDir string
 	// DisableShell prevents shell execution entirely
 	DisableShell bool
+	// MaxOutputBytes caps the output kept per stream; the oldest output is dropped first
+	MaxOutputBytes int
+	// OnOutput, if set, receives output live while the command runs
+	OnOutput OutputFunc
+	// PTY controls whether commands may request a pseudo-terminal
+	PTY PTYPolicy
+	// TrackChanges, if set, is called before each command runs; the function it returns
+	// (if any) is called once the command finishes and reports the files it changed
+	TrackChanges func(ctx context.Context) func(ctx context.Context) []FileChange
+}
+
+// Kinds of file change reported for a command
+const (
+	FileCreated  = "created"
+	FileModified = "modified"
+	FileDeleted  = "deleted"
+)
+
+// FileChange is a file a command created, modified or deleted
+type FileChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Size int64  `json:"size,omitempty"` // Size after the change; 0 when deleted
 }
 
 // NewExecuteTool creates a new execute tool with the given timeout
@@ -30,8 +56,10 @@ func NewExecuteTool(timeout time.Duration) *ExecuteTool {
 		timeout = 30 * time.Second
 	}
 	return &ExecuteTool{
-		Timeout:      timeout,
-		DisableShell: true, // Safe by default
+		Timeout:        timeout,
+		DisableShell:   true, // Safe by default
+		MaxOutputBytes: DefaultMaxOutputBytes,
+		PTY:            PTYOff,
 	}
 }
 
@@ -40,6 +68,12 @@ func (t *ExecuteTool) Name() string {
 	return "execute"
 }
 
+// Destructive reports commands that are hard or impossible to undo, such as rm -rf /
+func (t *ExecuteTool) Destructive(params map[string]interface{}) string {
+	command, _ := params["command"].(string)
+	return DestructiveCommand(command)
+}
+
 // Description returns the tool description
 func (t *ExecuteTool) Description() string {
 	return "Executes a shell command and returns its output"
@@ -47,7 +81,7 @@ func (t *ExecuteTool) Description() string {
 
 // ParameterSchema returns the JSON schema for this tool's parameters
 func (t *ExecuteTool) ParameterSchema() JSONSchema {
-	return JSONSchema{
+	schema := JSONSchema{
 		Type: "object",
 		Properties: map[string]JSONSchema{
 			"command": {
@@ -63,6 +97,13 @@ func (t *ExecuteTool) ParameterSchema() JSONSchema {
 		},
 		Required: []string{"command"},
 	}
+	if t.PTY == PTYAllow || t.PTY == PTYTakeover {
+		schema.Properties["pty"] = JSONSchema{
+			Type:        "boolean",
+			Description: "Run the command in a pseudo-terminal, for programs that need a TTY (confirm prompts, pagers, ssh). Output is returned as stdout.",
+		}
+	}
+	return schema
 }
 
 // Execute runs the shell command and returns its output
@@ -89,6 +130,14 @@ func (t *ExecuteTool) Execute(ctx context.Context, params map[string]interface{}
 		}
 	}
 
+	usePTY, _ := params["pty"].(bool)
+	if usePTY && t.PTY != PTYAllow && t.PTY != PTYTakeover {
+		return nil, &ErrInvalidToolParams{
+			ToolName: t.Name(),
+			Message:  "pseudo-terminal execution is disabled (execute_pty is off)",
+		}
+	}
+
 	// Extract timeout if provided
 	timeout := t.Timeout
 	if timeoutMs, ok := params["timeout_ms"].(float64); ok {
@@ -113,7 +162,7 @@ func (t *ExecuteTool) Execute(ctx context.Context, params map[string]interface{}
 		cmd = exec.CommandContext(execCtx, args[0], args[1:]...)
 	} else {
 		// Use shell execution (less safe, but sometimes necessary)
-		cmd = exec.CommandContext(execCtx, "sh", "-c", cmdStr)
+		cmd = platform.Current().Command(execCtx, cmdStr)
 	}
 
 	// Set working directory if specified
@@ -124,22 +173,55 @@ func (t *ExecuteTool) Execute(ctx context.Context, params map[string]interface{}
 	// Set clean environment to prevent injection via env vars
 	cmd.Env = getCleanEnvironment()
 
-	// Capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Capture the tail of stdout and stderr, streaming them live if requested
+	stdout := newTailBuffer(t.MaxOutputBytes)
+	stderr := newTailBuffer(t.MaxOutputBytes)
+	var stdoutW, stderrW io.Writer = stdout, stderr
+	if t.OnOutput != nil {
+		var mu sync.Mutex
+		stdoutW = io.MultiWriter(stdout, &streamWriter{stream: "stdout", fn: t.OnOutput, mu: &mu})
+		stderrW = io.MultiWriter(stderr, &streamWriter{stream: "stderr", fn: t.OnOutput, mu: &mu})
+	}
+
+	var changedFiles func(context.Context) []FileChange
+	if t.TrackChanges != nil {
+		changedFiles = t.TrackChanges(ctx)
+	}
 
-	// Run command
+	// Run command. A pseudo-terminal merges stderr into stdout.
 	startTime := time.Now()
-	err := cmd.Run()
+	var err error
+	if usePTY {
+		if os.Getenv("TERM") == "" {
+			cmd.Env = append(cmd.Env, "TERM=xterm")
+		}
+		err = runInPTY(execCtx, cmd, stdoutW, t.PTY == PTYTakeover)
+	} else {
+		cmd.Stdout, cmd.Stderr = stdoutW, stderrW
+		err = cmd.Run()
+	}
 	duration := time.Since(startTime)
 
 	// Prepare result
+	truncated := stdout.Truncated() || stderr.Truncated()
 	result := map[string]interface{}{
-		"command":     cmdStr,
-		"stdout":      stdout.String(),
-		"stderr":      stderr.String(),
-		"duration_ms": duration.Milliseconds(),
+		"command":      cmdStr,
+		"stdout":       stdout.String(),
+		"stderr":       stderr.String(),
+		"duration_ms":  duration.Milliseconds(),
+		"stdout_bytes": stdout.Total(),
+		"stderr_bytes": stderr.Total(),
+		"truncated":    truncated,
+	}
+	if usePTY {
+		result["pty"] = true
+		// Terminals end lines with \r\n
+		result["stdout"] = strings.ReplaceAll(result["stdout"].(string), "\r\n", "\n")
+	}
+	if changedFiles != nil {
+		if changes := changedFiles(ctx); len(changes) > 0 {
+			result["changed_files"] = changes
+		}
 	}
 
 	// Handle errors
@@ -156,6 +238,7 @@ func (t *ExecuteTool) Execute(ctx context.Context, params map[string]interface{}
 			// Other error
 			result["error"] = err.Error()
 		}
+		result["summary"] = executionSummary(result, duration, stdout.Total()+stderr.Total(), truncated, t.MaxOutputBytes)
 		return result, nil
 	}
 
@@ -165,18 +248,55 @@ func (t *ExecuteTool) Execute(ctx context.Context, params map[string]interface{}
 
 	// Trim trailing newlines from stdout for cleaner output
 	result["stdout"] = strings.TrimRight(result["stdout"].(string), "\n")
+	result["summary"] = executionSummary(result, duration, stdout.Total()+stderr.Total(), truncated, t.MaxOutputBytes)
 
 	return result, nil
 }
 
-// Helper function to create pointer to float64
-func ptr(v float64) *float64 {
-	return &v
+// executionSummary describes how a command finished in one line
+func executionSummary(result map[string]interface{}, duration time.Duration, outputBytes int64, truncated bool, limit int) string {
+	var status string
+	switch {
+	case result["timed_out"] == true:
+		status = "timed out"
+	case result["exit_code"] != nil:
+		status = fmt.Sprintf("exit code %v", result["exit_code"])
+	default:
+		status = fmt.Sprintf("failed: %v", result["error"])
+	}
+
+	summary := fmt.Sprintf("%s after %s, %s of output", status, duration.Round(time.Millisecond), formatBytes(outputBytes))
+	if truncated {
+		summary += fmt.Sprintf(" (only the last %s per stream kept)", formatBytes(int64(limit)))
+	}
+	if changes, _ := result["changed_files"].([]FileChange); len(changes) > 0 {
+		summary += "; " + DescribeFileChanges(changes)
+	}
+	return summary
 }
 
-// validateCommand checks if the command is safe to execute
-func (t *ExecuteTool) validateCommand(cmdStr string) error {
-	// Check for common dangerous patterns
+// DescribeFileChanges summarizes file changes in a phrase, such as
+// "changed 3 files (1 created, 2 modified)"
+func DescribeFileChanges(changes []FileChange) string {
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Kind]++
+	}
+	var parts []string
+	for _, kind := range []string{FileCreated, FileModified, FileDeleted} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	noun := "files"
+	if len(changes) == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("changed %d %s (%s)", len(changes), noun, strings.Join(parts, ", "))
+}
+
+// checkDangerousPatterns rejects commands matching common destructive patterns
+func checkDangerousPatterns(cmdStr string) error {
 	dangerousPatterns := []string{
 		`;\\s*rm\\s+-rf`,
 		`&&\\s*rm\\s+-rf`,
@@ -192,6 +312,19 @@ func (t *ExecuteTool) validateCommand(cmdStr string) error {
 			return fmt.Errorf("potentially dangerous command pattern detected")
 		}
 	}
+	return nil
+}
+
+// Helper function to create pointer to float64
+func ptr(v float64) *float64 {
+	return &v
+}
+
+// validateCommand checks if the command is safe to execute
+func (t *ExecuteTool) validateCommand(cmdStr string) error {
+	if err := checkDangerousPatterns(cmdStr); err != nil {
+		return err
+	}
 
 	// If whitelist is configured, check against it
 	if len(t.AllowedCommands) > 0 {
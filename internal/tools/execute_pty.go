@@ -0,0 +1,16 @@
+package tools
+
+// PTYPolicy controls whether the execute tool may run commands in a pseudo-terminal
+type PTYPolicy string
+
+const (
+	// PTYOff never allocates a pseudo-terminal
+	PTYOff PTYPolicy = "off"
+	// PTYAllow lets the model request a pseudo-terminal for commands that need a TTY
+	PTYAllow PTYPolicy = "allow"
+	// PTYTakeover additionally forwards the user's keystrokes to the command
+	PTYTakeover PTYPolicy = "takeover"
+)
+
+// ptyDetachKey stops forwarding keystrokes to an interactive command (Ctrl-])
+const ptyDetachKey = 0x1d
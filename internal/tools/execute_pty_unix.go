@@ -0,0 +1,98 @@
+//go:build !windows
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/creack/pty"
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// runInPTY runs cmd attached to a pseudo-terminal, copying everything it prints to out.
+// With takeover set and a terminal on stdin, the user's keystrokes are forwarded to the
+// command until it exits or the user presses Ctrl-].
+func runInPTY(ctx context.Context, cmd *exec.Cmd, out io.Writer, takeover bool) error {
+	stdinFd := int(os.Stdin.Fd())
+	interactive := takeover && term.IsTerminal(stdinFd)
+
+	size := &pty.Winsize{Rows: 24, Cols: 120}
+	if interactive {
+		if w, h, err := term.GetSize(stdinFd); err == nil {
+			size = &pty.Winsize{Rows: uint16(h), Cols: uint16(w)}
+		}
+	}
+
+	f, err := pty.StartWithSize(cmd, size)
+	if err != nil {
+		return fmt.Errorf("failed to start command in a pseudo-terminal: %w", err)
+	}
+	defer f.Close()
+
+	// Closing the terminal unblocks the copy below if the command is killed on timeout
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	if interactive {
+		if state, err := term.MakeRaw(stdinFd); err == nil {
+			var once sync.Once
+			restore := func() { once.Do(func() { term.Restore(stdinFd, state) }) }
+			defer restore()
+
+			fmt.Fprint(os.Stderr, "\r\n[interactive command: your input goes to the command, Ctrl-] stops forwarding]\r\n")
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				forwardInput(stdinFd, f, done)
+				restore()
+			}()
+		}
+	}
+
+	// Reading the terminal fails with EIO once the command exits; that is the normal end of output
+	io.Copy(out, f)
+	return cmd.Wait()
+}
+
+// forwardInput copies keystrokes from fd to dst until done is closed or the
+// detach key is pressed. It polls so that no keystroke is consumed after done.
+func forwardInput(fd int, dst io.Writer, done <-chan struct{}) {
+	buf := make([]byte, 1024)
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		ready, err := unix.Poll(fds, 100)
+		if err == unix.EINTR || (err == nil && ready == 0) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		n, err := unix.Read(fd, buf)
+		if err != nil || n <= 0 {
+			return
+		}
+		if i := bytes.IndexByte(buf[:n], ptyDetachKey); i >= 0 {
+			dst.Write(buf[:i])
+			return
+		}
+		if _, err := dst.Write(buf[:n]); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// runInPTY is not supported on Windows
+func runInPTY(ctx context.Context, cmd *exec.Cmd, out io.Writer, takeover bool) error {
+	return fmt.Errorf("pseudo-terminal execution is not supported on Windows")
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		writes    []string
+		want      string
+		truncated bool
+	}{
+		{"under limit", 16, []string{"abc", "def"}, "abcdef", false},
+		{"exact limit", 6, []string{"abc", "def"}, "abcdef", false},
+		{"drops oldest", 8, []string{"one\n", "two\n", "three\n"}, "[... 8 bytes omitted ...]\nthree\n", true},
+		{"single large write", 4, []string{"abcdefgh"}, "[... 4 bytes omitted ...]\nefgh", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTailBuffer(tt.limit)
+			for _, w := range tt.writes {
+				b.Write([]byte(w))
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if b.Truncated() != tt.truncated {
+				t.Errorf("Truncated() = %v, want %v", b.Truncated(), tt.truncated)
+			}
+		})
+	}
+}
+
+func TestExecuteToolStreamsAndCapsOutput(t *testing.T) {
+	tool := NewExecuteTool(10 * time.Second)
+	tool.MaxOutputBytes = 32
+
+	var mu sync.Mutex
+	var streamed strings.Builder
+	tool.OnOutput = func(stream, chunk string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stream == "stdout" {
+			streamed.WriteString(chunk)
+		}
+	}
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"command": "sh -c 'for i in 1 2 3 4 5 6 7 8 9 10; do echo line$i; done'",
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	res := result.(map[string]interface{})
+
+	if !strings.Contains(streamed.String(), "line1\n") || !strings.Contains(streamed.String(), "line10\n") {
+		t.Errorf("streamed output incomplete: %q", streamed.String())
+	}
+	if res["truncated"] != true {
+		t.Errorf("truncated = %v, want true", res["truncated"])
+	}
+	stdout := res["stdout"].(string)
+	if strings.Contains(stdout, "line1\n") || !strings.HasSuffix(stdout, "line10") {
+		t.Errorf("stdout should keep only the tail, got %q", stdout)
+	}
+	if res["stdout_bytes"] != int64(61) {
+		t.Errorf("stdout_bytes = %v, want 61", res["stdout_bytes"])
+	}
+	if summary, _ := res["summary"].(string); !strings.HasPrefix(summary, "exit code 0") || !strings.Contains(summary, "last 32 B") {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
+
+func TestExecuteToolPTY(t *testing.T) {
+	params := map[string]interface{}{
+		"command": "sh -c 'if [ -t 1 ]; then echo tty; else echo pipe; fi'",
+		"pty":     true,
+	}
+
+	tool := NewExecuteTool(10 * time.Second)
+	if _, err := tool.Execute(context.Background(), params); err == nil {
+		t.Fatalf("expected an error when PTY execution is off")
+	}
+
+	tool.PTY = PTYAllow
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	res := result.(map[string]interface{})
+	if res["success"] != true {
+		t.Skipf("pseudo-terminals unavailable: %v", res["error"])
+	}
+	if res["stdout"] != "tty" {
+		t.Errorf("stdout = %q, want %q", res["stdout"], "tty")
+	}
+}
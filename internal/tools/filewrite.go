@@ -3,12 +3,26 @@ package tools
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+
+	"codezilla/pkg/style"
 )
 
 // FileWriteTool allows writing content to a file
-type FileWriteTool struct{}
+type FileWriteTool struct {
+	// Output, if set, is where the diff of a write is shown instead of stderr
+	Output io.Writer
+}
+
+// output is where the tool shows the diffs of its writes
+func (t *FileWriteTool) output() io.Writer {
+	if t.Output != nil {
+		return t.Output
+	}
+	return os.Stderr
+}
 
 // NewFileWriteTool creates a new file write tool
 func NewFileWriteTool() *FileWriteTool {
@@ -89,7 +103,8 @@ func (t *FileWriteTool) Execute(ctx context.Context, params map[string]interface
 		skipDiff = skipDiffParam
 	}
 
-	// Expand ~ to home directory
+	// Expand ~ to home directory and lib:path to the project root named lib
+	filePath = ExpandRootPath(filePath)
 	if len(filePath) > 0 && filePath[0] == '~' {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
@@ -148,11 +163,12 @@ func (t *FileWriteTool) Execute(ctx context.Context, params map[string]interface
 		// Add diff to params with special "_" prefix to indicate it's internal
 		params["_fileDiff"] = diffOutput
 
-		// Also print the diff directly to stderr for immediate visibility
-		fmt.Fprintf(os.Stderr, "\n==== FILE DIFF ====\n")
-		fmt.Fprintf(os.Stderr, "File: %s\n", filePath)
-		fmt.Fprintf(os.Stderr, "%s\n", diffOutput)
-		fmt.Fprintf(os.Stderr, "================\n\n")
+		// Also print the diff directly for immediate visibility
+		out := t.output()
+		fmt.Fprintf(out, "\n==== FILE DIFF ====\n")
+		fmt.Fprintf(out, "File: %s\n", DisplayPath(filePath))
+		fmt.Fprintf(out, "%s\n", style.Text(diffOutput))
+		fmt.Fprintf(out, "================\n\n")
 	}
 
 	// Determine flags based on append mode
@@ -192,15 +208,16 @@ func (t *FileWriteTool) Execute(ctx context.Context, params map[string]interface
 
 	// If a diff was generated, show it again after writing (so user can see what was changed)
 	if fileExists && !skipDiff && diffOutput != "No changes detected." {
-		fmt.Fprintf(os.Stderr, "\n==== CHANGES WRITTEN ====\n")
-		fmt.Fprintf(os.Stderr, "File updated: %s\n", filePath)
-		fmt.Fprintf(os.Stderr, "Bytes written: %d\n", len(content))
+		out := t.output()
+		fmt.Fprintf(out, "\n==== CHANGES WRITTEN ====\n")
+		fmt.Fprintf(out, "File updated: %s\n", DisplayPath(filePath))
+		fmt.Fprintf(out, "Bytes written: %d\n", len(content))
 		if append {
-			fmt.Fprintf(os.Stderr, "Mode: Append\n")
+			fmt.Fprintf(out, "Mode: Append\n")
 		} else {
-			fmt.Fprintf(os.Stderr, "Mode: Overwrite\n")
+			fmt.Fprintf(out, "Mode: Overwrite\n")
 		}
-		fmt.Fprintf(os.Stderr, "======================\n\n")
+		fmt.Fprintf(out, "======================\n\n")
 	}
 
 	result := map[string]interface{}{
@@ -0,0 +1,295 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Issue severities, from least to most serious
+const (
+	IssueSeverityLow    = "low"
+	IssueSeverityMedium = "medium"
+	IssueSeverityHigh   = "high"
+)
+
+// issueSeverityRank orders severities so the most serious of duplicates is kept
+var issueSeverityRank = map[string]int{
+	IssueSeverityLow:    1,
+	IssueSeverityMedium: 2,
+	IssueSeverityHigh:   3,
+}
+
+const (
+	// issueLineSlack is how far apart two findings' lines may be and still describe
+	// the same problem
+	issueLineSlack = 3
+	// issueSimilarity is the share of words two findings must have in common to be
+	// taken for the same problem
+	issueSimilarity = 0.5
+)
+
+// analyzerConfidence is how far a finding from each analyzer is trusted on its own.
+// Model findings are usually real; the keyword fallbacks only guess.
+var analyzerConfidence = map[string]float64{
+	"llm":      0.6,
+	"fallback": 0.2,
+	"default":  0.2,
+}
+
+var (
+	// explicitSeverityPattern matches a finding that states its severity up front,
+	// such as "[high] ..." or "Critical: ..."
+	explicitSeverityPattern = regexp.MustCompile(`(?i)^\s*\[?(critical|high|medium|moderate|low|minor)\]?\s*[:\-\]]\s*`)
+	// chunkLabelPattern matches the "lines a-b: " label mergeChunkAnalyses puts on findings
+	chunkLabelPattern = regexp.MustCompile(`^lines (\d+)-(\d+): `)
+	// lineReferencePattern matches a line the finding's text points at
+	lineReferencePattern = regexp.MustCompile(`(?i)\blines? (\d+)(?:\s*(?:-|to)\s*(\d+))?`)
+	// highSeverityWords mark findings that are serious whatever the model called them
+	highSeverityWords = []string{"injection", "data race", "race condition", "deadlock", "data loss", "panic", "crash", "vulnerab", "overflow", "hardcoded secret", "hard-coded secret"}
+	// issueStopWords are left out when comparing findings
+	issueStopWords = map[string]bool{
+		"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "not": true,
+		"are": true, "was": true, "may": true, "can": true, "could": true, "should": true, "when": true,
+		"from": true, "into": true, "file": true, "function": true, "code": true, "line": true, "lines": true,
+		"which": true, "there": true, "its": true, "has": true, "have": true, "does": true, "without": true,
+	}
+)
+
+// CodeIssue is a problem found in a file, merged from every analyzer and chunk that
+// reported it
+type CodeIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"` // 1-based; 0 when unknown
+	EndLine  int    `json:"end_line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Rule is the kind of issue, one of IssueRules for model findings
+	Rule string `json:"rule"`
+	URL  string `json:"url,omitempty"` // Advisory or documentation
+	// Sources name the analyzers that reported it, as category/analyzer/field
+	Sources []string `json:"sources"`
+	// Confidence that the issue is real, higher when several analyzers agree
+	Confidence  float64 `json:"confidence,omitempty"`
+	Occurrences int     `json:"occurrences"`
+
+	words map[string]bool
+}
+
+// collectIssues gathers the issues and code smells of analyzed files, merges those
+// describing the same problem, and orders them by severity and confidence
+func collectIssues(results []FileResult, categories map[string]FileCategory) []CodeIssue {
+	var issues []CodeIssue
+	for _, r := range results {
+		analyzer := r.Analysis.Metadata["analyzer"]
+		add := func(text, severity, field string) {
+			source := fmt.Sprintf("%s/%s/%s", categories[r.Path], analyzer, field)
+			issue := newCodeIssue(r.Path, text, severity, source, analyzer)
+			issue.Rule = ClassifyIssue(issue.Message, field == "code_smells")
+			issues = append(issues, issue)
+		}
+		for _, text := range r.Analysis.Issues {
+			add(text, IssueSeverityMedium, "issues")
+		}
+		for _, text := range r.Analysis.CodeSmells {
+			add(text, IssueSeverityLow, "code_smells")
+		}
+		for i, q := range r.Analysis.Queries {
+			for _, text := range q.Issues {
+				add(text, IssueSeverityMedium, fmt.Sprintf("query %d", i+1))
+			}
+		}
+	}
+	return dedupeIssues(issues)
+}
+
+// newCodeIssue parses a finding's text into an issue, taking its lines from the
+// chunk label or the text and its severity from the text when it states one
+func newCodeIssue(path, text, severity, source, analyzer string) CodeIssue {
+	issue := CodeIssue{File: path, Severity: severity, Sources: []string{source}, Occurrences: 1}
+	confidence, ok := analyzerConfidence[analyzer]
+	if !ok {
+		confidence = analyzerConfidence["default"]
+	}
+	issue.Confidence = confidence
+
+	if m := chunkLabelPattern.FindStringSubmatch(text); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.EndLine, _ = strconv.Atoi(m[2])
+		text = text[len(m[0]):]
+	}
+	if m := explicitSeverityPattern.FindStringSubmatch(text); m != nil {
+		switch strings.ToLower(m[1]) {
+		case "critical", "high":
+			issue.Severity = IssueSeverityHigh
+		case "medium", "moderate":
+			issue.Severity = IssueSeverityMedium
+		default:
+			issue.Severity = IssueSeverityLow
+		}
+		text = text[len(m[0]):]
+	} else {
+		lower := strings.ToLower(text)
+		for _, w := range highSeverityWords {
+			if strings.Contains(lower, w) {
+				issue.Severity = IssueSeverityHigh
+				break
+			}
+		}
+	}
+	// A line named in the text is more precise than the chunk it came from
+	if m := lineReferencePattern.FindStringSubmatch(text); m != nil {
+		start, _ := strconv.Atoi(m[1])
+		end, _ := strconv.Atoi(m[2])
+		if issue.Line == 0 || (start >= issue.Line && start <= issue.EndLine) {
+			issue.Line, issue.EndLine = start, end
+		}
+	}
+	if issue.EndLine < issue.Line {
+		issue.EndLine = 0
+	}
+	if issue.EndLine == issue.Line {
+		issue.EndLine = 0
+	}
+
+	issue.Message = strings.TrimSpace(text)
+	issue.words = issueWords(issue.Message)
+	return issue
+}
+
+// dedupeIssues merges issues in the same file at nearby lines whose descriptions
+// share most of their words. A merged issue keeps the highest severity, the most
+// precise lines and every source; its confidence combines those of its reports as
+// independent evidence.
+func dedupeIssues(issues []CodeIssue) []CodeIssue {
+	var merged []CodeIssue
+	members := make(map[int][]CodeIssue)
+	for _, issue := range issues {
+		found := -1
+		for i := range merged {
+			if merged[i].File != issue.File {
+				continue
+			}
+			for _, m := range members[i] {
+				if sameIssue(m, issue) {
+					found = i
+					break
+				}
+			}
+			if found >= 0 {
+				break
+			}
+		}
+		if found < 0 {
+			merged = append(merged, issue)
+			members[len(merged)-1] = []CodeIssue{issue}
+			continue
+		}
+		members[found] = append(members[found], issue)
+		mergeIssue(&merged[found], issue)
+	}
+
+	for i := range merged {
+		doubt := 1.0
+		for _, m := range members[i] {
+			doubt *= 1 - m.Confidence
+		}
+		merged[i].Confidence = math.Round((1-doubt)*100) / 100
+		sort.Strings(merged[i].Sources)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if issueSeverityRank[a.Severity] != issueSeverityRank[b.Severity] {
+			return issueSeverityRank[a.Severity] > issueSeverityRank[b.Severity]
+		}
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return merged
+}
+
+// mergeIssue folds a duplicate into an issue
+func mergeIssue(into *CodeIssue, dup CodeIssue) {
+	into.Occurrences++
+	if issueSeverityRank[dup.Severity] > issueSeverityRank[into.Severity] {
+		into.Severity = dup.Severity
+		into.Message = dup.Message
+		into.Rule = dup.Rule
+	}
+	if dup.Line > 0 && (into.Line == 0 || issueSpan(dup) < issueSpan(*into)) {
+		into.Line, into.EndLine = dup.Line, dup.EndLine
+	}
+	for _, s := range dup.Sources {
+		if !containsString(into.Sources, s) {
+			into.Sources = append(into.Sources, s)
+		}
+	}
+}
+
+// sameIssue reports whether two findings in a file describe the same problem
+func sameIssue(a, b CodeIssue) bool {
+	if a.Line > 0 && b.Line > 0 {
+		aEnd, bEnd := max(a.Line, a.EndLine), max(b.Line, b.EndLine)
+		if a.Line > bEnd+issueLineSlack || b.Line > aEnd+issueLineSlack {
+			return false
+		}
+	}
+	if len(a.words) == 0 || len(b.words) == 0 {
+		return strings.EqualFold(a.Message, b.Message)
+	}
+	common := 0
+	for w := range a.words {
+		if b.words[w] {
+			common++
+		}
+	}
+	union := len(a.words) + len(b.words) - common
+	return float64(common)/float64(union) >= issueSimilarity
+}
+
+// issueWords returns the distinct words of a description worth comparing, with
+// plural and -ing/-ed endings dropped so "unchecked errors" matches "error unchecked"
+func issueWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	}) {
+		if len(w) < 3 || issueStopWords[w] {
+			continue
+		}
+		for _, suffix := range []string{"ing", "ed", "es", "s"} {
+			if len(w) > len(suffix)+3 && strings.HasSuffix(w, suffix) {
+				w = strings.TrimSuffix(w, suffix)
+				break
+			}
+		}
+		words[w] = true
+	}
+	return words
+}
+
+// issueSpan is how many lines an issue covers
+func issueSpan(issue CodeIssue) int {
+	if issue.EndLine > issue.Line {
+		return issue.EndLine - issue.Line + 1
+	}
+	return 1
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewCodeIssue(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		severity string
+		wantLine int
+		wantEnd  int
+		wantSev  string
+		wantMsg  string
+	}{
+		{"plain", "Error from Close is ignored", IssueSeverityMedium, 0, 0, IssueSeverityMedium, "Error from Close is ignored"},
+		{"chunk label", "lines 10-40: Error from Close is ignored", IssueSeverityMedium, 10, 40, IssueSeverityMedium, "Error from Close is ignored"},
+		{"line in text", "lines 10-40: Error ignored on line 22", IssueSeverityMedium, 22, 0, IssueSeverityMedium, "Error ignored on line 22"},
+		{"line outside chunk", "lines 10-40: Same bug as line 3", IssueSeverityMedium, 10, 40, IssueSeverityMedium, "Same bug as line 3"},
+		{"explicit severity", "[critical] Token written to the log", IssueSeverityMedium, 0, 0, IssueSeverityHigh, "Token written to the log"},
+		{"explicit low", "Minor: long function", IssueSeverityMedium, 0, 0, IssueSeverityLow, "long function"},
+		{"serious words", "Possible SQL injection in Query", IssueSeverityLow, 0, 0, IssueSeverityHigh, "Possible SQL injection in Query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newCodeIssue("a.go", tt.text, tt.severity, "source/llm/issues", "llm")
+			if got.Line != tt.wantLine || got.EndLine != tt.wantEnd || got.Severity != tt.wantSev || got.Message != tt.wantMsg {
+				t.Errorf("newCodeIssue(%q) = line %d-%d %s %q, want line %d-%d %s %q",
+					tt.text, got.Line, got.EndLine, got.Severity, got.Message, tt.wantLine, tt.wantEnd, tt.wantSev, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCollectIssues(t *testing.T) {
+	results := []FileResult{
+		{Path: "a.go", Analysis: FileAnalysis{
+			Metadata: map[string]string{"analyzer": "llm"},
+			Issues: []string{
+				"lines 1-50: Error returned by Close is ignored on line 12",
+				"lines 51-90: Possible data race on the counter field",
+				"lines 51-90: Error returned by Close is ignored",
+			},
+			CodeSmells: []string{"Close errors are ignored (line 13)", "Function Run is too long"},
+		}},
+		{Path: "b.go", Analysis: FileAnalysis{
+			Metadata: map[string]string{"analyzer": "fallback"},
+			Issues:   []string{"Error returned by Close is ignored"},
+		}},
+	}
+	categories := map[string]FileCategory{"a.go": CategorySource, "b.go": CategoryTest}
+
+	got := collectIssues(results, categories)
+	type brief struct {
+		File       string
+		Line       int
+		Severity   string
+		Sources    []string
+		Confidence float64
+	}
+	var briefs []brief
+	for _, issue := range got {
+		briefs = append(briefs, brief{issue.File, issue.Line, issue.Severity, issue.Sources, issue.Confidence})
+	}
+	want := []brief{
+		{"a.go", 51, IssueSeverityHigh, []string{"source/llm/issues"}, 0.6},
+		{"a.go", 12, IssueSeverityMedium, []string{"source/llm/code_smells", "source/llm/issues"}, 0.84},
+		{"a.go", 51, IssueSeverityMedium, []string{"source/llm/issues"}, 0.6},
+		{"b.go", 0, IssueSeverityMedium, []string{"test/fallback/issues"}, 0.2},
+		{"a.go", 0, IssueSeverityLow, []string{"source/llm/code_smells"}, 0.6},
+	}
+	if !reflect.DeepEqual(briefs, want) {
+		t.Errorf("collectIssues() =\n%+v\nwant\n%+v", briefs, want)
+	}
+	if got[1].Occurrences != 2 || got[1].Message != "Error returned by Close is ignored on line 12" {
+		t.Errorf("merged issue = %+v", got[1])
+	}
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitChangesTimeout bounds the git commands run to find changed files
+const gitChangesTimeout = 5 * time.Second
+
+// branchBaseRefs are tried in order to find where the current branch started
+var branchBaseRefs = []string{"@{upstream}", "origin/HEAD", "origin/main", "origin/master", "main", "master"}
+
+// ChangedFiles returns the files under dir changed in its git repository: modified,
+// staged and untracked files in the working tree, and files changed by commits on
+// the current branch since it left its upstream or main branch. Paths are joined to
+// dir as given, so they compare equal to paths found by walking it. Deleted files
+// are left out. Outside a repository it returns an error.
+func ChangedFiles(ctx context.Context, dir string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitChangesTimeout)
+	defer cancel()
+
+	// Git reports paths relative to the top of the repository, which dir may be below
+	out, err := runGit(ctx, dir, "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitN(out, "\n", 3)
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("unexpected git rev-parse output: %q", out)
+	}
+	top, prefix := lines[0], lines[1]
+
+	changed := make(map[string]bool)
+	add := func(path string) {
+		if rel, ok := strings.CutPrefix(path, prefix); ok {
+			changed[filepath.Join(dir, filepath.FromSlash(rel))] = true
+		}
+	}
+	status, err := runGit(ctx, top, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	entries := strings.Split(status, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		// Renames and copies are followed by the original path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+		if code[0] != 'D' && code[1] != 'D' {
+			add(path)
+		}
+	}
+
+	head, err := runGit(ctx, top, "rev-parse", "HEAD")
+	if err != nil {
+		// No commits yet, so everything is in the working tree
+		return changed, nil
+	}
+	for _, ref := range branchBaseRefs {
+		base, err := runGit(ctx, top, "merge-base", "HEAD", ref)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(base) == strings.TrimSpace(head) {
+			break
+		}
+		diff, err := runGit(ctx, top, "diff", "--name-only", "--diff-filter=d", "-z", strings.TrimSpace(base), "HEAD")
+		if err != nil {
+			break
+		}
+		for _, path := range strings.Split(diff, "\x00") {
+			if path != "" {
+				add(path)
+			}
+		}
+		break
+	}
+	return changed, nil
+}
+
+// runGit runs git in dir and returns its output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
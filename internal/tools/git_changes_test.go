@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("a.go", "a")
+	write("b.go", "b")
+	write("sub/c.go", "c")
+	write("sub/gone.go", "gone")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+
+	git("checkout", "-q", "-b", "feature")
+	write("b.go", "b2")
+	git("commit", "-q", "-am", "change b")
+	write("sub/c.go", "c2")
+	write("sub/new.go", "new")
+	os.Remove(filepath.Join(root, "sub/gone.go"))
+
+	changed, err := ChangedFiles(context.Background(), root)
+	if err != nil {
+		t.Fatalf("ChangedFiles() error = %v", err)
+	}
+	var got []string
+	for path := range changed {
+		got = append(got, path)
+	}
+	sort.Strings(got)
+	want := []string{filepath.Join(root, "b.go"), filepath.Join(root, "sub", "c.go"), filepath.Join(root, "sub", "new.go")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+
+	// Below the top of the repository, only files under dir are returned
+	sub := filepath.Join(root, "sub")
+	changed, _ = ChangedFiles(context.Background(), sub)
+	if len(changed) != 2 || !changed[filepath.Join(sub, "c.go")] || !changed[filepath.Join(sub, "new.go")] {
+		t.Errorf("ChangedFiles(sub) = %v", changed)
+	}
+
+	if _, err := ChangedFiles(context.Background(), t.TempDir()); err == nil {
+		t.Error("ChangedFiles() outside a repository expected an error")
+	}
+}
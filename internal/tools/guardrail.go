@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DestructiveConfirmation is the word the user types to run a destructive call
+const DestructiveConfirmation = "DELETE"
+
+// Guarded is implemented by tools whose calls can be destructive depending on their
+// parameters, such as shell commands. Destructive calls always need a typed
+// confirmation, whatever the tool's permission level.
+type Guarded interface {
+	// Destructive returns why the call is destructive, or "" when it isn't
+	Destructive(params map[string]interface{}) string
+}
+
+// destructiveReason returns why a call of tool with params is destructive, or ""
+func destructiveReason(tool Tool, params map[string]interface{}) string {
+	if g, ok := tool.(Guarded); ok {
+		return g.Destructive(params)
+	}
+	return ""
+}
+
+// Patterns of destructive shell commands
+var (
+	// commandSeparator splits a command line into the commands it runs
+	commandSeparator = regexp.MustCompile(`&&|\|\||[;&\n]|\$\(|` + "`")
+	// pipeToShell matches a download piped into a shell, also through sudo or env
+	// with their options and variables
+	pipeToShell = regexp.MustCompile(`\b(curl|wget|fetch)\b[^|]*\|\s*((sudo|doas|env)(\s+(-\S+|\w+=\S*))*\s+)*(\S*/)?(ba|z|k|da|fi)?sh\b`)
+	// deviceWrite matches dd or a redirect writing to a device
+	deviceWrite = regexp.MustCompile(`(\bof=|>\s*)/dev/(\S+)`)
+	// formatDisk matches the programs that make a file system or wipe a disk
+	formatDisk = regexp.MustCompile(`^(mkfs(\.\w+)?|wipefs|shred|fdisk|sfdisk|parted)$`)
+	// forkBomb matches the classic shell fork bomb
+	forkBomb = regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`)
+)
+
+// commandWrappers are programs that run the command in their arguments, with the
+// options among theirs that take a value
+var commandWrappers = map[string][]string{
+	"sudo":    {"-u", "-g", "-h", "-p", "-C", "-D", "-U", "-r", "-t"},
+	"doas":    {"-u", "-C"},
+	"env":     {"-u", "-C", "-S"},
+	"nice":    {"-n"},
+	"nohup":   nil,
+	"time":    {"-f", "-o"},
+	"timeout": {"-s", "-k"},
+	"xargs":   {"-a", "-d", "-E", "-I", "-L", "-n", "-P", "-s"},
+	"command": nil,
+	"exec":    {"-a"},
+}
+
+// shells run the command given with -c
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "ksh": true, "dash": true, "fish": true}
+
+// harmlessDevices are the devices that can be written without harm
+var harmlessDevices = map[string]bool{"null": true, "zero": true, "stdout": true, "stderr": true, "tty": true}
+
+// DestructiveCommand returns why a shell command is destructive in a way that is hard
+// or impossible to undo, or "" when it isn't: deleting the file system root, the home
+// directory or everything in the current one, piping a download into a shell, writing
+// to a device, formatting a disk, force-pushing and fork bombs.
+func DestructiveCommand(command string) string {
+	return destructiveCommand(command, 0)
+}
+
+// maxShellNesting bounds how deep sh -c arguments are followed
+const maxShellNesting = 4
+
+// destructiveCommand classifies command, found depth levels of sh -c deep
+func destructiveCommand(command string, depth int) string {
+	if forkBomb.MatchString(command) {
+		return "it is a fork bomb, which exhausts the system's processes"
+	}
+	if pipeToShell.MatchString(command) {
+		return "it pipes a download into a shell, running code that was never reviewed"
+	}
+	for _, m := range deviceWrite.FindAllStringSubmatch(command, -1) {
+		if !harmlessDevices[strings.Trim(m[2], `"'`)] {
+			return "it writes directly to the device /dev/" + m[2]
+		}
+	}
+	for _, part := range commandSeparator.Split(command, -1) {
+		for _, segment := range strings.Split(part, "|") {
+			if reason := destructiveSegment(strings.TrimSpace(segment), depth); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// destructiveSegment classifies a single command, without separators or pipes.
+// Programs are recognized by name whatever their directory, past wrappers such as
+// sudo, env and xargs, and inside the command a shell runs with -c.
+func destructiveSegment(segment string, depth int) string {
+	args := unwrapCommand(parseCommandArgs(segment))
+	if len(args) == 0 {
+		return ""
+	}
+	name := filepath.Base(args[0])
+	if formatDisk.MatchString(name) {
+		return "it formats or wipes a disk"
+	}
+	switch {
+	case name == "rm":
+		return destructiveRemove(args[1:])
+	case name == "git":
+		return destructivePush(args[1:])
+	case shells[name] && depth < maxShellNesting:
+		for i, arg := range args[1:] {
+			if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c") && i+2 < len(args) {
+				return destructiveCommand(args[i+2], depth+1)
+			}
+		}
+	}
+	return ""
+}
+
+// unwrapCommand drops wrappers such as sudo, env and xargs, with their options and
+// variable assignments, from the front of args
+func unwrapCommand(args []string) []string {
+	for len(args) > 0 {
+		valueOptions, ok := commandWrappers[filepath.Base(args[0])]
+		if !ok {
+			return args
+		}
+		args = args[1:]
+		for len(args) > 0 {
+			arg := args[0]
+			if arg == "--" {
+				args = args[1:]
+				break
+			}
+			if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+				break
+			}
+			args = args[1:]
+			for _, option := range valueOptions {
+				if arg == option && len(args) > 0 {
+					args = args[1:]
+				}
+			}
+		}
+		// nice, timeout and the like take a number before the command
+		if len(args) > 0 && args[0] != "" && args[0][0] >= '0' && args[0][0] <= '9' && strings.Trim(args[0], "0123456789.smhd") == "" {
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// destructiveRemove reports recursive deletes of the root, the home directory, a
+// top-level system directory or everything in the current directory
+func destructiveRemove(args []string) string {
+	recursive := false
+	var targets []string
+	for _, arg := range args {
+		switch {
+		case arg == "--recursive":
+			recursive = true
+		case strings.HasPrefix(arg, "--"):
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			if strings.ContainsAny(arg, "rR") {
+				recursive = true
+			}
+		default:
+			targets = append(targets, arg)
+		}
+	}
+	if !recursive {
+		return ""
+	}
+	for _, target := range targets {
+		if sweepingPath(target) {
+			return "it recursively deletes " + target
+		}
+	}
+	return ""
+}
+
+// sweepingPath reports whether deleting path takes a whole system, home directory or
+// project with it
+func sweepingPath(path string) bool {
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "*"), "/")
+	switch path {
+	case "", ".", "..", "~", "$HOME", "${HOME}":
+		return true
+	}
+	if strings.HasPrefix(path, "/") {
+		// The root or a top-level directory such as /usr or /home
+		return !strings.Contains(path[1:], "/")
+	}
+	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "$HOME/") {
+		// A directory directly in the home directory, such as ~/.ssh
+		rest := path[strings.Index(path, "/")+1:]
+		return !strings.Contains(rest, "/")
+	}
+	return false
+}
+
+// destructivePush reports force pushes, which can discard commits on the remote;
+// --force-with-lease is allowed since it refuses to overwrite work it hasn't seen
+func destructivePush(args []string) string {
+	push := false
+	for _, arg := range args {
+		if arg == "push" {
+			push = true
+			continue
+		}
+		if !push {
+			continue
+		}
+		switch {
+		case arg == "--delete":
+			return "it deletes a branch on the remote"
+		case arg == "--force" || arg == "--mirror":
+			return "it force-pushes with " + arg + ", which can discard commits on the remote"
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "f"):
+			return "it force-pushes with " + arg + ", which can discard commits on the remote"
+		case strings.HasPrefix(arg, "+") && len(arg) > 1:
+			return "it force-pushes " + arg + ", which can discard commits on the remote"
+		}
+	}
+	return ""
+}
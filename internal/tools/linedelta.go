@@ -0,0 +1,55 @@
+package tools
+
+import "strings"
+
+// maxLineDeltaCells bounds the table LineDelta fills to match the changed middle of
+// two files; larger changes count every line of it as removed and added
+const maxLineDeltaCells = 4_000_000
+
+// LineDelta counts the lines added and removed to turn before into after, as a line
+// diff would
+func LineDelta(before, after string) (added, removed int) {
+	a, b := splitLines(before), splitLines(after)
+
+	// Lines kept at the start and end don't count
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxLineDeltaCells {
+		return len(b), len(a)
+	}
+
+	// Lines in the longest common subsequence are kept; the rest changed
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	kept := prev[len(b)]
+	return len(b) - kept, len(a) - kept
+}
+
+// CountLines counts the lines of content, including a last one without a newline
+func CountLines(content string) int {
+	return len(splitLines(content))
+}
+
+// splitLines splits content into lines, without an empty one after a final newline
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,27 @@
+package tools
+
+import "testing"
+
+func TestLineDelta(t *testing.T) {
+	tests := []struct {
+		name           string
+		before, after  string
+		added, removed int
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", 0, 0},
+		{"created", "", "a\nb\nc\n", 3, 0},
+		{"emptied", "a\nb", "", 0, 2},
+		{"line changed", "a\nb\nc\n", "a\nB\nc\n", 1, 1},
+		{"lines inserted", "a\nd\n", "a\nb\nc\nd\n", 2, 0},
+		{"moved line", "a\nb\nc\nd\n", "b\nc\na\nd\n", 1, 1},
+		{"newline at end", "a\nb", "a\nb\n", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := LineDelta(tt.before, tt.after)
+			if added != tt.added || removed != tt.removed {
+				t.Errorf("LineDelta() = +%d -%d, want +%d -%d", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
@@ -132,9 +132,12 @@ func (t *ListFilesTool) Execute(ctx context.Context, params map[string]interface
 		return t.createEnhancedResult(ctx, dir, files, maxFileSize)
 	}
 
-	// Return the simple result
+	// Return the simple result, showing files in additional project roots as lib:path
+	for i, file := range files {
+		files[i] = DisplayPath(file)
+	}
 	result := map[string]interface{}{
-		"directory": dir,
+		"directory": DisplayPath(dir),
 		"files":     files,
 		"count":     len(files),
 	}
@@ -142,6 +145,12 @@ func (t *ListFilesTool) Execute(ctx context.Context, params map[string]interface
 	return result, nil
 }
 
+// ResultSchema shows plain listings as a tree. Listings with file contents don't match
+// the schema and are shown in full.
+func (t *ListFilesTool) ResultSchema() ResultSchema {
+	return ResultSchema{Kind: ResultTree, Rows: "files", Summary: []string{"directory", "count"}}
+}
+
 // findFiles recursively finds files in a directory with pattern matching
 func findFiles(root, pattern string, maxDepth int, includeHidden bool) ([]string, error) {
 	var files []string
@@ -232,7 +241,7 @@ func (t *ListFilesTool) createEnhancedResult(ctx context.Context, dir string, fi
 		}
 
 		fileResult := EnhancedFileResult{
-			Path: filePath,
+			Path: DisplayPath(filePath),
 		}
 
 		// Get file info
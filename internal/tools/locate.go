@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxLocatorFiles bounds how many files a FileLocator indexes
+const maxLocatorFiles = 50000
+
+// locatorSkippedDirs are never indexed
+var locatorSkippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// FileLocator maps paths found in logs and stack traces to project files. Such paths
+// are often absolute paths on another machine (a CI runner, a container) or relative
+// to some other directory, so they are matched by their longest common suffix.
+type FileLocator struct {
+	root   string
+	byBase map[string][]string // base name -> slash-separated paths relative to root
+}
+
+// NewFileLocator indexes the files under root
+func NewFileLocator(root string) *FileLocator {
+	l := &FileLocator{root: root, byBase: make(map[string][]string)}
+	count := 0
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || locatorSkippedDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if count >= maxLocatorFiles {
+			return filepath.SkipAll
+		}
+		count++
+		rel, err := filepath.Rel(root, path)
+		if err == nil {
+			l.byBase[d.Name()] = append(l.byBase[d.Name()], filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return l
+}
+
+// Locate returns the project file (relative to the root, slash-separated) that path
+// most likely refers to. It fails when no file has the same name, or when several
+// match equally well.
+func (l *FileLocator) Locate(path string) (string, bool) {
+	path = filepath.ToSlash(strings.ReplaceAll(strings.TrimSpace(path), `\`, "/"))
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	candidates := l.byBase[parts[len(parts)-1]]
+
+	best, bestScore, tie := "", 0, false
+	for _, c := range candidates {
+		cparts := strings.Split(c, "/")
+		score := 0
+		for score < len(parts) && score < len(cparts) && parts[len(parts)-1-score] == cparts[len(cparts)-1-score] {
+			score++
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = c, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if best == "" || tie {
+		return "", false
+	}
+	return best, true
+}
+
+// Root returns the directory the locator indexed
+func (l *FileLocator) Root() string {
+	return l.root
+}
+
+// SourceSnippet returns the lines around line (1-based) of the file at path, each
+// prefixed with its number and the requested line marked with ">"
+func SourceSnippet(path string, line, radius int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if n < line-radius {
+			continue
+		}
+		if n > line+radius {
+			break
+		}
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s%5d  %s\n", marker, n, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
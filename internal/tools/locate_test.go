@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileLocator(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"cmd/app/main.go", "internal/app/main.go", "internal/parse/parse.go", "node_modules/x/parse.go"} {
+		os.MkdirAll(filepath.Join(root, filepath.Dir(p)), 0755)
+		os.WriteFile(filepath.Join(root, p), []byte("package x\n"), 0644)
+	}
+	l := NewFileLocator(root)
+
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"/home/runner/work/app/app/internal/parse/parse.go", "internal/parse/parse.go", true},
+		{"parse.go", "internal/parse/parse.go", true},
+		{`C:\build\internal\app\main.go`, "internal/app/main.go", true},
+		{"main.go", "", false}, // Ambiguous
+		{"/usr/local/go/src/fmt/print.go", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := l.Locate(tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Locate(%q) = %q, %v; want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package tools
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxLogClusters bounds the distinct message patterns tracked; later patterns are
+	// only counted in aggregate so memory stays flat on any log size
+	maxLogClusters = 10000
+	// maxLogExample caps the length of the example line kept for a pattern
+	maxLogExample = 300
+	// maxLogLine is the longest line analyzed; the rest of a longer line is skipped
+	maxLogLine = 64 * 1024
+)
+
+// LogPattern is a group of log lines that differ only in variable parts such as
+// numbers, IDs and quoted values
+type LogPattern struct {
+	Level     string `json:"level,omitempty"`
+	Signature string `json:"signature"`
+	Count     int    `json:"count"`
+	FirstSeen string `json:"first_seen,omitempty"` // Timestamp of the first occurrence, as written in the log
+	LastSeen  string `json:"last_seen,omitempty"`
+	FirstLine int    `json:"first_line"`
+	Example   string `json:"example"`
+}
+
+// LogSummary is a compact digest of a log file
+type LogSummary struct {
+	Lines          int            `json:"lines"`
+	Bytes          int64          `json:"bytes"`
+	Matched        int            `json:"matched"` // Lines that passed the filter
+	Levels         map[string]int `json:"levels"`
+	FirstTimestamp string         `json:"first_timestamp,omitempty"`
+	LastTimestamp  string         `json:"last_timestamp,omitempty"`
+	Patterns       int            `json:"patterns"`          // Distinct patterns seen
+	Untracked      int            `json:"untracked_lines"`   // Lines past the pattern limit
+	Errors         []LogPattern   `json:"errors"`            // Error and fatal patterns, most frequent first
+	Warnings       []LogPattern   `json:"warnings"`          // Warning patterns, most frequent first
+	Frequent       []LogPattern   `json:"frequent_messages"` // Most repeated patterns of any level
+}
+
+var (
+	logTimestamp = regexp.MustCompile(`\d{4}-\d\d-\d\d[T ]\d\d:\d\d:\d\d(?:[.,]\d+)?(?:Z|[+-]\d\d:?\d\d)?|\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) [ \d]\d \d\d:\d\d:\d\d\b`)
+	logLevel     = regexp.MustCompile(`(?i)\b(?:level=)?"?(fatal|panic|critical|crit|error|err|warning|warn|info|notice|debug|trace)\b`)
+	// logVariables are replaced, in order, to turn a line into its pattern
+	logVariables = []struct {
+		re          *regexp.Regexp
+		placeholder string
+	}{
+		{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+		{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<ip>"},
+		{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+		{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]*\d[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\b|\b[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\d[0-9a-fA-F]*\b`), "<hex>"},
+		{regexp.MustCompile(`\d+(?:\.\d+)?`), "<n>"},
+	}
+)
+
+// normalizeLevel maps level spellings onto fatal, error, warn, info and debug
+func normalizeLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "fatal", "panic", "critical", "crit":
+		return "fatal"
+	case "error", "err":
+		return "error"
+	case "warning", "warn":
+		return "warn"
+	case "info", "notice":
+		return "info"
+	case "debug", "trace":
+		return "debug"
+	}
+	return ""
+}
+
+// logSignature reduces a log line (without its timestamp) to its pattern
+func logSignature(message string) string {
+	for _, v := range logVariables {
+		message = v.re.ReplaceAllString(message, v.placeholder)
+	}
+	return strings.Join(strings.Fields(message), " ")
+}
+
+// readLogLine reads the next line, keeping at most maxLogLine bytes of it. size is
+// the full length read, including the newline.
+func readLogLine(r *bufio.Reader) (string, int64, error) {
+	var line []byte
+	var size int64
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		size += int64(len(chunk))
+		if room := maxLogLine - len(line); room > 0 {
+			if len(chunk) > room {
+				chunk = chunk[:room]
+			}
+			line = append(line, chunk...)
+		}
+		if err != nil {
+			return string(line), size, err
+		}
+		if !isPrefix {
+			return string(line), size + 1, nil
+		}
+	}
+}
+
+// AnalyzeLog reads a log line by line and groups the lines into patterns. Only lines
+// matching filter are counted when it is not nil. top limits each list in the summary.
+func AnalyzeLog(r io.Reader, filter *regexp.Regexp, top int) (*LogSummary, error) {
+	summary := &LogSummary{Levels: make(map[string]int)}
+	clusters := make(map[string]*LogPattern)
+
+	reader := bufio.NewReader(r)
+	for {
+		line, size, err := readLogLine(reader)
+		if err == io.EOF && size == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		summary.Lines++
+		summary.Bytes += size
+		if strings.TrimSpace(line) == "" || (filter != nil && !filter.MatchString(line)) {
+			continue
+		}
+		summary.Matched++
+
+		timestamp := logTimestamp.FindString(line)
+		message := line
+		if timestamp != "" {
+			message = strings.Replace(line, timestamp, "", 1)
+			if summary.FirstTimestamp == "" {
+				summary.FirstTimestamp = timestamp
+			}
+			summary.LastTimestamp = timestamp
+		}
+		level := ""
+		if m := logLevel.FindStringSubmatch(message); m != nil {
+			level = normalizeLevel(m[1])
+		}
+		if level != "" {
+			summary.Levels[level]++
+		}
+
+		key := level + "\x00" + logSignature(message)
+		c, ok := clusters[key]
+		if !ok {
+			if len(clusters) >= maxLogClusters {
+				summary.Untracked++
+				continue
+			}
+			example := strings.TrimSpace(line)
+			if len(example) > maxLogExample {
+				example = example[:maxLogExample] + "..."
+			}
+			c = &LogPattern{Level: level, Signature: logSignature(message), FirstSeen: timestamp, FirstLine: summary.Lines, Example: example}
+			clusters[key] = c
+		}
+		c.Count++
+		if timestamp != "" {
+			c.LastSeen = timestamp
+		}
+	}
+
+	all := make([]LogPattern, 0, len(clusters))
+	for _, c := range clusters {
+		all = append(all, *c)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Count != all[j].Count {
+			return all[i].Count > all[j].Count
+		}
+		return all[i].FirstLine < all[j].FirstLine
+	})
+	summary.Patterns = len(all)
+	summary.Errors, summary.Warnings = []LogPattern{}, []LogPattern{}
+	for _, p := range all {
+		switch p.Level {
+		case "fatal", "error":
+			if len(summary.Errors) < top {
+				summary.Errors = append(summary.Errors, p)
+			}
+		case "warn":
+			if len(summary.Warnings) < top {
+				summary.Warnings = append(summary.Warnings, p)
+			}
+		}
+		if p.Count > 1 && len(summary.Frequent) < top {
+			summary.Frequent = append(summary.Frequent, p)
+		}
+	}
+	return summary, nil
+}
+
+// LogAnalysisTool summarizes large log files without loading them into the context
+type LogAnalysisTool struct{}
+
+// NewLogAnalysisTool creates a new log analysis tool
+func NewLogAnalysisTool() *LogAnalysisTool {
+	return &LogAnalysisTool{}
+}
+
+// Name returns the tool name
+func (t *LogAnalysisTool) Name() string {
+	return "analyzeLog"
+}
+
+// Description returns the tool description
+func (t *LogAnalysisTool) Description() string {
+	return "Summarizes a log file of any size (plain or .gz): groups repeated messages into patterns and returns the error and warning patterns with counts, first/last timestamps and an example line, plus level counts. Use it instead of reading large logs"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *LogAnalysisTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"path": {
+				Type:        "string",
+				Description: "Path to the log file",
+			},
+			"filter": {
+				Type:        "string",
+				Description: "Only analyze lines matching this regular expression, e.g. a request ID or component name",
+			},
+			"top": {
+				Type:        "integer",
+				Description: "Patterns to return per list (default: 20)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// ResultSchema describes the summary as a table of error patterns
+func (t *LogAnalysisTool) ResultSchema() ResultSchema {
+	return ResultSchema{
+		Kind:    ResultTable,
+		Rows:    "errors",
+		Columns: []string{"level", "count", "first_seen", "last_seen", "signature"},
+		Summary: []string{"lines", "levels", "first_timestamp", "last_timestamp", "patterns"},
+	}
+}
+
+// Execute analyzes the log
+func (t *LogAnalysisTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	path, _ := params["path"].(string)
+	top := getIntParam(params, "top", 20)
+	if top <= 0 {
+		top = 20
+	}
+
+	var filter *regexp.Regexp
+	if expr, _ := params["filter"].(string); expr != "" {
+		var err error
+		if filter, err = regexp.Compile(expr); err != nil {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("invalid filter: %v", err)}
+		}
+	}
+
+	cleanPath, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	f, err := os.Open(cleanPath)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to open log file", Err: err}
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(cleanPath, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to decompress log file", Err: err}
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	summary, err := AnalyzeLog(r, filter, top)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to read log file", Err: err}
+	}
+	return summary, nil
+}
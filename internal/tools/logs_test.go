@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const sampleLog = `2024-05-01T10:00:00Z INFO server started on 10.0.0.1:8080
+2024-05-01T10:00:01Z ERROR request 42 failed: connection refused to "db-1"
+2024-05-01T10:00:02Z WARN slow query took 1200ms
+2024-05-01T10:00:03Z ERROR request 43 failed: connection refused to "db-2"
+
+2024-05-01T10:00:04Z INFO handled request 7f3a9c2e-0b1d-4e5f-8a6b-1c2d3e4f5a6b
+2024-05-01T10:00:05Z ERROR request 44 failed: connection refused to "db-1"
+2024-05-01T10:00:06Z level=fatal msg="out of memory"
+`
+
+func TestAnalyzeLog(t *testing.T) {
+	summary, err := AnalyzeLog(strings.NewReader(sampleLog), nil, 10)
+	if err != nil {
+		t.Fatalf("AnalyzeLog() error = %v", err)
+	}
+
+	if summary.Lines != 8 || summary.Matched != 7 {
+		t.Errorf("Lines, Matched = %d, %d, want 8, 7", summary.Lines, summary.Matched)
+	}
+	if summary.Levels["error"] != 3 || summary.Levels["fatal"] != 1 || summary.Levels["warn"] != 1 || summary.Levels["info"] != 2 {
+		t.Errorf("Levels = %v", summary.Levels)
+	}
+	if summary.FirstTimestamp != "2024-05-01T10:00:00Z" || summary.LastTimestamp != "2024-05-01T10:00:06Z" {
+		t.Errorf("time range = %s - %s", summary.FirstTimestamp, summary.LastTimestamp)
+	}
+
+	if len(summary.Errors) != 2 {
+		t.Fatalf("Errors = %+v, want 2 patterns", summary.Errors)
+	}
+	top := summary.Errors[0]
+	if top.Signature != "ERROR request <n> failed: connection refused to <str>" {
+		t.Errorf("Signature = %q", top.Signature)
+	}
+	if top.Count != 3 || top.FirstSeen != "2024-05-01T10:00:01Z" || top.LastSeen != "2024-05-01T10:00:05Z" || top.FirstLine != 2 {
+		t.Errorf("top error = %+v", top)
+	}
+	if summary.Errors[1].Level != "fatal" {
+		t.Errorf("second error level = %q, want fatal", summary.Errors[1].Level)
+	}
+	if len(summary.Warnings) != 1 || len(summary.Frequent) != 1 {
+		t.Errorf("Warnings = %+v, Frequent = %+v", summary.Warnings, summary.Frequent)
+	}
+}
+
+func TestAnalyzeLogFilter(t *testing.T) {
+	summary, err := AnalyzeLog(strings.NewReader(sampleLog), regexp.MustCompile(`db-1`), 10)
+	if err != nil {
+		t.Fatalf("AnalyzeLog() error = %v", err)
+	}
+	if summary.Matched != 2 || len(summary.Errors) != 1 || summary.Errors[0].Count != 2 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestAnalyzeLogLongLine(t *testing.T) {
+	log := "ERROR " + strings.Repeat("x", 3*maxLogLine) + "\nERROR done\n"
+	summary, err := AnalyzeLog(strings.NewReader(log), nil, 10)
+	if err != nil {
+		t.Fatalf("AnalyzeLog() error = %v", err)
+	}
+	if summary.Lines != 2 || summary.Levels["error"] != 2 || summary.Bytes != int64(len(log)) {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestLogAnalysisToolGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(sampleLog))
+	gz.Close()
+	f.Close()
+
+	result, err := NewLogAnalysisTool().Execute(context.Background(), map[string]interface{}{"path": path, "top": 1})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	summary := result.(*LogSummary)
+	if summary.Lines != 8 || len(summary.Errors) != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	if _, err := NewLogAnalysisTool().Execute(context.Background(), map[string]interface{}{"path": path, "filter": "("}); err == nil {
+		t.Error("expected an error for an invalid filter")
+	}
+}
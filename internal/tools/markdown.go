@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -11,6 +12,7 @@ type CodeBlock struct {
 	Content  string `json:"content"`
 	LineNum  int    `json:"line_number"`
 	IsShell  bool   `json:"is_shell"`
+	Info     string `json:"info,omitempty"` // Text after the language on the opening fence
 }
 
 // MarkdownAnalysis represents the analysis of a markdown file
@@ -67,6 +69,7 @@ func AnalyzeMarkdown(content string) *MarkdownAnalysis {
 					Content:  "",
 					LineNum:  lineNum,
 					IsShell:  isShellLanguage(language),
+					Info:     strings.TrimSpace(matches[2]),
 				}
 				inCodeBlock = true
 			} else {
@@ -134,6 +137,83 @@ func AnalyzeMarkdown(content string) *MarkdownAnalysis {
 	return analysis
 }
 
+// fencePattern matches an opening or closing code fence, allowing up to three spaces of indentation
+var fencePattern = regexp.MustCompile("^ {0,3}(```+|~~~+)\\s*([a-zA-Z0-9_+.#-]*)(.*)$")
+
+// ExtractFencedBlocks returns the fenced code blocks in content in order, with their
+// content unchanged apart from the fence lines. Unlike AnalyzeMarkdown it keeps
+// leading indentation and empty blocks are skipped.
+func ExtractFencedBlocks(content string) []CodeBlock {
+	var blocks []CodeBlock
+	var current *CodeBlock
+	var fence string
+	var body []string
+
+	for i, line := range strings.Split(content, "\n") {
+		matches := fencePattern.FindStringSubmatch(line)
+		if current == nil {
+			if matches != nil {
+				language := strings.ToLower(matches[2])
+				current = &CodeBlock{
+					Language: language,
+					LineNum:  i + 1,
+					IsShell:  isShellLanguage(language),
+					Info:     strings.TrimSpace(matches[3]),
+				}
+				fence = matches[1]
+				body = body[:0]
+			}
+			continue
+		}
+
+		// A closing fence uses the same character, is at least as long and has no info string
+		if matches != nil && matches[1][0] == fence[0] && len(matches[1]) >= len(fence) && matches[2] == "" && strings.TrimSpace(matches[3]) == "" {
+			current.Content = strings.Join(body, "\n")
+			if strings.TrimSpace(current.Content) != "" {
+				blocks = append(blocks, *current)
+			}
+			current = nil
+			continue
+		}
+		body = append(body, line)
+	}
+
+	// An unterminated block runs to the end of the content
+	if current != nil {
+		current.Content = strings.Join(body, "\n")
+		if strings.TrimSpace(current.Content) != "" {
+			blocks = append(blocks, *current)
+		}
+	}
+	return blocks
+}
+
+// pathAttributePattern matches title=, file=, filename= or path= attributes on a fence
+var pathAttributePattern = regexp.MustCompile(`(?:^|\s)(?:title|file|filename|path)=(?:"([^"]+)"|'([^']+)'|(\S+))`)
+
+// TargetPath returns the file a code block is annotated with, such as
+// "```go title=internal/foo.go", "```go:internal/foo.go" or "```go internal/foo.go",
+// or "" if the block has no file annotation
+func (b CodeBlock) TargetPath() string {
+	if m := pathAttributePattern.FindStringSubmatch(b.Info); m != nil {
+		for _, group := range m[1:] {
+			if group != "" {
+				return group
+			}
+		}
+	}
+
+	// Language and path joined by a colon, or a lone path after the language
+	info := strings.TrimSpace(strings.TrimPrefix(b.Info, ":"))
+	if info == "" || strings.ContainsAny(info, " \t=") {
+		return ""
+	}
+	if strings.Contains(info, "/") || filepath.Ext(info) != "" {
+		return info
+	}
+	return ""
+}
+
 // isShellLanguage checks if a language identifier represents a shell language
 func isShellLanguage(language string) bool {
 	if language == "" {
@@ -386,3 +386,60 @@ func TestCodeBlockParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractFencedBlocks(t *testing.T) {
+	content := "Intro\n" +
+		"```go title=main.go\n" +
+		"\tfmt.Println(\"hi\")\n" +
+		"\n" +
+		"```\n" +
+		"text\n" +
+		"````markdown\n" +
+		"```bash\n" +
+		"ls\n" +
+		"```\n" +
+		"````\n" +
+		"```\n" +
+		"   \n" +
+		"```\n" +
+		"~~~python\n" +
+		"print(1)"
+
+	blocks := ExtractFencedBlocks(content)
+	if len(blocks) != 3 {
+		t.Fatalf("ExtractFencedBlocks() returned %d blocks, want 3: %+v", len(blocks), blocks)
+	}
+
+	if b := blocks[0]; b.Language != "go" || b.Info != "title=main.go" || b.Content != "\tfmt.Println(\"hi\")\n" || b.LineNum != 2 {
+		t.Errorf("block 1 = %+v", b)
+	}
+	if b := blocks[1]; b.Language != "markdown" || b.Content != "```bash\nls\n```" {
+		t.Errorf("block 2 = %+v, want nested fence kept inside the longer fence", b)
+	}
+	if b := blocks[2]; b.Language != "python" || b.Content != "print(1)" {
+		t.Errorf("block 3 = %+v, want unterminated block to run to the end", b)
+	}
+}
+
+func TestCodeBlockTargetPath(t *testing.T) {
+	tests := []struct {
+		info string
+		want string
+	}{
+		{"title=internal/foo.go", "internal/foo.go"},
+		{`title="dir with space/a.go"`, "dir with space/a.go"},
+		{"linenums=1 file='x.py'", "x.py"},
+		{":internal/foo.go", "internal/foo.go"},
+		{"internal/foo.go", "internal/foo.go"},
+		{"Makefile.inc", "Makefile.inc"},
+		{"", ""},
+		{"example", ""},
+		{"run this first", ""},
+		{"linenums=1", ""},
+	}
+	for _, tt := range tests {
+		if got := (CodeBlock{Info: tt.info}).TargetPath(); got != tt.want {
+			t.Errorf("TargetPath(%q) = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
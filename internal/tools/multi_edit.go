@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// MultiEditTool applies edits to several files as a single atomic change
+type MultiEditTool struct{}
+
+// NewMultiEditTool creates a new multi-file edit tool
+func NewMultiEditTool() *MultiEditTool {
+	return &MultiEditTool{}
+}
+
+// Name returns the tool name
+func (t *MultiEditTool) Name() string {
+	return "multiEdit"
+}
+
+// Description returns the tool description
+func (t *MultiEditTool) Description() string {
+	return "Edits several files as one change. All edits are shown as a combined diff for a single approval and applied atomically: if any file cannot be written, every file is rolled back"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *MultiEditTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"edits": {
+				Type:        "array",
+				Description: "Edits to apply in order. Each edit either replaces the whole file (content) or replaces one unique occurrence of old_string with new_string",
+				Items: &JSONSchema{
+					Type: "object",
+					Properties: map[string]JSONSchema{
+						"file_path": {
+							Type:        "string",
+							Description: "The path to the file to edit or create",
+						},
+						"content": {
+							Type:        "string",
+							Description: "The full new content of the file",
+						},
+						"old_string": {
+							Type:        "string",
+							Description: "Text to replace; must occur exactly once in the file",
+						},
+						"new_string": {
+							Type:        "string",
+							Description: "Replacement text for old_string",
+						},
+					},
+					Required: []string{"file_path"},
+				},
+			},
+		},
+		Required: []string{"edits"},
+	}
+}
+
+// Preview returns the combined diff of all edits
+func (t *MultiEditTool) Preview(ctx context.Context, params map[string]interface{}) (string, error) {
+	tx, err := t.stage(params)
+	if err != nil {
+		return "", err
+	}
+	return tx.Diff(), nil
+}
+
+// Execute applies all edits atomically
+func (t *MultiEditTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	tx, err := t.stage(params)
+	if err != nil {
+		return nil, err
+	}
+
+	files := tx.Files()
+	if err := tx.Commit(); err != nil {
+		return nil, &ErrToolExecution{
+			ToolName: t.Name(),
+			Message:  "failed to apply edits",
+			Err:      err,
+		}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"files":   files,
+		"count":   len(files),
+	}, nil
+}
+
+// stage builds a transaction from the edits parameter without writing anything
+func (t *MultiEditTool) stage(params map[string]interface{}) (*EditTransaction, error) {
+	edits, ok := params["edits"].([]interface{})
+	if !ok || len(edits) == 0 {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: "edits must be a non-empty array"}
+	}
+
+	tx := NewEditTransaction()
+	for i, raw := range edits {
+		edit, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("edit %d must be an object", i+1)}
+		}
+
+		filePath, _ := edit["file_path"].(string)
+		if filePath == "" {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("edit %d is missing file_path", i+1)}
+		}
+		path, err := ValidateAndCleanPath(filePath)
+		if err != nil {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+
+		content, hasContent := edit["content"].(string)
+		oldString, hasOld := edit["old_string"].(string)
+		newString, _ := edit["new_string"].(string)
+
+		switch {
+		case hasContent && hasOld:
+			err = fmt.Errorf("use either content or old_string/new_string, not both")
+		case hasContent:
+			err = tx.Stage(path, content)
+		case hasOld:
+			err = tx.StageReplace(path, oldString, newString)
+		default:
+			err = fmt.Errorf("either content or old_string is required")
+		}
+		if err != nil {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("edit %d (%s): %v", i+1, filePath, err)}
+		}
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Note is a single scratchpad entry
+type Note struct {
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+}
+
+// NotesStore holds the scratchpad notes of one session
+type NotesStore struct {
+	mu    sync.RWMutex
+	notes []Note
+}
+
+// NewNotesStore creates an empty notes store
+func NewNotesStore() *NotesStore {
+	return &NotesStore{}
+}
+
+// Append adds a note
+func (s *NotesStore) Append(text string) Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note := Note{Text: text, Created: time.Now()}
+	s.notes = append(s.notes, note)
+	return note
+}
+
+// List returns a copy of all notes, oldest first
+func (s *NotesStore) List() []Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := make([]Note, len(s.notes))
+	copy(notes, s.notes)
+	return notes
+}
+
+// Clear removes all notes and returns how many were removed
+func (s *NotesStore) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.notes)
+	s.notes = nil
+	return n
+}
+
+// Replace sets the notes, as when switching to another session
+func (s *NotesStore) Replace(notes []Note) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notes = append([]Note(nil), notes...)
+}
+
+// Format renders the notes as a numbered list
+func (s *NotesStore) Format() string {
+	notes := s.List()
+	if len(notes) == 0 {
+		return "No notes yet."
+	}
+
+	var b strings.Builder
+	for i, note := range notes {
+		fmt.Fprintf(&b, "%d. [%s] %s\n", i+1, note.Created.Format("15:04:05"), note.Text)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// NotesTool lets the model keep a scratchpad outside the conversation context
+type NotesTool struct {
+	store *NotesStore
+}
+
+// NewNotesTool creates a notes tool backed by the given store
+func NewNotesTool(store *NotesStore) *NotesTool {
+	return &NotesTool{store: store}
+}
+
+// Name returns the tool name
+func (t *NotesTool) Name() string {
+	return "notes"
+}
+
+// Description returns the tool description
+func (t *NotesTool) Description() string {
+	return "Session scratchpad for intermediate findings (files already inspected, hypotheses ruled out, next steps). Use append to record, read to recall and clear to start over"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *NotesTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"action": {
+				Type:        "string",
+				Description: "The action to perform",
+				Enum:        []interface{}{"append", "read", "clear"},
+			},
+			"text": {
+				Type:        "string",
+				Description: "The note to record (required for append)",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+// Execute performs the requested notes action
+func (t *NotesTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	action, _ := params["action"].(string)
+	switch action {
+	case "append":
+		text, _ := params["text"].(string)
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, &ErrInvalidToolParams{
+				ToolName: t.Name(),
+				Message:  "text is required for append",
+			}
+		}
+		t.store.Append(text)
+		return map[string]interface{}{
+			"success": true,
+			"count":   len(t.store.List()),
+		}, nil
+
+	case "read":
+		return t.store.Format(), nil
+
+	case "clear":
+		return map[string]interface{}{
+			"success": true,
+			"removed": t.store.Clear(),
+		}, nil
+
+	default:
+		return nil, &ErrInvalidToolParams{
+			ToolName: t.Name(),
+			Message:  fmt.Sprintf("unknown action: %s (expected append, read or clear)", action),
+		}
+	}
+}
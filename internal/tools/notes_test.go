@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNotesTool(t *testing.T) {
+	store := NewNotesStore()
+	tool := NewNotesTool(store)
+	ctx := context.Background()
+
+	for _, text := range []string{"inspected main.go", "ruled out config parsing"} {
+		if _, err := tool.Execute(ctx, map[string]interface{}{"action": "append", "text": text}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "append"}); err == nil {
+		t.Error("expected error when appending without text")
+	}
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"action": "read"})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	read := result.(string)
+	if !strings.Contains(read, "1. ") || !strings.Contains(read, "2. ") || !strings.Contains(read, "ruled out config parsing") {
+		t.Errorf("unexpected notes:\n%s", read)
+	}
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "clear"}); err != nil {
+		t.Fatalf("clear failed: %v", err)
+	}
+	if len(store.List()) != 0 {
+		t.Errorf("expected notes to be cleared, got %v", store.List())
+	}
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxOutputBytes is the default amount of output kept per stream
+const DefaultMaxOutputBytes = 64 * 1024
+
+// OutputFunc receives command output as it is produced. stream is "stdout" or "stderr".
+type OutputFunc func(stream, chunk string)
+
+// tailBuffer keeps the last limit bytes written to it and counts everything written
+type tailBuffer struct {
+	buf   []byte
+	limit int
+	total int64
+}
+
+// newTailBuffer creates a buffer retaining at most limit bytes
+func newTailBuffer(limit int) *tailBuffer {
+	if limit <= 0 {
+		limit = DefaultMaxOutputBytes
+	}
+	return &tailBuffer{limit: limit}
+}
+
+// Write appends p, discarding the oldest bytes beyond the limit
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if len(p) >= b.limit {
+		b.buf = append(b.buf[:0], p[len(p)-b.limit:]...)
+		return len(p), nil
+	}
+	if over := len(b.buf) + len(p) - b.limit; over > 0 {
+		n := copy(b.buf, b.buf[over:])
+		b.buf = b.buf[:n]
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// Truncated reports whether output was discarded
+func (b *tailBuffer) Truncated() bool {
+	return b.total > int64(len(b.buf))
+}
+
+// Total returns the number of bytes written
+func (b *tailBuffer) Total() int64 {
+	return b.total
+}
+
+// String returns the retained output. When output was discarded it starts at
+// the first complete line and is prefixed with a marker saying how much is missing.
+func (b *tailBuffer) String() string {
+	if !b.Truncated() {
+		return string(b.buf)
+	}
+	kept := string(b.buf)
+	if i := strings.IndexByte(kept, '\n'); i >= 0 && i < len(kept)-1 {
+		kept = kept[i+1:]
+	}
+	return fmt.Sprintf("[... %d bytes omitted ...]\n%s", b.total-int64(len(kept)), kept)
+}
+
+// streamWriter forwards writes to an OutputFunc. Writers for stdout and stderr
+// share a mutex so the callback is never called concurrently.
+type streamWriter struct {
+	stream string
+	fn     OutputFunc
+	mu     *sync.Mutex
+}
+
+// Write passes p to the callback
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fn(w.stream, string(p))
+	return len(p), nil
+}
+
+// formatBytes renders a byte count for summaries
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
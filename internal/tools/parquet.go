@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// maxParquetFooter bounds the footer read from a parquet file
+const maxParquetFooter = 64 * 1024 * 1024
+
+// parquetMagic starts and ends every parquet file
+var parquetMagic = []byte("PAR1")
+
+// parquetPhysicalTypes names parquet's physical types by their enum value
+var parquetPhysicalTypes = []string{"boolean", "int32", "int64", "int96", "float", "double", "binary", "fixed_len_binary"}
+
+// parquetConvertedTypes names the converted (logical) types that refine a physical type
+var parquetConvertedTypes = map[int64]string{
+	0: "string", 4: "enum", 5: "decimal", 6: "date", 7: "time", 8: "time",
+	9: "timestamp", 10: "timestamp", 11: "uint8", 12: "uint16", 13: "uint32", 14: "uint64",
+	15: "int8", 16: "int16", 17: "int32", 18: "int64", 19: "json", 20: "bson", 21: "interval",
+}
+
+// ReadParquetSchema reads the row count and the leaf columns of a parquet file from
+// its footer. Nested columns are named by their dotted path. Only the metadata is
+// read; the data pages are not decoded.
+func ReadParquetSchema(path string) ([]TableColumn, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tail := make([]byte, 8)
+	if info.Size() < 12 {
+		return nil, 0, errors.New("not a parquet file")
+	}
+	if _, err := f.ReadAt(tail, info.Size()-8); err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(tail[4:], parquetMagic) {
+		return nil, 0, errors.New("not a parquet file")
+	}
+	size := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if size > maxParquetFooter || size > info.Size()-12 {
+		return nil, 0, fmt.Errorf("invalid parquet footer size %d", size)
+	}
+	footer := make([]byte, size)
+	if _, err := f.ReadAt(footer, info.Size()-8-size); err != nil {
+		return nil, 0, err
+	}
+
+	meta, err := (&thriftReader{data: footer}).readStruct()
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid parquet metadata: %w", err)
+	}
+	rows, _ := meta[3].(int64)
+	elements, _ := meta[2].([]interface{})
+	var columns []TableColumn
+	if len(elements) > 0 {
+		// The first element is the root; its children follow depth first
+		root, _ := elements[0].(map[int16]interface{})
+		next := 1
+		children, _ := root[5].(int64)
+		for i := int64(0); i < children; i++ {
+			columns = parquetColumns(elements, &next, "", columns)
+		}
+	}
+	return columns, rows, nil
+}
+
+// parquetColumns appends the leaf columns of the schema element at *next, advancing
+// *next past it and its children
+func parquetColumns(elements []interface{}, next *int, prefix string, columns []TableColumn) []TableColumn {
+	if *next >= len(elements) {
+		return columns
+	}
+	e, _ := elements[*next].(map[int16]interface{})
+	*next++
+	name, _ := e[4].([]byte)
+	full := prefix + string(name)
+
+	if children, ok := e[5].(int64); ok && children > 0 {
+		for i := int64(0); i < children; i++ {
+			columns = parquetColumns(elements, next, full+".", columns)
+		}
+		return columns
+	}
+
+	typ := "unknown"
+	if physical, ok := e[1].(int64); ok && physical >= 0 && int(physical) < len(parquetPhysicalTypes) {
+		typ = parquetPhysicalTypes[physical]
+	}
+	if converted, ok := e[6].(int64); ok && parquetConvertedTypes[converted] != "" {
+		typ = parquetConvertedTypes[converted]
+	}
+	repetition, _ := e[3].(int64)
+	if repetition == 2 {
+		typ = "list<" + typ + ">"
+	}
+	return append(columns, TableColumn{Name: full, Type: typ, Nullable: repetition == 1})
+}
+
+// thriftReader decodes the Thrift compact protocol parquet uses for its metadata into
+// generic values: structs become maps from field ID to value, lists become slices,
+// integers int64 and binary fields []byte
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+// Thrift compact protocol type IDs
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruct = 12
+)
+
+func (r *thriftReader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *thriftReader) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) readZigzag() (int64, error) {
+	v, err := r.readVarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *thriftReader) readStruct() (map[int16]interface{}, error) {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		header, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			long, err := r.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(long)
+		}
+		typ := header & 0x0f
+		var value interface{}
+		switch typ {
+		case thriftTrue:
+			value = true
+		case thriftFalse:
+			value = false
+		default:
+			if value, err = r.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+		fields[id] = value
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case thriftTrue, thriftFalse:
+		// Booleans inside lists take a byte of their own
+		b, err := r.readByte()
+		return b == thriftTrue, err
+	case thriftByte:
+		b, err := r.readByte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return r.readZigzag()
+	case thriftDouble:
+		if r.pos+8 > len(r.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case thriftBinary:
+		n, err := r.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(r.data)-r.pos) < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+		b := r.data[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return b, nil
+	case thriftList, thriftSet:
+		header, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > uint64(len(r.data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		list := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			v, err := r.readValue(header & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case thriftMap:
+		size, err := r.readVarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := r.readValue(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := r.readValue(types & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		// Parquet metadata maps are not needed
+		return nil, nil
+	case thriftStruct:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type %d", typ)
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"codezilla/internal/platform"
 )
 
 // PathValidator provides safe path validation and normalization
@@ -34,8 +36,8 @@ func NewPathValidator() *PathValidator {
 
 // ValidatePath checks if a path is safe to access
 func (v *PathValidator) ValidatePath(path string) (string, error) {
-	// Expand home directory if needed
-	expandedPath := path
+	// Expand home directory or a project root name if needed
+	expandedPath := ExpandRootPath(path)
 	if len(path) > 0 && path[0] == '~' {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
@@ -55,11 +57,12 @@ func (v *PathValidator) ValidatePath(path string) (string, error) {
 		return "", fmt.Errorf("path traversal detected in resolved path")
 	}
 
-	// Check against deny list
+	// Check against deny list, ignoring case where file names are case-insensitive
+	host := platform.Current()
 	for _, denyPath := range v.DenyPaths {
 		// Resolve deny path too
 		absDenyPath, _ := filepath.Abs(denyPath)
-		if strings.HasPrefix(cleanPath, absDenyPath) {
+		if host.Within(cleanPath, absDenyPath) {
 			return "", fmt.Errorf("access to path %s is denied", denyPath)
 		}
 	}
@@ -69,7 +72,7 @@ func (v *PathValidator) ValidatePath(path string) (string, error) {
 		allowed := false
 		for _, allowPath := range v.AllowedPaths {
 			absAllowPath, _ := filepath.Abs(allowPath)
-			if strings.HasPrefix(cleanPath, absAllowPath) {
+			if host.Within(cleanPath, absAllowPath) {
 				allowed = true
 				break
 			}
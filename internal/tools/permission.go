@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -37,12 +38,38 @@ type PermissionRequest struct {
 	ToolContext ToolContext
 	Description string
 	Tool        Tool
+	// Preview shows the effect of the call (e.g. a diff) when the tool implements Previewer
+	Preview string
+	// Dangerous is set for tools that implement Dangerous and report true
+	Dangerous bool
+	// Destructive is why the call is destructive, for tools that implement Guarded.
+	// Such calls are asked about at every permission level, and should only be granted
+	// once the user types DestructiveConfirmation.
+	Destructive string
+	// Invalid is why the parameters the user last edited were rejected. The request
+	// then shows the parameters from before that edit.
+	Invalid string
+}
+
+// Previewer is implemented by tools that can describe their changes before they run,
+// so the user can approve a multi-file change in one step
+type Previewer interface {
+	Preview(ctx context.Context, params map[string]interface{}) (string, error)
+}
+
+// Dangerous is implemented by tools that change the system in ways that are hard to undo,
+// such as installing third-party packages
+type Dangerous interface {
+	Dangerous() bool
 }
 
 // PermissionResponse represents the user's response to a permission request
 type PermissionResponse struct {
 	Granted    bool
 	RememberMe bool // Whether to remember this choice for future uses
+	// Params, when set, are the parameters the user edited the call to. The request is
+	// then made again with them, so the user approves the call that will run.
+	Params map[string]interface{}
 }
 
 // PermissionCallback is a function that handles permission requests
@@ -54,6 +81,7 @@ type ToolPermissionManager interface {
 	// RequestPermission requests permission to execute a tool
 	// If permission is granted, it returns true. Otherwise, it returns false
 	// If an error occurs during the request, it returns an error
+	// If the user edits the parameters before approving, params is updated in place
 	RequestPermission(ctx context.Context, toolName string, params map[string]interface{}, tool Tool) (bool, error)
 
 	// GetDefaultPermissionLevel returns the default permission level for a tool
@@ -111,43 +139,45 @@ func (m *defaultPermissionManager) RequestPermission(ctx context.Context, toolNa
 		}
 	}
 
+	// Destructive calls are always asked about, and the answer never remembered
+	destructive := destructiveReason(tool, params) != ""
+
 	// If we never ask for permission, immediately return granted
-	if perm.Level == NeverAsk {
+	if perm.Level == NeverAsk && !destructive {
 		return true, nil
 	}
 
 	// For AskOnce level, check if we've seen this action before
-	if perm.Level == AskOnce {
+	if perm.Level == AskOnce && !destructive {
 		actionKey := serializeParams(params)
 		if approved, found := perm.ApprovedActions[actionKey]; found {
 			return approved, nil
 		}
 	}
 
-	// We need to ask for permission
-	// Copy params to avoid any potential race conditions
-	paramsCopy := make(map[string]interface{})
-	for k, v := range params {
-		paramsCopy[k] = v
-	}
-
-	request := PermissionRequest{
-		ToolContext: ToolContext{
-			ToolName: toolName,
-			Params:   paramsCopy, // Use our copy
-			Time:     time.Now(),
-		},
-		Description: generateDescription(tool, paramsCopy),
-		Tool:        tool,
+	// We need to ask for permission, again after each edit of the parameters. Edits are
+	// typed text, so they are coerced and validated as the model's calls are.
+	response, err := m.ask(ctx, toolName, params, tool, "")
+	for err == nil && response.Params != nil {
+		edited := CoerceParams(response.Params, tool.ParameterSchema())
+		if invalid := validateEdit(tool, edited); invalid != nil {
+			response, err = m.ask(ctx, toolName, params, tool, invalid.Error())
+			continue
+		}
+		for k := range params {
+			delete(params, k)
+		}
+		for k, v := range edited {
+			params[k] = v
+		}
+		response, err = m.ask(ctx, toolName, params, tool, "")
 	}
-
-	response, err := m.callback(ctx, request)
 	if err != nil {
 		return false, err
 	}
 
 	// Remember this decision if requested
-	if response.RememberMe {
+	if response.RememberMe && destructiveReason(tool, params) == "" {
 		m.permissionsMutex.Lock()
 		defer m.permissionsMutex.Unlock()
 
@@ -168,6 +198,50 @@ func (m *defaultPermissionManager) RequestPermission(ctx context.Context, toolNa
 	return response.Granted, nil
 }
 
+// validateEdit checks parameters the user edited against the tool's schema
+func validateEdit(tool Tool, params map[string]interface{}) error {
+	if err := ValidateToolParams(tool, params); err != nil {
+		return err
+	}
+	return checkParamTypes(params, tool.ParameterSchema())
+}
+
+// ask shows the user a permission request for a call, with why their last edit was
+// rejected if it was
+func (m *defaultPermissionManager) ask(ctx context.Context, toolName string, params map[string]interface{}, tool Tool, invalid string) (PermissionResponse, error) {
+	// Copy params to avoid any potential race conditions
+	paramsCopy := make(map[string]interface{})
+	for k, v := range params {
+		paramsCopy[k] = v
+	}
+
+	request := PermissionRequest{
+		ToolContext: ToolContext{
+			ToolName: toolName,
+			Params:   paramsCopy, // Use our copy
+			Time:     time.Now(),
+		},
+		Description: generateDescription(tool, paramsCopy),
+		Tool:        tool,
+		Invalid:     invalid,
+	}
+
+	if d, ok := tool.(Dangerous); ok {
+		request.Dangerous = d.Dangerous()
+	}
+	request.Destructive = destructiveReason(tool, paramsCopy)
+
+	if previewer, ok := tool.(Previewer); ok {
+		preview, err := previewer.Preview(ctx, paramsCopy)
+		if err != nil {
+			preview = fmt.Sprintf("Preview unavailable: %v", err)
+		}
+		request.Preview = preview
+	}
+
+	return m.callback(ctx, request)
+}
+
 // GetDefaultPermissionLevel returns the default permission level for a tool
 func (m *defaultPermissionManager) GetDefaultPermissionLevel(toolName string) PermissionLevel {
 	m.permissionsMutex.RLock()
@@ -228,6 +302,11 @@ func generateDescription(tool Tool, params map[string]interface{}) string {
 			return fmt.Sprintf("Execute shell command: %s", cmd)
 		}
 		return "Execute shell command"
+	case "startProcess":
+		if cmd, ok := params["command"].(string); ok {
+			return fmt.Sprintf("Start background process: %s", cmd)
+		}
+		return "Start background process"
 	case "fileRead":
 		if path, ok := params["file_path"].(string); ok {
 			return fmt.Sprintf("Read file: %s", path)
@@ -245,6 +324,63 @@ func generateDescription(tool Tool, params map[string]interface{}) string {
 			return fmt.Sprintf("Write to file: %s", path)
 		}
 		return "Write to file"
+	case "multiEdit":
+		if edits, ok := params["edits"].([]interface{}); ok {
+			return fmt.Sprintf("Apply %d file edits as one change", len(edits))
+		}
+		return "Edit multiple files"
+	case "renameSymbol":
+		symbol, _ := params["symbol"].(string)
+		newName, _ := params["new_name"].(string)
+		return fmt.Sprintf("Rename symbol %s to %s across files", symbol, newName)
+	case "scaffold":
+		if template, ok := params["template"].(string); ok && template != "" {
+			return fmt.Sprintf("Create files from template: %s", template)
+		}
+		return "List templates"
+	case "codeSearch":
+		if query, ok := params["query"].(string); ok {
+			return fmt.Sprintf("Search files: %s", query)
+		}
+		return "Search files"
+	case "findDefinition":
+		if name, ok := params["name"].(string); ok {
+			return fmt.Sprintf("Find definition of %s", name)
+		}
+		return "Find definition"
+	case "getSnippet":
+		if name, ok := params["name"].(string); ok && name != "" {
+			return fmt.Sprintf("Read snippet: %s", name)
+		}
+		return "List snippets"
+	case "addDependency", "removeDependency":
+		verb := "Add"
+		if tool.Name() == "removeDependency" {
+			verb = "Remove"
+		}
+		var packages []string
+		if list, ok := params["packages"].([]interface{}); ok {
+			for _, p := range list {
+				packages = append(packages, fmt.Sprint(p))
+			}
+		}
+		return fmt.Sprintf("%s dependencies: %s", verb, strings.Join(packages, ", "))
+	case "createPullRequest":
+		if title, ok := params["title"].(string); ok {
+			return fmt.Sprintf("Open pull request: %s", title)
+		}
+		return "Open pull request"
+	case "createTodoIssues":
+		if planID, ok := params["plan_id"].(string); ok && planID != "" {
+			return fmt.Sprintf("Open issues for the open items of todo plan %s", planID)
+		}
+		return "Open issues for the open items of the current todo plan"
+	case "todo_export":
+		path, _ := params["path"].(string)
+		if path == "" {
+			path = DefaultTodoMarkdownFile
+		}
+		return fmt.Sprintf("Write todo plan checklist to %s", path)
 	default:
 		return fmt.Sprintf("Execute tool: %s", tool.Name())
 	}
@@ -266,6 +402,42 @@ func getInitialPermissionLevel(toolName string) PermissionLevel {
 	case "listFiles":
 		// Listing files is safe, never ask
 		return NeverAsk
+	case "codeSearch", "findDefinition":
+		// Only reads project files and writes its own index, never ask
+		return NeverAsk
+	case "notes":
+		// Notes only live in the session scratchpad, never ask
+		return NeverAsk
+	case "todo_harvest":
+		// Only reads project files into the session's todo plan, never ask
+		return NeverAsk
+	case "getSnippet":
+		// Only reads the user's saved snippets, never ask
+		return NeverAsk
+	case "licenseInventory":
+		// Only reads manifests and license files, never ask
+		return NeverAsk
+	case "parseStackTrace":
+		// Only reads project files around the trace's frames, never ask
+		return NeverAsk
+	case "analyzeLog":
+		// Only reads the log file, never ask
+		return NeverAsk
+	case "dataQuery", "previewTable", "apiSpec", "listTasks":
+		// Only read the given data, spec or task runner files, never ask
+		return NeverAsk
+	case "env":
+		// Read-only, and secret values are redacted, never ask
+		return NeverAsk
+	case "platformInfo":
+		// Only describes the operating system, never ask
+		return NeverAsk
+	case "listProcess", "stopProcess":
+		// Only inspect or stop processes the model started itself, never ask
+		return NeverAsk
+	case "listIssues", "readIssue", "readPullRequest", "triageCI":
+		// Only read from the project's forge (and, for triageCI, project files), never ask
+		return NeverAsk
 	default:
 		// For unknown tools, default to always asking
 		return AlwaysAsk
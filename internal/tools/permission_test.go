@@ -151,3 +151,95 @@ func TestPermissionRequestFormatting(t *testing.T) {
 		t.Error("Tool should not be nil in request")
 	}
 }
+
+func TestPermissionEditedParams(t *testing.T) {
+	var requests []PermissionRequest
+	pm := NewPermissionManager(func(ctx context.Context, req PermissionRequest) (PermissionResponse, error) {
+		requests = append(requests, req)
+		if len(requests) == 1 {
+			return PermissionResponse{Params: map[string]interface{}{"command": "go test ./internal/..."}}, nil
+		}
+		return PermissionResponse{Granted: true}, nil
+	})
+
+	params := map[string]interface{}{"command": "go test ./..."}
+	granted, err := pm.RequestPermission(context.Background(), "execute", params, &ExecuteTool{})
+	if err != nil || !granted {
+		t.Fatalf("RequestPermission() = %v, %v; want granted", granted, err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("asked %d times, want again after the edit", len(requests))
+	}
+	if got := requests[1].ToolContext.Params["command"]; got != "go test ./internal/..." {
+		t.Errorf("second request has command %v, want the edited one", got)
+	}
+	if got := params["command"]; got != "go test ./internal/..." {
+		t.Errorf("params has command %v, want the edited one to run", got)
+	}
+}
+
+func TestPermissionInvalidEdit(t *testing.T) {
+	edits := []map[string]interface{}{
+		{"command": "go test ./...", "timeout_ms": "soon"},
+		{"timeout_ms": "5000"},
+		{"command": "go test ./...", "timeout_ms": "5000"},
+	}
+	var requests []PermissionRequest
+	pm := NewPermissionManager(func(ctx context.Context, req PermissionRequest) (PermissionResponse, error) {
+		requests = append(requests, req)
+		if len(requests) <= len(edits) {
+			return PermissionResponse{Params: edits[len(requests)-1]}, nil
+		}
+		return PermissionResponse{Granted: true}, nil
+	})
+
+	params := map[string]interface{}{"command": "go test ./..."}
+	granted, err := pm.RequestPermission(context.Background(), "execute", params, &ExecuteTool{})
+	if err != nil || !granted {
+		t.Fatalf("RequestPermission() = %v, %v; want granted", granted, err)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("asked %d times, want 4", len(requests))
+	}
+	for i, want := range []bool{false, true, true, false} {
+		if got := requests[i].Invalid != ""; got != want {
+			t.Errorf("request %d invalid = %q, want rejected %v", i, requests[i].Invalid, want)
+		}
+	}
+	if _, edited := requests[2].ToolContext.Params["timeout_ms"]; edited {
+		t.Error("a rejected edit was shown as the call to approve")
+	}
+	if got := params["timeout_ms"]; got != float64(5000) {
+		t.Errorf("timeout_ms = %#v, want the edit coerced to float64(5000)", got)
+	}
+}
+
+func TestPermissionDestructiveCall(t *testing.T) {
+	var requests []PermissionRequest
+	pm := NewPermissionManager(func(ctx context.Context, req PermissionRequest) (PermissionResponse, error) {
+		requests = append(requests, req)
+		return PermissionResponse{Granted: true, RememberMe: true}, nil
+	})
+	pm.SetDefaultPermissionLevel("execute", NeverAsk)
+
+	safe := map[string]interface{}{"command": "rm -rf build"}
+	if granted, err := pm.RequestPermission(context.Background(), "execute", safe, &ExecuteTool{}); err != nil || !granted {
+		t.Fatalf("RequestPermission(safe) = %v, %v; want granted", granted, err)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("asked about a safe command with never_ask")
+	}
+
+	for i := 1; i <= 2; i++ {
+		params := map[string]interface{}{"command": "rm -rf /"}
+		if _, err := pm.RequestPermission(context.Background(), "execute", params, &ExecuteTool{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(requests) != i {
+			t.Fatalf("asked %d times about %d destructive calls, want every time", len(requests), i)
+		}
+	}
+	if requests[0].Destructive == "" {
+		t.Error("request doesn't say why the call is destructive")
+	}
+}
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+
+	"codezilla/internal/platform"
+)
+
+// PlatformTool reports the operating system commands run on, so the model writes
+// commands and paths that work there
+type PlatformTool struct{}
+
+// NewPlatformTool creates a new platform tool
+func NewPlatformTool() *PlatformTool {
+	return &PlatformTool{}
+}
+
+// Name returns the tool name
+func (t *PlatformTool) Name() string {
+	return "platformInfo"
+}
+
+// Description returns the tool description
+func (t *PlatformTool) Description() string {
+	return "Reports the operating system and architecture, the shell execute runs commands in, the path and PATH list separators, whether file names are case-insensitive, the line ending, and the home and temp directories. Check it before writing commands or paths on an unfamiliar system"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *PlatformTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type:       "object",
+		Properties: map[string]JSONSchema{},
+	}
+}
+
+// Execute returns the current platform
+func (t *PlatformTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	return platform.Current(), nil
+}
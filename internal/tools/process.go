@@ -0,0 +1,468 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProcessLogBytes is the amount of output kept per background process
+const DefaultProcessLogBytes = 256 * 1024
+
+// processStopGrace is how long a process gets to exit after SIGTERM before it is killed
+const processStopGrace = 5 * time.Second
+
+// ManagedProcess is a long-lived command started in the background
+type ManagedProcess struct {
+	ID      string
+	Command string
+	PID     int
+	Started time.Time
+
+	cmd  *exec.Cmd
+	mu   sync.Mutex
+	logs *tailBuffer
+	done chan struct{}
+
+	exitCode int
+	exitErr  error
+	ended    time.Time
+}
+
+// Write appends output to the rolling log
+func (p *ManagedProcess) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.logs.Write(b)
+}
+
+// Running reports whether the process is still running
+func (p *ManagedProcess) Running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Output returns the last n lines of output, or all retained output if n <= 0
+func (p *ManagedProcess) Output(n int) string {
+	p.mu.Lock()
+	out := p.logs.String()
+	p.mu.Unlock()
+
+	out = strings.TrimRight(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	if n <= 0 {
+		return out
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Status summarizes the process for tool results
+func (p *ManagedProcess) Status() map[string]interface{} {
+	status := map[string]interface{}{
+		"handle":  p.ID,
+		"command": p.Command,
+		"pid":     p.PID,
+		"running": p.Running(),
+		"started": p.Started.Format(time.RFC3339),
+	}
+	if !p.Running() {
+		status["exit_code"] = p.exitCode
+		status["uptime_ms"] = p.ended.Sub(p.Started).Milliseconds()
+		if p.exitErr != nil {
+			status["error"] = p.exitErr.Error()
+		}
+	} else {
+		status["uptime_ms"] = time.Since(p.Started).Milliseconds()
+	}
+	return status
+}
+
+// wait records how the process ended once it exits
+func (p *ManagedProcess) wait() {
+	err := p.cmd.Wait()
+	p.ended = time.Now()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		p.exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		p.exitCode = -1
+		p.exitErr = err
+	}
+	close(p.done)
+}
+
+// ProcessManager tracks background processes started by the model
+type ProcessManager struct {
+	// WorkingDir is the directory processes are started in (optional)
+	WorkingDir string
+	// MaxLogBytes caps the output kept per process
+	MaxLogBytes int
+
+	mu     sync.Mutex
+	procs  map[string]*ManagedProcess
+	nextID int
+}
+
+// NewProcessManager creates an empty process manager
+func NewProcessManager() *ProcessManager {
+	return &ProcessManager{
+		MaxLogBytes: DefaultProcessLogBytes,
+		procs:       make(map[string]*ManagedProcess),
+	}
+}
+
+// Start launches command in the background and returns immediately
+func (m *ProcessManager) Start(command string) (*ManagedProcess, error) {
+	if err := checkDangerousPatterns(command); err != nil {
+		return nil, err
+	}
+	args := parseCommandArgs(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("p%d", m.nextID)
+	m.mu.Unlock()
+
+	proc := &ManagedProcess{
+		ID:      id,
+		Command: command,
+		logs:    newTailBuffer(m.MaxLogBytes),
+		done:    make(chan struct{}),
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = m.WorkingDir
+	cmd.Env = getCleanEnvironment()
+	cmd.Stdout = proc
+	cmd.Stderr = proc
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+
+	proc.cmd = cmd
+	proc.PID = cmd.Process.Pid
+	proc.Started = time.Now()
+	go proc.wait()
+
+	m.mu.Lock()
+	m.procs[id] = proc
+	m.mu.Unlock()
+	return proc, nil
+}
+
+// Get returns the process with the given handle
+func (m *ProcessManager) Get(id string) (*ManagedProcess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	proc, ok := m.procs[strings.TrimSpace(id)]
+	if !ok {
+		return nil, fmt.Errorf("no process with handle %q", id)
+	}
+	return proc, nil
+}
+
+// List returns all processes, oldest first
+func (m *ProcessManager) List() []*ManagedProcess {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	procs := make([]*ManagedProcess, 0, len(m.procs))
+	for _, p := range m.procs {
+		procs = append(procs, p)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].Started.Before(procs[j].Started)
+	})
+	return procs
+}
+
+// Stop terminates a process and its children, killing them if they do not exit in time
+func (m *ProcessManager) Stop(id string) (*ManagedProcess, error) {
+	proc, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !proc.Running() {
+		return proc, nil
+	}
+
+	terminateProcessGroup(proc.cmd)
+	select {
+	case <-proc.done:
+	case <-time.After(processStopGrace):
+		killProcessGroup(proc.cmd)
+		<-proc.done
+	}
+	return proc, nil
+}
+
+// StopAll stops every running process. It is called when the application exits.
+func (m *ProcessManager) StopAll() {
+	for _, proc := range m.List() {
+		if proc.Running() {
+			m.Stop(proc.ID)
+		}
+	}
+}
+
+// waitForOutput waits until pattern matches the process output, the process
+// exits or the timeout passes, and reports whether the pattern matched
+func waitForOutput(ctx context.Context, proc *ManagedProcess, pattern *regexp.Regexp, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if pattern != nil && pattern.MatchString(proc.Output(0)) {
+			return true
+		}
+		select {
+		case <-proc.done:
+			return pattern != nil && pattern.MatchString(proc.Output(0))
+		case <-deadline.C:
+			return false
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartProcessTool starts a long-lived command such as a dev server in the background
+type StartProcessTool struct {
+	manager *ProcessManager
+}
+
+// NewStartProcessTool creates a startProcess tool backed by the given manager
+func NewStartProcessTool(manager *ProcessManager) *StartProcessTool {
+	return &StartProcessTool{manager: manager}
+}
+
+// Name returns the tool name
+func (t *StartProcessTool) Name() string {
+	return "startProcess"
+}
+
+// Destructive reports commands that are hard or impossible to undo, such as rm -rf /
+func (t *StartProcessTool) Destructive(params map[string]interface{}) string {
+	command, _ := params["command"].(string)
+	return DestructiveCommand(command)
+}
+
+// Description returns the tool description
+func (t *StartProcessTool) Description() string {
+	return "Starts a long-running command (dev server, watcher) in the background and returns a handle. Waits briefly, or until ready_pattern appears in the output, and returns the output so far. Use listProcess to read more output and stopProcess to stop it"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *StartProcessTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"command": {
+				Type:        "string",
+				Description: "The command to start, e.g. npm run dev",
+			},
+			"ready_pattern": {
+				Type:        "string",
+				Description: "Regular expression that signals the process is ready, e.g. listening on",
+			},
+			"wait_ms": {
+				Type:        "integer",
+				Description: "How long to wait for output before returning (default: 3000)",
+				Minimum:     ptr(float64(0)),
+				Maximum:     ptr(float64(60000)),
+			},
+		},
+		Required: []string{"command"},
+	}
+}
+
+// Execute starts the process
+func (t *StartProcessTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	command, _ := params["command"].(string)
+	var pattern *regexp.Regexp
+	if expr, _ := params["ready_pattern"].(string); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, &ErrInvalidToolParams{
+				ToolName: t.Name(),
+				Message:  fmt.Sprintf("invalid ready_pattern: %v", err),
+			}
+		}
+		pattern = re
+	}
+	wait := 3 * time.Second
+	if ms, ok := params["wait_ms"].(float64); ok {
+		wait = time.Duration(ms) * time.Millisecond
+	}
+
+	proc, err := t.manager.Start(command)
+	if err != nil {
+		return nil, &ErrToolExecution{
+			ToolName: t.Name(),
+			Message:  "failed to start process",
+			Err:      err,
+		}
+	}
+
+	ready := waitForOutput(ctx, proc, pattern, wait)
+	result := proc.Status()
+	result["output"] = proc.Output(50)
+	if pattern != nil {
+		result["ready"] = ready
+	}
+	return result, nil
+}
+
+// StopProcessTool stops a background process started with startProcess
+type StopProcessTool struct {
+	manager *ProcessManager
+}
+
+// NewStopProcessTool creates a stopProcess tool backed by the given manager
+func NewStopProcessTool(manager *ProcessManager) *StopProcessTool {
+	return &StopProcessTool{manager: manager}
+}
+
+// Name returns the tool name
+func (t *StopProcessTool) Name() string {
+	return "stopProcess"
+}
+
+// Description returns the tool description
+func (t *StopProcessTool) Description() string {
+	return "Stops a background process started with startProcess and returns its last output"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *StopProcessTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"handle": {
+				Type:        "string",
+				Description: "The handle returned by startProcess",
+			},
+		},
+		Required: []string{"handle"},
+	}
+}
+
+// Execute stops the process
+func (t *StopProcessTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	handle, _ := params["handle"].(string)
+	proc, err := t.manager.Stop(handle)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+
+	result := proc.Status()
+	result["output"] = proc.Output(20)
+	return result, nil
+}
+
+// ListProcessTool lists background processes and shows their recent output
+type ListProcessTool struct {
+	manager *ProcessManager
+}
+
+// NewListProcessTool creates a listProcess tool backed by the given manager
+func NewListProcessTool(manager *ProcessManager) *ListProcessTool {
+	return &ListProcessTool{manager: manager}
+}
+
+// Name returns the tool name
+func (t *ListProcessTool) Name() string {
+	return "listProcess"
+}
+
+// Description returns the tool description
+func (t *ListProcessTool) Description() string {
+	return "Lists background processes started with startProcess. With a handle, returns that process's status and recent output"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *ListProcessTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"handle": {
+				Type:        "string",
+				Description: "Show the output of this process",
+			},
+			"lines": {
+				Type:        "integer",
+				Description: "Number of output lines to return with a handle (default: 50)",
+				Minimum:     ptr(float64(1)),
+				Maximum:     ptr(float64(2000)),
+			},
+		},
+	}
+}
+
+// Execute lists processes or returns the output of one
+func (t *ListProcessTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	if handle, _ := params["handle"].(string); handle != "" {
+		proc, err := t.manager.Get(handle)
+		if err != nil {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+		lines := 50
+		if n, ok := params["lines"].(float64); ok {
+			lines = int(n)
+		}
+		result := proc.Status()
+		result["output"] = proc.Output(lines)
+		return result, nil
+	}
+
+	procs := t.manager.List()
+	statuses := make([]map[string]interface{}, 0, len(procs))
+	for _, proc := range procs {
+		statuses = append(statuses, proc.Status())
+	}
+	return map[string]interface{}{
+		"processes": statuses,
+		"count":     len(statuses),
+	}, nil
+}
+
+// ResultSchema shows the process list as a table
+func (t *ListProcessTool) ResultSchema() ResultSchema {
+	return ResultSchema{
+		Kind:    ResultTable,
+		Rows:    "processes",
+		Columns: []string{"handle", "pid", "running", "exit_code", "uptime_ms", "command"},
+		Summary: []string{"count"},
+	}
+}
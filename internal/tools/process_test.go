@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProcessTools(t *testing.T) {
+	manager := NewProcessManager()
+	defer manager.StopAll()
+	ctx := context.Background()
+
+	result, err := NewStartProcessTool(manager).Execute(ctx, map[string]interface{}{
+		"command":       "sh -c 'echo starting; echo listening on 8080; sleep 30'",
+		"ready_pattern": "listening on \\d+",
+		"wait_ms":       float64(5000),
+	})
+	if err != nil {
+		t.Fatalf("startProcess failed: %v", err)
+	}
+	started := result.(map[string]interface{})
+	if started["ready"] != true || started["running"] != true {
+		t.Fatalf("expected a running, ready process, got %v", started)
+	}
+	handle := started["handle"].(string)
+
+	result, err = NewListProcessTool(manager).Execute(ctx, map[string]interface{}{
+		"handle": handle,
+		"lines":  float64(1),
+	})
+	if err != nil {
+		t.Fatalf("listProcess failed: %v", err)
+	}
+	if out := result.(map[string]interface{})["output"]; out != "listening on 8080" {
+		t.Errorf("output = %q, want the last line", out)
+	}
+
+	result, err = NewStopProcessTool(manager).Execute(ctx, map[string]interface{}{"handle": handle})
+	if err != nil {
+		t.Fatalf("stopProcess failed: %v", err)
+	}
+	if stopped := result.(map[string]interface{}); stopped["running"] != false {
+		t.Errorf("process still running after stop: %v", stopped)
+	}
+
+	if _, err := NewStopProcessTool(manager).Execute(ctx, map[string]interface{}{"handle": "p99"}); err == nil || !strings.Contains(err.Error(), "p99") {
+		t.Errorf("expected an unknown handle error, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package tools
+
+import "os/exec"
+
+// setProcessGroup is a no-op on Windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup stops the command; Windows has no SIGTERM
+func terminateProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
+
+// killProcessGroup forcibly stops the command
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -3,6 +3,8 @@ package tools
 import (
 	"fmt"
 	"time"
+
+	"codezilla/pkg/style"
 )
 
 // ProgressReporter interface for reporting analysis progress
@@ -36,7 +38,7 @@ type TerminalProgressReporter struct {
 func NewTerminalProgressReporter(printFunc func(format string, args ...interface{})) *TerminalProgressReporter {
 	if printFunc == nil {
 		printFunc = func(format string, args ...interface{}) {
-			fmt.Printf(format, args...)
+			fmt.Print(style.Text(fmt.Sprintf(format, args...)))
 		}
 	}
 	return &TerminalProgressReporter{
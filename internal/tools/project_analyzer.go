@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,7 +13,9 @@ import (
 	"sync"
 	"time"
 
+	"codezilla/internal/platform"
 	"codezilla/pkg/logger"
+	"codezilla/pkg/style"
 )
 
 // ================================
@@ -32,6 +36,8 @@ type FileAnalysis struct {
 	Dependencies []string          `json:"dependencies,omitempty"`
 	CodeSmells   []string          `json:"code_smells,omitempty"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
+	// Queries answers each query of a scan with several, in order
+	Queries []QueryAnalysis `json:"queries,omitempty"`
 }
 
 // FileCategory represents the category of a file
@@ -84,7 +90,7 @@ func (a *DefaultFileAnalyzer) AnalyzeFile(ctx context.Context, filePath string,
 		relevance = 0.8
 	}
 
-	return &FileAnalysis{
+	analysis := &FileAnalysis{
 		Summary:     fmt.Sprintf("File with %d lines", len(lines)),
 		KeyFindings: []string{"Basic analysis without LLM"},
 		Relevance:   relevance,
@@ -92,7 +98,12 @@ func (a *DefaultFileAnalyzer) AnalyzeFile(ctx context.Context, filePath string,
 			"analyzer": "default",
 			"lines":    fmt.Sprintf("%d", len(lines)),
 		},
-	}, nil
+	}
+	if queries := analysisQueries(ctx); queries != nil {
+		analysis.Queries = keywordQueryAnalyses(queries, content)
+		analysis.Relevance = maxQueryRelevance(analysis.Queries)
+	}
+	return analysis, nil
 }
 
 // ================================
@@ -103,6 +114,8 @@ func (a *DefaultFileAnalyzer) AnalyzeFile(ctx context.Context, filePath string,
 type LLMFileAnalyzer struct {
 	llmClient LLMClient
 	logger    *logger.Logger
+	prompts   *AnalysisPrompts
+	outline   Outliner // Finds where large files are split; nil splits by size only
 }
 
 // NewLLMFileAnalyzer creates a new LLM-based file analyzer
@@ -110,44 +123,68 @@ func NewLLMFileAnalyzer(llmClient LLMClient, logger *logger.Logger) *LLMFileAnal
 	return &LLMFileAnalyzer{
 		llmClient: llmClient,
 		logger:    logger,
+		prompts:   NewAnalysisPrompts("", nil),
 	}
 }
 
-// AnalyzeFile analyzes a file using LLM
+// AnalyzeFile analyzes a file using LLM. Files too large for one call are split into
+// chunks at the definitions in the file's outline, analyzed separately and merged.
 func (a *LLMFileAnalyzer) AnalyzeFile(ctx context.Context, filePath string, content string, userQuery string) (*FileAnalysis, error) {
 	// Check if LLM client is available
 	if a.llmClient == nil {
-		return a.fallbackAnalysis(filePath, content, userQuery), nil
+		return a.fallbackAnalysis(ctx, filePath, content, userQuery), nil
 	}
-	// Truncate content if too large (keep first and last parts)
-	maxContentSize := 4000 // Conservative limit for context
-	truncatedContent := content
-	if len(content) > maxContentSize {
-		halfSize := maxContentSize / 2
-		truncatedContent = content[:halfSize] + "\n\n[... content truncated ...]\n\n" + content[len(content)-halfSize:]
+	if len(content) <= maxAnalysisChunkSize {
+		analysis, err := a.analyzeContent(ctx, filePath, "", content, userQuery)
+		if err != nil {
+			return a.fallbackAnalysis(ctx, filePath, content, userQuery), nil
+		}
+		return analysis, nil
 	}
 
-	prompt := fmt.Sprintf(`Analyze the following file with respect to this query: "%s"
-
-File: %s
-
-Content:
-%s
-
-Provide a structured analysis with:
-1. A brief summary (1-2 sentences)
-2. Key findings relevant to the query (list 2-5 items)
-3. Relevance score (0-1) based on how well this file matches the query
-4. Any issues, problems, or code smells found (if applicable)
-5. Key dependencies or relationships with other parts of the codebase (if identifiable)
+	var starts []int
+	if a.outline != nil {
+		starts = a.outline(filePath, []byte(content))
+	}
+	all := splitIntoChunks(content, starts, maxAnalysisChunkSize)
+	chunks := selectChunks(all, userQuery, maxAnalysisChunks)
+	analyses := make([]*FileAnalysis, len(chunks))
+	succeeded := 0
+	for i, chunk := range chunks {
+		if ctx.Err() != nil {
+			break
+		}
+		section := fmt.Sprintf("lines %d-%d", chunk.StartLine, chunk.EndLine)
+		analysis, err := a.analyzeContent(ctx, filePath, section, chunk.Text, userQuery)
+		if err != nil {
+			continue
+		}
+		analyses[i] = analysis
+		succeeded++
+	}
+	if succeeded == 0 {
+		return a.fallbackAnalysis(ctx, filePath, content, userQuery), nil
+	}
+	return mergeChunkAnalyses(chunks, analyses, len(all)), nil
+}
 
-Format your response as JSON with these fields:
-- summary: string
-- key_findings: array of strings
-- relevance: number between 0 and 1
-- issues: array of strings (optional)
-- dependencies: array of strings (optional)
-- code_smells: array of strings (optional)`, userQuery, filePath, truncatedContent)
+// analyzeContent asks the model to analyze content, which is the part of the file
+// named by section, or the whole file when section is empty
+func (a *LLMFileAnalyzer) analyzeContent(ctx context.Context, filePath, section, content, userQuery string) (*FileAnalysis, error) {
+	file := filePath
+	if section != "" {
+		file = fmt.Sprintf("%s (%s; the file is analyzed in parts, judge only this part)", filePath, section)
+	}
+	// The scan validates the profile, so an unknown one only comes from direct use
+	rubric, err := a.prompts.Rubric(analysisProfile(ctx))
+	if err != nil {
+		rubric, _ = a.prompts.Rubric("")
+	}
+	queries := analysisQueries(ctx)
+	if queries != nil {
+		rubric += "\n\n" + multiQueryInstructions(len(queries))
+	}
+	prompt := a.prompts.Render(userQuery, file, content, rubric)
 
 	messages := []LLMMessage{
 		{
@@ -163,15 +200,18 @@ Format your response as JSON with these fields:
 	response, err := a.llmClient.GenerateResponse(ctx, messages)
 	if err != nil {
 		a.logger.Error("LLM analysis failed for %s: %v", filePath, err)
-		// Fall back to basic analysis
-		return a.fallbackAnalysis(filePath, content, userQuery), nil
+		return nil, err
 	}
 
 	// Parse LLM response
 	analysis, err := a.parseAnalysisResponse(response)
 	if err != nil {
 		a.logger.Warn("Failed to parse LLM response for %s: %v", filePath, err)
-		return a.fallbackAnalysis(filePath, content, userQuery), nil
+		return nil, err
+	}
+	if queries != nil {
+		analysis.Queries = alignQueryAnalyses(queries, analysis.Queries)
+		analysis.Relevance = maxQueryRelevance(analysis.Queries)
 	}
 
 	return analysis, nil
@@ -194,12 +234,13 @@ func (a *LLMFileAnalyzer) parseAnalysisResponse(response string) (*FileAnalysis,
 	}
 
 	var result struct {
-		Summary      string   `json:"summary"`
-		KeyFindings  []string `json:"key_findings"`
-		Relevance    float64  `json:"relevance"`
-		Issues       []string `json:"issues,omitempty"`
-		Dependencies []string `json:"dependencies,omitempty"`
-		CodeSmells   []string `json:"code_smells,omitempty"`
+		Summary      string          `json:"summary"`
+		KeyFindings  []string        `json:"key_findings"`
+		Relevance    float64         `json:"relevance"`
+		Issues       []string        `json:"issues,omitempty"`
+		Dependencies []string        `json:"dependencies,omitempty"`
+		CodeSmells   []string        `json:"code_smells,omitempty"`
+		Queries      []QueryAnalysis `json:"queries,omitempty"`
 	}
 
 	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
@@ -220,13 +261,14 @@ func (a *LLMFileAnalyzer) parseAnalysisResponse(response string) (*FileAnalysis,
 		Issues:       result.Issues,
 		Dependencies: result.Dependencies,
 		CodeSmells:   result.CodeSmells,
+		Queries:      result.Queries,
 		Metadata: map[string]string{
 			"analyzer": "llm",
 		},
 	}, nil
 }
 
-func (a *LLMFileAnalyzer) fallbackAnalysis(filePath string, content string, userQuery string) *FileAnalysis {
+func (a *LLMFileAnalyzer) fallbackAnalysis(ctx context.Context, filePath string, content string, userQuery string) *FileAnalysis {
 	lines := strings.Split(content, "\n")
 
 	// Simple keyword-based relevance
@@ -238,7 +280,7 @@ func (a *LLMFileAnalyzer) fallbackAnalysis(filePath string, content string, user
 		relevance = 0.8
 	}
 
-	return &FileAnalysis{
+	analysis := &FileAnalysis{
 		Summary:     fmt.Sprintf("File with %d lines (fallback analysis)", len(lines)),
 		KeyFindings: []string{"LLM analysis failed, using fallback"},
 		Relevance:   relevance,
@@ -247,6 +289,11 @@ func (a *LLMFileAnalyzer) fallbackAnalysis(filePath string, content string, user
 			"lines":    fmt.Sprintf("%d", len(lines)),
 		},
 	}
+	if queries := analysisQueries(ctx); queries != nil {
+		analysis.Queries = keywordQueryAnalyses(queries, content)
+		analysis.Relevance = maxQueryRelevance(analysis.Queries)
+	}
+	return analysis
 }
 
 // ================================
@@ -790,9 +837,24 @@ type CategoryMetrics struct {
 // ProjectScanAnalyzer is an enhanced version with all improvements
 type ProjectScanAnalyzer struct {
 	*EnhancedProjectScanAnalyzer
+	llmAnalyzer      *LLMFileAnalyzer
 	errorHandler     *ErrorHandler
 	progressReporter SimpleProgressReporter
 	analysisMetrics  *AnalysisMetrics
+
+	// PrioritizeChanged analyzes files changed in the git working tree or on the
+	// current branch first, unless a call sets prioritizeChanged to false
+	PrioritizeChanged bool
+	// Output, if set, is where progress is shown instead of stderr
+	Output io.Writer
+}
+
+// output is where the analyzer shows its progress
+func (a *ProjectScanAnalyzer) output() io.Writer {
+	if a.Output != nil {
+		return a.Output
+	}
+	return os.Stderr
 }
 
 // NewProjectScanAnalyzer creates the enhanced analyzer
@@ -808,6 +870,7 @@ func NewProjectScanAnalyzer(llmClient LLMClient, logger *logger.Logger) *Project
 
 	return &ProjectScanAnalyzer{
 		EnhancedProjectScanAnalyzer: enhancedAnalyzer,
+		llmAnalyzer:                 llmAnalyzer,
 		errorHandler:                errorHandler,
 		progressReporter:            &NullSimpleProgressReporter{},
 		analysisMetrics: &AnalysisMetrics{
@@ -817,6 +880,17 @@ func NewProjectScanAnalyzer(llmClient LLMClient, logger *logger.Logger) *Project
 	}
 }
 
+// SetOutliner sets how large files are split into chunks for analysis, so that
+// chunks start at definitions
+func (a *ProjectScanAnalyzer) SetOutliner(outline Outliner) {
+	a.llmAnalyzer.outline = outline
+}
+
+// SetAnalysisPrompts replaces the prompt template and profiles used to analyze files
+func (a *ProjectScanAnalyzer) SetAnalysisPrompts(prompts *AnalysisPrompts) {
+	a.llmAnalyzer.prompts = prompts
+}
+
 // Name returns the tool name
 func (a *ProjectScanAnalyzer) Name() string {
 	return "projectScanAnalyzer"
@@ -847,6 +921,68 @@ func (a *ProjectScanAnalyzer) ParameterSchema() JSONSchema {
 		Description: "Specific directories to search in, relative to the base dir (e.g., ['internal/', 'cmd/', 'pkg/']). If provided, only these directories will be scanned instead of the entire project. Useful for focusing analysis on specific parts of large codebases.",
 	}
 
+	profiles := make([]interface{}, 0)
+	for _, name := range a.llmAnalyzer.prompts.Names() {
+		profiles = append(profiles, name)
+	}
+	baseSchema.Properties["analysisProfile"] = JSONSchema{
+		Type:        "string",
+		Description: "Rubric to analyze each file with, e.g. 'concurrency' to look for data races and deadlocks or 'security' for injection and unsafe input handling (default: 'default')",
+		Enum:        profiles,
+	}
+
+	baseSchema.Properties["queries"] = JSONSchema{
+		Type: "array",
+		Items: &JSONSchema{
+			Type: "string",
+		},
+		Description: "Several questions to answer in one pass over the files instead of userQuery (or in addition to it). Each file is analyzed once for all of them, and the result gives per-query relevance and findings for each file and the relevant files for each query.",
+	}
+	baseSchema.Properties["userQuery"] = JSONSchema{
+		Type:        "string",
+		Description: "The user's query or analysis criteria to apply to each file (required unless queries is given)",
+	}
+	baseSchema.Required = nil
+
+	baseSchema.Properties["diffRef"] = JSONSchema{
+		Type:        "string",
+		Description: "Diff-scoped mode: only analyze the changes between this git ref and the working tree (e.g. 'main', 'HEAD~1', 'HEAD' for uncommitted changes), each changed part with surrounding lines numbered as in the new file. Findings cite those line numbers. userQuery defaults to a code review",
+	}
+
+	baseSchema.Properties["patch"] = JSONSchema{
+		Type:        "string",
+		Description: "Diff-scoped mode from a unified diff instead of diffRef, with paths relative to dir",
+	}
+
+	baseSchema.Properties["diffContext"] = JSONSchema{
+		Type:        "integer",
+		Description: "Lines shown around each change in diff-scoped mode (default: 10)",
+		Default:     defaultDiffContext,
+	}
+
+	baseSchema.Properties["sarifFile"] = JSONSchema{
+		Type:        "string",
+		Description: "Also write the merged issues to this file as SARIF 2.1.0, for GitHub code scanning or an IDE (e.g. 'codezilla.sarif')",
+	}
+
+	baseSchema.Properties["useBaseline"] = JSONSchema{
+		Type:        "boolean",
+		Description: "Leave out issues accepted as known in .codezilla/baseline.json (default: true)",
+		Default:     true,
+	}
+
+	baseSchema.Properties["updateBaseline"] = JSONSchema{
+		Type:        "boolean",
+		Description: "Accept every issue this scan finds as known in .codezilla/baseline.json, so later scans only report new ones. Only when the user asks for it",
+		Default:     false,
+	}
+
+	baseSchema.Properties["prioritizeChanged"] = JSONSchema{
+		Type:        "boolean",
+		Description: "Analyze and list files changed in the git working tree or on the current branch first, as they are most likely related to the task (default: from configuration)",
+		Default:     a.PrioritizeChanged,
+	}
+
 	baseSchema.Properties["onlyInSpecificDirs"] = JSONSchema{
 		Type:        "boolean",
 		Description: "When true and specificDirs is provided, only scan files directly in those directories (no subdirectories). Useful when you want to analyze only the top-level files in specific folders. When false, scan all subdirectories within specificDirs. Default: false",
@@ -887,9 +1023,36 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 		}
 	}
 
-	userQuery, ok := params["userQuery"].(string)
-	if !ok || userQuery == "" {
-		return nil, fmt.Errorf("userQuery is required")
+	// Several queries are answered in one pass, with one call per file covering all of them
+	var queries []string
+	if list, ok := params["queries"].([]interface{}); ok {
+		for _, q := range list {
+			if s, ok := q.(string); ok && strings.TrimSpace(s) != "" {
+				queries = append(queries, strings.TrimSpace(s))
+			}
+		}
+	}
+	userQuery, _ := params["userQuery"].(string)
+	if userQuery != "" && len(queries) > 0 {
+		queries = append([]string{userQuery}, queries...)
+	}
+	switch {
+	case len(queries) == 1:
+		userQuery = queries[0]
+	case len(queries) > 1:
+		userQuery = combineQueries(queries)
+		ctx = withAnalysisQueries(ctx, queries)
+	}
+
+	// A diff-scoped scan only analyzes the changed parts of the files a diff touches
+	diffRef, _ := params["diffRef"].(string)
+	patch, _ := params["patch"].(string)
+	diffScoped := diffRef != "" || patch != ""
+	if userQuery == "" {
+		if !diffScoped {
+			return nil, fmt.Errorf("userQuery or queries is required")
+		}
+		userQuery = defaultDiffQuery
 	}
 
 	// Setup progress reporter
@@ -899,7 +1062,7 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 	if enableProgress {
 		enhancedReporter := NewEnhancedProgressReporter(
 			func(format string, args ...interface{}) {
-				fmt.Fprintf(os.Stderr, format, args...)
+				fmt.Fprint(a.output(), style.Text(fmt.Sprintf(format, args...)))
 			},
 			showDetails,
 		)
@@ -919,6 +1082,13 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 		}
 	}
 
+	// Analyzers read the profile from the context
+	profile, _ := params["analysisProfile"].(string)
+	if _, err := a.llmAnalyzer.prompts.Rubric(profile); err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: a.Name(), Message: err.Error()}
+	}
+	ctx = withAnalysisProfile(ctx, profile)
+
 	// Get analysis parameters
 	pattern, _ := params["pattern"].(string)
 	includeHidden := getBoolParam(params, "includeHidden", false)
@@ -931,6 +1101,12 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 	excludePatterns := getDefaultExcludePatterns()
 	if customExcludes, ok := params["excludePatterns"].([]string); ok {
 		excludePatterns = append(excludePatterns, customExcludes...)
+	} else if customExcludes, ok := params["excludePatterns"].([]interface{}); ok {
+		for _, p := range customExcludes {
+			if pattern, ok := p.(string); ok {
+				excludePatterns = append(excludePatterns, pattern)
+			}
+		}
 	}
 
 	// Get specific directories to search
@@ -949,17 +1125,37 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 	// Scan for files
 	if len(specificDirs) > 0 {
 		if onlyInSpecificDirs {
-			fmt.Fprintf(os.Stderr, "🎯 Scanning only files in specific directories (no subdirectories): %v\n", specificDirs)
+			fmt.Fprint(a.output(), style.Text(fmt.Sprintf("🎯 Scanning only files in specific directories (no subdirectories): %v\n", specificDirs)))
 		} else {
-			fmt.Fprintf(os.Stderr, "🎯 Scanning specific directories (including subdirectories): %v\n", specificDirs)
+			fmt.Fprint(a.output(), style.Text(fmt.Sprintf("🎯 Scanning specific directories (including subdirectories): %v\n", specificDirs)))
 		}
 	}
-	files, err := a.scanFiles(dir, pattern, excludePatterns, includeHidden, maxDepth, specificDirs, onlyInSpecificDirs)
-	if err != nil {
-		return nil, &ErrToolExecution{
-			ToolName: a.Name(),
-			Message:  "failed to scan directory",
-			Err:      err,
+	var files []string
+	var excerpts map[string]string
+	var changedLines map[string][][2]int
+	if diffScoped {
+		var diffFiles []string
+		diffFiles, excerpts, changedLines, err = diffScope(ctx, dir, diffRef, patch, getIntParam(params, "diffContext", defaultDiffContext))
+		if err != nil {
+			return nil, &ErrToolExecution{
+				ToolName: a.Name(),
+				Message:  "failed to get the diff",
+				Err:      err,
+			}
+		}
+		for _, path := range diffFiles {
+			if info, err := os.Stat(path); err == nil && a.shouldIncludeFile(path, info, dir, pattern, excludePatterns, includeHidden) {
+				files = append(files, path)
+			}
+		}
+	} else {
+		files, err = a.scanFiles(dir, pattern, excludePatterns, includeHidden, maxDepth, specificDirs, onlyInSpecificDirs)
+		if err != nil {
+			return nil, &ErrToolExecution{
+				ToolName: a.Name(),
+				Message:  "failed to scan directory",
+				Err:      err,
+			}
 		}
 	}
 
@@ -978,6 +1174,14 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 	// Sort files by path for consistent ordering
 	sort.Strings(files)
 
+	// Files changed in git come first, so they are analyzed even if the scan is cut short
+	var changed map[string]bool
+	if getBoolParam(params, "prioritizeChanged", a.PrioritizeChanged) {
+		if changed, err = ChangedFiles(ctx, dir); err == nil {
+			sort.SliceStable(files, func(i, j int) bool { return changed[files[i]] && !changed[files[j]] })
+		}
+	}
+
 	// Categorize files
 	fileCategories := make(map[string]FileCategory)
 	for _, filePath := range files {
@@ -1007,10 +1211,36 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 	a.progressReporter.StartAnalysis(len(files))
 
 	// Analyze files sequentially
-	err = a.analyzeFilesSequential(ctx, files, fileCategories, userQuery, relevanceThreshold, timeout, result)
+	err = a.analyzeFilesSequential(ctx, files, excerpts, fileCategories, userQuery, relevanceThreshold, timeout, result)
 	if err != nil {
 		return nil, err
 	}
+	for i := range result.FileResults {
+		result.FileResults[i].Changed = changed[result.FileResults[i].Path]
+		result.FileResults[i].ChangedLines = changedLines[result.FileResults[i].Path]
+	}
+	if len(queries) > 1 {
+		result.Queries = queries
+		result.QueryFiles = filesByQuery(queries, result.FileResults, relevanceThreshold)
+	}
+	result.Issues = collectIssues(result.FileResults, fileCategories)
+	if err := a.applyBaseline(dir, params, result); err != nil {
+		return nil, &ErrToolExecution{
+			ToolName: a.Name(),
+			Message:  "failed to apply the baseline",
+			Err:      err,
+		}
+	}
+	if sarifFile, _ := params["sarifFile"].(string); sarifFile != "" {
+		if err := writeSARIFFile(sarifFile, dir, result.Issues); err != nil {
+			return nil, &ErrToolExecution{
+				ToolName: a.Name(),
+				Message:  "failed to write SARIF file",
+				Err:      err,
+			}
+		}
+		result.SARIFFile = sarifFile
+	}
 
 	// Generate summary
 	a.generateEnhancedSummary(result, fileCategories, maxFileSize)
@@ -1024,8 +1254,29 @@ func (a *ProjectScanAnalyzer) Execute(ctx context.Context, params map[string]int
 	return result, nil
 }
 
-// analyzeFilesSequential performs sequential analysis of files
-func (a *ProjectScanAnalyzer) analyzeFilesSequential(ctx context.Context, files []string,
+// applyBaseline leaves out the issues the project's baseline accepts, or records
+// them all in it when the call asks to update it
+func (a *ProjectScanAnalyzer) applyBaseline(dir string, params map[string]interface{}, result *EnhancedProjectScanResult) error {
+	update := getBoolParam(params, "updateBaseline", false)
+	if !update && !getBoolParam(params, "useBaseline", true) {
+		return nil
+	}
+	baseline, err := LoadBaseline(dir)
+	if err != nil {
+		return err
+	}
+	if update {
+		baseline.Update(dir, "scan", result.Issues)
+		result.BaselineUpdated = true
+		return baseline.Save(dir)
+	}
+	result.Issues, result.SuppressedIssues = baseline.Filter(dir, result.Issues)
+	return nil
+}
+
+// analyzeFilesSequential performs sequential analysis of files. Files with an
+// excerpt are analyzed through it instead of their whole content.
+func (a *ProjectScanAnalyzer) analyzeFilesSequential(ctx context.Context, files []string, excerpts map[string]string,
 	fileCategories map[string]FileCategory, userQuery string,
 	relevanceThreshold float64, timeout time.Duration, result *EnhancedProjectScanResult) error {
 
@@ -1043,7 +1294,7 @@ func (a *ProjectScanAnalyzer) analyzeFilesSequential(ctx context.Context, files
 		fileCtx, cancel := context.WithTimeout(ctx, timeout)
 
 		// Analyze the file
-		fileResult, timelineEvent := a.analyzeFileWithMetrics(fileCtx, filePath, fileCategories[filePath], userQuery)
+		fileResult, timelineEvent := a.analyzeFileWithMetrics(fileCtx, filePath, excerpts, fileCategories[filePath], userQuery)
 
 		cancel() // Clean up the context
 
@@ -1084,7 +1335,7 @@ func (a *ProjectScanAnalyzer) analyzeFilesSequential(ctx context.Context, files
 }
 
 // analyzeFileWithMetrics analyzes a single file and records metrics
-func (a *ProjectScanAnalyzer) analyzeFileWithMetrics(ctx context.Context, filePath string,
+func (a *ProjectScanAnalyzer) analyzeFileWithMetrics(ctx context.Context, filePath string, excerpts map[string]string,
 	category FileCategory, userQuery string) (FileResult, TimelineEvent) {
 
 	startTime := time.Now()
@@ -1102,7 +1353,13 @@ func (a *ProjectScanAnalyzer) analyzeFileWithMetrics(ctx context.Context, filePa
 	}
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("%s:%s", filePath, userQuery)
+	cacheKey := fmt.Sprintf("%s:%s:%s", filePath, analysisProfile(ctx), userQuery)
+	excerpt, scoped := excerpts[filePath]
+	if scoped {
+		h := fnv.New64a()
+		h.Write([]byte(excerpt))
+		cacheKey = fmt.Sprintf("%s:diff-%x", cacheKey, h.Sum64())
+	}
 	if cached, found := a.analysisCache.Get(cacheKey); found {
 		event.Success = true
 		event.DurationMs = 0 // Cached result
@@ -1114,7 +1371,11 @@ func (a *ProjectScanAnalyzer) analyzeFileWithMetrics(ctx context.Context, filePa
 
 	// Read file content
 	readStart := time.Now()
-	content, err := os.ReadFile(filePath)
+	content := []byte(excerpt)
+	var err error
+	if !scoped {
+		content, err = os.ReadFile(filePath)
+	}
 	if err != nil {
 		event.ErrorMsg = fmt.Sprintf("failed to read: %v", err)
 		event.DurationMs = time.Since(startTime).Milliseconds()
@@ -1292,6 +1553,16 @@ type ProjectAnalysisResult struct {
 	FileResults   []FileResult `json:"file_results"`
 	Summary       string       `json:"summary"`
 	Errors        []string     `json:"errors,omitempty"`
+	// Queries and QueryFiles are set for scans with several queries
+	Queries    []string     `json:"queries,omitempty"`
+	QueryFiles []QueryFiles `json:"query_files,omitempty"`
+	// Issues are the issues and code smells of the relevant files with duplicates
+	// merged, most severe first
+	Issues    []CodeIssue `json:"issues,omitempty"`
+	SARIFFile string      `json:"sarif_file,omitempty"` // Where the issues were written as SARIF
+	// SuppressedIssues counts the issues left out because the baseline accepts them
+	SuppressedIssues int  `json:"suppressed_issues,omitempty"`
+	BaselineUpdated  bool `json:"baseline_updated,omitempty"`
 }
 
 // FileResult represents a single file analysis result
@@ -1299,6 +1570,9 @@ type FileResult struct {
 	Path     string       `json:"path"`
 	Analysis FileAnalysis `json:"analysis,omitempty"`
 	Error    string       `json:"error,omitempty"`
+	Changed  bool         `json:"changed,omitempty"` // Changed in git, see ChangedFiles
+	// ChangedLines are the new-file lines a diff-scoped scan analyzed the file for
+	ChangedLines [][2]int `json:"changed_lines,omitempty"`
 }
 
 // scanFiles scans the directory for files matching criteria
@@ -1487,6 +1761,12 @@ func (a *ProjectScanAnalyzer) generateEnhancedSummary(result *EnhancedProjectSca
 	if totalIssues > 0 {
 		summaryParts = append(summaryParts, fmt.Sprintf("Identified %d total issues", totalIssues))
 	}
+	if len(result.Issues) > 0 {
+		summaryParts = append(summaryParts, fmt.Sprintf("%d distinct issues in relevant files after merging duplicates", len(result.Issues)))
+	}
+	if result.SuppressedIssues > 0 {
+		summaryParts = append(summaryParts, fmt.Sprintf("Left out %d known issues accepted in the baseline", result.SuppressedIssues))
+	}
 
 	if result.SkippedFiles > 0 {
 		summaryParts = append(summaryParts, fmt.Sprintf("Skipped %d files", result.SkippedFiles))
@@ -1507,9 +1787,19 @@ func getFloatParam(params map[string]interface{}, key string, defaultValue float
 	return defaultValue
 }
 
-// Helper function to check if path matches any exclude pattern
+// Helper function to check if path matches any exclude pattern. Patterns are written
+// with forward slashes and match regardless of case where file names are case-insensitive.
 func matchesAnyPattern(path string, patterns []string) bool {
+	foldCase := platform.Current().CaseInsensitive
+	path = filepath.ToSlash(path)
+	if foldCase {
+		path = strings.ToLower(path)
+	}
 	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		if foldCase {
+			pattern = strings.ToLower(pattern)
+		}
 		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
 			return true
 		}
@@ -1560,7 +1850,7 @@ type ProgressStats struct {
 func NewEnhancedProgressReporter(printFunc func(format string, args ...interface{}), showDetails bool) *EnhancedProgressReporter {
 	if printFunc == nil {
 		printFunc = func(format string, args ...interface{}) {
-			fmt.Printf(format, args...)
+			fmt.Print(style.Text(fmt.Sprintf(format, args...)))
 		}
 	}
 
@@ -0,0 +1,475 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"codezilla/internal/platform"
+)
+
+// Rename backends
+const (
+	renameModeAuto  = "auto"
+	renameModeGopls = "gopls"
+	renameModeText  = "text"
+)
+
+// RenameSymbolTool renames an identifier across files, using gopls for Go when available
+type RenameSymbolTool struct {
+	// MaxFileSize skips larger files during textual search
+	MaxFileSize int64
+}
+
+// NewRenameSymbolTool creates a new rename symbol tool
+func NewRenameSymbolTool() *RenameSymbolTool {
+	return &RenameSymbolTool{
+		MaxFileSize: 1024 * 1024,
+	}
+}
+
+// Name returns the tool name
+func (t *RenameSymbolTool) Name() string {
+	return "renameSymbol"
+}
+
+// Description returns the tool description
+func (t *RenameSymbolTool) Description() string {
+	return "Renames a symbol across files. Uses gopls for Go (give file and line of a use or the declaration) and whole-word text matching otherwise. All affected files are shown as a diff and written atomically after one approval"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *RenameSymbolTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"symbol": {
+				Type:        "string",
+				Description: "The current name of the symbol",
+			},
+			"new_name": {
+				Type:        "string",
+				Description: "The new name for the symbol",
+			},
+			"dir": {
+				Type:        "string",
+				Description: "Directory to search for textual renames (defaults to current directory)",
+			},
+			"file": {
+				Type:        "string",
+				Description: "File containing a use or the declaration of the symbol (required for gopls)",
+			},
+			"line": {
+				Type:        "integer",
+				Description: "1-based line in file where the symbol appears (required for gopls)",
+			},
+			"pattern": {
+				Type:        "string",
+				Description: "Optional glob pattern limiting textual renames to matching file names (e.g., '*.py')",
+			},
+			"mode": {
+				Type:        "string",
+				Description: "Rename backend: auto (gopls for .go files when installed, text otherwise), gopls or text",
+				Enum:        []interface{}{renameModeAuto, renameModeGopls, renameModeText},
+				Default:     renameModeAuto,
+			},
+			"dry_run": {
+				Type:        "boolean",
+				Description: "Only return the preview without writing any files",
+				Default:     false,
+			},
+		},
+		Required: []string{"symbol", "new_name"},
+	}
+}
+
+// renameEdit replaces one occurrence of the symbol
+type renameEdit struct {
+	Line   int // 1-based
+	Column int // 1-based byte column
+}
+
+// renamePlan holds the computed changes for a rename
+type renamePlan struct {
+	Mode        string
+	Symbol      string
+	NewName     string
+	Files       []string // sorted paths of changed files
+	Original    map[string]string
+	Updated     map[string]string
+	Occurrences map[string][]renameEdit
+	// Skipped lists references outside the project, such as in GOROOT or the module
+	// cache, which are left alone
+	Skipped []string
+}
+
+// Preview describes the changes the rename would make, shown before permission is requested
+func (t *RenameSymbolTool) Preview(ctx context.Context, params map[string]interface{}) (string, error) {
+	plan, err := t.plan(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	return plan.diff(), nil
+}
+
+// Execute renames the symbol in all affected files
+func (t *RenameSymbolTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	plan, err := t.plan(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"mode":        plan.Mode,
+		"symbol":      plan.Symbol,
+		"new_name":    plan.NewName,
+		"files":       plan.Files,
+		"occurrences": plan.count(),
+		"changes":     plan.summary(),
+	}
+	if len(plan.Skipped) > 0 {
+		result["skipped_outside_project"] = plan.Skipped
+	}
+
+	if getBoolParam(params, "dry_run", false) || len(plan.Files) == 0 {
+		result["applied"] = false
+		return result, nil
+	}
+
+	tx := NewEditTransaction()
+	for _, path := range plan.Files {
+		if err := tx.Stage(path, plan.Updated[path]); err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to stage rename", Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, &ErrToolExecution{
+			ToolName: t.Name(),
+			Message:  "failed to apply rename, no files were changed",
+			Err:      err,
+		}
+	}
+
+	result["applied"] = true
+	return result, nil
+}
+
+// plan computes the rename without touching the filesystem
+func (t *RenameSymbolTool) plan(ctx context.Context, params map[string]interface{}) (*renamePlan, error) {
+	symbol, _ := params["symbol"].(string)
+	newName, _ := params["new_name"].(string)
+	symbol = strings.TrimSpace(symbol)
+	newName = strings.TrimSpace(newName)
+	if symbol == "" || newName == "" {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: "symbol and new_name must not be empty"}
+	}
+	if symbol == newName {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: "new_name is the same as symbol"}
+	}
+
+	file, _ := params["file"].(string)
+	line := getIntParam(params, "line", 0)
+	mode, _ := params["mode"].(string)
+	if mode == "" {
+		mode = renameModeAuto
+	}
+
+	if file != "" {
+		cleanFile, err := ValidateAndCleanPath(file)
+		if err != nil {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+		file = cleanFile
+	}
+
+	if mode == renameModeAuto {
+		mode = renameModeText
+		if file != "" && line > 0 && strings.HasSuffix(file, ".go") {
+			if _, err := exec.LookPath("gopls"); err == nil {
+				mode = renameModeGopls
+			}
+		}
+	}
+
+	plan := &renamePlan{
+		Mode:        mode,
+		Symbol:      symbol,
+		NewName:     newName,
+		Original:    make(map[string]string),
+		Updated:     make(map[string]string),
+		Occurrences: make(map[string][]renameEdit),
+	}
+
+	var err error
+	switch mode {
+	case renameModeGopls:
+		if file == "" || line <= 0 {
+			return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: "gopls mode requires file and line"}
+		}
+		err = t.collectGopls(ctx, plan, file, line)
+	case renameModeText:
+		dir, _ := params["dir"].(string)
+		pattern, _ := params["pattern"].(string)
+		err = t.collectText(ctx, plan, dir, pattern)
+	default:
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("unknown mode: %s", mode)}
+	}
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to find symbol occurrences", Err: err}
+	}
+
+	for path, edits := range plan.Occurrences {
+		plan.Updated[path] = applyRenameEdits(plan.Original[path], edits, len(symbol), newName)
+		plan.Files = append(plan.Files, path)
+	}
+	sort.Strings(plan.Files)
+
+	return plan, nil
+}
+
+// collectText finds whole-word occurrences of the symbol under dir
+func (t *RenameSymbolTool) collectText(ctx context.Context, plan *renamePlan, dir, pattern string) error {
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := ValidateAndCleanPath(dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := findFiles(dir, pattern, 0, false)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if skipRenameDir(dir, path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.Size() > t.MaxFileSize {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			// Unreadable or binary
+			continue
+		}
+
+		content := string(data)
+		edits := findWholeWord(content, plan.Symbol)
+		if len(edits) > 0 {
+			plan.Original[path] = content
+			plan.Occurrences[path] = edits
+		}
+	}
+	return nil
+}
+
+// skipRenameDir reports whether path lies in a dependency or build directory
+func skipRenameDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		switch part {
+		case "vendor", "node_modules", "build", "dist":
+			return true
+		}
+	}
+	return false
+}
+
+// findWholeWord returns the positions of symbol where it is not part of a longer identifier
+func findWholeWord(content, symbol string) []renameEdit {
+	var edits []renameEdit
+	for lineNum, line := range strings.Split(content, "\n") {
+		offset := 0
+		for {
+			idx := strings.Index(line[offset:], symbol)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			end := start + len(symbol)
+			before, _ := utf8.DecodeLastRuneInString(line[:start])
+			after, _ := utf8.DecodeRuneInString(line[end:])
+			if (start == 0 || !isIdentRune(before)) && (end == len(line) || !isIdentRune(after)) {
+				edits = append(edits, renameEdit{Line: lineNum + 1, Column: start + 1})
+			}
+			offset = end
+		}
+	}
+	return edits
+}
+
+// isIdentRune reports whether r can be part of an identifier in common languages
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// goplsSpan matches reference output such as "/path/file.go:12:6-9"
+var goplsSpan = regexp.MustCompile(`^(.+):(\d+):(\d+)-(\d+)$`)
+
+// collectGopls uses gopls to find every reference to the symbol at file:line
+func (t *RenameSymbolTool) collectGopls(ctx context.Context, plan *renamePlan, file string, line int) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		return fmt.Errorf("%s has only %d lines", file, len(lines))
+	}
+	edits := findWholeWord(lines[line-1], plan.Symbol)
+	if len(edits) == 0 {
+		return fmt.Errorf("symbol %q not found on %s:%d", plan.Symbol, file, line)
+	}
+
+	position := fmt.Sprintf("%s:%d:%d", file, line, edits[0].Column)
+	cmd := exec.CommandContext(ctx, "gopls", "references", "-d", position)
+	cmd.Dir = filepath.Dir(file)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("gopls references failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	root := goProjectRoot(file)
+	skipped := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		m := goplsSpan.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		path := m[1]
+		lineNum, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		endCol, _ := strconv.Atoi(m[4])
+		if endCol-col != len(plan.Symbol) {
+			continue
+		}
+		if !platform.Current().Within(path, root) || skipRenameDir(root, path) {
+			if !skipped[path] {
+				skipped[path] = true
+				plan.Skipped = append(plan.Skipped, path)
+			}
+			continue
+		}
+
+		if _, ok := plan.Original[path]; !ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			plan.Original[path] = string(content)
+		}
+		plan.Occurrences[path] = append(plan.Occurrences[path], renameEdit{Line: lineNum, Column: col})
+	}
+	return scanner.Err()
+}
+
+// goProjectRoot returns the directory of the go.work or go.mod file that file belongs
+// to, which bounds the files a rename may change; the file's directory without one
+func goProjectRoot(file string) string {
+	start := filepath.Dir(file)
+	for _, marker := range []string{"go.work", "go.mod"} {
+		for dir := start; ; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	return start
+}
+
+// applyRenameEdits replaces the symbol at each edit position, skipping positions that no longer match
+func applyRenameEdits(content string, edits []renameEdit, symbolLen int, newName string) string {
+	byLine := make(map[int][]int)
+	for _, e := range edits {
+		byLine[e.Line] = append(byLine[e.Line], e.Column)
+	}
+
+	lines := strings.Split(content, "\n")
+	for lineNum, cols := range byLine {
+		if lineNum < 1 || lineNum > len(lines) {
+			continue
+		}
+		// Replace right to left so earlier columns stay valid
+		sort.Sort(sort.Reverse(sort.IntSlice(cols)))
+		line := lines[lineNum-1]
+		for _, col := range cols {
+			start := col - 1
+			if start < 0 || start+symbolLen > len(line) {
+				continue
+			}
+			line = line[:start] + newName + line[start+symbolLen:]
+		}
+		lines[lineNum-1] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// count returns the total number of occurrences
+func (p *renamePlan) count() int {
+	total := 0
+	for _, edits := range p.Occurrences {
+		total += len(edits)
+	}
+	return total
+}
+
+// summary lists the changed lines as "file:line: new text"
+func (p *renamePlan) summary() []string {
+	var lines []string
+	for _, path := range p.Files {
+		updated := strings.Split(p.Updated[path], "\n")
+		seen := make(map[int]bool)
+		for _, e := range p.Occurrences[path] {
+			if seen[e.Line] || e.Line > len(updated) {
+				continue
+			}
+			seen[e.Line] = true
+			lines = append(lines, fmt.Sprintf("%s:%d: %s", path, e.Line, strings.TrimSpace(updated[e.Line-1])))
+		}
+	}
+	return lines
+}
+
+// diff renders the combined diff of all changed files
+func (p *renamePlan) diff() string {
+	if len(p.Files) == 0 {
+		return fmt.Sprintf("No occurrences of %s found", p.Symbol)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rename %s -> %s (%s): %d occurrences in %d files\n",
+		p.Symbol, p.NewName, p.Mode, p.count(), len(p.Files))
+	for _, path := range p.Files {
+		fmt.Fprintf(&b, "\n--- %s\n", path)
+		b.WriteString(GenerateDiff(p.Original[path], p.Updated[path], 1))
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindWholeWord(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []renameEdit
+	}{
+		{"count := count + 1", []renameEdit{{1, 1}, {1, 10}}},
+		{"counter := recount", nil},
+		{"x\nfoo(count)", []renameEdit{{2, 5}}},
+		{"$count = count_", nil},
+	}
+	for _, tt := range tests {
+		got := findWholeWord(tt.content, "count")
+		if len(got) != len(tt.want) {
+			t.Errorf("findWholeWord(%q) = %v, want %v", tt.content, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("findWholeWord(%q)[%d] = %v, want %v", tt.content, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRenameSymbolText(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.py")
+	b := filepath.Join(dir, "sub", "b.py")
+	untouched := filepath.Join(dir, "c.py")
+	if err := os.MkdirAll(filepath.Dir(b), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(a, []byte("def load_data():\n    return load_data_raw()\n"), 0644)
+	os.WriteFile(b, []byte("from a import load_data\nload_data()\n"), 0644)
+	os.WriteFile(untouched, []byte("print('hi')\n"), 0644)
+
+	tool := NewRenameSymbolTool()
+	params := map[string]interface{}{
+		"symbol":   "load_data",
+		"new_name": "read_data",
+		"dir":      dir,
+		"mode":     "text",
+		"dry_run":  true,
+	}
+
+	preview, err := tool.Preview(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if !strings.Contains(preview, "3 occurrences in 2 files") {
+		t.Errorf("unexpected preview:\n%s", preview)
+	}
+
+	if _, err := tool.Execute(context.Background(), params); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if data, _ := os.ReadFile(a); !strings.Contains(string(data), "def load_data") {
+		t.Fatalf("dry run modified files")
+	}
+
+	params["dry_run"] = false
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if applied := result.(map[string]interface{})["applied"]; applied != true {
+		t.Fatalf("expected rename to be applied, got %v", applied)
+	}
+
+	data, _ := os.ReadFile(a)
+	if string(data) != "def read_data():\n    return load_data_raw()\n" {
+		t.Errorf("unexpected a.py content: %q", data)
+	}
+	data, _ = os.ReadFile(b)
+	if string(data) != "from a import read_data\nread_data()\n" {
+		t.Errorf("unexpected b.py content: %q", data)
+	}
+}
+
+func TestGoProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	module := filepath.Join(root, "svc")
+	os.MkdirAll(filepath.Join(module, "internal", "x"), 0755)
+	os.WriteFile(filepath.Join(module, "go.mod"), []byte("module svc\n"), 0644)
+	file := filepath.Join(module, "internal", "x", "x.go")
+
+	if got := goProjectRoot(file); got != module {
+		t.Errorf("goProjectRoot() = %q, want the module %q", got, module)
+	}
+	os.WriteFile(filepath.Join(root, "go.work"), []byte("go 1.23\n"), 0644)
+	if got := goProjectRoot(file); got != root {
+		t.Errorf("goProjectRoot() = %q, want the workspace %q", got, root)
+	}
+}
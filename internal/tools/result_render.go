@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ResultKind says how a tool's result is best shown
+type ResultKind string
+
+const (
+	// ResultText is free text such as command output
+	ResultText ResultKind = "text"
+	// ResultTable is a list of records with the same fields, such as scan findings
+	ResultTable ResultKind = "table"
+	// ResultTree is a list of paths, such as a directory listing
+	ResultTree ResultKind = "tree"
+	// ResultRecord is a single object's fields
+	ResultRecord ResultKind = "record"
+)
+
+// ResultSchema describes the shape of a tool's result
+type ResultSchema struct {
+	Kind ResultKind `json:"kind"`
+	// Rows names the result field holding table rows or tree entries; empty means the
+	// result itself is the list
+	Rows string `json:"rows,omitempty"`
+	// Columns are the row fields shown in a table, in order. All fields are shown if empty.
+	Columns []string `json:"columns,omitempty"`
+	// PathField names the row field holding the path when tree entries are objects
+	PathField string `json:"path_field,omitempty"`
+	// Text names the field holding the main output of a text result
+	Text string `json:"text,omitempty"`
+	// Summary names fields shown above the body, such as exit codes and counts
+	Summary []string `json:"summary,omitempty"`
+}
+
+// ResultDescriber is implemented by tools that describe the shape of their results,
+// so the UI and the context formatter can render them consistently
+type ResultDescriber interface {
+	ResultSchema() ResultSchema
+}
+
+// RenderOptions limits the size of a rendered result
+type RenderOptions struct {
+	MaxRows  int // Rows, entries or lines shown before the rest are summarized; 0 shows all
+	MaxWidth int // Longest table cell; 0 means no limit
+}
+
+// ResultRenderer renders a result described by schema as text
+type ResultRenderer func(result interface{}, schema ResultSchema, opts RenderOptions) (string, error)
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[ResultKind]ResultRenderer{
+		ResultText:   renderText,
+		ResultTable:  renderTable,
+		ResultTree:   renderTree,
+		ResultRecord: renderRecord,
+	}
+)
+
+// RegisterResultRenderer sets the renderer for a result kind, replacing any existing one
+func RegisterResultRenderer(kind ResultKind, renderer ResultRenderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[kind] = renderer
+}
+
+// RenderResult renders a result with the renderer registered for its schema's kind,
+// including the schema's summary fields. It fails when no renderer is registered or
+// the result doesn't have the described shape.
+func RenderResult(result interface{}, schema ResultSchema, opts RenderOptions) (string, error) {
+	renderersMu.RLock()
+	renderer, ok := renderers[schema.Kind]
+	renderersMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no renderer for %q results", schema.Kind)
+	}
+
+	body, err := renderer(result, schema, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if fields, ok := result.(map[string]interface{}); ok {
+		for _, name := range schema.Summary {
+			if v, ok := fields[name]; ok {
+				fmt.Fprintf(&b, "%s: %v\n", name, v)
+			}
+		}
+	}
+	b.WriteString(body)
+	return b.String(), nil
+}
+
+// resultField returns the named field of a result, or the result itself for an empty name
+func resultField(result interface{}, name string) (interface{}, error) {
+	if name == "" {
+		return result, nil
+	}
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("result is %T, not an object", result)
+	}
+	v, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("result has no %q field", name)
+	}
+	return v, nil
+}
+
+// resultRows converts a list of any element type to generic values, decoding structs
+// through their JSON form
+func resultRows(v interface{}) ([]interface{}, error) {
+	if list, ok := v.([]interface{}); ok {
+		return list, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var list []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&list); err != nil {
+		return nil, fmt.Errorf("result is %T, not a list", v)
+	}
+	return list, nil
+}
+
+// moreLine summarizes rows left out of a rendering
+func moreLine(omitted int, noun string) string {
+	return fmt.Sprintf("... %d more %s\n", omitted, noun)
+}
+
+// renderText shows the text field, keeping the last lines when it is too long
+func renderText(result interface{}, schema ResultSchema, opts RenderOptions) (string, error) {
+	v, err := resultField(result, schema.Text)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimRight(fmt.Sprint(v), "\n")
+	if text == "" {
+		return "", nil
+	}
+	lines := strings.Split(text, "\n")
+	var b strings.Builder
+	if opts.MaxRows > 0 && len(lines) > opts.MaxRows {
+		fmt.Fprintf(&b, "... %d earlier lines\n", len(lines)-opts.MaxRows)
+		lines = lines[len(lines)-opts.MaxRows:]
+	}
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// renderTable aligns rows in columns
+func renderTable(result interface{}, schema ResultSchema, opts RenderOptions) (string, error) {
+	v, err := resultField(result, schema.Rows)
+	if err != nil {
+		return "", err
+	}
+	rows, err := resultRows(v)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "(no rows)\n", nil
+	}
+
+	columns := schema.Columns
+	if len(columns) == 0 {
+		first, ok := rows[0].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("table rows are %T, not objects", rows[0])
+		}
+		for name := range first {
+			columns = append(columns, name)
+		}
+		sort.Strings(columns)
+	}
+
+	shown := rows
+	if opts.MaxRows > 0 && len(rows) > opts.MaxRows {
+		shown = rows[:opts.MaxRows]
+	}
+	cells := make([][]string, 0, len(shown)+1)
+	cells = append(cells, columns)
+	for _, row := range shown {
+		fields, _ := row.(map[string]interface{})
+		line := make([]string, len(columns))
+		for i, col := range columns {
+			if v, ok := fields[col]; ok && v != nil {
+				line[i] = tableCell(v, opts.MaxWidth)
+			}
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(columns))
+	for _, line := range cells {
+		for i, cell := range line {
+			if n := len([]rune(cell)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	for n, line := range cells {
+		for i, cell := range line {
+			if i == len(line)-1 {
+				b.WriteString(cell)
+			} else {
+				fmt.Fprintf(&b, "%-*s  ", widths[i], cell)
+			}
+		}
+		b.WriteString("\n")
+		if n == 0 {
+			for i, w := range widths {
+				b.WriteString(strings.Repeat("-", w))
+				if i < len(widths)-1 {
+					b.WriteString("  ")
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(shown) < len(rows) {
+		b.WriteString(moreLine(len(rows)-len(shown), "rows"))
+	}
+	return b.String(), nil
+}
+
+// tableCell formats a value on one line, shortened to maxWidth runes
+func tableCell(v interface{}, maxWidth int) string {
+	var s string
+	switch val := v.(type) {
+	case string:
+		s = val
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(val)
+		s = string(data)
+	default:
+		s = fmt.Sprint(val)
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); maxWidth > 3 && len(r) > maxWidth {
+		s = string(r[:maxWidth-3]) + "..."
+	}
+	return s
+}
+
+// treeNode is a directory or file in a rendered tree
+type treeNode struct {
+	children map[string]*treeNode
+}
+
+// renderTree draws paths as an indented tree
+func renderTree(result interface{}, schema ResultSchema, opts RenderOptions) (string, error) {
+	v, err := resultField(result, schema.Rows)
+	if err != nil {
+		return "", err
+	}
+	entries, err := resultRows(v)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		p, ok := e.(string)
+		if fields, isObj := e.(map[string]interface{}); isObj {
+			if schema.PathField == "" {
+				return "", fmt.Errorf("tree entries are objects and the schema names no path field")
+			}
+			p, ok = fields[schema.PathField].(string)
+		}
+		if ok && p != "" {
+			paths = append(paths, path.Clean(strings.ReplaceAll(p, "\\", "/")))
+		}
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		return "(empty)\n", nil
+	}
+
+	shown := paths
+	if opts.MaxRows > 0 && len(paths) > opts.MaxRows {
+		shown = paths[:opts.MaxRows]
+	}
+	root := &treeNode{children: map[string]*treeNode{}}
+	for _, p := range shown {
+		node := root
+		for _, part := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{children: map[string]*treeNode{}}
+				node.children[part] = child
+			}
+			node = child
+		}
+	}
+
+	var b strings.Builder
+	writeTree(&b, root, "")
+	if len(shown) < len(paths) {
+		b.WriteString(moreLine(len(paths)-len(shown), "entries"))
+	}
+	return b.String(), nil
+}
+
+// writeTree writes a node's children, collapsing directories with a single child
+func writeTree(b *strings.Builder, node *treeNode, indent string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := node.children[name]
+		label := name
+		for len(child.children) == 1 {
+			for next, grandchild := range child.children {
+				label += "/" + next
+				child = grandchild
+			}
+		}
+		if len(child.children) > 0 {
+			label += "/"
+		}
+
+		branch, nextIndent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, nextIndent = "└── ", "    "
+		}
+		b.WriteString(indent + branch + label + "\n")
+		writeTree(b, child, indent+nextIndent)
+	}
+}
+
+// renderRecord lists an object's fields, one per line
+func renderRecord(result interface{}, schema ResultSchema, opts RenderOptions) (string, error) {
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("result is %T, not an object", result)
+	}
+	names := schema.Columns
+	if len(names) == 0 {
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	var b strings.Builder
+	for _, name := range names {
+		if v, ok := fields[name]; ok {
+			fmt.Fprintf(&b, "%s: %s\n", name, tableCell(v, opts.MaxWidth))
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  interface{}
+		schema  ResultSchema
+		opts    RenderOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "table",
+			result: map[string]interface{}{
+				"count": 2,
+				"processes": []map[string]interface{}{
+					{"handle": "p1", "pid": 1200000, "command": "npm run dev"},
+					{"handle": "p2", "pid": 42, "command": "go run ./cmd/server"},
+				},
+			},
+			schema: ResultSchema{Kind: ResultTable, Rows: "processes", Columns: []string{"handle", "pid", "command"}, Summary: []string{"count"}},
+			want: "count: 2\n" +
+				"handle  pid      command\n" +
+				"------  -------  -------------------\n" +
+				"p1      1200000  npm run dev\n" +
+				"p2      42       go run ./cmd/server\n",
+		},
+		{
+			name:   "table rows capped",
+			result: []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}, map[string]interface{}{"id": "c"}},
+			schema: ResultSchema{Kind: ResultTable},
+			opts:   RenderOptions{MaxRows: 1},
+			want:   "id\n--\na\n... 2 more rows\n",
+		},
+		{
+			name:   "tree",
+			result: map[string]interface{}{"files": []string{"cmd/app/main.go", "internal/a.go", "internal/b/b.go", "go.mod"}},
+			schema: ResultSchema{Kind: ResultTree, Rows: "files"},
+			want: "├── cmd/app/main.go\n" +
+				"├── go.mod\n" +
+				"└── internal/\n" +
+				"    ├── a.go\n" +
+				"    └── b/b.go\n",
+		},
+		{
+			name:    "tree of objects without a path field",
+			result:  map[string]interface{}{"files": []interface{}{map[string]interface{}{"path": "a.go", "content": "package a"}}},
+			schema:  ResultSchema{Kind: ResultTree, Rows: "files"},
+			wantErr: true,
+		},
+		{
+			name:   "text keeps the last lines",
+			result: map[string]interface{}{"stdout": "one\ntwo\nthree\n", "exit_code": 1},
+			schema: ResultSchema{Kind: ResultText, Text: "stdout", Summary: []string{"exit_code"}},
+			opts:   RenderOptions{MaxRows: 2},
+			want:   "exit_code: 1\n... 1 earlier lines\ntwo\nthree\n",
+		},
+		{
+			name:   "record",
+			result: map[string]interface{}{"name": "codezilla", "tags": []interface{}{"go", "cli"}},
+			schema: ResultSchema{Kind: ResultRecord},
+			want:   "name: codezilla\ntags: [\"go\",\"cli\"]\n",
+		},
+		{
+			name:    "missing rows field",
+			result:  map[string]interface{}{"output": "x"},
+			schema:  ResultSchema{Kind: ResultTable, Rows: "processes"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			result:  "x",
+			schema:  ResultSchema{Kind: "chart"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderResult(tt.result, tt.schema, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("rendered:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterResultRenderer(t *testing.T) {
+	RegisterResultRenderer("upper", func(result interface{}, schema ResultSchema, opts RenderOptions) (string, error) {
+		return strings.ToUpper(result.(string)), nil
+	})
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "upper")
+		renderersMu.Unlock()
+	}()
+
+	got, err := RenderResult("done", ResultSchema{Kind: "upper"}, RenderOptions{})
+	if err != nil || got != "DONE" {
+		t.Errorf("RenderResult = %q, %v; want DONE", got, err)
+	}
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Additional project roots, such as a shared library next to a service. Tools accept
+// paths qualified with a root's name, like lib:pkg/foo.go, and show paths in those
+// roots the same way.
+var (
+	rootsMu sync.RWMutex
+	roots   map[string]string // Name -> absolute directory
+)
+
+// rootNamePattern matches root names. Two characters at least, so a Windows drive
+// letter is never taken for a root.
+var rootNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]+$`)
+
+// ValidRootName reports whether name can name a project root
+func ValidRootName(name string) bool {
+	return rootNamePattern.MatchString(name)
+}
+
+// SetRoots sets the additional project roots by name. Relative directories are
+// resolved against base, the primary project root.
+func SetRoots(base string, named map[string]string) {
+	resolved := make(map[string]string, len(named))
+	for name, dir := range named {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		resolved[name] = filepath.Clean(dir)
+	}
+	rootsMu.Lock()
+	defer rootsMu.Unlock()
+	roots = resolved
+}
+
+// Roots returns the additional project roots by name
+func Roots() map[string]string {
+	rootsMu.RLock()
+	defer rootsMu.RUnlock()
+	copied := make(map[string]string, len(roots))
+	for name, dir := range roots {
+		copied[name] = dir
+	}
+	return copied
+}
+
+// MissingRoots returns the names of the roots whose directory does not exist, sorted
+func MissingRoots() []string {
+	var missing []string
+	for name, dir := range Roots() {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// RootsGuidance tells the model about the additional project roots, or returns ""
+// when there are none
+func RootsGuidance() string {
+	rootsMu.RLock()
+	defer rootsMu.RUnlock()
+	if len(roots) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(roots))
+	for name := range roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("The project spans several directories. Paths relative to the current directory are in the main project; the other roots are named, and their files are written as name:path in tool parameters and shown that way in results:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "- %s: %s\n", name, roots[name])
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// ExpandRootPath turns a path qualified with a root name, such as lib:pkg/foo.go,
+// into the path in that root. Other paths are returned unchanged.
+func ExpandRootPath(path string) string {
+	name, rest, found := strings.Cut(path, ":")
+	if !found || !ValidRootName(name) {
+		return path
+	}
+	rootsMu.RLock()
+	dir, ok := roots[name]
+	rootsMu.RUnlock()
+	if !ok {
+		return path
+	}
+	return filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(rest, "/")))
+}
+
+// ExpandPath expands a path as the file tools do: ~ to the home directory and a
+// root name, such as lib:pkg/foo.go, to that root
+func ExpandPath(path string) string {
+	path = ExpandRootPath(path)
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// DisplayPath shows a path inside an additional root qualified with the root's
+// name, such as lib:pkg/foo.go. Other paths are returned unchanged.
+func DisplayPath(path string) string {
+	if qualified, ok := qualify(path); ok {
+		return qualified
+	}
+	return path
+}
+
+// InProjectRoots reports whether path is inside base, the primary project root, or
+// one of the additional roots
+func InProjectRoots(base, path string) bool {
+	if _, ok := within(base, path); ok {
+		return true
+	}
+	_, ok := qualify(path)
+	return ok
+}
+
+// qualify returns path relative to the additional root containing it, after the
+// root's name
+func qualify(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		return "", false
+	}
+	rootsMu.RLock()
+	defer rootsMu.RUnlock()
+	for name, dir := range roots {
+		if rel, ok := within(dir, path); ok {
+			if rel == "." {
+				rel = ""
+			}
+			return name + ":" + rel, true
+		}
+	}
+	return "", false
+}
+
+// within returns path relative to dir, slash-separated, when it is inside dir
+func within(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRoots(t *testing.T) {
+	base := t.TempDir()
+	lib := filepath.Join(filepath.Dir(base), "shared-lib")
+	SetRoots(base, map[string]string{"lib": "../shared-lib"})
+	defer SetRoots(base, nil)
+
+	if got := MissingRoots(); !reflect.DeepEqual(got, []string{"lib"}) {
+		t.Errorf("MissingRoots() = %v, want [lib]", got)
+	}
+	if err := os.Mkdir(lib, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(lib)
+	if got := MissingRoots(); got != nil {
+		t.Errorf("MissingRoots() = %v once the directory exists", got)
+	}
+
+	expand := []struct {
+		path, want string
+	}{
+		{"lib:pkg/foo.go", filepath.Join(lib, "pkg", "foo.go")},
+		{"lib:", lib},
+		{"other:pkg/foo.go", "other:pkg/foo.go"},
+		{"C:/work/foo.go", "C:/work/foo.go"},
+		{"pkg/foo.go", "pkg/foo.go"},
+	}
+	for _, tt := range expand {
+		if got := ExpandRootPath(tt.path); got != tt.want {
+			t.Errorf("ExpandRootPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	display := []struct {
+		path, want string
+	}{
+		{filepath.Join(lib, "pkg", "foo.go"), "lib:pkg/foo.go"},
+		{lib, "lib:"},
+		{filepath.Join(base, "main.go"), filepath.Join(base, "main.go")},
+	}
+	for _, tt := range display {
+		if got := DisplayPath(tt.path); got != tt.want {
+			t.Errorf("DisplayPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	for path, want := range map[string]bool{
+		filepath.Join(base, "main.go"):    true,
+		filepath.Join(lib, "pkg/foo.go"):  true,
+		filepath.Join(lib+"-2", "foo.go"): false,
+		filepath.Dir(base):                false,
+	} {
+		if got := InProjectRoots(base, path); got != want {
+			t.Errorf("InProjectRoots(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	written := WrittenFiles("multiEdit", map[string]interface{}{"edits": []interface{}{
+		map[string]interface{}{"file_path": "lib:pkg/foo.go"},
+		map[string]interface{}{"file_path": "main.go"},
+	}})
+	if want := []string{filepath.Join(lib, "pkg", "foo.go"), "main.go"}; !reflect.DeepEqual(written, want) {
+		t.Errorf("WrittenFiles() = %v, want %v", written, want)
+	}
+
+	if cleaned, err := ValidateAndCleanPath("lib:pkg/foo.go"); err != nil || cleaned != filepath.Join(lib, "pkg", "foo.go") {
+		t.Errorf("ValidateAndCleanPath() = %q, %v; want the path in the lib root", cleaned, err)
+	}
+}
@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifFingerprintKey names the fingerprint in partialFingerprints; bump the
+	// version when Fingerprint changes so old results are not matched against new
+	sarifFingerprintKey = "codezilla/v1"
+	// sarifRootID is the base the locations are relative to
+	sarifRootID = "%SRCROOT%"
+)
+
+// IssueRule is a kind of issue. Model findings are worded differently on every run,
+// so they are sorted into a few fixed rules to keep rule IDs stable across runs.
+type IssueRule struct {
+	ID          string
+	Description string
+	// keywords put a finding under the rule when its message contains one of them
+	keywords []string
+}
+
+// IssueRules are the rules of model findings, tried in order. The last one takes
+// whatever the others do not.
+var IssueRules = []IssueRule{
+	{ID: "security", Description: "Security problem such as injection, unsafe input handling or exposed secrets",
+		keywords: []string{"inject", "xss", "csrf", "secret", "password", "credential", "token", "authori", "authenticat", "sanitiz", "escap", "vulnerab", "crypto", "path traversal", "unsafe input", "untrusted"}},
+	{ID: "concurrency", Description: "Concurrency problem such as a data race, deadlock or leaked goroutine",
+		keywords: []string{"race", "deadlock", "mutex", " lock", "locking", "unlock", "goroutine", "concurren", "thread", "atomic", "synchroniz", "channel"}},
+	{ID: "resource-leak", Description: "Resource that is not released, such as an unclosed file, connection or timer",
+		keywords: []string{"leak", "not closed", "never closed", "unclosed", "without closing", "not released", "file handle"}},
+	{ID: "error-handling", Description: "Error that is ignored, swallowed or reported without context",
+		keywords: []string{"error", "exception", "panic", "err ", "unchecked", "ignored", "swallow", "recover"}},
+	{ID: "performance", Description: "Performance problem such as needless work, allocation or I/O",
+		keywords: []string{"performance", "slow", "allocat", "quadratic", "o(n", "inefficien", "n+1", "cache", "repeated"}},
+	{ID: "maintainability", Description: "Code smell that makes the code harder to change"},
+	{ID: "bug", Description: "Likely bug or incorrect behavior"},
+}
+
+// ClassifyIssue returns the ID of the rule a model finding falls under. Code smells
+// are always maintainability issues.
+func ClassifyIssue(message string, codeSmell bool) string {
+	if codeSmell {
+		return "maintainability"
+	}
+	lower := strings.ToLower(message)
+	for _, rule := range IssueRules {
+		for _, k := range rule.keywords {
+			if strings.Contains(lower, k) {
+				return rule.ID
+			}
+		}
+	}
+	return "bug"
+}
+
+// Fingerprint identifies an issue across runs: its rule, its file relative to root
+// and the words of its message, but not its line, so it survives edits above it
+func (i CodeIssue) Fingerprint(root string) string {
+	words := make([]string, 0)
+	for w := range issueWords(i.Message) {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	sum := sha256.Sum256([]byte(i.Rule + "\x00" + relativeIssuePath(root, i.File) + "\x00" + strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// relativeIssuePath returns an issue's file relative to root, slash-separated
+func relativeIssuePath(root, file string) string {
+	if root != "" && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// SARIF 2.1.0 log, limited to what code scanning and IDEs read
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                   `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactURI `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult               `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifText              `json:"shortDescription"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifactURI struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifText              `json:"message"`
+	Locations           []sarifLocation        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactURI `json:"artifactLocation"`
+	Region           *sarifRegion     `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifLevels maps severities to SARIF levels
+var sarifLevels = map[string]string{
+	IssueSeverityHigh:   "error",
+	IssueSeverityMedium: "warning",
+	IssueSeverityLow:    "note",
+}
+
+// securitySeverity is the score GitHub code scanning ranks security rules by
+var securitySeverity = map[string]string{
+	IssueSeverityHigh:   "8.0",
+	IssueSeverityMedium: "5.0",
+	IssueSeverityLow:    "2.0",
+}
+
+// WriteSARIF writes issues as a SARIF 2.1.0 log, for GitHub code scanning and IDEs.
+// Paths are written relative to root. Issues whose rule is not one of IssueRules,
+// such as vulnerabilities named by their advisory ID, get a rule described by their
+// first message.
+func WriteSARIF(w io.Writer, root string, issues []CodeIssue) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "codezilla",
+			InformationURI: "https://github.com/bahmanasadi/codezilla",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if root != "" {
+		if abs, err := filepath.Abs(root); err == nil {
+			run.OriginalURIBaseIDs = map[string]sarifArtifactURI{
+				sarifRootID: {URI: "file://" + strings.TrimSuffix(filepath.ToSlash(abs), "/") + "/"},
+			}
+		}
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, issue := range issues {
+		if issue.Rule == "" {
+			issue.Rule = ClassifyIssue(issue.Message, false)
+		}
+		index, ok := ruleIndex[issue.Rule]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[issue.Rule] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(issue))
+		}
+		rule := &run.Tool.Driver.Rules[index]
+		// A rule's default level is that of its most severe result
+		if level := sarifLevels[issue.Severity]; sarifLevelRank(level) > sarifLevelRank(rule.DefaultConfiguration.Level) {
+			rule.DefaultConfiguration.Level = level
+			if _, ok := rule.Properties["security-severity"]; ok {
+				rule.Properties["security-severity"] = securitySeverity[issue.Severity]
+			}
+		}
+
+		result := sarifResult{
+			RuleID:              issue.Rule,
+			RuleIndex:           index,
+			Level:               sarifLevels[issue.Severity],
+			Message:             sarifText{Text: issue.Message},
+			PartialFingerprints: map[string]string{sarifFingerprintKey: issue.Fingerprint(root)},
+		}
+		if result.Level == "" {
+			result.Level = "warning"
+		}
+		if issue.File != "" {
+			location := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactURI{URI: relativeIssuePath(root, issue.File)},
+			}
+			if root != "" {
+				location.ArtifactLocation.URIBaseID = sarifRootID
+			}
+			if issue.Line > 0 {
+				location.Region = &sarifRegion{StartLine: issue.Line, EndLine: issue.EndLine}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		if len(issue.Sources) > 0 || issue.Confidence > 0 {
+			result.Properties = map[string]interface{}{}
+			if len(issue.Sources) > 0 {
+				result.Properties["sources"] = issue.Sources
+			}
+			if issue.Confidence > 0 {
+				result.Properties["confidence"] = issue.Confidence
+			}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("failed to write SARIF: %w", err)
+	}
+	return nil
+}
+
+// writeSARIFFile writes issues as SARIF to path, with locations relative to root
+func writeSARIFFile(path, root string, issues []CodeIssue) error {
+	cleanPath, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(cleanPath)
+	if err != nil {
+		return err
+	}
+	if err := WriteSARIF(f, root, issues); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newSARIFRule describes the rule of an issue
+func newSARIFRule(issue CodeIssue) sarifRule {
+	rule := sarifRule{
+		ID:                   issue.Rule,
+		ShortDescription:     sarifText{Text: issue.Message},
+		HelpURI:              issue.URL,
+		DefaultConfiguration: sarifConfiguration{Level: "note"},
+	}
+	// Rules of their own are advisories, which are security issues
+	security := true
+	for _, r := range IssueRules {
+		if r.ID == issue.Rule {
+			rule.ShortDescription.Text = r.Description
+			security = r.ID == "security"
+		}
+	}
+	if security {
+		rule.Properties = map[string]interface{}{
+			"tags":              []string{"security"},
+			"security-severity": securitySeverity[IssueSeverityLow],
+		}
+	}
+	return rule
+}
+
+// sarifLevelRank orders SARIF levels from least to most serious
+func sarifLevelRank(level string) int {
+	switch level {
+	case "error":
+		return 3
+	case "warning":
+		return 2
+	case "note":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyIssue(t *testing.T) {
+	tests := []struct {
+		message   string
+		codeSmell bool
+		want      string
+	}{
+		{"User input is concatenated into the SQL query (injection)", false, "security"},
+		{"Counter is updated from several goroutines without a mutex", false, "concurrency"},
+		{"Error returned by Write is ignored", false, "error-handling"},
+		{"File opened in Load is never closed", false, "resource-leak"},
+		{"The list is sorted on every call, quadratic in the number of items", false, "performance"},
+		{"Off-by-one in the loop bound", false, "bug"},
+		{"Error returned by Write is ignored", true, "maintainability"},
+	}
+	for _, tt := range tests {
+		if got := ClassifyIssue(tt.message, tt.codeSmell); got != tt.want {
+			t.Errorf("ClassifyIssue(%q, %v) = %q, want %q", tt.message, tt.codeSmell, got, tt.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	a := CodeIssue{File: filepath.Join(root, "pkg", "a.go"), Line: 10, Rule: "error-handling", Message: "Error from Close is ignored"}
+	moved := a
+	moved.Line = 42
+	moved.Message = "error from close is ignored."
+	relative := a
+	relative.File = "pkg/a.go"
+	other := a
+	other.Message = "Error from Flush is ignored"
+
+	if a.Fingerprint(root) != moved.Fingerprint(root) || a.Fingerprint(root) != relative.Fingerprint(root) {
+		t.Error("fingerprint changed with the line, case, punctuation or path form")
+	}
+	if a.Fingerprint(root) == other.Fingerprint(root) {
+		t.Error("different issues have the same fingerprint")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	root := t.TempDir()
+	issues := []CodeIssue{
+		{File: filepath.Join(root, "a.go"), Line: 3, EndLine: 5, Severity: IssueSeverityLow, Message: "Token logged", Rule: "security", Confidence: 0.6, Sources: []string{"source/llm/issues"}},
+		{File: filepath.Join(root, "b.go"), Line: 7, Severity: IssueSeverityHigh, Message: "SQL injection", Rule: "security"},
+		{File: "go.mod", Severity: IssueSeverityMedium, Message: "golang.org/x/net: HTTP/2 flood", Rule: "GO-2023-2102", URL: "https://pkg.go.dev/vuln/GO-2023-2102"},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, root, issues); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 2 || rules[0].ID != "security" || rules[1].ID != "GO-2023-2102" {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if rules[0].DefaultConfiguration.Level != "error" || rules[0].Properties["security-severity"] != "8.0" {
+		t.Errorf("security rule should take the level of its most severe result: %+v", rules[0])
+	}
+	if rules[1].HelpURI != issues[2].URL || rules[1].ShortDescription.Text != issues[2].Message {
+		t.Errorf("advisory rule = %+v", rules[1])
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("got %d results", len(run.Results))
+	}
+	first := run.Results[0]
+	loc := first.Locations[0].PhysicalLocation
+	if first.Level != "note" || loc.ArtifactLocation.URI != "a.go" || loc.ArtifactLocation.URIBaseID != "%SRCROOT%" ||
+		loc.Region == nil || loc.Region.StartLine != 3 || loc.Region.EndLine != 5 {
+		t.Errorf("first result = %+v", first)
+	}
+	if first.PartialFingerprints["codezilla/v1"] != issues[0].Fingerprint(root) {
+		t.Errorf("fingerprint = %v", first.PartialFingerprints)
+	}
+	if run.Results[2].RuleIndex != 1 || run.Results[2].Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("advisory result = %+v", run.Results[2])
+	}
+}
@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snippet is a reusable block of code kept by the user, such as an error handling
+// pattern or a table-test skeleton
+type Snippet struct {
+	Name        string    `json:"name"`
+	Language    string    `json:"language,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Code        string    `json:"code"`
+	Created     time.Time `json:"created"`
+}
+
+// snippetName restricts names to something easy to type after /snippet use
+var snippetName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// SnippetStore holds the user's snippets, saved as JSON so they are shared across
+// sessions and projects
+type SnippetStore struct {
+	mu       sync.RWMutex
+	path     string
+	snippets map[string]Snippet
+}
+
+// LoadSnippetStore reads the snippets in path. A missing file is an empty store and
+// an empty path keeps the snippets in memory only.
+func LoadSnippetStore(path string) (*SnippetStore, error) {
+	s := &SnippetStore{path: path, snippets: make(map[string]Snippet)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snippets: %w", err)
+	}
+	var list []Snippet
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse snippets %s: %w", path, err)
+	}
+	for _, snippet := range list {
+		s.snippets[snippet.Name] = snippet
+	}
+	return s, nil
+}
+
+// Add stores a snippet, replacing one of the same name, and saves the store
+func (s *SnippetStore) Add(snippet Snippet) error {
+	if !snippetName.MatchString(snippet.Name) {
+		return fmt.Errorf("invalid snippet name %q: use letters, digits, '.', '-' and '_'", snippet.Name)
+	}
+	if strings.TrimSpace(snippet.Code) == "" {
+		return fmt.Errorf("snippet %s has no code", snippet.Name)
+	}
+	if snippet.Created.IsZero() {
+		snippet.Created = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snippets[snippet.Name] = snippet
+	return s.save()
+}
+
+// Remove deletes the named snippet and saves the store
+func (s *SnippetStore) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snippets[name]; !ok {
+		return fmt.Errorf("no snippet named %q", name)
+	}
+	delete(s.snippets, name)
+	return s.save()
+}
+
+// Get returns the named snippet
+func (s *SnippetStore) Get(name string) (Snippet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snippet, ok := s.snippets[name]
+	if !ok {
+		names := make([]string, 0, len(s.snippets))
+		for n := range s.snippets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return Snippet{}, fmt.Errorf("no snippet named %q (no snippets saved yet)", name)
+		}
+		return Snippet{}, fmt.Errorf("no snippet named %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return snippet, nil
+}
+
+// List returns the snippets sorted by name
+func (s *SnippetStore) List() []Snippet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Snippet, 0, len(s.snippets))
+	for _, snippet := range s.snippets {
+		list = append(list, snippet)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// save writes the snippets to disk; the caller holds the lock
+func (s *SnippetStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]Snippet, 0, len(s.snippets))
+	for _, snippet := range s.snippets {
+		list = append(list, snippet)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create snippets directory: %w", err)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snippets: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snippets: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetSnippetTool gives the model the user's saved snippets, so they can be inserted
+// verbatim instead of being regenerated
+type GetSnippetTool struct {
+	store *SnippetStore
+}
+
+// NewGetSnippetTool creates a getSnippet tool backed by the given store
+func NewGetSnippetTool(store *SnippetStore) *GetSnippetTool {
+	return &GetSnippetTool{store: store}
+}
+
+// Name returns the tool name
+func (t *GetSnippetTool) Name() string {
+	return "getSnippet"
+}
+
+// Description returns the tool description
+func (t *GetSnippetTool) Description() string {
+	return "Returns a code snippet saved by the user (error handling patterns, test skeletons, boilerplate). Insert the code verbatim, changing only identifiers where needed, instead of writing your own version. Call it without a name to list the snippets"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *GetSnippetTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"name": {
+				Type:        "string",
+				Description: "Snippet name; omit to list the available snippets",
+			},
+		},
+	}
+}
+
+// Execute returns the named snippet, or the list of snippets
+func (t *GetSnippetTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	name, _ := params["name"].(string)
+	if name == "" {
+		var list []map[string]interface{}
+		for _, snippet := range t.store.List() {
+			list = append(list, map[string]interface{}{
+				"name":        snippet.Name,
+				"language":    snippet.Language,
+				"description": snippet.Description,
+				"lines":       strings.Count(strings.TrimRight(snippet.Code, "\n"), "\n") + 1,
+			})
+		}
+		return map[string]interface{}{"snippets": list, "count": len(list)}, nil
+	}
+
+	snippet, err := t.store.Get(name)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	return snippet, nil
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnippetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippets.json")
+	store, err := LoadSnippetStore(path)
+	if err != nil {
+		t.Fatalf("LoadSnippetStore() error = %v", err)
+	}
+
+	code := "if err != nil {\n\treturn fmt.Errorf(\"failed to x: %w\", err)\n}\n"
+	if err := store.Add(Snippet{Name: "wrap-err", Language: "go", Code: code}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := store.Add(Snippet{Name: "table-test", Language: "go", Code: "tests := []struct{}{}\n"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := store.Add(Snippet{Name: "bad name", Code: "x"}); err == nil {
+		t.Error("expected an error for a name with a space")
+	}
+	if err := store.Add(Snippet{Name: "empty", Code: "  \n"}); err == nil {
+		t.Error("expected an error for a snippet without code")
+	}
+
+	reloaded, err := LoadSnippetStore(path)
+	if err != nil {
+		t.Fatalf("LoadSnippetStore() error = %v", err)
+	}
+	list := reloaded.List()
+	if len(list) != 2 || list[0].Name != "table-test" || list[1].Name != "wrap-err" {
+		t.Fatalf("List() after reload = %+v", list)
+	}
+
+	tool := NewGetSnippetTool(reloaded)
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"name": "wrap-err"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := result.(Snippet).Code; got != code {
+		t.Errorf("Execute() code = %q, want %q verbatim", got, code)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": "missing"}); err == nil || !strings.Contains(err.Error(), "table-test, wrap-err") {
+		t.Errorf("Execute() for a missing snippet error = %v, want the available names", err)
+	}
+
+	if err := reloaded.Remove("wrap-err"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := reloaded.Remove("wrap-err"); err == nil {
+		t.Error("expected an error removing a missing snippet")
+	}
+	if again, _ := LoadSnippetStore(path); len(again.List()) != 1 {
+		t.Errorf("List() after Remove = %+v", again.List())
+	}
+}
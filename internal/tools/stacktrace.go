@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxSnippetFrames caps how many project frames get their surrounding code attached
+const maxSnippetFrames = 5
+
+// StackFrame is one frame of a stack trace
+type StackFrame struct {
+	Function string `json:"function,omitempty"`
+	Path     string `json:"path"`           // As written in the trace
+	File     string `json:"file,omitempty"` // The project file it refers to, if found
+	Line     int    `json:"line"`
+	Code     string `json:"code,omitempty"` // Lines around the frame, for project frames
+}
+
+// StackTrace is a parsed stack trace, innermost frame first
+type StackTrace struct {
+	Language string       `json:"language"`
+	Message  string       `json:"message,omitempty"` // The panic or exception message
+	Frames   []StackFrame `json:"frames"`
+}
+
+var (
+	goFrameFile    = regexp.MustCompile(`^\s+(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	goFrameFunc    = regexp.MustCompile(`^([\w./*()\[\]-]+)\(.*\)$`)
+	pythonFrame    = regexp.MustCompile(`^\s*File "([^"]+)", line (\d+)(?:, in (.+))?`)
+	pythonMessage  = regexp.MustCompile(`^([\w.]+(?:Error|Exception|Warning|Interrupt|Exit)\b.*)`)
+	nodeFrame      = regexp.MustCompile(`^\s+at (?:(.+?) \()?(?:file://)?(.+?):(\d+):\d+\)?$`)
+	nodeMessage    = regexp.MustCompile(`^(?:Uncaught )?(\w*(?:Error|Exception)\b.*)`)
+	goPanicMessage = regexp.MustCompile(`^(panic: .+|fatal error: .+)`)
+)
+
+// ParseStackTrace recognizes a Go, Python or Node.js stack trace in text. It returns
+// nil when text contains none.
+func ParseStackTrace(text string) *StackTrace {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	switch {
+	case strings.Contains(text, "Traceback (most recent call last):"):
+		return parsePythonTrace(lines)
+	case hasMatch(lines, goFrameFile):
+		if trace := parseGoTrace(lines); len(trace.Frames) > 0 {
+			return trace
+		}
+	}
+	if trace := parseNodeTrace(lines); len(trace.Frames) > 0 {
+		return trace
+	}
+	return nil
+}
+
+// hasMatch reports whether any line matches re
+func hasMatch(lines []string, re *regexp.Regexp) bool {
+	for _, l := range lines {
+		if re.MatchString(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoTrace reads a Go panic: function lines each followed by a tab-indented file:line.
+// Only the first goroutine is kept, which is the one that panicked.
+func parseGoTrace(lines []string) *StackTrace {
+	trace := &StackTrace{Language: "go"}
+	function, goroutines := "", 0
+	for _, line := range lines {
+		if m := goPanicMessage.FindStringSubmatch(line); m != nil && trace.Message == "" {
+			trace.Message = m[1]
+			continue
+		}
+		if strings.HasPrefix(line, "goroutine ") {
+			if goroutines++; goroutines > 1 {
+				break
+			}
+			continue
+		}
+		if m := goFrameFile.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			trace.Frames = append(trace.Frames, StackFrame{Function: function, Path: m[1], Line: n})
+			function = ""
+			continue
+		}
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "created by "); ok {
+			function, _, _ = strings.Cut(rest, " ")
+		} else if m := goFrameFunc.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			function = m[1]
+		}
+	}
+	return trace
+}
+
+// parsePythonTrace reads a Python traceback, which lists the innermost frame last
+func parsePythonTrace(lines []string) *StackTrace {
+	trace := &StackTrace{Language: "python"}
+	for _, line := range lines {
+		if m := pythonFrame.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			trace.Frames = append(trace.Frames, StackFrame{Function: m[3], Path: m[1], Line: n})
+			continue
+		}
+		if m := pythonMessage.FindStringSubmatch(line); m != nil {
+			trace.Message = strings.TrimSpace(m[1])
+		}
+	}
+	for i, j := 0, len(trace.Frames)-1; i < j; i, j = i+1, j-1 {
+		trace.Frames[i], trace.Frames[j] = trace.Frames[j], trace.Frames[i]
+	}
+	return trace
+}
+
+// parseNodeTrace reads a Node.js stack: an error message followed by "at" lines
+func parseNodeTrace(lines []string) *StackTrace {
+	trace := &StackTrace{Language: "node"}
+	for _, line := range lines {
+		if m := nodeFrame.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[3])
+			trace.Frames = append(trace.Frames, StackFrame{Function: m[1], Path: m[2], Line: n})
+			continue
+		}
+		if m := nodeMessage.FindStringSubmatch(strings.TrimSpace(line)); m != nil && trace.Message == "" && len(trace.Frames) == 0 {
+			trace.Message = m[1]
+		}
+	}
+	return trace
+}
+
+// StackTraceTool parses a pasted stack trace and pulls in the project code it points at
+type StackTraceTool struct{}
+
+// NewStackTraceTool creates a new stack trace tool
+func NewStackTraceTool() *StackTraceTool {
+	return &StackTraceTool{}
+}
+
+// Name returns the tool name
+func (t *StackTraceTool) Name() string {
+	return "parseStackTrace"
+}
+
+// Description returns the tool description
+func (t *StackTraceTool) Description() string {
+	return "Parses a Go, Python or Node.js stack trace (e.g. one the user pasted), maps its frames to project files, returns the code around the project frames and names the likely fault location. Use it before reading files by hand"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *StackTraceTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"trace": {
+				Type:        "string",
+				Description: "The stack trace text, including the panic or exception message",
+			},
+			"context_lines": {
+				Type:        "integer",
+				Description: "Lines of code to show above and below each project frame (default: 5)",
+			},
+		},
+		Required: []string{"trace"},
+	}
+}
+
+// Execute parses the trace
+func (t *StackTraceTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	text, _ := params["trace"].(string)
+	radius := getIntParam(params, "context_lines", 5)
+
+	trace := ParseStackTrace(text)
+	if trace == nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: "no Go, Python or Node.js stack trace found in the text"}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to get working directory", Err: err}
+	}
+	locator := NewFileLocator(cwd)
+	fault := -1
+	inProject, withCode := 0, 0
+	for i := range trace.Frames {
+		frame := &trace.Frames[i]
+		file, ok := locator.Locate(frame.Path)
+		if !ok {
+			continue
+		}
+		frame.File = file
+		inProject++
+		if fault < 0 {
+			fault = i
+		}
+		if withCode < maxSnippetFrames {
+			if code, err := SourceSnippet(filepath.Join(cwd, filepath.FromSlash(file)), frame.Line, radius); err == nil {
+				frame.Code = code
+				withCode++
+			}
+		}
+	}
+
+	result := map[string]interface{}{
+		"language":       trace.Language,
+		"message":        trace.Message,
+		"frames":         trace.Frames,
+		"project_frames": inProject,
+	}
+	summary := fmt.Sprintf("%s trace with %d frames, none in project files", trace.Language, len(trace.Frames))
+	if fault >= 0 {
+		f := trace.Frames[fault]
+		result["likely_fault"] = fmt.Sprintf("%s:%d", f.File, f.Line)
+		summary = fmt.Sprintf("Likely fault at %s:%d", f.File, f.Line)
+		if f.Function != "" {
+			summary += " in " + f.Function
+		}
+		summary += fmt.Sprintf(", the innermost of %d project frames (of %d)", inProject, len(trace.Frames))
+	}
+	if trace.Message != "" {
+		summary = trace.Message + "\n" + summary
+	}
+	result["summary"] = summary
+	return result, nil
+}
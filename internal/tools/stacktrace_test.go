@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const goTrace = `panic: runtime error: index out of range [3] with length 3
+
+goroutine 1 [running]:
+example.com/app/internal/parse.(*Parser).next(...)
+	/home/ci/app/internal/parse/parse.go:4
+example.com/app/internal/parse.Parse({0x4b8f20, 0x3})
+	/home/ci/app/internal/parse/parse.go:9 +0x1d
+main.main()
+	/home/ci/app/main.go:7 +0x25
+exit status 2`
+
+func TestParseStackTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		language  string
+		message   string
+		frames    int
+		innermost StackFrame
+	}{
+		{"go", goTrace, "go", "panic: runtime error: index out of range [3] with length 3", 3,
+			StackFrame{Function: "example.com/app/internal/parse.(*Parser).next", Path: "/home/ci/app/internal/parse/parse.go", Line: 4}},
+		{"python", `Traceback (most recent call last):
+  File "/srv/app/main.py", line 10, in <module>
+    run()
+  File "/srv/app/app/models.py", line 17, in save
+    raise ValueError("missing name")
+ValueError: missing name`, "python", "ValueError: missing name", 2,
+			StackFrame{Function: "save", Path: "/srv/app/app/models.py", Line: 17}},
+		{"node", `TypeError: Cannot read properties of undefined (reading 'id')
+    at getUser (/app/src/users.js:12:20)
+    at /app/src/server.js:30:5
+    at node:internal/process/task_queues:95:5`, "node", "TypeError: Cannot read properties of undefined (reading 'id')", 3,
+			StackFrame{Function: "getUser", Path: "/app/src/users.js", Line: 12}},
+	}
+	for _, tt := range tests {
+		trace := ParseStackTrace(tt.text)
+		if trace == nil {
+			t.Errorf("%s: no trace found", tt.name)
+			continue
+		}
+		if trace.Language != tt.language || trace.Message != tt.message || len(trace.Frames) != tt.frames {
+			t.Errorf("%s: got %s %q with %d frames, want %s %q with %d", tt.name, trace.Language, trace.Message, len(trace.Frames), tt.language, tt.message, tt.frames)
+			continue
+		}
+		if trace.Frames[0] != tt.innermost {
+			t.Errorf("%s: innermost frame = %+v, want %+v", tt.name, trace.Frames[0], tt.innermost)
+		}
+	}
+
+	if trace := ParseStackTrace("the build failed, can you check main.go?"); trace != nil {
+		t.Errorf("found a trace in plain text: %+v", trace)
+	}
+}
+
+func TestStackTraceTool(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "internal", "parse"), 0755)
+	os.WriteFile(filepath.Join(root, "internal", "parse", "parse.go"), []byte("package parse\n\nfunc (p *Parser) next() byte {\n\treturn p.buf[p.pos]\n}\n"), 0644)
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(root)
+
+	result, err := NewStackTraceTool().Execute(context.Background(), map[string]interface{}{"trace": goTrace, "context_lines": float64(1)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	res := result.(map[string]interface{})
+	if res["likely_fault"] != "internal/parse/parse.go:4" || res["project_frames"] != 3 {
+		t.Errorf("likely fault %v with %v project frames", res["likely_fault"], res["project_frames"])
+	}
+	frames := res["frames"].([]StackFrame)
+	if !strings.Contains(frames[0].Code, ">    4  \treturn p.buf[p.pos]") || strings.Contains(frames[0].Code, "package parse") {
+		t.Errorf("unexpected code for the innermost frame:\n%s", frames[0].Code)
+	}
+}
@@ -0,0 +1,328 @@
+package tools
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSampleRows caps the sample rows a table preview returns
+const maxSampleRows = 50
+
+// TableColumn describes a column of a tabular data file
+type TableColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Nulls    int    `json:"nulls,omitempty"` // Empty or NULL-like values, for CSV files
+	Example  string `json:"example,omitempty"`
+	Min      string `json:"min,omitempty"` // For numeric CSV columns
+	Max      string `json:"max,omitempty"`
+}
+
+// TablePreview is the schema, size and a sample of a tabular data file
+type TablePreview struct {
+	Format    string        `json:"format"`
+	Delimiter string        `json:"delimiter,omitempty"`
+	Header    bool          `json:"header"` // Whether the first CSV row names the columns
+	Rows      int64         `json:"rows"`   // Data rows, excluding the header
+	Ragged    int           `json:"ragged_rows,omitempty"`
+	Columns   []TableColumn `json:"columns"`
+	Sample    [][]string    `json:"sample,omitempty"`
+}
+
+// csvTypes are the types inferred for CSV columns, most specific first; a column gets
+// the first type all of its values parse as
+var csvTypes = []struct {
+	name  string
+	parse func(string) bool
+}{
+	{"integer", func(s string) bool { _, err := strconv.ParseInt(s, 10, 64); return err == nil }},
+	{"number", func(s string) bool { _, err := strconv.ParseFloat(s, 64); return err == nil }},
+	{"boolean", func(s string) bool {
+		switch strings.ToLower(s) {
+		case "true", "false", "yes", "no":
+			return true
+		}
+		return false
+	}},
+	{"date", func(s string) bool { _, err := time.Parse("2006-01-02", s); return err == nil }},
+	{"datetime", func(s string) bool {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04:05.999999"} {
+			if _, err := time.Parse(layout, s); err == nil {
+				return true
+			}
+		}
+		return false
+	}},
+}
+
+// isNullValue reports whether a CSV field stands for a missing value
+func isNullValue(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "null", "na", "n/a", "nan", "none", "nil", "-":
+		return true
+	}
+	return false
+}
+
+// columnStats accumulates what is inferred about one CSV column
+type columnStats struct {
+	possible   []bool // Indexed like csvTypes
+	values     int
+	nulls      int
+	example    string
+	min, max   float64
+	hasNumbers bool
+}
+
+func (c *columnStats) add(value string) {
+	value = strings.TrimSpace(value)
+	if isNullValue(value) {
+		c.nulls++
+		return
+	}
+	c.values++
+	if c.example == "" {
+		c.example = value
+	}
+	for i, t := range csvTypes {
+		if c.possible[i] && !t.parse(value) {
+			c.possible[i] = false
+		}
+	}
+	if c.possible[1] {
+		n, _ := strconv.ParseFloat(value, 64)
+		if !c.hasNumbers || n < c.min {
+			c.min = n
+		}
+		if !c.hasNumbers || n > c.max {
+			c.max = n
+		}
+		c.hasNumbers = true
+	}
+}
+
+func (c *columnStats) column(name string) TableColumn {
+	col := TableColumn{Name: name, Type: "string", Nullable: c.nulls > 0, Nulls: c.nulls, Example: c.example}
+	if c.values == 0 {
+		col.Type = "empty"
+		return col
+	}
+	for i, t := range csvTypes {
+		if c.possible[i] {
+			col.Type = t.name
+			break
+		}
+	}
+	if col.Type == "integer" || col.Type == "number" {
+		col.Min = strconv.FormatFloat(c.min, 'f', -1, 64)
+		col.Max = strconv.FormatFloat(c.max, 'f', -1, 64)
+	}
+	return col
+}
+
+// sniffDelimiter guesses the delimiter from the first line: whichever of comma, tab,
+// semicolon and pipe occurs most
+func sniffDelimiter(line string) rune {
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', '\t', ';', '|'} {
+		if n := strings.Count(line, string(d)); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+// PreviewCSV reads delimited text and infers its columns, counting every row and
+// keeping the first sampleRows. A zero delimiter is guessed from the first line. The
+// first row is taken as the header unless all of its fields are numbers.
+func PreviewCSV(r io.Reader, delimiter rune, sampleRows int) (*TablePreview, error) {
+	br := bufio.NewReader(r)
+	if delimiter == 0 {
+		first, err := br.Peek(4096)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		line, _, _ := strings.Cut(string(first), "\n")
+		delimiter = sniffDelimiter(line)
+	}
+
+	reader := csv.NewReader(br)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	preview := &TablePreview{Format: "csv", Delimiter: string(delimiter)}
+	var names []string
+	var stats []*columnStats
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if stats == nil {
+			for _, field := range record {
+				if !csvTypes[1].parse(strings.TrimSpace(field)) {
+					preview.Header = true
+					break
+				}
+			}
+			for i, field := range record {
+				name := fmt.Sprintf("column%d", i+1)
+				if preview.Header && strings.TrimSpace(field) != "" {
+					name = strings.TrimSpace(field)
+				}
+				names = append(names, name)
+				stats = append(stats, &columnStats{possible: []bool{true, true, true, true, true}})
+			}
+			if preview.Header {
+				continue
+			}
+		}
+
+		preview.Rows++
+		if len(record) != len(names) {
+			preview.Ragged++
+		}
+		for i, field := range record {
+			if i < len(stats) {
+				stats[i].add(field)
+			}
+		}
+		if len(preview.Sample) < sampleRows {
+			preview.Sample = append(preview.Sample, append([]string(nil), record...))
+		}
+	}
+
+	for i, s := range stats {
+		preview.Columns = append(preview.Columns, s.column(names[i]))
+	}
+	return preview, nil
+}
+
+// TablePreviewTool shows the schema and a sample of CSV, TSV and parquet files
+type TablePreviewTool struct{}
+
+// NewTablePreviewTool creates a new table preview tool
+func NewTablePreviewTool() *TablePreviewTool {
+	return &TablePreviewTool{}
+}
+
+// Name returns the tool name
+func (t *TablePreviewTool) Name() string {
+	return "previewTable"
+}
+
+// Description returns the tool description
+func (t *TablePreviewTool) Description() string {
+	return "Previews a tabular data file (CSV, TSV, optionally gzipped, or parquet): returns the column names, inferred types, null counts, the row count and a few sample rows. For parquet only the schema and row count are read. Use it instead of reading data files"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *TablePreviewTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"path": {
+				Type:        "string",
+				Description: "Path to the data file",
+			},
+			"sample_rows": {
+				Type:        "integer",
+				Description: "Sample rows to return (default: 5, max: 50)",
+			},
+			"delimiter": {
+				Type:        "string",
+				Description: "Field delimiter for delimited text; guessed when omitted",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// ResultSchema describes the preview as a table of columns
+func (t *TablePreviewTool) ResultSchema() ResultSchema {
+	return ResultSchema{
+		Kind:    ResultTable,
+		Rows:    "columns",
+		Columns: []string{"name", "type", "nullable", "example"},
+		Summary: []string{"format", "rows"},
+	}
+}
+
+// Execute previews the file
+func (t *TablePreviewTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	path, _ := params["path"].(string)
+	sampleRows := getIntParam(params, "sample_rows", 5)
+	if sampleRows < 0 {
+		sampleRows = 0
+	}
+	if sampleRows > maxSampleRows {
+		sampleRows = maxSampleRows
+	}
+	var delimiter rune
+	if d, _ := params["delimiter"].(string); d != "" {
+		if d == `\t` {
+			d = "\t"
+		}
+		delimiter = []rune(d)[0]
+	}
+
+	cleanPath, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	name := strings.ToLower(cleanPath)
+
+	if filepath.Ext(name) == ".parquet" {
+		columns, rows, err := ReadParquetSchema(cleanPath)
+		if err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to read parquet metadata", Err: err}
+		}
+		return &TablePreview{Format: "parquet", Rows: rows, Columns: columns}, nil
+	}
+
+	f, err := os.Open(cleanPath)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to open data file", Err: err}
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to decompress data file", Err: err}
+		}
+		defer gz.Close()
+		r = gz
+		name = strings.TrimSuffix(name, ".gz")
+	}
+	if delimiter == 0 && filepath.Ext(name) == ".tsv" {
+		delimiter = '\t'
+	}
+
+	preview, err := PreviewCSV(r, delimiter, sampleRows)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to parse delimited data", Err: err}
+	}
+	return preview, nil
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreviewCSV(t *testing.T) {
+	data := `id,name,score,active,joined,note
+1,ann,9.5,true,2024-01-02,
+2,bob,7,false,2024-02-03,NULL
+3,"cid, jr",8.25,yes,2024-03-04,late
+4,dee,,no,2024-04-05
+`
+	preview, err := PreviewCSV(strings.NewReader(data), 0, 2)
+	if err != nil {
+		t.Fatalf("PreviewCSV() error = %v", err)
+	}
+	if !preview.Header || preview.Rows != 4 || preview.Ragged != 1 || preview.Delimiter != "," {
+		t.Errorf("preview = %+v", preview)
+	}
+
+	want := []TableColumn{
+		{Name: "id", Type: "integer", Example: "1", Min: "1", Max: "4"},
+		{Name: "name", Type: "string", Example: "ann"},
+		{Name: "score", Type: "number", Nullable: true, Nulls: 1, Example: "9.5", Min: "7", Max: "9.5"},
+		{Name: "active", Type: "boolean", Example: "true"},
+		{Name: "joined", Type: "date", Example: "2024-01-02"},
+		{Name: "note", Type: "string", Nullable: true, Nulls: 2, Example: "late"},
+	}
+	if !reflect.DeepEqual(preview.Columns, want) {
+		t.Errorf("Columns = %+v\nwant %+v", preview.Columns, want)
+	}
+	if len(preview.Sample) != 2 || preview.Sample[1][1] != "bob" {
+		t.Errorf("Sample = %v", preview.Sample)
+	}
+}
+
+func TestPreviewCSVWithoutHeader(t *testing.T) {
+	preview, err := PreviewCSV(strings.NewReader("1;2.5\n3;4\n"), 0, 5)
+	if err != nil {
+		t.Fatalf("PreviewCSV() error = %v", err)
+	}
+	if preview.Header || preview.Rows != 2 || preview.Delimiter != ";" {
+		t.Errorf("preview = %+v", preview)
+	}
+	if preview.Columns[0].Name != "column1" || preview.Columns[1].Type != "number" {
+		t.Errorf("Columns = %+v", preview.Columns)
+	}
+}
+
+// thriftWriter encodes the subset of the Thrift compact protocol used by parquet footers
+type thriftWriter struct {
+	bytes.Buffer
+	last []int16
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	w.WriteByte(byte(id-w.last[len(w.last)-1])<<4 | typ)
+	w.last[len(w.last)-1] = id
+}
+
+func (w *thriftWriter) varint(v int64) {
+	w.Write(binary.AppendUvarint(nil, uint64((v<<1)^(v>>63))))
+}
+
+func (w *thriftWriter) i64(id int16, typ byte, v int64) {
+	w.field(id, typ)
+	w.varint(v)
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	w.WriteString(s)
+}
+
+func (w *thriftWriter) begin() { w.last = append(w.last, 0) }
+
+func (w *thriftWriter) end() {
+	w.WriteByte(0)
+	w.last = w.last[:len(w.last)-1]
+}
+
+func TestReadParquetSchema(t *testing.T) {
+	w := &thriftWriter{}
+	w.begin()
+	w.i64(1, thriftI32, 1)
+	w.field(2, thriftList)
+	w.WriteByte(5<<4 | thriftStruct)
+	for _, e := range []struct {
+		name                            string
+		physical, repetition, converted int64
+		children                        int64
+	}{
+		{name: "schema", physical: -1, repetition: -1, converted: -1, children: 3},
+		{name: "id", physical: 2, repetition: 0, converted: -1},
+		{name: "name", physical: 6, repetition: 1, converted: 0},
+		{name: "address", physical: -1, repetition: 1, converted: -1, children: 1},
+		{name: "city", physical: 6, repetition: 2, converted: 0},
+	} {
+		w.begin()
+		if e.physical >= 0 {
+			w.i64(1, thriftI32, e.physical)
+		}
+		if e.repetition >= 0 {
+			w.i64(3, thriftI32, e.repetition)
+		}
+		w.str(4, e.name)
+		if e.children > 0 {
+			w.i64(5, thriftI32, e.children)
+		}
+		if e.converted >= 0 {
+			w.i64(6, thriftI32, e.converted)
+		}
+		w.end()
+	}
+	w.i64(3, thriftI64, 1234)
+	w.field(4, thriftList)
+	w.WriteByte(thriftStruct)
+	w.end()
+
+	footer := w.Bytes()
+	file := append([]byte("PAR1"), footer...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(footer)))
+	file = append(file, "PAR1"...)
+	path := filepath.Join(t.TempDir(), "users.parquet")
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewTablePreviewTool().Execute(context.Background(), map[string]interface{}{"path": path})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	preview := result.(*TablePreview)
+	want := []TableColumn{
+		{Name: "id", Type: "int64"},
+		{Name: "name", Type: "string", Nullable: true},
+		{Name: "address.city", Type: "list<string>"},
+	}
+	if preview.Format != "parquet" || preview.Rows != 1234 || !reflect.DeepEqual(preview.Columns, want) {
+		t.Errorf("preview = %+v", preview)
+	}
+
+	os.WriteFile(path, []byte("id,name\n"), 0644)
+	if _, err := NewTablePreviewTool().Execute(context.Background(), map[string]interface{}{"path": path}); err == nil {
+		t.Error("expected an error for a file that is not parquet")
+	}
+}
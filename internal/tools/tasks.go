@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"codezilla/internal/project"
+)
+
+// ListTasksTool lists the build, test and other commands the project defines
+type ListTasksTool struct{}
+
+// NewListTasksTool creates a new task listing tool
+func NewListTasksTool() *ListTasksTool {
+	return &ListTasksTool{}
+}
+
+// Name returns the tool name
+func (t *ListTasksTool) Name() string {
+	return "listTasks"
+}
+
+// Description returns the tool description
+func (t *ListTasksTool) Description() string {
+	return "Lists the project's own tasks from its Makefile, Taskfile, package.json scripts and justfile, with the command to run each and its description. Use it to find the canonical build, test and lint commands instead of guessing them"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *ListTasksTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"path": {
+				Type:        "string",
+				Description: "Project directory (default: current directory)",
+			},
+			"filter": {
+				Type:        "string",
+				Description: "Only list tasks whose name or description contains this text, e.g. \"test\"",
+			},
+		},
+	}
+}
+
+// ResultSchema describes the result as a table of tasks
+func (t *ListTasksTool) ResultSchema() ResultSchema {
+	return ResultSchema{
+		Kind:    ResultTable,
+		Rows:    "tasks",
+		Columns: []string{"command", "description", "source"},
+		Summary: []string{"count"},
+	}
+}
+
+// Execute lists the tasks
+func (t *ListTasksTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	path, _ := params["path"].(string)
+	if path == "" {
+		path = "."
+	}
+	filter, _ := params["filter"].(string)
+
+	cleanPath, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	if info, err := os.Stat(cleanPath); err != nil || !info.IsDir() {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: fmt.Sprintf("%s is not a directory", cleanPath)}
+	}
+
+	tasks := []project.Task{}
+	for _, task := range project.DiscoverTasks(cleanPath) {
+		text := strings.ToLower(task.Name + " " + task.Description)
+		if filter == "" || strings.Contains(text, strings.ToLower(filter)) {
+			tasks = append(tasks, task)
+		}
+	}
+	result := map[string]interface{}{
+		"tasks": tasks,
+		"count": len(tasks),
+	}
+	if len(tasks) == 0 && filter == "" {
+		result["note"] = "No Makefile, Taskfile, package.json scripts or justfile found"
+	}
+	return result, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,6 +19,55 @@ type TodoItem struct {
 	UpdatedAt    time.Time  `json:"updated_at"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty"`
 	Dependencies []string   `json:"dependencies,omitempty"` // IDs of tasks that must be completed first
+	// File and Line link an item harvested from a code comment to it
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Issue is the URL of the issue the item was filed as
+	Issue string `json:"issue,omitempty"`
+	// StartedAt is when the clock on an item in progress last started; it is nil
+	// while the item is not in progress or its session has ended
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// TimeSpent is how long the item was in progress before StartedAt
+	TimeSpent time.Duration `json:"time_spent,omitempty"`
+	// ToolCalls counts the tools run while the item was in progress, by name
+	ToolCalls map[string]int `json:"tool_calls,omitempty"`
+	// Commits are the commits made while the item was in progress
+	Commits []string `json:"commits,omitempty"`
+}
+
+// setStatus moves the item to status, keeping the time it spends in progress
+func (item *TodoItem) setStatus(status string, now time.Time) {
+	if status != "in_progress" {
+		item.pause(now)
+	}
+	if status == "in_progress" && item.StartedAt == nil {
+		item.StartedAt = &now
+	}
+	switch {
+	case status == "completed" && item.Status != "completed":
+		item.CompletedAt = &now
+	case status != "completed":
+		item.CompletedAt = nil
+	}
+	item.Status = status
+	item.UpdatedAt = now
+}
+
+// pause adds the time since the clock on the item started to its time spent
+func (item *TodoItem) pause(now time.Time) {
+	if item.Status == "in_progress" && item.StartedAt != nil {
+		item.TimeSpent += now.Sub(*item.StartedAt)
+		item.StartedAt = nil
+	}
+}
+
+// timeInProgress is how long the item has been in progress in all, up to now
+func (item *TodoItem) timeInProgress(now time.Time) time.Duration {
+	spent := item.TimeSpent
+	if item.StartedAt != nil {
+		spent += now.Sub(*item.StartedAt)
+	}
+	return spent
 }
 
 // TodoPlan represents a collection of todo items with planning metadata
@@ -47,6 +97,20 @@ func NewTodoManager() *TodoManager {
 // Global todo manager instance
 var globalTodoManager = NewTodoManager()
 
+// CurrentTodoPlan returns a copy of the current plan, or nil if there is none
+func CurrentTodoPlan() *TodoPlan {
+	globalTodoManager.mu.RLock()
+	defer globalTodoManager.mu.RUnlock()
+
+	plan, ok := globalTodoManager.plans[globalTodoManager.currentPlanID]
+	if !ok {
+		return nil
+	}
+	copied := *plan
+	copied.Items = append([]TodoItem(nil), plan.Items...)
+	return &copied
+}
+
 // TodoCreateTool creates new todo plans
 type TodoCreateTool struct{}
 
@@ -75,7 +139,7 @@ func (t TodoCreateTool) ParameterSchema() JSONSchema {
 						"dependencies": {
 							Type:        "array",
 							Items:       &JSONSchema{Type: "string"},
-							Description: "IDs of tasks that must be completed first",
+							Description: "Tasks that must be completed first, by position in this list (task_1 is the first) or ID",
 						},
 					},
 					Required: []string{"content"},
@@ -128,13 +192,34 @@ func (t TodoCreateTool) Execute(ctx context.Context, params map[string]interface
 		}
 	}
 
+	// Dependencies may name tasks by position, as their IDs do not exist yet
+	var warnings []string
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		var dangling []string
+		item.Dependencies, dangling = resolveTodoDependencies(plan.Items, item.Dependencies)
+		for _, dep := range dangling {
+			warnings = append(warnings, fmt.Sprintf("Warning: dependency %q of %q matches no task and was dropped", dep, item.Content))
+		}
+	}
+	if cycles := todoCycles(plan.Items); len(cycles) > 0 {
+		return nil, &ErrInvalidToolParams{
+			ToolName: t.Name(),
+			Message:  "dependency cycle, so these tasks could never start: " + formatTodoCycle(cycles[0], plan.Items),
+		}
+	}
+
 	globalTodoManager.mu.Lock()
 	globalTodoManager.plans[plan.ID] = plan
 	globalTodoManager.currentPlanID = plan.ID
 	globalTodoManager.mu.Unlock()
 
 	result, _ := json.MarshalIndent(plan, "", "  ")
-	return fmt.Sprintf("Created todo plan:\n%s", string(result)), nil
+	output := fmt.Sprintf("Created todo plan:\n%s", string(result))
+	if len(warnings) > 0 {
+		output += "\n\n" + strings.Join(warnings, "\n")
+	}
+	return output, nil
 }
 
 // TodoUpdateTool updates todo item status
@@ -156,6 +241,11 @@ func (t TodoUpdateTool) ParameterSchema() JSONSchema {
 			"task_id": {Type: "string", Description: "Task ID to update"},
 			"status":  {Type: "string", Enum: []interface{}{"pending", "in_progress", "completed", "cancelled"}},
 			"content": {Type: "string", Description: "Updated task content (optional)"},
+			"dependencies": {
+				Type:        "array",
+				Items:       &JSONSchema{Type: "string"},
+				Description: "Replace the tasks that must be completed first, by ID or position in the plan (optional)",
+			},
 		},
 		Required: []string{"task_id", "status"},
 	}
@@ -180,20 +270,40 @@ func (t TodoUpdateTool) Execute(ctx context.Context, params map[string]interface
 
 	for i := range plan.Items {
 		if plan.Items[i].ID == taskID {
-			plan.Items[i].Status = status
-			plan.Items[i].UpdatedAt = time.Now()
-
-			if status == "completed" {
-				now := time.Now()
-				plan.Items[i].CompletedAt = &now
+			var warning string
+			if deps, ok := params["dependencies"].([]interface{}); ok {
+				var refs []string
+				for _, dep := range deps {
+					if depStr, ok := dep.(string); ok {
+						refs = append(refs, depStr)
+					}
+				}
+				ids, dangling := resolveTodoDependencies(plan.Items, refs)
+				previous := plan.Items[i].Dependencies
+				plan.Items[i].Dependencies = ids
+				// Only a cycle through this task is new; others were there already
+				for _, cycle := range todoCycles(plan.Items) {
+					if containsString(cycle, taskID) {
+						plan.Items[i].Dependencies = previous
+						return "", &ErrInvalidToolParams{
+							ToolName: t.Name(),
+							Message:  "dependencies not changed, they would form a cycle: " + formatTodoCycle(cycle, plan.Items),
+						}
+					}
+				}
+				if len(dangling) > 0 {
+					warning = fmt.Sprintf("\nWarning: dependencies %s match no task and were dropped", strings.Join(dangling, ", "))
+				}
 			}
 
+			plan.Items[i].setStatus(status, time.Now())
+
 			if content, ok := params["content"].(string); ok {
 				plan.Items[i].Content = content
 			}
 
 			plan.UpdatedAt = time.Now()
-			return fmt.Sprintf("Updated task %s to status: %s", taskID, status), nil
+			return fmt.Sprintf("Updated task %s to status: %s%s", taskID, status, warning), nil
 		}
 	}
 
@@ -302,6 +412,12 @@ func formatPlan(plan *TodoPlan, statusFilter string) string {
 				output += fmt.Sprintf("- [%s] %s %s (ID: %s)\n",
 					item.ID, priorityIcon, item.Content, item.ID)
 
+				if item.File != "" {
+					output += fmt.Sprintf("  Location: %s:%d\n", item.File, item.Line)
+				}
+				if item.Issue != "" {
+					output += fmt.Sprintf("  Issue: %s\n", item.Issue)
+				}
 				if len(item.Dependencies) > 0 {
 					output += fmt.Sprintf("  Dependencies: %v\n", item.Dependencies)
 				}
@@ -454,6 +570,27 @@ func (t TodoAnalyzeTool) Execute(ctx context.Context, params map[string]interfac
 		output += "\n"
 	}
 
+	cycles := todoCycles(plan.Items)
+	if len(cycles) > 0 {
+		output += "## ♻️ Dependency Cycles\n"
+		output += "These tasks wait for each other, so none of them can start until a dependency is removed:\n\n"
+		for _, cycle := range cycles {
+			output += fmt.Sprintf("- %s\n", formatTodoCycle(cycle, plan.Items))
+		}
+		output += "\n"
+	}
+
+	if dangling := danglingTodoDependencies(plan.Items); len(dangling) > 0 {
+		output += "## ⚠️ Missing Dependencies\n"
+		output += "These dependencies name no task in the plan and are treated as done:\n\n"
+		for _, item := range plan.Items {
+			if deps := dangling[item.ID]; len(deps) > 0 {
+				output += fmt.Sprintf("- %s (ID: %s) waits for %s\n", item.Content, item.ID, strings.Join(deps, ", "))
+			}
+		}
+		output += "\n"
+	}
+
 	// Recommendations
 	output += "## 📋 Recommendations\n\n"
 	if len(inProgress) > 0 {
@@ -471,6 +608,9 @@ func (t TodoAnalyzeTool) Execute(ctx context.Context, params map[string]interfac
 	if len(blocked) > 0 {
 		output += fmt.Sprintf("3. %d tasks are blocked by dependencies\n", len(blocked))
 	}
+	if len(cycles) > 0 {
+		output += "4. Break the dependency cycles with todo_update, removing a dependency from one task in each\n"
+	}
 
 	return output, nil
 }
@@ -482,5 +622,8 @@ func GetTodoTools() []Tool {
 		TodoUpdateTool{},
 		TodoListTool{},
 		TodoAnalyzeTool{},
+		TodoHarvestTool{},
+		TodoExportTool{},
+		TodoImportTool{},
 	}
 }
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// todoPositionPattern matches a dependency given by position, such as "task_2" or "2",
+// which is how tasks are referred to before they have IDs
+var todoPositionPattern = regexp.MustCompile(`^(?:task_?)?(\d+)$`)
+
+// resolveTodoDependencies returns the IDs of the items a list of references points
+// at, and the references that match none. A reference is an item's ID or its
+// 1-based position in the plan.
+func resolveTodoDependencies(items []TodoItem, refs []string) (ids []string, dangling []string) {
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.ID] = true
+	}
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		id := ""
+		if known[ref] {
+			id = ref
+		} else if m := todoPositionPattern.FindStringSubmatch(ref); m != nil {
+			if n, _ := strconv.Atoi(m[1]); n >= 1 && n <= len(items) {
+				id = items[n-1].ID
+			}
+		}
+		switch {
+		case id == "":
+			dangling = append(dangling, ref)
+		case !containsString(ids, id):
+			ids = append(ids, id)
+		}
+	}
+	return ids, dangling
+}
+
+// todoCycles returns the dependency cycles of a plan, each as the IDs along it with
+// the first repeated at the end, such as [a b c a] when a waits for b, b for c and
+// c for a. Each cycle is reported once, starting from its first item in the plan.
+func todoCycles(items []TodoItem) [][]string {
+	deps := make(map[string][]string, len(items))
+	for _, item := range items {
+		deps[item.ID] = item.Dependencies
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(items))
+	var cycles [][]string
+	var path []string
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		path = append(path, id)
+		for _, dep := range deps[id] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == dep {
+						cycle := append(append([]string(nil), path[i:]...), dep)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			case unvisited:
+				visit(dep)
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+	for _, item := range items {
+		if state[item.ID] == unvisited {
+			visit(item.ID)
+		}
+	}
+	return cycles
+}
+
+// danglingTodoDependencies returns the dependencies of each item that are not in the plan
+func danglingTodoDependencies(items []TodoItem) map[string][]string {
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.ID] = true
+	}
+	dangling := make(map[string][]string)
+	for _, item := range items {
+		for _, dep := range item.Dependencies {
+			if !known[dep] {
+				dangling[item.ID] = append(dangling[item.ID], dep)
+			}
+		}
+	}
+	return dangling
+}
+
+// formatTodoCycle describes a cycle by the content of its items, such as
+// `"Migrate" waits for "Backfill", which waits for "Migrate"`
+func formatTodoCycle(cycle []string, items []TodoItem) string {
+	content := make(map[string]string, len(items))
+	for _, item := range items {
+		content[item.ID] = item.Content
+	}
+	name := func(id string) string {
+		return fmt.Sprintf("%q (%s)", content[id], id)
+	}
+	if len(cycle) == 2 {
+		return fmt.Sprintf("%s depends on itself", name(cycle[0]))
+	}
+	var b strings.Builder
+	b.WriteString(name(cycle[0]))
+	for i, id := range cycle[1:] {
+		if i == 0 {
+			b.WriteString(" waits for ")
+		} else {
+			b.WriteString(", which waits for ")
+		}
+		b.WriteString(name(id))
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveTodoDependencies(t *testing.T) {
+	items := []TodoItem{{ID: "task_9_0"}, {ID: "task_9_1"}, {ID: "task_9_2"}}
+	tests := []struct {
+		name         string
+		refs         []string
+		wantIDs      []string
+		wantDangling []string
+	}{
+		{"ids", []string{"task_9_0", "task_9_2"}, []string{"task_9_0", "task_9_2"}, nil},
+		{"positions", []string{"task_1", "3"}, []string{"task_9_0", "task_9_2"}, nil},
+		{"duplicates merged", []string{"task_2", "task_9_1"}, []string{"task_9_1"}, nil},
+		{"out of range", []string{"task_4", "task_0"}, nil, []string{"task_4", "task_0"}},
+		{"unknown", []string{"setup"}, nil, []string{"setup"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, dangling := resolveTodoDependencies(items, tt.refs)
+			if !reflect.DeepEqual(ids, tt.wantIDs) || !reflect.DeepEqual(dangling, tt.wantDangling) {
+				t.Errorf("resolveTodoDependencies(%v) = %v, %v; want %v, %v", tt.refs, ids, dangling, tt.wantIDs, tt.wantDangling)
+			}
+		})
+	}
+}
+
+func TestTodoCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []TodoItem
+		want  [][]string
+	}{
+		{"chain", []TodoItem{{ID: "a"}, {ID: "b", Dependencies: []string{"a"}}, {ID: "c", Dependencies: []string{"b", "gone"}}}, nil},
+		{"self", []TodoItem{{ID: "a", Dependencies: []string{"a"}}}, [][]string{{"a", "a"}}},
+		{"loop", []TodoItem{
+			{ID: "a", Dependencies: []string{"b"}},
+			{ID: "b", Dependencies: []string{"c"}},
+			{ID: "c", Dependencies: []string{"a"}},
+			{ID: "d", Dependencies: []string{"c"}},
+		}, [][]string{{"a", "b", "c", "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := todoCycles(tt.items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("todoCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTodoCreateRejectsCycles(t *testing.T) {
+	ctx := context.Background()
+	_, err := TodoCreateTool{}.Execute(ctx, map[string]interface{}{
+		"name": "Cyclic",
+		"items": []interface{}{
+			map[string]interface{}{"content": "Migrate", "dependencies": []interface{}{"task_2"}},
+			map[string]interface{}{"content": "Backfill", "dependencies": []interface{}{"task_1"}},
+		},
+	})
+	var invalid *ErrInvalidToolParams
+	if !errors.As(err, &invalid) || !strings.Contains(err.Error(), `"Migrate"`) || !strings.Contains(err.Error(), `which waits for "Migrate"`) {
+		t.Fatalf("Execute() error = %v, want the cycle path", err)
+	}
+
+	result, err := TodoCreateTool{}.Execute(ctx, map[string]interface{}{
+		"name": "Linear",
+		"items": []interface{}{
+			map[string]interface{}{"content": "Migrate"},
+			map[string]interface{}{"content": "Backfill", "dependencies": []interface{}{"task_1", "setup"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(result.(string), `dependency "setup" of "Backfill" matches no task`) {
+		t.Errorf("result does not warn about the missing dependency:\n%s", result)
+	}
+	plan := CurrentTodoPlan()
+	if deps := plan.Items[1].Dependencies; len(deps) != 1 || deps[0] != plan.Items[0].ID {
+		t.Errorf("dependencies = %v, want [%s]", deps, plan.Items[0].ID)
+	}
+
+	// Making the first task wait for the second closes a loop
+	_, err = TodoUpdateTool{}.Execute(ctx, map[string]interface{}{
+		"task_id": plan.Items[0].ID, "status": "pending", "dependencies": []interface{}{"task_2"},
+	})
+	if !errors.As(err, &invalid) {
+		t.Fatalf("todo_update error = %v, want a cycle", err)
+	}
+	if deps := CurrentTodoPlan().Items[0].Dependencies; len(deps) != 0 {
+		t.Errorf("rejected update changed dependencies to %v", deps)
+	}
+}
+
+func TestTodoAnalyzeExplainsCycles(t *testing.T) {
+	globalTodoManager.ImportPlan(&TodoPlan{ID: "plan_cycle", Name: "Imported", Items: []TodoItem{
+		{ID: "a", Content: "Schema", Status: "pending", Dependencies: []string{"b"}},
+		{ID: "b", Content: "Backfill", Status: "pending", Dependencies: []string{"a", "zzz"}},
+	}})
+	result, err := TodoAnalyzeTool{}.Execute(context.Background(), map[string]interface{}{"plan_id": "plan_cycle"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	for _, want := range []string{
+		"Dependency Cycles",
+		`"Schema" (a) waits for "Backfill" (b), which waits for "Schema" (a)`,
+		"Backfill (ID: b) waits for zzz",
+		"Break the dependency cycles",
+	} {
+		if !strings.Contains(result.(string), want) {
+			t.Errorf("analysis is missing %q:\n%s", want, result)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CodeTodoPlanID is the plan harvested code comments are kept in, so every
+	// rescan updates the same plan
+	CodeTodoPlanID = "plan_code_todos"
+	// maxHarvestFileSize skips generated and data files
+	maxHarvestFileSize = 1 << 20
+	// maxHarvestedTodos bounds the items a harvest adds
+	maxHarvestedTodos = 500
+)
+
+// todoCommentPattern matches a TODO, FIXME, HACK or XXX marker in a comment, with an
+// optional owner in parentheses, capturing the kind and the text after it
+var todoCommentPattern = regexp.MustCompile(`(?:^|\s)(?://+|#+|/\*+|\*|--|;+|<!--)\s*(TODO|FIXME|HACK|XXX)\b(?:\([^)]*\))?:?\s*(.*)`)
+
+// todoKindPriority is the priority each kind of comment gets in the plan
+var todoKindPriority = map[string]string{
+	"FIXME": "high",
+	"XXX":   "medium",
+	"HACK":  "medium",
+	"TODO":  "low",
+}
+
+// harvestSkippedDirs are never searched for comments, in addition to hidden directories
+var harvestSkippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+}
+
+// CodeTodo is a TODO-style comment in the code
+type CodeTodo struct {
+	Path string `json:"path"` // Relative to the harvested directory, slash-separated
+	Line int    `json:"line"`
+	Kind string `json:"kind"` // TODO, FIXME, HACK or XXX
+	Text string `json:"text"`
+}
+
+// key identifies the comment across rescans by its file, kind and text, so it
+// keeps its status when lines above it change
+func (t CodeTodo) key() string {
+	sum := sha1.Sum([]byte(t.Path + "\x00" + t.Kind + "\x00" + t.Text))
+	return "code_" + hex.EncodeToString(sum[:6])
+}
+
+// HarvestTodos finds the TODO, FIXME, HACK and XXX comments in the text files under
+// dir, in path and line order
+func HarvestTodos(ctx context.Context, dir string) ([]CodeTodo, error) {
+	var todos []CodeTodo
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || harvestSkippedDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxHarvestFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		sniff := data
+		if len(sniff) > 8000 {
+			sniff = sniff[:8000]
+		}
+		if bytes.IndexByte(sniff, 0) >= 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		for i, line := range strings.Split(string(data), "\n") {
+			m := todoCommentPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[2]), "*/"))
+			text = strings.TrimSpace(strings.TrimSuffix(text, "-->"))
+			todos = append(todos, CodeTodo{Path: filepath.ToSlash(rel), Line: i + 1, Kind: m[1], Text: text})
+		}
+		return nil
+	})
+	return todos, err
+}
+
+// TodoHarvestResult reports what a harvest changed in the plan
+type TodoHarvestResult struct {
+	PlanID   string `json:"plan_id"`
+	Found    int    `json:"found"`
+	Added    int    `json:"added"`
+	Updated  int    `json:"updated"`
+	Resolved int    `json:"resolved"`
+	Omitted  int    `json:"omitted,omitempty"` // Left out over the limit
+}
+
+// SyncCodeTodos brings the code TODO plan in line with todos: new comments are
+// added as pending items, moved ones get their new line, and items whose comment
+// is gone are completed. Items added to the plan by hand are left alone.
+func (m *TodoManager) SyncCodeTodos(dir string, todos []CodeTodo) TodoHarvestResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	result := TodoHarvestResult{PlanID: CodeTodoPlanID, Found: len(todos)}
+	plan, ok := m.plans[CodeTodoPlanID]
+	if !ok {
+		plan = &TodoPlan{
+			ID:          CodeTodoPlanID,
+			Name:        "Code TODOs",
+			Description: fmt.Sprintf("TODO, FIXME, HACK and XXX comments in %s", dir),
+			Items:       []TodoItem{},
+			CreatedAt:   now,
+		}
+		m.plans[CodeTodoPlanID] = plan
+	}
+	plan.UpdatedAt = now
+
+	existing := make(map[string]int)
+	for i, item := range plan.Items {
+		existing[item.ID] = i
+	}
+	found := make(map[string]bool)
+	for _, todo := range todos {
+		id := todo.key()
+		if found[id] {
+			continue
+		}
+		found[id] = true
+		content := fmt.Sprintf("%s: %s", todo.Kind, todo.Text)
+		if i, ok := existing[id]; ok {
+			item := &plan.Items[i]
+			if item.Line != todo.Line || item.Status == "completed" {
+				item.Line = todo.Line
+				item.UpdatedAt = now
+				// A comment that came back is open again
+				if item.Status == "completed" {
+					item.setStatus("pending", now)
+				}
+				result.Updated++
+			}
+			continue
+		}
+		if result.Added >= maxHarvestedTodos {
+			result.Omitted++
+			continue
+		}
+		plan.Items = append(plan.Items, TodoItem{
+			ID:        id,
+			Content:   content,
+			Status:    "pending",
+			Priority:  todoKindPriority[todo.Kind],
+			CreatedAt: now,
+			UpdatedAt: now,
+			File:      todo.Path,
+			Line:      todo.Line,
+		})
+		result.Added++
+	}
+
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		if item.File == "" || found[item.ID] || item.Status == "completed" || item.Status == "cancelled" {
+			continue
+		}
+		item.setStatus("completed", now)
+		result.Resolved++
+	}
+
+	sort.SliceStable(plan.Items, func(i, j int) bool {
+		a, b := plan.Items[i], plan.Items[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	m.currentPlanID = CodeTodoPlanID
+	return result
+}
+
+// TodoHarvestTool turns the TODO-style comments of the project into a todo plan
+type TodoHarvestTool struct{}
+
+func (t TodoHarvestTool) Name() string {
+	return "todo_harvest"
+}
+
+func (t TodoHarvestTool) Description() string {
+	return "Collect the TODO, FIXME, HACK and XXX comments in the project into the 'Code TODOs' plan with their file and line. Rerun it to sync: new comments are added, moved ones updated, and removed ones marked completed"
+}
+
+func (t TodoHarvestTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"dir": {Type: "string", Description: "Directory to scan (default: current directory)"},
+		},
+	}
+}
+
+func (t TodoHarvestTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	dir, _ := params["dir"].(string)
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := ValidateAndCleanPath(dir)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+
+	todos, err := HarvestTodos(ctx, dir)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to scan for comments", Err: err}
+	}
+	result := globalTodoManager.SyncCodeTodos(dir, todos)
+
+	output := fmt.Sprintf("Found %d comments: %d added, %d updated, %d resolved (plan ID: %s)",
+		result.Found, result.Added, result.Updated, result.Resolved, result.PlanID)
+	if result.Omitted > 0 {
+		output += fmt.Sprintf("; %d more left out over the limit of %d", result.Omitted, maxHarvestedTodos)
+	}
+	return output, nil
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHarvestTodos(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":           "package main\n\n// TODO: handle flags\nfunc main() {\n\tx := 1 // FIXME(ann): overflow on 32-bit\n\t/* HACK skip validation */\n}\n",
+		"tools/run.py":      "# XXX: remove once the API is fixed\nprint('TODO: not a comment')\n",
+		"vendor/lib/lib.go": "// TODO: vendored\n",
+		".git/hooks/pre":    "# TODO: hidden\n",
+		"data.bin":          "\x00// TODO: binary\n",
+		"docs/index.html":   "<!-- TODO: add screenshots -->\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := HarvestTodos(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("HarvestTodos() error = %v", err)
+	}
+	want := []CodeTodo{
+		{Path: "docs/index.html", Line: 1, Kind: "TODO", Text: "add screenshots"},
+		{Path: "main.go", Line: 3, Kind: "TODO", Text: "handle flags"},
+		{Path: "main.go", Line: 5, Kind: "FIXME", Text: "overflow on 32-bit"},
+		{Path: "main.go", Line: 6, Kind: "HACK", Text: "skip validation"},
+		{Path: "tools/run.py", Line: 1, Kind: "XXX", Text: "remove once the API is fixed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HarvestTodos() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSyncCodeTodos(t *testing.T) {
+	m := NewTodoManager()
+	flags := CodeTodo{Path: "main.go", Line: 3, Kind: "TODO", Text: "handle flags"}
+	overflow := CodeTodo{Path: "main.go", Line: 5, Kind: "FIXME", Text: "overflow"}
+
+	result := m.SyncCodeTodos("/repo", []CodeTodo{flags, overflow})
+	if result.Added != 2 || m.currentPlanID != CodeTodoPlanID {
+		t.Fatalf("first sync = %+v", result)
+	}
+	plan := m.plans[CodeTodoPlanID]
+	if plan.Items[1].Priority != "high" || plan.Items[1].Content != "FIXME: overflow" || plan.Items[1].File != "main.go" {
+		t.Errorf("harvested item = %+v", plan.Items[1])
+	}
+	plan.Items = append(plan.Items, TodoItem{ID: "task_manual", Content: "Write docs", Status: "pending"})
+
+	// The TODO moved down two lines and the FIXME was fixed
+	flags.Line = 5
+	result = m.SyncCodeTodos("/repo", []CodeTodo{flags})
+	if result.Added != 0 || result.Updated != 1 || result.Resolved != 1 {
+		t.Errorf("second sync = %+v", result)
+	}
+	status := make(map[string]string)
+	for _, item := range plan.Items {
+		status[item.Content] = item.Status
+	}
+	want := map[string]string{"TODO: handle flags": "pending", "FIXME: overflow": "completed", "Write docs": "pending"}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("statuses = %v, want %v", status, want)
+	}
+
+	// A comment that comes back reopens its item
+	result = m.SyncCodeTodos("/repo", []CodeTodo{flags, overflow})
+	if result.Updated != 1 || len(plan.Items) != 3 {
+		t.Errorf("third sync = %+v with %d items", result, len(plan.Items))
+	}
+}
@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTodoMarkdownFile is where plans are exported when no path is given
+const DefaultTodoMarkdownFile = "TODO.md"
+
+var (
+	// todoChecklistPattern matches a Markdown checklist item, capturing the box and the text
+	todoChecklistPattern = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	// todoMetadataPattern matches the comment an export puts after each item and plan
+	// heading, capturing its key=value fields
+	todoMetadataPattern = regexp.MustCompile(`\s*<!--\s*todo(?:-plan)?\s+(.*?)\s*-->\s*$`)
+	// todoLinkSuffixPattern matches a link an export appends to an item, such as the
+	// file it came from or its issue
+	todoLinkSuffixPattern = regexp.MustCompile(`\s*\(\[[^\]]*\]\([^)]*\)\)$`)
+)
+
+// Markdown renders the plan as a checklist that can be committed and imported
+// again. Each item carries its ID, status, priority and links in an HTML comment,
+// which Markdown viewers do not show; ticking a box by hand is honored on import.
+func (p *TodoPlan) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n<!-- todo-plan id=%s -->\n\n", oneLine(p.Name), p.ID)
+	if p.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(p.Description))
+	}
+	for _, item := range p.Items {
+		box, content := " ", oneLine(item.Content)
+		switch item.Status {
+		case "completed":
+			box = "x"
+		case "cancelled":
+			box, content = "x", "~~"+content+"~~"
+		}
+		fields := []string{"id=" + item.ID, "status=" + item.Status, "priority=" + item.Priority}
+		if len(item.Dependencies) > 0 {
+			fields = append(fields, "deps="+strings.Join(item.Dependencies, ","))
+		}
+		if item.File != "" {
+			content += fmt.Sprintf(" ([%s:%d](%s#L%d))", item.File, item.Line, item.File, item.Line)
+			fields = append(fields, "file="+item.File, fmt.Sprintf("line=%d", item.Line))
+		}
+		if item.Issue != "" {
+			content += fmt.Sprintf(" ([issue](%s))", item.Issue)
+			fields = append(fields, "issue="+item.Issue)
+		}
+		fmt.Fprintf(&b, "- [%s] %s <!-- todo %s -->\n", box, content, strings.Join(fields, " "))
+	}
+	return b.String()
+}
+
+// ParseTodoMarkdown reads a Markdown checklist into a plan: the first heading is its
+// name, the text before the first item its description, and every checklist item a
+// task. Checklists written by hand work too; their items are pending or, when
+// ticked, completed, with medium priority.
+func ParseTodoMarkdown(data string) (*TodoPlan, error) {
+	now := time.Now()
+	plan := &TodoPlan{CreatedAt: now, UpdatedAt: now, Items: []TodoItem{}}
+	var description []string
+	for _, line := range strings.Split(data, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := todoChecklistPattern.FindStringSubmatch(line); m != nil {
+			plan.Items = append(plan.Items, parseTodoMarkdownItem(m[1] != " ", m[2], len(plan.Items), now))
+			continue
+		}
+		if strings.HasPrefix(trimmed, "<!-- todo-plan") {
+			if fields := todoMetadata(trimmed); fields["id"] != "" {
+				plan.ID = fields["id"]
+			}
+			continue
+		}
+		if plan.Name == "" && strings.HasPrefix(trimmed, "# ") {
+			plan.Name = strings.TrimSpace(trimmed[2:])
+			continue
+		}
+		if len(plan.Items) == 0 && plan.Name != "" {
+			description = append(description, line)
+		}
+	}
+	if len(plan.Items) == 0 {
+		return nil, fmt.Errorf("no checklist items (- [ ] task) found")
+	}
+	if plan.Name == "" {
+		plan.Name = "Imported checklist"
+	}
+	if plan.ID == "" {
+		plan.ID = fmt.Sprintf("plan_%d", now.UnixNano())
+	}
+	plan.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return plan, nil
+}
+
+// parseTodoMarkdownItem reads one checklist item
+func parseTodoMarkdownItem(checked bool, text string, index int, now time.Time) TodoItem {
+	item := TodoItem{
+		ID:        fmt.Sprintf("task_%d_%d", now.UnixNano(), index),
+		Status:    "pending",
+		Priority:  "medium",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	var fields map[string]string
+	if loc := todoMetadataPattern.FindStringIndex(text); loc != nil {
+		fields = todoMetadata(text[loc[0]:])
+		text = text[:loc[0]]
+		// The links were added by the export and are in the comment too
+		for todoLinkSuffixPattern.MatchString(text) {
+			text = todoLinkSuffixPattern.ReplaceAllString(text, "")
+		}
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "~~") && strings.HasSuffix(text, "~~") && len(text) > 4 {
+		text = text[2 : len(text)-2]
+		if checked {
+			item.Status = "cancelled"
+		}
+	}
+	item.Content = text
+
+	if id := fields["id"]; id != "" {
+		item.ID = id
+	}
+	switch fields["priority"] {
+	case "high", "medium", "low":
+		item.Priority = fields["priority"]
+	}
+	// The box wins over the recorded status, as it may have been ticked by hand
+	status := fields["status"]
+	switch {
+	case checked && item.Status != "cancelled":
+		item.Status = "completed"
+		if status == "cancelled" {
+			item.Status = status
+		}
+	case !checked && status == "in_progress":
+		item.Status = status
+	}
+	if item.Status == "completed" {
+		completed := now
+		item.CompletedAt = &completed
+	}
+	if deps := fields["deps"]; deps != "" {
+		item.Dependencies = strings.Split(deps, ",")
+	}
+	item.File = fields["file"]
+	fmt.Sscanf(fields["line"], "%d", &item.Line)
+	item.Issue = fields["issue"]
+	return item
+}
+
+// todoMetadata returns the key=value fields of an export comment
+func todoMetadata(comment string) map[string]string {
+	fields := make(map[string]string)
+	m := todoMetadataPattern.FindStringSubmatch(comment)
+	if m == nil {
+		return fields
+	}
+	for _, field := range strings.Fields(m[1]) {
+		if key, value, ok := strings.Cut(field, "="); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// oneLine joins the lines of s, as a checklist item cannot span several
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ImportPlan adds a plan and makes it current. A plan with the same ID is replaced,
+// keeping the creation times and tracked time, tool calls and commits of the items
+// both have.
+func (m *TodoManager) ImportPlan(plan *TodoPlan) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.plans[plan.ID]; ok {
+		plan.CreatedAt = old.CreatedAt
+		previous := make(map[string]TodoItem)
+		for _, item := range old.Items {
+			previous[item.ID] = item
+		}
+		now := time.Now()
+		for i := range plan.Items {
+			item := &plan.Items[i]
+			was, ok := previous[item.ID]
+			if !ok {
+				continue
+			}
+			status := item.Status
+			item.CreatedAt = was.CreatedAt
+			item.Status, item.CompletedAt = was.Status, was.CompletedAt
+			item.StartedAt, item.TimeSpent = was.StartedAt, was.TimeSpent
+			item.ToolCalls, item.Commits = was.ToolCalls, was.Commits
+			if status != was.Status {
+				item.setStatus(status, now)
+			}
+		}
+	}
+	m.plans[plan.ID] = plan
+	m.currentPlanID = plan.ID
+}
+
+// LinkTodoIssue records the issue an item was filed as
+func LinkTodoIssue(planID, taskID, url string) error {
+	globalTodoManager.mu.Lock()
+	defer globalTodoManager.mu.Unlock()
+
+	plan, ok := globalTodoManager.plans[planID]
+	if !ok {
+		return fmt.Errorf("plan not found: %s", planID)
+	}
+	for i := range plan.Items {
+		if plan.Items[i].ID == taskID {
+			plan.Items[i].Issue = url
+			plan.Items[i].UpdatedAt = time.Now()
+			plan.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("task not found: %s", taskID)
+}
+
+// TodoPlanByID returns a copy of a plan, or of the current plan when id is empty
+func TodoPlanByID(id string) (*TodoPlan, error) {
+	globalTodoManager.mu.RLock()
+	defer globalTodoManager.mu.RUnlock()
+
+	if id == "" {
+		id = globalTodoManager.currentPlanID
+	}
+	plan, ok := globalTodoManager.plans[id]
+	if !ok {
+		return nil, fmt.Errorf("plan not found: %s", id)
+	}
+	copied := *plan
+	copied.Items = append([]TodoItem(nil), plan.Items...)
+	return &copied, nil
+}
+
+// TodoExportTool writes a plan to a Markdown checklist
+type TodoExportTool struct{}
+
+func (t TodoExportTool) Name() string {
+	return "todo_export"
+}
+
+func (t TodoExportTool) Description() string {
+	return "Write a todo plan to a Markdown checklist file that can be committed to the repository and imported again with todo_import"
+}
+
+func (t TodoExportTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"plan_id": {Type: "string", Description: "Plan ID (optional, uses current plan if not specified)"},
+			"path":    {Type: "string", Description: "File to write (default: TODO.md)"},
+		},
+	}
+}
+
+func (t TodoExportTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	planID, _ := params["plan_id"].(string)
+	path, _ := params["path"].(string)
+	if path == "" {
+		path = DefaultTodoMarkdownFile
+	}
+	path, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+
+	plan, err := TodoPlanByID(planID)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	if err := os.WriteFile(path, []byte(plan.Markdown()), 0644); err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to write checklist", Err: err}
+	}
+	return fmt.Sprintf("Exported %d items of plan %s to %s", len(plan.Items), plan.ID, path), nil
+}
+
+// TodoImportTool reads a Markdown checklist into a plan
+type TodoImportTool struct{}
+
+func (t TodoImportTool) Name() string {
+	return "todo_import"
+}
+
+func (t TodoImportTool) Description() string {
+	return "Read a Markdown checklist (- [ ] task) into a todo plan and make it current. A file written by todo_export replaces the plan it came from, with boxes ticked since counted as completed"
+}
+
+func (t TodoImportTool) ParameterSchema() JSONSchema {
+	return JSONSchema{
+		Type: "object",
+		Properties: map[string]JSONSchema{
+			"path": {Type: "string", Description: "Checklist file to read (default: TODO.md)"},
+		},
+	}
+}
+
+func (t TodoImportTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path, _ := params["path"].(string)
+	if path == "" {
+		path = DefaultTodoMarkdownFile
+	}
+	path, err := ValidateAndCleanPath(path)
+	if err != nil {
+		return nil, &ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to read checklist", Err: err}
+	}
+	plan, err := ParseTodoMarkdown(string(data))
+	if err != nil {
+		return nil, &ErrToolExecution{ToolName: t.Name(), Message: "failed to parse checklist", Err: err}
+	}
+	globalTodoManager.ImportPlan(plan)
+	output := fmt.Sprintf("Imported %d items from %s:\n\n%s", len(plan.Items), path, formatPlan(plan, "all"))
+	for _, cycle := range todoCycles(plan.Items) {
+		output += "\nWarning: dependency cycle: " + formatTodoCycle(cycle, plan.Items)
+	}
+	return output, nil
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTodoMarkdownRoundTrip(t *testing.T) {
+	plan := &TodoPlan{
+		ID:          "plan_1",
+		Name:        "Release",
+		Description: "Ship 1.2",
+		Items: []TodoItem{
+			{ID: "task_a", Content: "Update the changelog", Status: "completed", Priority: "low"},
+			{ID: "task_b", Content: "Tag the\nrelease", Status: "in_progress", Priority: "high", Dependencies: []string{"task_a"}},
+			{ID: "code_1", Content: "FIXME: overflow", Status: "pending", Priority: "high", File: "main.go", Line: 5, Issue: "https://github.com/acme/app/issues/9"},
+			{ID: "task_c", Content: "Announce", Status: "cancelled", Priority: "medium"},
+		},
+	}
+	md := plan.Markdown()
+	for _, want := range []string{
+		"# Release\n",
+		"- [x] Update the changelog <!-- todo id=task_a status=completed priority=low -->",
+		"- [ ] Tag the release <!-- todo id=task_b status=in_progress priority=high deps=task_a -->",
+		"- [ ] FIXME: overflow ([main.go:5](main.go#L5)) ([issue](https://github.com/acme/app/issues/9))",
+		"- [x] ~~Announce~~",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() is missing %q:\n%s", want, md)
+		}
+	}
+
+	// Someone ticks the FIXME on GitHub before it is imported again
+	md = strings.Replace(md, "- [ ] FIXME", "- [x] FIXME", 1)
+	got, err := ParseTodoMarkdown(md)
+	if err != nil {
+		t.Fatalf("ParseTodoMarkdown() error = %v", err)
+	}
+	if got.ID != "plan_1" || got.Name != "Release" || got.Description != "Ship 1.2" || len(got.Items) != 4 {
+		t.Fatalf("ParseTodoMarkdown() = %+v", got)
+	}
+	want := []struct{ id, content, status, priority string }{
+		{"task_a", "Update the changelog", "completed", "low"},
+		{"task_b", "Tag the release", "in_progress", "high"},
+		{"code_1", "FIXME: overflow", "completed", "high"},
+		{"task_c", "Announce", "cancelled", "medium"},
+	}
+	for i, w := range want {
+		item := got.Items[i]
+		if item.ID != w.id || item.Content != w.content || item.Status != w.status || item.Priority != w.priority {
+			t.Errorf("item %d = %+v, want %+v", i, item, w)
+		}
+	}
+	if fixme := got.Items[2]; fixme.File != "main.go" || fixme.Line != 5 || fixme.Issue == "" || fixme.CompletedAt == nil {
+		t.Errorf("links of the FIXME were lost: %+v", fixme)
+	}
+	if deps := got.Items[1].Dependencies; len(deps) != 1 || deps[0] != "task_a" {
+		t.Errorf("dependencies = %v", deps)
+	}
+}
+
+func TestParseHandWrittenChecklist(t *testing.T) {
+	got, err := ParseTodoMarkdown("Some notes\n\n* [ ] Write docs\n  - [X] Fix [the link](docs.md)\n- not a task\n")
+	if err != nil {
+		t.Fatalf("ParseTodoMarkdown() error = %v", err)
+	}
+	if got.Name != "Imported checklist" || !strings.HasPrefix(got.ID, "plan_") || len(got.Items) != 2 {
+		t.Fatalf("ParseTodoMarkdown() = %+v", got)
+	}
+	if got.Items[0].Status != "pending" || got.Items[1].Status != "completed" || got.Items[1].Content != "Fix [the link](docs.md)" {
+		t.Errorf("items = %+v", got.Items)
+	}
+	if got.Items[0].ID == got.Items[1].ID {
+		t.Error("items share an ID")
+	}
+
+	if _, err := ParseTodoMarkdown("# Empty\n\nNothing to do\n"); err == nil {
+		t.Error("ParseTodoMarkdown() accepted a file without items")
+	}
+}
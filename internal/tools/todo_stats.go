@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTodoCommits bounds the commits kept per item
+const maxTodoCommits = 50
+
+// RecordTodoToolCall attaches a tool call to the items of the current plan that are
+// in progress, restarting their clock if it was paused when the last session ended.
+// A command that commits to git in dir attaches the new commit too. Calls of the todo
+// tools themselves are not counted.
+func RecordTodoToolCall(ctx context.Context, dir, toolName string, params map[string]interface{}) {
+	if strings.HasPrefix(toolName, "todo_") {
+		return
+	}
+	now := time.Now()
+	if !globalTodoManager.recordInProgress(func(item *TodoItem) {
+		if item.StartedAt == nil {
+			item.StartedAt = &now
+		}
+		if item.ToolCalls == nil {
+			item.ToolCalls = make(map[string]int)
+		}
+		item.ToolCalls[toolName]++
+	}) {
+		return
+	}
+	if command, _ := params["command"].(string); toolName == "execute" && strings.Contains(command, "git commit") {
+		RecordTodoCommit(ctx, dir)
+	}
+}
+
+// PauseTodoTime stops the clock on the items in progress when the session ends, so
+// the time until the next one is not counted. It restarts on the next tool call.
+func PauseTodoTime(now time.Time) {
+	m := globalTodoManager
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, plan := range m.plans {
+		for i := range plan.Items {
+			plan.Items[i].pause(now)
+		}
+	}
+}
+
+// RecordTodoCommit attaches the HEAD commit of the repository in dir to the items of
+// the current plan that are in progress
+func RecordTodoCommit(ctx context.Context, dir string) {
+	head, err := runGit(ctx, dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return
+	}
+	recordTodoCommit(strings.TrimSpace(head))
+}
+
+// recordTodoCommit attaches a commit to the in-progress items of the current plan
+func recordTodoCommit(hash string) {
+	if hash == "" {
+		return
+	}
+	globalTodoManager.recordInProgress(func(item *TodoItem) {
+		if containsString(item.Commits, hash) || len(item.Commits) >= maxTodoCommits {
+			return
+		}
+		item.Commits = append(item.Commits, hash)
+	})
+}
+
+// recordInProgress applies record to every in-progress item of the current plan and
+// reports whether there was one
+func (m *TodoManager) recordInProgress(record func(item *TodoItem)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	plan, ok := m.plans[m.currentPlanID]
+	if !ok {
+		return false
+	}
+	found := false
+	for i := range plan.Items {
+		if plan.Items[i].Status == "in_progress" {
+			record(&plan.Items[i])
+			found = true
+		}
+	}
+	return found
+}
+
+// SaveTodoState writes the todo plans to disk, so their tracked time carries over
+// to the next session
+func SaveTodoState() error {
+	if todoPersistence == nil {
+		return nil
+	}
+	globalTodoManager.mu.RLock()
+	empty := len(globalTodoManager.plans) == 0
+	globalTodoManager.mu.RUnlock()
+	if empty {
+		return nil
+	}
+	return todoPersistence.Save(globalTodoManager)
+}
+
+// TodoDay is how many items were completed on a day and how many were left after it
+type TodoDay struct {
+	Date      string `json:"date"` // YYYY-MM-DD, local time
+	Completed int    `json:"completed"`
+	Remaining int    `json:"remaining"`
+}
+
+// TodoItemTime is the tracked work on one item
+type TodoItemTime struct {
+	ID        string        `json:"id"`
+	Content   string        `json:"content"`
+	Status    string        `json:"status"`
+	TimeSpent time.Duration `json:"time_spent"`
+	ToolCalls int           `json:"tool_calls"`
+	Commits   int           `json:"commits"`
+}
+
+// TodoStats summarizes the progress of a plan
+type TodoStats struct {
+	PlanID     string `json:"plan_id"`
+	Name       string `json:"name"`
+	Total      int    `json:"total"`
+	Completed  int    `json:"completed"`
+	InProgress int    `json:"in_progress"`
+	Pending    int    `json:"pending"`
+	Cancelled  int    `json:"cancelled"`
+	// TimeSpent is the time the items were in progress, up to now
+	TimeSpent time.Duration `json:"time_spent"`
+	// AverageTime is the mean time in progress of the completed items that were tracked
+	AverageTime time.Duration `json:"average_time,omitempty"`
+	ToolCalls   int           `json:"tool_calls"`
+	Commits     int           `json:"commits"`
+	// Velocity is the items completed per day since the plan was created
+	Velocity float64 `json:"velocity"`
+	// Estimate is how long the open items take at the current velocity; 0 when there
+	// is none yet
+	Estimate time.Duration  `json:"estimate,omitempty"`
+	Burndown []TodoDay      `json:"burndown"`
+	Items    []TodoItemTime `json:"items"` // Tracked items, most time first
+}
+
+// Stats returns the completion velocity, time spent and daily burndown of the plan
+func (p *TodoPlan) Stats(now time.Time) TodoStats {
+	stats := TodoStats{PlanID: p.ID, Name: p.Name, Total: len(p.Items)}
+	start := p.CreatedAt
+	completedPerDay := make(map[string]int)
+	var trackedDone int
+	var trackedDoneTime time.Duration
+	for i := range p.Items {
+		item := &p.Items[i]
+		switch item.Status {
+		case "completed":
+			stats.Completed++
+			if item.CompletedAt != nil {
+				completedPerDay[item.CompletedAt.Local().Format("2006-01-02")]++
+			}
+		case "in_progress":
+			stats.InProgress++
+		case "cancelled":
+			stats.Cancelled++
+		default:
+			stats.Pending++
+		}
+
+		spent := item.timeInProgress(now)
+		calls := 0
+		for _, n := range item.ToolCalls {
+			calls += n
+		}
+		stats.TimeSpent += spent
+		stats.ToolCalls += calls
+		stats.Commits += len(item.Commits)
+		if item.Status == "completed" && spent > 0 {
+			trackedDone++
+			trackedDoneTime += spent
+		}
+		if spent > 0 || calls > 0 || len(item.Commits) > 0 {
+			stats.Items = append(stats.Items, TodoItemTime{
+				ID: item.ID, Content: item.Content, Status: item.Status,
+				TimeSpent: spent, ToolCalls: calls, Commits: len(item.Commits),
+			})
+		}
+	}
+	if trackedDone > 0 {
+		stats.AverageTime = trackedDoneTime / time.Duration(trackedDone)
+	}
+	sort.SliceStable(stats.Items, func(i, j int) bool {
+		return stats.Items[i].TimeSpent > stats.Items[j].TimeSpent
+	})
+
+	// Days are counted from the plan's creation, at least one so a plan finished in
+	// an hour does not report a velocity of hundreds a day
+	days := math.Max(now.Sub(start).Hours()/24, 1)
+	stats.Velocity = math.Round(float64(stats.Completed)/days*100) / 100
+	open := stats.Pending + stats.InProgress
+	if stats.Velocity > 0 && open > 0 {
+		stats.Estimate = time.Duration(float64(open) / stats.Velocity * 24 * float64(time.Hour))
+	}
+
+	dates := make([]string, 0, len(completedPerDay))
+	for date := range completedPerDay {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	remaining := stats.Total - stats.Cancelled
+	for _, date := range dates {
+		remaining -= completedPerDay[date]
+		stats.Burndown = append(stats.Burndown, TodoDay{Date: date, Completed: completedPerDay[date], Remaining: remaining})
+	}
+	return stats
+}
+
+// FormatTodoStats renders plan stats for the terminal
+func FormatTodoStats(stats TodoStats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Stats: %s\n\n", stats.Name)
+	fmt.Fprintf(&b, "Items: %d completed, %d in progress, %d pending, %d cancelled (of %d)\n",
+		stats.Completed, stats.InProgress, stats.Pending, stats.Cancelled, stats.Total)
+	fmt.Fprintf(&b, "Time in progress: %s over %d tool calls and %d commits\n",
+		formatTodoDuration(stats.TimeSpent), stats.ToolCalls, stats.Commits)
+	if stats.AverageTime > 0 {
+		fmt.Fprintf(&b, "Average per completed item: %s\n", formatTodoDuration(stats.AverageTime))
+	}
+	fmt.Fprintf(&b, "Velocity: %.2f items/day\n", stats.Velocity)
+	if stats.Estimate > 0 {
+		fmt.Fprintf(&b, "Estimated time to finish: %s\n", formatTodoDuration(stats.Estimate))
+	}
+
+	if len(stats.Burndown) > 0 {
+		b.WriteString("\n### Burndown\n\n")
+		for _, day := range stats.Burndown {
+			fmt.Fprintf(&b, "%s  %s %d done, %d left\n", day.Date, strings.Repeat("#", day.Completed), day.Completed, day.Remaining)
+		}
+	}
+	if len(stats.Items) > 0 {
+		b.WriteString("\n### Time per item\n\n")
+		for _, item := range stats.Items {
+			fmt.Fprintf(&b, "- %s [%s] %s, %d tool calls, %d commits\n",
+				item.Content, item.Status, formatTodoDuration(item.TimeSpent), item.ToolCalls, item.Commits)
+		}
+	}
+	return b.String()
+}
+
+// formatTodoDuration rounds a duration to what matters for work on a task
+func formatTodoDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	case d >= time.Hour:
+		return d.Round(time.Minute).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
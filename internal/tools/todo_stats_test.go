@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTodoItemTimeTracking(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	item := TodoItem{ID: "task_a", Status: "pending"}
+
+	item.setStatus("in_progress", start)
+	item.setStatus("pending", start.Add(20*time.Minute))
+	item.setStatus("in_progress", start.Add(time.Hour))
+	if got := item.timeInProgress(start.Add(70 * time.Minute)); got != 30*time.Minute {
+		t.Errorf("timeInProgress() while in progress = %v, want 30m", got)
+	}
+	// Setting in_progress again does not restart the clock
+	item.setStatus("in_progress", start.Add(80*time.Minute))
+	item.setStatus("completed", start.Add(90*time.Minute))
+	if item.TimeSpent != 50*time.Minute || item.StartedAt != nil {
+		t.Errorf("after completing: TimeSpent = %v, StartedAt = %v; want 50m, nil", item.TimeSpent, item.StartedAt)
+	}
+	if item.CompletedAt == nil || !item.CompletedAt.Equal(start.Add(90*time.Minute)) {
+		t.Errorf("CompletedAt = %v", item.CompletedAt)
+	}
+	item.setStatus("pending", start.Add(2*time.Hour))
+	if item.CompletedAt != nil {
+		t.Errorf("reopened item keeps CompletedAt = %v", item.CompletedAt)
+	}
+
+	// The clock stops when the session ends, and the item stays in progress
+	item.setStatus("in_progress", start.Add(3*time.Hour))
+	item.pause(start.Add(190 * time.Minute))
+	if got := item.timeInProgress(start.Add(48 * time.Hour)); item.Status != "in_progress" || got != time.Hour {
+		t.Errorf("after pausing: status %q, timeInProgress() = %v; want in_progress, 1h", item.Status, got)
+	}
+}
+
+func TestRecordInProgress(t *testing.T) {
+	m := NewTodoManager()
+	if m.recordInProgress(func(*TodoItem) {}) {
+		t.Error("recordInProgress() without a plan = true")
+	}
+	m.plans["p"] = &TodoPlan{ID: "p", Items: []TodoItem{
+		{ID: "a", Status: "in_progress"},
+		{ID: "b", Status: "pending"},
+	}}
+	m.currentPlanID = "p"
+	count := func(item *TodoItem) {
+		if item.ToolCalls == nil {
+			item.ToolCalls = make(map[string]int)
+		}
+		item.ToolCalls["execute"]++
+	}
+	if !m.recordInProgress(count) || !m.recordInProgress(count) {
+		t.Fatal("recordInProgress() with an item in progress = false")
+	}
+	if got := m.plans["p"].Items; got[0].ToolCalls["execute"] != 2 || got[1].ToolCalls != nil {
+		t.Errorf("tool calls = %v, %v; want 2 on the in-progress item only", got[0].ToolCalls, got[1].ToolCalls)
+	}
+}
+
+func TestTodoPlanStats(t *testing.T) {
+	day := func(d int, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.Local) }
+	at := func(t time.Time) *time.Time { return &t }
+	plan := &TodoPlan{
+		ID:        "p",
+		Name:      "Migration",
+		CreatedAt: day(1, 9),
+		Items: []TodoItem{
+			{ID: "a", Content: "Schema", Status: "completed", CompletedAt: at(day(1, 12)), TimeSpent: 2 * time.Hour, ToolCalls: map[string]int{"execute": 3, "fileWrite": 1}, Commits: []string{"abc123"}},
+			{ID: "b", Content: "Backfill", Status: "completed", CompletedAt: at(day(2, 15)), TimeSpent: 4 * time.Hour},
+			{ID: "c", Content: "Switch reads", Status: "completed", CompletedAt: at(day(2, 17))},
+			{ID: "d", Content: "Drop old table", Status: "in_progress", StartedAt: at(day(5, 7)), TimeSpent: time.Hour},
+			{ID: "e", Content: "Docs", Status: "pending"},
+			{ID: "f", Content: "Old plan", Status: "cancelled"},
+		},
+	}
+	stats := plan.Stats(day(5, 9))
+
+	if stats.Completed != 3 || stats.InProgress != 1 || stats.Pending != 1 || stats.Cancelled != 1 {
+		t.Errorf("counts = %d/%d/%d/%d", stats.Completed, stats.InProgress, stats.Pending, stats.Cancelled)
+	}
+	if stats.TimeSpent != 9*time.Hour || stats.AverageTime != 3*time.Hour {
+		t.Errorf("TimeSpent = %v, AverageTime = %v; want 9h, 3h", stats.TimeSpent, stats.AverageTime)
+	}
+	if stats.ToolCalls != 4 || stats.Commits != 1 {
+		t.Errorf("ToolCalls = %d, Commits = %d", stats.ToolCalls, stats.Commits)
+	}
+	// 3 items in 4 days, so the 2 open ones take about 2.7 days
+	if stats.Velocity != 0.75 {
+		t.Errorf("Velocity = %v, want 0.75", stats.Velocity)
+	}
+	if want := time.Duration(2 / 0.75 * 24 * float64(time.Hour)); stats.Estimate != want {
+		t.Errorf("Estimate = %v, want %v", stats.Estimate, want)
+	}
+	wantBurndown := []TodoDay{{Date: "2026-03-01", Completed: 1, Remaining: 4}, {Date: "2026-03-02", Completed: 2, Remaining: 2}}
+	if len(stats.Burndown) != len(wantBurndown) {
+		t.Fatalf("Burndown = %+v, want %+v", stats.Burndown, wantBurndown)
+	}
+	for i, d := range wantBurndown {
+		if stats.Burndown[i] != d {
+			t.Errorf("Burndown[%d] = %+v, want %+v", i, stats.Burndown[i], d)
+		}
+	}
+	if len(stats.Items) != 3 || stats.Items[0].ID != "b" || stats.Items[2].ID != "a" {
+		t.Errorf("Items = %+v, want b, d, a by time spent", stats.Items)
+	}
+
+	out := FormatTodoStats(stats)
+	for _, want := range []string{"3 completed, 1 in progress", "Velocity: 0.75 items/day", "2026-03-02  ## 2 done, 2 left", "- Backfill [completed] 4h0m0s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatTodoStats() is missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EditTransaction stages edits to several files so they can be reviewed as one diff
+// and applied together. Either every file is written or none is.
+type EditTransaction struct {
+	edits map[string]*stagedEdit
+}
+
+// stagedEdit is a pending change to a single file
+type stagedEdit struct {
+	original string
+	existed  bool
+	mode     os.FileMode
+	content  string
+	deleted  bool
+}
+
+// NewEditTransaction creates an empty edit transaction
+func NewEditTransaction() *EditTransaction {
+	return &EditTransaction{edits: make(map[string]*stagedEdit)}
+}
+
+// Stage records new content for path. Staging the same path again replaces the pending content.
+func (tx *EditTransaction) Stage(path, content string) error {
+	if edit, ok := tx.edits[path]; ok {
+		edit.content = content
+		edit.deleted = false
+		return nil
+	}
+
+	edit := &stagedEdit{mode: 0644, content: content}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		edit.original = string(data)
+		edit.existed = true
+		edit.mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	tx.edits[path] = edit
+	return nil
+}
+
+// StageDelete stages removing path. Deleting a file that doesn't exist is a no-op.
+func (tx *EditTransaction) StageDelete(path string) error {
+	if err := tx.Stage(path, ""); err != nil {
+		return err
+	}
+	edit := tx.edits[path]
+	if !edit.existed {
+		delete(tx.edits, path)
+		return nil
+	}
+	edit.deleted = true
+	return nil
+}
+
+// StageReplace stages replacing exactly one occurrence of oldText in path,
+// taking earlier staged edits to the same file into account
+func (tx *EditTransaction) StageReplace(path, oldText, newText string) error {
+	if oldText == "" {
+		return fmt.Errorf("text to replace in %s must not be empty", path)
+	}
+
+	current, ok := tx.Content(path)
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		current = string(data)
+	}
+
+	switch n := strings.Count(current, oldText); n {
+	case 0:
+		return fmt.Errorf("text to replace not found in %s", path)
+	case 1:
+		return tx.Stage(path, strings.Replace(current, oldText, newText, 1))
+	default:
+		return fmt.Errorf("text to replace occurs %d times in %s, include more context to make it unique", n, path)
+	}
+}
+
+// Content returns the pending content for path, if it has been staged
+func (tx *EditTransaction) Content(path string) (string, bool) {
+	edit, ok := tx.edits[path]
+	if !ok || edit.deleted {
+		return "", false
+	}
+	return edit.content, true
+}
+
+// Original returns the content path had when it was first staged, if it existed
+func (tx *EditTransaction) Original(path string) (string, bool) {
+	edit, ok := tx.edits[path]
+	if !ok || !edit.existed {
+		return "", false
+	}
+	return edit.original, true
+}
+
+// Files returns the staged paths that would change, sorted
+func (tx *EditTransaction) Files() []string {
+	var files []string
+	for path, edit := range tx.edits {
+		if !edit.existed || edit.deleted || edit.original != edit.content {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Diff renders a combined diff of all staged changes
+func (tx *EditTransaction) Diff() string {
+	files := tx.Files()
+	if len(files) == 0 {
+		return "No changes staged"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d files will be changed\n", len(files))
+	for _, path := range files {
+		edit := tx.edits[path]
+		switch {
+		case edit.deleted:
+			fmt.Fprintf(&b, "\n--- %s (deleted)\n", path)
+		case edit.existed:
+			fmt.Fprintf(&b, "\n--- %s\n", path)
+		default:
+			fmt.Fprintf(&b, "\n--- %s (new file)\n", path)
+		}
+		b.WriteString(GenerateDiff(edit.original, edit.content, 2))
+	}
+	return b.String()
+}
+
+// Commit writes all staged changes. Every file is first written to a temporary file
+// beside it and the temporaries are then renamed into place; deleted files are removed
+// last. If anything fails, files already replaced or removed are restored and new files
+// removed, so the tree is left unchanged.
+func (tx *EditTransaction) Commit() error {
+	var files, deleted []string
+	for _, path := range tx.Files() {
+		if tx.edits[path].deleted {
+			deleted = append(deleted, path)
+		} else {
+			files = append(files, path)
+		}
+	}
+
+	temps := make(map[string]string, len(files))
+	cleanup := func() {
+		for _, tmp := range temps {
+			os.Remove(tmp)
+		}
+	}
+
+	// Stage every file before touching any originals
+	var createdDirs []string
+	for _, path := range files {
+		edit := tx.edits[path]
+
+		dir := filepath.Dir(path)
+		if top := topMissingDir(dir); top != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				cleanup()
+				removeDirs(createdDirs)
+				return fmt.Errorf("failed to create directory %s: %w", dir, err)
+			}
+			createdDirs = append(createdDirs, top)
+		}
+
+		tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+		if err != nil {
+			cleanup()
+			removeDirs(createdDirs)
+			return fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		temps[path] = tmp.Name()
+
+		_, writeErr := tmp.WriteString(edit.content)
+		if closeErr := tmp.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr == nil {
+			writeErr = os.Chmod(tmp.Name(), edit.mode)
+		}
+		if writeErr != nil {
+			cleanup()
+			removeDirs(createdDirs)
+			return fmt.Errorf("failed to stage %s: %w", path, writeErr)
+		}
+	}
+
+	// Swap staged files into place
+	var replaced []string
+	for _, path := range files {
+		if err := os.Rename(temps[path], path); err != nil {
+			tx.rollback(replaced)
+			cleanup()
+			removeDirs(createdDirs)
+			return fmt.Errorf("failed to replace %s, all changes rolled back: %w", path, err)
+		}
+		replaced = append(replaced, path)
+		delete(temps, path)
+	}
+
+	for _, path := range deleted {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			tx.rollback(replaced)
+			removeDirs(createdDirs)
+			return fmt.Errorf("failed to delete %s, all changes rolled back: %w", path, err)
+		}
+		replaced = append(replaced, path)
+	}
+
+	return nil
+}
+
+// rollback restores files that were already replaced during a failed commit
+func (tx *EditTransaction) rollback(paths []string) {
+	for _, path := range paths {
+		edit := tx.edits[path]
+		if edit.existed {
+			os.WriteFile(path, []byte(edit.original), edit.mode)
+		} else {
+			os.Remove(path)
+		}
+	}
+}
+
+// topMissingDir returns the outermost ancestor of dir (or dir itself) that does not exist yet
+func topMissingDir(dir string) string {
+	top := ""
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return top
+		}
+		top = dir
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return top
+		}
+		dir = parent
+	}
+}
+
+// removeDirs removes directories created during a failed commit
+func removeDirs(dirs []string) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.RemoveAll(dirs[i])
+	}
+}
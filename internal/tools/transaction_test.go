@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditTransactionCommit(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	created := filepath.Join(dir, "pkg", "util", "util.go")
+	removed := filepath.Join(dir, "old.go")
+	os.WriteFile(existing, []byte("package main\n\nfunc old() {}\n"), 0600)
+	os.WriteFile(removed, []byte("package main\n"), 0644)
+
+	tx := NewEditTransaction()
+	if err := tx.StageReplace(existing, "old", "renamed"); err != nil {
+		t.Fatalf("StageReplace failed: %v", err)
+	}
+	if err := tx.Stage(created, "package util\n"); err != nil {
+		t.Fatalf("Stage failed: %v", err)
+	}
+	if err := tx.StageDelete(removed); err != nil {
+		t.Fatalf("StageDelete failed: %v", err)
+	}
+	if err := tx.StageDelete(filepath.Join(dir, "missing.go")); err != nil {
+		t.Fatalf("StageDelete of a missing file failed: %v", err)
+	}
+
+	if files := tx.Files(); len(files) != 3 {
+		t.Fatalf("expected 3 staged files, got %v", files)
+	}
+	if diff := tx.Diff(); !strings.Contains(diff, "(new file)") || !strings.Contains(diff, "old.go (deleted)") {
+		t.Errorf("diff should mark new and deleted files:\n%s", diff)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(existing)
+	if string(data) != "package main\n\nfunc renamed() {}\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if info, _ := os.Stat(existing); info.Mode().Perm() != 0600 {
+		t.Errorf("file mode not preserved: %v", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(created); string(data) != "package util\n" {
+		t.Errorf("unexpected new file content: %q", data)
+	}
+	if _, err := os.Stat(removed); !os.IsNotExist(err) {
+		t.Errorf("expected old.go to be deleted")
+	}
+}
+
+func TestEditTransactionRollback(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.txt")
+	blocked := filepath.Join(dir, "z.txt")
+	os.WriteFile(good, []byte("original"), 0644)
+
+	tx := NewEditTransaction()
+	tx.Stage(good, "changed")
+	tx.Stage(filepath.Join(dir, "new", "b.txt"), "new")
+	tx.Stage(blocked, "fails")
+
+	// A directory appearing after staging makes the last rename fail,
+	// after the other files have already been swapped into place
+	os.MkdirAll(filepath.Join(blocked, "child"), 0755)
+
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expected commit to fail")
+	}
+
+	if data, _ := os.ReadFile(good); string(data) != "original" {
+		t.Errorf("expected a.txt to be untouched, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+		t.Errorf("expected created directory to be removed")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestEditTransactionStageReplaceErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	os.WriteFile(path, []byte("a b a"), 0644)
+
+	tx := NewEditTransaction()
+	if err := tx.StageReplace(path, "a", "x"); err == nil {
+		t.Error("expected error for ambiguous replacement")
+	}
+	if err := tx.StageReplace(path, "zzz", "x"); err == nil {
+		t.Error("expected error for missing text")
+	}
+	if err := tx.StageReplace(filepath.Join(dir, "missing.txt"), "a", "x"); err == nil {
+		t.Error("expected error for missing file")
+	}
+	if len(tx.Files()) != 0 {
+		t.Errorf("failed replacements should not stage changes, got %v", tx.Files())
+	}
+}
+
+func TestMultiEditTool(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("hello world"), 0644)
+	os.WriteFile(b, []byte("keep me"), 0644)
+
+	tool := NewMultiEditTool()
+	params := map[string]interface{}{
+		"edits": []interface{}{
+			map[string]interface{}{"file_path": a, "old_string": "world", "new_string": "there"},
+			map[string]interface{}{"file_path": b, "old_string": "not present", "new_string": "x"},
+		},
+	}
+
+	// One bad edit rejects the whole change
+	if _, err := tool.Execute(context.Background(), params); err == nil {
+		t.Fatal("expected error for edit that does not apply")
+	}
+	if data, _ := os.ReadFile(a); string(data) != "hello world" {
+		t.Errorf("a.txt changed despite failed edit set: %q", data)
+	}
+
+	params["edits"].([]interface{})[1] = map[string]interface{}{"file_path": b, "content": "replaced"}
+	if _, err := tool.Execute(context.Background(), params); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "hello there" {
+		t.Errorf("unexpected a.txt: %q", data)
+	}
+	if data, _ := os.ReadFile(b); string(data) != "replaced" {
+		t.Errorf("unexpected b.txt: %q", data)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"codezilla/internal/cli"
+	"codezilla/internal/i18n"
+	"codezilla/pkg/style"
 
 	"golang.org/x/term"
 )
@@ -23,8 +25,8 @@ type BaseUI struct {
 	width        int
 }
 
-// NewBaseUI creates a new base UI
-func NewBaseUI(historyFile string) (UI, error) {
+// NewBaseUI creates a new base UI keeping up to historyMax input history entries
+func NewBaseUI(historyFile string, historyMax int) (UI, error) {
 	// Get terminal width
 	width, _, _ := term.GetSize(int(os.Stdout.Fd()))
 	if width == 0 {
@@ -35,6 +37,7 @@ func NewBaseUI(historyFile string) (UI, error) {
 	reader, err := cli.NewFixedInput(
 		"", // Prompt will be set by theme
 		historyFile,
+		historyMax,
 	)
 	if err != nil {
 		return nil, err
@@ -43,7 +46,7 @@ func NewBaseUI(historyFile string) (UI, error) {
 	ui := &BaseUI{
 		theme:  DefaultTheme(),
 		reader: reader,
-		writer: bufio.NewWriter(os.Stdout),
+		writer: bufio.NewWriter(style.NewWriter(os.Stdout)),
 		width:  width,
 	}
 
@@ -88,29 +91,24 @@ func (ui *BaseUI) ShowBanner() {
                                          
 `
 	fmt.Fprint(ui.writer, ui.theme.ColorCyan+banner+ui.theme.ColorReset)
-	fmt.Fprintln(ui.writer, ui.theme.ColorBold+"AI-Powered Coding Assistant"+ui.theme.ColorReset)
+	fmt.Fprintln(ui.writer, ui.theme.ColorBold+i18n.T("ui.tagline")+ui.theme.ColorReset)
 	fmt.Fprintln(ui.writer, strings.Repeat("─", ui.width))
 	ui.writer.Flush()
 }
 
 // ShowWelcome displays the welcome message
 func (ui *BaseUI) ShowWelcome(model, ollamaURL string, contextEnabled bool) {
-	ui.Print("%sWelcome!%s Type %s/help%s for commands or start chatting.\n",
-		ui.theme.ColorBold, ui.theme.ColorReset,
-		ui.theme.ColorYellow, ui.theme.ColorReset)
-	ui.Print("Press %sEnter%s to submit your message.\n",
-		ui.theme.ColorYellow, ui.theme.ColorReset)
-	ui.Print("Using model: %s%s%s\n",
-		ui.theme.ColorYellow, model, ui.theme.ColorReset)
-	ui.Print("Ollama URL: %s%s%s\n",
-		ui.theme.ColorDim, ollamaURL, ui.theme.ColorReset)
+	ui.Print("%s%s%s %s\n",
+		ui.theme.ColorBold, i18n.T("ui.welcome"), ui.theme.ColorReset,
+		i18n.T("ui.welcome_hint", ui.theme.ColorYellow+"/help"+ui.theme.ColorReset))
+	ui.Println("%s", i18n.T("ui.enter_hint", ui.theme.ColorYellow+i18n.T("ui.enter")+ui.theme.ColorReset))
+	ui.Println("%s", i18n.T("ui.using_model", ui.theme.ColorYellow+model+ui.theme.ColorReset))
+	ui.Println("%s", i18n.T("ui.ollama_url", ui.theme.ColorDim+ollamaURL+ui.theme.ColorReset))
 
 	if contextEnabled {
-		ui.Print("Context retention: %sEnabled%s\n",
-			ui.theme.ColorGreen, ui.theme.ColorReset)
+		ui.Println("%s", i18n.T("ui.context_retention", ui.theme.ColorGreen+i18n.T("ui.enabled")+ui.theme.ColorReset))
 	} else {
-		ui.Print("Context retention: %sDisabled%s (use /context on to enable)\n",
-			ui.theme.ColorDim, ui.theme.ColorReset)
+		ui.Println("%s", i18n.T("ui.context_retention", ui.theme.ColorDim+i18n.T("ui.disabled")+ui.theme.ColorReset)+" "+i18n.T("ui.context_enable_hint"))
 	}
 	ui.Println("")
 }
@@ -178,8 +176,12 @@ func (ui *BaseUI) ShowThinking() {
 				ui.Print("\r%s\r", strings.Repeat(" ", 20))
 				return
 			default:
-				ui.Print("\r%s%s Thinking...%s",
-					ui.theme.ColorCyan, chars[i%len(chars)], ui.theme.ColorReset)
+				status := i18n.T("ui.thinking") + "..."
+				if n := ui.queuedCount(); n > 0 {
+					status += " " + i18n.T("ui.queued", n)
+				}
+				ui.Print("\r%s%s %s%s",
+					ui.theme.ColorCyan, chars[i%len(chars)], status, ui.theme.ColorReset)
 				i++
 				time.Sleep(100 * time.Millisecond)
 			}
@@ -200,7 +202,7 @@ func (ui *BaseUI) HideThinking() {
 
 // ShowResponse displays an AI response
 func (ui *BaseUI) ShowResponse(response string) {
-	ui.Println("\n%sAssistant:%s", ui.theme.ColorGreen, ui.theme.ColorReset)
+	ui.Println("\n%s%s%s", ui.theme.ColorGreen, i18n.T("ui.assistant"), ui.theme.ColorReset)
 
 	// Process response for code blocks
 	lines := strings.Split(response, "\n")
@@ -232,39 +234,61 @@ func (ui *BaseUI) ShowCode(language, code string) {
 	ui.Println("%s```%s", ui.theme.ColorPurple, ui.theme.ColorReset)
 }
 
+// helpCommands are the slash commands listed by /help, with the message describing each
+var helpCommands = []struct {
+	cmd, key string
+}{
+	{"/help, /h", "help.help"},
+	{"/exit, /quit, /q", "help.exit"},
+	{"/clear, /c", "help.clear"},
+	{"/models", "help.models"},
+	{"/model [name]", "help.model"},
+	{"/context [on|off|clear|show]", "help.context"},
+	{"/tools", "help.tools"},
+	{"/notes [clear]", "help.notes"},
+	{"/search <query>", "help.search"},
+	{"/snippet [list|add|show|use|remove]", "help.snippet"},
+	{"/prompt [show|add|remove|use]", "help.prompt"},
+	{"/apply", "help.apply"},
+	{"/save-code [n path]", "help.save_code"},
+	{"/summarize [replace]", "help.summarize"},
+	{"/todo [list|stats] [plan-id]", "help.todo"},
+	{"/dryrun [on|off]", "help.dryrun"},
+	{"/verbose [quiet|normal|trace]", "help.verbose"},
+	{"/shadow <task>", "help.shadow"},
+	{"/task [title]", "help.task"},
+	{"/finish", "help.finish"},
+	{"/work <issue>", "help.work"},
+	{"/reset", "help.reset"},
+	{"/sessions [resume|rename|delete]", "help.sessions"},
+	{"/rename <title>", "help.rename"},
+	{"/checkpoint [name]", "help.checkpoint"},
+	{"/fork <checkpoint>", "help.fork"},
+	{"/share [md|html] [file]", "help.share"},
+	{"/dashboard [days]", "help.dashboard"},
+	{"/keys", "help.keys"},
+	{"/edit [text]", "help.edit"},
+}
+
 // ShowHelp displays help information
 func (ui *BaseUI) ShowHelp() {
-	ui.Println("\n%sAvailable Commands:%s", ui.theme.ColorBold, ui.theme.ColorReset)
-
-	commands := []struct {
-		cmd  string
-		desc string
-	}{
-		{"/help, /h", "Show this help"},
-		{"/exit, /quit, /q", "Exit the application"},
-		{"/clear, /c", "Clear the screen"},
-		{"/models", "List available models"},
-		{"/model [name]", "Show or change model"},
-		{"/context [on|off|clear|show]", "Manage context"},
-		{"/tools", "Show available tools"},
-		{"/reset", "Reset conversation"},
-	}
+	ui.Println("\n%s%s%s", ui.theme.ColorBold, i18n.T("ui.commands"), ui.theme.ColorReset)
 
-	for _, cmd := range commands {
+	for _, cmd := range helpCommands {
 		ui.Print("  %s%-30s%s %s\n",
-			ui.theme.ColorYellow, cmd.cmd, ui.theme.ColorReset, cmd.desc)
+			ui.theme.ColorYellow, cmd.cmd, ui.theme.ColorReset, i18n.T(cmd.key))
 	}
 	ui.Println("")
 }
 
 // ShowModels displays available models
 func (ui *BaseUI) ShowModels(models []string, current string) {
-	ui.Println("\n%sAvailable Models:%s", ui.theme.ColorBold, ui.theme.ColorReset)
+	ui.Println("\n%s%s%s", ui.theme.ColorBold, i18n.T("ui.models"), ui.theme.ColorReset)
 
 	for _, model := range models {
 		if model == current {
-			ui.Print("  %s*%s %s (current)\n",
-				ui.theme.ColorGreen, ui.theme.ColorReset, model)
+			ui.Print("  %s*%s %s (%s)\n",
+				ui.theme.ColorGreen, ui.theme.ColorReset, model, i18n.T("ui.current"))
 		} else {
 			ui.Println("    %s", model)
 		}
@@ -274,7 +298,7 @@ func (ui *BaseUI) ShowModels(models []string, current string) {
 
 // ShowTools displays available tools
 func (ui *BaseUI) ShowTools(tools []ToolInfo) {
-	ui.Println("\n%sAvailable Tools:%s", ui.theme.ColorBold, ui.theme.ColorReset)
+	ui.Println("\n%s%s%s", ui.theme.ColorBold, i18n.T("ui.tools"), ui.theme.ColorReset)
 
 	for _, tool := range tools {
 		permColor := ui.theme.ColorYellow
@@ -294,9 +318,9 @@ func (ui *BaseUI) ShowTools(tools []ToolInfo) {
 
 // ShowContext displays conversation context
 func (ui *BaseUI) ShowContext(context string) {
-	ui.Println("\n%sConversation Context:%s", ui.theme.ColorBold, ui.theme.ColorReset)
+	ui.Println("\n%s%s%s", ui.theme.ColorBold, i18n.T("ui.context"), ui.theme.ColorReset)
 	if context == "" {
-		ui.Println("No context stored")
+		ui.Println("%s", i18n.T("ui.no_context"))
 	} else {
 		ui.Println(context)
 	}
@@ -312,6 +336,37 @@ func (ui *BaseUI) ReadLine() (string, error) {
 	return ui.reader.ReadLine()
 }
 
+// ReadAnswer reads the answer to a question, such as a permission request. Lines
+// queued meanwhile stay queued, and the answer isn't added to the history.
+func (ui *BaseUI) ReadAnswer() (string, error) {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		return fixedInput.ReadAnswer()
+	}
+	return ui.reader.ReadLine()
+}
+
+// StartQueue collects lines typed while a prompt is processed
+func (ui *BaseUI) StartQueue(onQueue func(line string, queued int)) {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		fixedInput.StartQueue(onQueue)
+	}
+}
+
+// StopQueue stops collecting lines, keeping those queued for ReadLine
+func (ui *BaseUI) StopQueue() {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		fixedInput.StopQueue()
+	}
+}
+
+// queuedCount returns the number of lines waiting in the queue
+func (ui *BaseUI) queuedCount() int {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		return len(fixedInput.Queued())
+	}
+	return 0
+}
+
 // ReadPassword reads a password without echoing
 func (ui *BaseUI) ReadPassword(prompt string) (string, error) {
 	ui.Print(prompt)
@@ -330,20 +385,19 @@ func (ui *BaseUI) ReadPassword(prompt string) (string, error) {
 // Confirm asks for yes/no confirmation
 func (ui *BaseUI) Confirm(prompt string) (bool, error) {
 	for {
-		ui.Print("%s (y/n): ", prompt)
-		response, err := ui.ReadLine()
+		ui.Print("%s %s: ", prompt, i18n.T("confirm.suffix"))
+		response, err := ui.ReadAnswer()
 		if err != nil {
 			return false, err
 		}
 
-		response = strings.ToLower(strings.TrimSpace(response))
-		switch response {
-		case "y", "yes":
+		switch {
+		case i18n.IsYes(response):
 			return true, nil
-		case "n", "no":
+		case i18n.IsNo(response):
 			return false, nil
 		default:
-			ui.Warning("Please answer 'y' or 'n'")
+			ui.Warning("%s", i18n.T("confirm.retry"))
 		}
 	}
 }
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"codezilla/internal/i18n"
 )
 
 // FancyUI implements a fancy UI with animations and extra visual elements
@@ -15,8 +17,8 @@ type FancyUI struct {
 }
 
 // NewFancyUI creates a fancy UI implementation
-func NewFancyUI(historyFile string) (UI, error) {
-	baseUI, err := NewBaseUI(historyFile)
+func NewFancyUI(historyFile string, historyMax int) (UI, error) {
+	baseUI, err := NewBaseUI(historyFile, historyMax)
 	if err != nil {
 		return nil, err
 	}
@@ -58,12 +60,10 @@ func (ui *FancyUI) ShowBanner() {
 		// Add sparkles on last line
 		if i == len(banner)-1 {
 			ui.Print("\n%s✨ ", ui.theme.ColorYellow)
-			time.Sleep(50 * time.Millisecond)
-			ui.Print("AI-Powered ")
-			time.Sleep(50 * time.Millisecond)
-			ui.Print("Coding ")
-			time.Sleep(50 * time.Millisecond)
-			ui.Print("Assistant ")
+			for _, word := range strings.Fields(i18n.T("ui.tagline")) {
+				time.Sleep(50 * time.Millisecond)
+				ui.Print("%s ", word)
+			}
 			time.Sleep(50 * time.Millisecond)
 			ui.Println("✨%s", ui.theme.ColorReset)
 		}
@@ -90,7 +90,7 @@ func (ui *FancyUI) ShowBanner() {
 // ShowWelcome displays an enhanced welcome message
 func (ui *FancyUI) ShowWelcome(model, ollamaURL string, contextEnabled bool) {
 	// Animated welcome
-	welcome := "Welcome to Codezilla!"
+	welcome := i18n.T("ui.welcome_to")
 	ui.Print("%s", ui.theme.ColorBold)
 	for _, char := range welcome {
 		ui.Print("%c", char)
@@ -98,30 +98,25 @@ func (ui *FancyUI) ShowWelcome(model, ollamaURL string, contextEnabled bool) {
 	}
 	ui.Println("%s", ui.theme.ColorReset)
 
-	ui.Print("Type %s/help%s for commands or start chatting.\n",
-		ui.theme.ColorYellow, ui.theme.ColorReset)
+	ui.Println("%s", i18n.T("ui.welcome_hint", ui.theme.ColorYellow+"/help"+ui.theme.ColorReset))
 
 	// Model info with icon
-	ui.Print("🧠 Model: %s%s%s\n",
-		ui.theme.ColorYellow, model, ui.theme.ColorReset)
+	ui.Println("🧠 %s", i18n.T("ui.model", ui.theme.ColorYellow+model+ui.theme.ColorReset))
 
 	// Connection info with icon
-	ui.Print("🔌 Ollama: %s%s%s\n",
+	ui.Println("🔌 Ollama: %s%s%s",
 		ui.theme.ColorDim, ollamaURL, ui.theme.ColorReset)
 
 	// Context status with appropriate icon
 	if contextEnabled {
-		ui.Print("💾 Context: %sEnabled%s %s\n",
-			ui.theme.ColorGreen, ui.theme.ColorReset, "✓")
+		ui.Println("💾 %s %s", i18n.T("ui.context_status", ui.theme.ColorGreen+i18n.T("ui.enabled")+ui.theme.ColorReset), "✓")
 	} else {
-		ui.Print("💾 Context: %sDisabled%s\n",
-			ui.theme.ColorDim, ui.theme.ColorReset)
+		ui.Println("💾 %s", i18n.T("ui.context_status", ui.theme.ColorDim+i18n.T("ui.disabled")+ui.theme.ColorReset))
 	}
 
 	// Working directory info
 	cwd, _ := os.Getwd()
-	ui.Print("📁 Working Directory: %s%s%s\n",
-		ui.theme.ColorCyan, cwd, ui.theme.ColorReset)
+	ui.Println("📁 %s", i18n.T("ui.working_directory", ui.theme.ColorCyan+cwd+ui.theme.ColorReset))
 
 	ui.Println("")
 }
@@ -141,19 +136,11 @@ func (ui *FancyUI) ShowThinking() {
 	go func() {
 		defer ui.spinnerWg.Done()
 
-		frames := []string{
-			"🤔 Thinking",
-			"🤔 Thinking.",
-			"🤔 Thinking..",
-			"🤔 Thinking...",
-			"💭 Processing",
-			"💭 Processing.",
-			"💭 Processing..",
-			"💭 Processing...",
-			"🧠 Analyzing",
-			"🧠 Analyzing.",
-			"🧠 Analyzing..",
-			"🧠 Analyzing...",
+		var frames []string
+		for _, step := range []string{"🤔 " + i18n.T("ui.thinking"), "💭 " + i18n.T("ui.processing"), "🧠 " + i18n.T("ui.analyzing")} {
+			for dots := 0; dots <= 3; dots++ {
+				frames = append(frames, step+strings.Repeat(".", dots))
+			}
 		}
 
 		i := 0
@@ -211,7 +198,7 @@ func (ui *FancyUI) HideThinking() {
 func (ui *FancyUI) ShowResponse(response string) {
 	// Move to a new line first to avoid overwriting issues
 	ui.Println("")
-	ui.Println("%s🤖 Assistant:%s", ui.theme.ColorGreen, ui.theme.ColorReset)
+	ui.Println("%s🤖 %s%s", ui.theme.ColorGreen, i18n.T("ui.assistant"), ui.theme.ColorReset)
 
 	// Typing effect for first line
 	lines := strings.Split(response, "\n")
@@ -51,9 +51,16 @@ type UI interface {
 
 	// Input methods
 	ReadLine() (string, error)
+	// ReadAnswer reads the answer to a question, without a prompt or history
+	ReadAnswer() (string, error)
 	ReadPassword(prompt string) (string, error)
 	Confirm(prompt string) (bool, error)
 
+	// StartQueue lets the user submit lines while a prompt is processed; ReadLine
+	// returns them in order once StopQueue is called. onQueue announces each one.
+	StartQueue(onQueue func(line string, queued int))
+	StopQueue()
+
 	// Theme management
 	GetTheme() Theme
 	SetTheme(theme Theme)
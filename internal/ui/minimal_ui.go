@@ -3,45 +3,50 @@ package ui
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"codezilla/internal/cli"
+	"codezilla/internal/i18n"
+	"codezilla/pkg/style"
+
 	"golang.org/x/term"
 )
 
 // MinimalUI implements a minimal UI with no colors or fancy formatting
 type MinimalUI struct {
 	reader cli.InputReader
+	writer io.Writer
 }
 
 // NewMinimalUI creates a minimal UI implementation
-func NewMinimalUI(historyFile string) (UI, error) {
-	reader, err := cli.NewFixedInput("> ", historyFile)
+func NewMinimalUI(historyFile string, historyMax int) (UI, error) {
+	reader, err := cli.NewFixedInput("> ", historyFile, historyMax)
 	if err != nil {
 		return nil, err
 	}
 
-	return &MinimalUI{reader: reader}, nil
+	return &MinimalUI{reader: reader, writer: style.NewWriter(os.Stdout)}, nil
 }
 
 func (ui *MinimalUI) Clear() {
 	// Simple clear - just add some blank lines
-	fmt.Print("\n\n\n")
+	fmt.Fprint(ui.writer, "\n\n\n")
 }
 
 func (ui *MinimalUI) ShowBanner() {
-	fmt.Println()
-	fmt.Println("CODEZILLA - AI-Powered Coding Assistant")
-	fmt.Println("========================================")
-	fmt.Println()
+	fmt.Fprintln(ui.writer)
+	fmt.Fprintln(ui.writer, "CODEZILLA - "+i18n.T("ui.tagline"))
+	fmt.Fprintln(ui.writer, "========================================")
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ShowWelcome(model, ollamaURL string, contextEnabled bool) {
-	fmt.Println("Welcome! Type /help for commands.")
-	fmt.Printf("Model: %s\n", model)
-	fmt.Printf("Context: %s\n", map[bool]string{true: "enabled", false: "disabled"}[contextEnabled])
-	fmt.Println()
+	fmt.Fprintln(ui.writer, i18n.T("ui.welcome"), i18n.T("ui.welcome_hint", "/help"))
+	fmt.Fprintln(ui.writer, i18n.T("ui.model", model))
+	fmt.Fprintln(ui.writer, i18n.T("ui.context_status", map[bool]string{true: i18n.T("ui.enabled"), false: i18n.T("ui.disabled")}[contextEnabled]))
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ShowPrompt() string {
@@ -49,106 +54,109 @@ func (ui *MinimalUI) ShowPrompt() string {
 }
 
 func (ui *MinimalUI) Print(format string, args ...interface{}) {
-	fmt.Printf(format, args...)
+	fmt.Fprintf(ui.writer, format, args...)
 }
 
 func (ui *MinimalUI) Println(format string, args ...interface{}) {
-	fmt.Printf(format+"\n", args...)
+	fmt.Fprintf(ui.writer, format+"\n", args...)
 }
 
 func (ui *MinimalUI) Success(format string, args ...interface{}) {
-	fmt.Printf("[OK] "+format+"\n", args...)
+	fmt.Fprintf(ui.writer, "[OK] "+format+"\n", args...)
 }
 
 func (ui *MinimalUI) Error(format string, args ...interface{}) {
-	fmt.Printf("[ERROR] "+format+"\n", args...)
+	fmt.Fprintf(ui.writer, "[ERROR] "+format+"\n", args...)
 }
 
 func (ui *MinimalUI) Warning(format string, args ...interface{}) {
-	fmt.Printf("[WARN] "+format+"\n", args...)
+	fmt.Fprintf(ui.writer, "[WARN] "+format+"\n", args...)
 }
 
 func (ui *MinimalUI) Info(format string, args ...interface{}) {
-	fmt.Printf("[INFO] "+format+"\n", args...)
+	fmt.Fprintf(ui.writer, "[INFO] "+format+"\n", args...)
 }
 
 func (ui *MinimalUI) ShowThinking() {
-	fmt.Print("Thinking...")
+	fmt.Fprint(ui.writer, i18n.T("ui.thinking")+"...")
 }
 
 func (ui *MinimalUI) HideThinking() {
-	fmt.Print("\r            \r")
+	fmt.Fprint(ui.writer, "\r"+strings.Repeat(" ", 20)+"\r")
 }
 
 func (ui *MinimalUI) ShowResponse(response string) {
-	fmt.Println("\nAssistant:")
-	fmt.Println(response)
-	fmt.Println()
+	fmt.Fprintln(ui.writer, "\n"+i18n.T("ui.assistant"))
+	fmt.Fprintln(ui.writer, response)
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ShowCode(language, code string) {
-	fmt.Printf("--- %s ---\n", language)
-	fmt.Print(code)
+	fmt.Fprintf(ui.writer, "--- %s ---\n", language)
+	fmt.Fprint(ui.writer, code)
 	if !strings.HasSuffix(code, "\n") {
-		fmt.Println()
+		fmt.Fprintln(ui.writer)
 	}
-	fmt.Println("--- end ---")
+	fmt.Fprintln(ui.writer, "--- end ---")
 }
 
 func (ui *MinimalUI) ShowHelp() {
-	fmt.Println("\nCommands:")
-	fmt.Println("  /help       - Show help")
-	fmt.Println("  /exit       - Exit")
-	fmt.Println("  /clear      - Clear screen")
-	fmt.Println("  /models     - List models")
-	fmt.Println("  /model      - Show/change model")
-	fmt.Println("  /context    - Manage context")
-	fmt.Println("  /tools      - Show tools")
-	fmt.Println()
+	fmt.Fprintf(ui.writer, "\n%s\n", i18n.T("ui.commands"))
+	for _, cmd := range helpCommands {
+		fmt.Fprintf(ui.writer, "  %-36s %s\n", cmd.cmd, i18n.T(cmd.key))
+	}
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ShowModels(models []string, current string) {
-	fmt.Println("\nModels:")
+	fmt.Fprintln(ui.writer, "\nModels:")
 	for _, model := range models {
 		if model == current {
-			fmt.Printf("  * %s\n", model)
+			fmt.Fprintf(ui.writer, "  * %s\n", model)
 		} else {
-			fmt.Printf("    %s\n", model)
+			fmt.Fprintf(ui.writer, "    %s\n", model)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ShowTools(tools []ToolInfo) {
-	fmt.Println("\nTools:")
+	fmt.Fprintln(ui.writer, "\nTools:")
 	for _, tool := range tools {
-		fmt.Printf("  - %s: %s (%s)\n", tool.Name, tool.Description, tool.Permission)
+		fmt.Fprintf(ui.writer, "  - %s: %s (%s)\n", tool.Name, tool.Description, tool.Permission)
 	}
-	fmt.Println()
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ShowContext(context string) {
-	fmt.Println("\nContext:")
+	fmt.Fprintln(ui.writer, "\nContext:")
 	if context == "" {
-		fmt.Println("  (empty)")
+		fmt.Fprintln(ui.writer, "  (empty)")
 	} else {
-		fmt.Println(context)
+		fmt.Fprintln(ui.writer, context)
 	}
-	fmt.Println()
+	fmt.Fprintln(ui.writer)
 }
 
 func (ui *MinimalUI) ReadLine() (string, error) {
 	return ui.reader.ReadLine()
 }
 
+func (ui *MinimalUI) ReadAnswer() (string, error) {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		return fixedInput.ReadAnswer()
+	}
+	return ui.reader.ReadLine()
+}
+
 func (ui *MinimalUI) ReadPassword(prompt string) (string, error) {
-	fmt.Print(prompt)
+	fmt.Fprint(ui.writer, prompt)
 	// Try to read password securely
 	fd := int(os.Stdin.Fd())
 	if term.IsTerminal(fd) {
 		// Read password without echo
 		passBytes, err := term.ReadPassword(fd)
-		fmt.Println() // Add newline after password input
+		fmt.Fprintln(ui.writer) // Add newline after password input
 		if err != nil {
 			return "", err
 		}
@@ -161,14 +169,24 @@ func (ui *MinimalUI) ReadPassword(prompt string) (string, error) {
 }
 
 func (ui *MinimalUI) Confirm(prompt string) (bool, error) {
-	fmt.Printf("%s (y/n): ", prompt)
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	fmt.Fprintf(ui.writer, "%s %s: ", prompt, i18n.T("confirm.suffix"))
+	response, err := ui.ReadAnswer()
 	if err != nil {
 		return false, err
 	}
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes", nil
+	return i18n.IsYes(response), nil
+}
+
+func (ui *MinimalUI) StartQueue(onQueue func(line string, queued int)) {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		fixedInput.StartQueue(onQueue)
+	}
+}
+
+func (ui *MinimalUI) StopQueue() {
+	if fixedInput, ok := ui.reader.(*cli.FixedInput); ok {
+		fixedInput.StopQueue()
+	}
 }
 
 func (ui *MinimalUI) GetTheme() Theme {
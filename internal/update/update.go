@@ -0,0 +1,267 @@
+// Package update replaces the running codezilla binary with the latest release,
+// verified against the release's checksums.
+package update
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultFeed is the GitHub API endpoint of the latest release
+const DefaultFeed = "https://api.github.com/repos/bahmanasadi/codezilla/releases/latest"
+
+// ChecksumsAsset is the release asset listing the SHA-256 of the binaries, in the
+// format of sha256sum
+const ChecksumsAsset = "checksums.txt"
+
+// maxBinarySize bounds the download, so a broken feed can't fill the disk
+const maxBinarySize = 512 << 20
+
+// ErrManaged is returned when the binary belongs to a package manager, which should
+// update it instead
+var ErrManaged = errors.New("installed by a package manager")
+
+// Release is a published version and its downloadable files
+type Release struct {
+	Version string            // Tag, such as v2.1.0
+	Assets  map[string]string // File name -> download URL
+}
+
+// Updater checks a release feed and installs new versions
+type Updater struct {
+	Feed   string       // URL of the latest release in the GitHub API format; DefaultFeed when empty
+	Client *http.Client // Defaults to a client with a timeout
+}
+
+// Latest fetches the latest release from the feed
+func (u *Updater) Latest(ctx context.Context) (Release, error) {
+	feed := u.Feed
+	if feed == "" {
+		feed = DefaultFeed
+	}
+	body, err := u.get(ctx, feed, 1<<20)
+	if err != nil {
+		return Release{}, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	var latest struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return Release{}, fmt.Errorf("failed to parse the release feed: %w", err)
+	}
+	if latest.TagName == "" {
+		return Release{}, errors.New("the release feed names no version")
+	}
+	release := Release{Version: latest.TagName, Assets: make(map[string]string)}
+	for _, a := range latest.Assets {
+		release.Assets[a.Name] = a.URL
+	}
+	return release, nil
+}
+
+// Install downloads the binary for this platform from release, checks it against the
+// release's checksums and swaps it in for the file at exe. The new binary is written
+// next to exe and renamed over it, so exe is never left half-written.
+func (u *Updater) Install(ctx context.Context, release Release, exe string) error {
+	if manager := Manager(exe); manager != "" {
+		return fmt.Errorf("%w: update with %s", ErrManaged, manager)
+	}
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, ok := release.Assets[name]
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s (%s)", release.Version, runtime.GOOS, runtime.GOARCH, name)
+	}
+	checksumsURL, ok := release.Assets[ChecksumsAsset]
+	if !ok {
+		return fmt.Errorf("release %s has no %s to verify the download against", release.Version, ChecksumsAsset)
+	}
+
+	checksums, err := u.get(ctx, checksumsURL, 1<<20)
+	if err != nil {
+		return fmt.Errorf("failed to download checksums: %w", err)
+	}
+	want, err := findChecksum(checksums, name)
+	if err != nil {
+		return err
+	}
+	binary, err := u.get(ctx, binaryURL, maxBinarySize)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return replace(exe, binary)
+}
+
+// replace writes binary to a temporary file next to exe and renames it over exe
+func replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".new-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(binary)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), info.Mode().Perm()|0o111)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+
+	// A running executable can't be replaced on Windows, but it can be renamed
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to move the current binary aside: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), exe); err != nil {
+			os.Rename(old, exe)
+			return fmt.Errorf("failed to install the new binary: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	return nil
+}
+
+// get downloads url, failing on error statuses and bodies over limit bytes
+func (u *Updater) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	client := u.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, limit)
+	}
+	return body, nil
+}
+
+// findChecksum returns the SHA-256 listed for name in a sha256sum file
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(checksums)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsAsset, name)
+}
+
+// AssetName returns the name of the release binary for goos and goarch, such as
+// codezilla-macos-arm64 or codezilla-windows-amd64.exe
+func AssetName(goos, goarch string) string {
+	switch goos {
+	case "darwin":
+		return "codezilla-macos-" + goarch
+	case "windows":
+		return "codezilla-windows-" + goarch + ".exe"
+	default:
+		return "codezilla-" + goos + "-" + goarch
+	}
+}
+
+// Manager returns the package manager that installed the binary at exe, judging by
+// where it lives, such as "brew upgrade codezilla", or "" for a standalone binary
+func Manager(exe string) string {
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	path := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(path, "/Cellar/") || strings.Contains(path, "/homebrew/") || strings.Contains(path, "/linuxbrew/"):
+		return "brew upgrade codezilla"
+	case strings.Contains(strings.ToLower(path), "/scoop/apps/"):
+		return "scoop update codezilla"
+	case strings.HasPrefix(path, "/nix/store/"):
+		return "your Nix configuration"
+	}
+	return ""
+}
+
+// Newer reports whether version latest is newer than current. Versions are compared
+// by their numeric parts, as in v2.10.0 > v2.9.1; a current version that isn't one,
+// such as "dev", is never older.
+func Newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses v1.2.3 into its numbers, ignoring a pre-release or build suffix
+// and the "-N-gHASH" that git describe adds after a tag
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
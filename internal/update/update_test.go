@@ -0,0 +1,144 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v2.1.0", "v2.0.0", true},
+		{"v2.10.0", "v2.9.3", true},
+		{"v2.0.1", "2.0.0", true},
+		{"v2.0.0", "v2.0.0", false},
+		{"v2.0.0", "v2.0.0-4-gdeadbee", false},
+		{"v1.9.0", "v2.0.0", false},
+		{"v2.1.0", "dev", false},
+		{"nightly", "v2.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestManager(t *testing.T) {
+	tests := map[string]string{
+		"/opt/homebrew/Cellar/codezilla/2.0.0/bin/codezilla":     "brew upgrade codezilla",
+		"/home/linuxbrew/.linuxbrew/bin/codezilla":               "brew upgrade codezilla",
+		`C:\Users\me\scoop\apps\codezilla\current\codezilla.exe`: "scoop update codezilla",
+		"/usr/local/bin/codezilla":                               "",
+	}
+	for exe, want := range tests {
+		if runtime.GOOS != "windows" && strings.Contains(exe, `\`) {
+			exe = strings.ReplaceAll(exe, `\`, "/")
+		}
+		if got := Manager(exe); got != want {
+			t.Errorf("Manager(%q) = %q, want %q", exe, got, want)
+		}
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	tests := []struct{ goos, goarch, want string }{
+		{"linux", "amd64", "codezilla-linux-amd64"},
+		{"linux", "arm64", "codezilla-linux-arm64"},
+		{"darwin", "arm64", "codezilla-macos-arm64"},
+		{"windows", "amd64", "codezilla-windows-amd64.exe"},
+	}
+	for _, tt := range tests {
+		if got := AssetName(tt.goos, tt.goarch); got != tt.want {
+			t.Errorf("AssetName(%q, %q) = %q, want %q", tt.goos, tt.goarch, got, tt.want)
+		}
+	}
+}
+
+// releaseServer serves a release feed with a binary and checksums for it
+func releaseServer(t *testing.T, binary []byte, checksum string) *httptest.Server {
+	t.Helper()
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name": "v9.0.0", "assets": [
+			{"name": %q, "browser_download_url": %q},
+			{"name": "checksums.txt", "browser_download_url": %q}]}`,
+			name, srv.URL+"/"+name, srv.URL+"/checksums.txt")
+	})
+	mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	mux.HandleFunc("/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  codezilla-other\n%s  %s\n", strings.Repeat("0", 64), checksum, name)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInstall(t *testing.T) {
+	binary := []byte("new binary")
+	sum := sha256.Sum256(binary)
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  string
+	}{
+		{"matching checksum", hex.EncodeToString(sum[:]), ""},
+		{"checksum mismatch", strings.Repeat("a", 64), "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := releaseServer(t, binary, tt.checksum)
+			exe := filepath.Join(t.TempDir(), "codezilla")
+			if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			u := &Updater{Feed: srv.URL + "/latest"}
+			release, err := u.Latest(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if release.Version != "v9.0.0" {
+				t.Errorf("Latest() version = %q, want v9.0.0", release.Version)
+			}
+
+			err = u.Install(context.Background(), release, exe)
+			got, _ := os.ReadFile(exe)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Install() error = %v, want %q", err, tt.wantErr)
+				}
+				if string(got) != "old binary" {
+					t.Errorf("binary is %q after a failed update, want it unchanged", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(binary) {
+				t.Errorf("binary is %q, want the new one", got)
+			}
+			entries, _ := os.ReadDir(filepath.Dir(exe))
+			if len(entries) != 1 {
+				t.Errorf("left %d files next to the binary, want only the binary", len(entries))
+			}
+		})
+	}
+}
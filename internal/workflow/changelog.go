@@ -0,0 +1,340 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// Changelog categories, in the order they are rendered
+const (
+	CategoryFeatures = "features"
+	CategoryFixes    = "fixes"
+	CategoryDocs     = "docs"
+	CategoryOther    = "other"
+)
+
+// changelogCategories lists categories with their section headings
+var changelogCategories = []struct {
+	Key     string
+	Heading string
+}{
+	{CategoryFeatures, "Features"},
+	{CategoryFixes, "Bug Fixes"},
+	{CategoryDocs, "Documentation"},
+	{CategoryOther, "Other Changes"},
+}
+
+// Commit is a single commit read from git history
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+}
+
+// ChangelogEntry is a commit placed into a changelog category
+type ChangelogEntry struct {
+	Hash     string `json:"hash"`
+	Category string `json:"category"`
+	Text     string `json:"text"`
+}
+
+// Changelog is a generated changelog section
+type Changelog struct {
+	Version string           `json:"version"`
+	Date    time.Time        `json:"date"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// Markdown renders the changelog as a CHANGELOG.md section
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+
+	version := c.Version
+	if version == "" {
+		version = "Unreleased"
+	}
+	fmt.Fprintf(&b, "## %s - %s\n", version, c.Date.Format("2006-01-02"))
+
+	for _, cat := range changelogCategories {
+		var lines []string
+		for _, e := range c.Entries {
+			if e.Category == cat.Key {
+				lines = append(lines, fmt.Sprintf("- %s (%s)", e.Text, shortHash(e.Hash)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n%s\n", cat.Heading, strings.Join(lines, "\n"))
+	}
+
+	return b.String()
+}
+
+// ChangelogWorkflow groups commits into a changelog using the model
+type ChangelogWorkflow struct {
+	llmClient tools.LLMClient
+	logger    *logger.Logger
+	// MaxCommits limits how many commits are sent to the model in one request; longer
+	// histories are grouped in several requests
+	MaxCommits int
+}
+
+// NewChangelogWorkflow creates a new changelog workflow
+func NewChangelogWorkflow(llmClient tools.LLMClient, logger *logger.Logger) *ChangelogWorkflow {
+	return &ChangelogWorkflow{
+		llmClient:  llmClient,
+		logger:     logger,
+		MaxCommits: 200,
+	}
+}
+
+// Generate builds a changelog for the given commits
+func (w *ChangelogWorkflow) Generate(ctx context.Context, commits []Commit, version string) (*Changelog, error) {
+	changelog := &Changelog{Version: version, Date: time.Now()}
+	if len(commits) == 0 {
+		return changelog, nil
+	}
+
+	batch := len(commits)
+	if w.MaxCommits > 0 && batch > w.MaxCommits {
+		batch = w.MaxCommits
+		w.logger.Info("Grouping changelog commits in batches", "commits", len(commits), "batch", batch)
+	}
+	for start := 0; start < len(commits); start += batch {
+		entries, err := w.group(ctx, commits[start:min(start+batch, len(commits))])
+		if err != nil {
+			return nil, err
+		}
+		changelog.Entries = append(changelog.Entries, entries...)
+	}
+	return changelog, nil
+}
+
+// group asks the model to categorize commits, falling back to their subject prefixes
+// for commits it skips
+func (w *ChangelogWorkflow) group(ctx context.Context, commits []Commit) ([]ChangelogEntry, error) {
+	var list strings.Builder
+	for i, c := range commits {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, c.Subject)
+		if body := strings.TrimSpace(c.Body); body != "" {
+			fmt.Fprintf(&list, "   %s\n", strings.ReplaceAll(body, "\n", "\n   "))
+		}
+	}
+
+	prompt := fmt.Sprintf(`Group the following commits for a release changelog.
+
+Commits:
+%s
+Format your response as JSON with a single field:
+- entries: array of objects with fields
+  - commit: number (the commit's number in the list above)
+  - category: "features", "fixes", "docs" or "other"
+  - text: string (a concise, user-facing description in imperative mood)
+
+Include every commit exactly once. Merge-only or trivial commits go into "other".`, list.String())
+
+	messages := []tools.LLMMessage{
+		{
+			Role:    "system",
+			Content: "You are a release manager writing concise changelogs. Return valid JSON only.",
+		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}
+
+	response, err := w.llmClient.GenerateResponse(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("changelog request failed: %w", err)
+	}
+
+	entries, err := parseChangelogResponse(response, commits)
+	if err != nil {
+		// The model's answer is unusable, fall back to conventional commit prefixes
+		w.logger.Warn("Failed to parse changelog response, using commit prefixes", "error", err)
+		entries = nil
+	}
+	return fillMissingEntries(entries, commits), nil
+}
+
+// parseChangelogResponse maps the model's grouping back onto commits
+func parseChangelogResponse(response string, commits []Commit) ([]ChangelogEntry, error) {
+	var parsed struct {
+		Entries []struct {
+			Commit   int    `json:"commit"`
+			Category string `json:"category"`
+			Text     string `json:"text"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(extractJSONObject(response)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse changelog JSON: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	var entries []ChangelogEntry
+	for _, e := range parsed.Entries {
+		idx := e.Commit - 1
+		if idx < 0 || idx >= len(commits) || seen[idx] {
+			continue
+		}
+		seen[idx] = true
+
+		text := strings.TrimSpace(e.Text)
+		if text == "" {
+			text = commits[idx].Subject
+		}
+		entries = append(entries, ChangelogEntry{
+			Hash:     commits[idx].Hash,
+			Category: normalizeCategory(e.Category),
+			Text:     text,
+		})
+	}
+	return entries, nil
+}
+
+// fillMissingEntries adds commits the model skipped, categorized by their subject prefix
+func fillMissingEntries(entries []ChangelogEntry, commits []Commit) []ChangelogEntry {
+	have := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		have[e.Hash] = true
+	}
+	for _, c := range commits {
+		if have[c.Hash] {
+			continue
+		}
+		category, text := categorizeSubject(c.Subject)
+		entries = append(entries, ChangelogEntry{Hash: c.Hash, Category: category, Text: text})
+	}
+	return entries
+}
+
+// conventionalPrefix matches subjects such as "feat(ui): add thing"
+var conventionalPrefix = regexp.MustCompile(`^(\w+)(\([^)]*\))?!?:\s*`)
+
+// categorizeSubject guesses a category from a conventional commit subject
+func categorizeSubject(subject string) (string, string) {
+	m := conventionalPrefix.FindStringSubmatch(subject)
+	if m == nil {
+		return CategoryOther, subject
+	}
+	text := subject[len(m[0]):]
+	switch strings.ToLower(m[1]) {
+	case "feat", "feature":
+		return CategoryFeatures, text
+	case "fix", "bugfix":
+		return CategoryFixes, text
+	case "docs", "doc":
+		return CategoryDocs, text
+	default:
+		return CategoryOther, text
+	}
+}
+
+// normalizeCategory maps a model supplied category onto a known one
+func normalizeCategory(category string) string {
+	switch strings.ToLower(strings.TrimSpace(category)) {
+	case "features", "feature", "feat":
+		return CategoryFeatures
+	case "fixes", "fix", "bugfix", "bug fixes":
+		return CategoryFixes
+	case "docs", "doc", "documentation":
+		return CategoryDocs
+	default:
+		return CategoryOther
+	}
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// CommitsBetween returns the non-merge commits reachable from to but not from, oldest first.
+// An empty from lists the whole history up to to.
+func CommitsBetween(ctx context.Context, dir, from, to string) ([]Commit, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	// A revision starting with "-" would be read as an option, such as --output
+	for _, rev := range []string{from, to} {
+		if strings.HasPrefix(rev, "-") {
+			return nil, fmt.Errorf("invalid revision %q: must not start with \"-\"", rev)
+		}
+	}
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+
+	// Unit and record separators keep multi-line bodies intact
+	out, err := gitOutput(ctx, dir, "log", "--no-merges", "--reverse", "--format=%H%x1f%s%x1f%b%x1e", "--end-of-options", revRange, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x1f", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Subject: fields[1]}
+		if len(fields) == 3 {
+			c.Body = strings.TrimSpace(fields[2])
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// LatestTag returns the most recent tag reachable from HEAD, or "" if there is none
+func LatestTag(ctx context.Context, dir string) string {
+	tag, err := gitOutput(ctx, dir, "describe", "--tags", "--abbrev=0")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(tag)
+}
+
+// PrependChangelog inserts a section at the top of a changelog file, below its title.
+// The file is created with a "# Changelog" title if it does not exist.
+func PrependChangelog(path, section string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	content := string(existing)
+	if strings.TrimSpace(content) == "" {
+		content = "# Changelog\n"
+	}
+
+	section = strings.TrimRight(section, "\n") + "\n"
+
+	var updated string
+	if strings.HasPrefix(content, "# ") {
+		title, rest, _ := strings.Cut(content, "\n")
+		updated = title + "\n\n" + section + "\n" + strings.TrimLeft(rest, "\n")
+	} else {
+		updated = section + "\n" + content
+	}
+
+	return os.WriteFile(path, []byte(strings.TrimRight(updated, "\n")+"\n"), 0644)
+}
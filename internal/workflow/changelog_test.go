@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codezilla/pkg/logger"
+)
+
+func TestChangelogGenerate(t *testing.T) {
+	commits := []Commit{
+		{Hash: "aaaaaaaaaa", Subject: "Add dark mode"},
+		{Hash: "bbbbbbbbbb", Subject: "Fix crash on empty input"},
+		{Hash: "cccccccccc", Subject: "docs: explain config file"},
+	}
+	// The model skips the third commit, which should fall back to its prefix
+	llm := &fakeLLM{response: `{"entries": [
+		{"commit": 1, "category": "feature", "text": "Add a dark mode theme"},
+		{"commit": 2, "category": "fixes", "text": "Fix crash on empty input"},
+		{"commit": 9, "category": "fixes", "text": "out of range"}
+	]}`}
+
+	log, _ := logger.New(logger.Config{Silent: true})
+	changelog, err := NewChangelogWorkflow(llm, log).Generate(context.Background(), commits, "1.2.0")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(changelog.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(changelog.Entries), changelog.Entries)
+	}
+
+	md := changelog.Markdown()
+	for _, want := range []string{
+		"## 1.2.0 - ",
+		"### Features\n\n- Add a dark mode theme (aaaaaaa)",
+		"### Bug Fixes\n\n- Fix crash on empty input (bbbbbbb)",
+		"### Documentation\n\n- explain config file (ccccccc)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "Other Changes") {
+		t.Errorf("empty categories should not be rendered:\n%s", md)
+	}
+}
+
+func TestChangelogGenerateBatches(t *testing.T) {
+	var commits []Commit
+	for i := 0; i < 5; i++ {
+		commits = append(commits, Commit{Hash: fmt.Sprintf("%010d", i), Subject: fmt.Sprintf("fix: bug %d", i)})
+	}
+	log, _ := logger.New(logger.Config{Silent: true})
+	w := NewChangelogWorkflow(&fakeLLM{response: "not JSON"}, log)
+	w.MaxCommits = 2
+	changelog, err := w.Generate(context.Background(), commits, "")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(changelog.Entries) != len(commits) {
+		t.Errorf("got %d entries, want one per commit: %+v", len(changelog.Entries), changelog.Entries)
+	}
+}
+
+func TestCommitsBetweenRejectsOptions(t *testing.T) {
+	dir := t.TempDir()
+	for _, rev := range []string{"--output=" + filepath.Join(dir, "x"), "-p"} {
+		if _, err := CommitsBetween(context.Background(), dir, rev, ""); err == nil {
+			t.Errorf("CommitsBetween(%q) accepted an option", rev)
+		}
+		if _, err := CommitsBetween(context.Background(), dir, "", rev); err == nil {
+			t.Errorf("CommitsBetween(to %q) accepted an option", rev)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x")); err == nil {
+		t.Error("git wrote the --output file")
+	}
+}
+
+func TestCategorizeSubject(t *testing.T) {
+	tests := []struct {
+		subject, category, text string
+	}{
+		{"feat(ui): add spinner", CategoryFeatures, "add spinner"},
+		{"fix!: handle nil", CategoryFixes, "handle nil"},
+		{"chore: bump deps", CategoryOther, "bump deps"},
+		{"Update README", CategoryOther, "Update README"},
+	}
+	for _, tt := range tests {
+		category, text := categorizeSubject(tt.subject)
+		if category != tt.category || text != tt.text {
+			t.Errorf("categorizeSubject(%q) = (%q, %q), want (%q, %q)", tt.subject, category, text, tt.category, tt.text)
+		}
+	}
+}
+
+func TestPrependChangelog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	if err := PrependChangelog(path, "## 1.0.0\n\n- first\n"); err != nil {
+		t.Fatalf("PrependChangelog failed: %v", err)
+	}
+	if err := PrependChangelog(path, "## 1.1.0\n\n- second\n"); err != nil {
+		t.Fatalf("PrependChangelog failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read changelog: %v", err)
+	}
+	want := "# Changelog\n\n## 1.1.0\n\n- second\n\n## 1.0.0\n\n- first\n"
+	if string(data) != want {
+		t.Errorf("unexpected changelog:\n%q\nwant:\n%q", string(data), want)
+	}
+}
@@ -0,0 +1,114 @@
+package workflow
+
+import (
+	"context"
+	"os"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// ChangelogTool exposes the changelog workflow to the agent
+type ChangelogTool struct {
+	workflow *ChangelogWorkflow
+}
+
+// NewChangelogTool creates a new changelog tool
+func NewChangelogTool(llmClient tools.LLMClient, logger *logger.Logger) *ChangelogTool {
+	return &ChangelogTool{workflow: NewChangelogWorkflow(llmClient, logger)}
+}
+
+// Name returns the tool name
+func (t *ChangelogTool) Name() string {
+	return "generateChangelog"
+}
+
+// Description returns the tool description
+func (t *ChangelogTool) Description() string {
+	return "Generates release notes from the git commits between two refs, grouped into features, fixes, docs and other changes, and optionally prepends them to CHANGELOG.md"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *ChangelogTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"from": {
+				Type:        "string",
+				Description: "Start ref (exclusive). Defaults to the latest tag",
+			},
+			"to": {
+				Type:        "string",
+				Description: "End ref (inclusive). Defaults to HEAD",
+			},
+			"version": {
+				Type:        "string",
+				Description: "Version heading for the section (default: Unreleased)",
+			},
+			"write": {
+				Type:        "boolean",
+				Description: "Prepend the section to the changelog file (default: false)",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Changelog file to update when write is true (default: CHANGELOG.md)",
+			},
+		},
+	}
+}
+
+// Execute generates the changelog section
+func (t *ChangelogTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	from, _ := params["from"].(string)
+	to, _ := params["to"].(string)
+	version, _ := params["version"].(string)
+	write, _ := params["write"].(bool)
+	path, _ := params["path"].(string)
+	if path == "" {
+		path = "CHANGELOG.md"
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to get working directory", Err: err}
+	}
+
+	if from == "" {
+		from = LatestTag(ctx, cwd)
+	}
+
+	commits, err := CommitsBetween(ctx, cwd, from, to)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to read git history", Err: err}
+	}
+
+	changelog, err := t.workflow.Generate(ctx, commits, version)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to generate changelog", Err: err}
+	}
+
+	markdown := changelog.Markdown()
+	result := map[string]interface{}{
+		"from":      from,
+		"to":        to,
+		"commits":   len(commits),
+		"changelog": markdown,
+	}
+
+	if write {
+		cleanPath, err := tools.ValidateAndCleanPath(path)
+		if err != nil {
+			return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+		if err := PrependChangelog(cleanPath, markdown); err != nil {
+			return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to update changelog", Err: err}
+		}
+		result["written"] = cleanPath
+	}
+
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+const (
+	// maxErrorsPerStep caps the error messages kept for one failing step
+	maxErrorsPerStep = 20
+	// maxLocationsPerStep caps the source locations kept for one failing step
+	maxLocationsPerStep = 10
+	// snippetRadius is how many lines around a location are shown to the model
+	snippetRadius = 5
+)
+
+// CIFailure is a failing step of a CI job with the errors it reported
+type CIFailure struct {
+	Job       string           `json:"job,omitempty"`
+	Step      string           `json:"step"`
+	Errors    []string         `json:"errors"`
+	Locations []SourceLocation `json:"locations,omitempty"`
+}
+
+// SourceLocation is a file and line mentioned in a log
+type SourceLocation struct {
+	Path    string `json:"path"`           // As written in the log
+	File    string `json:"file,omitempty"` // The project file it refers to, if found
+	Line    int    `json:"line"`
+	Snippet string `json:"-"`
+}
+
+// CIFix is a proposed fix for a CI failure
+type CIFix struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Problem string `json:"problem"`
+	Fix     string `json:"fix"`
+}
+
+// CITriageResult holds what failed in CI and how to fix it
+type CITriageResult struct {
+	Summary  string      `json:"summary"`
+	Failures []CIFailure `json:"failures"`
+	Fixes    []CIFix     `json:"fixes"`
+}
+
+var (
+	// ciTimestamp is the timestamp GitHub Actions puts in front of every log line
+	ciTimestamp = regexp.MustCompile(`^\d{4}-\d\d-\d\dT[\d:.]+Z ?`)
+	ansiEscape  = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// GitHub Actions groups a step's output in ##[group]; GitLab marks sections
+	ghStep = regexp.MustCompile(`^##\[group\](?:Run )?(.+)`)
+	glStep = regexp.MustCompile(`section_start:\d+:([^\r\s\[]+)`)
+	// errorLines recognize error messages from CI runners, compilers and test runners
+	errorLines = []*regexp.Regexp{
+		regexp.MustCompile(`^##\[error\](.+)`),
+		regexp.MustCompile(`^\s*(--- FAIL: .+)`),
+		regexp.MustCompile(`^(FAIL\s.+)`),
+		regexp.MustCompile(`^(panic: .+)`),
+		regexp.MustCompile(`^\s*(\S+\.\w+:\d+(?::\d+)?: .+)`),
+		regexp.MustCompile(`^\s*((?i:error|fatal)(?:\[\w+\])?: .+)`),
+		regexp.MustCompile(`^\s*(\w*(?:Error|Exception): .+)`),
+		regexp.MustCompile(`^(E\s{3}.+)`),
+		regexp.MustCompile(`^(npm ERR! .+)`),
+	}
+	// runnerExit is the runner's closing message, only kept when a step reports nothing else
+	runnerExit = regexp.MustCompile(`^Process completed with exit code \d+`)
+	// sourceRef matches "path/file.go:12" and Python's `File "path/file.py", line 12`
+	sourceRef = regexp.MustCompile(`([\w./\\@-]+\.(?:go|py|js|jsx|mjs|cjs|ts|tsx|rb|rs|java|kt|c|cc|cpp|h|hpp|cs|php|swift))(?::(\d+)|", line (\d+))`)
+)
+
+// CITriageWorkflow finds what failed in a CI log and proposes fixes. It runs in three
+// stages that can also be used on their own: Extract, Locate and Propose.
+type CITriageWorkflow struct {
+	llmClient tools.LLMClient
+	logger    *logger.Logger
+	// MaxPromptChars limits how much failure context is sent to the model
+	MaxPromptChars int
+}
+
+// NewCITriageWorkflow creates a new CI triage workflow
+func NewCITriageWorkflow(llmClient tools.LLMClient, logger *logger.Logger) *CITriageWorkflow {
+	return &CITriageWorkflow{
+		llmClient:      llmClient,
+		logger:         logger,
+		MaxPromptChars: 30000,
+	}
+}
+
+// Triage extracts the failures from the jobs' logs, locates them in the project at
+// root and asks the model for fixes
+func (w *CITriageWorkflow) Triage(ctx context.Context, jobs []CIJob, root string) (*CITriageResult, error) {
+	var failures []CIFailure
+	for _, job := range jobs {
+		failures = append(failures, w.Extract(job.Name, job.Log)...)
+	}
+	if len(failures) == 0 {
+		return &CITriageResult{Summary: "No failing steps found in the log"}, nil
+	}
+	w.Locate(failures, tools.NewFileLocator(root))
+	return w.Propose(ctx, failures)
+}
+
+// Extract finds the failing steps of a job's log and the errors they reported
+func (w *CITriageWorkflow) Extract(job, log string) []CIFailure {
+	return ExtractCIFailures(job, log)
+}
+
+// Locate resolves the failures' source locations to project files and reads the code
+// around them
+func (w *CITriageWorkflow) Locate(failures []CIFailure, locator *tools.FileLocator) {
+	for i := range failures {
+		for j := range failures[i].Locations {
+			loc := &failures[i].Locations[j]
+			file, ok := locator.Locate(loc.Path)
+			if !ok {
+				continue
+			}
+			loc.File = file
+			snippet, err := tools.SourceSnippet(filepath.Join(locator.Root(), filepath.FromSlash(file)), loc.Line, snippetRadius)
+			if err != nil {
+				w.logger.Debug("Failed to read source for CI failure", "file", file, "error", err)
+				continue
+			}
+			loc.Snippet = snippet
+		}
+	}
+}
+
+// Propose asks the model to explain the failures and suggest fixes
+func (w *CITriageWorkflow) Propose(ctx context.Context, failures []CIFailure) (*CITriageResult, error) {
+	var report strings.Builder
+	for _, f := range failures {
+		fmt.Fprintf(&report, "## Step: %s", f.Step)
+		if f.Job != "" {
+			fmt.Fprintf(&report, " (job %s)", f.Job)
+		}
+		report.WriteString("\n\nErrors:\n")
+		for _, e := range f.Errors {
+			report.WriteString("  " + e + "\n")
+		}
+		for _, loc := range f.Locations {
+			if loc.Snippet != "" {
+				fmt.Fprintf(&report, "\n%s:%d\n%s", loc.File, loc.Line, loc.Snippet)
+			}
+		}
+		report.WriteString("\n")
+	}
+	text := report.String()
+	if w.MaxPromptChars > 0 && len(text) > w.MaxPromptChars {
+		cut := w.MaxPromptChars
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "\n\n[... failures truncated ...]\n"
+	}
+
+	prompt := fmt.Sprintf(`These steps failed in CI. The errors are taken from the log and the code is from the project.
+
+%s
+Explain what broke and propose fixes. Format your response as JSON with these fields:
+- summary: string (one or two sentences on the root cause)
+- fixes: array of objects with fields
+  - file: string (project path, empty if the fix is not in the code, e.g. CI configuration)
+  - line: number (0 if unknown)
+  - problem: string
+  - fix: string (the concrete change to make)`, text)
+
+	messages := []tools.LLMMessage{
+		{
+			Role:    "system",
+			Content: "You are a build engineer triaging CI failures. Distinguish code bugs from flaky tests and environment problems. Return valid JSON only.",
+		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}
+
+	response, err := w.llmClient.GenerateResponse(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("triage request failed: %w", err)
+	}
+
+	result := &CITriageResult{Failures: failures}
+	if err := json.Unmarshal([]byte(extractJSONObject(response)), result); err != nil {
+		// Keep the extracted failures, which are useful without the model's proposal
+		w.logger.Warn("Failed to parse triage response", "error", err)
+		result.Summary = strings.TrimSpace(response)
+	}
+	result.Failures = failures
+	return result, nil
+}
+
+// ExtractCIFailures splits a CI log into steps and returns the ones that reported
+// errors, with the errors and the source locations mentioned in them
+func ExtractCIFailures(job, log string) []CIFailure {
+	var failures []CIFailure
+	current := CIFailure{Job: job, Step: "log"}
+	var exitLine string
+	seen := make(map[string]bool)
+
+	flush := func() {
+		if len(current.Errors) == 0 && exitLine != "" {
+			current.Errors = append(current.Errors, exitLine)
+		}
+		if len(current.Errors) > 0 {
+			failures = append(failures, current)
+		}
+		exitLine = ""
+		seen = make(map[string]bool)
+	}
+
+	for _, raw := range strings.Split(log, "\n") {
+		line := ansiEscape.ReplaceAllString(ciTimestamp.ReplaceAllString(strings.TrimRight(raw, "\r"), ""), "")
+
+		if m := ghStep.FindStringSubmatch(line); m != nil {
+			flush()
+			current = CIFailure{Job: job, Step: strings.TrimSpace(m[1])}
+			continue
+		}
+		if m := glStep.FindStringSubmatch(raw); m != nil {
+			flush()
+			current = CIFailure{Job: job, Step: m[1]}
+			continue
+		}
+		if runnerExit.MatchString(strings.TrimPrefix(line, "##[error]")) {
+			exitLine = strings.TrimPrefix(line, "##[error]")
+			continue
+		}
+
+		isError := false
+		for _, re := range errorLines {
+			if m := re.FindStringSubmatch(line); m != nil {
+				isError = true
+				msg := strings.TrimSpace(m[1])
+				if !seen[msg] && len(current.Errors) < maxErrorsPerStep {
+					seen[msg] = true
+					current.Errors = append(current.Errors, msg)
+				}
+				break
+			}
+		}
+		if !isError && !strings.Contains(line, `File "`) {
+			continue
+		}
+		for _, m := range sourceRef.FindAllStringSubmatch(line, -1) {
+			lineNo, _ := strconv.Atoi(m[2] + m[3])
+			key := m[1] + ":" + strconv.Itoa(lineNo)
+			if lineNo == 0 || seen[key] || len(current.Locations) >= maxLocationsPerStep {
+				continue
+			}
+			seen[key] = true
+			current.Locations = append(current.Locations, SourceLocation{Path: m[1], Line: lineNo})
+		}
+	}
+	flush()
+	return failures
+}
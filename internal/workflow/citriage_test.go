@@ -0,0 +1,115 @@
+package workflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+func TestExtractCIFailures(t *testing.T) {
+	githubLog := strings.Join([]string{
+		"2024-05-01T10:00:00.0000000Z ##[group]Run actions/checkout@v4",
+		"2024-05-01T10:00:01.0000000Z ##[endgroup]",
+		"2024-05-01T10:00:02.0000000Z ##[group]Run go test ./...",
+		"2024-05-01T10:00:03.0000000Z ok  \tcodezilla/internal/cli\t0.1s",
+		"2024-05-01T10:00:04.0000000Z --- FAIL: TestParse (0.00s)",
+		"2024-05-01T10:00:04.0000000Z     /home/runner/work/app/app/internal/parse/parse_test.go:42: got 1, want 2",
+		"2024-05-01T10:00:04.0000000Z --- FAIL: TestParse (0.00s)",
+		"2024-05-01T10:00:05.0000000Z FAIL\tcodezilla/internal/parse\t0.2s",
+		"2024-05-01T10:00:06.0000000Z ##[error]Process completed with exit code 1.",
+		"2024-05-01T10:00:07.0000000Z ##[group]Run golangci-lint",
+		"2024-05-01T10:00:08.0000000Z ##[error]Process completed with exit code 2.",
+	}, "\n")
+
+	got := ExtractCIFailures("test", githubLog)
+	want := []CIFailure{
+		{
+			Job:  "test",
+			Step: "go test ./...",
+			Errors: []string{
+				"--- FAIL: TestParse (0.00s)",
+				"/home/runner/work/app/app/internal/parse/parse_test.go:42: got 1, want 2",
+				"FAIL\tcodezilla/internal/parse\t0.2s",
+			},
+			Locations: []SourceLocation{{Path: "/home/runner/work/app/app/internal/parse/parse_test.go", Line: 42}},
+		},
+		{Job: "test", Step: "golangci-lint", Errors: []string{"Process completed with exit code 2."}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractCIFailures(github) =\n%+v\nwant\n%+v", got, want)
+	}
+
+	gitlabLog := strings.Join([]string{
+		"section_start:1714557600:step_script\r\x1b[0K\x1b[0K\x1b[36;1mExecuting \"step_script\"\x1b[0;m",
+		"$ pytest",
+		"Traceback (most recent call last):",
+		"  File \"/builds/group/app/app/models.py\", line 17, in save",
+		"ValueError: missing name",
+		"section_end:1714557601:step_script\r\x1b[0K",
+	}, "\n")
+	got = ExtractCIFailures("", gitlabLog)
+	if len(got) != 1 || got[0].Step != "step_script" || !reflect.DeepEqual(got[0].Errors, []string{"ValueError: missing name"}) ||
+		!reflect.DeepEqual(got[0].Locations, []SourceLocation{{Path: "/builds/group/app/app/models.py", Line: 17}}) {
+		t.Errorf("ExtractCIFailures(gitlab) = %+v", got)
+	}
+
+	if got := ExtractCIFailures("", "all good\nok\n"); len(got) != 0 {
+		t.Errorf("ExtractCIFailures(passing log) = %+v, want none", got)
+	}
+}
+
+func TestCITriageLocate(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "internal", "parse"), 0755)
+	os.WriteFile(filepath.Join(root, "internal", "parse", "parse_test.go"), []byte("package parse\n\nfunc TestParse() {\n\tcheck(1, 2)\n}\n"), 0644)
+
+	log, _ := logger.New(logger.Config{Silent: true})
+	w := NewCITriageWorkflow(nil, log)
+	failures := []CIFailure{{Step: "test", Locations: []SourceLocation{
+		{Path: "/home/runner/work/app/app/internal/parse/parse_test.go", Line: 4},
+		{Path: "/usr/local/go/src/testing/testing.go", Line: 1},
+	}}}
+	w.Locate(failures, tools.NewFileLocator(root))
+
+	loc := failures[0].Locations[0]
+	if loc.File != "internal/parse/parse_test.go" || !strings.Contains(loc.Snippet, ">    4  \tcheck(1, 2)") {
+		t.Errorf("located %q with snippet:\n%s", loc.File, loc.Snippet)
+	}
+	if failures[0].Locations[1].File != "" {
+		t.Errorf("a file outside the project was located as %q", failures[0].Locations[1].File)
+	}
+}
+
+type promptRecorder struct {
+	prompt string
+}
+
+func (r *promptRecorder) GenerateResponse(ctx context.Context, messages []tools.LLMMessage) (string, error) {
+	r.prompt = messages[len(messages)-1].Content
+	return `{"summary": "broken"}`, nil
+}
+
+func TestCITriageProposeTruncatesOnCharacters(t *testing.T) {
+	llm := &promptRecorder{}
+	log, _ := logger.New(logger.Config{Silent: true})
+	w := NewCITriageWorkflow(llm, log)
+	w.MaxPromptChars = 40
+
+	failures := []CIFailure{{Step: "test", Errors: []string{strings.Repeat("é", 40)}}}
+	if _, err := w.Propose(context.Background(), failures); err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+	if !strings.Contains(llm.prompt, "failures truncated") {
+		t.Fatal("expected the report to be truncated")
+	}
+	if !utf8.ValidString(llm.prompt) {
+		t.Errorf("truncation split a character: %q", llm.prompt)
+	}
+}
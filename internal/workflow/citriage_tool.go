@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"context"
+	"os"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// CITriageTool exposes the CI triage workflow to the agent
+type CITriageTool struct {
+	workflow *CITriageWorkflow
+	client   *forgeClient
+}
+
+// NewCITriageTool creates a new CI triage tool; failed runs are fetched from the forge
+// described by forge when no log file is given
+func NewCITriageTool(llmClient tools.LLMClient, logger *logger.Logger, forge ForgeConfig) *CITriageTool {
+	return &CITriageTool{workflow: NewCITriageWorkflow(llmClient, logger), client: &forgeClient{config: forge}}
+}
+
+// Name returns the tool name
+func (t *CITriageTool) Name() string {
+	return "triageCI"
+}
+
+// Description returns the tool description
+func (t *CITriageTool) Description() string {
+	return "Triages a CI failure: extracts the failing steps and error messages from a CI log file, or from the latest failed GitHub Actions run or GitLab pipeline, finds the project files they point to and proposes fixes"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *CITriageTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"log_file": {
+				Type:        "string",
+				Description: "Path to a saved CI log. When omitted, the latest failed run is fetched from GitHub or GitLab",
+			},
+		},
+	}
+}
+
+// ExternalContent marks the result as untrusted: CI logs carry the output of the code
+// and commands under test, which anyone who can push a branch controls
+func (t *CITriageTool) ExternalContent() bool { return true }
+
+// Execute triages the failure
+func (t *CITriageTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	logFile, _ := params["log_file"].(string)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to get working directory", Err: err}
+	}
+
+	var run *CIRun
+	if logFile != "" {
+		cleanPath, err := tools.ValidateAndCleanPath(logFile)
+		if err != nil {
+			return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+		data, err := os.ReadFile(cleanPath)
+		if err != nil {
+			return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to read log file", Err: err}
+		}
+		run = &CIRun{Name: cleanPath, Jobs: []CIJob{{Log: string(data)}}}
+	} else {
+		forge, err := t.client.forge(ctx)
+		if err != nil {
+			return nil, forgeError(t.Name(), "failed to connect to the repository's forge", err)
+		}
+		if run, err = forge.LatestFailedRun(ctx); err != nil {
+			return nil, forgeError(t.Name(), "failed to fetch the latest failed run", err)
+		}
+	}
+
+	result, err := t.workflow.Triage(ctx, run.Jobs, cwd)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to triage CI failure", Err: err}
+	}
+	return map[string]interface{}{"run": run, "triage": result}, nil
+}
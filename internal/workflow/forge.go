@@ -0,0 +1,637 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Forge providers
+const (
+	ProviderAuto   = "auto"
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Repo identifies a repository on a code forge
+type Repo struct {
+	Provider string // ProviderGitHub or ProviderGitLab
+	Host     string // e.g. github.com
+	Path     string // owner/name, or group/subgroup/name on GitLab
+}
+
+// Issue is an issue with its discussion
+type Issue struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	State    string    `json:"state"`
+	Author   string    `json:"author"`
+	URL      string    `json:"url"`
+	Labels   []string  `json:"labels,omitempty"`
+	Body     string    `json:"body,omitempty"`
+	Comments []Comment `json:"comments,omitempty"`
+}
+
+// Comment is a comment on an issue or pull request. Review comments on a diff line
+// have a path and line.
+type Comment struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+	Path   string `json:"path,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// PullRequest is a pull request (a merge request on GitLab)
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Head   string `json:"head"`
+	Base   string `json:"base"`
+}
+
+// NewPullRequest describes a pull request to open
+type NewPullRequest struct {
+	Title string
+	Body  string
+	Head  string // Branch with the changes
+	Base  string // Branch to merge into; the repository's default branch if empty
+	Draft bool
+}
+
+// CIRun is a CI run: a GitHub Actions workflow run or a GitLab pipeline
+type CIRun struct {
+	ID     int64   `json:"id"`
+	Name   string  `json:"name"`
+	URL    string  `json:"url"`
+	Branch string  `json:"branch"`
+	Jobs   []CIJob `json:"jobs"`
+}
+
+// CIJob is a failed job of a CI run with its log
+type CIJob struct {
+	Name string `json:"name"`
+	Log  string `json:"-"`
+}
+
+// Forge reads issues, pull requests and CI runs and opens issues and pull requests
+type Forge interface {
+	ListIssues(ctx context.Context, state string, limit int) ([]Issue, error)
+	Issue(ctx context.Context, number int) (*Issue, error)
+	PullRequestDiff(ctx context.Context, number int) (string, error)
+	PullRequestComments(ctx context.Context, number int) ([]Comment, error)
+	CreatePullRequest(ctx context.Context, pr NewPullRequest) (*PullRequest, error)
+	CreateIssue(ctx context.Context, title, body string) (*Issue, error)
+	// LatestFailedRun returns the most recent failed CI run with the logs of its failed jobs
+	LatestFailedRun(ctx context.Context) (*CIRun, error)
+}
+
+// remotePattern matches scp-like and URL git remotes, capturing host and path
+var remotePattern = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+
+// ParseRemote identifies the repository behind a git remote URL. With ProviderAuto the
+// provider is guessed from the host name.
+func ParseRemote(remote, provider string) (*Repo, error) {
+	m := remotePattern.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil || !strings.Contains(m[2], "/") {
+		return nil, fmt.Errorf("cannot parse git remote %q", remote)
+	}
+	repo := &Repo{Provider: provider, Host: m[1], Path: strings.TrimPrefix(m[2], "/")}
+	if provider == "" || provider == ProviderAuto {
+		switch {
+		case strings.Contains(repo.Host, "github"):
+			repo.Provider = ProviderGitHub
+		case strings.Contains(repo.Host, "gitlab"):
+			repo.Provider = ProviderGitLab
+		default:
+			return nil, fmt.Errorf("cannot tell whether %s is GitHub or GitLab; set forge.provider in the config", repo.Host)
+		}
+	}
+	return repo, nil
+}
+
+// issueURLPattern matches GitHub and GitLab issue URLs, capturing host, repository path and number
+var issueURLPattern = regexp.MustCompile(`^https?://([^/]+)/(.+?)(?:/-)?/issues/(\d+)/?(?:[?#].*)?$`)
+
+// ParseIssueRef reads an issue reference: an issue URL, "#12" or "12". A nil repo
+// means the reference is to the repository in the working directory.
+func ParseIssueRef(ref, provider string) (*Repo, int, error) {
+	ref = strings.TrimSpace(ref)
+	if m := issueURLPattern.FindStringSubmatch(ref); m != nil {
+		repo, err := ParseRemote("https://"+m[1]+"/"+m[2], provider)
+		if err != nil {
+			return nil, 0, err
+		}
+		number, _ := strconv.Atoi(m[3])
+		return repo, number, nil
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err != nil || number <= 0 {
+		return nil, 0, fmt.Errorf("%q is not an issue URL or number", ref)
+	}
+	return nil, number, nil
+}
+
+// DetectRepo identifies the repository from the origin remote of the git checkout in dir
+func DetectRepo(ctx context.Context, dir, provider string) (*Repo, error) {
+	remote, err := gitOutput(ctx, dir, "remote", "get-url", "origin")
+	if err != nil {
+		return nil, err
+	}
+	return ParseRemote(remote, provider)
+}
+
+// NewForge returns a client for repo. With a token it calls the REST API at apiURL
+// (derived from the host when empty); without one it goes through the gh or glab CLI,
+// which use their own login.
+func NewForge(repo *Repo, apiURL, token, dir string) (Forge, error) {
+	var caller apiCaller
+	switch {
+	case repo.Provider != ProviderGitHub && repo.Provider != ProviderGitLab:
+		return nil, fmt.Errorf("unsupported forge provider %q", repo.Provider)
+	case token == "":
+		bin := "gh"
+		if repo.Provider == ProviderGitLab {
+			bin = "glab"
+		}
+		if _, err := exec.LookPath(bin); err != nil {
+			return nil, fmt.Errorf("no %s token configured and the %s CLI is not installed", repo.Provider, bin)
+		}
+		caller = &cliCaller{bin: bin, host: repo.Host, dir: dir}
+	case repo.Provider == ProviderGitHub:
+		if apiURL == "" {
+			apiURL = "https://" + repo.Host + "/api/v3"
+			if repo.Host == "github.com" {
+				apiURL = "https://api.github.com"
+			}
+		}
+		caller = &httpCaller{base: apiURL, header: "Authorization", token: "Bearer " + token}
+	default:
+		if apiURL == "" {
+			apiURL = "https://" + repo.Host + "/api/v4"
+		}
+		caller = &httpCaller{base: apiURL, header: "PRIVATE-TOKEN", token: token}
+	}
+
+	if repo.Provider == ProviderGitHub {
+		return &gitHub{api: caller, repo: repo}, nil
+	}
+	return &gitLab{api: caller, repo: repo}, nil
+}
+
+// apiCaller sends a REST API request. Fields are sent as the JSON body; accept overrides
+// the response media type.
+type apiCaller interface {
+	call(ctx context.Context, method, path string, fields map[string]interface{}, accept string) ([]byte, error)
+}
+
+// httpCaller calls the API directly with a token
+type httpCaller struct {
+	base   string
+	header string
+	token  string
+	client *http.Client
+}
+
+func (c *httpCaller) call(ctx context.Context, method, path string, fields map[string]interface{}, accept string) ([]byte, error) {
+	var body io.Reader
+	if fields != nil {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.base, "/")+"/"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(c.header, c.token)
+	if fields != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	client := c.client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s failed: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// cliCaller calls the API through "gh api" or "glab api"
+type cliCaller struct {
+	bin  string
+	host string
+	dir  string
+}
+
+func (c *cliCaller) call(ctx context.Context, method, path string, fields map[string]interface{}, accept string) ([]byte, error) {
+	args := []string{"api", "--method", method, "--hostname", c.host}
+	if accept != "" {
+		args = append(args, "--header", "Accept: "+accept)
+	}
+	for k, v := range fields {
+		flag := "--raw-field"
+		if _, ok := v.(string); !ok {
+			flag = "--field" // Typed, so booleans stay booleans
+		}
+		args = append(args, flag, fmt.Sprintf("%s=%v", k, v))
+	}
+	args = append(args, path)
+
+	cmd := exec.CommandContext(ctx, c.bin, args...)
+	cmd.Dir = c.dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s api %s failed: %s", c.bin, path, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s api %s failed: %w", c.bin, path, err)
+	}
+	return out, nil
+}
+
+// getJSON calls the API and decodes the JSON response into out
+func getJSON(ctx context.Context, api apiCaller, method, path string, fields map[string]interface{}, out interface{}) error {
+	data, err := api.call(ctx, method, path, fields, "")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("unexpected response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// gitHub implements Forge with the GitHub REST API
+type gitHub struct {
+	api  apiCaller
+	repo *Repo
+}
+
+type gitHubIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	URL    string `json:"html_url"`
+	Body   string `json:"body"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	PullRequest *json.RawMessage `json:"pull_request"`
+}
+
+func (i gitHubIssue) issue() Issue {
+	issue := Issue{Number: i.Number, Title: i.Title, State: i.State, Author: i.User.Login, URL: i.URL, Body: i.Body}
+	for _, l := range i.Labels {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	return issue
+}
+
+type gitHubComment struct {
+	Body string `json:"body"`
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+func (g *gitHub) path(format string, args ...interface{}) string {
+	return "repos/" + g.repo.Path + "/" + fmt.Sprintf(format, args...)
+}
+
+func (g *gitHub) ListIssues(ctx context.Context, state string, limit int) ([]Issue, error) {
+	var raw []gitHubIssue
+	// The issues endpoint includes pull requests, so ask for extra and filter them out
+	if err := getJSON(ctx, g.api, "GET", g.path("issues?state=%s&per_page=%d", url.QueryEscape(state), min(limit*2, 100)), nil, &raw); err != nil {
+		return nil, err
+	}
+	var issues []Issue
+	for _, r := range raw {
+		if r.PullRequest == nil && len(issues) < limit {
+			issue := r.issue()
+			issue.Body = ""
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+func (g *gitHub) Issue(ctx context.Context, number int) (*Issue, error) {
+	var raw gitHubIssue
+	if err := getJSON(ctx, g.api, "GET", g.path("issues/%d", number), nil, &raw); err != nil {
+		return nil, err
+	}
+	var comments []gitHubComment
+	if err := getJSON(ctx, g.api, "GET", g.path("issues/%d/comments?per_page=100", number), nil, &comments); err != nil {
+		return nil, err
+	}
+	issue := raw.issue()
+	for _, c := range comments {
+		issue.Comments = append(issue.Comments, Comment{Author: c.User.Login, Body: c.Body})
+	}
+	return &issue, nil
+}
+
+func (g *gitHub) PullRequestDiff(ctx context.Context, number int) (string, error) {
+	data, err := g.api.call(ctx, "GET", g.path("pulls/%d", number), nil, "application/vnd.github.diff")
+	return string(data), err
+}
+
+func (g *gitHub) PullRequestComments(ctx context.Context, number int) ([]Comment, error) {
+	var conversation, review []gitHubComment
+	if err := getJSON(ctx, g.api, "GET", g.path("issues/%d/comments?per_page=100", number), nil, &conversation); err != nil {
+		return nil, err
+	}
+	if err := getJSON(ctx, g.api, "GET", g.path("pulls/%d/comments?per_page=100", number), nil, &review); err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, c := range append(conversation, review...) {
+		comments = append(comments, Comment{Author: c.User.Login, Body: c.Body, Path: c.Path, Line: c.Line})
+	}
+	return comments, nil
+}
+
+func (g *gitHub) CreatePullRequest(ctx context.Context, pr NewPullRequest) (*PullRequest, error) {
+	if pr.Base == "" {
+		var repo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := getJSON(ctx, g.api, "GET", "repos/"+g.repo.Path, nil, &repo); err != nil {
+			return nil, err
+		}
+		pr.Base = repo.DefaultBranch
+	}
+	var created struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		URL    string `json:"html_url"`
+	}
+	fields := map[string]interface{}{"title": pr.Title, "body": pr.Body, "head": pr.Head, "base": pr.Base, "draft": pr.Draft}
+	if err := getJSON(ctx, g.api, "POST", g.path("pulls"), fields, &created); err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: created.Number, Title: created.Title, URL: created.URL, Head: pr.Head, Base: pr.Base}, nil
+}
+
+func (g *gitHub) CreateIssue(ctx context.Context, title, body string) (*Issue, error) {
+	var created gitHubIssue
+	if err := getJSON(ctx, g.api, "POST", g.path("issues"), map[string]interface{}{"title": title, "body": body}, &created); err != nil {
+		return nil, err
+	}
+	issue := created.issue()
+	return &issue, nil
+}
+
+func (g *gitHub) LatestFailedRun(ctx context.Context) (*CIRun, error) {
+	var runs struct {
+		WorkflowRuns []struct {
+			ID         int64  `json:"id"`
+			Name       string `json:"name"`
+			URL        string `json:"html_url"`
+			HeadBranch string `json:"head_branch"`
+		} `json:"workflow_runs"`
+	}
+	if err := getJSON(ctx, g.api, "GET", g.path("actions/runs?status=failure&per_page=1"), nil, &runs); err != nil {
+		return nil, err
+	}
+	if len(runs.WorkflowRuns) == 0 {
+		return nil, fmt.Errorf("no failed workflow runs in %s", g.repo.Path)
+	}
+	r := runs.WorkflowRuns[0]
+	run := &CIRun{ID: r.ID, Name: r.Name, URL: r.URL, Branch: r.HeadBranch}
+
+	var jobs struct {
+		Jobs []struct {
+			ID         int64  `json:"id"`
+			Name       string `json:"name"`
+			Conclusion string `json:"conclusion"`
+		} `json:"jobs"`
+	}
+	if err := getJSON(ctx, g.api, "GET", g.path("actions/runs/%d/jobs?filter=latest&per_page=100", r.ID), nil, &jobs); err != nil {
+		return nil, err
+	}
+	for _, j := range jobs.Jobs {
+		if j.Conclusion != "failure" {
+			continue
+		}
+		log, err := g.api.call(ctx, "GET", g.path("actions/jobs/%d/logs", j.ID), nil, "")
+		if err != nil {
+			return nil, err
+		}
+		run.Jobs = append(run.Jobs, CIJob{Name: j.Name, Log: string(log)})
+	}
+	return run, nil
+}
+
+// gitLab implements Forge with the GitLab REST API; pull requests are merge requests
+type gitLab struct {
+	api  apiCaller
+	repo *Repo
+}
+
+type gitLabIssue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	State       string   `json:"state"`
+	URL         string   `json:"web_url"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+	Author      struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+type gitLabNote struct {
+	Body   string `json:"body"`
+	System bool   `json:"system"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	Position *struct {
+		NewPath string `json:"new_path"`
+		NewLine int    `json:"new_line"`
+	} `json:"position"`
+}
+
+func (g *gitLab) path(format string, args ...interface{}) string {
+	return "projects/" + url.PathEscape(g.repo.Path) + "/" + fmt.Sprintf(format, args...)
+}
+
+// gitLabState maps GitHub-style issue states to GitLab's
+func gitLabState(state string) string {
+	switch state {
+	case "open":
+		return "opened"
+	case "all":
+		return "all"
+	default:
+		return state
+	}
+}
+
+func (g *gitLab) ListIssues(ctx context.Context, state string, limit int) ([]Issue, error) {
+	var raw []gitLabIssue
+	if err := getJSON(ctx, g.api, "GET", g.path("issues?state=%s&per_page=%d", url.QueryEscape(gitLabState(state)), min(limit, 100)), nil, &raw); err != nil {
+		return nil, err
+	}
+	var issues []Issue
+	for _, r := range raw {
+		issues = append(issues, Issue{Number: r.IID, Title: r.Title, State: r.State, Author: r.Author.Username, URL: r.URL, Labels: r.Labels})
+	}
+	return issues, nil
+}
+
+func (g *gitLab) Issue(ctx context.Context, number int) (*Issue, error) {
+	var raw gitLabIssue
+	if err := getJSON(ctx, g.api, "GET", g.path("issues/%d", number), nil, &raw); err != nil {
+		return nil, err
+	}
+	notes, err := g.notes(ctx, g.path("issues/%d/notes?sort=asc&per_page=100", number))
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Number: raw.IID, Title: raw.Title, State: raw.State, Author: raw.Author.Username, URL: raw.URL,
+		Labels: raw.Labels, Body: raw.Description, Comments: notes}, nil
+}
+
+// notes reads a list of notes, skipping system notes such as label changes
+func (g *gitLab) notes(ctx context.Context, path string) ([]Comment, error) {
+	var raw []gitLabNote
+	if err := getJSON(ctx, g.api, "GET", path, nil, &raw); err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, n := range raw {
+		if n.System {
+			continue
+		}
+		c := Comment{Author: n.Author.Username, Body: n.Body}
+		if n.Position != nil {
+			c.Path, c.Line = n.Position.NewPath, n.Position.NewLine
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+func (g *gitLab) PullRequestDiff(ctx context.Context, number int) (string, error) {
+	var files []struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+		Diff    string `json:"diff"`
+	}
+	if err := getJSON(ctx, g.api, "GET", g.path("merge_requests/%d/diffs?per_page=100", number), nil, &files); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n%s", f.OldPath, f.NewPath, f.Diff)
+		if !strings.HasSuffix(f.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func (g *gitLab) PullRequestComments(ctx context.Context, number int) ([]Comment, error) {
+	return g.notes(ctx, g.path("merge_requests/%d/notes?sort=asc&per_page=100", number))
+}
+
+func (g *gitLab) CreatePullRequest(ctx context.Context, pr NewPullRequest) (*PullRequest, error) {
+	if pr.Base == "" {
+		var project struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := getJSON(ctx, g.api, "GET", "projects/"+url.PathEscape(g.repo.Path), nil, &project); err != nil {
+			return nil, err
+		}
+		pr.Base = project.DefaultBranch
+	}
+	title := pr.Title
+	if pr.Draft {
+		title = "Draft: " + title
+	}
+	var created struct {
+		IID   int    `json:"iid"`
+		Title string `json:"title"`
+		URL   string `json:"web_url"`
+	}
+	fields := map[string]interface{}{"title": title, "description": pr.Body, "source_branch": pr.Head, "target_branch": pr.Base}
+	if err := getJSON(ctx, g.api, "POST", g.path("merge_requests"), fields, &created); err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: created.IID, Title: created.Title, URL: created.URL, Head: pr.Head, Base: pr.Base}, nil
+}
+
+func (g *gitLab) CreateIssue(ctx context.Context, title, body string) (*Issue, error) {
+	var created gitLabIssue
+	if err := getJSON(ctx, g.api, "POST", g.path("issues"), map[string]interface{}{"title": title, "description": body}, &created); err != nil {
+		return nil, err
+	}
+	return &Issue{Number: created.IID, Title: created.Title, State: created.State, Author: created.Author.Username, URL: created.URL,
+		Labels: created.Labels, Body: created.Description}, nil
+}
+
+func (g *gitLab) LatestFailedRun(ctx context.Context) (*CIRun, error) {
+	var pipelines []struct {
+		ID  int64  `json:"id"`
+		Ref string `json:"ref"`
+		URL string `json:"web_url"`
+	}
+	if err := getJSON(ctx, g.api, "GET", g.path("pipelines?status=failed&per_page=1"), nil, &pipelines); err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, fmt.Errorf("no failed pipelines in %s", g.repo.Path)
+	}
+	p := pipelines[0]
+	run := &CIRun{ID: p.ID, Name: fmt.Sprintf("pipeline %d", p.ID), URL: p.URL, Branch: p.Ref}
+
+	var jobs []struct {
+		ID    int64  `json:"id"`
+		Name  string `json:"name"`
+		Stage string `json:"stage"`
+	}
+	if err := getJSON(ctx, g.api, "GET", g.path("pipelines/%d/jobs?scope=failed&per_page=100", p.ID), nil, &jobs); err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		log, err := g.api.call(ctx, "GET", g.path("jobs/%d/trace", j.ID), nil, "")
+		if err != nil {
+			return nil, err
+		}
+		run.Jobs = append(run.Jobs, CIJob{Name: j.Stage + "/" + j.Name, Log: string(log)})
+	}
+	return run, nil
+}
@@ -0,0 +1,211 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		remote, provider string
+		want             Repo
+		wantErr          bool
+	}{
+		{"https://github.com/acme/app.git", ProviderAuto, Repo{ProviderGitHub, "github.com", "acme/app"}, false},
+		{"git@github.com:acme/app.git", "", Repo{ProviderGitHub, "github.com", "acme/app"}, false},
+		{"ssh://git@gitlab.example.com:2222/group/sub/app.git", ProviderAuto, Repo{ProviderGitLab, "gitlab.example.com", "group/sub/app"}, false},
+		{"https://code.example.com/team/app", ProviderGitLab, Repo{ProviderGitLab, "code.example.com", "team/app"}, false},
+		{"https://code.example.com/team/app", ProviderAuto, Repo{}, true},
+		{"not a remote", ProviderAuto, Repo{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRemote(tt.remote, tt.provider)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRemote(%q) = %+v, want error", tt.remote, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRemote(%q): %v", tt.remote, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseRemote(%q) = %+v, want %+v", tt.remote, *got, tt.want)
+		}
+	}
+}
+
+// forgeServer serves canned responses keyed by method and escaped path, recording request bodies
+func forgeServer(t *testing.T, header, token string, responses map[string]string) (*httptest.Server, map[string]map[string]interface{}) {
+	bodies := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(header); got != token {
+			t.Errorf("%s header = %q, want %q", header, got, token)
+		}
+		key := r.Method + " " + strings.TrimPrefix(r.URL.EscapedPath(), "/")
+		if r.Method == "GET" && r.Header.Get("Accept") == "application/vnd.github.diff" {
+			key += " diff"
+		}
+		if r.Body != nil && r.ContentLength > 0 {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies[key] = body
+		}
+		response, ok := responses[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func TestGitHubForge(t *testing.T) {
+	server, bodies := forgeServer(t, "Authorization", "Bearer tok", map[string]string{
+		"GET repos/acme/app/issues": `[{"number": 3, "title": "Crash on start", "state": "open", "html_url": "u3", "user": {"login": "ann"}, "labels": [{"name": "bug"}]},
+			{"number": 2, "title": "Fix crash", "state": "open", "pull_request": {}}]`,
+		"GET repos/acme/app/pulls/2 diff":        "diff --git a/main.go b/main.go\n",
+		"GET repos/acme/app/issues/2/comments":   `[{"body": "Looks good", "user": {"login": "bob"}}]`,
+		"GET repos/acme/app/pulls/2/comments":    `[{"body": "Check nil", "path": "main.go", "line": 7, "user": {"login": "cid"}}]`,
+		"GET repos/acme/app":                     `{"default_branch": "main"}`,
+		"POST repos/acme/app/pulls":              `{"number": 4, "title": "Add run", "html_url": "u4"}`,
+		"GET repos/acme/app/actions/runs":        `{"workflow_runs": [{"id": 9, "name": "CI", "html_url": "u9", "head_branch": "main"}]}`,
+		"GET repos/acme/app/actions/runs/9/jobs": `{"jobs": [{"id": 1, "name": "lint", "conclusion": "success"}, {"id": 2, "name": "test", "conclusion": "failure"}]}`,
+		"GET repos/acme/app/actions/jobs/2/logs": "--- FAIL: TestRun\n",
+		"POST repos/acme/app/issues":             `{"number": 10, "title": "Handle flags", "state": "open", "html_url": "u10", "user": {"login": "ann"}}`,
+	})
+	forge, err := NewForge(&Repo{Provider: ProviderGitHub, Host: "github.com", Path: "acme/app"}, server.URL, "tok", "")
+	if err != nil {
+		t.Fatalf("NewForge: %v", err)
+	}
+	ctx := context.Background()
+
+	issues, err := forge.ListIssues(ctx, "open", 10)
+	if err != nil {
+		t.Fatalf("ListIssues: %v", err)
+	}
+	want := []Issue{{Number: 3, Title: "Crash on start", State: "open", Author: "ann", URL: "u3", Labels: []string{"bug"}}}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("ListIssues = %+v, want %+v (pull requests filtered out)", issues, want)
+	}
+
+	if diff, err := forge.PullRequestDiff(ctx, 2); err != nil || !strings.HasPrefix(diff, "diff --git") {
+		t.Errorf("PullRequestDiff = %q, %v", diff, err)
+	}
+	comments, err := forge.PullRequestComments(ctx, 2)
+	if err != nil {
+		t.Fatalf("PullRequestComments: %v", err)
+	}
+	wantComments := []Comment{{Author: "bob", Body: "Looks good"}, {Author: "cid", Body: "Check nil", Path: "main.go", Line: 7}}
+	if !reflect.DeepEqual(comments, wantComments) {
+		t.Errorf("PullRequestComments = %+v, want %+v", comments, wantComments)
+	}
+
+	pr, err := forge.CreatePullRequest(ctx, NewPullRequest{Title: "Add run", Head: "task/add-run"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if pr.Number != 4 || pr.Base != "main" || bodies["POST repos/acme/app/pulls"]["base"] != "main" {
+		t.Errorf("CreatePullRequest = %+v with body %v, want #4 into the default branch", pr, bodies["POST repos/acme/app/pulls"])
+	}
+
+	issue, err := forge.CreateIssue(ctx, "Handle flags", "From main.go")
+	if err != nil {
+		t.Fatalf("CreateIssue: %v", err)
+	}
+	if issue.Number != 10 || issue.URL != "u10" || bodies["POST repos/acme/app/issues"]["body"] != "From main.go" {
+		t.Errorf("CreateIssue = %+v with body %v", issue, bodies["POST repos/acme/app/issues"])
+	}
+
+	run, err := forge.LatestFailedRun(ctx)
+	if err != nil {
+		t.Fatalf("LatestFailedRun: %v", err)
+	}
+	if run.ID != 9 || !reflect.DeepEqual(run.Jobs, []CIJob{{Name: "test", Log: "--- FAIL: TestRun\n"}}) {
+		t.Errorf("LatestFailedRun = %+v, want run 9 with the failed test job", run)
+	}
+}
+
+func TestGitLabForge(t *testing.T) {
+	server, bodies := forgeServer(t, "PRIVATE-TOKEN", "tok", map[string]string{
+		"GET projects/group%2Fapp/merge_requests/5/diffs": `[{"old_path": "a.go", "new_path": "a.go", "diff": "@@ -1 +1 @@\n-x\n+y"}]`,
+		"GET projects/group%2Fapp/merge_requests/5/notes": `[{"body": "added label", "system": true, "author": {"username": "bot"}},
+			{"body": "Rename this", "author": {"username": "dee"}, "position": {"new_path": "a.go", "new_line": 1}}]`,
+		"POST projects/group%2Fapp/merge_requests": `{"iid": 6, "title": "Draft: Add run", "web_url": "u6"}`,
+		"POST projects/group%2Fapp/issues":         `{"iid": 11, "title": "Handle flags", "state": "opened", "web_url": "u11"}`,
+	})
+	forge, err := NewForge(&Repo{Provider: ProviderGitLab, Host: "gitlab.com", Path: "group/app"}, server.URL, "tok", "")
+	if err != nil {
+		t.Fatalf("NewForge: %v", err)
+	}
+	ctx := context.Background()
+
+	diff, err := forge.PullRequestDiff(ctx, 5)
+	if err != nil {
+		t.Fatalf("PullRequestDiff: %v", err)
+	}
+	if want := "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-x\n+y\n"; diff != want {
+		t.Errorf("PullRequestDiff = %q, want %q", diff, want)
+	}
+
+	comments, err := forge.PullRequestComments(ctx, 5)
+	if err != nil {
+		t.Fatalf("PullRequestComments: %v", err)
+	}
+	if want := []Comment{{Author: "dee", Body: "Rename this", Path: "a.go", Line: 1}}; !reflect.DeepEqual(comments, want) {
+		t.Errorf("PullRequestComments = %+v, want %+v (system notes skipped)", comments, want)
+	}
+
+	pr, err := forge.CreatePullRequest(ctx, NewPullRequest{Title: "Add run", Head: "task/add-run", Base: "develop", Draft: true})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	body := bodies["POST projects/group%2Fapp/merge_requests"]
+	if pr.Number != 6 || body["title"] != "Draft: Add run" || body["target_branch"] != "develop" || body["source_branch"] != "task/add-run" {
+		t.Errorf("CreatePullRequest = %+v with body %v", pr, body)
+	}
+
+	issue, err := forge.CreateIssue(ctx, "Handle flags", "From main.go")
+	if err != nil {
+		t.Fatalf("CreateIssue: %v", err)
+	}
+	if issue.Number != 11 || issue.URL != "u11" || bodies["POST projects/group%2Fapp/issues"]["description"] != "From main.go" {
+		t.Errorf("CreateIssue = %+v with body %v", issue, bodies["POST projects/group%2Fapp/issues"])
+	}
+}
+
+func TestParseIssueRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		wantRepo   *Repo
+		wantNumber int
+		wantErr    bool
+	}{
+		{"#12", nil, 12, false},
+		{"7", nil, 7, false},
+		{"https://github.com/acme/app/issues/42", &Repo{ProviderGitHub, "github.com", "acme/app"}, 42, false},
+		{"https://gitlab.com/group/sub/app/-/issues/5#note_1", &Repo{ProviderGitLab, "gitlab.com", "group/sub/app"}, 5, false},
+		{"https://github.com/acme/app/pull/3", nil, 0, true},
+		{"#0", nil, 0, true},
+	}
+	for _, tt := range tests {
+		repo, number, err := ParseIssueRef(tt.ref, ProviderAuto)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseIssueRef(%q) = %+v, %d; want error", tt.ref, repo, number)
+			}
+			continue
+		}
+		if err != nil || number != tt.wantNumber || !reflect.DeepEqual(repo, tt.wantRepo) {
+			t.Errorf("ParseIssueRef(%q) = %+v, %d, %v; want %+v, %d", tt.ref, repo, number, err, tt.wantRepo, tt.wantNumber)
+		}
+	}
+}
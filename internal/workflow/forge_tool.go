@@ -0,0 +1,437 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// ForgeConfig selects the forge and credentials used by the issue and pull request tools
+type ForgeConfig struct {
+	Provider string // ProviderAuto, ProviderGitHub or ProviderGitLab
+	APIURL   string // REST API base URL; derived from the remote's host when empty
+	Token    string // API token; the gh or glab CLI is used when empty
+}
+
+// forgeClient connects to the forge of the repository in the working directory
+type forgeClient struct {
+	config ForgeConfig
+}
+
+func (c *forgeClient) forge(ctx context.Context) (Forge, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	repo, err := DetectRepo(ctx, cwd, c.config.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return NewForge(repo, c.config.APIURL, c.config.Token, cwd)
+}
+
+// NewForgeTools creates the tools for reading issues and pull requests, opening pull
+// requests and filing todo items as issues
+func NewForgeTools(config ForgeConfig) []tools.Tool {
+	client := &forgeClient{config: config}
+	return []tools.Tool{
+		&ListIssuesTool{client: client},
+		&ReadIssueTool{client: client},
+		&ReadPullRequestTool{client: client},
+		&CreatePullRequestTool{client: client},
+		&CreateTodoIssuesTool{client: client},
+	}
+}
+
+// forgeError wraps a forge failure for the agent
+func forgeError(tool, message string, err error) error {
+	return &tools.ErrToolExecution{ToolName: tool, Message: message, Err: err}
+}
+
+// intParam reads an integer parameter, which arrives as a float64 from JSON
+func intParam(params map[string]interface{}, name string, fallback int) int {
+	if v, ok := params[name].(float64); ok {
+		return int(v)
+	}
+	if v, ok := params[name].(int); ok {
+		return v
+	}
+	return fallback
+}
+
+// ListIssuesTool lists the repository's issues
+type ListIssuesTool struct {
+	client *forgeClient
+}
+
+// Name returns the tool name
+func (t *ListIssuesTool) Name() string {
+	return "listIssues"
+}
+
+// Description returns the tool description
+func (t *ListIssuesTool) Description() string {
+	return "Lists issues of the project's GitHub or GitLab repository (number, state, title, author, labels)"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *ListIssuesTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"state": {
+				Type:        "string",
+				Description: "Issue state to list (default: open)",
+				Enum:        []interface{}{"open", "closed", "all"},
+			},
+			"limit": {
+				Type:        "integer",
+				Description: "Maximum number of issues (default: 20, at most 100)",
+			},
+		},
+	}
+}
+
+// ResultSchema describes the issue list as a table
+func (t *ListIssuesTool) ResultSchema() tools.ResultSchema {
+	return tools.ResultSchema{Kind: tools.ResultTable, Rows: "issues", Columns: []string{"number", "state", "title", "author", "labels"}}
+}
+
+// ExternalContent marks the result as untrusted: issue titles are written by people outside the project
+func (t *ListIssuesTool) ExternalContent() bool {
+	return true
+}
+
+// Execute lists the issues
+func (t *ListIssuesTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	state, _ := params["state"].(string)
+	if state == "" {
+		state = "open"
+	}
+	limit := min(max(intParam(params, "limit", 20), 1), 100)
+
+	forge, err := t.client.forge(ctx)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to connect to the repository's forge", err)
+	}
+	issues, err := forge.ListIssues(ctx, state, limit)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to list issues", err)
+	}
+	return map[string]interface{}{"issues": issues, "count": len(issues)}, nil
+}
+
+// ReadIssueTool reads an issue with its comments
+type ReadIssueTool struct {
+	client *forgeClient
+}
+
+// Name returns the tool name
+func (t *ReadIssueTool) Name() string {
+	return "readIssue"
+}
+
+// Description returns the tool description
+func (t *ReadIssueTool) Description() string {
+	return "Reads an issue of the project's GitHub or GitLab repository with its description and comments"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *ReadIssueTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"number": {
+				Type:        "integer",
+				Description: "Issue number",
+			},
+		},
+		Required: []string{"number"},
+	}
+}
+
+// ExternalContent marks the result as untrusted: issues and comments are written by people outside the project
+func (t *ReadIssueTool) ExternalContent() bool {
+	return true
+}
+
+// Execute reads the issue
+func (t *ReadIssueTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	forge, err := t.client.forge(ctx)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to connect to the repository's forge", err)
+	}
+	issue, err := forge.Issue(ctx, intParam(params, "number", 0))
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to read issue", err)
+	}
+	return issue, nil
+}
+
+// ReadPullRequestTool reads a pull request's diff and comments
+type ReadPullRequestTool struct {
+	client *forgeClient
+}
+
+// Name returns the tool name
+func (t *ReadPullRequestTool) Name() string {
+	return "readPullRequest"
+}
+
+// Description returns the tool description
+func (t *ReadPullRequestTool) Description() string {
+	return "Reads the diff and the comments (including review comments on lines) of a pull request, or merge request on GitLab"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *ReadPullRequestTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"number": {
+				Type:        "integer",
+				Description: "Pull request number",
+			},
+			"include": {
+				Type:        "string",
+				Description: "What to read (default: both)",
+				Enum:        []interface{}{"diff", "comments", "both"},
+			},
+		},
+		Required: []string{"number"},
+	}
+}
+
+// ExternalContent marks the result as untrusted: pull request comments are written by people outside the project
+func (t *ReadPullRequestTool) ExternalContent() bool {
+	return true
+}
+
+// Execute reads the pull request
+func (t *ReadPullRequestTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	number := intParam(params, "number", 0)
+	include, _ := params["include"].(string)
+	if include == "" {
+		include = "both"
+	}
+
+	forge, err := t.client.forge(ctx)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to connect to the repository's forge", err)
+	}
+	result := map[string]interface{}{"number": number}
+	if include != "comments" {
+		diff, err := forge.PullRequestDiff(ctx, number)
+		if err != nil {
+			return nil, forgeError(t.Name(), "failed to read pull request diff", err)
+		}
+		result["diff"] = diff
+	}
+	if include != "diff" {
+		comments, err := forge.PullRequestComments(ctx, number)
+		if err != nil {
+			return nil, forgeError(t.Name(), "failed to read pull request comments", err)
+		}
+		result["comments"] = comments
+	}
+	return result, nil
+}
+
+// CreatePullRequestTool opens a pull request from a pushed branch
+type CreatePullRequestTool struct {
+	client *forgeClient
+}
+
+// Name returns the tool name
+func (t *CreatePullRequestTool) Name() string {
+	return "createPullRequest"
+}
+
+// Description returns the tool description
+func (t *CreatePullRequestTool) Description() string {
+	return "Opens a pull request (merge request on GitLab) from a branch that has been pushed. Mention the issue it closes in the body, e.g. \"Closes #12\""
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *CreatePullRequestTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"title": {
+				Type:        "string",
+				Description: "Pull request title",
+			},
+			"body": {
+				Type:        "string",
+				Description: "Pull request description in Markdown",
+			},
+			"head": {
+				Type:        "string",
+				Description: "Branch with the changes (default: the current branch)",
+			},
+			"base": {
+				Type:        "string",
+				Description: "Branch to merge into (default: the repository's default branch)",
+			},
+			"draft": {
+				Type:        "boolean",
+				Description: "Open as a draft (default: false)",
+			},
+		},
+		Required: []string{"title"},
+	}
+}
+
+// Execute opens the pull request
+func (t *CreatePullRequestTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+	pr := NewPullRequest{}
+	pr.Title, _ = params["title"].(string)
+	pr.Body, _ = params["body"].(string)
+	pr.Head, _ = params["head"].(string)
+	pr.Base, _ = params["base"].(string)
+	pr.Draft, _ = params["draft"].(bool)
+
+	if pr.Head == "" {
+		cwd, _ := os.Getwd()
+		branch, err := gitOutput(ctx, cwd, "branch", "--show-current")
+		if err != nil || strings.TrimSpace(branch) == "" {
+			return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: "head is required when no branch is checked out"}
+		}
+		pr.Head = strings.TrimSpace(branch)
+	}
+
+	forge, err := t.client.forge(ctx)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to connect to the repository's forge", err)
+	}
+	created, err := forge.CreatePullRequest(ctx, pr)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to open pull request", err)
+	}
+	return created, nil
+}
+
+// CreateTodoIssuesTool files the open items of a todo plan as issues
+type CreateTodoIssuesTool struct {
+	client *forgeClient
+}
+
+// Name returns the tool name
+func (t *CreateTodoIssuesTool) Name() string {
+	return "createTodoIssues"
+}
+
+// Description returns the tool description
+func (t *CreateTodoIssuesTool) Description() string {
+	return "Opens an issue (GitHub or GitLab) for each open item of a todo plan that has none yet, and links the item to it. Only when the user asks for issues"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *CreateTodoIssuesTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"plan_id": {
+				Type:        "string",
+				Description: "Plan ID (optional, uses current plan if not specified)",
+			},
+			"task_ids": {
+				Type:        "array",
+				Items:       &tools.JSONSchema{Type: "string"},
+				Description: "Only file these items (default: every pending or in-progress item)",
+			},
+		},
+	}
+}
+
+// Execute opens the issues
+func (t *CreateTodoIssuesTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	planID, _ := params["plan_id"].(string)
+	plan, err := tools.TodoPlanByID(planID)
+	if err != nil {
+		return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+	}
+	only := make(map[string]bool)
+	if ids, ok := params["task_ids"].([]interface{}); ok {
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				only[s] = true
+			}
+		}
+	}
+
+	var pending []tools.TodoItem
+	for _, item := range plan.Items {
+		if item.Issue != "" || (len(only) > 0 && !only[item.ID]) {
+			continue
+		}
+		if item.Status == "pending" || item.Status == "in_progress" {
+			pending = append(pending, item)
+		}
+	}
+	if len(pending) == 0 {
+		return "No open items without an issue", nil
+	}
+
+	forge, err := t.client.forge(ctx)
+	if err != nil {
+		return nil, forgeError(t.Name(), "failed to connect to the repository's forge", err)
+	}
+	var created []map[string]interface{}
+	for _, item := range pending {
+		issue, err := forge.CreateIssue(ctx, item.Content, todoIssueBody(plan, item))
+		if err != nil {
+			// Items filed so far stay linked, so a retry does not file them again
+			return nil, forgeError(t.Name(), fmt.Sprintf("failed to open an issue for %s after opening %d", item.ID, len(created)), err)
+		}
+		if err := tools.LinkTodoIssue(plan.ID, item.ID, issue.URL); err != nil {
+			return nil, forgeError(t.Name(), "failed to link the issue", err)
+		}
+		created = append(created, map[string]interface{}{"task_id": item.ID, "number": issue.Number, "url": issue.URL})
+	}
+	return map[string]interface{}{"plan_id": plan.ID, "created": created}, nil
+}
+
+// todoIssueBody describes a todo item in an issue
+func todoIssueBody(plan *tools.TodoPlan, item tools.TodoItem) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From the todo plan **%s**", plan.Name)
+	if plan.Description != "" {
+		fmt.Fprintf(&b, ": %s", plan.Description)
+	}
+	b.WriteString("\n")
+	if item.File != "" {
+		fmt.Fprintf(&b, "\nLocation: `%s:%d`\n", item.File, item.Line)
+	}
+	if len(item.Dependencies) > 0 {
+		b.WriteString("\nDepends on:\n")
+		for _, dep := range item.Dependencies {
+			for _, other := range plan.Items {
+				if other.ID != dep {
+					continue
+				}
+				if other.Issue != "" {
+					fmt.Fprintf(&b, "- %s (%s)\n", other.Content, other.Issue)
+				} else {
+					fmt.Fprintf(&b, "- %s\n", other.Content)
+				}
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hook scripts written by codezilla
+const hookMarker = "# codezilla-hook"
+
+// HookMode controls what a hook does when the review reports problems
+type HookMode string
+
+const (
+	// HookModeWarn prints findings but never stops the git operation
+	HookModeWarn HookMode = "warn"
+	// HookModeBlock aborts the git operation on findings at or above the threshold
+	HookModeBlock HookMode = "block"
+)
+
+// HookOptions configures hook installation
+type HookOptions struct {
+	// Hooks lists the git hooks to install ("pre-commit", "pre-push")
+	Hooks []string
+	// Mode selects warn or block behavior
+	Mode HookMode
+	// FailOn is the minimum severity that blocks in block mode
+	FailOn Severity
+	// Binary is the codezilla executable invoked by the hook
+	Binary string
+	// Force overwrites hooks that were not written by codezilla (a backup is kept)
+	Force bool
+}
+
+// SupportedHooks lists the git hooks install-hooks knows how to write
+var SupportedHooks = []string{"pre-commit", "pre-push"}
+
+// InstallHooks writes codezilla review hooks into the git repository at repoDir
+// and returns the paths of the hooks that were written
+func InstallHooks(ctx context.Context, repoDir string, opts HookOptions) ([]string, error) {
+	if opts.Mode == "" {
+		opts.Mode = HookModeWarn
+	}
+	if opts.Mode != HookModeWarn && opts.Mode != HookModeBlock {
+		return nil, fmt.Errorf("unknown hook mode %q (expected warn or block)", opts.Mode)
+	}
+	if opts.FailOn == "" {
+		opts.FailOn = SeverityHigh
+	}
+	if opts.Binary == "" {
+		opts.Binary = "codezilla"
+	}
+	if len(opts.Hooks) == 0 {
+		opts.Hooks = []string{"pre-commit"}
+	}
+
+	// Ask git where hooks live so worktrees and core.hooksPath are respected
+	hooksDir, err := gitOutput(ctx, repoDir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	hooksDir = strings.TrimSpace(hooksDir)
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(repoDir, hooksDir)
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	var written []string
+	for _, hook := range opts.Hooks {
+		script, err := hookScript(hook, opts)
+		if err != nil {
+			return written, err
+		}
+
+		hookPath := filepath.Join(hooksDir, hook)
+		if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), hookMarker) {
+			if !opts.Force {
+				return written, fmt.Errorf("%s already exists and was not installed by codezilla (use -force to replace it)", hookPath)
+			}
+			if err := os.WriteFile(hookPath+".bak", existing, 0755); err != nil {
+				return written, fmt.Errorf("failed to back up %s: %w", hookPath, err)
+			}
+		}
+
+		if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", hookPath, err)
+		}
+		written = append(written, hookPath)
+	}
+
+	return written, nil
+}
+
+// hookScript renders the shell script for a single hook
+func hookScript(hook string, opts HookOptions) (string, error) {
+	var review string
+	switch hook {
+	case "pre-commit":
+		review = fmt.Sprintf("%s review -staged -fail-on %s", shellQuote(opts.Binary), opts.FailOn)
+	case "pre-push":
+		// git passes "<local ref> <local sha> <remote ref> <remote sha>" lines on stdin
+		review = fmt.Sprintf(`zero=0000000000000000000000000000000000000000
+status=0
+while read local_ref local_sha remote_ref remote_sha; do
+  [ "$local_sha" = "$zero" ] && continue
+  if [ "$remote_sha" = "$zero" ]; then
+    range="$(git merge-base HEAD @{upstream} 2>/dev/null || git rev-list --max-parents=0 HEAD | tail -n 1)..$local_sha"
+  else
+    range="$remote_sha..$local_sha"
+  fi
+  %s review -range "$range" -fail-on %s || status=$?
+done
+(exit $status)`, shellQuote(opts.Binary), opts.FailOn)
+	default:
+		return "", fmt.Errorf("unsupported hook %q (supported: %s)", hook, strings.Join(SupportedHooks, ", "))
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(fmt.Sprintf("%s: %s (mode: %s)\n", hookMarker, hook, opts.Mode))
+	b.WriteString("# Re-run `codezilla install-hooks` to change these settings.\n\n")
+	b.WriteString(review)
+	b.WriteString("\nstatus=$?\n\n")
+
+	if opts.Mode == HookModeBlock {
+		// Exit code 1 means findings met the threshold; anything else (e.g. Ollama
+		// being unreachable) should not prevent the user from committing
+		b.WriteString("if [ $status -eq 1 ]; then\n")
+		b.WriteString(fmt.Sprintf("  echo \"codezilla: %s-severity findings, %s aborted (bypass with --no-verify)\" >&2\n", opts.FailOn, strings.TrimPrefix(hook, "pre-")))
+		b.WriteString("  exit 1\n")
+		b.WriteString("fi\n")
+	}
+	b.WriteString("exit 0\n")
+
+	return b.String(), nil
+}
+
+// shellQuote quotes a string for safe use in a POSIX shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
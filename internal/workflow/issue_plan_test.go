@@ -0,0 +1,39 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIssuePlanResponse(t *testing.T) {
+	response := "Here is the plan:\n```json\n" + `{
+  "name": "Fix crash on start",
+  "description": "Stop the nil config from crashing startup",
+  "steps": [
+    {"content": "Find where the config is loaded", "priority": "high"},
+    {"content": "  ", "priority": "low"},
+    {"content": "Add a regression test", "priority": "urgent"}
+  ]
+}` + "\n```"
+
+	plan, err := parseIssuePlanResponse(response)
+	if err != nil {
+		t.Fatalf("parseIssuePlanResponse: %v", err)
+	}
+	want := []PlanStep{
+		{Content: "Find where the config is loaded", Priority: "high"},
+		{Content: "Add a regression test", Priority: "medium"},
+	}
+	if plan.Name != "Fix crash on start" || !reflect.DeepEqual(plan.Steps, want) {
+		t.Errorf("plan = %+v, want steps %+v", plan, want)
+	}
+
+	items, _ := plan.TodoParams()["items"].([]interface{})
+	if len(items) != 2 || items[1].(map[string]interface{})["content"] != "Add a regression test" {
+		t.Errorf("TodoParams items = %v", items)
+	}
+
+	if _, err := parseIssuePlanResponse(`{"name": "empty", "steps": []}`); err == nil {
+		t.Error("expected an error for a plan without steps")
+	}
+}
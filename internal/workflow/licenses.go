@@ -0,0 +1,304 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"codezilla/internal/tools"
+)
+
+// Dependency is one entry of a license inventory
+type Dependency struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Ecosystem  string `json:"ecosystem"` // "go" or "npm"
+	Direct     bool   `json:"direct"`
+	Dev        bool   `json:"dev,omitempty"`
+	License    string `json:"license"` // SPDX identifier or expression, "unknown" if not found
+	Disallowed bool   `json:"disallowed,omitempty"`
+}
+
+// LicenseInventory lists a project's dependencies and their licenses
+type LicenseInventory struct {
+	Dependencies []Dependency   `json:"dependencies"`
+	Licenses     map[string]int `json:"licenses"` // Dependency count per license
+	Violations   []Dependency   `json:"violations"`
+	Disallowed   []string       `json:"disallowed,omitempty"`
+}
+
+// licenseFileNames are checked in order when looking for a module's license
+var licenseFileNames = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "COPYING", "COPYING.md"}
+
+// BuildLicenseInventory inventories the Go and npm dependencies of the project in dir.
+// Transitive dependencies are included unless directOnly is set. Licenses matching an
+// entry in disallowed (e.g. "GPL-3.0", which also matches GPL-3.0-only) are flagged.
+func BuildLicenseInventory(dir string, directOnly bool, disallowed []string) (*LicenseInventory, error) {
+	var deps []Dependency
+	found := false
+
+	if fileExists(filepath.Join(dir, "go.mod")) {
+		found = true
+		goDeps, err := goDependencies(dir)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, goDeps...)
+	}
+	if fileExists(filepath.Join(dir, "package-lock.json")) {
+		found = true
+		npmDeps, err := npmDependencies(dir)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, npmDeps...)
+	}
+	if !found {
+		return nil, fmt.Errorf("no go.mod or package-lock.json found in %s", dir)
+	}
+
+	inv := &LicenseInventory{
+		Dependencies: []Dependency{},
+		Licenses:     make(map[string]int),
+		Violations:   []Dependency{},
+		Disallowed:   disallowed,
+	}
+	for _, d := range deps {
+		if directOnly && !d.Direct {
+			continue
+		}
+		d.Disallowed = licenseDisallowed(d.License, disallowed)
+		inv.Dependencies = append(inv.Dependencies, d)
+		inv.Licenses[d.License]++
+		if d.Disallowed {
+			inv.Violations = append(inv.Violations, d)
+		}
+	}
+	return inv, nil
+}
+
+// licenseDisallowed reports whether a license expression is disallowed. For "A OR B"
+// every alternative must be disallowed; for "A AND B" any disallowed part counts.
+func licenseDisallowed(license string, disallowed []string) bool {
+	if len(disallowed) == 0 || license == "" || license == "unknown" {
+		return false
+	}
+	expr := strings.Trim(license, "()")
+	if alternatives := strings.Split(expr, " OR "); len(alternatives) > 1 {
+		for _, alt := range alternatives {
+			if !licenseDisallowed(alt, disallowed) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, part := range strings.Split(expr, " AND ") {
+		part = strings.TrimSpace(strings.Trim(part, "()"))
+		for _, d := range disallowed {
+			if strings.EqualFold(part, d) || strings.HasPrefix(strings.ToLower(part), strings.ToLower(d)+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goDependencies reads direct requirements from go.mod and transitive modules from go.sum,
+// looking up licenses in the module cache
+func goDependencies(dir string) ([]Dependency, error) {
+	requirements, err := tools.GoModRequirements(dir)
+	if err != nil {
+		return nil, err
+	}
+	direct := make(map[string]string)
+	indirect := make(map[string]string)
+	for _, r := range requirements {
+		if r.Indirect {
+			indirect[r.Path] = r.Version
+		} else {
+			direct[r.Path] = r.Version
+		}
+	}
+
+	// go.sum lists every module whose contents were needed; lines ending in /go.mod are metadata only
+	versions := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, "go.sum")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 3 && !strings.HasSuffix(fields[1], "/go.mod") {
+				versions[fields[0]] = fields[1]
+			}
+		}
+	}
+	for mod, v := range indirect {
+		versions[mod] = v
+	}
+	for mod, v := range direct {
+		versions[mod] = v
+	}
+
+	cache := goModCache()
+	deps := make([]Dependency, 0, len(versions))
+	for mod, version := range versions {
+		_, isDirect := direct[mod]
+		deps = append(deps, Dependency{
+			Name:      mod,
+			Version:   version,
+			Ecosystem: "go",
+			Direct:    isDirect,
+			License:   detectLicenseInDir(filepath.Join(cache, escapeModulePath(mod)+"@"+version)),
+		})
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps, nil
+}
+
+// goModCache returns the module cache directory
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// escapeModulePath applies the module cache's case encoding (Upper becomes !upper)
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// npmDependencies reads package-lock.json (lockfile version 2 or 3)
+func npmDependencies(dir string) ([]Dependency, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package-lock.json"))
+	if err != nil {
+		return nil, err
+	}
+	var lock struct {
+		LockfileVersion int `json:"lockfileVersion"`
+		Packages        map[string]struct {
+			Version         string            `json:"version"`
+			License         interface{}       `json:"license"`
+			Dev             bool              `json:"dev"`
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse package-lock.json: %w", err)
+	}
+	if lock.Packages == nil {
+		return nil, fmt.Errorf("package-lock.json version %d is not supported; regenerate it with npm 7 or later", lock.LockfileVersion)
+	}
+
+	root := lock.Packages[""]
+	var deps []Dependency
+	for key, pkg := range lock.Packages {
+		i := strings.LastIndex(key, "node_modules/")
+		if key == "" || i < 0 {
+			continue
+		}
+		name := key[i+len("node_modules/"):]
+		_, isDep := root.Dependencies[name]
+		_, isDevDep := root.DevDependencies[name]
+
+		license := npmLicense(pkg.License)
+		if license == "unknown" {
+			license = detectLicenseInDir(filepath.Join(dir, key))
+		}
+		deps = append(deps, Dependency{
+			Name:      name,
+			Version:   pkg.Version,
+			Ecosystem: "npm",
+			Direct:    (isDep || isDevDep) && key == "node_modules/"+name,
+			Dev:       pkg.Dev,
+			License:   license,
+		})
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].Version < deps[j].Version
+	})
+	return deps, nil
+}
+
+// npmLicense reads a package's license field, which is a string or a legacy {type} object
+func npmLicense(v interface{}) string {
+	switch l := v.(type) {
+	case string:
+		if l != "" {
+			return l
+		}
+	case map[string]interface{}:
+		if t, ok := l["type"].(string); ok && t != "" {
+			return t
+		}
+	}
+	return "unknown"
+}
+
+// detectLicenseInDir identifies the license file in dir
+func detectLicenseInDir(dir string) string {
+	for _, name := range licenseFileNames {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			return DetectLicense(string(data))
+		}
+	}
+	return "unknown"
+}
+
+// DetectLicense identifies common licenses from their text and returns the SPDX identifier
+func DetectLicense(text string) string {
+	t := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	has := func(s string) bool { return strings.Contains(t, s) }
+
+	switch {
+	case has("gnu affero general public license"):
+		return "AGPL-3.0"
+	case has("gnu lesser general public license"):
+		if has("version 3") {
+			return "LGPL-3.0"
+		}
+		return "LGPL-2.1"
+	case has("gnu general public license"):
+		if has("version 3") {
+			return "GPL-3.0"
+		}
+		return "GPL-2.0"
+	case has("mozilla public license") && has("2.0"):
+		return "MPL-2.0"
+	case has("apache license") && has("version 2.0"):
+		return "Apache-2.0"
+	case has("permission is hereby granted, free of charge"):
+		return "MIT"
+	case has("redistribution and use in source and binary forms"):
+		if has("neither the name") || has("names of its contributors") {
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case has("permission to use, copy, modify, and/or distribute this software for any purpose"),
+		has("permission to use, copy, modify, and distribute this software for any purpose"):
+		return "ISC"
+	case has("this is free and unencumbered software released into the public domain"):
+		return "Unlicense"
+	case has("eclipse public license"):
+		return "EPL-2.0"
+	}
+	return "unknown"
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectLicense(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Permission is hereby granted, free of charge, to any person obtaining a copy", "MIT"},
+		{"Apache License\n   Version 2.0, January 2004", "Apache-2.0"},
+		{"Redistribution and use in source and binary forms ... Neither the name of Google Inc.", "BSD-3-Clause"},
+		{"GNU GENERAL PUBLIC LICENSE\n Version 3, 29 June 2007", "GPL-3.0"},
+		{"Mozilla Public License Version 2.0", "MPL-2.0"},
+		{"All rights reserved.", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := DetectLicense(tt.text); got != tt.want {
+			t.Errorf("DetectLicense(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLicenseDisallowed(t *testing.T) {
+	disallowed := []string{"GPL-3.0", "AGPL-3.0"}
+	tests := []struct {
+		license string
+		want    bool
+	}{
+		{"MIT", false},
+		{"GPL-3.0", true},
+		{"GPL-3.0-or-later", true},
+		{"LGPL-3.0", false},
+		{"(MIT OR GPL-3.0)", false},
+		{"(AGPL-3.0 OR GPL-3.0-only)", true},
+		{"MIT AND GPL-3.0", true},
+		{"unknown", false},
+	}
+	for _, tt := range tests {
+		if got := licenseDisallowed(tt.license, disallowed); got != tt.want {
+			t.Errorf("licenseDisallowed(%q) = %v, want %v", tt.license, got, tt.want)
+		}
+	}
+}
+
+func TestBuildLicenseInventory(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	t.Setenv("GOMODCACHE", cache)
+
+	write := func(path, content string) {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(dir, "go.mod"), "module example\n\nrequire (\n\tgithub.com/Foo/bar v1.0.0\n\tgithub.com/x/dep v0.1.0 // indirect\n)\n")
+	write(filepath.Join(dir, "go.sum"), "github.com/Foo/bar v1.0.0 h1:abc=\ngithub.com/Foo/bar v1.0.0/go.mod h1:def=\ngithub.com/x/dep v0.1.0 h1:ghi=\ngithub.com/y/meta v0.2.0/go.mod h1:jkl=\n")
+	write(filepath.Join(cache, "github.com/!foo/bar@v1.0.0", "LICENSE"), "Permission is hereby granted, free of charge")
+	write(filepath.Join(cache, "github.com/x/dep@v0.1.0", "COPYING"), "GNU GENERAL PUBLIC LICENSE Version 3")
+
+	inv, err := BuildLicenseInventory(dir, false, []string{"GPL-3.0"})
+	if err != nil {
+		t.Fatalf("BuildLicenseInventory failed: %v", err)
+	}
+	if len(inv.Dependencies) != 2 {
+		t.Fatalf("got %d dependencies, want 2 (go.mod-only go.sum lines are skipped): %+v", len(inv.Dependencies), inv.Dependencies)
+	}
+	bar, dep := inv.Dependencies[0], inv.Dependencies[1]
+	if bar.Name != "github.com/Foo/bar" || !bar.Direct || bar.License != "MIT" {
+		t.Errorf("unexpected direct dependency: %+v", bar)
+	}
+	if dep.Direct || dep.License != "GPL-3.0" || !dep.Disallowed {
+		t.Errorf("unexpected transitive dependency: %+v", dep)
+	}
+	if len(inv.Violations) != 1 || inv.Licenses["MIT"] != 1 {
+		t.Errorf("unexpected summary: violations %v, licenses %v", inv.Violations, inv.Licenses)
+	}
+
+	direct, err := BuildLicenseInventory(dir, true, nil)
+	if err != nil || len(direct.Dependencies) != 1 {
+		t.Errorf("direct_only inventory = %+v, %v", direct, err)
+	}
+}
@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"codezilla/internal/tools"
+)
+
+// LicenseTool exposes the license inventory to the agent
+type LicenseTool struct {
+	// Disallowed lists licenses to flag, from the disallowed_licenses setting
+	Disallowed []string
+}
+
+// NewLicenseTool creates a new licenseInventory tool
+func NewLicenseTool(disallowed []string) *LicenseTool {
+	return &LicenseTool{Disallowed: disallowed}
+}
+
+// Name returns the tool name
+func (t *LicenseTool) Name() string {
+	return "licenseInventory"
+}
+
+// Description returns the tool description
+func (t *LicenseTool) Description() string {
+	return "Lists the project's Go (go.mod/go.sum) and npm (package-lock.json) dependencies with their versions and licenses as JSON, and flags disallowed licenses"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *LicenseTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"direct_only": {
+				Type:        "boolean",
+				Description: "Only list direct dependencies (default: false, include transitive ones)",
+			},
+			"disallowed": {
+				Type:        "array",
+				Description: "Licenses to flag, overriding the configured list (SPDX identifiers such as GPL-3.0)",
+				Items:       &tools.JSONSchema{Type: "string"},
+			},
+		},
+	}
+}
+
+// Execute builds the inventory and returns it as JSON
+func (t *LicenseTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	directOnly, _ := params["direct_only"].(bool)
+	disallowed := t.Disallowed
+	if list, ok := params["disallowed"].([]interface{}); ok {
+		disallowed = nil
+		for _, l := range list {
+			if s, ok := l.(string); ok && s != "" {
+				disallowed = append(disallowed, s)
+			}
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to get working directory", Err: err}
+	}
+
+	inventory, err := BuildLicenseInventory(cwd, directOnly, disallowed)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to build license inventory", Err: err}
+	}
+
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to encode inventory", Err: err}
+	}
+	return string(data), nil
+}
@@ -0,0 +1,217 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+// Severity ranks how serious a review finding is
+type Severity string
+
+const (
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// severityRank orders severities so thresholds can be compared
+var severityRank = map[Severity]int{
+	SeverityLow:    1,
+	SeverityMedium: 2,
+	SeverityHigh:   3,
+}
+
+// ParseSeverity converts a user supplied string into a Severity
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("unknown severity %q (expected low, medium or high)", s)
+	}
+	return sev, nil
+}
+
+// AtLeast reports whether s is at least as severe as threshold
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRank[s] >= severityRank[threshold]
+}
+
+// Finding is a single issue reported by the review workflow
+type Finding struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// ReviewResult holds the outcome of reviewing a diff
+type ReviewResult struct {
+	Summary   string    `json:"summary"`
+	Findings  []Finding `json:"findings"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// HasFindingsAtLeast reports whether any finding meets the severity threshold
+func (r *ReviewResult) HasFindingsAtLeast(threshold Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewWorkflow asks the model to review a unified diff
+type ReviewWorkflow struct {
+	llmClient tools.LLMClient
+	logger    *logger.Logger
+	// MaxDiffChars limits how much of the diff is sent to the model
+	MaxDiffChars int
+}
+
+// NewReviewWorkflow creates a new review workflow
+func NewReviewWorkflow(llmClient tools.LLMClient, logger *logger.Logger) *ReviewWorkflow {
+	return &ReviewWorkflow{
+		llmClient:    llmClient,
+		logger:       logger,
+		MaxDiffChars: 30000,
+	}
+}
+
+// ReviewDiff reviews the given unified diff and returns structured findings
+func (w *ReviewWorkflow) ReviewDiff(ctx context.Context, diff string) (*ReviewResult, error) {
+	if strings.TrimSpace(diff) == "" {
+		return &ReviewResult{Summary: "No changes to review"}, nil
+	}
+
+	truncated := false
+	if w.MaxDiffChars > 0 && len(diff) > w.MaxDiffChars {
+		cut := w.MaxDiffChars
+		for cut > 0 && !utf8.RuneStart(diff[cut]) {
+			cut--
+		}
+		diff = diff[:cut] + "\n\n[... diff truncated ...]\n"
+		truncated = true
+	}
+
+	prompt := fmt.Sprintf(`Review the following change set like a careful senior engineer.
+
+Report bugs, security problems, data loss risks, race conditions and clear mistakes.
+Do not report style nits.
+
+Diff:
+%s
+
+Format your response as JSON with these fields:
+- summary: string (one or two sentences)
+- findings: array of objects with fields
+  - file: string (path as shown in the diff)
+  - line: number (line in the new file, 0 if unknown)
+  - severity: "low", "medium" or "high"
+  - message: string`, diff)
+
+	messages := []tools.LLMMessage{
+		{
+			Role:    "system",
+			Content: "You are a code review assistant. Be precise and only report real problems. Return valid JSON only.",
+		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}
+
+	response, err := w.llmClient.GenerateResponse(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("review request failed: %w", err)
+	}
+
+	result, err := parseReviewResponse(response)
+	if err != nil {
+		w.logger.Warn("Failed to parse review response", "error", err)
+		return nil, err
+	}
+	result.Truncated = truncated
+
+	return result, nil
+}
+
+// parseReviewResponse extracts the JSON review result from a model response
+func parseReviewResponse(response string) (*ReviewResult, error) {
+	jsonStr := extractJSONObject(response)
+
+	var result ReviewResult
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse review JSON: %w", err)
+	}
+
+	// Normalize severities so threshold checks behave predictably
+	for i := range result.Findings {
+		sev, err := ParseSeverity(string(result.Findings[i].Severity))
+		if err != nil {
+			sev = SeverityMedium
+		}
+		result.Findings[i].Severity = sev
+	}
+
+	// Most severe first, then by location
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] > severityRank[b.Severity]
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	return &result, nil
+}
+
+// extractJSONObject pulls a JSON object out of a response that may wrap it in markdown
+func extractJSONObject(response string) string {
+	if idx := strings.Index(response, "```json"); idx >= 0 {
+		start := idx + 7
+		if end := strings.Index(response[start:], "```"); end >= 0 {
+			return response[start : start+end]
+		}
+	}
+	if idx := strings.Index(response, "{"); idx >= 0 {
+		if end := strings.LastIndex(response, "}"); end >= idx {
+			return response[idx : end+1]
+		}
+	}
+	return response
+}
+
+// StagedDiff returns the diff of changes staged for commit in dir
+func StagedDiff(ctx context.Context, dir string) (string, error) {
+	return gitOutput(ctx, dir, "diff", "--cached", "--no-color", "--unified=5")
+}
+
+// RangeDiff returns the diff between two revisions in dir
+func RangeDiff(ctx context.Context, dir, revRange string) (string, error) {
+	return gitOutput(ctx, dir, "diff", "--no-color", "--unified=5", revRange)
+}
+
+// gitOutput runs git with the given arguments and returns its stdout
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"codezilla/internal/tools"
+	"codezilla/pkg/logger"
+)
+
+type fakeLLM struct {
+	response string
+}
+
+func (f *fakeLLM) GenerateResponse(ctx context.Context, messages []tools.LLMMessage) (string, error) {
+	return f.response, nil
+}
+
+func TestReviewDiffParsesFindings(t *testing.T) {
+	llm := &fakeLLM{response: "Here is my review:\n```json\n" + `{
+  "summary": "Two issues",
+  "findings": [
+    {"file": "b.go", "line": 3, "severity": "low", "message": "naming"},
+    {"file": "a.go", "line": 10, "severity": "CRITICAL", "message": "unknown severity"},
+    {"file": "a.go", "line": 2, "severity": "high", "message": "nil dereference"}
+  ]
+}` + "\n```"}
+
+	log, _ := logger.New(logger.Config{Silent: true})
+	result, err := NewReviewWorkflow(llm, log).ReviewDiff(context.Background(), "diff --git a/a.go b/a.go\n+x")
+	if err != nil {
+		t.Fatalf("ReviewDiff failed: %v", err)
+	}
+
+	if len(result.Findings) != 3 {
+		t.Fatalf("expected 3 findings, got %d", len(result.Findings))
+	}
+	if result.Findings[0].Severity != SeverityHigh || result.Findings[0].File != "a.go" {
+		t.Errorf("expected high finding in a.go first, got %+v", result.Findings[0])
+	}
+	if result.Findings[1].Severity != SeverityMedium {
+		t.Errorf("expected unknown severity to normalize to medium, got %q", result.Findings[1].Severity)
+	}
+	if !result.HasFindingsAtLeast(SeverityHigh) {
+		t.Error("expected high-severity findings to meet the high threshold")
+	}
+}
+
+func TestReviewDiffEmpty(t *testing.T) {
+	log, _ := logger.New(logger.Config{Silent: true})
+	result, err := NewReviewWorkflow(&fakeLLM{}, log).ReviewDiff(context.Background(), "  ")
+	if err != nil {
+		t.Fatalf("ReviewDiff failed: %v", err)
+	}
+	if len(result.Findings) != 0 || result.HasFindingsAtLeast(SeverityLow) {
+		t.Errorf("expected no findings for an empty diff, got %+v", result)
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	tests := []struct {
+		s, threshold Severity
+		want         bool
+	}{
+		{SeverityHigh, SeverityLow, true},
+		{SeverityMedium, SeverityMedium, true},
+		{SeverityLow, SeverityHigh, false},
+	}
+	for _, tt := range tests {
+		if got := tt.s.AtLeast(tt.threshold); got != tt.want {
+			t.Errorf("%s.AtLeast(%s) = %v, want %v", tt.s, tt.threshold, got, tt.want)
+		}
+	}
+
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
+
+func TestHookScript(t *testing.T) {
+	script, err := hookScript("pre-commit", HookOptions{Mode: HookModeBlock, FailOn: SeverityHigh, Binary: "/usr/bin/codezilla"})
+	if err != nil {
+		t.Fatalf("hookScript failed: %v", err)
+	}
+	for _, want := range []string{hookMarker, "'/usr/bin/codezilla' review -staged -fail-on high", "exit 1"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("pre-commit script missing %q:\n%s", want, script)
+		}
+	}
+
+	warn, _ := hookScript("pre-commit", HookOptions{Mode: HookModeWarn, FailOn: SeverityHigh, Binary: "codezilla"})
+	if strings.Contains(warn, "exit 1") {
+		t.Errorf("warn mode script should never block:\n%s", warn)
+	}
+
+	if _, err := hookScript("post-merge", HookOptions{Binary: "codezilla"}); err == nil {
+		t.Error("expected error for unsupported hook")
+	}
+}
+
+func TestReviewDiffTruncatesOnCharacters(t *testing.T) {
+	llm := &promptRecorder{}
+	log, _ := logger.New(logger.Config{Silent: true})
+	w := NewReviewWorkflow(llm, log)
+	w.MaxDiffChars = 40
+
+	if _, err := w.ReviewDiff(context.Background(), "+"+strings.Repeat("ü", 40)); err != nil {
+		t.Fatalf("ReviewDiff failed: %v", err)
+	}
+	if !strings.Contains(llm.prompt, "diff truncated") {
+		t.Fatal("expected the diff to be truncated")
+	}
+	if !utf8.ValidString(llm.prompt) {
+		t.Errorf("truncation split a character: %q", llm.prompt)
+	}
+}
@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"fmt"
+
+	"codezilla/internal/tools"
+)
+
+// scannerManifests are the files vulnerabilities found by each scanner are reported
+// against, as code scanning needs a location for every result
+var scannerManifests = map[string]string{
+	ScannerGovulncheck: "go.mod",
+	ScannerNpmAudit:    "package.json",
+}
+
+// CodeIssues returns the review findings as issues, for SARIF output
+func (r *ReviewResult) CodeIssues() []tools.CodeIssue {
+	issues := make([]tools.CodeIssue, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		issues = append(issues, tools.CodeIssue{
+			File:        f.File,
+			Line:        f.Line,
+			Severity:    string(f.Severity),
+			Message:     f.Message,
+			Rule:        tools.ClassifyIssue(f.Message, false),
+			Sources:     []string{"review"},
+			Occurrences: 1,
+		})
+	}
+	return issues
+}
+
+// CodeIssues returns the vulnerabilities as issues named by their advisory ID, for
+// SARIF output and the baseline. The call path is left out of the message, as it
+// changes with the code calling the vulnerable function.
+func (r *VulnReport) CodeIssues() []tools.CodeIssue {
+	issues := make([]tools.CodeIssue, 0, len(r.Vulnerabilities))
+	for _, v := range r.Vulnerabilities {
+		message := fmt.Sprintf("%s: %s", v.Package, v.Title)
+		if v.Version != "" {
+			message = fmt.Sprintf("%s@%s: %s", v.Package, v.Version, v.Title)
+		}
+		if v.FixedVersion != "" {
+			message += fmt.Sprintf(" (fixed in %s)", v.FixedVersion)
+		}
+		issues = append(issues, tools.CodeIssue{
+			File:        scannerManifests[v.Scanner],
+			Severity:    string(v.Severity),
+			Message:     message,
+			Rule:        v.ID,
+			URL:         v.URL,
+			Sources:     []string{v.Scanner},
+			Occurrences: 1,
+		})
+	}
+	return issues
+}
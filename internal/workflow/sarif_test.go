@@ -0,0 +1,21 @@
+package workflow
+
+import "testing"
+
+func TestVulnReportCodeIssues(t *testing.T) {
+	report := &VulnReport{Vulnerabilities: []Vulnerability{{
+		ID: "GO-2023-2102", Package: "golang.org/x/net", Version: "v0.15.0", Severity: SeverityHigh,
+		Title: "HTTP/2 rapid reset", FixedVersion: "v0.17.0", Path: []string{"main.serve", "http2.ServeConn"},
+		URL: "https://pkg.go.dev/vuln/GO-2023-2102", Scanner: ScannerGovulncheck,
+	}}}
+
+	issues := report.CodeIssues()
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues", len(issues))
+	}
+	got := issues[0]
+	want := "golang.org/x/net@v0.15.0: HTTP/2 rapid reset (fixed in v0.17.0)"
+	if got.File != "go.mod" || got.Rule != "GO-2023-2102" || got.Severity != "high" || got.Message != want || got.URL == "" {
+		t.Errorf("CodeIssues() = %+v", got)
+	}
+}
@@ -0,0 +1,383 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// Vulnerability scanners supported by RunVulnCheck
+const (
+	ScannerGovulncheck = "govulncheck"
+	ScannerNpmAudit    = "npm-audit"
+)
+
+// Vulnerability is a known vulnerability affecting the project, normalized across scanners
+type Vulnerability struct {
+	ID           string   `json:"id"`
+	Package      string   `json:"package"`
+	Version      string   `json:"version,omitempty"`
+	Severity     Severity `json:"severity"`
+	Title        string   `json:"title"`
+	FixedVersion string   `json:"fixed_version,omitempty"`
+	// Path is the call path for Go (caller first) or the dependency path for npm (direct dependency first)
+	Path    []string `json:"path,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Scanner string   `json:"scanner"`
+}
+
+// VulnReport is the combined result of the vulnerability scanners that ran
+type VulnReport struct {
+	Scanners        []string          `json:"scanners"`
+	Vulnerabilities []Vulnerability   `json:"vulnerabilities"`
+	Skipped         map[string]string `json:"skipped,omitempty"` // Scanner name to reason
+	// Suppressed counts the vulnerabilities left out because the baseline accepts them
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// ApplyBaseline leaves out the vulnerabilities the baseline of the project in root
+// accepts
+func (r *VulnReport) ApplyBaseline(baseline *tools.Baseline, root string) {
+	issues := r.CodeIssues()
+	kept := r.Vulnerabilities[:0]
+	for i, v := range r.Vulnerabilities {
+		if remaining, _ := baseline.Filter(root, issues[i:i+1]); len(remaining) > 0 {
+			kept = append(kept, v)
+		} else {
+			r.Suppressed++
+		}
+	}
+	r.Vulnerabilities = kept
+}
+
+// HasVulnerabilitiesAtLeast reports whether any vulnerability meets the severity threshold
+func (r *VulnReport) HasVulnerabilitiesAtLeast(threshold Severity) bool {
+	for _, v := range r.Vulnerabilities {
+		if v.Severity.AtLeast(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunVulnCheck runs the scanners that apply to the project in dir. scanner selects one
+// scanner by name; "" or "auto" runs govulncheck for Go modules and npm audit for npm projects.
+func RunVulnCheck(ctx context.Context, dir, scanner string) (*VulnReport, error) {
+	report := &VulnReport{Vulnerabilities: []Vulnerability{}, Skipped: make(map[string]string)}
+
+	auto := scanner == "" || scanner == "auto"
+	runGo := scanner == ScannerGovulncheck || (auto && fileExists(filepath.Join(dir, "go.mod")))
+	runNpm := scanner == ScannerNpmAudit || (auto && fileExists(filepath.Join(dir, "package.json")))
+	if !auto && !runGo && !runNpm {
+		return nil, fmt.Errorf("unknown scanner %q (expected %s or %s)", scanner, ScannerGovulncheck, ScannerNpmAudit)
+	}
+	if !runGo && !runNpm {
+		return nil, fmt.Errorf("no Go module or npm project found in %s", dir)
+	}
+
+	if runGo {
+		if _, err := exec.LookPath("govulncheck"); err != nil {
+			report.Skipped[ScannerGovulncheck] = "govulncheck is not installed (go install golang.org/x/vuln/cmd/govulncheck@latest)"
+		} else if out, err := scannerOutput(ctx, dir, "govulncheck", "-json", "./..."); err != nil {
+			report.Skipped[ScannerGovulncheck] = err.Error()
+		} else if vulns, err := ParseGovulncheck(out); err != nil {
+			report.Skipped[ScannerGovulncheck] = err.Error()
+		} else {
+			report.Scanners = append(report.Scanners, ScannerGovulncheck)
+			report.Vulnerabilities = append(report.Vulnerabilities, vulns...)
+		}
+	}
+
+	if runNpm {
+		if _, err := exec.LookPath("npm"); err != nil {
+			report.Skipped[ScannerNpmAudit] = "npm is not installed"
+		} else if out, err := scannerOutput(ctx, dir, "npm", "audit", "--json"); err != nil {
+			report.Skipped[ScannerNpmAudit] = err.Error()
+		} else if vulns, err := ParseNpmAudit(out); err != nil {
+			report.Skipped[ScannerNpmAudit] = err.Error()
+		} else {
+			report.Scanners = append(report.Scanners, ScannerNpmAudit)
+			report.Vulnerabilities = append(report.Vulnerabilities, vulns...)
+		}
+	}
+
+	sort.SliceStable(report.Vulnerabilities, func(i, j int) bool {
+		return severityRank[report.Vulnerabilities[i].Severity] > severityRank[report.Vulnerabilities[j].Severity]
+	})
+	return report, nil
+}
+
+// scannerOutput runs a scanner and returns its stdout. Scanners that exit non-zero
+// because they found vulnerabilities still count as successful when they printed JSON.
+func scannerOutput(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && len(bytes.TrimSpace(out)) == 0 {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("%s failed: %s", name, msg)
+	}
+	return out, nil
+}
+
+// govulncheckMessage is one object in the govulncheck -json stream
+type govulncheckMessage struct {
+	OSV *struct {
+		ID       string   `json:"id"`
+		Summary  string   `json:"summary"`
+		Aliases  []string `json:"aliases"`
+		Database struct {
+			URL string `json:"url"`
+		} `json:"database_specific"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string `json:"osv"`
+		FixedVersion string `json:"fixed_version"`
+		Trace        []struct {
+			Module   string `json:"module"`
+			Version  string `json:"version"`
+			Package  string `json:"package"`
+			Function string `json:"function"`
+			Receiver string `json:"receiver"`
+			Position *struct {
+				Filename string `json:"filename"`
+				Line     int    `json:"line"`
+			} `json:"position"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+// ParseGovulncheck normalizes govulncheck -json output. govulncheck has no severity,
+// so reachability stands in for it: a called vulnerable function is high, an imported
+// vulnerable package medium and a required vulnerable module low.
+func ParseGovulncheck(data []byte) ([]Vulnerability, error) {
+	type advisory struct{ summary, url string }
+	advisories := make(map[string]advisory)
+	byID := make(map[string]*Vulnerability)
+	var order []string
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var msg govulncheckMessage
+		if err := dec.Decode(&msg); err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+		if msg.OSV != nil {
+			advisories[msg.OSV.ID] = advisory{summary: msg.OSV.Summary, url: msg.OSV.Database.URL}
+		}
+		if msg.Finding == nil || len(msg.Finding.Trace) == 0 {
+			continue
+		}
+
+		f := msg.Finding
+		vulnerable := f.Trace[0]
+		severity := SeverityLow
+		switch {
+		case vulnerable.Function != "":
+			severity = SeverityHigh
+		case vulnerable.Package != "":
+			severity = SeverityMedium
+		}
+
+		// Keep the most reachable finding per advisory
+		if existing, ok := byID[f.OSV]; ok && severityRank[existing.Severity] >= severityRank[severity] {
+			continue
+		}
+
+		var path []string
+		if severity == SeverityHigh {
+			for i := len(f.Trace) - 1; i >= 0; i-- {
+				frame := f.Trace[i]
+				name := frame.Package + "." + frame.Function
+				if frame.Receiver != "" {
+					name = frame.Package + "." + strings.TrimPrefix(frame.Receiver, "*") + "." + frame.Function
+				}
+				if frame.Position != nil && frame.Position.Filename != "" {
+					name += fmt.Sprintf(" (%s:%d)", frame.Position.Filename, frame.Position.Line)
+				}
+				path = append(path, name)
+			}
+		}
+
+		if _, ok := byID[f.OSV]; !ok {
+			order = append(order, f.OSV)
+		}
+		byID[f.OSV] = &Vulnerability{
+			ID:           f.OSV,
+			Package:      vulnerable.Module,
+			Version:      vulnerable.Version,
+			Severity:     severity,
+			FixedVersion: f.FixedVersion,
+			Path:         path,
+			Scanner:      ScannerGovulncheck,
+		}
+	}
+
+	vulns := make([]Vulnerability, 0, len(order))
+	for _, id := range order {
+		v := byID[id]
+		v.Title = advisories[id].summary
+		v.URL = advisories[id].url
+		if v.URL == "" {
+			v.URL = "https://pkg.go.dev/vuln/" + id
+		}
+		vulns = append(vulns, *v)
+	}
+	return vulns, nil
+}
+
+// npmAuditReport is the part of npm audit --json (npm 7+) that is used
+type npmAuditReport struct {
+	Vulnerabilities map[string]struct {
+		Name         string            `json:"name"`
+		Severity     string            `json:"severity"`
+		IsDirect     bool              `json:"isDirect"`
+		Via          []json.RawMessage `json:"via"`
+		Effects      []string          `json:"effects"`
+		Range        string            `json:"range"`
+		FixAvailable json.RawMessage   `json:"fixAvailable"`
+	} `json:"vulnerabilities"`
+}
+
+// ParseNpmAudit normalizes npm audit --json output
+func ParseNpmAudit(data []byte) ([]Vulnerability, error) {
+	var report npmAuditReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse npm audit output: %w", err)
+	}
+
+	// dependencyPath walks from a vulnerable package up to a direct dependency
+	var dependencyPath func(name string, seen map[string]bool) []string
+	dependencyPath = func(name string, seen map[string]bool) []string {
+		entry, ok := report.Vulnerabilities[name]
+		if !ok || entry.IsDirect || len(entry.Effects) == 0 || seen[name] {
+			return []string{name}
+		}
+		seen[name] = true
+		return append(dependencyPath(entry.Effects[0], seen), name)
+	}
+
+	names := make([]string, 0, len(report.Vulnerabilities))
+	for name := range report.Vulnerabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var vulns []Vulnerability
+	for _, name := range names {
+		entry := report.Vulnerabilities[name]
+		fixed := npmFixVersion(entry.FixAvailable)
+		for _, raw := range entry.Via {
+			// Strings refer to another vulnerable package, which is reported on its own
+			var advisory struct {
+				Source   interface{} `json:"source"`
+				Name     string      `json:"name"`
+				Title    string      `json:"title"`
+				URL      string      `json:"url"`
+				Severity string      `json:"severity"`
+				Range    string      `json:"range"`
+			}
+			if err := json.Unmarshal(raw, &advisory); err != nil {
+				continue
+			}
+
+			id := fmt.Sprint(advisory.Source)
+			if i := strings.LastIndex(advisory.URL, "/"); i >= 0 && strings.Contains(advisory.URL, "GHSA-") {
+				id = advisory.URL[i+1:]
+			}
+			vulns = append(vulns, Vulnerability{
+				ID:           id,
+				Package:      name,
+				Version:      advisory.Range,
+				Severity:     npmSeverity(advisory.Severity),
+				Title:        advisory.Title,
+				FixedVersion: fixed,
+				Path:         dependencyPath(name, make(map[string]bool)),
+				URL:          advisory.URL,
+				Scanner:      ScannerNpmAudit,
+			})
+		}
+	}
+	return vulns, nil
+}
+
+// npmFixVersion describes npm's fixAvailable field
+func npmFixVersion(raw json.RawMessage) string {
+	var fix struct {
+		Name          string `json:"name"`
+		Version       string `json:"version"`
+		IsSemVerMajor bool   `json:"isSemVerMajor"`
+	}
+	if json.Unmarshal(raw, &fix) != nil || fix.Version == "" {
+		return ""
+	}
+	desc := fix.Name + "@" + fix.Version
+	if fix.IsSemVerMajor {
+		desc += " (major upgrade)"
+	}
+	return desc
+}
+
+// npmSeverity maps npm's severities onto review severities
+func npmSeverity(s string) Severity {
+	switch s {
+	case "critical", "high":
+		return SeverityHigh
+	case "moderate":
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// FormatVulnReport renders a report for the terminal
+func FormatVulnReport(report *VulnReport) string {
+	var b strings.Builder
+	if len(report.Scanners) > 0 {
+		fmt.Fprintf(&b, "Scanned with %s: %d vulnerabilities\n", strings.Join(report.Scanners, ", "), len(report.Vulnerabilities))
+	}
+	if report.Suppressed > 0 {
+		fmt.Fprintf(&b, "Left out %d known vulnerabilities accepted in %s\n", report.Suppressed, tools.BaselineFile)
+	}
+	skipped := make([]string, 0, len(report.Skipped))
+	for name := range report.Skipped {
+		skipped = append(skipped, name)
+	}
+	sort.Strings(skipped)
+	for _, name := range skipped {
+		fmt.Fprintf(&b, "Skipped %s: %s\n", name, report.Skipped[name])
+	}
+
+	for _, v := range report.Vulnerabilities {
+		fmt.Fprintf(&b, "\n  [%s] %s %s: %s\n", strings.ToUpper(string(v.Severity)), v.ID, v.Package, v.Title)
+		if v.FixedVersion != "" {
+			fmt.Fprintf(&b, "    fixed in: %s\n", v.FixedVersion)
+		}
+		if len(v.Path) > 0 {
+			fmt.Fprintf(&b, "    path: %s\n", strings.Join(v.Path, " -> "))
+		}
+		if v.URL != "" {
+			fmt.Fprintf(&b, "    %s\n", v.URL)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,74 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+)
+
+const govulncheckOutput = `{"config":{"protocol_version":"v1.0.0","scanner_name":"govulncheck"}}
+{"osv":{"id":"GO-2024-0001","summary":"Denial of service in net/http2","database_specific":{"url":"https://pkg.go.dev/vuln/GO-2024-0001"}}}
+{"osv":{"id":"GO-2024-0002","summary":"Path traversal in archive helper"}}
+{"finding":{"osv":"GO-2024-0001","fixed_version":"v0.23.0","trace":[{"module":"golang.org/x/net","version":"v0.20.0"}]}}
+{"finding":{"osv":"GO-2024-0001","fixed_version":"v0.23.0","trace":[{"module":"golang.org/x/net","version":"v0.20.0","package":"golang.org/x/net/http2","function":"ReadFrame","receiver":"*Framer"},{"module":"example.com/app","package":"example.com/app/server","function":"serve","position":{"filename":"server/serve.go","line":42}}]}}
+{"finding":{"osv":"GO-2024-0002","trace":[{"module":"example.com/archive","version":"v1.0.0","package":"example.com/archive"}]}}
+`
+
+func TestParseGovulncheck(t *testing.T) {
+	vulns, err := ParseGovulncheck([]byte(govulncheckOutput))
+	if err != nil {
+		t.Fatalf("ParseGovulncheck failed: %v", err)
+	}
+	if len(vulns) != 2 {
+		t.Fatalf("got %d vulnerabilities, want 2: %+v", len(vulns), vulns)
+	}
+
+	called := vulns[0]
+	if called.ID != "GO-2024-0001" || called.Severity != SeverityHigh || called.FixedVersion != "v0.23.0" {
+		t.Errorf("unexpected called vulnerability: %+v", called)
+	}
+	wantPath := "example.com/app/server.serve (server/serve.go:42) -> golang.org/x/net/http2.Framer.ReadFrame"
+	if got := strings.Join(called.Path, " -> "); got != wantPath {
+		t.Errorf("path = %q, want %q", got, wantPath)
+	}
+
+	imported := vulns[1]
+	if imported.Severity != SeverityMedium || imported.Title != "Path traversal in archive helper" || imported.URL != "https://pkg.go.dev/vuln/GO-2024-0002" {
+		t.Errorf("unexpected imported vulnerability: %+v", imported)
+	}
+}
+
+const npmAuditOutput = `{
+  "auditReportVersion": 2,
+  "vulnerabilities": {
+    "minimist": {
+      "name": "minimist", "severity": "critical", "isDirect": false,
+      "via": [{"source": 1096, "name": "minimist", "title": "Prototype Pollution in minimist", "url": "https://github.com/advisories/GHSA-xvch-5gv4-984h", "severity": "critical", "range": "<1.2.6"}],
+      "effects": ["mkdirp"], "range": "<1.2.6", "fixAvailable": {"name": "mkdirp", "version": "1.0.4", "isSemVerMajor": true}
+    },
+    "mkdirp": {
+      "name": "mkdirp", "severity": "critical", "isDirect": true,
+      "via": ["minimist"], "effects": [], "range": "0.4.1 - 0.5.1", "fixAvailable": {"name": "mkdirp", "version": "1.0.4", "isSemVerMajor": true}
+    }
+  }
+}`
+
+func TestParseNpmAudit(t *testing.T) {
+	vulns, err := ParseNpmAudit([]byte(npmAuditOutput))
+	if err != nil {
+		t.Fatalf("ParseNpmAudit failed: %v", err)
+	}
+	if len(vulns) != 1 {
+		t.Fatalf("got %d vulnerabilities, want 1 (mkdirp is only affected via minimist): %+v", len(vulns), vulns)
+	}
+
+	v := vulns[0]
+	if v.ID != "GHSA-xvch-5gv4-984h" || v.Package != "minimist" || v.Severity != SeverityHigh {
+		t.Errorf("unexpected vulnerability: %+v", v)
+	}
+	if v.FixedVersion != "mkdirp@1.0.4 (major upgrade)" {
+		t.Errorf("fixed version = %q", v.FixedVersion)
+	}
+	if got := strings.Join(v.Path, " -> "); got != "mkdirp -> minimist" {
+		t.Errorf("path = %q, want mkdirp -> minimist", got)
+	}
+}
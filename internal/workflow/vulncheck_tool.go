@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"context"
+	"os"
+
+	"codezilla/internal/tools"
+)
+
+// VulnCheckTool exposes the vulnerability scanners to the agent
+type VulnCheckTool struct{}
+
+// NewVulnCheckTool creates a new vulnCheck tool
+func NewVulnCheckTool() *VulnCheckTool {
+	return &VulnCheckTool{}
+}
+
+// Name returns the tool name
+func (t *VulnCheckTool) Name() string {
+	return "vulnCheck"
+}
+
+// Description returns the tool description
+func (t *VulnCheckTool) Description() string {
+	return "Scans the project's dependencies for known vulnerabilities with govulncheck (Go) and npm audit (npm), returning each finding's package, severity, fixed version and call or dependency path"
+}
+
+// ParameterSchema returns the JSON schema for this tool's parameters
+func (t *VulnCheckTool) ParameterSchema() tools.JSONSchema {
+	return tools.JSONSchema{
+		Type: "object",
+		Properties: map[string]tools.JSONSchema{
+			"scanner": {
+				Type:        "string",
+				Description: "Scanner to run (default: auto, every scanner that applies to the project)",
+				Enum:        []interface{}{"auto", ScannerGovulncheck, ScannerNpmAudit},
+			},
+			"min_severity": {
+				Type:        "string",
+				Description: "Only report vulnerabilities at or above this severity (default: low)",
+				Enum:        []interface{}{"low", "medium", "high"},
+			},
+		},
+	}
+}
+
+// Execute runs the scan
+func (t *VulnCheckTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if err := tools.ValidateToolParams(t, params); err != nil {
+		return nil, err
+	}
+
+	scanner, _ := params["scanner"].(string)
+	minSeverity := SeverityLow
+	if s, _ := params["min_severity"].(string); s != "" {
+		sev, err := ParseSeverity(s)
+		if err != nil {
+			return nil, &tools.ErrInvalidToolParams{ToolName: t.Name(), Message: err.Error()}
+		}
+		minSeverity = sev
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "failed to get working directory", Err: err}
+	}
+
+	report, err := RunVulnCheck(ctx, cwd, scanner)
+	if err != nil {
+		return nil, &tools.ErrToolExecution{ToolName: t.Name(), Message: "vulnerability scan failed", Err: err}
+	}
+
+	filtered := report.Vulnerabilities[:0]
+	for _, v := range report.Vulnerabilities {
+		if v.Severity.AtLeast(minSeverity) {
+			filtered = append(filtered, v)
+		}
+	}
+	report.Vulnerabilities = filtered
+
+	result := map[string]interface{}{
+		"scanners": report.Scanners,
+		"count":    len(report.Vulnerabilities),
+		"report":   FormatVulnReport(report),
+	}
+	if report.HasVulnerabilitiesAtLeast(SeverityHigh) {
+		result["has_high"] = true
+	}
+	return result, nil
+}
+
+// ResultSchema shows the formatted report under the finding count
+func (t *VulnCheckTool) ResultSchema() tools.ResultSchema {
+	return tools.ResultSchema{Kind: tools.ResultText, Text: "report", Summary: []string{"scanners", "count", "has_high"}}
+}
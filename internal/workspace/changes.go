@@ -0,0 +1,364 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codezilla/internal/tools"
+)
+
+// Ways of finding the files a command changes
+const (
+	TrackOff   = "off"
+	TrackAuto  = "auto"  // git in git repositories, mtime elsewhere
+	TrackGit   = "git"   // git status: tracked and untracked files, but not ignored ones
+	TrackMtime = "mtime" // Sizes and modification times of all files under the root
+)
+
+// maxTrackedFiles bounds the files compared by modification time; larger trees are
+// not tracked, since walking them before and after every command would be slow
+const maxTrackedFiles = 20000
+
+// ErrNotTracked is returned for projects whose changes can't be tracked
+var ErrNotTracked = errors.New("project changes are not tracked")
+
+// fileStamp identifies a version of a file cheaply
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// dirtyFile is a file that differed from HEAD before the command
+type dirtyFile struct {
+	exists bool
+	stamp  fileStamp
+	blob   string // Its content, stored in the repository
+	mode   string // Git file mode, 100644 or 100755
+}
+
+// FileState records the files of a project before a command runs, to find the files
+// the command changes. In a git repository only the files that differ from HEAD are
+// recorded, and their contents are stored so the state can be restored later.
+type FileState struct {
+	root   string
+	git    bool
+	head   string               // HEAD when captured, in git mode
+	dirty  map[string]dirtyFile // Slash-separated path -> file, in git mode
+	stamps map[string]fileStamp // Slash-separated path -> stamp, in mtime mode
+}
+
+// CaptureFileState records the files of the project at root, by the given mode
+func CaptureFileState(ctx context.Context, root, mode string) (*FileState, error) {
+	if mode == TrackOff {
+		return nil, ErrNotTracked
+	}
+	if mode == TrackAuto || mode == TrackGit {
+		top, err := gitOutput(ctx, root, "rev-parse", "--show-toplevel")
+		if err == nil {
+			return captureGit(ctx, strings.TrimSpace(top))
+		}
+		if mode == TrackGit {
+			return nil, fmt.Errorf("%w: not a git repository", ErrNotTracked)
+		}
+	}
+	stamps, err := walkStamps(root)
+	if err != nil {
+		return nil, err
+	}
+	return &FileState{root: root, stamps: stamps}, nil
+}
+
+// Root returns the directory the paths of Changes are relative to: the repository in
+// git mode, otherwise the project root
+func (s *FileState) Root() string {
+	return s.root
+}
+
+// Changes returns the files changed since the state was captured, sorted by path.
+// Paths are relative to the project root, or to the repository in git mode.
+func (s *FileState) Changes(ctx context.Context) ([]tools.FileChange, error) {
+	if !s.git {
+		stamps, err := walkStamps(s.root)
+		if err != nil {
+			return nil, err
+		}
+		var changes []tools.FileChange
+		for path, before := range s.stamps {
+			if after, ok := stamps[path]; !ok {
+				changes = append(changes, tools.FileChange{Path: path, Kind: tools.FileDeleted})
+			} else if after != before {
+				changes = append(changes, tools.FileChange{Path: path, Kind: tools.FileModified, Size: after.size})
+			}
+		}
+		for path, after := range stamps {
+			if _, ok := s.stamps[path]; !ok {
+				changes = append(changes, tools.FileChange{Path: path, Kind: tools.FileCreated, Size: after.size})
+			}
+		}
+		sortChanges(changes)
+		return changes, nil
+	}
+
+	status, err := gitStatus(ctx, s.root)
+	if err != nil {
+		return nil, err
+	}
+	// A commit or checkout by the command changes files without leaving them dirty
+	committed := map[string]bool{}
+	if head := currentHead(ctx, s.root); head != s.head && s.head != "" && head != "" {
+		out, err := gitOutput(ctx, s.root, "diff", "-z", "--name-only", "--no-renames", s.head, head)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range strings.Split(out, "\x00") {
+			if path != "" {
+				committed[path] = true
+			}
+		}
+	}
+
+	candidates := map[string]bool{}
+	for path := range s.dirty {
+		candidates[path] = true
+	}
+	for path := range status {
+		candidates[path] = true
+	}
+	for path := range committed {
+		candidates[path] = true
+	}
+
+	var changes []tools.FileChange
+	for path := range candidates {
+		stamp, existsNow := statFile(filepath.Join(s.root, filepath.FromSlash(path)))
+		before, wasDirty := s.dirty[path]
+		var kind string
+		switch {
+		case !wasDirty && status[path] == "??":
+			kind = tools.FileCreated
+		case !wasDirty:
+			// Unchanged from HEAD before, so it existed unless HEAD moved
+			kind = tools.FileModified
+			if !existsNow {
+				kind = tools.FileDeleted
+			}
+		case !before.exists && existsNow:
+			kind = tools.FileCreated
+		case before.exists && !existsNow:
+			kind = tools.FileDeleted
+		case before.exists && stamp != before.stamp:
+			kind = tools.FileModified
+		}
+		if kind != "" {
+			changes = append(changes, tools.FileChange{Path: path, Kind: kind, Size: stamp.size})
+		}
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+// Snapshot records the files as they were when the state was captured, under ref,
+// for the checkpoint system to restore. Only states captured in git mode can be
+// recorded, since elsewhere the earlier contents are not kept.
+func (s *FileState) Snapshot(ctx context.Context, ref string, changes []tools.FileChange) (Snapshot, error) {
+	if !s.git {
+		return Snapshot{}, fmt.Errorf("%w: earlier file contents are only kept in git repositories", ErrNotTracked)
+	}
+	changed := map[string]bool{}
+	for _, c := range changes {
+		changed[c.Path] = true
+	}
+
+	var tree string
+	err := withTempIndex(func(index string) error {
+		if s.head != "" {
+			if _, err := gitIndexOutput(ctx, s.root, index, "read-tree", s.head); err != nil {
+				return err
+			}
+		}
+		paths := make([]string, 0, len(s.dirty))
+		for path := range s.dirty {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			f := s.dirty[path]
+			if !f.exists {
+				if _, err := gitIndexOutput(ctx, s.root, index, "update-index", "--force-remove", "--", path); err != nil {
+					return err
+				}
+				continue
+			}
+			if f.blob == "" {
+				if changed[path] {
+					return fmt.Errorf("the earlier content of %s was not recorded", path)
+				}
+				// Unchanged by the command, so its current content is the earlier one
+				out, err := gitOutput(ctx, s.root, "hash-object", "-w", "--", path)
+				if err != nil {
+					return err
+				}
+				f.blob = strings.TrimSpace(out)
+			}
+			if _, err := gitIndexOutput(ctx, s.root, index, "update-index", "--add", "--cacheinfo", f.mode+","+f.blob+","+path); err != nil {
+				return err
+			}
+		}
+		out, err := gitIndexOutput(ctx, s.root, index, "write-tree")
+		tree = strings.TrimSpace(out)
+		return err
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	args := []string{"-c", "user.name=codezilla", "-c", "user.email=codezilla@localhost", "commit-tree", tree, "-m", "codezilla checkpoint " + ref}
+	if s.head != "" {
+		args = append(args, "-p", s.head)
+	}
+	commit, err := gitOutput(ctx, s.root, args...)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	commit = strings.TrimSpace(commit)
+	if _, err := gitOutput(ctx, s.root, "update-ref", ref, commit); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Commit: commit}, nil
+}
+
+// maxRecordedFileSize bounds the dirty files whose contents are stored before each
+// command; larger ones can only be restored when the command leaves them alone
+const maxRecordedFileSize = 8 << 20
+
+// captureGit records the files of the repository at top that differ from HEAD
+func captureGit(ctx context.Context, top string) (*FileState, error) {
+	status, err := gitStatus(ctx, top)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileState{root: top, git: true, head: currentHead(ctx, top), dirty: make(map[string]dirtyFile, len(status))}
+
+	var toHash []string
+	for path := range status {
+		f := dirtyFile{mode: "100644"}
+		info, err := os.Lstat(filepath.Join(top, filepath.FromSlash(path)))
+		if err == nil && info.Mode().IsRegular() {
+			f.exists = true
+			f.stamp = fileStamp{size: info.Size(), modTime: info.ModTime()}
+			if info.Mode()&0111 != 0 {
+				f.mode = "100755"
+			}
+			if info.Size() <= maxRecordedFileSize {
+				toHash = append(toHash, path)
+			}
+		} else if err == nil {
+			// Symlinks and submodules are left to git
+			continue
+		}
+		s.dirty[path] = f
+	}
+
+	if len(toHash) > 0 {
+		sort.Strings(toHash)
+		cmd := exec.CommandContext(ctx, "git", "hash-object", "-w", "--stdin-paths")
+		cmd.Dir = top
+		cmd.Stdin = strings.NewReader(strings.Join(toHash, "\n") + "\n")
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git hash-object failed: %w", err)
+		}
+		blobs := strings.Fields(string(out))
+		if len(blobs) != len(toHash) {
+			return nil, fmt.Errorf("git hash-object returned %d objects for %d files", len(blobs), len(toHash))
+		}
+		for i, path := range toHash {
+			f := s.dirty[path]
+			f.blob = blobs[i]
+			s.dirty[path] = f
+		}
+	}
+	return s, nil
+}
+
+// gitStatus returns the status code of each file that differs from HEAD, such as " M"
+// or "??", by slash-separated path
+func gitStatus(ctx context.Context, top string) (map[string]string, error) {
+	out, err := gitOutput(ctx, top, "status", "--porcelain=v1", "-z", "--untracked-files=all", "--no-renames")
+	if err != nil {
+		return nil, err
+	}
+	status := make(map[string]string)
+	for _, entry := range strings.Split(out, "\x00") {
+		if len(entry) > 3 {
+			status[entry[3:]] = entry[:2]
+		}
+	}
+	return status, nil
+}
+
+// currentHead returns the commit checked out in the repository at top, or "" before
+// the first commit
+func currentHead(ctx context.Context, top string) string {
+	head, err := gitOutput(ctx, top, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(head)
+}
+
+// walkStamps records the size and modification time of each file under root, except
+// in .git directories
+func walkStamps(root string) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(stamps) >= maxTrackedFiles {
+			return fmt.Errorf("%w: more than %d files", ErrNotTracked, maxTrackedFiles)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		stamps[filepath.ToSlash(rel)] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stamps, nil
+}
+
+// statFile returns the stamp of the regular file at path, and whether there is one
+func statFile(path string) (fileStamp, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// sortChanges orders changes by path
+func sortChanges(changes []tools.FileChange) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+}
@@ -0,0 +1,147 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codezilla/internal/tools"
+)
+
+func TestFileStateChanges(t *testing.T) {
+	for _, mode := range []string{TrackMtime, TrackGit} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			root := t.TempDir()
+			write := func(name, content string) {
+				path := filepath.Join(root, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			read := func(name string) string {
+				data, err := os.ReadFile(filepath.Join(root, name))
+				if err != nil {
+					return "<missing>"
+				}
+				return string(data)
+			}
+			git := func(args ...string) {
+				cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+				cmd.Dir = root
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+
+			write("main.go", "package main\n")
+			write("old.go", "package main\n\nfunc old() {}\n")
+			write("same.go", "package main\n")
+			if mode == TrackGit {
+				if _, err := exec.LookPath("git"); err != nil {
+					t.Skip("git not installed")
+				}
+				git("init", "-q")
+				git("add", ".")
+				git("commit", "-q", "-m", "init")
+			}
+			// Dirty before the command
+			write("main.go", "package main\n\nfunc main() {}\n")
+			write("notes.txt", "untracked\n")
+
+			state, err := CaptureFileState(ctx, root, mode)
+			if err != nil {
+				t.Fatalf("CaptureFileState: %v", err)
+			}
+
+			// The command
+			write("main.go", "package main\n\nfunc main() { broken() }\n")
+			write("pkg/new.go", "package pkg\n")
+			os.Remove(filepath.Join(root, "old.go"))
+			os.Remove(filepath.Join(root, "notes.txt"))
+
+			changes, err := state.Changes(ctx)
+			if err != nil {
+				t.Fatalf("Changes: %v", err)
+			}
+			want := []tools.FileChange{
+				{Path: "main.go", Kind: tools.FileModified, Size: 39},
+				{Path: "notes.txt", Kind: tools.FileDeleted},
+				{Path: "old.go", Kind: tools.FileDeleted},
+				{Path: "pkg/new.go", Kind: tools.FileCreated, Size: 12},
+			}
+			if !reflect.DeepEqual(changes, want) {
+				t.Fatalf("changes = %+v, want %+v", changes, want)
+			}
+
+			snap, err := state.Snapshot(ctx, "refs/codezilla/checkpoints/test", changes)
+			if mode == TrackMtime {
+				if !errors.Is(err, ErrNotTracked) {
+					t.Errorf("Snapshot outside git = %v, want ErrNotTracked", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Snapshot: %v", err)
+			}
+			if err := snap.Restore(ctx, root); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			got := map[string]string{}
+			for _, name := range []string{"main.go", "old.go", "same.go", "notes.txt", "pkg/new.go"} {
+				got[name] = read(name)
+			}
+			wantFiles := map[string]string{
+				"main.go":    "package main\n\nfunc main() {}\n",
+				"old.go":     "package main\n\nfunc old() {}\n",
+				"same.go":    "package main\n",
+				"notes.txt":  "untracked\n",
+				"pkg/new.go": "<missing>",
+			}
+			if !reflect.DeepEqual(got, wantFiles) {
+				t.Errorf("restored files = %q, want %q", got, wantFiles)
+			}
+		})
+	}
+}
+
+func TestFileStateGitCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("a\n"), 0644)
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	state, err := CaptureFileState(ctx, root, TrackAuto)
+	if err != nil {
+		t.Fatalf("CaptureFileState: %v", err)
+	}
+	// A command that changes a file and commits it leaves nothing dirty
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("changed\n"), 0644)
+	git("commit", "-q", "-am", "change")
+
+	changes, err := state.Changes(ctx)
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	want := []tools.FileChange{{Path: "a.txt", Kind: tools.FileModified, Size: 8}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+}
@@ -0,0 +1,229 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// copySkippedDirs are left out of project copies, and left alone when one is
+// restored, in addition to .git: Codezilla's own state and dependencies or build
+// output that can be fetched or built again
+var copySkippedDirs = map[string]bool{
+	".codezilla":   true,
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// Snapshot records the files of a project so they can be restored later. In a git
+// repository it is a commit kept under a private ref; elsewhere it is a copy.
+type Snapshot struct {
+	Commit string `json:"commit,omitempty"` // Commit holding the files, in git repositories
+	Dir    string `json:"dir,omitempty"`    // Copy of the files, outside git repositories
+}
+
+// TakeSnapshot records the files of the project at root, including uncommitted and
+// untracked files that are not ignored. In a git repository the snapshot covers the
+// whole repository and is kept under ref; otherwise root is copied to copyDir, where
+// only the files changed since the copy in base, if any, take up space, leaving out
+// copySkippedDirs.
+// Neither the branch, the index nor the working tree is touched.
+func TakeSnapshot(ctx context.Context, root, ref, copyDir, base string) (Snapshot, error) {
+	top, err := gitOutput(ctx, root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		if err := linkTree(root, copyDir, base); err != nil {
+			return Snapshot{}, fmt.Errorf("failed to copy project files: %w", err)
+		}
+		return Snapshot{Dir: copyDir}, nil
+	}
+	top = strings.TrimSpace(top)
+
+	tree, err := snapshotTree(ctx, top)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	args := []string{"-c", "user.name=codezilla", "-c", "user.email=codezilla@localhost", "commit-tree", tree, "-m", "codezilla checkpoint " + ref}
+	if head, err := gitOutput(ctx, top, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		args = append(args, "-p", strings.TrimSpace(head))
+	}
+	commit, err := gitOutput(ctx, top, args...)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	commit = strings.TrimSpace(commit)
+	// The ref keeps the snapshot from being garbage collected
+	if _, err := gitOutput(ctx, top, "update-ref", ref, commit); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Commit: commit}, nil
+}
+
+// Restore makes the project at root match the snapshot: changed and deleted files are
+// brought back and files created since are removed. Ignored files are left alone in
+// git repositories, and copySkippedDirs elsewhere. The checked out branch and the index are not changed.
+func (s Snapshot) Restore(ctx context.Context, root string) error {
+	if s.Dir != "" {
+		if err := syncTree(s.Dir, root, copySkippedDirs); err != nil {
+			return fmt.Errorf("failed to restore project files: %w", err)
+		}
+		return nil
+	}
+	if s.Commit == "" {
+		return fmt.Errorf("snapshot records no files")
+	}
+
+	top, err := gitOutput(ctx, root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("snapshot was taken in a git repository: %w", err)
+	}
+	top = strings.TrimSpace(top)
+
+	current, err := snapshotTree(ctx, top)
+	if err != nil {
+		return err
+	}
+	added, err := gitOutput(ctx, top, "diff-tree", "-r", "-z", "--name-only", "--no-renames", "--diff-filter=A", s.Commit+"^{tree}", current)
+	if err != nil {
+		return err
+	}
+	for _, rel := range strings.Split(added, "\x00") {
+		if rel == "" {
+			continue
+		}
+		path := filepath.Join(top, filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		removeEmptyParents(filepath.Dir(path), top)
+	}
+
+	return withTempIndex(func(index string) error {
+		if _, err := gitIndexOutput(ctx, top, index, "read-tree", s.Commit); err != nil {
+			return err
+		}
+		_, err := gitIndexOutput(ctx, top, index, "checkout-index", "--all", "--force")
+		return err
+	})
+}
+
+// linkTree copies the files under src to dst, replacing what dst held. Files with
+// the same content in base are hard linked to it instead of copied; neither copy is
+// ever written to again, so they can share the data. Symlinks are recreated; .git
+// and copySkippedDirs are skipped.
+func linkTree(src, dst, base string) error {
+	if filepath.Clean(base) == filepath.Clean(dst) {
+		base = ""
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" && path != src {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() && path != src && copySkippedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			if base != "" {
+				previous := filepath.Join(base, rel)
+				if same, _ := sameContent(path, previous); same && os.Link(previous, target) == nil {
+					return nil
+				}
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// snapshotTree writes the files of the working tree at top, as git add -A would stage
+// them, to a tree object and returns its ID
+func snapshotTree(ctx context.Context, top string) (string, error) {
+	var tree string
+	err := withTempIndex(func(index string) error {
+		// Start from HEAD so tracked files that are now ignored are kept
+		if _, err := gitOutput(ctx, top, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+			if _, err := gitIndexOutput(ctx, top, index, "read-tree", "HEAD"); err != nil {
+				return err
+			}
+		}
+		if _, err := gitIndexOutput(ctx, top, index, "add", "--all", "."); err != nil {
+			return err
+		}
+		out, err := gitIndexOutput(ctx, top, index, "write-tree")
+		tree = strings.TrimSpace(out)
+		return err
+	})
+	return tree, err
+}
+
+// withTempIndex calls fn with the path of a fresh git index file, so snapshots never
+// disturb what the user has staged
+func withTempIndex(fn func(index string) error) error {
+	dir, err := os.MkdirTemp("", "codezilla-index-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	return fn(filepath.Join(dir, "index"))
+}
+
+// gitIndexOutput is gitOutput using index as the git index file, when it is set
+func gitIndexOutput(ctx context.Context, dir, index string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if index != "" {
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+index)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// removeEmptyParents removes dir and its parents up to, but not including, stop while
+// they are empty
+func removeEmptyParents(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,190 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	for _, useGit := range []bool{false, true} {
+		name := "copy"
+		if useGit {
+			name = "git"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			root := t.TempDir()
+			write := func(name, content string) {
+				path := filepath.Join(root, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			read := func(name string) string {
+				data, err := os.ReadFile(filepath.Join(root, name))
+				if err != nil {
+					return "<missing>"
+				}
+				return string(data)
+			}
+			git := func(args ...string) {
+				cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+				cmd.Dir = root
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+
+			write("main.go", "package main\n")
+			write("old.go", "package main\n\nfunc old() {}\n")
+			if useGit {
+				if _, err := exec.LookPath("git"); err != nil {
+					t.Skip("git not installed")
+				}
+				write(".gitignore", "build/\n")
+				git("init", "-q")
+				git("add", ".")
+				git("commit", "-q", "-m", "init")
+			}
+			// Uncommitted and untracked changes are part of the snapshot
+			write("main.go", "package main\n\nfunc main() {}\n")
+			write("notes.txt", "untracked\n")
+
+			snap, err := TakeSnapshot(ctx, root, "refs/codezilla/checkpoints/test", filepath.Join(t.TempDir(), "copy"), "")
+			if err != nil {
+				t.Fatalf("TakeSnapshot: %v", err)
+			}
+			if useGit != (snap.Commit != "") {
+				t.Fatalf("snapshot = %+v, want a commit only in git repositories", snap)
+			}
+
+			write("main.go", "package main\n\nfunc main() { broken() }\n")
+			write("pkg/new.go", "package pkg\n")
+			os.Remove(filepath.Join(root, "old.go"))
+			if useGit {
+				write("build/out", "artifact\n")
+			}
+
+			if err := snap.Restore(ctx, root); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			got := map[string]string{}
+			for _, name := range []string{"main.go", "old.go", "notes.txt", "pkg/new.go"} {
+				got[name] = read(name)
+			}
+			want := map[string]string{
+				"main.go":    "package main\n\nfunc main() {}\n",
+				"old.go":     "package main\n\nfunc old() {}\n",
+				"notes.txt":  "untracked\n",
+				"pkg/new.go": "<missing>",
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("restored files = %q, want %q", got, want)
+			}
+			if _, err := os.Stat(filepath.Join(root, "pkg")); !os.IsNotExist(err) {
+				t.Errorf("directory emptied by the restore should be removed")
+			}
+			if useGit {
+				if read("build/out") != "artifact\n" {
+					t.Errorf("ignored files should be left alone")
+				}
+				// The index still matches the last commit
+				cmd := exec.Command("git", "diff", "--cached", "--quiet")
+				cmd.Dir = root
+				if err := cmd.Run(); err != nil {
+					t.Errorf("snapshot and restore changed the index: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotCopySharesUnchangedFiles(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	copies := t.TempDir()
+	for name, content := range map[string]string{"main.go": "package main\n", "lib.go": "package main\n\nfunc lib() {}\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := TakeSnapshot(ctx, root, "refs/codezilla/checkpoints/first", filepath.Join(copies, "first"), "")
+	if err != nil || first.Dir == "" {
+		t.Skipf("project copy not used: %+v, %v", first, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := TakeSnapshot(ctx, root, "refs/codezilla/checkpoints/second", filepath.Join(copies, "second"), first.Dir)
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+
+	shared := func(name string) bool {
+		a, errA := os.Stat(filepath.Join(first.Dir, name))
+		b, errB := os.Stat(filepath.Join(second.Dir, name))
+		return errA == nil && errB == nil && os.SameFile(a, b)
+	}
+	if !shared("lib.go") {
+		t.Error("an unchanged file should be linked to the previous copy")
+	}
+	if shared("main.go") {
+		t.Error("a changed file should be copied")
+	}
+	if data, _ := os.ReadFile(filepath.Join(first.Dir, "main.go")); string(data) != "package main\n" {
+		t.Errorf("the previous copy changed: main.go = %q", data)
+	}
+}
+
+func TestSnapshotCopyLeavesSkippedDirsAlone(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n")
+	write(".codezilla/baseline.json", "{}\n")
+	write("vendor/lib/lib.go", "package lib\n")
+
+	snap, err := TakeSnapshot(ctx, root, "refs/codezilla/checkpoints/test", filepath.Join(t.TempDir(), "copy"), "")
+	if err != nil || snap.Dir == "" {
+		t.Skipf("project copy not used: %+v, %v", snap, err)
+	}
+	for _, dir := range []string{".codezilla", "vendor"} {
+		if _, err := os.Stat(filepath.Join(snap.Dir, dir)); !os.IsNotExist(err) {
+			t.Errorf("%s should be left out of the copy", dir)
+		}
+	}
+
+	// Directories created after the checkpoint
+	write("gen/api.go", "package gen\n")
+	write("node_modules/left-pad/index.js", "module.exports = 1\n")
+	write("vendor/lib/lib.go", "package lib // updated\n")
+
+	if err := snap.Restore(ctx, root); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "gen")); !os.IsNotExist(err) {
+		t.Error("a project directory created after the checkpoint should be removed")
+	}
+	for name, want := range map[string]string{
+		"main.go":                        "package main\n",
+		".codezilla/baseline.json":       "{}\n",
+		"vendor/lib/lib.go":              "package lib // updated\n",
+		"node_modules/left-pad/index.js": "module.exports = 1\n",
+	} {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+}
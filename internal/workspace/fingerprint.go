@@ -0,0 +1,91 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fingerprint identifies the state of a project as a conversation saw it: the commit
+// checked out and the files the conversation refers to
+type Fingerprint struct {
+	Head   string               `json:"head,omitempty"`   // Commit checked out, in git repositories
+	Branch string               `json:"branch,omitempty"` // Branch checked out; "" when detached
+	Files  map[string]FileStamp `json:"files,omitempty"`  // Absolute path -> stamp
+}
+
+// FileStamp identifies a version of a file by its size and modification time
+type FileStamp struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Drift is how a project changed since a fingerprint was taken
+type Drift struct {
+	OldHead, NewHead     string
+	OldBranch, NewBranch string
+	NewCommits           int // Commits added on top of the old HEAD; -1 when it is not an ancestor
+	Modified             []string
+	Deleted              []string
+}
+
+// Empty reports whether nothing changed
+func (d Drift) Empty() bool {
+	return d.OldHead == d.NewHead && d.OldBranch == d.NewBranch && len(d.Modified) == 0 && len(d.Deleted) == 0
+}
+
+// TakeFingerprint records the state of the project at root, with the stamps of the
+// given files. Files that don't exist are left out.
+func TakeFingerprint(ctx context.Context, root string, files []string) Fingerprint {
+	var f Fingerprint
+	if head, err := gitOutput(ctx, root, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		f.Head = strings.TrimSpace(head)
+		if branch, err := gitOutput(ctx, root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+			f.Branch = strings.TrimSpace(branch)
+		}
+	}
+	for _, path := range files {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if f.Files == nil {
+			f.Files = make(map[string]FileStamp)
+		}
+		f.Files[filepath.Clean(path)] = FileStamp{Size: info.Size(), ModTime: info.ModTime()}
+	}
+	return f
+}
+
+// Compare returns how the project at root changed since the fingerprint was taken
+func (f Fingerprint) Compare(ctx context.Context, root string) Drift {
+	current := TakeFingerprint(ctx, root, nil)
+	d := Drift{OldHead: f.Head, NewHead: current.Head, OldBranch: f.Branch, NewBranch: current.Branch}
+	if f.Head != "" && current.Head != "" && f.Head != current.Head {
+		d.NewCommits = -1
+		if _, err := gitOutput(ctx, root, "merge-base", "--is-ancestor", f.Head, current.Head); err == nil {
+			if out, err := gitOutput(ctx, root, "rev-list", "--count", f.Head+".."+current.Head); err == nil {
+				d.NewCommits, _ = strconv.Atoi(strings.TrimSpace(out))
+			}
+		}
+	}
+	for path, stamp := range f.Files {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			d.Deleted = append(d.Deleted, path)
+		case info.Size() != stamp.Size || !info.ModTime().Equal(stamp.ModTime):
+			d.Modified = append(d.Modified, path)
+		}
+	}
+	sort.Strings(d.Modified)
+	sort.Strings(d.Deleted)
+	return d
+}
@@ -0,0 +1,62 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFingerprintCompare(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n")
+	write("b.go", "package a\n")
+	write("c.go", "package a\n")
+	git("init", "-q", "-b", "main")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	f := TakeFingerprint(ctx, root, []string{"a.go", filepath.Join(root, "b.go"), "c.go", "missing.go"})
+	if len(f.Files) != 3 || f.Head == "" || f.Branch != "main" {
+		t.Fatalf("fingerprint = %+v", f)
+	}
+	if d := f.Compare(ctx, root); !d.Empty() {
+		t.Fatalf("unchanged project drifted: %+v", d)
+	}
+
+	write("a.go", "package a\n\nfunc A() {}\n")
+	os.Chtimes(filepath.Join(root, "a.go"), time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+	os.Remove(filepath.Join(root, "c.go"))
+	git("commit", "-q", "-am", "change")
+
+	d := f.Compare(ctx, root)
+	want := Drift{
+		OldHead: f.Head, NewHead: d.NewHead,
+		OldBranch: "main", NewBranch: "main",
+		NewCommits: 1,
+		Modified:   []string{filepath.Join(root, "a.go")},
+		Deleted:    []string{filepath.Join(root, "c.go")},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("drift = %+v, want %+v", d, want)
+	}
+}
@@ -0,0 +1,293 @@
+// Package workspace keeps agent tasks apart from the project: in disposable copies whose
+// changes are reviewed as one diff before they reach the real files, or on task branches.
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codezilla/internal/tools"
+)
+
+// Shadow modes
+const (
+	ModeAuto     = "auto"     // A git worktree in git repositories, otherwise a copy
+	ModeWorktree = "worktree" // A git worktree, brought up to date with uncommitted changes
+	ModeCopy     = "copy"     // A plain copy of the project
+)
+
+// Change is a file that differs between the shadow and the real project
+type Change struct {
+	Path string // Relative to the project root, with forward slashes
+	Kind string // "added", "modified" or "deleted"
+}
+
+// Shadow is a temporary copy of a project
+type Shadow struct {
+	Root string // The real project
+	Dir  string // The copy tasks run in
+
+	base     string // Temporary directory holding the copy
+	worktree string // Worktree path to unregister on Close, if one was created
+}
+
+// New creates a shadow of the project at root. The .git directory is never copied;
+// in worktree mode the shadow shares the repository's history instead.
+func New(ctx context.Context, root, mode string) (*Shadow, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	base, err := os.MkdirTemp("", "codezilla-shadow-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shadow directory: %w", err)
+	}
+	s := &Shadow{Root: root, base: base}
+
+	prefix, gitErr := gitOutput(ctx, root, "rev-parse", "--show-prefix")
+	switch {
+	case mode == ModeWorktree && gitErr != nil:
+		s.Close()
+		return nil, fmt.Errorf("worktree shadow needs a git repository: %w", gitErr)
+	case mode == ModeCopy || (mode != ModeWorktree && gitErr != nil):
+		s.Dir = filepath.Join(base, filepath.Base(root))
+	default:
+		s.worktree = filepath.Join(base, "worktree")
+		_, err := gitOutput(ctx, root, "worktree", "add", "--detach", s.worktree, "HEAD")
+		switch {
+		case err == nil:
+			s.Dir = filepath.Join(s.worktree, filepath.FromSlash(strings.TrimSuffix(strings.TrimSpace(prefix), "/")))
+		case mode == ModeWorktree:
+			s.worktree = ""
+			s.Close()
+			return nil, err
+		default:
+			// No commit to check out yet; a copy works just as well
+			s.worktree = ""
+			s.Dir = filepath.Join(base, filepath.Base(root))
+		}
+	}
+
+	// Bring the shadow up to date with the working tree, including untracked files
+	if err := syncTree(root, s.Dir, nil); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to copy project to shadow: %w", err)
+	}
+	return s, nil
+}
+
+// Changes lists the files that differ between the shadow and the real project, sorted by path
+func (s *Shadow) Changes() ([]Change, error) {
+	original, err := listFiles(s.Root, nil)
+	if err != nil {
+		return nil, err
+	}
+	shadow, err := listFiles(s.Dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for rel := range shadow {
+		if _, ok := original[rel]; !ok {
+			changes = append(changes, Change{Path: rel, Kind: "added"})
+			continue
+		}
+		same, err := sameContent(filepath.Join(s.Root, rel), filepath.Join(s.Dir, rel))
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			changes = append(changes, Change{Path: rel, Kind: "modified"})
+		}
+	}
+	for rel := range original {
+		if _, ok := shadow[rel]; !ok {
+			changes = append(changes, Change{Path: rel, Kind: "deleted"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Transaction stages the shadow's changes against the real project, to be reviewed
+// with Diff and applied with Commit
+func (s *Shadow) Transaction(changes []Change) (*tools.EditTransaction, error) {
+	tx := tools.NewEditTransaction()
+	for _, c := range changes {
+		target := filepath.Join(s.Root, filepath.FromSlash(c.Path))
+		if c.Kind == "deleted" {
+			if err := tx.StageDelete(target); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.Dir, filepath.FromSlash(c.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read shadow copy of %s: %w", c.Path, err)
+		}
+		if err := tx.Stage(target, string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// Close removes the shadow and, in worktree mode, unregisters the worktree
+func (s *Shadow) Close() error {
+	if s.worktree != "" {
+		gitOutput(context.Background(), s.Root, "worktree", "remove", "--force", s.worktree)
+	}
+	return os.RemoveAll(s.base)
+}
+
+// listFiles returns the regular files under dir by slash-separated relative path,
+// skipping .git and the directories named in skip
+func listFiles(dir string, skip map[string]bool) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() && path != dir && skip[d.Name()] {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			rel, _ := filepath.Rel(dir, path)
+			files[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// syncTree makes dst match src: files missing or different in dst are copied and
+// files only in dst are removed, along with directories they leave empty. Symlinks
+// are recreated; .git and the directories named in skip are left alone.
+func syncTree(src, dst string, skip map[string]bool) error {
+	present, err := listFilesIfExists(dst, skip)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.Name() == ".git" && path != src {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() && path != src && skip[d.Name()] {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			delete(present, filepath.ToSlash(rel))
+			if same, _ := sameContent(path, target); same {
+				return nil
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for rel := range present {
+		path := filepath.Join(dst, filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removeEmptyParents(filepath.Dir(path), dst)
+	}
+	return nil
+}
+
+// listFilesIfExists is listFiles for a directory that may not exist yet
+func listFilesIfExists(dir string, skip map[string]bool) (map[string]bool, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return map[string]bool{}, nil
+	}
+	return listFiles(dir, skip)
+}
+
+// copyFile copies src to dst, replacing dst
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sameContent reports whether two files have the same bytes. A missing file is never the same.
+func sameContent(a, b string) (bool, error) {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return false, nil
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
+
+// gitOutput runs git in dir and returns its stdout
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	return gitIndexOutput(ctx, dir, "", args...)
+}
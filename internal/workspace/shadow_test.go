@@ -0,0 +1,93 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShadow(t *testing.T) {
+	for _, mode := range []string{ModeCopy, ModeWorktree} {
+		t.Run(mode, func(t *testing.T) {
+			root := t.TempDir()
+			write := func(dir, name, content string) {
+				path := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			write(root, "main.go", "package main\n")
+			write(root, "old.go", "package main\n\nfunc old() {}\n")
+			write(root, "docs/readme.md", "# Docs\n")
+
+			if mode == ModeWorktree {
+				if _, err := exec.LookPath("git"); err != nil {
+					t.Skip("git not installed")
+				}
+				git := func(args ...string) {
+					cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+					cmd.Dir = root
+					if out, err := cmd.CombinedOutput(); err != nil {
+						t.Fatalf("git %v: %v\n%s", args, err, out)
+					}
+				}
+				git("init", "-q")
+				git("add", ".")
+				git("commit", "-q", "-m", "init")
+				// Uncommitted and untracked changes are part of the shadow too
+				write(root, "main.go", "package main\n\nfunc main() {}\n")
+				write(root, "notes.txt", "untracked\n")
+			}
+
+			shadow, err := New(context.Background(), root, mode)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer shadow.Close()
+
+			if changes, err := shadow.Changes(); err != nil || len(changes) != 0 {
+				t.Fatalf("fresh shadow differs from the project: %v, %v", changes, err)
+			}
+
+			write(shadow.Dir, "main.go", "package main\n\nfunc main() { run() }\n")
+			write(shadow.Dir, "run.go", "package main\n\nfunc run() {}\n")
+			os.Remove(filepath.Join(shadow.Dir, "old.go"))
+
+			changes, err := shadow.Changes()
+			if err != nil {
+				t.Fatalf("Changes: %v", err)
+			}
+			want := []Change{{"main.go", "modified"}, {"old.go", "deleted"}, {"run.go", "added"}}
+			if !reflect.DeepEqual(changes, want) {
+				t.Errorf("changes = %v, want %v", changes, want)
+			}
+			if data, _ := os.ReadFile(filepath.Join(root, "main.go")); strings.Contains(string(data), "run()") {
+				t.Fatal("editing the shadow changed the real project")
+			}
+
+			tx, err := shadow.Transaction(changes)
+			if err != nil {
+				t.Fatalf("Transaction: %v", err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			if changes, _ := shadow.Changes(); len(changes) != 0 {
+				t.Errorf("project still differs from the shadow after applying: %v", changes)
+			}
+
+			dir := shadow.Dir
+			if err := shadow.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("shadow directory left behind")
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Task branch modes
+const (
+	TaskBranchesOff      = "off"      // Tasks run on the current branch
+	TaskBranchesBranch   = "branch"   // Each task gets a branch in the working tree
+	TaskBranchesWorktree = "worktree" // Each task gets a branch checked out in its own worktree
+)
+
+// maxBranchSlug caps the part of a branch name taken from the task title
+const maxBranchSlug = 40
+
+// Task is a unit of work on its own git branch
+type Task struct {
+	Title  string
+	Branch string
+	Base   string // Branch (or commit, when detached) the task started from
+	Dir    string // Directory the task runs in: the project, or its copy in the worktree
+	Root   string // The project directory the task was started from
+
+	baseCommit string
+	worktree   string // Worktree path, if the task has one
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// BranchName derives a branch name from a task title, such as "task/fix-login-redirect"
+func BranchName(title string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > maxBranchSlug {
+		slug = strings.TrimRight(slug[:maxBranchSlug], "-")
+	}
+	if slug == "" {
+		slug = "untitled"
+	}
+	return "task/" + slug
+}
+
+// StartTask creates a branch for a task in the repository containing root and checks
+// it out: in root itself, which must have no uncommitted changes, or with useWorktree
+// in a new worktree beside the repository. A numeric suffix keeps branch names unique.
+func StartTask(ctx context.Context, root, title string, useWorktree bool) (*Task, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	top, err := gitOutput(ctx, root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("task branches need a git repository: %w", err)
+	}
+	top = strings.TrimSpace(top)
+	prefix, _ := gitOutput(ctx, root, "rev-parse", "--show-prefix")
+	prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "/")
+
+	baseCommit, err := gitOutput(ctx, root, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("task branches need at least one commit: %w", err)
+	}
+	t := &Task{Title: title, Root: root, baseCommit: strings.TrimSpace(baseCommit)}
+	if base, err := gitOutput(ctx, root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		t.Base = strings.TrimSpace(base)
+	} else {
+		t.Base = t.baseCommit
+	}
+
+	t.Branch = BranchName(title)
+	for n := 2; ; n++ {
+		if _, err := gitOutput(ctx, root, "rev-parse", "--verify", "--quiet", "refs/heads/"+t.Branch); err != nil {
+			break
+		}
+		t.Branch = fmt.Sprintf("%s-%d", BranchName(title), n)
+	}
+
+	if !useWorktree {
+		status, err := gitOutput(ctx, root, "status", "--porcelain")
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(status) != "" {
+			return nil, fmt.Errorf("the working tree has uncommitted changes; commit or stash them first, or use worktree mode")
+		}
+		if _, err := gitOutput(ctx, root, "switch", "-c", t.Branch); err != nil {
+			return nil, err
+		}
+		t.Dir = root
+		return t, nil
+	}
+
+	t.worktree = filepath.Join(filepath.Dir(top), filepath.Base(top)+"-"+strings.TrimPrefix(t.Branch, "task/"))
+	if _, err := os.Stat(t.worktree); err == nil {
+		return nil, fmt.Errorf("cannot create worktree: %s already exists", t.worktree)
+	}
+	if _, err := gitOutput(ctx, root, "worktree", "add", "-b", t.Branch, t.worktree, t.baseCommit); err != nil {
+		return nil, err
+	}
+	t.Dir = filepath.Join(t.worktree, filepath.FromSlash(prefix))
+	return t, nil
+}
+
+// Commit commits all changes in the task's checkout, returning false when there was nothing to commit
+func (t *Task) Commit(ctx context.Context, message string) (bool, error) {
+	if _, err := gitOutput(ctx, t.Dir, "add", "-A", "--", "."); err != nil {
+		return false, err
+	}
+	if _, err := gitOutput(ctx, t.Dir, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if _, err := gitOutput(ctx, t.Dir, "commit", "--quiet", "-m", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Commits returns the subjects of the task's commits, oldest first
+func (t *Task) Commits(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, t.Dir, "log", "--reverse", "--format=%s", t.baseCommit+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var subjects []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// DiffStat summarizes the files the task changed
+func (t *Task) DiffStat(ctx context.Context) (string, error) {
+	out, err := gitOutput(ctx, t.Dir, "diff", "--stat", t.baseCommit+"..HEAD")
+	return strings.TrimRight(out, "\n"), err
+}
+
+// Squash replaces the task's commits with a single commit
+func (t *Task) Squash(ctx context.Context, message string) error {
+	if _, err := gitOutput(ctx, t.Dir, "reset", "--soft", t.baseCommit); err != nil {
+		return err
+	}
+	_, err := gitOutput(ctx, t.Dir, "commit", "--quiet", "-m", message)
+	return err
+}
+
+// PullRequest drafts a pull request title and description from the task's commits
+func (t *Task) PullRequest(ctx context.Context) (title, body string, err error) {
+	subjects, err := t.Commits(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	stat, err := t.DiffStat(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("## Changes\n\n")
+	for _, s := range subjects {
+		b.WriteString("- " + s + "\n")
+	}
+	if stat != "" {
+		b.WriteString("\n## Files\n\n```\n" + stat + "\n```\n")
+	}
+	return t.Title, b.String(), nil
+}
+
+// Close leaves the task: in branch mode the base branch is checked out again, in
+// worktree mode the worktree is removed. The task branch and its commits are kept.
+func (t *Task) Close(ctx context.Context) error {
+	if t.worktree == "" {
+		_, err := gitOutput(ctx, t.Root, "switch", t.Base)
+		if err != nil && t.Base == t.baseCommit {
+			_, err = gitOutput(ctx, t.Root, "switch", "--detach", t.Base)
+		}
+		return err
+	}
+	_, err := gitOutput(ctx, t.Root, "worktree", "remove", t.worktree)
+	return err
+}
@@ -0,0 +1,105 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBranchName(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"Fix login redirect", "task/fix-login-redirect"},
+		{"  Add /dryrun (preview) mode!  ", "task/add-dryrun-preview-mode"},
+		{"Make the scanner stream results instead of buffering everything", "task/make-the-scanner-stream-results-instead"},
+		{"???", "task/untitled"},
+	}
+	for _, tt := range tests {
+		if got := BranchName(tt.title); got != tt.want {
+			t.Errorf("BranchName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTask(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+
+	for _, useWorktree := range []bool{false, true} {
+		name := "branch"
+		if useWorktree {
+			name = "worktree"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			git := func(dir string, args ...string) string {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+				return strings.TrimSpace(string(out))
+			}
+			git(root, "init", "-q", "-b", "main")
+			git(root, "config", "user.name", "t")
+			git(root, "config", "user.email", "t@example.com")
+			os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+			git(root, "add", ".")
+			git(root, "commit", "-q", "-m", "init")
+
+			task, err := StartTask(ctx, root, "Add a run command", useWorktree)
+			if err != nil {
+				t.Fatalf("StartTask: %v", err)
+			}
+			if task.Branch != "task/add-a-run-command" || task.Base != "main" {
+				t.Errorf("branch %q from %q, want task/add-a-run-command from main", task.Branch, task.Base)
+			}
+			if got := git(task.Dir, "branch", "--show-current"); got != task.Branch {
+				t.Errorf("task dir is on %q, want %q", got, task.Branch)
+			}
+
+			for i, content := range []string{"package main\n\nfunc run() {}\n", "package main\n\nfunc run() { println() }\n"} {
+				os.WriteFile(filepath.Join(task.Dir, "run.go"), []byte(content), 0644)
+				if ok, err := task.Commit(ctx, fmt.Sprintf("step %d", i+1)); err != nil || !ok {
+					t.Fatalf("Commit: %v, %v", ok, err)
+				}
+			}
+			if ok, err := task.Commit(ctx, "nothing"); err != nil || ok {
+				t.Errorf("Commit with no changes = %v, %v; want false", ok, err)
+			}
+			if commits, _ := task.Commits(ctx); !reflect.DeepEqual(commits, []string{"step 1", "step 2"}) {
+				t.Errorf("commits = %v", commits)
+			}
+
+			if err := task.Squash(ctx, "Add a run command"); err != nil {
+				t.Fatalf("Squash: %v", err)
+			}
+			title, body, err := task.PullRequest(ctx)
+			if err != nil {
+				t.Fatalf("PullRequest: %v", err)
+			}
+			if title != "Add a run command" || !strings.Contains(body, "- Add a run command\n") || !strings.Contains(body, "run.go") {
+				t.Errorf("unexpected pull request draft %q:\n%s", title, body)
+			}
+
+			if err := task.Close(ctx); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if got := git(root, "branch", "--show-current"); got != "main" {
+				t.Errorf("project is on %q after Close, want main", got)
+			}
+			if got := git(root, "log", "--format=%s", "task/add-a-run-command"); got != "Add a run command\ninit" {
+				t.Errorf("task branch history = %q", got)
+			}
+		})
+	}
+}
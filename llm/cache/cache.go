@@ -0,0 +1,124 @@
+// Package cache stores model responses on disk, so idempotent internal calls such as
+// file analysis, summaries and classification are not repeated for unchanged input.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"codezilla/llm/ollama"
+)
+
+// Cache keeps one file per response under a directory. Entries older than the TTL are
+// treated as missing and removed when read.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// entry is a cached response as stored on disk
+type entry struct {
+	Model    string    `json:"model"`
+	Created  time.Time `json:"created"`
+	Response string    `json:"response"`
+}
+
+// New creates a cache in dir. A ttl of zero keeps entries forever.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Key identifies a request by its model, options and normalized prompt, so requests
+// that differ only in line endings or trailing whitespace share a response
+func Key(req ollama.GenerateRequest) string {
+	// Options are marshaled with sorted keys, so equal maps hash the same
+	options, _ := json.Marshal(req.Options)
+
+	h := sha256.New()
+	for _, part := range []string{req.Model, req.Format, req.Template, string(options), normalize(req.System), normalize(req.Prompt)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalize unifies line endings and drops trailing whitespace from every line and
+// surrounding blank lines
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// Get returns the cached response for key
+func (c *Cache) Get(key string) (string, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.misses.Add(1)
+		return "", false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || (c.ttl > 0 && c.now().Sub(e.Created) > c.ttl) {
+		os.Remove(path)
+		c.misses.Add(1)
+		return "", false
+	}
+	c.hits.Add(1)
+	return e.Response, true
+}
+
+// Put stores the response for key
+func (c *Cache) Put(key, model, response string) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(entry{Model: model, Created: c.now(), Response: response})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	// Responses can quote private code, so entries are only readable by the user
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+key+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the number of hits and misses since the cache was created
+func (c *Cache) Stats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// path spreads entries over subdirectories named after the first byte of the key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"codezilla/llm/ollama"
+)
+
+func TestKey(t *testing.T) {
+	base := ollama.GenerateRequest{Model: "qwen3:14b", Prompt: "User: summarize\r\nthis  \n\n", Options: map[string]interface{}{"temperature": 0, "seed": 1}}
+	same := ollama.GenerateRequest{Model: "qwen3:14b", Prompt: "User: summarize\nthis", Options: map[string]interface{}{"seed": 1, "temperature": 0}}
+	if Key(base) != Key(same) {
+		t.Error("requests differing only in whitespace and option order should share a key")
+	}
+
+	for name, req := range map[string]ollama.GenerateRequest{
+		"model":   {Model: "llama3", Prompt: same.Prompt, Options: same.Options},
+		"prompt":  {Model: same.Model, Prompt: "User: summarize that", Options: same.Options},
+		"options": {Model: same.Model, Prompt: same.Prompt, Options: map[string]interface{}{"temperature": 0.7}},
+		"indent":  {Model: same.Model, Prompt: "User: summarize\n  this", Options: same.Options},
+	} {
+		if Key(req) == Key(same) {
+			t.Errorf("a different %s should change the key", name)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := New(t.TempDir(), time.Hour)
+	c.now = func() time.Time { return now }
+
+	key := Key(ollama.GenerateRequest{Model: "m", Prompt: "p"})
+	if _, ok := c.Get(key); ok {
+		t.Fatal("Get() on an empty cache should miss")
+	}
+	if err := c.Put(key, "m", "answer"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	info, err := os.Stat(c.path(key))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("cache entry permissions = %o, want 600", perm)
+	}
+	if got, ok := c.Get(key); !ok || got != "answer" {
+		t.Errorf("Get() = %q, %v, want the stored answer", got, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := c.Get(key); ok {
+		t.Error("Get() should miss once the entry is older than the TTL")
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses, want 1 and 2", hits, misses)
+	}
+}
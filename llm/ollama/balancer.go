@@ -0,0 +1,230 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balancing strategies
+const (
+	BalanceRoundRobin = "round_robin" // Take turns among the healthy servers
+	BalanceModel      = "model"       // Take turns among the healthy servers that have the model
+)
+
+const (
+	// healthCheckInterval is how often a server's health and models are checked again
+	healthCheckInterval = 30 * time.Second
+	// healthCheckTimeout bounds one health check
+	healthCheckTimeout = 3 * time.Second
+)
+
+// endpoint is one server behind a Balancer
+type endpoint struct {
+	url    string
+	client Client
+
+	mu      sync.Mutex
+	checked time.Time
+	healthy bool
+	models  map[string]bool
+}
+
+// Balancer spreads requests over several Ollama servers. Servers are health-checked
+// through the model list, at most every 30 seconds and again after a request to them
+// fails with a transport or server error; such a request is retried on the next server.
+type Balancer struct {
+	endpoints []*endpoint
+	strategy  string
+	next      atomic.Uint64
+	now       func() time.Time
+}
+
+var _ Client = (*Balancer)(nil)
+
+// NewBalancer creates a client for the servers at urls, each created with options
+// apart from its base URL. strategy is BalanceRoundRobin or BalanceModel.
+func NewBalancer(urls []string, strategy string, options ...func(*ClientOptions)) *Balancer {
+	b := &Balancer{strategy: strategy, now: time.Now}
+	for _, url := range urls {
+		opts := append(append([]func(*ClientOptions){}, options...), WithBaseURL(url))
+		b.endpoints = append(b.endpoints, &endpoint{url: url, client: NewClient(opts...), healthy: true})
+	}
+	return b
+}
+
+// Generate sends a generate request to the next suitable server
+func (b *Balancer) Generate(ctx context.Context, request GenerateRequest) (*GenerateResponse, error) {
+	var response *GenerateResponse
+	err := b.try(ctx, request.Model, func(c Client) (err error) {
+		response, err = c.Generate(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// Chat sends a chat request to the next suitable server
+func (b *Balancer) Chat(ctx context.Context, request ChatRequest) (*ChatResponse, error) {
+	var response *ChatResponse
+	err := b.try(ctx, request.Model, func(c Client) (err error) {
+		response, err = c.Chat(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// StreamGenerate starts a streamed generate request on the next suitable server
+func (b *Balancer) StreamGenerate(ctx context.Context, request GenerateRequest) (<-chan StreamResponse, error) {
+	var stream <-chan StreamResponse
+	err := b.try(ctx, request.Model, func(c Client) (err error) {
+		stream, err = c.StreamGenerate(ctx, request)
+		return err
+	})
+	return stream, err
+}
+
+// ListModels returns the models of all reachable servers, each listed once
+func (b *Balancer) ListModels(ctx context.Context) (*ListModelsResponse, error) {
+	merged := &ListModelsResponse{}
+	seen := make(map[string]bool)
+	var lastErr error
+	for _, e := range b.endpoints {
+		models, err := e.client.ListModels(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", e.url, err)
+			continue
+		}
+		for _, m := range models.Models {
+			if !seen[m.Name] {
+				seen[m.Name] = true
+				merged.Models = append(merged.Models, m)
+			}
+		}
+	}
+	if len(seen) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return merged, nil
+}
+
+// Status describes a server behind the balancer
+type Status struct {
+	URL     string
+	Healthy bool
+	Models  int
+}
+
+// Status checks the servers that are due and returns their state
+func (b *Balancer) Status(ctx context.Context) []Status {
+	b.check(ctx)
+	statuses := make([]Status, len(b.endpoints))
+	for i, e := range b.endpoints {
+		e.mu.Lock()
+		statuses[i] = Status{URL: e.url, Healthy: e.healthy, Models: len(e.models)}
+		e.mu.Unlock()
+	}
+	return statuses
+}
+
+// try calls fn with the servers suited to model in turn until one succeeds. A
+// request the server rejects, such as for an unknown model, would fail on any
+// server and leaves it healthy; only transport errors and server errors count.
+func (b *Balancer) try(ctx context.Context, model string, fn func(Client) error) error {
+	var lastErr error
+	for _, e := range b.candidates(ctx, model) {
+		err := fn(e.client)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		var status *StatusError
+		if errors.As(err, &status) && status.StatusCode < http.StatusInternalServerError {
+			return fmt.Errorf("%s: %w", e.url, err)
+		}
+		e.mu.Lock()
+		e.healthy = false
+		e.checked = b.now()
+		e.mu.Unlock()
+		lastErr = fmt.Errorf("%s: %w", e.url, err)
+	}
+	return lastErr
+}
+
+// candidates returns the servers to try for model, starting with the one whose turn
+// it is. Unhealthy servers are only tried when no server is healthy.
+func (b *Balancer) candidates(ctx context.Context, model string) []*endpoint {
+	b.check(ctx)
+
+	var healthy, withModel []*endpoint
+	for _, e := range b.endpoints {
+		e.mu.Lock()
+		if e.healthy {
+			healthy = append(healthy, e)
+			if e.models[normalizeModel(model)] {
+				withModel = append(withModel, e)
+			}
+		}
+		e.mu.Unlock()
+	}
+	if len(healthy) == 0 {
+		healthy = b.endpoints
+	}
+	if b.strategy == BalanceModel && len(withModel) > 0 {
+		healthy = withModel
+	}
+
+	start := int(b.next.Add(1)-1) % len(healthy)
+	return append(append([]*endpoint{}, healthy[start:]...), healthy[:start]...)
+}
+
+// check refreshes the health and models of the servers not checked recently, in parallel
+func (b *Balancer) check(ctx context.Context) {
+	now := b.now()
+	var wg sync.WaitGroup
+	for _, e := range b.endpoints {
+		e.mu.Lock()
+		due := now.Sub(e.checked) >= healthCheckInterval
+		if due {
+			// Claim the check so concurrent requests don't repeat it
+			e.checked = now
+		}
+		e.mu.Unlock()
+		if !due {
+			continue
+		}
+
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			list, err := e.client.ListModels(checkCtx)
+
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.healthy = err == nil
+			if err == nil {
+				e.models = make(map[string]bool, len(list.Models))
+				for _, m := range list.Models {
+					e.models[normalizeModel(m.Name)] = true
+				}
+			}
+		}(e)
+	}
+	wg.Wait()
+}
+
+// normalizeModel adds the implicit ":latest" tag to a model name
+func normalizeModel(name string) string {
+	if name != "" && !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
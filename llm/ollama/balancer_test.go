@@ -0,0 +1,129 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer answers the model list with models and counts the generate requests
+type fakeServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	generated int
+	down      bool
+	rejecting bool
+}
+
+func newFakeServer(t *testing.T, models ...string) *fakeServer {
+	f := &fakeServer{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/tags") {
+			var names []string
+			for _, m := range models {
+				names = append(names, fmt.Sprintf(`{"name": %q}`, m))
+			}
+			fmt.Fprintf(w, `{"models": [%s]}`, strings.Join(names, ","))
+			return
+		}
+		f.generated++
+		if f.rejecting {
+			http.Error(w, `{"error": "model not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"response": "ok", "done": true}`)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeServer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.generated
+}
+
+func TestBalancer(t *testing.T) {
+	ctx := context.Background()
+	generate := func(b *Balancer, model string, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := b.Generate(ctx, GenerateRequest{Model: model, Prompt: "hi"}); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+		}
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		a, b := newFakeServer(t, "qwen3:14b"), newFakeServer(t, "llama3:latest")
+		balancer := NewBalancer([]string{a.URL + "/api", b.URL + "/api"}, BalanceRoundRobin)
+		generate(balancer, "qwen3:14b", 4)
+		if a.count() != 2 || b.count() != 2 {
+			t.Errorf("requests = %d, %d; want them spread evenly", a.count(), b.count())
+		}
+
+		models, err := balancer.ListModels(ctx)
+		if err != nil || len(models.Models) != 2 {
+			t.Errorf("ListModels() = %+v, %v; want the models of both servers", models, err)
+		}
+	})
+
+	t.Run("by model", func(t *testing.T) {
+		a, b, c := newFakeServer(t, "qwen3:14b"), newFakeServer(t, "llama3:latest"), newFakeServer(t, "llama3:latest")
+		balancer := NewBalancer([]string{a.URL + "/api", b.URL + "/api", c.URL + "/api"}, BalanceModel)
+		generate(balancer, "llama3", 4)
+		if a.count() != 0 || b.count() != 2 || c.count() != 2 {
+			t.Errorf("requests = %d, %d, %d; want them on the servers with the model", a.count(), b.count(), c.count())
+		}
+	})
+
+	t.Run("failover", func(t *testing.T) {
+		a, b := newFakeServer(t, "qwen3:14b"), newFakeServer(t, "qwen3:14b")
+		balancer := NewBalancer([]string{a.URL + "/api", b.URL + "/api"}, BalanceRoundRobin)
+		a.mu.Lock()
+		a.down = true
+		a.mu.Unlock()
+
+		generate(balancer, "qwen3:14b", 3)
+		if a.count() != 0 || b.count() != 3 {
+			t.Errorf("requests = %d, %d; want all on the healthy server", a.count(), b.count())
+		}
+		statuses := balancer.Status(ctx)
+		if statuses[0].Healthy || !statuses[1].Healthy {
+			t.Errorf("Status() = %+v, want the first server unhealthy", statuses)
+		}
+	})
+
+	t.Run("rejected request", func(t *testing.T) {
+		a, b := newFakeServer(t, "qwen3:14b"), newFakeServer(t, "qwen3:14b")
+		balancer := NewBalancer([]string{a.URL + "/api", b.URL + "/api"}, BalanceRoundRobin)
+		a.mu.Lock()
+		a.rejecting = true
+		a.mu.Unlock()
+
+		_, err := balancer.Generate(ctx, GenerateRequest{Model: "qwen3:14b", Prompt: "hi"})
+		var status *StatusError
+		if !errors.As(err, &status) || status.StatusCode != http.StatusNotFound {
+			t.Fatalf("Generate() error = %v, want the 404 from the first server", err)
+		}
+		if b.count() != 0 {
+			t.Error("a rejected request should not be retried on the next server")
+		}
+		for _, s := range balancer.Status(ctx) {
+			if !s.Healthy {
+				t.Errorf("Status() = %+v, want every server healthy", s)
+			}
+		}
+	})
+}
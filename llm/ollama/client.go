@@ -3,6 +3,8 @@ package ollama
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,6 +36,78 @@ type ClientOptions struct {
 	Username string
 	Password string
 	Headers  map[string]string
+	// TLSConfig verifies HTTPS servers, e.g. against a private CA; nil uses the defaults
+	TLSConfig *tls.Config
+	// Transport tunes the connections kept to the server
+	Transport TransportOptions
+	// ModelOptions are request options by model name, sent with every request to it
+	ModelOptions map[string]map[string]interface{}
+}
+
+// TransportOptions tune the connections the client keeps open. Go's default of two idle
+// connections per host makes concurrent requests, such as parallel file analysis,
+// open a new connection (and TLS handshake) for most calls.
+type TransportOptions struct {
+	MaxIdleConns      int           // Idle connections kept to the server
+	IdleConnTimeout   time.Duration // How long an idle connection is kept
+	DisableKeepAlives bool          // Open a new connection for every request
+	DisableHTTP2      bool          // Stay on HTTP/1.1 when an HTTPS server offers HTTP/2
+}
+
+// DefaultTransportOptions returns the transport settings used unless configured
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:    32,
+		IdleConnTimeout: 90 * time.Second,
+	}
+}
+
+// newTransport builds an HTTP transport from the options
+func (o TransportOptions) newTransport(tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = o.MaxIdleConns
+	transport.MaxIdleConnsPerHost = o.MaxIdleConns
+	transport.IdleConnTimeout = o.IdleConnTimeout
+	transport.DisableKeepAlives = o.DisableKeepAlives
+	if o.DisableHTTP2 {
+		// A non-nil, empty map turns off HTTP/2 negotiation
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport
+}
+
+// TLSOptions configures how the client verifies an HTTPS server, such as a remote
+// Ollama behind a reverse proxy
+type TLSOptions struct {
+	CACertFile         string // PEM file of CA certificates to trust besides the system ones
+	InsecureSkipVerify bool   // Accept any server certificate; for testing only
+}
+
+// Config builds the TLS configuration, or returns nil when the options change nothing
+func (o TLSOptions) Config() (*tls.Config, error) {
+	if o.CACertFile == "" && !o.InsecureSkipVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CACertFile != "" {
+		pem, err := os.ReadFile(o.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", o.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
 }
 
 // clientImpl implements the Client interface
@@ -45,6 +119,8 @@ type clientImpl struct {
 	username   string
 	password   string
 	headers    map[string]string
+	// modelOptions are added to the options of requests, by model name
+	modelOptions map[string]map[string]interface{}
 }
 
 // NewClient creates a new Ollama client with the given options
@@ -52,11 +128,18 @@ func NewClient(options ...func(*ClientOptions)) Client {
 	opts := ClientOptions{
 		BaseURL:    DefaultBaseURL,
 		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Transport:  DefaultTransportOptions(),
 	}
 
 	for _, option := range options {
 		option(&opts)
 	}
+	// A client passed in with its own transport is used as is
+	if opts.HTTPClient.Transport == nil {
+		httpClient := *opts.HTTPClient
+		httpClient.Transport = opts.Transport.newTransport(opts.TLSConfig)
+		opts.HTTPClient = &httpClient
+	}
 
 	return &clientImpl{
 		baseURL:    opts.BaseURL,
@@ -66,6 +149,8 @@ func NewClient(options ...func(*ClientOptions)) Client {
 		username:   opts.Username,
 		password:   opts.Password,
 		headers:    opts.Headers,
+
+		modelOptions: opts.ModelOptions,
 	}
 }
 
@@ -109,6 +194,20 @@ func WithHeaders(headers map[string]string) func(*ClientOptions) {
 	}
 }
 
+// WithTLSConfig sets how HTTPS servers are verified
+func WithTLSConfig(cfg *tls.Config) func(*ClientOptions) {
+	return func(o *ClientOptions) {
+		o.TLSConfig = cfg
+	}
+}
+
+// WithTransportOptions tunes the connections kept to the server
+func WithTransportOptions(transport TransportOptions) func(*ClientOptions) {
+	return func(o *ClientOptions) {
+		o.Transport = transport
+	}
+}
+
 // GenerateRequest represents a request to the Ollama generate API
 type GenerateRequest struct {
 	Model     string                 `json:"model"`
@@ -198,11 +297,26 @@ type ModelDetails struct {
 	QuantizationLevel string   `json:"quantization_level"`
 }
 
+// StatusError is returned when the server answers with a status other than 200 OK
+type StatusError struct {
+	URL        string // Request URL, when the message names it
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.URL != "" {
+		return fmt.Sprintf("unsuccessful response from %s: %d %s", e.URL, e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("unsuccessful response: %d %s", e.StatusCode, e.Body)
+}
+
 // Generate sends a generate request to the Ollama API
 func (c *clientImpl) Generate(ctx context.Context, request GenerateRequest) (*GenerateResponse, error) {
 	// Create a copy of the request with stream explicitly set to false
 	requestCopy := request
 	requestCopy.Stream = false // This will always be included in the JSON now
+	requestCopy.Options = c.withModelOptions(request.Model, request.Options)
 
 	reqBody, err := json.Marshal(requestCopy)
 	if err != nil {
@@ -229,7 +343,7 @@ func (c *clientImpl) Generate(ctx context.Context, request GenerateRequest) (*Ge
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unsuccessful response: %d %s", resp.StatusCode, string(bodyBytes))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Read the entire response body for debugging
@@ -253,6 +367,7 @@ func (c *clientImpl) Chat(ctx context.Context, request ChatRequest) (*ChatRespon
 	// Create a copy of the request with stream set to false
 	requestCopy := request
 	requestCopy.Stream = false
+	requestCopy.Options = c.withModelOptions(request.Model, request.Options)
 
 	reqBody, err := json.Marshal(requestCopy)
 	if err != nil {
@@ -282,9 +397,7 @@ func (c *clientImpl) Chat(ctx context.Context, request ChatRequest) (*ChatRespon
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		errMsg := string(bodyBytes)
-		fmt.Fprintf(os.Stderr, "Error response body: %s\n", errMsg)
-		return nil, fmt.Errorf("unsuccessful response from %s: %d %s", chatURL, resp.StatusCode, errMsg)
+		return nil, &StatusError{URL: chatURL, StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Read the entire response body for debugging
@@ -313,6 +426,7 @@ func (c *clientImpl) StreamGenerate(ctx context.Context, request GenerateRequest
 	// Create a copy of the request with stream explicitly set to true
 	requestCopy := request
 	requestCopy.Stream = true // This will always be included in the JSON now
+	requestCopy.Options = c.withModelOptions(request.Model, request.Options)
 
 	reqBody, err := json.Marshal(requestCopy)
 	if err != nil {
@@ -334,7 +448,7 @@ func (c *clientImpl) StreamGenerate(ctx context.Context, request GenerateRequest
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("unsuccessful response: %d %s", resp.StatusCode, string(bodyBytes))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Buffer the channel to prevent goroutine leak if consumer stops reading
@@ -386,7 +500,7 @@ func (c *clientImpl) ListModels(ctx context.Context) (*ListModelsResponse, error
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unsuccessful response: %d %s", resp.StatusCode, string(bodyBytes))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	var response ListModelsResponse
@@ -0,0 +1,187 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientTLSAndAuth(t *testing.T) {
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`{"models": []}`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tls     TLSOptions
+		wantErr bool
+	}{
+		{"untrusted certificate", TLSOptions{}, true},
+		{"custom CA", TLSOptions{CACertFile: caFile}, false},
+		{"skip verify", TLSOptions{InsecureSkipVerify: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.tls.Config()
+			if err != nil {
+				t.Fatalf("Config() error = %v", err)
+			}
+			client := NewClient(WithBaseURL(server.URL+"/api"), WithAPIKey("token"), WithTLSConfig(cfg))
+			authorization = ""
+			_, err = client.ListModels(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListModels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && authorization != "Bearer token" {
+				t.Errorf("Authorization = %q, want the bearer token", authorization)
+			}
+		})
+	}
+
+	if _, err := (TLSOptions{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}).Config(); err == nil {
+		t.Error("Config() should fail for a missing CA file")
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0600)
+	if _, err := (TLSOptions{CACertFile: notPEM}).Config(); err == nil {
+		t.Error("Config() should fail when the file has no certificates")
+	}
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	opened := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`{"models": []}`))
+	}))
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			opened++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// Each round runs requests in parallel, as file analysis does
+	const parallel = 8
+	round := func(client Client) int {
+		mu.Lock()
+		before := opened
+		mu.Unlock()
+		var wg sync.WaitGroup
+		for i := 0; i < parallel; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.ListModels(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return opened - before
+	}
+
+	client := NewClient(WithBaseURL(server.URL + "/api"))
+	round(client)
+	if n := round(client); n > parallel/4 {
+		t.Errorf("second round opened %d connections, want the pooled ones reused", n)
+	}
+
+	opts := DefaultTransportOptions()
+	opts.DisableKeepAlives = true
+	client = NewClient(WithBaseURL(server.URL+"/api"), WithTransportOptions(opts))
+	if n := round(client); n != parallel {
+		t.Errorf("without keep-alives %d connections were opened, want %d", n, parallel)
+	}
+}
+
+func TestClientModelOptions(t *testing.T) {
+	var options map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Options map[string]interface{} `json:"options"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		options = body.Options
+		w.Write([]byte(`{"response": "ok", "message": {"role": "assistant"}, "done": true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL+"/api"), WithModelOptions(map[string]map[string]interface{}{
+		"llama3":    {"num_ctx": 8192, "temperature": 0.1},
+		"qwen3:14b": {"stop": []string{"<|im_end|>"}},
+	}))
+	ctx := context.Background()
+
+	request := GenerateRequest{Model: "llama3:latest", Options: map[string]interface{}{"temperature": 0.7, "seed": 1}}
+	if _, err := client.Generate(ctx, request); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if options["num_ctx"] != float64(8192) || options["temperature"] != 0.1 || options["seed"] != float64(1) {
+		t.Errorf("options = %v, want the profile's merged over the request's", options)
+	}
+	if request.Options["temperature"] != 0.7 {
+		t.Error("the caller's options should not be changed")
+	}
+
+	if _, err := client.Chat(ctx, ChatRequest{Model: "qwen3:14b"}); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if stop, ok := options["stop"].([]interface{}); !ok || len(stop) != 1 {
+		t.Errorf("options = %v, want the stop sequence", options)
+	}
+
+	if _, err := client.Generate(ctx, GenerateRequest{Model: "mistral"}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if options != nil {
+		t.Errorf("options = %v, want none for a model without a profile", options)
+	}
+}
+
+func TestCheckOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{"num_ctx", float64(8192), false},
+		{"num_ctx", 8192, false},
+		{"num_ctx", 81.5, true},
+		{"temperature", 1, false},
+		{"temperature", "hot", true},
+		{"use_mmap", true, false},
+		{"stop", []interface{}{"a", "b"}, false},
+		{"stop", []interface{}{"a", 1}, true},
+		{"stop", "a", true},
+		{"num_ctxx", 8192, true},
+	}
+	for _, tt := range tests {
+		if err := CheckOption(tt.name, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("CheckOption(%q, %v) error = %v, wantErr %v", tt.name, tt.value, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ollama
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Kinds of values taken by Ollama request options
+const (
+	optionInt     = "integer"
+	optionNumber  = "number"
+	optionBool    = "boolean"
+	optionStrings = "list of strings"
+)
+
+// KnownOptions are the request options Ollama understands, with the kind of value
+// each takes
+var KnownOptions = map[string]string{
+	"num_ctx":           optionInt,
+	"num_predict":       optionInt,
+	"num_keep":          optionInt,
+	"num_batch":         optionInt,
+	"num_gpu":           optionInt,
+	"main_gpu":          optionInt,
+	"num_thread":        optionInt,
+	"seed":              optionInt,
+	"top_k":             optionInt,
+	"repeat_last_n":     optionInt,
+	"mirostat":          optionInt,
+	"temperature":       optionNumber,
+	"top_p":             optionNumber,
+	"min_p":             optionNumber,
+	"typical_p":         optionNumber,
+	"tfs_z":             optionNumber,
+	"repeat_penalty":    optionNumber,
+	"presence_penalty":  optionNumber,
+	"frequency_penalty": optionNumber,
+	"mirostat_tau":      optionNumber,
+	"mirostat_eta":      optionNumber,
+	"penalize_newline":  optionBool,
+	"numa":              optionBool,
+	"low_vram":          optionBool,
+	"use_mmap":          optionBool,
+	"use_mlock":         optionBool,
+	"stop":              optionStrings,
+}
+
+// KnownOptionNames returns the names of the known options, sorted
+func KnownOptionNames() []string {
+	names := make([]string, 0, len(KnownOptions))
+	for name := range KnownOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckOption reports whether value suits the known option name. Values may come from
+// JSON, YAML or TOML, so whole floats count as integers and any list as a list.
+func CheckOption(name string, value interface{}) error {
+	kind, ok := KnownOptions[name]
+	if !ok {
+		return fmt.Errorf("unknown option")
+	}
+	valid := false
+	switch kind {
+	case optionInt:
+		switch v := value.(type) {
+		case int, int64:
+			valid = true
+		case float64:
+			valid = v == math.Trunc(v)
+		}
+	case optionNumber:
+		switch value.(type) {
+		case int, int64, float64:
+			valid = true
+		}
+	case optionBool:
+		_, valid = value.(bool)
+	case optionStrings:
+		switch v := value.(type) {
+		case []string:
+			valid = true
+		case []interface{}:
+			valid = true
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					valid = false
+				}
+			}
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%v is not a %s", value, kind)
+	}
+	return nil
+}
+
+// WithModelOptions sets request options by model name, such as num_ctx or stop. They
+// are sent with every request to that model, replacing options the request sets.
+func WithModelOptions(options map[string]map[string]interface{}) func(*ClientOptions) {
+	return func(o *ClientOptions) {
+		o.ModelOptions = options
+	}
+}
+
+// withModelOptions returns the request options with those configured for model added
+func (c *clientImpl) withModelOptions(model string, options map[string]interface{}) map[string]interface{} {
+	preset, ok := c.modelOptions[model]
+	if !ok {
+		// llama3 and llama3:latest name the same model
+		for name, o := range c.modelOptions {
+			if normalizeModel(name) == normalizeModel(model) {
+				preset, ok = o, true
+				break
+			}
+		}
+	}
+	if !ok || len(preset) == 0 {
+		return options
+	}
+	merged := make(map[string]interface{}, len(options)+len(preset))
+	for k, v := range options {
+		merged[k] = v
+	}
+	for k, v := range preset {
+		merged[k] = v
+	}
+	return merged
+}
@@ -11,6 +11,7 @@ import (
 const (
 	ColorCodeReset  = "\033[0m"
 	ColorCodeBold   = "\033[1m"
+	ColorCodeDim    = "\033[2m"
 	ColorCodeRed    = "\033[31m"
 	ColorCodeGreen  = "\033[32m"
 	ColorCodeYellow = "\033[33m"
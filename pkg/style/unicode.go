@@ -0,0 +1,140 @@
+package style
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unicode output modes
+const (
+	UnicodeFull  = "full"  // Emoji, box drawing and block characters as written
+	UnicodeASCII = "ascii" // ASCII equivalents, for terminals and logs that mangle them
+)
+
+var (
+	// UseUnicode determines if emoji and box-drawing characters are printed as is
+	UseUnicode = true
+)
+
+// SetUnicode selects the Unicode output mode; anything but UnicodeASCII prints as is
+func SetUnicode(mode string) {
+	UseUnicode = mode != UnicodeASCII
+}
+
+// asciiReplacer swaps symbols for ASCII equivalents. Box drawing and block characters
+// take one cell each so tables and bars stay aligned; status emoji become short tags.
+var asciiReplacer = strings.NewReplacer(
+	// Status symbols
+	"✅", "[ok]", "✓", "[ok]", "✔", "[ok]",
+	"❌", "[X]", "✗", "[X]", "✘", "[X]",
+	"⚠️", "[!]", "⚠", "[!]",
+	"ℹ️", "[i]", "ℹ", "[i]", "💡", "[i]",
+	"⏳", "[..]", "🔄", "[~]", "⏭️", "[>>]", "⏭", "[>>]", "🚫", "[-]",
+	"🔴", "(!)", "🟡", "(=)", "🟢", "(.)",
+	// Box drawing
+	"─", "-", "━", "-", "═", "=",
+	"│", "|", "┃", "|", "║", "|",
+	"┌", "+", "┐", "+", "└", "+", "┘", "+",
+	"├", "+", "┤", "+", "┬", "+", "┴", "+", "┼", "+", "╪", "+", "╬", "+",
+	// Blocks and bars
+	"█", "#", "▉", "#", "▊", "#", "▋", "#", "▌", "#", "▍", "=", "▎", "-", "▏", "-",
+	"░", ".", "▒", ":", "▓", "#",
+	// Spinner
+	"⠋", "|", "⠙", "/", "⠹", "-", "⠸", "\\", "⠼", "|", "⠴", "/", "⠦", "-", "⠧", "\\", "⠇", "|", "⠏", "/",
+	// Punctuation
+	"•", "*", "…", "...", "—", "--", "–", "-", "→", "->", "←", "<-", "↵", "\\", "“", `"`, "”", `"`, "‘", "'", "’", "'",
+)
+
+// Text returns s as it should be printed: unchanged in full Unicode mode, converted
+// by ASCII otherwise
+func Text(s string) string {
+	if UseUnicode {
+		return s
+	}
+	return ASCII(s)
+}
+
+// ASCII swaps emoji, box-drawing and block characters for ASCII equivalents.
+// Decorative emoji without one are dropped with the space after them. Letters of any
+// script are kept.
+func ASCII(s string) string {
+	s = asciiReplacer.Replace(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	skipSpace := false
+	for _, r := range s {
+		if skipSpace {
+			skipSpace = false
+			if r == ' ' {
+				continue
+			}
+		}
+		if isPictograph(r) {
+			skipSpace = r != '\uFE0F' && r != '\u200D'
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isPictograph reports whether r is an emoji, a symbol drawn as one, or a joiner or
+// presentation selector that belongs to one
+func isPictograph(r rune) bool {
+	switch {
+	case r == '\uFE0F', r == '\u200D':
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF: // Emoji, pictographs, transport, flags
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF, r >= 0x2300 && r <= 0x23FF: // Arrows, technical
+		return true
+	}
+	return unicode.Is(unicode.So, r) && r > 0x2000
+}
+
+// Writer converts what is written through it with Text before passing it on
+type Writer struct {
+	w       io.Writer
+	partial []byte // Incomplete UTF-8 sequence held back from the last write
+}
+
+// NewWriter creates a writer that prints to w in the current Unicode mode
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write converts p and writes it. A character split across writes is held back until
+// the rest of it arrives.
+func (w *Writer) Write(p []byte) (int, error) {
+	if UseUnicode && len(w.partial) == 0 {
+		return w.w.Write(p)
+	}
+	data := append(w.partial, p...)
+	w.partial = nil
+	if end := completeUTF8(data); end < len(data) {
+		w.partial = append([]byte(nil), data[end:]...)
+		data = data[:end]
+	}
+	if _, err := io.WriteString(w.w, Text(string(data))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// completeUTF8 returns the length of data without a trailing incomplete character
+func completeUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			return i
+		}
+		break
+	}
+	return len(data)
+}
@@ -0,0 +1,61 @@
+package style
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestASCII(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "hello, world", "hello, world"},
+		{"status symbols", "✅ done ❌ failed ⚠️ careful", "[ok] done [X] failed [!] careful"},
+		{"decorative emoji", "🎯 Scanning 📁 src", "Scanning src"},
+		{"joined emoji", "👩‍💻 coder", "coder"},
+		{"box drawing keeps width", "├── a\n│   └── b", "+-- a\n|   +-- b"},
+		{"bars keep width", "██▌░░", "###.."},
+		{"punctuation", "a — b… • c", "a -- b... * c"},
+		{"letters of any script", "Größe ändern — 日本", "Größe ändern -- 日本"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ASCII(tt.in); got != tt.want {
+				t.Errorf("ASCII(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextFollowsMode(t *testing.T) {
+	defer SetUnicode(UnicodeFull)
+
+	SetUnicode(UnicodeFull)
+	if got := Text("✓ ok"); got != "✓ ok" {
+		t.Errorf("full mode changed text: %q", got)
+	}
+	SetUnicode(UnicodeASCII)
+	if got := Text("✓ ok"); got != "[ok] ok" {
+		t.Errorf("ascii mode: got %q", got)
+	}
+}
+
+func TestWriterJoinsSplitCharacters(t *testing.T) {
+	defer SetUnicode(UnicodeFull)
+	SetUnicode(UnicodeASCII)
+
+	var out strings.Builder
+	w := NewWriter(&out)
+	data := []byte("a ✓ b")
+	// Split inside the three bytes of ✓
+	for _, part := range [][]byte{data[:3], data[3:]} {
+		if n, err := w.Write(part); err != nil || n != len(part) {
+			t.Fatalf("Write = %d, %v", n, err)
+		}
+	}
+	if got := out.String(); got != "a [ok] b" {
+		t.Errorf("got %q, want %q", got, "a [ok] b")
+	}
+}